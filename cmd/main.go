@@ -1,21 +1,20 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/breakfront-planner/auth-service/internal/configs"
 	"github.com/breakfront-planner/auth-service/internal/database"
+	"github.com/breakfront-planner/auth-service/internal/server"
 
 	// Register database drivers
 	_ "github.com/lib/pq"
 
-	/*"github.com/breakfront-planner/auth-service/internal/jwt"
-	"github.com/breakfront-planner/auth-service/internal/repositories"
-	"github.com/breakfront-planner/auth-service/internal/services"
-	"github.com/breakfront-planner/auth-service/internal/configs"
-
-	"os"
-	*/
-
 	"github.com/joho/godotenv"
 )
 
@@ -26,6 +25,11 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
+	cfg, err := configs.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
 	db, err := database.Connect()
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
@@ -42,19 +46,39 @@ func main() {
 	}
 	log.Println("Migrations ok")
 
-	/*
+	deps, err := server.NewDependencies(db, cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize dependencies:", err)
+	}
+	httpServer := server.NewHTTPServer(cfg, deps)
+	grpcServer := server.NewGRPCServer(cfg, deps)
 
-				userRepo := repositories.NewUserRepository(db)
-				tokenRepo := repositories.NewTokenRepository(db)
+	go func() {
+		log.Printf("HTTP server listening on %s", cfg.HTTPAddr)
+		if err := httpServer.Start(); err != nil {
+			log.Fatal("HTTP server failed:", err)
+		}
+	}()
 
-				cfg, _ := configs.Load()
-				jwtManager := jwt.NewManager(cfg.JWTSecret, cfg.AccessDuration, cfg.RefreshDuration)
-		hashService := services.NewHashService()
-				userService := services.NewUserService(userRepo, hashService)
-				tokenService := services.NewTokenService(tokenRepo, hashService, jwtManager)
-				validator := validators.NewTokenValidator(jwtManager, userService)
-				authService := services.NewAuthService(tokenService, userService, validator)
+	go func() {
+		log.Printf("gRPC server listening on %s", cfg.GRPCAddr)
+		if err := grpcServer.Start(); err != nil {
+			log.Fatal("gRPC server failed:", err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
 
-	*/
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Printf("HTTP server shutdown failed: %v", err)
+	}
+	if err := grpcServer.Shutdown(ctx); err != nil {
+		log.Printf("gRPC server shutdown failed: %v", err)
+	}
+	log.Println("Server stopped")
 }
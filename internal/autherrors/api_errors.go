@@ -0,0 +1,37 @@
+package autherrors
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrBadRequestBody        = errors.New("invalid request body")
+	ErrEmptyCredentials      = errors.New("login and password are required")
+	ErrEmptyToken            = errors.New("refresh_token is required")
+	ErrEmptyAccessToken      = errors.New("access_token is required")
+	ErrMissingAuthHeader     = errors.New("missing authorization header")
+	ErrMalformedAuthHeader   = errors.New("authorization header must use the Bearer scheme")
+	ErrInvalidSessionID      = errors.New("invalid session id")
+	ErrEmptyTokenParam       = errors.New("token is required")
+	ErrMissingParameter      = errors.New("missing required parameter")
+	ErrUnsupportedParam      = errors.New("unsupported parameter")
+	ErrEmptyMFACode          = errors.New("code is required")
+	ErrEmptyChallengeToken   = errors.New("challenge_token is required")
+	ErrEmptyCredential       = errors.New("credential is required")
+	ErrInvalidPasskeyID      = errors.New("invalid passkey id")
+	ErrEmptyReauthentication = errors.New("password or code is required")
+	ErrInvalidIPAddress      = errors.New("invalid ip address")
+)
+
+func ErrInvalidRequestBody(err error) error {
+	return fmt.Errorf("%w: %w", ErrBadRequestBody, err)
+}
+
+func ErrMissingParam(name string) error {
+	return fmt.Errorf("%w: %v", ErrMissingParameter, name)
+}
+
+func ErrParamNotSupported(name string) error {
+	return fmt.Errorf("%w: %v", ErrUnsupportedParam, name)
+}
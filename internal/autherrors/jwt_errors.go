@@ -11,8 +11,37 @@ var (
 	ErrTokenSignMethod = errors.New("unexpected signing method")
 	ErrInvalidJWT      = errors.New("invalid JWT")
 	ErrInvalidUserID   = errors.New("invalid user_id format")
+	ErrNoSigningKey    = errors.New("signing key is not configured")
+	ErrKeyAlgMismatch  = errors.New("key type doesn't match signing algorithm")
+	ErrUnknownKeyID    = errors.New("unknown key id")
+	ErrRetireActiveKey = errors.New("active signing key can't be retired")
+	ErrShortSecret     = errors.New("HMAC secret must be at least 32 bytes")
 )
 
 func ErrNoClaimInToken(claim string) error {
 	return fmt.Errorf("not found in token: %v", claim)
 }
+
+func ErrInvalidClaim(claim string) error {
+	return fmt.Errorf("invalid claim in token: %v", claim)
+}
+
+func ErrUnsupportedAlgorithm(algorithm string) error {
+	return fmt.Errorf("unsupported signing algorithm: %v", algorithm)
+}
+
+func ErrLoadKey(err error) error {
+	return fmt.Errorf("failed to load signing key: %w", err)
+}
+
+func ErrDuplicateKeyID(kid string) error {
+	return fmt.Errorf("duplicate key id: %v", kid)
+}
+
+func ErrInvalidJWK(err error) error {
+	return fmt.Errorf("invalid JWK: %w", err)
+}
+
+func ErrFetchJWKS(err error) error {
+	return fmt.Errorf("failed to fetch JWKS: %w", err)
+}
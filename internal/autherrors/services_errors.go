@@ -3,14 +3,67 @@ package autherrors
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/breakfront-planner/auth-service/internal/models"
 )
 
 var (
-	ErrLoginTaken   = errors.New("login already taken")
-	ErrTokenType    = errors.New("wrong token type")
-	ErrTokenExpired = errors.New("token expired")
+	ErrLoginTaken              = errors.New("login already taken")
+	ErrTokenType               = errors.New("wrong token type")
+	ErrTokenExpired            = errors.New("token expired")
+	ErrUserNotExist            = errors.New("user not found")
+	ErrPasswordMismatch        = errors.New("wrong password")
+	ErrTokenParseFailed        = errors.New("failed to parse token")
+	ErrTokenReused             = errors.New("refresh token reuse detected")
+	ErrNoDenylist              = errors.New("token denylist is not configured")
+	ErrTokenIssuer             = errors.New("token issuer mismatch")
+	ErrTokenAudience           = errors.New("token audience mismatch")
+	ErrTokenDelegated          = errors.New("delegated token not accepted")
+	ErrTokenClient             = errors.New("token was issued to another client")
+	ErrRoleNotFound            = errors.New("role not found")
+	ErrRoleExists              = errors.New("role already exists")
+	ErrMissingRole             = errors.New("required role missing")
+	ErrMissingPermission       = errors.New("required permission missing")
+	ErrInvalidClient           = errors.New("client authentication failed")
+	ErrInvalidRedirectURI      = errors.New("redirect_uri is not registered for the client")
+	ErrUnsupportedResponseType = errors.New("unsupported response_type")
+	ErrInvalidCodeChallenge    = errors.New("code_challenge with code_challenge_method S256 is required")
+	ErrInvalidGrant            = errors.New("invalid authorization grant")
+	ErrUnsupportedGrantType    = errors.New("unsupported grant_type")
+	ErrUnauthorizedClient      = errors.New("client is not allowed to use this grant type")
+	ErrInvalidScope            = errors.New("requested scope is not allowed for the client")
+	ErrAuthorizationPending    = errors.New("the user has not yet approved the device")
+	ErrSlowDown                = errors.New("polling too frequently, slow down")
+	ErrAccessDenied            = errors.New("the user denied the authorization request")
+	ErrDeviceCodeExpired       = errors.New("device code expired")
+	ErrInvalidUserCode         = errors.New("unknown or expired user code")
+	ErrUserCodeTaken           = errors.New("user code already in use")
+	ErrInvalidSubjectToken     = errors.New("invalid subject_token")
+	ErrUnsupportedTokenType    = errors.New("unsupported token type")
+	ErrInvalidTarget           = errors.New("requested audience is not allowed")
+	ErrInvalidPasswordHash     = errors.New("malformed password hash")
+	ErrHashMismatch            = errors.New("password does not match hash")
+	ErrWeakPassword            = errors.New("password does not meet the password policy")
+	ErrInvalidBreachList       = errors.New("malformed breached password list")
+	ErrAccountLocked           = errors.New("too many failed login attempts, try again later")
+	ErrRateLimited             = errors.New("too many requests, try again later")
+	ErrInvalidRate             = errors.New("rate must be <requests>/<period>, e.g. 10/1m")
+	ErrMFARequired             = errors.New("two-factor authentication required")
+	ErrInvalidMFACode          = errors.New("invalid authentication code")
+	ErrMFAAlreadyEnabled       = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled          = errors.New("no pending two-factor authentication enrollment")
+	ErrMFANotEnabled           = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTOTPSecret       = errors.New("malformed TOTP secret")
+	ErrInvalidEncryptionKey    = errors.New("encryption key must be 32 bytes")
+	ErrNoEncryptionKey         = errors.New("TOTP secret is encrypted but no encryption key is configured")
+	ErrInvalidPasskey          = errors.New("passkey verification failed")
+	ErrPasskeyCounter          = errors.New("passkey signature counter did not increase, the passkey may be cloned")
+	ErrPasskeyExists           = errors.New("passkey is already registered")
+	ErrNoPasskeys              = errors.New("no passkeys registered")
+	ErrNoPasskeyService        = errors.New("passkeys are not configured")
+	ErrInvalidCBOR             = errors.New("malformed CBOR data")
 )
 
 func ErrPassHash(err error) error {
@@ -38,7 +91,7 @@ func ErrWrongLogin(err error) error {
 }
 
 func ErrWrongPassword(err error) error {
-	return fmt.Errorf("wrong password: %w", err)
+	return fmt.Errorf("%w: %w", ErrPasswordMismatch, err)
 }
 
 func ErrRefreshToken(err error) error {
@@ -50,5 +103,132 @@ func ErrRevokeToken(err error) error {
 }
 
 func ErrParseToken(err error) error {
-	return fmt.Errorf("failed to parse token: %w", err)
+	return fmt.Errorf("%w: %w", ErrTokenParseFailed, err)
+}
+
+func ErrTokenReuse(err error) error {
+	return fmt.Errorf("%w: %w", ErrTokenReused, err)
+}
+
+func ErrUpdateRoles(err error) error {
+	return fmt.Errorf("failed to update user roles: %w", err)
+}
+
+func ErrLoadRoles(err error) error {
+	return fmt.Errorf("failed to load user roles: %w", err)
+}
+
+func ErrRoleRequired(role string) error {
+	return fmt.Errorf("%w: %v", ErrMissingRole, role)
+}
+
+func ErrPermissionRequired(permission string) error {
+	return fmt.Errorf("%w: %v", ErrMissingPermission, permission)
+}
+
+func ErrInvalidAuthorizationCode(reason string) error {
+	return fmt.Errorf("%w: %v", ErrInvalidGrant, reason)
+}
+
+func ErrScopeNotAllowed(scope string) error {
+	return fmt.Errorf("%w: %v", ErrInvalidScope, scope)
+}
+
+func ErrRegisterClient(err error) error {
+	return fmt.Errorf("failed to register client: %w", err)
+}
+
+func ErrInvalidDeviceGrant(reason string) error {
+	return fmt.Errorf("%w: %v", ErrInvalidGrant, reason)
+}
+
+func ErrSubjectToken(err error) error {
+	return fmt.Errorf("%w: %w", ErrInvalidSubjectToken, err)
+}
+
+func ErrTokenTypeNotSupported(tokenType string) error {
+	return fmt.Errorf("%w: %v", ErrUnsupportedTokenType, tokenType)
+}
+
+func ErrAudienceNotAllowed(audience string) error {
+	return fmt.Errorf("%w: %v", ErrInvalidTarget, audience)
+}
+
+func ErrDecryptSecret(err error) error {
+	return fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+}
+
+func ErrPasskeyVerification(reason string) error {
+	return fmt.Errorf("%w: %v", ErrInvalidPasskey, reason)
+}
+
+func ErrReadBreachList(err error) error {
+	return fmt.Errorf("failed to read breached password list: %w", err)
+}
+
+// PasswordViolation is a rule of the password policy that a password fails.
+type PasswordViolation struct {
+	// Rule identifies the rule, e.g. "min_length", so clients can show their own message.
+	Rule    string
+	Message string
+}
+
+// PasswordPolicyError lists every rule of the password policy that a password fails.
+// It matches ErrWeakPassword with errors.Is.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return fmt.Sprintf("%v: %v", ErrWeakPassword, strings.Join(messages, "; "))
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// LockoutError reports that logins are locked after too many failed attempts, for the login or the client's IP address.
+// It matches ErrAccountLocked with errors.Is.
+type LockoutError struct {
+	Until time.Time
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("%v: locked until %v", ErrAccountLocked, e.Until.Format(time.RFC3339))
+}
+
+func (e *LockoutError) Unwrap() error {
+	return ErrAccountLocked
+}
+
+// RateLimitError reports that a request was rejected by a rate limiter. It matches ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%v: retry after %v", ErrRateLimited, e.RetryAfter)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// MFARequiredError reports that the password was right but the user has two-factor authentication enabled.
+// ChallengeToken is exchanged together with an authentication code for the tokens of the login.
+// It matches ErrMFARequired with errors.Is.
+type MFARequiredError struct {
+	ChallengeToken *models.Token
+}
+
+func (e *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFARequiredError) Unwrap() error {
+	return ErrMFARequired
 }
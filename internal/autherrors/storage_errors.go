@@ -8,12 +8,27 @@ import (
 var (
 	ErrNoPtrsFilterFields = errors.New("all filter fields must be pointers")
 	ErrEmptyFilter        = errors.New("filter cannot be empty")
+	ErrTokenInvalid       = errors.New("invalid token")
+	ErrTokenRevoked       = errors.New("token revoked")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrRoleNotAssigned    = errors.New("role not assigned to user")
+	ErrCodeNotFound       = errors.New("authorization code not found or already used")
+	ErrDeviceCodeNotFound = errors.New("device authorization not found")
+	ErrPasskeyNotFound    = errors.New("passkey not found")
 )
 
 func ErrMissingEnvVars(varNames []string) error {
 	return fmt.Errorf("missing required environment variables: %v", varNames)
 }
 
+func ErrInvalidEnvVar(varName string, value string) error {
+	return fmt.Errorf("invalid value of environment variable %v: %q", varName, value)
+}
+
+func ErrShortEnvVar(varName string, minLength int) error {
+	return fmt.Errorf("environment variable %v must be at least %d bytes", varName, minLength)
+}
+
 func ErrFailToCreateUser(err error) error {
 	return fmt.Errorf("failed to create user: %w", err)
 }
@@ -22,6 +37,10 @@ func ErrFailToFindUser(err error) error {
 	return fmt.Errorf("failed to find user: %w", err)
 }
 
+func ErrUpdateUser(err error) error {
+	return fmt.Errorf("failed to update user: %w", err)
+}
+
 func ErrSaveToken(err error) error {
 	return fmt.Errorf("failed to save token: %w", err)
 }
@@ -35,7 +54,7 @@ func ErrDBTransactionFailed(err error) error {
 }
 
 func ErrInvalidToken(err error) error {
-	return fmt.Errorf("invalid token: %w", err)
+	return fmt.Errorf("%w: %w", ErrTokenInvalid, err)
 }
 
 func ErrCheckToken(err error) error {
@@ -45,3 +64,155 @@ func ErrCheckToken(err error) error {
 func ErrExpiredToken(err error) error {
 	return fmt.Errorf("token expired: %w", err)
 }
+
+func ErrSaveSecurityEvent(err error) error {
+	return fmt.Errorf("failed to save security event: %w", err)
+}
+
+func ErrFindSecurityEvents(err error) error {
+	return fmt.Errorf("failed to find security events: %w", err)
+}
+
+func ErrFindSessions(err error) error {
+	return fmt.Errorf("failed to find sessions: %w", err)
+}
+
+func ErrSaveDenylistEntry(err error) error {
+	return fmt.Errorf("failed to save denylist entry: %w", err)
+}
+
+func ErrCheckDenylist(err error) error {
+	return fmt.Errorf("failed to check token denylist: %w", err)
+}
+
+func ErrDeleteDenylistEntries(err error) error {
+	return fmt.Errorf("failed to delete expired denylist entries: %w", err)
+}
+
+func ErrSaveRole(err error) error {
+	return fmt.Errorf("failed to save role: %w", err)
+}
+
+func ErrFindRoles(err error) error {
+	return fmt.Errorf("failed to find roles: %w", err)
+}
+
+func ErrAssignRole(err error) error {
+	return fmt.Errorf("failed to update role assignment: %w", err)
+}
+
+func ErrSaveClient(err error) error {
+	return fmt.Errorf("failed to save client: %w", err)
+}
+
+func ErrFindClient(err error) error {
+	return fmt.Errorf("failed to find client: %w", err)
+}
+
+func ErrSaveAuthorizationCode(err error) error {
+	return fmt.Errorf("failed to save authorization code: %w", err)
+}
+
+func ErrConsumeAuthorizationCode(err error) error {
+	return fmt.Errorf("failed to consume authorization code: %w", err)
+}
+
+func ErrFindAuthorizationCode(err error) error {
+	return fmt.Errorf("failed to find authorization code: %w", err)
+}
+
+func ErrDeleteAuthorizationCodes(err error) error {
+	return fmt.Errorf("failed to delete expired authorization codes: %w", err)
+}
+
+func ErrSaveDeviceAuthorization(err error) error {
+	return fmt.Errorf("failed to save device authorization: %w", err)
+}
+
+func ErrFindDeviceAuthorization(err error) error {
+	return fmt.Errorf("failed to find device authorization: %w", err)
+}
+
+func ErrUpdateDeviceAuthorization(err error) error {
+	return fmt.Errorf("failed to update device authorization: %w", err)
+}
+
+func ErrDeleteDeviceAuthorizations(err error) error {
+	return fmt.Errorf("failed to delete expired device authorizations: %w", err)
+}
+
+func ErrFindLoginAttempts(err error) error {
+	return fmt.Errorf("failed to find login attempts: %w", err)
+}
+
+func ErrRecordLoginAttempt(err error) error {
+	return fmt.Errorf("failed to record login attempt: %w", err)
+}
+
+func ErrDeleteLoginAttempts(err error) error {
+	return fmt.Errorf("failed to delete login attempts: %w", err)
+}
+
+func ErrUpdateRateLimit(err error) error {
+	return fmt.Errorf("failed to update rate limit: %w", err)
+}
+
+func ErrDeleteRateLimits(err error) error {
+	return fmt.Errorf("failed to delete expired rate limits: %w", err)
+}
+
+func ErrSaveTOTP(err error) error {
+	return fmt.Errorf("failed to save TOTP credential: %w", err)
+}
+
+func ErrFindTOTP(err error) error {
+	return fmt.Errorf("failed to find TOTP credential: %w", err)
+}
+
+func ErrUpdateTOTP(err error) error {
+	return fmt.Errorf("failed to update TOTP credential: %w", err)
+}
+
+func ErrDeleteTOTP(err error) error {
+	return fmt.Errorf("failed to delete TOTP credential: %w", err)
+}
+
+func ErrSaveRecoveryCodes(err error) error {
+	return fmt.Errorf("failed to save recovery codes: %w", err)
+}
+
+func ErrFindRecoveryCodes(err error) error {
+	return fmt.Errorf("failed to find recovery codes: %w", err)
+}
+
+func ErrUseRecoveryCode(err error) error {
+	return fmt.Errorf("failed to use recovery code: %w", err)
+}
+
+func ErrSavePasskeyChallenge(err error) error {
+	return fmt.Errorf("failed to save passkey challenge: %w", err)
+}
+
+func ErrConsumePasskeyChallenge(err error) error {
+	return fmt.Errorf("failed to consume passkey challenge: %w", err)
+}
+
+func ErrDeletePasskeyChallenges(err error) error {
+	return fmt.Errorf("failed to delete expired passkey challenges: %w", err)
+}
+
+func ErrSavePasskey(err error) error {
+	return fmt.Errorf("failed to save passkey: %w", err)
+}
+
+func ErrFindPasskeys(err error) error {
+	return fmt.Errorf("failed to find passkeys: %w", err)
+}
+
+func ErrUpdatePasskey(err error) error {
+	return fmt.Errorf("failed to update passkey: %w", err)
+}
+
+func ErrDeletePasskey(err error) error {
+	return fmt.Errorf("failed to delete passkey: %w", err)
+}
@@ -0,0 +1,96 @@
+// Package clientip determines the IP address of the client of a request that may have passed reverse proxies.
+package clientip
+
+import (
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// ForwardedForHeader is the header, or gRPC metadata key in lower case, in which proxies append the address
+// they received a request from.
+const ForwardedForHeader = "X-Forwarded-For"
+
+// Resolver determines client IP addresses, trusting the X-Forwarded-For header only if it was set by
+// one of the trusted proxies. Anyone can send the header, so without trusted proxies it is ignored
+// and the address of the peer is the client's.
+type Resolver struct {
+	trustedProxies []netip.Prefix
+}
+
+// NewResolver creates a resolver trusting the proxies in the given networks.
+func NewResolver(trustedProxies []netip.Prefix) *Resolver {
+	return &Resolver{trustedProxies: trustedProxies}
+}
+
+// Resolve returns the IP address of the client given the address of the peer, with or without port, and the values of
+// the X-Forwarded-For headers. The header is read from right to left, as long as the address it was received from
+// is a trusted proxy, and the first address not of a trusted proxy is the client's. Invalid entries end the search
+// at the last valid address.
+func (r *Resolver) Resolve(remoteAddr string, forwardedFor []string) string {
+	addr, err := parseAddr(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	if !r.isTrusted(addr) {
+		return addr.String()
+	}
+
+	var hops []string
+	for _, value := range forwardedFor {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	for _, hop := range slices.Backward(hops) {
+		if !r.isTrusted(addr) {
+			break
+		}
+		forwarded, err := parseAddr(strings.TrimSpace(hop))
+		if err != nil {
+			break
+		}
+		addr = forwarded
+	}
+
+	return addr.String()
+}
+
+// isTrusted reports whether the address is of a trusted proxy.
+func (r *Resolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range r.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAddr parses an IP address with or without port; IPv4-mapped IPv6 addresses are returned as IPv4.
+func parseAddr(value string) (netip.Addr, error) {
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addr.Unmap(), nil
+}
+
+// ParsePrefix parses a network in CIDR notation or a single IP address, which stands for a network of just it.
+func ParsePrefix(value string) (netip.Prefix, error) {
+	if !strings.Contains(value, "/") {
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
@@ -0,0 +1,87 @@
+package clientip
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type ResolverTestSuite struct {
+	suite.Suite
+	resolver *Resolver
+}
+
+func (s *ResolverTestSuite) SetupTest() {
+	s.resolver = NewResolver([]netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	})
+}
+
+func (s *ResolverTestSuite) TestResolve() {
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		want         string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:52113", want: "203.0.113.7"},
+		{
+			name:         "header of an untrusted peer",
+			remoteAddr:   "203.0.113.7:52113",
+			forwardedFor: []string{"198.51.100.23"},
+			want:         "203.0.113.7",
+		},
+		{name: "trusted proxy", remoteAddr: "10.1.2.3:443", forwardedFor: []string{"198.51.100.23"}, want: "198.51.100.23"},
+		{
+			name:         "chain of trusted proxies",
+			remoteAddr:   "10.1.2.3:443",
+			forwardedFor: []string{"198.51.100.23, 10.4.5.6", "10.7.8.9"},
+			want:         "198.51.100.23",
+		},
+		{
+			name:         "spoofed entries left of the client",
+			remoteAddr:   "10.1.2.3:443",
+			forwardedFor: []string{"192.0.2.99, 198.51.100.23"},
+			want:         "198.51.100.23",
+		},
+		{name: "only trusted proxies", remoteAddr: "10.1.2.3:443", forwardedFor: []string{"10.4.5.6"}, want: "10.4.5.6"},
+		{name: "proxy without header", remoteAddr: "10.1.2.3:443", want: "10.1.2.3"},
+		{name: "invalid entry", remoteAddr: "10.1.2.3:443", forwardedFor: []string{"198.51.100.23, unknown"}, want: "10.1.2.3"},
+		{name: "IPv6 proxy", remoteAddr: "[2001:db8::1]:443", forwardedFor: []string{"2001:db8:ffff::1"}, want: "2001:db8:ffff::1"},
+		{name: "IPv4-mapped peer", remoteAddr: "[::ffff:10.1.2.3]:443", forwardedFor: []string{"198.51.100.23"}, want: "198.51.100.23"},
+		{name: "address without port", remoteAddr: "203.0.113.7", want: "203.0.113.7"},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			assert.Equal(s.T(), tc.want, s.resolver.Resolve(tc.remoteAddr, tc.forwardedFor))
+		})
+	}
+}
+
+func (s *ResolverTestSuite) TestWithoutTrustedProxies() {
+	resolver := NewResolver(nil)
+
+	assert.Equal(s.T(), "10.1.2.3", resolver.Resolve("10.1.2.3:443", []string{"198.51.100.23"}))
+}
+
+func (s *ResolverTestSuite) TestParsePrefix() {
+	prefix, err := ParsePrefix("10.1.2.3/8")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), netip.MustParsePrefix("10.0.0.0/8"), prefix)
+
+	prefix, err = ParsePrefix("192.0.2.10")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), netip.MustParsePrefix("192.0.2.10/32"), prefix)
+
+	_, err = ParsePrefix("proxy.internal")
+	assert.Error(s.T(), err)
+}
+
+func TestResolverTestSuite(t *testing.T) {
+	suite.Run(t, new(ResolverTestSuite))
+}
@@ -1,33 +1,497 @@
 package configs
 
 import (
+	"encoding/base64"
+	"math"
+	"net/netip"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/clientip"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+	"github.com/breakfront-planner/auth-service/internal/password"
+	"github.com/breakfront-planner/auth-service/internal/ratelimit"
 )
 
+// VerificationKey points to the public key of a rotated-out signing key
+// that stays valid for token verification until it is retired.
+type VerificationKey struct {
+	Algorithm     string
+	PublicKeyPath string
+}
+
 // Config holds the application configuration settings.
 type Config struct {
-	JWTSecret       string
+	JWTSecret         string
+	JWTAlgorithm      string
+	JWTPrivateKeyPath string
+	// JWTVerificationKeys is parsed from JWT_VERIFICATION_KEYS as "ALG:path,ALG:path".
+	JWTVerificationKeys []VerificationKey
+	JWTIssuer           string
+	// JWTAudience is parsed from JWT_AUDIENCE as a comma separated list of service names; AuthAudience is added.
+	JWTAudience     []string
+	JWTLeeway       time.Duration
 	AccessDuration  time.Duration
 	RefreshDuration time.Duration
+	HTTPAddr        string
+	GRPCAddr        string
+	// AuthAudience is the name of the auth service itself in the aud claim, "auth-service" unless AUTH_AUDIENCE is set.
+	// It is always one of the JWTAudience, and the endpoints managing the user's account only accept tokens meant for it.
+	AuthAudience string
+	// TokenExchangeAudiences is parsed from TOKEN_EXCHANGE_AUDIENCES as a comma separated list of the services
+	// user tokens can be exchanged for. They must not be in JWTAudience, so those services only accept
+	// exchanged tokens; token exchange is refused if the list is empty.
+	TokenExchangeAudiences []string
+	// IntrospectionClients is parsed from INTROSPECTION_CLIENTS as "client_id:secret,client_id:secret".
+	// These clients may call the token introspection endpoint.
+	IntrospectionClients map[string]string
+	// AuthorizationCodeDuration is how long OAuth authorization codes can be exchanged for tokens.
+	AuthorizationCodeDuration time.Duration
+	// DeviceVerificationURI is the frontend page where users sign in and enter the user code of a device.
+	// The device authorization grant is only enabled if it is set.
+	DeviceVerificationURI string
+	// DeviceCodeDuration is how long the user has to approve a device.
+	DeviceCodeDuration time.Duration
+	// DevicePollInterval is the minimum time between two token requests of a device.
+	DevicePollInterval time.Duration
+	// Argon2Memory (in KiB), Argon2Iterations and Argon2Parallelism are the cost parameters of new password hashes.
+	// Stored hashes with other parameters are replaced on the user's next login.
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	// PasswordMinLength, PasswordMaxLength, PasswordMinCharacterClasses and PasswordMinStrength (0-4)
+	// are the rules of the password policy for new passwords.
+	PasswordMinLength           int
+	PasswordMaxLength           int
+	PasswordMinCharacterClasses int
+	PasswordMinStrength         int
+	// PasswordBreachList is the path of an offline copy of the Have I Been Pwned password list,
+	// either a sorted file or a directory of range files. Breached passwords are not screened if it is empty.
+	PasswordBreachList string
+	// LockoutLoginThreshold and LockoutIPThreshold are the numbers of failed logins after which a login
+	// or a client IP address is locked; 0 disables locking them.
+	LockoutLoginThreshold int
+	LockoutIPThreshold    int
+	// LockoutDuration is how long the first lock lasts; it doubles with every further failure up to LockoutMaxDuration.
+	LockoutDuration    time.Duration
+	LockoutMaxDuration time.Duration
+	// LockoutFailureWindow is how long failed logins are counted.
+	LockoutFailureWindow time.Duration
+	// RateLimitAlgorithm is token_bucket or sliding_window. RateLimitStore is memory, counting per instance,
+	// or postgres, sharing the counts of all replicas.
+	RateLimitAlgorithm string
+	RateLimitStore     string
+	// RateLimitIP, RateLimitLogin and RateLimitClient are parsed as "<requests>/<period>" and limit requests to
+	// the authentication endpoints per client IP address, per login and per OAuth client; "0" disables a limit.
+	RateLimitIP     ratelimit.Rate
+	RateLimitLogin  ratelimit.Rate
+	RateLimitClient ratelimit.Rate
+	// TrustedProxies is parsed from TRUSTED_PROXIES as a comma separated list of networks in CIDR notation or
+	// IP addresses. The X-Forwarded-For header is only honored on requests from these proxies.
+	TrustedProxies []netip.Prefix
+	// MFAIssuer names the service in authenticator apps.
+	MFAIssuer string
+	// MFAChallengeDuration is how long users with two-factor authentication have to enter a code after their password.
+	MFAChallengeDuration time.Duration
+	// MFAEncryptionKey is parsed from MFA_ENCRYPTION_KEY as 32 base64 encoded bytes and encrypts TOTP secrets
+	// at rest; they are stored unencrypted if it is empty.
+	MFAEncryptionKey []byte
+	// WebAuthnRPID is the domain passkeys are bound to; passkeys are disabled if it is empty.
+	WebAuthnRPID string
+	// WebAuthnRPName names the service when users create a passkey; it defaults to MFAIssuer.
+	WebAuthnRPName string
+	// WebAuthnOrigins are the origins of the pages running the WebAuthn ceremonies; they default to https://<WebAuthnRPID>.
+	WebAuthnOrigins []string
+	// WebAuthnChallengeDuration is how long users have to complete a passkey registration or login.
+	WebAuthnChallengeDuration time.Duration
 }
 
 // Load reads configuration from environment variables.
-// It parses token durations and falls back to default values if parsing fails.
+// Variables that are not set fall back to default values; variables that are set but invalid
+// or out of range are reported with autherrors.ErrInvalidEnvVar.
 func Load() (*Config, error) {
-	accessDur, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_DURATION"))
+	accessDur, err := parseDuration("ACCESS_TOKEN_DURATION", 10*time.Minute, time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshDur, err := parseDuration("REFRESH_TOKEN_DURATION", 48*time.Hour, time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	jwtAlgorithm := os.Getenv("JWT_ALGORITHM")
+	if jwtAlgorithm == "" {
+		jwtAlgorithm = "HS256"
+	}
+
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwt.IsHMAC(jwtAlgorithm) && len(jwtSecret) < jwt.MinHMACSecretLength {
+		return nil, autherrors.ErrShortEnvVar("JWT_SECRET", jwt.MinHMACSecretLength)
+	}
+
+	verificationKeys, err := parseVerificationKeys(os.Getenv("JWT_VERIFICATION_KEYS"))
+	if err != nil {
+		return nil, err
+	}
+
+	leeway, err := parseDuration("JWT_LEEWAY", jwt.DefaultLeeway, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	authAudience := os.Getenv("AUTH_AUDIENCE")
+	if authAudience == "" {
+		authAudience = "auth-service"
+	}
+
+	jwtAudience := parseList(os.Getenv("JWT_AUDIENCE"))
+	if !slices.Contains(jwtAudience, authAudience) {
+		jwtAudience = append(jwtAudience, authAudience)
+	}
+	exchangeAudiences := parseList(os.Getenv("TOKEN_EXCHANGE_AUDIENCES"))
+	for _, audience := range exchangeAudiences {
+		if slices.Contains(jwtAudience, audience) {
+			return nil, autherrors.ErrInvalidEnvVar("TOKEN_EXCHANGE_AUDIENCES", audience)
+		}
+	}
+
+	codeDur, err := parseDuration("AUTHORIZATION_CODE_DURATION", time.Minute, time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceCodeDur, err := parseDuration("DEVICE_CODE_DURATION", 10*time.Minute, time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	devicePollInterval, err := parseDuration("DEVICE_POLL_INTERVAL", 5*time.Second, time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	argon2Memory, err := parseUint("ARGON2_MEMORY", constants.DefaultArgon2Memory, 32)
+	if err != nil {
+		return nil, err
+	}
+	argon2Iterations, err := parseUint("ARGON2_ITERATIONS", constants.DefaultArgon2Iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	argon2Parallelism, err := parseUint("ARGON2_PARALLELISM", constants.DefaultArgon2Parallelism, 8)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordMinLength, err := parseInt("PASSWORD_MIN_LENGTH", password.DefaultMinLength, 1, password.DefaultMaxLength)
+	if err != nil {
+		return nil, err
+	}
+	passwordMaxLength, err := parseInt("PASSWORD_MAX_LENGTH", password.DefaultMaxLength, passwordMinLength, math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	passwordMinClasses, err := parseInt("PASSWORD_MIN_CHARACTER_CLASSES", password.DefaultMinCharacterClasses, 0, 4)
+	if err != nil {
+		return nil, err
+	}
+	passwordMinStrength, err := parseInt("PASSWORD_MIN_STRENGTH", password.DefaultMinStrength, 0, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutLoginThreshold, err := parseInt("LOCKOUT_LOGIN_THRESHOLD", constants.DefaultLockoutLoginThreshold, 0, math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	lockoutIPThreshold, err := parseInt("LOCKOUT_IP_THRESHOLD", constants.DefaultLockoutIPThreshold, 0, math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutDur, err := parseDuration("LOCKOUT_DURATION", constants.DefaultLockoutDuration, time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	// The maximum lock must not be shorter than the first one; the default grows with a longer first lock
+	lockoutMaxDur, err := parseDuration("LOCKOUT_MAX_DURATION", max(constants.DefaultLockoutMaxDuration, lockoutDur),
+		lockoutDur)
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutWindow, err := parseDuration("LOCKOUT_FAILURE_WINDOW", constants.DefaultLockoutFailureWindow, time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimitAlgorithm, err := parseChoice("RATE_LIMIT_ALGORITHM", ratelimit.AlgorithmTokenBucket,
+		ratelimit.AlgorithmSlidingWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimitStore, err := parseChoice("RATE_LIMIT_STORE", ratelimit.StoreMemory, ratelimit.StorePostgres)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimitIP, err := parseRate("RATE_LIMIT_IP", ratelimit.Rate{Limit: 60, Period: time.Minute})
 	if err != nil {
-		accessDur = 10 * time.Minute
+		return nil, err
 	}
 
-	refreshDur, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_DURATION"))
+	rateLimitLogin, err := parseRate("RATE_LIMIT_LOGIN", ratelimit.Rate{Limit: 10, Period: time.Minute})
 	if err != nil {
-		refreshDur = 48 * time.Hour
+		return nil, err
+	}
+
+	rateLimitClient, err := parseRate("RATE_LIMIT_CLIENT", ratelimit.Rate{})
+	if err != nil {
+		return nil, err
+	}
+
+	trustedProxies, err := parseProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, err
+	}
+
+	mfaIssuer := os.Getenv("MFA_ISSUER")
+	if mfaIssuer == "" {
+		mfaIssuer = "Breakfront"
+	}
+
+	mfaChallengeDur, err := parseDuration("MFA_CHALLENGE_DURATION", jwt.DefaultChallengeDuration, time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	mfaEncryptionKey, err := parseKey("MFA_ENCRYPTION_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	webAuthnRPID := os.Getenv("WEBAUTHN_RP_ID")
+
+	webAuthnRPName := os.Getenv("WEBAUTHN_RP_NAME")
+	if webAuthnRPName == "" {
+		webAuthnRPName = mfaIssuer
+	}
+
+	webAuthnOrigins := parseList(os.Getenv("WEBAUTHN_ORIGINS"))
+	if len(webAuthnOrigins) == 0 && webAuthnRPID != "" {
+		webAuthnOrigins = []string{"https://" + webAuthnRPID}
+	}
+
+	webAuthnChallengeDur, err := parseDuration("WEBAUTHN_CHALLENGE_DURATION", 5*time.Minute, time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	introspectionClients, err := parseClients(os.Getenv("INTROSPECTION_CLIENTS"))
+	if err != nil {
+		return nil, err
+	}
+
+	httpAddr := os.Getenv("HTTP_ADDR")
+	if httpAddr == "" {
+		httpAddr = ":8080"
+	}
+
+	grpcAddr := os.Getenv("GRPC_ADDR")
+	if grpcAddr == "" {
+		grpcAddr = ":9090"
 	}
 
 	return &Config{
-		JWTSecret:       os.Getenv("JWT_SECRET"),
-		AccessDuration:  accessDur,
-		RefreshDuration: refreshDur,
+		JWTSecret:                   jwtSecret,
+		JWTAlgorithm:                jwtAlgorithm,
+		JWTPrivateKeyPath:           os.Getenv("JWT_PRIVATE_KEY_PATH"),
+		JWTVerificationKeys:         verificationKeys,
+		JWTIssuer:                   os.Getenv("JWT_ISSUER"),
+		JWTAudience:                 jwtAudience,
+		AuthAudience:                authAudience,
+		TokenExchangeAudiences:      exchangeAudiences,
+		JWTLeeway:                   leeway,
+		AccessDuration:              accessDur,
+		RefreshDuration:             refreshDur,
+		HTTPAddr:                    httpAddr,
+		GRPCAddr:                    grpcAddr,
+		IntrospectionClients:        introspectionClients,
+		AuthorizationCodeDuration:   codeDur,
+		DeviceVerificationURI:       os.Getenv("DEVICE_VERIFICATION_URI"),
+		DeviceCodeDuration:          deviceCodeDur,
+		DevicePollInterval:          devicePollInterval,
+		Argon2Memory:                argon2Memory,
+		Argon2Iterations:            argon2Iterations,
+		Argon2Parallelism:           uint8(argon2Parallelism),
+		PasswordMinLength:           passwordMinLength,
+		PasswordMaxLength:           passwordMaxLength,
+		PasswordMinCharacterClasses: passwordMinClasses,
+		PasswordMinStrength:         passwordMinStrength,
+		PasswordBreachList:          os.Getenv("PASSWORD_BREACH_LIST"),
+		LockoutLoginThreshold:       lockoutLoginThreshold,
+		LockoutIPThreshold:          lockoutIPThreshold,
+		LockoutDuration:             lockoutDur,
+		LockoutMaxDuration:          lockoutMaxDur,
+		LockoutFailureWindow:        lockoutWindow,
+		RateLimitAlgorithm:          rateLimitAlgorithm,
+		RateLimitStore:              rateLimitStore,
+		RateLimitIP:                 rateLimitIP,
+		RateLimitLogin:              rateLimitLogin,
+		RateLimitClient:             rateLimitClient,
+		TrustedProxies:              trustedProxies,
+		MFAIssuer:                   mfaIssuer,
+		MFAChallengeDuration:        mfaChallengeDur,
+		MFAEncryptionKey:            mfaEncryptionKey,
+		WebAuthnRPID:                webAuthnRPID,
+		WebAuthnRPName:              webAuthnRPName,
+		WebAuthnOrigins:             webAuthnOrigins,
+		WebAuthnChallengeDuration:   webAuthnChallengeDur,
 	}, nil
 }
+
+func parseVerificationKeys(value string) ([]VerificationKey, error) {
+	var keys []VerificationKey
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		algorithm, path, found := strings.Cut(entry, ":")
+		if !found || algorithm == "" || path == "" {
+			return nil, autherrors.ErrInvalidEnvVar("JWT_VERIFICATION_KEYS", entry)
+		}
+
+		keys = append(keys, VerificationKey{
+			Algorithm:     algorithm,
+			PublicKeyPath: path,
+		})
+	}
+	return keys, nil
+}
+
+func parseClients(value string) (map[string]string, error) {
+	clients := make(map[string]string)
+	for _, entry := range parseList(value) {
+		clientID, secret, found := strings.Cut(entry, ":")
+		if !found || clientID == "" || secret == "" {
+			return nil, autherrors.ErrInvalidEnvVar("INTROSPECTION_CLIENTS", clientID)
+		}
+		clients[clientID] = secret
+	}
+	return clients, nil
+}
+
+func parseProxies(value string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, entry := range parseList(value) {
+		prefix, err := clientip.ParsePrefix(entry)
+		if err != nil {
+			return nil, autherrors.ErrInvalidEnvVar("TRUSTED_PROXIES", entry)
+		}
+		proxies = append(proxies, prefix)
+	}
+	return proxies, nil
+}
+
+// parseDuration parses the duration in the environment variable, which must be at least low,
+// falling back to the default if the variable is empty.
+func parseDuration(varName string, fallback time.Duration, low time.Duration) (time.Duration, error) {
+	value := os.Getenv(varName)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < low {
+		return 0, autherrors.ErrInvalidEnvVar(varName, value)
+	}
+	return parsed, nil
+}
+
+// parseUint parses the positive integer of the given bit size in the environment variable,
+// falling back to the default if the variable is empty.
+func parseUint(varName string, fallback uint32, bitSize int) (uint32, error) {
+	value := os.Getenv(varName)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.ParseUint(value, 10, bitSize)
+	if err != nil || parsed == 0 {
+		return 0, autherrors.ErrInvalidEnvVar(varName, value)
+	}
+	return uint32(parsed), nil
+}
+
+// parseInt parses the integer within [low, high] in the environment variable,
+// falling back to the default if the variable is empty.
+func parseInt(varName string, fallback int, low int, high int) (int, error) {
+	value := os.Getenv(varName)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < low || parsed > high {
+		return 0, autherrors.ErrInvalidEnvVar(varName, value)
+	}
+	return parsed, nil
+}
+
+// parseChoice returns the value of the environment variable, which must be one of the choices;
+// the first one is the default if the variable is empty.
+func parseChoice(varName string, choices ...string) (string, error) {
+	value := os.Getenv(varName)
+	if value == "" {
+		return choices[0], nil
+	}
+	if !slices.Contains(choices, value) {
+		return "", autherrors.ErrInvalidEnvVar(varName, value)
+	}
+	return value, nil
+}
+
+// parseRate parses the rate in the environment variable, falling back to the default if the variable is empty.
+func parseRate(varName string, fallback ratelimit.Rate) (ratelimit.Rate, error) {
+	value := os.Getenv(varName)
+	if value == "" {
+		return fallback, nil
+	}
+	rate, err := ratelimit.ParseRate(value)
+	if err != nil {
+		return ratelimit.Rate{}, autherrors.ErrInvalidEnvVar(varName, value)
+	}
+	return rate, nil
+}
+
+// parseKey decodes the base64 encoded key in the environment variable, or returns nil if the variable is empty.
+// Invalid keys are not echoed in the error.
+func parseKey(varName string) ([]byte, error) {
+	value := os.Getenv(varName)
+	if value == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, autherrors.ErrInvalidEnvVar(varName, "<redacted>")
+	}
+	return key, nil
+}
+
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
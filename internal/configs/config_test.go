@@ -0,0 +1,111 @@
+package configs
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+)
+
+type ConfigTestSuite struct {
+	suite.Suite
+}
+
+func (s *ConfigTestSuite) SetupTest() {
+	s.T().Setenv("JWT_SECRET", strings.Repeat("s", jwt.MinHMACSecretLength))
+}
+
+func (s *ConfigTestSuite) TestLoadDefaults() {
+	cfg, err := Load()
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 10*time.Minute, cfg.AccessDuration)
+	assert.Equal(s.T(), jwt.DefaultLeeway, cfg.JWTLeeway)
+	assert.Equal(s.T(), constants.DefaultLockoutMaxDuration, cfg.LockoutMaxDuration)
+	assert.Equal(s.T(), uint32(constants.DefaultArgon2Memory), cfg.Argon2Memory)
+}
+
+func (s *ConfigTestSuite) TestLoadValidValues() {
+	s.T().Setenv("JWT_LEEWAY", "0s")
+	s.T().Setenv("LOCKOUT_DURATION", "2h")
+	s.T().Setenv("ARGON2_MEMORY", "65536")
+	s.T().Setenv("LOCKOUT_LOGIN_THRESHOLD", "0")
+	s.T().Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.10")
+
+	cfg, err := Load()
+
+	require.NoError(s.T(), err)
+	assert.Zero(s.T(), cfg.JWTLeeway)
+	assert.Equal(s.T(), 2*time.Hour, cfg.LockoutMaxDuration, "the default maximum must not be shorter than the first lock")
+	assert.Equal(s.T(), uint32(65536), cfg.Argon2Memory)
+	assert.Zero(s.T(), cfg.LockoutLoginThreshold)
+	assert.Equal(s.T(), []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.10/32")},
+		cfg.TrustedProxies)
+}
+
+func (s *ConfigTestSuite) TestLoadRequiresHMACSecret() {
+	s.T().Setenv("JWT_ALGORITHM", "HS256")
+	s.T().Setenv("JWT_SECRET", "")
+
+	cfg, err := Load()
+
+	assert.Nil(s.T(), cfg)
+	assert.ErrorContains(s.T(), err, "JWT_SECRET")
+}
+
+func (s *ConfigTestSuite) TestLoadAsymmetricAlgorithmWithoutSecret() {
+	s.T().Setenv("JWT_ALGORITHM", "RS256")
+	s.T().Setenv("JWT_SECRET", "")
+
+	_, err := Load()
+
+	require.NoError(s.T(), err)
+}
+
+func (s *ConfigTestSuite) TestLoadRejectsInvalidValues() {
+	testCases := []struct {
+		name  string
+		env   map[string]string
+		field string
+	}{
+		{name: "unparsable duration", env: map[string]string{"JWT_LEEWAY": "30"}, field: "JWT_LEEWAY"},
+		{name: "negative duration", env: map[string]string{"JWT_LEEWAY": "-1s"}, field: "JWT_LEEWAY"},
+		{name: "zero duration", env: map[string]string{"ACCESS_TOKEN_DURATION": "0s"}, field: "ACCESS_TOKEN_DURATION"},
+		{name: "poll interval too short", env: map[string]string{"DEVICE_POLL_INTERVAL": "500ms"}, field: "DEVICE_POLL_INTERVAL"},
+		{
+			name:  "maximum lock shorter than the first",
+			env:   map[string]string{"LOCKOUT_DURATION": "10m", "LOCKOUT_MAX_DURATION": "5m"},
+			field: "LOCKOUT_MAX_DURATION",
+		},
+		{name: "unparsable integer", env: map[string]string{"LOCKOUT_IP_THRESHOLD": "twenty"}, field: "LOCKOUT_IP_THRESHOLD"},
+		{name: "integer out of range", env: map[string]string{"PASSWORD_MIN_STRENGTH": "5"}, field: "PASSWORD_MIN_STRENGTH"},
+		{name: "zero cost parameter", env: map[string]string{"ARGON2_ITERATIONS": "0"}, field: "ARGON2_ITERATIONS"},
+		{name: "cost parameter too large", env: map[string]string{"ARGON2_PARALLELISM": "256"}, field: "ARGON2_PARALLELISM"},
+		{name: "short HMAC secret", env: map[string]string{"JWT_SECRET": "test-secret-key"}, field: "JWT_SECRET"},
+		{name: "proxy host name", env: map[string]string{"TRUSTED_PROXIES": "10.0.0.0/8,proxy.internal"}, field: "TRUSTED_PROXIES"},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			for name, value := range tc.env {
+				s.T().Setenv(name, value)
+			}
+
+			cfg, err := Load()
+
+			assert.Nil(s.T(), cfg)
+			assert.ErrorContains(s.T(), err, tc.field)
+		})
+	}
+}
+
+func TestConfigTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigTestSuite))
+}
@@ -0,0 +1,22 @@
+package constants
+
+import "time"
+
+// Default security settings, used by the services unless configured otherwise.
+const (
+	// DefaultArgon2Memory (in KiB), DefaultArgon2Iterations and DefaultArgon2Parallelism follow the OWASP
+	// recommendation of 19 MiB, two iterations and one thread for Argon2id password hashes.
+	DefaultArgon2Memory      = 19 * 1024
+	DefaultArgon2Iterations  = 2
+	DefaultArgon2Parallelism = 1
+	DefaultArgon2SaltLength  = 16
+	DefaultArgon2KeyLength   = 32
+
+	// DefaultLockoutLoginThreshold and DefaultLockoutIPThreshold lock a login after 5 and an IP address
+	// after 20 failures within DefaultLockoutFailureWindow, for a minute at first and up to an hour.
+	DefaultLockoutLoginThreshold = 5
+	DefaultLockoutIPThreshold    = 20
+	DefaultLockoutDuration       = time.Minute
+	DefaultLockoutMaxDuration    = time.Hour
+	DefaultLockoutFailureWindow  = 24 * time.Hour
+)
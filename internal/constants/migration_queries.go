@@ -24,6 +24,203 @@ const (
 	CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id 
 	ON refresh_tokens(user_id);`
 
+	//nolint:gosec // G101: False positive - this is a SQL schema definition, not hardcoded credentials
+	AddRefreshTokenFamilies = `
+	ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS family_id UUID;
+
+	UPDATE refresh_tokens SET family_id = id WHERE family_id IS NULL;
+
+	ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+	CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id
+	ON refresh_tokens(family_id);`
+
+	CreateSecurityEventsTable = `
+    CREATE TABLE IF NOT EXISTS security_events (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		event_type VARCHAR(64) NOT NULL,
+		token_family_id UUID,
+		created_at TIMESTAMPTZ DEFAULT now()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_security_events_user_id
+	ON security_events(user_id);`
+
+	//nolint:gosec // G101: False positive - this is a SQL schema definition, not hardcoded credentials
+	AddRefreshTokenSessions = `
+	ALTER TABLE refresh_tokens
+		ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS ip_address VARCHAR(45) NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS device_name VARCHAR(255) NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS session_started_at TIMESTAMPTZ;
+
+	UPDATE refresh_tokens SET session_started_at = created_at WHERE session_started_at IS NULL;
+
+	ALTER TABLE refresh_tokens ALTER COLUMN session_started_at SET DEFAULT now();`
+
+	AddUserTokensValidAfter = `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMPTZ;`
+
+	//nolint:gosec // G101: False positive - this is a SQL schema definition, not hardcoded credentials
+	CreateAccessTokenDenylistTable = `
+    CREATE TABLE IF NOT EXISTS access_token_denylist (
+		jti VARCHAR(64) PRIMARY KEY,
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		expires_at TIMESTAMPTZ NOT NULL,
+		revoked_at TIMESTAMPTZ DEFAULT now()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_access_token_denylist_expires_at
+	ON access_token_denylist(expires_at);`
+
+	CreateRolesTables = `
+    CREATE TABLE IF NOT EXISTS roles (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		name VARCHAR(64) UNIQUE NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ DEFAULT now()
+	);
+
+    CREATE TABLE IF NOT EXISTS permissions (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		name VARCHAR(128) UNIQUE NOT NULL
+	);
+
+    CREATE TABLE IF NOT EXISTS role_permissions (
+		role_id UUID REFERENCES roles(id) ON DELETE CASCADE,
+		permission_id UUID REFERENCES permissions(id) ON DELETE CASCADE,
+		PRIMARY KEY (role_id, permission_id)
+	);
+
+    CREATE TABLE IF NOT EXISTS user_roles (
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		role_id UUID REFERENCES roles(id) ON DELETE CASCADE,
+		assigned_at TIMESTAMPTZ DEFAULT now(),
+		PRIMARY KEY (user_id, role_id)
+	);`
+
+	CreateOAuthTables = `
+    CREATE TABLE IF NOT EXISTS oauth_clients (
+		id VARCHAR(64) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL DEFAULT '',
+		secret_hash VARCHAR(64) NOT NULL DEFAULT '',
+		redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+		created_at TIMESTAMPTZ DEFAULT now()
+	);
+
+    CREATE TABLE IF NOT EXISTS authorization_codes (
+		code_hash VARCHAR(64) PRIMARY KEY,
+		client_id VARCHAR(64) REFERENCES oauth_clients(id) ON DELETE CASCADE,
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		redirect_uri TEXT NOT NULL,
+		code_challenge VARCHAR(128) NOT NULL,
+		code_challenge_method VARCHAR(16) NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		used_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT now()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_authorization_codes_expires_at
+	ON authorization_codes(expires_at);`
+
+	AddOAuthClientScopes = `
+    ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS scopes TEXT[] NOT NULL DEFAULT '{}';`
+
+	AddAuthorizationCodeOIDCColumns = `
+    ALTER TABLE authorization_codes ADD COLUMN IF NOT EXISTS scopes TEXT[] NOT NULL DEFAULT '{}';
+    ALTER TABLE authorization_codes ADD COLUMN IF NOT EXISTS nonce TEXT NOT NULL DEFAULT '';`
+
+	CreateDeviceAuthorizationsTable = `
+    CREATE TABLE IF NOT EXISTS device_authorizations (
+		device_code_hash VARCHAR(64) PRIMARY KEY,
+		user_code_hash VARCHAR(64) NOT NULL UNIQUE,
+		client_id VARCHAR(64) REFERENCES oauth_clients(id) ON DELETE CASCADE,
+		scopes TEXT[] NOT NULL DEFAULT '{}',
+		status VARCHAR(16) NOT NULL DEFAULT 'pending',
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		poll_interval_seconds INTEGER NOT NULL,
+		last_polled_at TIMESTAMPTZ,
+		expires_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT now()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_device_authorizations_expires_at
+	ON device_authorizations(expires_at);`
+
+	CreateLoginAttemptsTable = `
+    CREATE TABLE IF NOT EXISTS login_attempts (
+		kind VARCHAR(8) NOT NULL,
+		value VARCHAR(255) NOT NULL,
+		failed_count INTEGER NOT NULL,
+		last_failed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		locked_until TIMESTAMPTZ,
+		PRIMARY KEY (kind, value)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failed_at
+	ON login_attempts(last_failed_at);`
+
+	CreateRateLimitsTable = `
+    CREATE TABLE IF NOT EXISTS rate_limits (
+		key VARCHAR(512) PRIMARY KEY,
+		value DOUBLE PRECISION NOT NULL DEFAULT 0,
+		previous_value DOUBLE PRECISION NOT NULL DEFAULT 0,
+		updated_at TIMESTAMPTZ,
+		expires_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_rate_limits_expires_at
+	ON rate_limits(expires_at);`
+
+	CreateMFATables = `
+    CREATE TABLE IF NOT EXISTS totp_credentials (
+		user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		secret TEXT NOT NULL,
+		confirmed_at TIMESTAMPTZ,
+		last_used_step BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ DEFAULT now()
+	);
+
+    CREATE TABLE IF NOT EXISTS recovery_codes (
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		code_hash VARCHAR(64) NOT NULL,
+		used_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT now(),
+		PRIMARY KEY (user_id, code_hash)
+	);`
+
+	CreatePasskeyTables = `
+    CREATE TABLE IF NOT EXISTS passkeys (
+		id BYTEA PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(255) NOT NULL,
+		public_key BYTEA NOT NULL,
+		sign_count BIGINT NOT NULL DEFAULT 0,
+		aaguid BYTEA,
+		backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+		backed_up BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMPTZ DEFAULT now(),
+		last_used_at TIMESTAMPTZ
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_passkeys_user_id
+	ON passkeys(user_id);
+
+    CREATE TABLE IF NOT EXISTS passkey_challenges (
+		challenge_hash VARCHAR(64) PRIMARY KEY,
+		ceremony VARCHAR(16) NOT NULL,
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		expires_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT now()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_passkey_challenges_expires_at
+	ON passkey_challenges(expires_at);`
+
+	AddAuthorizationCodeTokenFamily = `
+    ALTER TABLE authorization_codes ADD COLUMN IF NOT EXISTS token_family_id UUID;`
+
 	CreateMigrationsTable = `
     CREATE TABLE IF NOT EXISTS schema_migrations (
         version VARCHAR(255) PRIMARY KEY,
@@ -0,0 +1,12 @@
+package constants
+
+// OAuth 2.0 and OpenID Connect identifiers supported by the authorization server.
+const (
+	// ResponseTypeCode is the only response type supported by the authorization endpoint.
+	ResponseTypeCode = "code"
+	// CodeChallengeMethodS256 is the only PKCE method accepted; "plain" offers no protection against code interception.
+	CodeChallengeMethodS256 = "S256"
+	// ScopeOpenID turns an authorization request into an OpenID Connect authentication request:
+	// the token endpoint then also issues an ID token.
+	ScopeOpenID = "openid"
+)
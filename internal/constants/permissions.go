@@ -0,0 +1,7 @@
+package constants
+
+// Permissions of the auth service's administrative endpoints, granted to users through their roles.
+const (
+	// PermissionUnlockLogins allows lifting the lockout of logins and IP addresses after failed logins.
+	PermissionUnlockLogins = "lockouts:unlock"
+)
@@ -0,0 +1,13 @@
+package constants
+
+type SecurityEventType string
+
+const (
+	// SecurityEventRefreshTokenReuse is recorded when an already rotated refresh token is presented again.
+	SecurityEventRefreshTokenReuse SecurityEventType = "refresh_token_reuse"
+	// SecurityEventPasskeyCloned is recorded when a passkey's signature counter goes back, which means another copy
+	// of the passkey is in use.
+	SecurityEventPasskeyCloned SecurityEventType = "passkey_cloned"
+	// SecurityEventPasskeyAdded is recorded when a user registers a passkey, a new way of signing in to the account.
+	SecurityEventPasskeyAdded SecurityEventType = "passkey_added"
+)
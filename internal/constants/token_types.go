@@ -5,4 +5,25 @@ type TokenType string
 const (
 	TokenTypeAccess  TokenType = "access"
 	TokenTypeRefresh TokenType = "refresh"
+	// TokenTypeClient is the access token of a service calling with the client credentials grant.
+	// Its subject is the client ID instead of a user.
+	TokenTypeClient TokenType = "client"
+	// TokenTypeID is the OpenID Connect ID token telling a client who signed in. It is not accepted as an access token.
+	TokenTypeID TokenType = "id"
+	// TokenTypeMFAChallenge is issued by a password login of a user with two-factor authentication enabled
+	// and exchanged together with an authentication code for the tokens of the login. It grants no access.
+	TokenTypeMFAChallenge TokenType = "mfa_challenge"
 )
+
+// BearerScheme is the token type reported to clients and expected in the Authorization header.
+const BearerScheme = "Bearer"
+
+// OAuth token type identifiers (RFC 7009, RFC 7662), used as token_type_hint and in introspection responses.
+const (
+	TokenTypeHintAccess  = "access_token"
+	TokenTypeHintRefresh = "refresh_token"
+)
+
+// TokenTypeURIAccessToken identifies access tokens in token exchange requests and responses (RFC 8693, section 3).
+// It is the only token type that can be exchanged or requested.
+const TokenTypeURIAccessToken = "urn:ietf:params:oauth:token-type:access_token"
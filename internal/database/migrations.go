@@ -20,6 +20,21 @@ func RunMigrations(db *sql.DB) error {
 	}{
 		{"001_create_users_table", constants.CreateUsersTable},
 		{"002_create_refresh_tokens_table", constants.CreateRefreshTokensTable},
+		{"003_add_refresh_token_families", constants.AddRefreshTokenFamilies},
+		{"004_create_security_events_table", constants.CreateSecurityEventsTable},
+		{"005_add_refresh_token_sessions", constants.AddRefreshTokenSessions},
+		{"006_add_user_tokens_valid_after", constants.AddUserTokensValidAfter},
+		{"007_create_access_token_denylist_table", constants.CreateAccessTokenDenylistTable},
+		{"008_create_roles_tables", constants.CreateRolesTables},
+		{"009_create_oauth_tables", constants.CreateOAuthTables},
+		{"010_add_oauth_client_scopes", constants.AddOAuthClientScopes},
+		{"011_add_authorization_code_oidc_columns", constants.AddAuthorizationCodeOIDCColumns},
+		{"012_create_device_authorizations_table", constants.CreateDeviceAuthorizationsTable},
+		{"013_create_login_attempts_table", constants.CreateLoginAttemptsTable},
+		{"014_create_rate_limits_table", constants.CreateRateLimitsTable},
+		{"015_create_mfa_tables", constants.CreateMFATables},
+		{"016_create_passkey_tables", constants.CreatePasskeyTables},
+		{"017_add_authorization_code_token_family", constants.AddAuthorizationCodeTokenFamily},
 	}
 
 	for _, migration := range migrations {
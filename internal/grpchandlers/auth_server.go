@@ -0,0 +1,259 @@
+package grpchandlers
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/validators"
+	authv1 "github.com/breakfront-planner/auth-service/pkg/api/auth/v1"
+	"github.com/breakfront-planner/auth-service/pkg/authmw"
+)
+
+// PublicMethods lists the RPCs served without an access token.
+// All other RPCs must be guarded by an authmw interceptor that puts the caller's token into the context.
+var PublicMethods = []string{
+	authv1.AuthService_Register_FullMethodName,
+	authv1.AuthService_Login_FullMethodName,
+	authv1.AuthService_VerifyMFA_FullMethodName,
+	authv1.AuthService_Refresh_FullMethodName,
+	authv1.AuthService_Logout_FullMethodName,
+	authv1.AuthService_ValidateAccessToken_FullMethodName,
+}
+
+// IAuthService defines the authentication operations exposed over gRPC.
+type IAuthService interface {
+	Register(login string, password string, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error)
+	Login(login string, password string, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error)
+	VerifyMFA(challengeTokenValue string, code string, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error)
+	Refresh(oldRefreshTokenValue string, session models.SessionMetadata) (newAccessToken, newRefreshToken *models.Token, err error)
+	Logout(refreshTokenValue string) error
+	ListSessions(userID uuid.UUID) ([]*models.Session, error)
+	RevokeSession(userID uuid.UUID, sessionID uuid.UUID) error
+	LogoutAll(userID uuid.UUID) error
+}
+
+// ITokenValidator defines the access token validation exposed over gRPC.
+type ITokenValidator interface {
+	Validate(tokenValue string, opts ...validators.ValidationOption) (*models.ParsedToken, error)
+}
+
+// AuthServer implements the AuthService gRPC API on top of the service layer.
+type AuthServer struct {
+	authv1.UnimplementedAuthServiceServer
+	authService    IAuthService
+	tokenValidator ITokenValidator
+	audience       string
+}
+
+// AuthServerOption is a function that modifies the AuthServer configuration.
+type AuthServerOption func(*AuthServer)
+
+// WithAudience makes ValidateAccessToken accept only tokens meant for the audience,
+// the name of the auth service itself among the services in the tokens' aud claim.
+func WithAudience(audience string) AuthServerOption {
+	return func(s *AuthServer) {
+		s.audience = audience
+	}
+}
+
+// NewAuthServer creates a new gRPC authentication server instance.
+func NewAuthServer(authService IAuthService, tokenValidator ITokenValidator, opts ...AuthServerOption) *AuthServer {
+	server := &AuthServer{
+		authService:    authService,
+		tokenValidator: tokenValidator,
+	}
+	for _, opt := range opts {
+		opt(server)
+	}
+	return server
+}
+
+// Register creates a new user account and returns a fresh token pair.
+func (s *AuthServer) Register(ctx context.Context, req *authv1.RegisterRequest) (*authv1.RegisterResponse, error) {
+	if req.GetLogin() == "" || req.GetPassword() == "" {
+		return nil, statusError(autherrors.ErrEmptyCredentials)
+	}
+
+	accessToken, refreshToken, err := s.authService.Register(req.GetLogin(), req.GetPassword(), sessionMetadata(ctx, req.GetDeviceName()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &authv1.RegisterResponse{TokenPair: newTokenPair(accessToken, refreshToken)}, nil
+}
+
+// Login authenticates the user and returns a fresh token pair.
+func (s *AuthServer) Login(ctx context.Context, req *authv1.LoginRequest) (*authv1.LoginResponse, error) {
+	if req.GetLogin() == "" || req.GetPassword() == "" {
+		return nil, statusError(autherrors.ErrEmptyCredentials)
+	}
+
+	accessToken, refreshToken, err := s.authService.Login(req.GetLogin(), req.GetPassword(), sessionMetadata(ctx, req.GetDeviceName()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &authv1.LoginResponse{TokenPair: newTokenPair(accessToken, refreshToken)}, nil
+}
+
+// VerifyMFA checks the authentication code against the challenge token of a login of a user
+// with two-factor authentication and returns a fresh token pair.
+func (s *AuthServer) VerifyMFA(ctx context.Context, req *authv1.VerifyMFARequest) (*authv1.VerifyMFAResponse, error) {
+	if req.GetChallengeToken() == "" {
+		return nil, statusError(autherrors.ErrEmptyChallengeToken)
+	}
+	if req.GetCode() == "" {
+		return nil, statusError(autherrors.ErrEmptyMFACode)
+	}
+
+	accessToken, refreshToken, err := s.authService.VerifyMFA(req.GetChallengeToken(), req.GetCode(), sessionMetadata(ctx, req.GetDeviceName()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &authv1.VerifyMFAResponse{TokenPair: newTokenPair(accessToken, refreshToken)}, nil
+}
+
+// Refresh rotates the provided refresh token and returns a new token pair.
+func (s *AuthServer) Refresh(ctx context.Context, req *authv1.RefreshRequest) (*authv1.RefreshResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, statusError(autherrors.ErrEmptyToken)
+	}
+
+	accessToken, refreshToken, err := s.authService.Refresh(req.GetRefreshToken(), sessionMetadata(ctx, ""))
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &authv1.RefreshResponse{TokenPair: newTokenPair(accessToken, refreshToken)}, nil
+}
+
+// Logout revokes the provided refresh token.
+func (s *AuthServer) Logout(_ context.Context, req *authv1.LogoutRequest) (*authv1.LogoutResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, statusError(autherrors.ErrEmptyToken)
+	}
+
+	if err := s.authService.Logout(req.GetRefreshToken()); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &authv1.LogoutResponse{}, nil
+}
+
+// ValidateAccessToken verifies the access token of a user and returns its claims.
+// Tokens another service obtained by token exchange to act for the user are rejected.
+func (s *AuthServer) ValidateAccessToken(_ context.Context, req *authv1.ValidateAccessTokenRequest) (*authv1.ValidateAccessTokenResponse, error) {
+	if req.GetAccessToken() == "" {
+		return nil, statusError(autherrors.ErrEmptyAccessToken)
+	}
+
+	parsedToken, err := s.tokenValidator.Validate(req.GetAccessToken(),
+		validators.WithTokenType(constants.TokenTypeAccess),
+		validators.WithAudience(s.audience),
+		validators.WithoutDelegatedTokens(),
+		validators.WithRevocationCheck(),
+		validators.WithUserExistenceCheck())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &authv1.ValidateAccessTokenResponse{
+		UserId:    parsedToken.UserID.String(),
+		TokenType: parsedToken.Type,
+		ExpiresAt: timestamppb.New(parsedToken.ExpiresAt),
+	}, nil
+}
+
+// ListSessions returns the caller's active sessions.
+func (s *AuthServer) ListSessions(ctx context.Context, _ *authv1.ListSessionsRequest) (*authv1.ListSessionsResponse, error) {
+	userID, ok := authmw.UserIDFromContext(ctx)
+	if !ok {
+		return nil, statusError(autherrors.ErrMissingAuthHeader)
+	}
+
+	sessions, err := s.authService.ListSessions(userID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &authv1.ListSessionsResponse{Sessions: make([]*authv1.Session, 0, len(sessions))}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, &authv1.Session{
+			Id:         session.ID.String(),
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			IpAddress:  session.IPAddress,
+			CreatedAt:  timestamppb.New(session.CreatedAt),
+			LastUsedAt: timestamppb.New(session.LastUsedAt),
+			ExpiresAt:  timestamppb.New(session.ExpiresAt),
+		})
+	}
+
+	return resp, nil
+}
+
+// RevokeSession logs the caller out of the given session.
+func (s *AuthServer) RevokeSession(ctx context.Context, req *authv1.RevokeSessionRequest) (*authv1.RevokeSessionResponse, error) {
+	userID, ok := authmw.UserIDFromContext(ctx)
+	if !ok {
+		return nil, statusError(autherrors.ErrMissingAuthHeader)
+	}
+
+	sessionID, err := uuid.Parse(req.GetSessionId())
+	if err != nil {
+		return nil, statusError(autherrors.ErrInvalidSessionID)
+	}
+
+	if err := s.authService.RevokeSession(userID, sessionID); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &authv1.RevokeSessionResponse{}, nil
+}
+
+// LogoutAll logs the caller out of every device and invalidates all of their tokens.
+func (s *AuthServer) LogoutAll(ctx context.Context, _ *authv1.LogoutAllRequest) (*authv1.LogoutAllResponse, error) {
+	userID, ok := authmw.UserIDFromContext(ctx)
+	if !ok {
+		return nil, statusError(autherrors.ErrMissingAuthHeader)
+	}
+
+	if err := s.authService.LogoutAll(userID); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &authv1.LogoutAllResponse{}, nil
+}
+
+// sessionMetadata describes the calling client for the session it opens or refreshes.
+func sessionMetadata(ctx context.Context, deviceName string) models.SessionMetadata {
+	session := models.SessionMetadata{DeviceName: deviceName}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if userAgent := md.Get("user-agent"); len(userAgent) > 0 {
+			session.UserAgent = userAgent[0]
+		}
+	}
+
+	session.IPAddress = clientIP(ctx)
+
+	return session
+}
+
+// newTokenPair converts issued access and refresh tokens to their protobuf representation.
+func newTokenPair(accessToken, refreshToken *models.Token) *authv1.TokenPair {
+	return &authv1.TokenPair{
+		AccessToken:           accessToken.Value,
+		AccessTokenExpiresAt:  timestamppb.New(accessToken.ExpiresAt),
+		RefreshToken:          refreshToken.Value,
+		RefreshTokenExpiresAt: timestamppb.New(refreshToken.ExpiresAt),
+		TokenType:             constants.BearerScheme,
+	}
+}
@@ -0,0 +1,649 @@
+package grpchandlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+	"github.com/breakfront-planner/auth-service/internal/totp"
+	"github.com/breakfront-planner/auth-service/internal/validators"
+	authv1 "github.com/breakfront-planner/auth-service/pkg/api/auth/v1"
+	"github.com/breakfront-planner/auth-service/pkg/authmw"
+)
+
+const bufSize = 1024 * 1024
+
+type AuthServerTestSuite struct {
+	suite.Suite
+	ctrl             *gomock.Controller
+	mockUserRepo     *mocks.MockIUserRepository
+	mockTokenRepo    *mocks.MockITokenRepository
+	mockEventRepo    *mocks.MockISecurityEventRepository
+	mockDenylistRepo *mocks.MockIDenylistRepository
+	mockRoleRepo     *mocks.MockIRoleRepository
+	mockMFARepo      *mocks.MockIMFARepository
+	userRoles        []*models.Role
+	hashService      *services.HashService
+	jwtManager       *jwt.Manager
+	grpcServer       *grpc.Server
+	conn             *grpc.ClientConn
+	client           authv1.AuthServiceClient
+	testUser         *models.User
+	testLogin        string
+	testPassword     string
+	jwtSecret        string
+	// totpCredential is the test user's authenticator; tests set it to require two-factor authentication
+	totpCredential *models.TOTPCredential
+}
+
+func (s *AuthServerTestSuite) SetupSuite() {
+	err := godotenv.Load("../../.env.test")
+	require.NoError(s.T(), err, "Failed to load .env.test")
+
+	s.testLogin = os.Getenv("TEST_LOGIN")
+	s.testPassword = os.Getenv("TEST_PASS")
+	s.jwtSecret = os.Getenv("TEST_JWT_SECRET")
+
+	require.NotEmpty(s.T(), s.testLogin, "TEST_LOGIN must be set in .env.test")
+	require.NotEmpty(s.T(), s.testPassword, "TEST_PASS must be set in .env.test")
+	require.NotEmpty(s.T(), s.jwtSecret, "TEST_JWT_SECRET must be set in .env.test")
+
+	accessDuration, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_DURATION"))
+	require.NoError(s.T(), err)
+
+	refreshDuration, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_DURATION"))
+	require.NoError(s.T(), err)
+
+	s.hashService = services.NewHashService()
+	s.jwtManager = jwt.NewManager(s.jwtSecret, accessDuration, refreshDuration)
+
+	passHash, err := s.hashService.HashPassword(s.testPassword)
+	require.NoError(s.T(), err)
+
+	s.testUser = &models.User{
+		ID:           uuid.New(),
+		Login:        s.testLogin,
+		PasswordHash: passHash,
+	}
+}
+
+func (s *AuthServerTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockUserRepo = mocks.NewMockIUserRepository(s.ctrl)
+	s.mockTokenRepo = mocks.NewMockITokenRepository(s.ctrl)
+	s.mockEventRepo = mocks.NewMockISecurityEventRepository(s.ctrl)
+	s.mockDenylistRepo = mocks.NewMockIDenylistRepository(s.ctrl)
+	s.mockRoleRepo = mocks.NewMockIRoleRepository(s.ctrl)
+	s.mockMFARepo = mocks.NewMockIMFARepository(s.ctrl)
+
+	// Token issuance loads the user's roles; tests may set s.userRoles to grant some
+	s.userRoles = nil
+	s.mockRoleRepo.EXPECT().
+		FindUserRoles(gomock.Any()).
+		DoAndReturn(func(uuid.UUID) ([]*models.Role, error) {
+			return s.userRoles, nil
+		}).
+		AnyTimes()
+
+	s.totpCredential = nil
+	s.mockMFARepo.EXPECT().
+		FindTOTP(gomock.Any()).
+		DoAndReturn(func(uuid.UUID) (*models.TOTPCredential, error) {
+			return s.totpCredential, nil
+		}).
+		AnyTimes()
+
+	userService := services.NewUserService(s.mockUserRepo, s.hashService)
+	tokenService := services.NewTokenService(s.mockTokenRepo, s.mockEventRepo, s.hashService, s.jwtManager)
+	denylist := services.NewDenylistService(s.mockDenylistRepo)
+	tokenValidator := validators.NewTokenValidator(s.jwtManager, userService, denylist)
+	mfaService := services.NewMFAService(s.mockMFARepo, userService, s.hashService, "Breakfront")
+	authService := services.NewAuthService(tokenService, userService, tokenValidator, denylist, services.NewRoleService(s.mockRoleRepo),
+		services.WithMFA(mfaService))
+
+	listener := bufconn.Listen(bufSize)
+	s.grpcServer = grpc.NewServer(
+		grpc.UnaryInterceptor(authmw.UnaryServerInterceptor(tokenValidator,
+			authmw.WithPublicMethods(PublicMethods...), authmw.WithRevocationCheck(), authmw.WithUserExistenceCheck())),
+	)
+	authv1.RegisterAuthServiceServer(s.grpcServer, NewAuthServer(authService, tokenValidator))
+	go func() {
+		_ = s.grpcServer.Serve(listener)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(s.T(), err)
+
+	s.conn = conn
+	s.client = authv1.NewAuthServiceClient(conn)
+}
+
+func (s *AuthServerTestSuite) TearDownTest() {
+	s.conn.Close()
+	s.grpcServer.Stop()
+	s.ctrl.Finish()
+}
+
+func (s *AuthServerTestSuite) assertCode(err error, code codes.Code) {
+	require.Error(s.T(), err)
+	st, ok := status.FromError(err)
+	require.True(s.T(), ok, "error should be a gRPC status")
+	assert.Equal(s.T(), code, st.Code())
+}
+
+func (s *AuthServerTestSuite) TestRegisterSuccess() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(nil, nil)
+
+	s.mockUserRepo.EXPECT().
+		CreateUser(s.testLogin, gomock.Any()).
+		Return(s.testUser, nil)
+
+	s.mockTokenRepo.EXPECT().
+		SaveToken(gomock.Any()).
+		Return(nil)
+
+	resp, err := s.client.Register(context.Background(), &authv1.RegisterRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+
+	require.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), resp.GetTokenPair().GetAccessToken())
+	assert.NotEmpty(s.T(), resp.GetTokenPair().GetRefreshToken())
+	assert.Equal(s.T(), constants.BearerScheme, resp.GetTokenPair().GetTokenType())
+	assert.True(s.T(), resp.GetTokenPair().GetRefreshTokenExpiresAt().AsTime().After(time.Now()))
+}
+
+func (s *AuthServerTestSuite) TestRegisterLoginTaken() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	_, err := s.client.Register(context.Background(), &authv1.RegisterRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+
+	s.assertCode(err, codes.AlreadyExists)
+}
+
+func (s *AuthServerTestSuite) TestRegisterEmptyCredentials() {
+	_, err := s.client.Register(context.Background(), &authv1.RegisterRequest{
+		Login: s.testLogin,
+	})
+
+	s.assertCode(err, codes.InvalidArgument)
+}
+
+func (s *AuthServerTestSuite) TestWeakPasswordStatus() {
+	err := statusError(autherrors.ErrRegisterFailed(&autherrors.PasswordPolicyError{
+		Violations: []autherrors.PasswordViolation{
+			{Rule: "min_length", Message: "must be at least 10 characters long"},
+			{Rule: "breached", Message: "appeared in a data breach and must not be used"},
+		},
+	}))
+
+	s.assertCode(err, codes.InvalidArgument)
+	st, _ := status.FromError(err)
+	assert.Equal(s.T(), autherrors.ErrWeakPassword.Error(), st.Message())
+	require.Len(s.T(), st.Details(), 1)
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(s.T(), ok, "details should be a BadRequest")
+
+	var reasons []string
+	for _, violation := range badRequest.GetFieldViolations() {
+		assert.Equal(s.T(), "password", violation.GetField())
+		assert.NotEmpty(s.T(), violation.GetDescription())
+		reasons = append(reasons, violation.GetReason())
+	}
+	assert.Equal(s.T(), []string{"min_length", "breached"}, reasons)
+}
+
+func (s *AuthServerTestSuite) TestLockoutStatus() {
+	err := statusError(&autherrors.LockoutError{Until: time.Now().Add(2 * time.Minute)})
+
+	s.assertCode(err, codes.ResourceExhausted)
+	st, _ := status.FromError(err)
+	assert.Equal(s.T(), autherrors.ErrAccountLocked.Error(), st.Message())
+	require.Len(s.T(), st.Details(), 1)
+	retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(s.T(), ok, "details should be a RetryInfo")
+	assert.Equal(s.T(), 2*time.Minute, retryInfo.GetRetryDelay().AsDuration())
+}
+
+func (s *AuthServerTestSuite) TestRegisterStorageError() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(nil, errors.New("database error"))
+
+	_, err := s.client.Register(context.Background(), &authv1.RegisterRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+
+	s.assertCode(err, codes.Internal)
+	assert.NotContains(s.T(), err.Error(), "database error")
+}
+
+func (s *AuthServerTestSuite) TestLoginSuccess() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		Times(2)
+
+	s.mockTokenRepo.EXPECT().
+		SaveToken(gomock.Any()).
+		Return(nil)
+
+	resp, err := s.client.Login(context.Background(), &authv1.LoginRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+
+	require.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), resp.GetTokenPair().GetAccessToken())
+}
+
+func (s *AuthServerTestSuite) TestLoginWrongPassword() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	_, err := s.client.Login(context.Background(), &authv1.LoginRequest{
+		Login:    s.testLogin,
+		Password: "wrongpassword",
+	})
+
+	s.assertCode(err, codes.Unauthenticated)
+}
+
+func (s *AuthServerTestSuite) TestLoginWithMFA() {
+	secret, err := totp.GenerateSecret()
+	require.NoError(s.T(), err)
+	confirmedAt := time.Now().Add(-time.Hour)
+	s.totpCredential = &models.TOTPCredential{UserID: s.testUser.ID, Secret: secret, ConfirmedAt: &confirmedAt}
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		AnyTimes()
+
+	_, err = s.client.Login(context.Background(), &authv1.LoginRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+
+	s.assertCode(err, codes.Unauthenticated)
+	st, _ := status.FromError(err)
+	assert.Equal(s.T(), autherrors.ErrMFARequired.Error(), st.Message())
+	require.Len(s.T(), st.Details(), 1)
+	errorInfo, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(s.T(), ok, "details should be an ErrorInfo")
+	assert.Equal(s.T(), "MFA_REQUIRED", errorInfo.GetReason())
+	challengeToken := errorInfo.GetMetadata()["challenge_token"]
+	require.NotEmpty(s.T(), challengeToken)
+
+	s.mockDenylistRepo.EXPECT().IsDenylisted(gomock.Any()).Return(false, nil)
+	s.mockMFARepo.EXPECT().UseTOTPStep(s.testUser.ID, gomock.Any()).Return(true, nil)
+	s.mockDenylistRepo.EXPECT().SaveEntry(gomock.Any()).Return(nil)
+	s.mockDenylistRepo.EXPECT().DeleteExpiredEntries().Return(nil)
+	s.mockTokenRepo.EXPECT().SaveToken(gomock.Any()).Return(nil)
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(s.T(), err)
+
+	resp, err := s.client.VerifyMFA(context.Background(), &authv1.VerifyMFARequest{
+		ChallengeToken: challengeToken,
+		Code:           code,
+	})
+
+	require.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), resp.GetTokenPair().GetAccessToken())
+	assert.NotEmpty(s.T(), resp.GetTokenPair().GetRefreshToken())
+}
+
+func (s *AuthServerTestSuite) TestVerifyMFAErrors() {
+	_, err := s.client.VerifyMFA(context.Background(), &authv1.VerifyMFARequest{Code: "123456"})
+	s.assertCode(err, codes.InvalidArgument)
+
+	_, err = s.client.VerifyMFA(context.Background(), &authv1.VerifyMFARequest{ChallengeToken: "challenge"})
+	s.assertCode(err, codes.InvalidArgument)
+
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+	_, err = s.client.VerifyMFA(context.Background(), &authv1.VerifyMFARequest{
+		ChallengeToken: accessToken.Value,
+		Code:           "123456",
+	})
+	s.assertCode(err, codes.Unauthenticated)
+}
+
+func (s *AuthServerTestSuite) TestRefreshSuccess() {
+	oldRefreshToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	s.mockTokenRepo.EXPECT().
+		RotateToken(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	resp, err := s.client.Refresh(context.Background(), &authv1.RefreshRequest{
+		RefreshToken: oldRefreshToken.Value,
+	})
+
+	require.NoError(s.T(), err)
+	assert.NotEqual(s.T(), oldRefreshToken.Value, resp.GetTokenPair().GetRefreshToken())
+}
+
+func (s *AuthServerTestSuite) TestRefreshRevokedToken() {
+	refreshToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	s.mockTokenRepo.EXPECT().
+		RotateToken(gomock.Any(), gomock.Any()).
+		Return(autherrors.ErrInvalidToken(errors.New("revoked")))
+
+	_, err = s.client.Refresh(context.Background(), &authv1.RefreshRequest{
+		RefreshToken: refreshToken.Value,
+	})
+
+	s.assertCode(err, codes.Unauthenticated)
+}
+
+func (s *AuthServerTestSuite) TestLogoutSuccess() {
+	refreshToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	s.mockTokenRepo.EXPECT().
+		FindToken(gomock.Any()).
+		Return(nil)
+
+	s.mockTokenRepo.EXPECT().
+		RevokeToken(gomock.Any()).
+		Return(nil)
+
+	_, err = s.client.Logout(context.Background(), &authv1.LogoutRequest{
+		RefreshToken: refreshToken.Value,
+	})
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *AuthServerTestSuite) TestLogoutEmptyToken() {
+	_, err := s.client.Logout(context.Background(), &authv1.LogoutRequest{})
+
+	s.assertCode(err, codes.InvalidArgument)
+}
+
+func (s *AuthServerTestSuite) TestValidateAccessTokenSuccess() {
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, nil)
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	resp, err := s.client.ValidateAccessToken(context.Background(), &authv1.ValidateAccessTokenRequest{
+		AccessToken: accessToken.Value,
+	})
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.testUser.ID.String(), resp.GetUserId())
+	assert.Equal(s.T(), string(constants.TokenTypeAccess), resp.GetTokenType())
+	assert.Equal(s.T(), accessToken.ExpiresAt.Unix(), resp.GetExpiresAt().AsTime().Unix())
+}
+
+func (s *AuthServerTestSuite) TestValidateAccessTokenDelegated() {
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+	subject, err := s.jwtManager.ParseToken(accessToken.Value)
+	require.NoError(s.T(), err)
+	delegated, err := s.jwtManager.GenerateExchangedToken(subject, "notifications", nil, &models.Actor{Subject: "scheduler"})
+	require.NoError(s.T(), err)
+
+	_, err = s.client.ValidateAccessToken(context.Background(), &authv1.ValidateAccessTokenRequest{
+		AccessToken: delegated.Value,
+	})
+
+	s.assertCode(err, codes.Unauthenticated)
+}
+
+func (s *AuthServerTestSuite) TestValidateAccessTokenRevoked() {
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(true, nil)
+
+	_, err = s.client.ValidateAccessToken(context.Background(), &authv1.ValidateAccessTokenRequest{
+		AccessToken: accessToken.Value,
+	})
+
+	s.assertCode(err, codes.Unauthenticated)
+}
+
+func (s *AuthServerTestSuite) TestValidateAccessTokenInvalid() {
+	refreshToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+
+	expiredManager := jwt.NewManager(s.jwtSecret, -time.Hour, -time.Hour)
+	expiredToken, err := expiredManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	testCases := []struct {
+		name  string
+		token string
+		code  codes.Code
+	}{
+		{
+			name:  "empty token",
+			token: "",
+			code:  codes.InvalidArgument,
+		},
+		{
+			name:  "malformed token",
+			token: "invalid.jwt.token",
+			code:  codes.Unauthenticated,
+		},
+		{
+			name:  "refresh token instead of access",
+			token: refreshToken.Value,
+			code:  codes.Unauthenticated,
+		},
+		{
+			name:  "expired token",
+			token: expiredToken.Value,
+			code:  codes.Unauthenticated,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			_, err := s.client.ValidateAccessToken(context.Background(), &authv1.ValidateAccessTokenRequest{
+				AccessToken: tc.token,
+			})
+
+			s.assertCode(err, tc.code)
+		})
+	}
+}
+
+func (s *AuthServerTestSuite) authContext() context.Context {
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, nil).
+		AnyTimes()
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		AnyTimes()
+
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+accessToken.Value)
+}
+
+func (s *AuthServerTestSuite) TestLoginRecordsSession() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		Times(2)
+
+	s.mockTokenRepo.EXPECT().
+		SaveToken(gomock.Any()).
+		DoAndReturn(func(token *models.Token) error {
+			assert.Equal(s.T(), "Tablet", token.Session.DeviceName)
+			assert.Contains(s.T(), token.Session.UserAgent, "grpc-go")
+			assert.NotEmpty(s.T(), token.Session.IPAddress)
+			return nil
+		})
+
+	_, err := s.client.Login(context.Background(), &authv1.LoginRequest{
+		Login:      s.testUser.Login,
+		Password:   s.testPassword,
+		DeviceName: "Tablet",
+	})
+
+	require.NoError(s.T(), err)
+}
+
+func (s *AuthServerTestSuite) TestListSessions() {
+	token := &models.Token{
+		UserID:           s.testUser.ID,
+		FamilyID:         uuid.New(),
+		Session:          models.SessionMetadata{UserAgent: "planner-ios/2.1", DeviceName: "iPhone"},
+		CreatedAt:        time.Now().UTC(),
+		SessionStartedAt: time.Now().UTC().Add(-time.Hour),
+		ExpiresAt:        time.Now().UTC().Add(time.Hour),
+	}
+
+	s.mockTokenRepo.EXPECT().
+		FindActiveTokens(s.testUser.ID).
+		Return([]*models.Token{token}, nil)
+
+	resp, err := s.client.ListSessions(s.authContext(), &authv1.ListSessionsRequest{})
+
+	require.NoError(s.T(), err)
+	require.Len(s.T(), resp.GetSessions(), 1)
+	assert.Equal(s.T(), token.FamilyID.String(), resp.GetSessions()[0].GetId())
+	assert.Equal(s.T(), "iPhone", resp.GetSessions()[0].GetDeviceName())
+}
+
+func (s *AuthServerTestSuite) TestListSessionsUnauthenticated() {
+	_, err := s.client.ListSessions(context.Background(), &authv1.ListSessionsRequest{})
+
+	s.assertCode(err, codes.Unauthenticated)
+}
+
+func (s *AuthServerTestSuite) TestRevokeSession() {
+	sessionID := uuid.New()
+
+	s.mockTokenRepo.EXPECT().
+		RevokeSession(s.testUser.ID, sessionID).
+		Return(nil)
+
+	_, err := s.client.RevokeSession(s.authContext(), &authv1.RevokeSessionRequest{SessionId: sessionID.String()})
+	require.NoError(s.T(), err)
+
+	s.mockTokenRepo.EXPECT().
+		RevokeSession(s.testUser.ID, gomock.Any()).
+		Return(autherrors.ErrSessionNotFound)
+
+	_, err = s.client.RevokeSession(s.authContext(), &authv1.RevokeSessionRequest{SessionId: uuid.NewString()})
+	s.assertCode(err, codes.NotFound)
+
+	_, err = s.client.RevokeSession(s.authContext(), &authv1.RevokeSessionRequest{SessionId: "not-a-uuid"})
+	s.assertCode(err, codes.InvalidArgument)
+}
+
+func (s *AuthServerTestSuite) TestLogoutAll() {
+	s.mockUserRepo.EXPECT().
+		InvalidateTokens(s.testUser.ID, gomock.Any()).
+		Return(nil)
+
+	s.mockTokenRepo.EXPECT().
+		RevokeAllUserTokens(s.testUser.ID).
+		Return(nil)
+
+	_, err := s.client.LogoutAll(s.authContext(), &authv1.LogoutAllRequest{})
+
+	require.NoError(s.T(), err)
+}
+
+func (s *AuthServerTestSuite) TestLogoutAllUnauthenticated() {
+	_, err := s.client.LogoutAll(context.Background(), &authv1.LogoutAllRequest{})
+
+	s.assertCode(err, codes.Unauthenticated)
+}
+
+func (s *AuthServerTestSuite) TestValidateAccessTokenIssuedBeforeLogoutAll() {
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	validAfter := time.Now().UTC().Add(time.Second)
+	signedOutUser := *s.testUser
+	signedOutUser.TokensValidAfter = &validAfter
+
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, nil)
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(&signedOutUser, nil)
+
+	_, err = s.client.ValidateAccessToken(context.Background(), &authv1.ValidateAccessTokenRequest{AccessToken: accessToken.Value})
+
+	s.assertCode(err, codes.Unauthenticated)
+}
+
+func TestAuthServerTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthServerTestSuite))
+}
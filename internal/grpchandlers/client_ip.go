@@ -0,0 +1,51 @@
+package grpchandlers
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/breakfront-planner/auth-service/internal/clientip"
+)
+
+// clientIPKey is the context key of the client IP address resolved by UnaryClientIPInterceptor.
+type clientIPKey struct{}
+
+// UnaryClientIPInterceptor returns a gRPC unary interceptor that determines the client IP address of each request
+// with the resolver, honoring x-forwarded-for metadata only from trusted proxies, for rate limiting and sessions.
+// It must come before the rate limit interceptor in the chain.
+func UnaryClientIPInterceptor(resolver *clientip.Resolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		p, ok := peer.FromContext(ctx)
+		if !ok || p.Addr == nil {
+			return handler(ctx, req)
+		}
+
+		forwardedFor := metadata.ValueFromIncomingContext(ctx, strings.ToLower(clientip.ForwardedForHeader))
+		ip := resolver.Resolve(p.Addr.String(), forwardedFor)
+
+		return handler(context.WithValue(ctx, clientIPKey{}, ip), req)
+	}
+}
+
+// clientIP returns the IP address the request came from: the one resolved by UnaryClientIPInterceptor,
+// or the address of the peer without it.
+func clientIP(ctx context.Context) string {
+	if ip, ok := ctx.Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	ip, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return ip
+}
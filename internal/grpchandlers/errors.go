@@ -0,0 +1,176 @@
+package grpchandlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+const (
+	msgInvalidCredentials = "invalid login or password"
+	msgInvalidToken       = "invalid or expired token"
+	msgForbidden          = "insufficient permissions"
+	msgInternalError      = "internal server error"
+)
+
+// errorDomain and reasonMFARequired identify the ErrorInfo details of logins that need a second factor.
+const (
+	errorDomain       = "auth.breakfront"
+	reasonMFARequired = "MFA_REQUIRED"
+)
+
+// statusError maps service layer errors to a gRPC status with a client-safe message.
+// Unknown errors are reported as Internal without exposing details.
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, autherrors.ErrEmptyCredentials),
+		errors.Is(err, autherrors.ErrEmptyToken),
+		errors.Is(err, autherrors.ErrEmptyAccessToken),
+		errors.Is(err, autherrors.ErrInvalidSessionID),
+		errors.Is(err, autherrors.ErrEmptyChallengeToken),
+		errors.Is(err, autherrors.ErrEmptyMFACode):
+		return status.Error(codes.InvalidArgument, err.Error())
+
+	case errors.Is(err, autherrors.ErrMFANotEnabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+
+	case errors.Is(err, autherrors.ErrWeakPassword):
+		return weakPasswordStatus(err)
+
+	case errors.Is(err, autherrors.ErrSessionNotFound):
+		return status.Error(codes.NotFound, autherrors.ErrSessionNotFound.Error())
+
+	case errors.Is(err, autherrors.ErrMissingAuthHeader):
+		return status.Error(codes.Unauthenticated, err.Error())
+
+	case errors.Is(err, autherrors.ErrMissingRole),
+		errors.Is(err, autherrors.ErrMissingPermission):
+		return status.Error(codes.PermissionDenied, msgForbidden)
+
+	case errors.Is(err, autherrors.ErrLoginTaken):
+		return status.Error(codes.AlreadyExists, autherrors.ErrLoginTaken.Error())
+
+	case errors.Is(err, autherrors.ErrPasswordMismatch),
+		errors.Is(err, autherrors.ErrUserNotExist):
+		return status.Error(codes.Unauthenticated, msgInvalidCredentials)
+
+	case errors.Is(err, autherrors.ErrMFARequired):
+		return mfaRequiredStatus(err)
+
+	case errors.Is(err, autherrors.ErrInvalidMFACode):
+		return status.Error(codes.Unauthenticated, autherrors.ErrInvalidMFACode.Error())
+
+	case errors.Is(err, autherrors.ErrAccountLocked):
+		return lockoutStatus(err)
+
+	case errors.Is(err, autherrors.ErrRateLimited):
+		return rateLimitStatus(err)
+
+	case errors.Is(err, autherrors.ErrTokenExpired),
+		errors.Is(err, autherrors.ErrTokenType),
+		errors.Is(err, autherrors.ErrTokenIssuer),
+		errors.Is(err, autherrors.ErrTokenAudience),
+		errors.Is(err, autherrors.ErrTokenDelegated),
+		errors.Is(err, autherrors.ErrTokenParseFailed),
+		errors.Is(err, autherrors.ErrTokenInvalid),
+		errors.Is(err, autherrors.ErrTokenRevoked):
+		return status.Error(codes.Unauthenticated, msgInvalidToken)
+
+	default:
+		log.Printf("internal error: %v", err)
+		return status.Error(codes.Internal, msgInternalError)
+	}
+}
+
+// weakPasswordStatus reports a password not meeting the password policy as InvalidArgument,
+// with every rule it fails as a field violation of the password in the BadRequest details.
+func weakPasswordStatus(err error) error {
+	st := status.New(codes.InvalidArgument, autherrors.ErrWeakPassword.Error())
+
+	var policyErr *autherrors.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return st.Err()
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, violation := range policyErr.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       "password",
+			Reason:      violation.Rule,
+			Description: violation.Message,
+		})
+	}
+
+	detailed, detailsErr := st.WithDetails(badRequest)
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// mfaRequiredStatus reports the login of a user with two-factor authentication as Unauthenticated,
+// with an ErrorInfo of reason MFA_REQUIRED whose metadata holds the challenge token for VerifyMFA.
+func mfaRequiredStatus(err error) error {
+	st := status.New(codes.Unauthenticated, autherrors.ErrMFARequired.Error())
+
+	var mfaErr *autherrors.MFARequiredError
+	if !errors.As(err, &mfaErr) || mfaErr.ChallengeToken == nil {
+		return st.Err()
+	}
+
+	detailed, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: reasonMFARequired,
+		Domain: errorDomain,
+		Metadata: map[string]string{
+			"challenge_token":            mfaErr.ChallengeToken.Value,
+			"challenge_token_expires_at": mfaErr.ChallengeToken.ExpiresAt.UTC().Format(time.RFC3339),
+		},
+	})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// lockoutStatus reports a locked login as ResourceExhausted, with the time until the lock ends
+// as the retry delay in the RetryInfo details.
+func lockoutStatus(err error) error {
+	st := status.New(codes.ResourceExhausted, autherrors.ErrAccountLocked.Error())
+
+	var lockoutErr *autherrors.LockoutError
+	if !errors.As(err, &lockoutErr) {
+		return st.Err()
+	}
+	return withRetryDelay(st, time.Until(lockoutErr.Until))
+}
+
+// rateLimitStatus reports a rate limited request as ResourceExhausted, with the time until it would be allowed
+// as the retry delay in the RetryInfo details.
+func rateLimitStatus(err error) error {
+	st := status.New(codes.ResourceExhausted, autherrors.ErrRateLimited.Error())
+
+	var rateLimitErr *autherrors.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		return st.Err()
+	}
+	return withRetryDelay(st, rateLimitErr.RetryAfter)
+}
+
+// withRetryDelay adds the delay, rounded up to whole seconds, as RetryInfo details to the status.
+func withRetryDelay(st *status.Status, delay time.Duration) error {
+	delay = max(time.Duration(math.Ceil(delay.Seconds()))*time.Second, time.Second)
+
+	detailed, detailsErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,69 @@
+package grpchandlers
+
+import (
+	"context"
+	"log"
+
+	"google.golang.org/grpc"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/ratelimit"
+)
+
+// IRateLimiter defines the rate limiting of requests by key.
+type IRateLimiter interface {
+	Allow(key string) (ratelimit.Result, error)
+}
+
+// RateLimitKeyFunc derives the key a request is rate limited by. Requests without a key are not limited.
+type RateLimitKeyFunc func(ctx context.Context, req any) string
+
+// RateLimitRule limits the requests of an RPC by the key derived from them.
+type RateLimitRule struct {
+	Limiter IRateLimiter
+	Key     RateLimitKeyFunc
+}
+
+// UnaryRateLimitInterceptor returns a gRPC unary interceptor that rejects requests exceeding any of the rules
+// of their RPC, keyed by full method name, with ResourceExhausted and the time until the request would be allowed
+// in the RetryInfo details. Requests are let through if a limiter fails, like in the HTTP middleware.
+func UnaryRateLimitInterceptor(rules map[string][]RateLimitRule) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var limited *autherrors.RateLimitError
+
+		for _, rule := range rules[info.FullMethod] {
+			key := rule.Key(ctx, req)
+			if key == "" {
+				continue
+			}
+
+			result, err := rule.Limiter.Allow(key)
+			if err != nil {
+				log.Printf("rate limiting failed: %v", err)
+				continue
+			}
+			if !result.Allowed && (limited == nil || result.RetryAfter > limited.RetryAfter) {
+				limited = &autherrors.RateLimitError{RetryAfter: result.RetryAfter}
+			}
+		}
+
+		if limited != nil {
+			return nil, statusError(limited)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitByIP keys requests by the client's IP address.
+func RateLimitByIP(ctx context.Context, _ any) string {
+	return sessionMetadata(ctx, "").IPAddress
+}
+
+// RateLimitByLogin keys requests by their login, for RPCs whose requests have one.
+func RateLimitByLogin(_ context.Context, req any) string {
+	if withLogin, ok := req.(interface{ GetLogin() string }); ok {
+		return withLogin.GetLogin()
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package grpchandlers
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/clientip"
+	"github.com/breakfront-planner/auth-service/internal/ratelimit"
+	authv1 "github.com/breakfront-planner/auth-service/pkg/api/auth/v1"
+)
+
+type RateLimitTestSuite struct {
+	suite.Suite
+	interceptor grpc.UnaryServerInterceptor
+	ctx         context.Context
+	calls       int
+}
+
+func (s *RateLimitTestSuite) SetupTest() {
+	rate := ratelimit.Rate{Limit: 1, Period: time.Minute}
+	ipLimiter := ratelimit.NewLimiter("ip", ratelimit.NewTokenBucket(ratelimit.Rate{Limit: 2, Period: time.Minute}),
+		ratelimit.NewMemoryStore())
+	loginLimiter := ratelimit.NewLimiter("login", ratelimit.NewSlidingWindow(rate), ratelimit.NewMemoryStore())
+
+	s.interceptor = UnaryRateLimitInterceptor(map[string][]RateLimitRule{
+		authv1.AuthService_Login_FullMethodName: {
+			{Limiter: ipLimiter, Key: RateLimitByIP},
+			{Limiter: loginLimiter, Key: RateLimitByLogin},
+		},
+	})
+	s.ctx = peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 52113},
+	})
+	s.calls = 0
+}
+
+// call sends the request of the RPC through the interceptor.
+func (s *RateLimitTestSuite) call(method string, req any) error {
+	_, err := s.interceptor(s.ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, any) (any, error) {
+		s.calls++
+		return nil, nil
+	})
+	return err
+}
+
+func (s *RateLimitTestSuite) TestLimitsByLogin() {
+	require.NoError(s.T(), s.call(authv1.AuthService_Login_FullMethodName, &authv1.LoginRequest{Login: "alice"}))
+
+	err := s.call(authv1.AuthService_Login_FullMethodName, &authv1.LoginRequest{Login: "alice"})
+
+	st, ok := status.FromError(err)
+	require.True(s.T(), ok)
+	assert.Equal(s.T(), codes.ResourceExhausted, st.Code())
+	assert.Equal(s.T(), autherrors.ErrRateLimited.Error(), st.Message())
+	require.Len(s.T(), st.Details(), 1)
+	retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(s.T(), ok, "details should be a RetryInfo")
+	// A window of one request frees up once the previous window no longer overlaps
+	assert.GreaterOrEqual(s.T(), retryInfo.GetRetryDelay().AsDuration(), time.Minute)
+	assert.LessOrEqual(s.T(), retryInfo.GetRetryDelay().AsDuration(), 2*time.Minute)
+	assert.Equal(s.T(), 1, s.calls)
+}
+
+func (s *RateLimitTestSuite) TestLimitsByIP() {
+	require.NoError(s.T(), s.call(authv1.AuthService_Login_FullMethodName, &authv1.LoginRequest{Login: "alice"}))
+	require.NoError(s.T(), s.call(authv1.AuthService_Login_FullMethodName, &authv1.LoginRequest{Login: "bob"}))
+
+	err := s.call(authv1.AuthService_Login_FullMethodName, &authv1.LoginRequest{Login: "carol"})
+
+	assert.Equal(s.T(), codes.ResourceExhausted, status.Code(err))
+}
+
+func (s *RateLimitTestSuite) TestIPFromTrustedProxies() {
+	resolveIP := UnaryClientIPInterceptor(clientip.NewResolver([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}))
+	md := metadata.Pairs(strings.ToLower(clientip.ForwardedForHeader), "198.51.100.23")
+
+	testCases := []struct {
+		name string
+		peer net.IP
+		want string
+	}{
+		{name: "trusted proxy", peer: net.ParseIP("10.1.2.3"), want: "198.51.100.23"},
+		{name: "untrusted peer", peer: net.ParseIP("203.0.113.7"), want: "203.0.113.7"},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			ctx := peer.NewContext(metadata.NewIncomingContext(context.Background(), md),
+				&peer.Peer{Addr: &net.TCPAddr{IP: tc.peer, Port: 443}})
+			var key string
+
+			_, err := resolveIP(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+				key = RateLimitByIP(ctx, req)
+				return nil, nil
+			})
+
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), tc.want, key)
+		})
+	}
+}
+
+func (s *RateLimitTestSuite) TestOtherMethodsAreNotLimited() {
+	for range 3 {
+		require.NoError(s.T(), s.call(authv1.AuthService_Refresh_FullMethodName, &authv1.RefreshRequest{}))
+	}
+
+	assert.Equal(s.T(), 3, s.calls)
+}
+
+func TestRateLimitTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitTestSuite))
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/pkg/authmw"
+)
+
+// IAuthService defines the authentication operations exposed over HTTP.
+type IAuthService interface {
+	Register(login string, password string, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error)
+	Login(login string, password string, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error)
+	VerifyMFA(challengeTokenValue string, code string, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error)
+	Refresh(oldRefreshTokenValue string, session models.SessionMetadata) (newAccessToken, newRefreshToken *models.Token, err error)
+	RefreshForClient(oldRefreshTokenValue string, clientID string, session models.SessionMetadata) (newAccessToken, newRefreshToken *models.Token, err error)
+	Logout(refreshTokenValue string) error
+	ListSessions(userID uuid.UUID) ([]*models.Session, error)
+	RevokeSession(userID uuid.UUID, sessionID uuid.UUID) error
+	LogoutAll(userID uuid.UUID) error
+	Introspect(tokenValue string) (*models.TokenIntrospection, error)
+	Revoke(tokenValue string, clientID string) error
+}
+
+// AuthHandler serves the authentication HTTP endpoints.
+type AuthHandler struct {
+	authService IAuthService
+}
+
+// NewAuthHandler creates a new authentication handler instance.
+func NewAuthHandler(authService IAuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+// Register creates a new user account and responds with a fresh token pair.
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req CredentialsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	accessToken, refreshToken, err := h.authService.Register(req.Login, req.Password, sessionMetadata(r, req.DeviceName))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, NewTokenPairResponse(accessToken, refreshToken))
+}
+
+// Login authenticates the user and responds with a fresh token pair.
+// Users with two-factor authentication get a challenge token instead, see VerifyMFA.
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req CredentialsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	accessToken, refreshToken, err := h.authService.Login(req.Login, req.Password, sessionMetadata(r, req.DeviceName))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewTokenPairResponse(accessToken, refreshToken))
+}
+
+// VerifyMFA finishes the login of a user with two-factor authentication: it checks the code against
+// the challenge token of the login and responds with a fresh token pair.
+func (h *AuthHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req MFAVerifyRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	accessToken, refreshToken, err := h.authService.VerifyMFA(req.ChallengeToken, req.Code, sessionMetadata(r, req.DeviceName))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewTokenPairResponse(accessToken, refreshToken))
+}
+
+// Refresh rotates the provided refresh token and responds with a new token pair.
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshTokenRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	accessToken, refreshToken, err := h.authService.Refresh(req.RefreshToken, sessionMetadata(r, ""))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewTokenPairResponse(accessToken, refreshToken))
+}
+
+// Logout revokes the provided refresh token.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req RefreshTokenRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.authService.Logout(req.RefreshToken); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListSessions responds with the active sessions of the authenticated user.
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewSessionsResponse(sessions))
+}
+
+// RevokeSession logs the authenticated user out of the session given in the path.
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	sessionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, autherrors.ErrInvalidSessionID)
+		return
+	}
+
+	if err := h.authService.RevokeSession(userID, sessionID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll logs the authenticated user out of every device and invalidates all of their tokens.
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	if err := h.authService.LogoutAll(userID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sessionMetadata describes the client of the request for the session it opens or refreshes.
+func sessionMetadata(r *http.Request, deviceName string) models.SessionMetadata {
+	return models.SessionMetadata{
+		UserAgent:  r.UserAgent(),
+		IPAddress:  clientIP(r),
+		DeviceName: deviceName,
+	}
+}
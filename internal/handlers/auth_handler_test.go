@@ -0,0 +1,890 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/password"
+	"github.com/breakfront-planner/auth-service/internal/ratelimit"
+	"github.com/breakfront-planner/auth-service/internal/services"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+	"github.com/breakfront-planner/auth-service/internal/totp"
+	"github.com/breakfront-planner/auth-service/internal/validators"
+)
+
+type AuthHandlerTestSuite struct {
+	suite.Suite
+	ctrl             *gomock.Controller
+	mockUserRepo     *mocks.MockIUserRepository
+	mockTokenRepo    *mocks.MockITokenRepository
+	mockEventRepo    *mocks.MockISecurityEventRepository
+	mockDenylistRepo *mocks.MockIDenylistRepository
+	mockRoleRepo     *mocks.MockIRoleRepository
+	userRoles        []*models.Role
+	hashService      *services.HashService
+	jwtManager       *jwt.Manager
+	router           http.Handler
+	testUser         *models.User
+	testLogin        string
+	testPassword     string
+	jwtSecret        string
+}
+
+func (s *AuthHandlerTestSuite) SetupSuite() {
+	err := godotenv.Load("../../.env.test")
+	require.NoError(s.T(), err, "Failed to load .env.test")
+
+	s.testLogin = os.Getenv("TEST_LOGIN")
+	s.testPassword = os.Getenv("TEST_PASS")
+	s.jwtSecret = os.Getenv("TEST_JWT_SECRET")
+
+	require.NotEmpty(s.T(), s.testLogin, "TEST_LOGIN must be set in .env.test")
+	require.NotEmpty(s.T(), s.testPassword, "TEST_PASS must be set in .env.test")
+	require.NotEmpty(s.T(), s.jwtSecret, "TEST_JWT_SECRET must be set in .env.test")
+
+	accessDuration, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_DURATION"))
+	require.NoError(s.T(), err)
+
+	refreshDuration, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_DURATION"))
+	require.NoError(s.T(), err)
+
+	s.hashService = services.NewHashService()
+	s.jwtManager = jwt.NewManager(s.jwtSecret, accessDuration, refreshDuration)
+
+	passHash, err := s.hashService.HashPassword(s.testPassword)
+	require.NoError(s.T(), err)
+
+	s.testUser = &models.User{
+		ID:           uuid.New(),
+		Login:        s.testLogin,
+		PasswordHash: passHash,
+	}
+}
+
+func (s *AuthHandlerTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockUserRepo = mocks.NewMockIUserRepository(s.ctrl)
+	s.mockTokenRepo = mocks.NewMockITokenRepository(s.ctrl)
+	s.mockEventRepo = mocks.NewMockISecurityEventRepository(s.ctrl)
+	s.mockDenylistRepo = mocks.NewMockIDenylistRepository(s.ctrl)
+	s.mockRoleRepo = mocks.NewMockIRoleRepository(s.ctrl)
+
+	// Token issuance loads the user's roles; tests may set s.userRoles to grant some
+	s.userRoles = nil
+	s.mockRoleRepo.EXPECT().
+		FindUserRoles(gomock.Any()).
+		DoAndReturn(func(uuid.UUID) ([]*models.Role, error) {
+			return s.userRoles, nil
+		}).
+		AnyTimes()
+
+	s.router = s.newRouter(nil, nil)
+}
+
+// newRouter creates the router of the auth endpoints with the suite's mocks.
+func (s *AuthHandlerTestSuite) newRouter(userOpts []services.UserServiceOption, authOpts []services.AuthServiceOption,
+	routerOpts ...RouterOption) http.Handler {
+	userService := services.NewUserService(s.mockUserRepo, s.hashService, userOpts...)
+	tokenService := services.NewTokenService(s.mockTokenRepo, s.mockEventRepo, s.hashService, s.jwtManager)
+	denylist := services.NewDenylistService(s.mockDenylistRepo)
+	tokenValidator := validators.NewTokenValidator(s.jwtManager, userService, denylist)
+	authService := services.NewAuthService(tokenService, userService, tokenValidator, denylist, services.NewRoleService(s.mockRoleRepo),
+		authOpts...)
+
+	return NewRouter(NewAuthHandler(authService), NewJWKSHandler(s.jwtManager.KeySet()), NewOAuthHandler(authService, nil, nil, nil, nil), nil, nil, nil, nil, tokenValidator,
+		routerOpts...)
+}
+
+func (s *AuthHandlerTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *AuthHandlerTestSuite) doRequest(method, path string, body any) *httptest.ResponseRecorder {
+	return s.doRequestWithHeaders(method, path, body, nil)
+}
+
+func (s *AuthHandlerTestSuite) doRequestWithHeaders(method, path string, body any, headers map[string]string) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		require.NoError(s.T(), json.NewEncoder(&payload).Encode(body))
+	}
+
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func (s *AuthHandlerTestSuite) decodeTokenPair(rec *httptest.ResponseRecorder) TokenPairResponse {
+	var resp TokenPairResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	return resp
+}
+
+func (s *AuthHandlerTestSuite) decodeError(rec *httptest.ResponseRecorder) ErrorResponse {
+	var resp ErrorResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	return resp
+}
+
+func (s *AuthHandlerTestSuite) TestRegisterSuccess() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(nil, nil)
+
+	s.mockUserRepo.EXPECT().
+		CreateUser(s.testLogin, gomock.Any()).
+		Return(s.testUser, nil)
+
+	s.mockTokenRepo.EXPECT().
+		SaveToken(gomock.Any()).
+		Return(nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/register", CredentialsRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+
+	assert.Equal(s.T(), http.StatusCreated, rec.Code)
+	assert.Equal(s.T(), "application/json", rec.Header().Get("Content-Type"))
+
+	resp := s.decodeTokenPair(rec)
+	assert.NotEmpty(s.T(), resp.AccessToken)
+	assert.NotEmpty(s.T(), resp.RefreshToken)
+	assert.Equal(s.T(), "Bearer", resp.TokenType)
+
+	parsed, err := s.jwtManager.ParseToken(resp.AccessToken)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.testUser.ID, parsed.UserID)
+}
+
+func (s *AuthHandlerTestSuite) TestRegisterLoginTaken() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/register", CredentialsRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+
+	assert.Equal(s.T(), http.StatusConflict, rec.Code)
+	assert.Equal(s.T(), autherrors.ErrLoginTaken.Error(), s.decodeError(rec).Error)
+}
+
+func (s *AuthHandlerTestSuite) TestRegisterBadRequest() {
+	testCases := []struct {
+		name string
+		body any
+	}{
+		{
+			name: "empty password",
+			body: CredentialsRequest{Login: s.testLogin},
+		},
+		{
+			name: "empty login",
+			body: CredentialsRequest{Password: s.testPassword},
+		},
+		{
+			name: "unknown field",
+			body: map[string]string{"username": s.testLogin, "password": s.testPassword},
+		},
+		{
+			name: "not a JSON object",
+			body: "plain string",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			rec := s.doRequest(http.MethodPost, "/auth/register", tc.body)
+
+			assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+			assert.NotEmpty(s.T(), s.decodeError(rec).Error)
+		})
+	}
+}
+
+func (s *AuthHandlerTestSuite) TestRegisterWeakPassword() {
+	s.router = s.newRouter([]services.UserServiceOption{
+		services.WithPasswordPolicy(password.NewPolicy(password.WithMinCharacterClasses(2))),
+	}, nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/register", CredentialsRequest{
+		Login:    s.testLogin,
+		Password: s.testLogin,
+	})
+
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	resp := s.decodeError(rec)
+	assert.Equal(s.T(), autherrors.ErrWeakPassword.Error(), resp.Error)
+
+	rules := make([]string, 0, len(resp.Violations))
+	for _, violation := range resp.Violations {
+		assert.NotEmpty(s.T(), violation.Message)
+		rules = append(rules, violation.Rule)
+	}
+	assert.Equal(s.T(), []string{password.RuleMinLength, password.RuleCharacterClasses, password.RuleNotLogin,
+		password.RuleStrength}, rules)
+}
+
+func (s *AuthHandlerTestSuite) TestRegisterStorageError() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(nil, errors.New("database error"))
+
+	rec := s.doRequest(http.MethodPost, "/auth/register", CredentialsRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+
+	assert.Equal(s.T(), http.StatusInternalServerError, rec.Code)
+	assert.Equal(s.T(), msgInternalError, s.decodeError(rec).Error)
+}
+
+func (s *AuthHandlerTestSuite) TestLoginSuccess() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		Times(2)
+
+	s.mockTokenRepo.EXPECT().
+		SaveToken(gomock.Any()).
+		Return(nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/login", CredentialsRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+
+	resp := s.decodeTokenPair(rec)
+	assert.NotEmpty(s.T(), resp.AccessToken)
+	assert.NotEmpty(s.T(), resp.RefreshToken)
+}
+
+func (s *AuthHandlerTestSuite) TestLoginEmbedsRoles() {
+	user := *s.testUser
+	s.userRoles = []*models.Role{
+		{Name: "editor", Permissions: []string{"plans:read", "plans:write"}},
+		{Name: "viewer", Permissions: []string{"plans:read"}},
+	}
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(&user, nil).
+		Times(2)
+
+	s.mockTokenRepo.EXPECT().
+		SaveToken(gomock.Any()).
+		Return(nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/login", CredentialsRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+
+	resp := s.decodeTokenPair(rec)
+	parsedToken, err := s.jwtManager.ParseToken(resp.AccessToken)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"editor", "viewer"}, parsedToken.Roles)
+	assert.Equal(s.T(), []string{"plans:read", "plans:write"}, parsedToken.Permissions)
+
+	parsedToken, err = s.jwtManager.ParseToken(resp.RefreshToken)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), parsedToken.Roles, "refresh tokens carry no authorization data")
+}
+
+func (s *AuthHandlerTestSuite) TestLoginRecordsSession() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		Times(2)
+
+	s.mockTokenRepo.EXPECT().
+		SaveToken(gomock.Any()).
+		DoAndReturn(func(token *models.Token) error {
+			assert.Equal(s.T(), models.SessionMetadata{
+				UserAgent:  "planner-web/1.4",
+				IPAddress:  "192.0.2.1",
+				DeviceName: "Office desktop",
+			}, token.Session)
+			return nil
+		})
+
+	rec := s.doRequestWithHeaders(http.MethodPost, "/auth/login", CredentialsRequest{
+		Login:      s.testLogin,
+		Password:   s.testPassword,
+		DeviceName: "Office desktop",
+	}, map[string]string{"User-Agent": "planner-web/1.4"})
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+}
+
+func (s *AuthHandlerTestSuite) TestLoginInvalidCredentials() {
+	testCases := []struct {
+		name     string
+		user     *models.User
+		password string
+	}{
+		{
+			name:     "wrong password",
+			user:     s.testUser,
+			password: "wrongpassword",
+		},
+		{
+			name:     "unknown login",
+			user:     nil,
+			password: s.testPassword,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.mockUserRepo.EXPECT().
+				FindUser(gomock.Any()).
+				Return(tc.user, nil)
+
+			rec := s.doRequest(http.MethodPost, "/auth/login", CredentialsRequest{
+				Login:    s.testLogin,
+				Password: tc.password,
+			})
+
+			assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+			assert.Equal(s.T(), msgInvalidCredentials, s.decodeError(rec).Error)
+		})
+	}
+}
+
+func (s *AuthHandlerTestSuite) TestLoginLocked() {
+	attemptRepo := mocks.NewMockILoginAttemptRepository(s.ctrl)
+	lockout := services.NewLockoutService(attemptRepo, services.DefaultLockoutPolicy)
+	s.router = s.newRouter(nil, []services.AuthServiceOption{services.WithLockout(lockout)})
+
+	lockedUntil := time.Now().Add(90 * time.Second)
+	attemptRepo.EXPECT().
+		FindAttempts(models.LoginAttemptKey{Kind: models.LoginAttemptLogin, Value: s.testLogin}).
+		Return(&models.LoginAttempts{FailedCount: 5, LockedUntil: &lockedUntil}, nil)
+	attemptRepo.EXPECT().
+		FindAttempts(gomock.Any()).
+		Return(nil, nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/login", CredentialsRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+
+	assert.Equal(s.T(), http.StatusTooManyRequests, rec.Code)
+	assert.Equal(s.T(), "90", rec.Header().Get("Retry-After"))
+	assert.Equal(s.T(), autherrors.ErrAccountLocked.Error(), s.decodeError(rec).Error)
+}
+
+func (s *AuthHandlerTestSuite) TestLoginFailureIsCounted() {
+	attemptRepo := mocks.NewMockILoginAttemptRepository(s.ctrl)
+	lockout := services.NewLockoutService(attemptRepo, services.DefaultLockoutPolicy)
+	s.router = s.newRouter(nil, []services.AuthServiceOption{services.WithLockout(lockout)})
+
+	attemptRepo.EXPECT().FindAttempts(gomock.Any()).Return(nil, nil).Times(2)
+	s.mockUserRepo.EXPECT().FindUser(gomock.Any()).Return(s.testUser, nil)
+	attemptRepo.EXPECT().
+		RecordFailure(models.LoginAttemptKey{Kind: models.LoginAttemptLogin, Value: s.testLogin}, gomock.Any(), gomock.Any()).
+		Return(&models.LoginAttempts{FailedCount: 1}, nil)
+	attemptRepo.EXPECT().
+		RecordFailure(models.LoginAttemptKey{Kind: models.LoginAttemptIPAddress, Value: "192.0.2.1"}, gomock.Any(), gomock.Any()).
+		Return(&models.LoginAttempts{FailedCount: 1}, nil)
+	attemptRepo.EXPECT().DeleteStaleAttempts(gomock.Any()).Return(nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/login", CredentialsRequest{
+		Login:    s.testLogin,
+		Password: "wrongpassword",
+	})
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Empty(s.T(), rec.Header().Get("Retry-After"))
+}
+
+func (s *AuthHandlerTestSuite) TestLoginRateLimited() {
+	limiter := ratelimit.NewLimiter("login", ratelimit.NewSlidingWindow(ratelimit.Rate{Limit: 1, Period: time.Hour}),
+		ratelimit.NewMemoryStore())
+	s.router = s.newRouter(nil, nil,
+		WithRateLimit("POST /auth/login", RateLimitRule{Limiter: limiter, Key: RateLimitByLogin}))
+
+	// The first attempt reaches the handler with its body intact
+	s.mockUserRepo.EXPECT().FindUser(gomock.Any()).Return(s.testUser, nil)
+	rec := s.doRequest(http.MethodPost, "/auth/login", CredentialsRequest{
+		Login:    s.testLogin,
+		Password: "wrongpassword",
+	})
+	require.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+
+	rec = s.doRequest(http.MethodPost, "/auth/login", CredentialsRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+
+	assert.Equal(s.T(), http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(s.T(), rec.Header().Get("Retry-After"))
+	assert.Equal(s.T(), autherrors.ErrRateLimited.Error(), s.decodeError(rec).Error)
+}
+
+// withMFA rebuilds the router with two-factor authentication enabled for the test user
+// and returns the secret of the user's authenticator.
+func (s *AuthHandlerTestSuite) withMFA() (*mocks.MockIMFARepository, string) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(s.T(), err)
+
+	confirmedAt := time.Now().Add(-time.Hour)
+	mfaRepo := mocks.NewMockIMFARepository(s.ctrl)
+	mfaRepo.EXPECT().
+		FindTOTP(s.testUser.ID).
+		Return(&models.TOTPCredential{UserID: s.testUser.ID, Secret: secret, ConfirmedAt: &confirmedAt}, nil).
+		AnyTimes()
+
+	mfaService := services.NewMFAService(mfaRepo, services.NewUserService(s.mockUserRepo, s.hashService), s.hashService,
+		"Breakfront")
+	s.router = s.newRouter(nil, []services.AuthServiceOption{services.WithMFA(mfaService)})
+
+	return mfaRepo, secret
+}
+
+// loginChallenge logs the test user in with two-factor authentication enabled and returns the challenge token.
+func (s *AuthHandlerTestSuite) loginChallenge() string {
+	rec := s.doRequest(http.MethodPost, "/auth/login", CredentialsRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+	require.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+
+	resp := s.decodeError(rec)
+	require.NotEmpty(s.T(), resp.ChallengeToken)
+	return resp.ChallengeToken
+}
+
+func (s *AuthHandlerTestSuite) TestLoginWithMFAReturnsChallenge() {
+	s.withMFA()
+	s.mockUserRepo.EXPECT().FindUser(gomock.Any()).Return(s.testUser, nil).Times(2)
+
+	rec := s.doRequest(http.MethodPost, "/auth/login", CredentialsRequest{
+		Login:    s.testLogin,
+		Password: s.testPassword,
+	})
+
+	require.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	resp := s.decodeError(rec)
+	assert.Equal(s.T(), autherrors.ErrMFARequired.Error(), resp.Error)
+	require.NotNil(s.T(), resp.ChallengeTokenExpiresAt)
+	assert.True(s.T(), resp.ChallengeTokenExpiresAt.After(time.Now()))
+
+	parsedToken, err := s.jwtManager.ParseToken(resp.ChallengeToken)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), string(constants.TokenTypeMFAChallenge), parsedToken.Type)
+}
+
+func (s *AuthHandlerTestSuite) TestVerifyMFASuccess() {
+	mfaRepo, secret := s.withMFA()
+	s.mockUserRepo.EXPECT().FindUser(gomock.Any()).Return(s.testUser, nil).AnyTimes()
+	challengeToken := s.loginChallenge()
+
+	s.mockDenylistRepo.EXPECT().IsDenylisted(gomock.Any()).Return(false, nil)
+	mfaRepo.EXPECT().UseTOTPStep(s.testUser.ID, gomock.Any()).Return(true, nil)
+	s.mockDenylistRepo.EXPECT().SaveEntry(gomock.Any()).Return(nil)
+	s.mockDenylistRepo.EXPECT().DeleteExpiredEntries().Return(nil)
+	s.mockTokenRepo.EXPECT().SaveToken(gomock.Any()).Return(nil)
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(s.T(), err)
+
+	rec := s.doRequest(http.MethodPost, "/auth/mfa/verify", MFAVerifyRequest{ChallengeToken: challengeToken, Code: code})
+
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	resp := s.decodeTokenPair(rec)
+	assert.NotEmpty(s.T(), resp.AccessToken)
+	assert.NotEmpty(s.T(), resp.RefreshToken)
+}
+
+func (s *AuthHandlerTestSuite) TestVerifyMFAWrongCode() {
+	mfaRepo, _ := s.withMFA()
+	s.mockUserRepo.EXPECT().FindUser(gomock.Any()).Return(s.testUser, nil).AnyTimes()
+	challengeToken := s.loginChallenge()
+
+	s.mockDenylistRepo.EXPECT().IsDenylisted(gomock.Any()).Return(false, nil)
+	mfaRepo.EXPECT().UseRecoveryCode(s.testUser.ID, gomock.Any()).Return(false, nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/mfa/verify", MFAVerifyRequest{ChallengeToken: challengeToken, Code: "ABCDE-FGHJK"})
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Equal(s.T(), autherrors.ErrInvalidMFACode.Error(), s.decodeError(rec).Error)
+}
+
+func (s *AuthHandlerTestSuite) TestVerifyMFARejectsOtherTokens() {
+	s.withMFA()
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	rec := s.doRequest(http.MethodPost, "/auth/mfa/verify", MFAVerifyRequest{ChallengeToken: accessToken.Value, Code: "123456"})
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Equal(s.T(), msgInvalidToken, s.decodeError(rec).Error)
+}
+
+func (s *AuthHandlerTestSuite) TestVerifyMFABadRequest() {
+	testCases := []struct {
+		name string
+		body MFAVerifyRequest
+		err  error
+	}{
+		{name: "missing challenge token", body: MFAVerifyRequest{Code: "123456"}, err: autherrors.ErrEmptyChallengeToken},
+		{name: "missing code", body: MFAVerifyRequest{ChallengeToken: "challenge"}, err: autherrors.ErrEmptyMFACode},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			rec := s.doRequest(http.MethodPost, "/auth/mfa/verify", tc.body)
+
+			assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+			assert.Equal(s.T(), tc.err.Error(), s.decodeError(rec).Error)
+		})
+	}
+}
+
+func (s *AuthHandlerTestSuite) TestRefreshSuccess() {
+	oldRefreshToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	s.mockTokenRepo.EXPECT().
+		RotateToken(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(oldToken, newToken *models.Token) error {
+			assert.Equal(s.T(), s.hashService.HashToken(oldRefreshToken.Value), oldToken.HashedValue)
+			assert.Equal(s.T(), s.hashService.HashToken(newToken.Value), newToken.HashedValue)
+			return nil
+		})
+
+	rec := s.doRequest(http.MethodPost, "/auth/refresh", RefreshTokenRequest{
+		RefreshToken: oldRefreshToken.Value,
+	})
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+
+	resp := s.decodeTokenPair(rec)
+	assert.NotEmpty(s.T(), resp.AccessToken)
+	assert.NotEqual(s.T(), oldRefreshToken.Value, resp.RefreshToken)
+}
+
+func (s *AuthHandlerTestSuite) TestRefreshInvalidToken() {
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	expiredManager := jwt.NewManager(s.jwtSecret, -time.Hour, -time.Hour)
+	expiredToken, err := expiredManager.GenerateToken(s.testUser, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+
+	testCases := []struct {
+		name  string
+		token string
+	}{
+		{
+			name:  "malformed token",
+			token: "invalid.jwt.token",
+		},
+		{
+			name:  "access token instead of refresh",
+			token: accessToken.Value,
+		},
+		{
+			name:  "expired token",
+			token: expiredToken.Value,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			rec := s.doRequest(http.MethodPost, "/auth/refresh", RefreshTokenRequest{
+				RefreshToken: tc.token,
+			})
+
+			assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+			assert.Equal(s.T(), msgInvalidToken, s.decodeError(rec).Error)
+		})
+	}
+}
+
+func (s *AuthHandlerTestSuite) TestRefreshRevokedToken() {
+	refreshToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	s.mockTokenRepo.EXPECT().
+		RotateToken(gomock.Any(), gomock.Any()).
+		Return(autherrors.ErrInvalidToken(errors.New("revoked")))
+
+	rec := s.doRequest(http.MethodPost, "/auth/refresh", RefreshTokenRequest{
+		RefreshToken: refreshToken.Value,
+	})
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Equal(s.T(), msgInvalidToken, s.decodeError(rec).Error)
+}
+
+func (s *AuthHandlerTestSuite) TestRefreshReusedTokenRevokesFamily() {
+	refreshToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+	familyID := uuid.New()
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	s.mockTokenRepo.EXPECT().
+		RotateToken(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(oldToken, newToken *models.Token) error {
+			oldToken.FamilyID = familyID
+			return autherrors.ErrInvalidToken(autherrors.ErrTokenRevoked)
+		})
+
+	s.mockTokenRepo.EXPECT().
+		RevokeTokenFamily(familyID).
+		Return(nil)
+
+	s.mockEventRepo.EXPECT().
+		SaveEvent(gomock.Any()).
+		Return(nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/refresh", RefreshTokenRequest{
+		RefreshToken: refreshToken.Value,
+	})
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Equal(s.T(), msgInvalidToken, s.decodeError(rec).Error)
+}
+
+func (s *AuthHandlerTestSuite) TestLogoutSuccess() {
+	refreshToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	s.mockTokenRepo.EXPECT().
+		FindToken(gomock.Any()).
+		Return(nil)
+
+	s.mockTokenRepo.EXPECT().
+		RevokeToken(gomock.Any()).
+		Return(nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/logout", RefreshTokenRequest{
+		RefreshToken: refreshToken.Value,
+	})
+
+	assert.Equal(s.T(), http.StatusNoContent, rec.Code)
+	assert.Empty(s.T(), rec.Body.String())
+}
+
+func (s *AuthHandlerTestSuite) TestLogoutMissingToken() {
+	rec := s.doRequest(http.MethodPost, "/auth/logout", RefreshTokenRequest{})
+
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Equal(s.T(), autherrors.ErrEmptyToken.Error(), s.decodeError(rec).Error)
+}
+
+// authHeaders returns an Authorization header for the test user and lets the middleware look the user up.
+func (s *AuthHandlerTestSuite) authHeaders() map[string]string {
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, nil).
+		AnyTimes()
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		AnyTimes()
+
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+	return map[string]string{"Authorization": "Bearer " + accessToken.Value}
+}
+
+func (s *AuthHandlerTestSuite) TestListSessions() {
+	token := &models.Token{
+		UserID:           s.testUser.ID,
+		FamilyID:         uuid.New(),
+		Session:          models.SessionMetadata{UserAgent: "planner-ios/2.1", IPAddress: "203.0.113.7", DeviceName: "iPhone"},
+		CreatedAt:        time.Now().UTC().Add(-time.Hour),
+		SessionStartedAt: time.Now().UTC().Add(-72 * time.Hour),
+		ExpiresAt:        time.Now().UTC().Add(47 * time.Hour),
+	}
+
+	s.mockTokenRepo.EXPECT().
+		FindActiveTokens(s.testUser.ID).
+		Return([]*models.Token{token}, nil)
+
+	rec := s.doRequestWithHeaders(http.MethodGet, "/auth/sessions", nil, s.authHeaders())
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+
+	var resp SessionsResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(s.T(), resp.Sessions, 1)
+	assert.Equal(s.T(), token.FamilyID.String(), resp.Sessions[0].ID)
+	assert.Equal(s.T(), "iPhone", resp.Sessions[0].DeviceName)
+	assert.Equal(s.T(), "203.0.113.7", resp.Sessions[0].IPAddress)
+	assert.True(s.T(), token.SessionStartedAt.Equal(resp.Sessions[0].CreatedAt))
+	assert.True(s.T(), token.CreatedAt.Equal(resp.Sessions[0].LastUsedAt))
+}
+
+func (s *AuthHandlerTestSuite) TestSessionsRequireAccessToken() {
+	refreshToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+
+	rec := s.doRequest(http.MethodGet, "/auth/sessions", nil)
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+
+	rec = s.doRequestWithHeaders(http.MethodDelete, "/auth/sessions/"+uuid.NewString(), nil,
+		map[string]string{"Authorization": "Bearer " + refreshToken.Value})
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code, "refresh tokens must not authorize session management")
+}
+
+func (s *AuthHandlerTestSuite) TestSessionsRejectDelegatedToken() {
+	s.authHeaders()
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+	subject, err := s.jwtManager.ParseToken(accessToken.Value)
+	require.NoError(s.T(), err)
+	delegated, err := s.jwtManager.GenerateExchangedToken(subject, "notifications", nil, &models.Actor{Subject: "scheduler"})
+	require.NoError(s.T(), err)
+
+	rec := s.doRequestWithHeaders(http.MethodGet, "/auth/sessions", nil,
+		map[string]string{"Authorization": "Bearer " + delegated.Value})
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+}
+
+func (s *AuthHandlerTestSuite) TestSessionsRequireOwnAudience() {
+	s.router = s.newRouter(nil, nil, WithAudience("auth-service"))
+
+	rec := s.doRequestWithHeaders(http.MethodGet, "/auth/sessions", nil, s.authHeaders())
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code, "tokens issued for other services must be rejected")
+}
+
+func (s *AuthHandlerTestSuite) TestRevokeSession() {
+	sessionID := uuid.New()
+
+	s.mockTokenRepo.EXPECT().
+		RevokeSession(s.testUser.ID, sessionID).
+		Return(nil)
+
+	rec := s.doRequestWithHeaders(http.MethodDelete, "/auth/sessions/"+sessionID.String(), nil, s.authHeaders())
+
+	assert.Equal(s.T(), http.StatusNoContent, rec.Code)
+}
+
+func (s *AuthHandlerTestSuite) TestRevokeSessionErrors() {
+	s.mockTokenRepo.EXPECT().
+		RevokeSession(s.testUser.ID, gomock.Any()).
+		Return(autherrors.ErrSessionNotFound)
+
+	rec := s.doRequestWithHeaders(http.MethodDelete, "/auth/sessions/"+uuid.NewString(), nil, s.authHeaders())
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code)
+	assert.Equal(s.T(), autherrors.ErrSessionNotFound.Error(), s.decodeError(rec).Error)
+
+	rec = s.doRequestWithHeaders(http.MethodDelete, "/auth/sessions/not-a-uuid", nil, s.authHeaders())
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+}
+
+func (s *AuthHandlerTestSuite) TestLogoutAll() {
+	var validAfter time.Time
+
+	s.mockUserRepo.EXPECT().
+		InvalidateTokens(s.testUser.ID, gomock.Any()).
+		DoAndReturn(func(_ uuid.UUID, cutoff time.Time) error {
+			validAfter = cutoff
+			return nil
+		})
+
+	s.mockTokenRepo.EXPECT().
+		RevokeAllUserTokens(s.testUser.ID).
+		Return(nil)
+
+	rec := s.doRequestWithHeaders(http.MethodPost, "/auth/logout-all", nil, s.authHeaders())
+
+	assert.Equal(s.T(), http.StatusNoContent, rec.Code)
+	assert.True(s.T(), validAfter.After(time.Now().UTC()), "the cutoff must cover tokens issued within the current second")
+}
+
+func (s *AuthHandlerTestSuite) TestLogoutAllRejectsEarlierAccessTokens() {
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	validAfter := time.Now().UTC().Add(time.Second)
+	signedOutUser := *s.testUser
+	signedOutUser.TokensValidAfter = &validAfter
+
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, nil)
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(&signedOutUser, nil)
+
+	rec := s.doRequestWithHeaders(http.MethodGet, "/auth/sessions", nil,
+		map[string]string{"Authorization": "Bearer " + accessToken.Value})
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+}
+
+func (s *AuthHandlerTestSuite) TestRevokedAccessTokenRejected() {
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(true, nil)
+
+	rec := s.doRequestWithHeaders(http.MethodGet, "/auth/sessions", nil,
+		map[string]string{"Authorization": "Bearer " + accessToken.Value})
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+}
+
+func (s *AuthHandlerTestSuite) TestMethodNotAllowed() {
+	rec := s.doRequest(http.MethodGet, "/auth/login", nil)
+
+	assert.Equal(s.T(), http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestAuthHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthHandlerTestSuite))
+}
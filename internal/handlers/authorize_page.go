@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// authorizePage is the sign-in form of the authorization endpoint.
+// The authorization request is carried through the form in hidden fields.
+// Users with two-factor authentication or a passkey get the form a second time asking for a code,
+// with the challenge token of their correct password in a hidden field. If they have passkeys, a button
+// instead signs with one of them and submits the credential in another hidden field.
+var authorizePage = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Sign in to Breakfront</title>
+</head>
+<body>
+<main>
+<h1>Sign in to continue to {{.ClientName}}</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="post" action="/oauth/authorize">
+<input type="hidden" name="response_type" value="{{.Request.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.Request.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+<input type="hidden" name="state" value="{{.Request.State}}">
+<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+<input type="hidden" name="scope" value="{{.Request.Scope}}">
+<input type="hidden" name="nonce" value="{{.Request.Nonce}}">
+{{if .MFAToken}}<input type="hidden" name="mfa_token" value="{{.MFAToken}}">
+<label>Authentication code <input name="code" inputmode="numeric" autocomplete="one-time-code" required></label>
+<p>Enter the code shown in your authenticator app, or one of your recovery codes.</p>
+{{with .PasskeyOptions}}<input type="hidden" name="passkey_credential">
+<button type="button" id="use-passkey">Use a passkey instead</button>
+<script>
+document.getElementById("use-passkey").addEventListener("click", async (event) => {
+  const form = event.target.form;
+  const options = PublicKeyCredential.parseRequestOptionsFromJSON({{.}});
+  const credential = await navigator.credentials.get({publicKey: options});
+  form.elements.passkey_credential.value = JSON.stringify(credential.toJSON());
+  form.submit();
+});
+</script>
+{{end}}{{else}}<label>Login <input name="login" autocomplete="username" required></label>
+<label>Password <input type="password" name="password" autocomplete="current-password" required></label>
+{{end}}<button type="submit">Sign in</button>
+</form>
+</main>
+</body>
+</html>
+`))
+
+// authorizeErrorPage is shown instead of redirecting when the client or its redirect URI can't be trusted.
+var authorizeErrorPage = template.Must(template.New("authorize-error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Sign-in failed</title>
+</head>
+<body>
+<main>
+<h1>This sign-in request is invalid</h1>
+<p>{{.}}</p>
+</main>
+</body>
+</html>
+`))
+
+// authorizePageData is the data rendered by authorizePage.
+type authorizePageData struct {
+	ClientName string
+	Request    *models.AuthorizationRequest
+	Error      string
+	// MFAToken is the challenge token of the second sign-in step; the first step asks for the password.
+	MFAToken string
+	// PasskeyOptions are offered in the second step to users with passkeys.
+	PasskeyOptions *PasskeyRequestOptionsResponse
+}
+
+// writeHTML renders the page with the given status code.
+// Pages of the authorization endpoint must not be cached or framed by other sites.
+func writeHTML(w http.ResponseWriter, status int, page *template.Template, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	w.WriteHeader(status)
+
+	if err := page.Execute(w, data); err != nil {
+		log.Printf("failed to write page: %v", err)
+	}
+}
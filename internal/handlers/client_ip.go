@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/breakfront-planner/auth-service/internal/clientip"
+)
+
+// clientIPKey is the context key of the client IP address resolved by ResolveClientIP.
+type clientIPKey struct{}
+
+// ResolveClientIP returns middleware that determines the client IP address of each request with the resolver,
+// honoring X-Forwarded-For only from trusted proxies, for rate limiting, lockouts and sessions.
+func ResolveClientIP(resolver *clientip.Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolver.Resolve(r.RemoteAddr, r.Header.Values(clientip.ForwardedForHeader))
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip)))
+		})
+	}
+}
+
+// clientIP returns the IP address the request came from: the one resolved by ResolveClientIP,
+// or the address of the peer without it.
+func clientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
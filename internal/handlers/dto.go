@@ -0,0 +1,691 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// CredentialsRequest is the request body for register and login endpoints.
+// DeviceName is an optional, user-facing name for the session being opened.
+type CredentialsRequest struct {
+	Login      string `json:"login"`
+	Password   string `json:"password"`
+	DeviceName string `json:"device_name,omitempty"`
+}
+
+// Validate checks that both login and password are present.
+func (r *CredentialsRequest) Validate() error {
+	if r.Login == "" || r.Password == "" {
+		return autherrors.ErrEmptyCredentials
+	}
+	return nil
+}
+
+// RefreshTokenRequest is the request body for refresh and logout endpoints.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Validate checks that the refresh token is present.
+func (r *RefreshTokenRequest) Validate() error {
+	if r.RefreshToken == "" {
+		return autherrors.ErrEmptyToken
+	}
+	return nil
+}
+
+// TokenPairResponse is the response body returned when a new token pair is issued.
+type TokenPairResponse struct {
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	TokenType             string    `json:"token_type"`
+}
+
+// NewTokenPairResponse builds a TokenPairResponse from issued access and refresh tokens.
+func NewTokenPairResponse(accessToken, refreshToken *models.Token) *TokenPairResponse {
+	return &TokenPairResponse{
+		AccessToken:           accessToken.Value,
+		AccessTokenExpiresAt:  accessToken.ExpiresAt,
+		RefreshToken:          refreshToken.Value,
+		RefreshTokenExpiresAt: refreshToken.ExpiresAt,
+		TokenType:             constants.BearerScheme,
+	}
+}
+
+// SessionResponse describes one active session of the user.
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// SessionsResponse is the response body of the session listing endpoint.
+type SessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
+
+// NewSessionsResponse builds a SessionsResponse from the user's active sessions.
+func NewSessionsResponse(sessions []*models.Session) *SessionsResponse {
+	resp := &SessionsResponse{Sessions: make([]SessionResponse, 0, len(sessions))}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, SessionResponse{
+			ID:         session.ID.String(),
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+		})
+	}
+	return resp
+}
+
+// MFAVerifyRequest is the request body finishing the login of a user with two-factor authentication.
+// Code is a code of the user's authenticator app or one of the user's recovery codes.
+type MFAVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	DeviceName     string `json:"device_name,omitempty"`
+}
+
+// Validate checks that both the challenge token and the code are present.
+func (r *MFAVerifyRequest) Validate() error {
+	if r.ChallengeToken == "" {
+		return autherrors.ErrEmptyChallengeToken
+	}
+	if r.Code == "" {
+		return autherrors.ErrEmptyMFACode
+	}
+	return nil
+}
+
+// MFACodeRequest is the request body of the two-factor authentication settings that require a current code.
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+// Validate checks that the code is present.
+func (r *MFACodeRequest) Validate() error {
+	if r.Code == "" {
+		return autherrors.ErrEmptyMFACode
+	}
+	return nil
+}
+
+// MFAStatusResponse describes the two-factor authentication settings of the user.
+type MFAStatusResponse struct {
+	TOTPEnabled            bool `json:"totp_enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// NewMFAStatusResponse builds an MFAStatusResponse from the user's MFA status.
+func NewMFAStatusResponse(status *models.MFAStatus) *MFAStatusResponse {
+	return &MFAStatusResponse{
+		TOTPEnabled:            status.TOTPEnabled,
+		RecoveryCodesRemaining: status.RecoveryCodesRemaining,
+	}
+}
+
+// TOTPEnrollmentResponse is the response body of a started authenticator app enrollment.
+// The provisioning URI is usually shown as a QR code; the secret can be typed in instead.
+type TOTPEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// NewTOTPEnrollmentResponse builds a TOTPEnrollmentResponse from the started enrollment.
+func NewTOTPEnrollmentResponse(enrollment *models.TOTPEnrollment) *TOTPEnrollmentResponse {
+	return &TOTPEnrollmentResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	}
+}
+
+// RecoveryCodesResponse is the response body listing newly issued recovery codes.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// Base64URL is binary data encoded as unpadded base64url in JSON, as WebAuthn encodes credential IDs,
+// challenges and authenticator responses.
+type Base64URL []byte
+
+// MarshalJSON encodes the data as an unpadded base64url string.
+func (b Base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON decodes a base64url string; padding is tolerated.
+func (b *Base64URL) UnmarshalJSON(data []byte) error {
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// PasskeyCreationOptionsResponse is the response body starting a passkey registration. It follows
+// the PublicKeyCredentialCreationOptionsJSON of WebAuthn Level 3, so browsers can pass it
+// to PublicKeyCredential.parseCreationOptionsFromJSON and then to navigator.credentials.create.
+type PasskeyCreationOptionsResponse struct {
+	RP                     RelyingPartyEntity          `json:"rp"`
+	User                   UserEntity                  `json:"user"`
+	Challenge              Base64URL                   `json:"challenge"`
+	PubKeyCredParams       []CredentialParameter       `json:"pubKeyCredParams"`
+	Timeout                int64                       `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor      `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelectionEntry `json:"authenticatorSelection"`
+	Attestation            string                      `json:"attestation"`
+}
+
+// RelyingPartyEntity names the service the passkey is created for.
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity describes the user a passkey is created for; ID is the user handle returned at login.
+type UserEntity struct {
+	ID          Base64URL `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+}
+
+// CredentialParameter is a COSE algorithm accepted for the passkey's public key.
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// CredentialDescriptor identifies a passkey.
+type CredentialDescriptor struct {
+	Type string    `json:"type"`
+	ID   Base64URL `json:"id"`
+}
+
+// AuthenticatorSelectionEntry asks for a discoverable passkey, which can be used without entering the login.
+type AuthenticatorSelectionEntry struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// NewPasskeyCreationOptionsResponse builds a PasskeyCreationOptionsResponse from the registration options.
+// Attestation statements are not asked for, as authenticators are not checked against a trust list.
+func NewPasskeyCreationOptionsResponse(options *models.PasskeyCreationOptions) *PasskeyCreationOptionsResponse {
+	params := make([]CredentialParameter, 0, len(options.Algorithms))
+	for _, alg := range options.Algorithms {
+		params = append(params, CredentialParameter{Type: publicKeyCredentialType, Alg: alg})
+	}
+	return &PasskeyCreationOptionsResponse{
+		RP:                 RelyingPartyEntity{ID: options.RPID, Name: options.RPName},
+		User:               UserEntity{ID: options.UserHandle, Name: options.UserName, DisplayName: options.UserName},
+		Challenge:          options.Challenge,
+		PubKeyCredParams:   params,
+		Timeout:            options.Timeout.Milliseconds(),
+		ExcludeCredentials: newCredentialDescriptors(options.ExcludeCredentials),
+		AuthenticatorSelection: AuthenticatorSelectionEntry{
+			ResidentKey:        "required",
+			RequireResidentKey: true,
+			UserVerification:   "preferred",
+		},
+		Attestation: "none",
+	}
+}
+
+// PasskeyRequestOptionsResponse is the response body starting a passkey login or second factor. It follows
+// the PublicKeyCredentialRequestOptionsJSON of WebAuthn Level 3, so browsers can pass it
+// to PublicKeyCredential.parseRequestOptionsFromJSON and then to navigator.credentials.get.
+type PasskeyRequestOptionsResponse struct {
+	Challenge        Base64URL              `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// NewPasskeyRequestOptionsResponse builds a PasskeyRequestOptionsResponse from the authentication options.
+func NewPasskeyRequestOptionsResponse(options *models.PasskeyRequestOptions) *PasskeyRequestOptionsResponse {
+	return &PasskeyRequestOptionsResponse{
+		Challenge:        options.Challenge,
+		Timeout:          options.Timeout.Milliseconds(),
+		RPID:             options.RPID,
+		AllowCredentials: newCredentialDescriptors(options.AllowCredentials),
+		UserVerification: options.UserVerification,
+	}
+}
+
+// newCredentialDescriptors converts credential IDs to descriptors of public key credentials.
+func newCredentialDescriptors(ids [][]byte) []CredentialDescriptor {
+	descriptors := make([]CredentialDescriptor, 0, len(ids))
+	for _, id := range ids {
+		descriptors = append(descriptors, CredentialDescriptor{Type: publicKeyCredentialType, ID: id})
+	}
+	return descriptors
+}
+
+// RegistrationCredential is the passkey created by the browser, as serialized by PublicKeyCredential.toJSON.
+type RegistrationCredential struct {
+	ID       string    `json:"id"`
+	RawID    Base64URL `json:"rawId"`
+	Type     string    `json:"type"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON"`
+		AttestationObject Base64URL `json:"attestationObject"`
+	} `json:"response"`
+}
+
+// AuthenticationCredential is the passkey assertion returned by the browser,
+// as serialized by PublicKeyCredential.toJSON.
+type AuthenticationCredential struct {
+	ID       string    `json:"id"`
+	RawID    Base64URL `json:"rawId"`
+	Type     string    `json:"type"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON"`
+		AuthenticatorData Base64URL `json:"authenticatorData"`
+		Signature         Base64URL `json:"signature"`
+		UserHandle        Base64URL `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// Assertion converts the credential to the assertion checked by the passkey service.
+func (c *AuthenticationCredential) Assertion() *models.PasskeyAssertion {
+	return &models.PasskeyAssertion{
+		CredentialID:      c.RawID,
+		ClientDataJSON:    c.Response.ClientDataJSON,
+		AuthenticatorData: c.Response.AuthenticatorData,
+		Signature:         c.Response.Signature,
+		UserHandle:        c.Response.UserHandle,
+	}
+}
+
+// validate checks that the authenticator's response is present.
+func (c *AuthenticationCredential) validate() error {
+	if c == nil || len(c.RawID) == 0 || len(c.Response.ClientDataJSON) == 0 ||
+		len(c.Response.AuthenticatorData) == 0 || len(c.Response.Signature) == 0 {
+		return autherrors.ErrEmptyCredential
+	}
+	return nil
+}
+
+// PasskeyRegistrationOptionsRequest is the request body starting a passkey registration. The user confirms
+// either the current password or a code of their authenticator app or a recovery code.
+type PasskeyRegistrationOptionsRequest struct {
+	Password string `json:"password,omitempty"`
+	Code     string `json:"code,omitempty"`
+}
+
+// Validate checks that the password or the code is present.
+func (r *PasskeyRegistrationOptionsRequest) Validate() error {
+	if r.Password == "" && r.Code == "" {
+		return autherrors.ErrEmptyReauthentication
+	}
+	return nil
+}
+
+// PasskeyRegisterRequest is the request body finishing a passkey registration.
+// Name is an optional, user-facing name for the passkey.
+type PasskeyRegisterRequest struct {
+	Name       string                  `json:"name,omitempty"`
+	Credential *RegistrationCredential `json:"credential"`
+}
+
+// Validate checks that the created passkey is present.
+func (r *PasskeyRegisterRequest) Validate() error {
+	if r.Credential == nil || len(r.Credential.Response.ClientDataJSON) == 0 ||
+		len(r.Credential.Response.AttestationObject) == 0 {
+		return autherrors.ErrEmptyCredential
+	}
+	return nil
+}
+
+// Attestation converts the created passkey to the attestation checked by the passkey service.
+func (r *PasskeyRegisterRequest) Attestation() *models.PasskeyAttestation {
+	return &models.PasskeyAttestation{
+		CredentialID:      r.Credential.RawID,
+		ClientDataJSON:    r.Credential.Response.ClientDataJSON,
+		AttestationObject: r.Credential.Response.AttestationObject,
+	}
+}
+
+// PasskeyLoginRequest is the request body finishing a login with a passkey.
+type PasskeyLoginRequest struct {
+	Credential *AuthenticationCredential `json:"credential"`
+	DeviceName string                    `json:"device_name,omitempty"`
+}
+
+// Validate checks that the passkey assertion is present.
+func (r *PasskeyLoginRequest) Validate() error {
+	return r.Credential.validate()
+}
+
+// MFAPasskeyOptionsRequest is the request body starting to check a passkey as the second factor of a login.
+type MFAPasskeyOptionsRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+}
+
+// Validate checks that the challenge token is present.
+func (r *MFAPasskeyOptionsRequest) Validate() error {
+	if r.ChallengeToken == "" {
+		return autherrors.ErrEmptyChallengeToken
+	}
+	return nil
+}
+
+// MFAPasskeyVerifyRequest is the request body finishing the login of a user with two-factor authentication
+// with a passkey instead of a code.
+type MFAPasskeyVerifyRequest struct {
+	ChallengeToken string                    `json:"challenge_token"`
+	Credential     *AuthenticationCredential `json:"credential"`
+	DeviceName     string                    `json:"device_name,omitempty"`
+}
+
+// Validate checks that both the challenge token and the passkey assertion are present.
+func (r *MFAPasskeyVerifyRequest) Validate() error {
+	if r.ChallengeToken == "" {
+		return autherrors.ErrEmptyChallengeToken
+	}
+	return r.Credential.validate()
+}
+
+// PasskeyResponse describes one passkey of the user.
+type PasskeyResponse struct {
+	ID             Base64URL  `json:"id"`
+	Name           string     `json:"name"`
+	BackupEligible bool       `json:"backup_eligible"`
+	BackedUp       bool       `json:"backed_up"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+}
+
+// NewPasskeyResponse builds a PasskeyResponse from the passkey; its public key is not disclosed.
+func NewPasskeyResponse(passkey *models.Passkey) *PasskeyResponse {
+	return &PasskeyResponse{
+		ID:             passkey.ID,
+		Name:           passkey.Name,
+		BackupEligible: passkey.BackupEligible,
+		BackedUp:       passkey.BackedUp,
+		CreatedAt:      passkey.CreatedAt,
+		LastUsedAt:     passkey.LastUsedAt,
+	}
+}
+
+// PasskeysResponse is the response body of the passkey listing endpoint.
+type PasskeysResponse struct {
+	Passkeys []*PasskeyResponse `json:"passkeys"`
+}
+
+// NewPasskeysResponse builds a PasskeysResponse from the user's passkeys.
+func NewPasskeysResponse(passkeys []*models.Passkey) *PasskeysResponse {
+	resp := &PasskeysResponse{Passkeys: make([]*PasskeyResponse, 0, len(passkeys))}
+	for _, passkey := range passkeys {
+		resp.Passkeys = append(resp.Passkeys, NewPasskeyResponse(passkey))
+	}
+	return resp
+}
+
+// ErrorResponse is the response body returned for failed requests.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	// Violations lists the rules of the password policy a new password fails.
+	Violations []PasswordViolationResponse `json:"violations,omitempty"`
+	// ChallengeToken is sent with a code to POST /auth/mfa/verify, or with a passkey to POST /auth/mfa/passkey/verify,
+	// to finish the login of a user with two-factor authentication.
+	ChallengeToken          string     `json:"challenge_token,omitempty"`
+	ChallengeTokenExpiresAt *time.Time `json:"challenge_token_expires_at,omitempty"`
+}
+
+// PasswordViolationResponse is a rule of the password policy a password fails.
+type PasswordViolationResponse struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// NewPasswordViolationResponses converts password policy violations to their response representation.
+func NewPasswordViolationResponses(violations []autherrors.PasswordViolation) []PasswordViolationResponse {
+	responses := make([]PasswordViolationResponse, 0, len(violations))
+	for _, violation := range violations {
+		responses = append(responses, PasswordViolationResponse{Rule: violation.Rule, Message: violation.Message})
+	}
+	return responses
+}
+
+// IntrospectionResponse is the response body of the token introspection endpoint (RFC 7662).
+// Inactive tokens are reported with "active": false only.
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	JTI       string   `json:"jti,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	// Actor names the service acting for the user of a token issued by token exchange.
+	Actor *ActorClaim `json:"act,omitempty"`
+}
+
+// ActorClaim is the "act" claim of a delegated token (RFC 8693, section 4.1),
+// nesting the previous actors if the token was exchanged more than once.
+type ActorClaim struct {
+	Subject string      `json:"sub"`
+	Actor   *ActorClaim `json:"act,omitempty"`
+}
+
+// NewActorClaim builds an ActorClaim from the actor of a token, or returns nil if there is none.
+func NewActorClaim(actor *models.Actor) *ActorClaim {
+	if actor == nil {
+		return nil
+	}
+	return &ActorClaim{Subject: actor.Subject, Actor: NewActorClaim(actor.Actor)}
+}
+
+// NewIntrospectionResponse builds an IntrospectionResponse from the introspection result.
+// The token's permissions are reported as its scope. The subject of a client token is the client.
+func NewIntrospectionResponse(introspection *models.TokenIntrospection) *IntrospectionResponse {
+	if !introspection.Active || introspection.Token == nil {
+		return &IntrospectionResponse{Active: false}
+	}
+
+	token := introspection.Token
+	resp := &IntrospectionResponse{
+		Active:    true,
+		Scope:     strings.Join(token.Permissions, " "),
+		TokenType: constants.TokenTypeHintAccess,
+		Subject:   token.UserID.String(),
+		Audience:  token.Audience,
+		Issuer:    token.Issuer,
+		JTI:       token.JTI,
+		ExpiresAt: token.ExpiresAt.Unix(),
+		Roles:     token.Roles,
+		Actor:     NewActorClaim(token.Actor),
+	}
+	switch token.Type {
+	case string(constants.TokenTypeRefresh):
+		resp.TokenType = constants.TokenTypeHintRefresh
+	case string(constants.TokenTypeClient):
+		resp.Subject = token.ClientID
+		resp.ClientID = token.ClientID
+	}
+	if !token.IssuedAt.IsZero() {
+		resp.IssuedAt = token.IssuedAt.Unix()
+	}
+	return resp
+}
+
+// OAuthTokenResponse is the response body of the token endpoint (RFC 6749, section 5.1).
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	// IssuedTokenType is only reported for token exchange (RFC 8693, section 2.2.1).
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+}
+
+// NewOAuthTokenResponse builds an OAuthTokenResponse from the tokens issued by a grant.
+func NewOAuthTokenResponse(tokens *models.IssuedTokens) *OAuthTokenResponse {
+	resp := &OAuthTokenResponse{
+		AccessToken:     tokens.AccessToken.Value,
+		TokenType:       constants.BearerScheme,
+		ExpiresIn:       int64(time.Until(tokens.AccessToken.ExpiresAt).Round(time.Second).Seconds()),
+		Scope:           strings.Join(tokens.Scopes, " "),
+		IssuedTokenType: tokens.IssuedTokenType,
+	}
+	if tokens.RefreshToken != nil {
+		resp.RefreshToken = tokens.RefreshToken.Value
+	}
+	if tokens.IDToken != nil {
+		resp.IDToken = tokens.IDToken.Value
+	}
+	return resp
+}
+
+// DeviceAuthorizationResponse is the response body of the device authorization endpoint (RFC 8628, section 3.2).
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// NewDeviceAuthorizationResponse builds a DeviceAuthorizationResponse from the issued device codes.
+func NewDeviceAuthorizationResponse(codes *models.DeviceCodes) *DeviceAuthorizationResponse {
+	return &DeviceAuthorizationResponse{
+		DeviceCode:              codes.DeviceCode,
+		UserCode:                codes.UserCode,
+		VerificationURI:         codes.VerificationURI,
+		VerificationURIComplete: codes.VerificationURIComplete,
+		ExpiresIn:               int64(time.Until(codes.ExpiresAt).Round(time.Second).Seconds()),
+		Interval:                int64(codes.Interval.Seconds()),
+	}
+}
+
+// DeviceVerificationRequest is the request body with which the signed-in user approves or denies a device.
+type DeviceVerificationRequest struct {
+	UserCode string `json:"user_code"`
+	Approve  *bool  `json:"approve"`
+}
+
+// Validate checks that the user code and the decision are present.
+func (r *DeviceVerificationRequest) Validate() error {
+	if r.UserCode == "" {
+		return autherrors.ErrMissingParam("user_code")
+	}
+	if r.Approve == nil {
+		return autherrors.ErrMissingParam("approve")
+	}
+	return nil
+}
+
+// DeviceVerificationResponse describes a pending device authorization to the user asked to approve it.
+type DeviceVerificationResponse struct {
+	ClientID   string    `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	Scope      string    `json:"scope,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// NewDeviceVerificationResponse builds a DeviceVerificationResponse from the pending request and its client.
+func NewDeviceVerificationResponse(auth *models.DeviceAuthorization, client *models.Client) *DeviceVerificationResponse {
+	return &DeviceVerificationResponse{
+		ClientID:   client.ID,
+		ClientName: client.Name,
+		Scope:      strings.Join(auth.Scopes, " "),
+		ExpiresAt:  auth.ExpiresAt,
+	}
+}
+
+// ProviderMetadata is the OpenID Connect discovery document describing the endpoints and features
+// of the auth service as an identity provider.
+type ProviderMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	DeviceAuthorizationEndpoint       string   `json:"device_authorization_endpoint,omitempty"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// NewProviderMetadata describes the endpoints served below the issuer URL.
+func NewProviderMetadata(issuer string, signingAlgorithm string, deviceGrant bool) *ProviderMetadata {
+	metadata := &ProviderMetadata{
+		Issuer:                 issuer,
+		AuthorizationEndpoint:  issuer + "/oauth/authorize",
+		TokenEndpoint:          issuer + "/oauth/token",
+		UserInfoEndpoint:       issuer + "/userinfo",
+		JWKSURI:                issuer + "/.well-known/jwks.json",
+		IntrospectionEndpoint:  issuer + "/oauth/introspect",
+		RevocationEndpoint:     issuer + "/oauth/revoke",
+		ScopesSupported:        []string{constants.ScopeOpenID},
+		ResponseTypesSupported: []string{constants.ResponseTypeCode},
+		GrantTypesSupported: []string{grantTypeAuthorizationCode, grantTypeRefreshToken, grantTypeClientCredentials,
+			grantTypeTokenExchange},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{signingAlgorithm},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{constants.CodeChallengeMethodS256},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "preferred_username"},
+	}
+	if deviceGrant {
+		metadata.DeviceAuthorizationEndpoint = issuer + "/oauth/device_authorization"
+		metadata.GrantTypesSupported = append(metadata.GrantTypesSupported, grantTypeDeviceCode)
+	}
+	return metadata
+}
+
+// UserInfoResponse is the response body of the userinfo endpoint.
+type UserInfoResponse struct {
+	Subject           string `json:"sub"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// NewUserInfoResponse builds a UserInfoResponse from the user; the login is reported as the preferred username.
+func NewUserInfoResponse(user *models.User) *UserInfoResponse {
+	return &UserInfoResponse{
+		Subject:           user.ID.String(),
+		PreferredUsername: user.Login,
+	}
+}
+
+// OAuthErrorResponse is the response body returned by the OAuth endpoints for failed requests (RFC 6749, section 5.2).
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
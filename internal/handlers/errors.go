@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// OAuth error codes (RFC 6749, section 5.2).
+const (
+	oauthInvalidRequest          = "invalid_request"
+	oauthInvalidClient           = "invalid_client"
+	oauthInvalidGrant            = "invalid_grant"
+	oauthAccessDenied            = "access_denied"
+	oauthUnsupportedGrantType    = "unsupported_grant_type"
+	oauthUnsupportedResponseType = "unsupported_response_type"
+	oauthUnauthorizedClient      = "unauthorized_client"
+	oauthInvalidScope            = "invalid_scope"
+	oauthServerError             = "server_error"
+	// Device authorization grant error codes (RFC 8628, section 3.5).
+	oauthAuthorizationPending = "authorization_pending"
+	oauthSlowDown             = "slow_down"
+	oauthExpiredToken         = "expired_token"
+	// Token exchange error code (RFC 8693, section 2.2.2).
+	oauthInvalidTarget = "invalid_target"
+)
+
+const (
+	msgInvalidCredentials = "invalid login or password"
+	msgInvalidToken       = "invalid or expired token"
+	msgForbidden          = "insufficient permissions"
+	msgInternalError      = "internal server error"
+)
+
+// errorStatus maps service layer errors to an HTTP status code and a client-safe message.
+// Unknown errors are reported as internal server errors without exposing details.
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, autherrors.ErrBadRequestBody),
+		errors.Is(err, autherrors.ErrEmptyCredentials),
+		errors.Is(err, autherrors.ErrEmptyToken),
+		errors.Is(err, autherrors.ErrInvalidSessionID),
+		errors.Is(err, autherrors.ErrMissingParameter),
+		errors.Is(err, autherrors.ErrInvalidUserCode),
+		errors.Is(err, autherrors.ErrEmptyMFACode),
+		errors.Is(err, autherrors.ErrEmptyChallengeToken),
+		errors.Is(err, autherrors.ErrMFANotEnrolled),
+		errors.Is(err, autherrors.ErrMFANotEnabled),
+		errors.Is(err, autherrors.ErrEmptyCredential),
+		errors.Is(err, autherrors.ErrEmptyReauthentication),
+		errors.Is(err, autherrors.ErrInvalidPasskeyID),
+		errors.Is(err, autherrors.ErrInvalidIPAddress),
+		errors.Is(err, autherrors.ErrNoPasskeys),
+		errors.Is(err, autherrors.ErrNoPasskeyService):
+		return http.StatusBadRequest, err.Error()
+
+	// The violated rules are listed separately, see writeError
+	case errors.Is(err, autherrors.ErrWeakPassword):
+		return http.StatusBadRequest, autherrors.ErrWeakPassword.Error()
+
+	case errors.Is(err, autherrors.ErrSessionNotFound):
+		return http.StatusNotFound, autherrors.ErrSessionNotFound.Error()
+
+	case errors.Is(err, autherrors.ErrPasskeyNotFound):
+		return http.StatusNotFound, autherrors.ErrPasskeyNotFound.Error()
+
+	case errors.Is(err, autherrors.ErrMissingRole),
+		errors.Is(err, autherrors.ErrMissingPermission):
+		return http.StatusForbidden, msgForbidden
+
+	case errors.Is(err, autherrors.ErrLoginTaken):
+		return http.StatusConflict, autherrors.ErrLoginTaken.Error()
+
+	case errors.Is(err, autherrors.ErrMFAAlreadyEnabled):
+		return http.StatusConflict, autherrors.ErrMFAAlreadyEnabled.Error()
+
+	case errors.Is(err, autherrors.ErrPasskeyExists):
+		return http.StatusConflict, autherrors.ErrPasskeyExists.Error()
+
+	case errors.Is(err, autherrors.ErrPasswordMismatch),
+		errors.Is(err, autherrors.ErrUserNotExist):
+		return http.StatusUnauthorized, msgInvalidCredentials
+
+	// The challenge token for the second step is added to the response, see writeError
+	case errors.Is(err, autherrors.ErrMFARequired):
+		return http.StatusUnauthorized, autherrors.ErrMFARequired.Error()
+
+	case errors.Is(err, autherrors.ErrInvalidMFACode):
+		return http.StatusUnauthorized, autherrors.ErrInvalidMFACode.Error()
+
+	// Why a passkey was rejected is not disclosed, a cloned passkey included
+	case errors.Is(err, autherrors.ErrInvalidPasskey),
+		errors.Is(err, autherrors.ErrPasskeyCounter):
+		return http.StatusUnauthorized, autherrors.ErrInvalidPasskey.Error()
+
+	case errors.Is(err, autherrors.ErrAccountLocked):
+		return http.StatusTooManyRequests, autherrors.ErrAccountLocked.Error()
+
+	case errors.Is(err, autherrors.ErrRateLimited):
+		return http.StatusTooManyRequests, autherrors.ErrRateLimited.Error()
+
+	case errors.Is(err, autherrors.ErrTokenExpired),
+		errors.Is(err, autherrors.ErrTokenType),
+		errors.Is(err, autherrors.ErrTokenIssuer),
+		errors.Is(err, autherrors.ErrTokenAudience),
+		errors.Is(err, autherrors.ErrTokenDelegated),
+		errors.Is(err, autherrors.ErrTokenParseFailed),
+		errors.Is(err, autherrors.ErrTokenInvalid),
+		errors.Is(err, autherrors.ErrTokenRevoked):
+		return http.StatusUnauthorized, msgInvalidToken
+
+	default:
+		return http.StatusInternalServerError, msgInternalError
+	}
+}
+
+// writeError writes the JSON error response matching err.
+// Passwords not meeting the password policy are answered with every rule they fail,
+// logins of users with two-factor authentication with the challenge token of the second step,
+// locked logins and rate limited requests with the time until they may be retried in the Retry-After header.
+func writeError(w http.ResponseWriter, err error) {
+	status, message := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("internal error: %v", err)
+	}
+
+	setRetryAfter(w, err)
+
+	response := ErrorResponse{Error: message}
+	var policyErr *autherrors.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		response.Violations = NewPasswordViolationResponses(policyErr.Violations)
+	}
+	var mfaErr *autherrors.MFARequiredError
+	if errors.As(err, &mfaErr) && mfaErr.ChallengeToken != nil {
+		response.ChallengeToken = mfaErr.ChallengeToken.Value
+		response.ChallengeTokenExpiresAt = &mfaErr.ChallengeToken.ExpiresAt
+	}
+	writeJSON(w, status, response)
+}
+
+// oauthErrorStatus maps errors of the OAuth endpoints to an HTTP status code, an OAuth error code
+// and a client-safe description.
+func oauthErrorStatus(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, autherrors.ErrBadRequestBody),
+		errors.Is(err, autherrors.ErrEmptyTokenParam),
+		errors.Is(err, autherrors.ErrMissingParameter),
+		errors.Is(err, autherrors.ErrInvalidCodeChallenge),
+		errors.Is(err, autherrors.ErrInvalidRedirectURI),
+		errors.Is(err, autherrors.ErrUnsupportedParam),
+		errors.Is(err, autherrors.ErrUnsupportedTokenType):
+		return http.StatusBadRequest, oauthInvalidRequest, err.Error()
+
+	// An invalid subject token is a bad token exchange request; why it is invalid is not disclosed
+	case errors.Is(err, autherrors.ErrInvalidSubjectToken):
+		return http.StatusBadRequest, oauthInvalidRequest, autherrors.ErrInvalidSubjectToken.Error()
+
+	case errors.Is(err, autherrors.ErrInvalidTarget):
+		return http.StatusBadRequest, oauthInvalidTarget, err.Error()
+
+	case errors.Is(err, autherrors.ErrUnsupportedResponseType):
+		return http.StatusBadRequest, oauthUnsupportedResponseType, err.Error()
+
+	case errors.Is(err, autherrors.ErrUnsupportedGrantType):
+		return http.StatusBadRequest, oauthUnsupportedGrantType, err.Error()
+
+	case errors.Is(err, autherrors.ErrUnauthorizedClient):
+		return http.StatusBadRequest, oauthUnauthorizedClient, err.Error()
+
+	case errors.Is(err, autherrors.ErrInvalidScope):
+		return http.StatusBadRequest, oauthInvalidScope, err.Error()
+
+	case errors.Is(err, autherrors.ErrInvalidGrant):
+		return http.StatusBadRequest, oauthInvalidGrant, err.Error()
+
+	case errors.Is(err, autherrors.ErrAuthorizationPending):
+		return http.StatusBadRequest, oauthAuthorizationPending, err.Error()
+
+	case errors.Is(err, autherrors.ErrSlowDown):
+		return http.StatusBadRequest, oauthSlowDown, err.Error()
+
+	case errors.Is(err, autherrors.ErrAccessDenied):
+		return http.StatusBadRequest, oauthAccessDenied, err.Error()
+
+	case errors.Is(err, autherrors.ErrDeviceCodeExpired):
+		return http.StatusBadRequest, oauthExpiredToken, err.Error()
+
+	case errors.Is(err, autherrors.ErrTokenExpired),
+		errors.Is(err, autherrors.ErrTokenType),
+		errors.Is(err, autherrors.ErrTokenParseFailed),
+		errors.Is(err, autherrors.ErrTokenInvalid),
+		errors.Is(err, autherrors.ErrTokenRevoked),
+		errors.Is(err, autherrors.ErrUserNotExist):
+		return http.StatusBadRequest, oauthInvalidGrant, msgInvalidToken
+
+	case errors.Is(err, autherrors.ErrPasswordMismatch):
+		return http.StatusUnauthorized, oauthAccessDenied, msgInvalidCredentials
+
+	case errors.Is(err, autherrors.ErrInvalidClient):
+		return http.StatusUnauthorized, oauthInvalidClient, ""
+
+	default:
+		return http.StatusInternalServerError, oauthServerError, ""
+	}
+}
+
+// writeOAuthError writes the OAuth error response matching err.
+// Failed client authentication asks for HTTP Basic credentials.
+func writeOAuthError(w http.ResponseWriter, err error) {
+	status, code, description := oauthErrorStatus(err)
+
+	switch {
+	case status == http.StatusInternalServerError:
+		log.Printf("internal error: %v", err)
+	case code == oauthInvalidClient:
+		w.Header().Set("WWW-Authenticate", `Basic realm="auth-service"`)
+	}
+
+	writeJSON(w, status, OAuthErrorResponse{Error: code, ErrorDescription: description})
+}
+
+// setRetryAfter sets the Retry-After header to the seconds until a lockout ends if err is an *autherrors.LockoutError,
+// or until a rate limited request would be allowed if err is an *autherrors.RateLimitError.
+func setRetryAfter(w http.ResponseWriter, err error) {
+	var retryAfter time.Duration
+
+	var lockoutErr *autherrors.LockoutError
+	var rateLimitErr *autherrors.RateLimitError
+	switch {
+	case errors.As(err, &lockoutErr):
+		retryAfter = time.Until(lockoutErr.Until)
+	case errors.As(err, &rateLimitErr):
+		retryAfter = rateLimitErr.RetryAfter
+	default:
+		return
+	}
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+)
+
+// IKeySet defines the source of the published public signing keys.
+type IKeySet interface {
+	JWKS() *jwt.JWKS
+}
+
+// JWKSHandler serves the public signing keys so consumers can verify tokens locally.
+type JWKSHandler struct {
+	keySet IKeySet
+}
+
+// NewJWKSHandler creates a new JWKS handler instance.
+func NewJWKSHandler(keySet IKeySet) *JWKSHandler {
+	return &JWKSHandler{
+		keySet: keySet,
+	}
+}
+
+// JWKS responds with the JSON Web Key Set of all active and not yet retired keys.
+func (h *JWKSHandler) JWKS(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, h.keySet.JWKS())
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+)
+
+type JWKSHandlerTestSuite struct {
+	suite.Suite
+	signingKey *jwt.SigningKey
+	router     http.Handler
+}
+
+func (s *JWKSHandlerTestSuite) SetupTest() {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(s.T(), err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(s.T(), err)
+
+	s.signingKey, err = jwt.ParsePrivateKey("EdDSA", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(s.T(), err)
+
+	keySet, err := jwt.NewKeySet(s.signingKey, jwt.NewHMACKey("secret"))
+	require.NoError(s.T(), err)
+
+	s.router = NewRouter(NewAuthHandler(nil), NewJWKSHandler(keySet), NewOAuthHandler(nil, nil, nil, nil, nil), nil, nil, nil, nil, nil)
+}
+
+func (s *JWKSHandlerTestSuite) TestServeJWKS() {
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Equal(s.T(), "application/json", rec.Header().Get("Content-Type"))
+	assert.NotEmpty(s.T(), rec.Header().Get("Cache-Control"))
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(s.T(), err)
+
+	keys, err := jwt.ParseJWKS(body)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), keys, 1, "symmetric keys must not be published")
+	assert.Equal(s.T(), s.signingKey.ID, keys[0].ID)
+	assert.Equal(s.T(), "EdDSA", keys[0].Method.Alg())
+}
+
+func TestJWKSHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(JWKSHandlerTestSuite))
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// ILockoutService defines the lifting of the locks put on logins and IP addresses after failed logins.
+type ILockoutService interface {
+	UnlockLogin(login string) error
+	UnlockIPAddress(ipAddress string) error
+}
+
+// LockoutHandler serves the administrative endpoints that unlock logins and IP addresses
+// before their lock expires, e.g. once support verified the user.
+type LockoutHandler struct {
+	lockoutService ILockoutService
+}
+
+// NewLockoutHandler creates a new lockout handler instance.
+func NewLockoutHandler(lockoutService ILockoutService) *LockoutHandler {
+	return &LockoutHandler{
+		lockoutService: lockoutService,
+	}
+}
+
+// UnlockLogin lifts the lock of the login given in the path and resets its failed attempts.
+// Unlocking a login that isn't locked succeeds too.
+func (h *LockoutHandler) UnlockLogin(w http.ResponseWriter, r *http.Request) {
+	login := r.PathValue("login")
+	if login == "" {
+		writeError(w, autherrors.ErrMissingParam("login"))
+		return
+	}
+
+	if err := h.lockoutService.UnlockLogin(login); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnlockIPAddress lifts the lock of the IP address given in the path and resets its failed attempts.
+// Unlocking an address that isn't locked succeeds too.
+func (h *LockoutHandler) UnlockIPAddress(w http.ResponseWriter, r *http.Request) {
+	ip := net.ParseIP(r.PathValue("ip"))
+	if ip == nil {
+		writeError(w, autherrors.ErrInvalidIPAddress)
+		return
+	}
+
+	// Attempts are counted for the canonical form of the address, as the connection reports it
+	if err := h.lockoutService.UnlockIPAddress(ip.String()); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+	"github.com/breakfront-planner/auth-service/internal/validators"
+)
+
+type LockoutHandlerTestSuite struct {
+	suite.Suite
+	ctrl            *gomock.Controller
+	mockAttemptRepo *mocks.MockILoginAttemptRepository
+	jwtManager      *jwt.Manager
+	router          http.Handler
+	admin           *models.User
+	user            *models.User
+}
+
+func (s *LockoutHandlerTestSuite) SetupSuite() {
+	err := godotenv.Load("../../.env.test")
+	require.NoError(s.T(), err, "Failed to load .env.test")
+
+	jwtSecret := os.Getenv("TEST_JWT_SECRET")
+	require.NotEmpty(s.T(), jwtSecret, "TEST_JWT_SECRET must be set in .env.test")
+
+	s.jwtManager = jwt.NewManager(jwtSecret, 10*time.Minute, time.Hour)
+	s.admin = &models.User{ID: uuid.New(), Login: "support@example.com", Permissions: []string{constants.PermissionUnlockLogins}}
+	s.user = &models.User{ID: uuid.New(), Login: "alice@example.com"}
+}
+
+func (s *LockoutHandlerTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockAttemptRepo = mocks.NewMockILoginAttemptRepository(s.ctrl)
+
+	mockUserRepo := mocks.NewMockIUserRepository(s.ctrl)
+	mockUserRepo.EXPECT().FindUser(gomock.Any()).Return(s.admin, nil).AnyTimes()
+	mockDenylistRepo := mocks.NewMockIDenylistRepository(s.ctrl)
+	mockDenylistRepo.EXPECT().IsDenylisted(gomock.Any()).Return(false, nil).AnyTimes()
+
+	hashService := services.NewHashService()
+	userService := services.NewUserService(mockUserRepo, hashService)
+	tokenValidator := validators.NewTokenValidator(s.jwtManager, userService, services.NewDenylistService(mockDenylistRepo))
+	lockoutService := services.NewLockoutService(s.mockAttemptRepo, services.DefaultLockoutPolicy)
+
+	s.router = NewRouter(NewAuthHandler(nil), NewJWKSHandler(s.jwtManager.KeySet()), NewOAuthHandler(nil, nil, nil, nil, nil),
+		nil, nil, nil, NewLockoutHandler(lockoutService), tokenValidator)
+}
+
+func (s *LockoutHandlerTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+// doRequest sends the request with an access token of the user.
+func (s *LockoutHandlerTestSuite) doRequest(method, path string, user *models.User) *httptest.ResponseRecorder {
+	accessToken, err := s.jwtManager.GenerateToken(user, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken.Value)
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func (s *LockoutHandlerTestSuite) TestUnlockLogin() {
+	s.mockAttemptRepo.EXPECT().
+		DeleteAttempts(models.LoginAttemptKey{Kind: models.LoginAttemptLogin, Value: "alice@example.com"}).
+		Return(nil)
+
+	rec := s.doRequest(http.MethodDelete, "/admin/lockouts/logins/alice@example.com", s.admin)
+
+	assert.Equal(s.T(), http.StatusNoContent, rec.Code)
+}
+
+func (s *LockoutHandlerTestSuite) TestUnlockIPAddress() {
+	s.mockAttemptRepo.EXPECT().
+		DeleteAttempts(models.LoginAttemptKey{Kind: models.LoginAttemptIPAddress, Value: "2001:db8::7"}).
+		Return(nil)
+
+	rec := s.doRequest(http.MethodDelete, "/admin/lockouts/ip-addresses/2001:DB8:0::7", s.admin)
+
+	assert.Equal(s.T(), http.StatusNoContent, rec.Code)
+}
+
+func (s *LockoutHandlerTestSuite) TestUnlockInvalidIPAddress() {
+	rec := s.doRequest(http.MethodDelete, "/admin/lockouts/ip-addresses/not-an-ip", s.admin)
+
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+}
+
+func (s *LockoutHandlerTestSuite) TestUnlockRequiresPermission() {
+	rec := s.doRequest(http.MethodDelete, "/admin/lockouts/logins/alice@example.com", s.user)
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code)
+
+	rec = s.doRequest(http.MethodDelete, "/admin/lockouts/ip-addresses/203.0.113.7", s.user)
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code)
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/lockouts/logins/alice@example.com", nil)
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+}
+
+func (s *LockoutHandlerTestSuite) TestUnlockStorageError() {
+	s.mockAttemptRepo.EXPECT().
+		DeleteAttempts(gomock.Any()).
+		Return(errors.New("database error"))
+
+	rec := s.doRequest(http.MethodDelete, "/admin/lockouts/logins/alice@example.com", s.admin)
+
+	assert.Equal(s.T(), http.StatusInternalServerError, rec.Code)
+}
+
+func TestLockoutHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(LockoutHandlerTestSuite))
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/pkg/authmw"
+)
+
+// IMFAService defines the two-factor authentication settings of the signed-in user.
+type IMFAService interface {
+	Status(userID uuid.UUID) (*models.MFAStatus, error)
+	EnrollTOTP(userID uuid.UUID) (*models.TOTPEnrollment, error)
+	ConfirmTOTP(userID uuid.UUID, code string, ipAddress string) ([]string, error)
+	DisableTOTP(userID uuid.UUID, code string, ipAddress string) error
+	RegenerateRecoveryCodes(userID uuid.UUID, code string, ipAddress string) ([]string, error)
+}
+
+// MFAHandler serves the endpoints with which signed-in users manage their two-factor authentication.
+type MFAHandler struct {
+	mfaService IMFAService
+}
+
+// NewMFAHandler creates a new two-factor authentication handler instance.
+func NewMFAHandler(mfaService IMFAService) *MFAHandler {
+	return &MFAHandler{
+		mfaService: mfaService,
+	}
+}
+
+// Status responds with whether the authenticated user has two-factor authentication enabled.
+func (h *MFAHandler) Status(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	status, err := h.mfaService.Status(userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewMFAStatusResponse(status))
+}
+
+// EnrollTOTP starts setting up an authenticator app and responds with its secret and provisioning URI.
+// Two-factor authentication is enabled once a first code is confirmed with ConfirmTOTP.
+func (h *MFAHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	enrollment, err := h.mfaService.EnrollTOTP(userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, NewTOTPEnrollmentResponse(enrollment))
+}
+
+// ConfirmTOTP enables two-factor authentication with a code of the enrolled authenticator app
+// and responds with the user's recovery codes.
+func (h *MFAHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	var req MFACodeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmTOTP(userID, req.Code, clientIP(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP turns two-factor authentication off after checking a current code or recovery code.
+func (h *MFAHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	var req MFACodeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.mfaService.DisableTOTP(userID, req.Code, clientIP(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a current code or recovery code
+// and responds with the new ones.
+func (h *MFAHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	var req MFACodeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(userID, req.Code, clientIP(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+	"github.com/breakfront-planner/auth-service/internal/totp"
+	"github.com/breakfront-planner/auth-service/internal/validators"
+)
+
+type MFAHandlerTestSuite struct {
+	suite.Suite
+	ctrl        *gomock.Controller
+	mockMFARepo *mocks.MockIMFARepository
+	jwtManager  *jwt.Manager
+	router      http.Handler
+	testUser    *models.User
+	secret      string
+	accessToken string
+}
+
+func (s *MFAHandlerTestSuite) SetupSuite() {
+	err := godotenv.Load("../../.env.test")
+	require.NoError(s.T(), err, "Failed to load .env.test")
+
+	jwtSecret := os.Getenv("TEST_JWT_SECRET")
+	require.NotEmpty(s.T(), jwtSecret, "TEST_JWT_SECRET must be set in .env.test")
+
+	s.jwtManager = jwt.NewManager(jwtSecret, 10*time.Minute, time.Hour)
+	s.testUser = &models.User{ID: uuid.New(), Login: "alice@example.com"}
+}
+
+func (s *MFAHandlerTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockMFARepo = mocks.NewMockIMFARepository(s.ctrl)
+
+	mockUserRepo := mocks.NewMockIUserRepository(s.ctrl)
+	mockUserRepo.EXPECT().FindUser(gomock.Any()).Return(s.testUser, nil).AnyTimes()
+	mockDenylistRepo := mocks.NewMockIDenylistRepository(s.ctrl)
+	mockDenylistRepo.EXPECT().IsDenylisted(gomock.Any()).Return(false, nil).AnyTimes()
+
+	hashService := services.NewHashService()
+	userService := services.NewUserService(mockUserRepo, hashService)
+	tokenValidator := validators.NewTokenValidator(s.jwtManager, userService, services.NewDenylistService(mockDenylistRepo))
+	mfaService := services.NewMFAService(s.mockMFARepo, userService, hashService, "Breakfront")
+
+	s.router = NewRouter(NewAuthHandler(nil), NewJWKSHandler(s.jwtManager.KeySet()), NewOAuthHandler(nil, nil, nil, nil, nil),
+		nil, NewMFAHandler(mfaService), nil, nil, tokenValidator)
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(s.T(), err)
+	s.secret = secret
+
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+	s.accessToken = accessToken.Value
+}
+
+func (s *MFAHandlerTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *MFAHandlerTestSuite) doRequest(method, path string, body any) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		require.NoError(s.T(), json.NewEncoder(&payload).Encode(body))
+	}
+
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.accessToken)
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func (s *MFAHandlerTestSuite) currentCode() string {
+	code, err := totp.GenerateCode(s.secret, time.Now())
+	require.NoError(s.T(), err)
+	return code
+}
+
+// credential returns a TOTP credential of the test user with the test secret, confirmed or pending.
+func (s *MFAHandlerTestSuite) credential(confirmed bool) *models.TOTPCredential {
+	credential := &models.TOTPCredential{UserID: s.testUser.ID, Secret: s.secret}
+	if confirmed {
+		confirmedAt := time.Now().Add(-time.Hour)
+		credential.ConfirmedAt = &confirmedAt
+	}
+	return credential
+}
+
+func (s *MFAHandlerTestSuite) TestStatus() {
+	s.mockMFARepo.EXPECT().FindTOTP(s.testUser.ID).Return(s.credential(true), nil)
+	s.mockMFARepo.EXPECT().CountRecoveryCodes(s.testUser.ID).Return(7, nil)
+
+	rec := s.doRequest(http.MethodGet, "/auth/mfa", nil)
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var resp MFAStatusResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(s.T(), MFAStatusResponse{TOTPEnabled: true, RecoveryCodesRemaining: 7}, resp)
+}
+
+func (s *MFAHandlerTestSuite) TestEnrollTOTP() {
+	s.mockMFARepo.EXPECT().FindTOTP(s.testUser.ID).Return(nil, nil)
+	s.mockMFARepo.EXPECT().SaveTOTP(gomock.Any()).Return(nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/mfa/totp", nil)
+
+	require.Equal(s.T(), http.StatusCreated, rec.Code)
+	var resp TOTPEnrollmentResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	assert.NotEmpty(s.T(), resp.Secret)
+	assert.Contains(s.T(), resp.ProvisioningURI, "otpauth://totp/Breakfront:alice@example.com?")
+	assert.Contains(s.T(), resp.ProvisioningURI, "secret="+resp.Secret)
+}
+
+func (s *MFAHandlerTestSuite) TestEnrollTOTPAlreadyEnabled() {
+	s.mockMFARepo.EXPECT().FindTOTP(s.testUser.ID).Return(s.credential(true), nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/mfa/totp", nil)
+
+	assert.Equal(s.T(), http.StatusConflict, rec.Code)
+}
+
+func (s *MFAHandlerTestSuite) TestConfirmTOTP() {
+	s.mockMFARepo.EXPECT().FindTOTP(s.testUser.ID).Return(s.credential(false), nil)
+	s.mockMFARepo.EXPECT().ConfirmTOTP(s.testUser.ID, gomock.Any(), gomock.Len(10)).Return(nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/mfa/totp/confirm", MFACodeRequest{Code: s.currentCode()})
+
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var resp RecoveryCodesResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Len(s.T(), resp.RecoveryCodes, 10)
+}
+
+func (s *MFAHandlerTestSuite) TestConfirmTOTPErrors() {
+	s.mockMFARepo.EXPECT().FindTOTP(s.testUser.ID).Return(s.credential(false), nil)
+	rec := s.doRequest(http.MethodPost, "/auth/mfa/totp/confirm", MFACodeRequest{Code: "000000"})
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Equal(s.T(), autherrors.ErrInvalidMFACode.Error(), s.decodeError(rec).Error)
+
+	s.mockMFARepo.EXPECT().FindTOTP(s.testUser.ID).Return(nil, nil)
+	rec = s.doRequest(http.MethodPost, "/auth/mfa/totp/confirm", MFACodeRequest{Code: "000000"})
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Equal(s.T(), autherrors.ErrMFANotEnrolled.Error(), s.decodeError(rec).Error)
+
+	rec = s.doRequest(http.MethodPost, "/auth/mfa/totp/confirm", MFACodeRequest{})
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Equal(s.T(), autherrors.ErrEmptyMFACode.Error(), s.decodeError(rec).Error)
+}
+
+func (s *MFAHandlerTestSuite) TestDisableTOTP() {
+	s.mockMFARepo.EXPECT().FindTOTP(s.testUser.ID).Return(s.credential(true), nil)
+	s.mockMFARepo.EXPECT().UseTOTPStep(s.testUser.ID, gomock.Any()).Return(true, nil)
+	s.mockMFARepo.EXPECT().DeleteTOTP(s.testUser.ID).Return(nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/mfa/totp/disable", MFACodeRequest{Code: s.currentCode()})
+
+	assert.Equal(s.T(), http.StatusNoContent, rec.Code)
+}
+
+func (s *MFAHandlerTestSuite) TestRegenerateRecoveryCodes() {
+	s.mockMFARepo.EXPECT().FindTOTP(s.testUser.ID).Return(s.credential(true), nil)
+	s.mockMFARepo.EXPECT().UseRecoveryCode(s.testUser.ID, gomock.Any()).Return(true, nil)
+	s.mockMFARepo.EXPECT().ReplaceRecoveryCodes(s.testUser.ID, gomock.Len(10)).Return(nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/mfa/recovery-codes", MFACodeRequest{Code: "abcde-fghjk"})
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var resp RecoveryCodesResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Len(s.T(), resp.RecoveryCodes, 10)
+}
+
+func (s *MFAHandlerTestSuite) TestEndpointsRequireAccessToken() {
+	s.accessToken = ""
+
+	for _, path := range []string{"/auth/mfa/totp", "/auth/mfa/totp/confirm", "/auth/mfa/totp/disable", "/auth/mfa/recovery-codes"} {
+		rec := s.doRequest(http.MethodPost, path, MFACodeRequest{Code: "123456"})
+
+		assert.Equal(s.T(), http.StatusUnauthorized, rec.Code, path)
+	}
+}
+
+func (s *MFAHandlerTestSuite) decodeError(rec *httptest.ResponseRecorder) ErrorResponse {
+	var resp ErrorResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	return resp
+}
+
+func TestMFAHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(MFAHandlerTestSuite))
+}
@@ -0,0 +1,616 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/pkg/authmw"
+)
+
+// IOAuthService defines the OAuth 2.0 authorization code grant.
+type IOAuthService interface {
+	ValidateRedirect(req *models.AuthorizationRequest) (*models.Client, error)
+	ValidateAuthorizationRequest(req *models.AuthorizationRequest) error
+	Authorize(req *models.AuthorizationRequest, login string, password string, ipAddress string) (string, error)
+	AuthorizeMFA(req *models.AuthorizationRequest, challengeTokenValue string, code string, ipAddress string) (string, error)
+	BeginPasskeyMFA(challengeTokenValue string) (*models.PasskeyRequestOptions, error)
+	AuthorizePasskeyMFA(req *models.AuthorizationRequest, challengeTokenValue string, assertion *models.PasskeyAssertion,
+		ipAddress string) (string, error)
+	ExchangeAuthorizationCode(exchange *models.AuthorizationCodeExchange, session models.SessionMetadata) (*models.IssuedTokens, error)
+	IssueClientToken(clientID string, clientSecret string, scopes []string) (*models.IssuedTokens, error)
+}
+
+// IDeviceAuthorizationService defines the OAuth 2.0 device authorization grant.
+type IDeviceAuthorizationService interface {
+	StartDeviceAuthorization(clientID string, clientSecret string, scopes []string) (*models.DeviceCodes, error)
+	FindPendingAuthorization(userCode string) (*models.DeviceAuthorization, *models.Client, error)
+	DecideDeviceAuthorization(userCode string, userID uuid.UUID, approve bool) error
+	PollDeviceToken(clientID string, clientSecret string, deviceCode string, session models.SessionMetadata) (*models.IssuedTokens, error)
+}
+
+// ITokenExchangeService defines the OAuth 2.0 token exchange grant.
+type ITokenExchangeService interface {
+	ExchangeToken(exchange *models.TokenExchange) (*models.IssuedTokens, error)
+}
+
+// IClientAuthenticator defines the authentication of OAuth clients.
+type IClientAuthenticator interface {
+	AuthenticateClient(clientID string, clientSecret string) error
+	IdentifyClient(clientID string, clientSecret string) (*models.Client, error)
+}
+
+// OAuthHandler serves the OAuth 2.0 endpoints: authorization and token endpoints for apps signing users in,
+// the device authorization endpoints for devices without a browser, and token introspection and revocation.
+type OAuthHandler struct {
+	authService     IAuthService
+	oauthService    IOAuthService
+	deviceService   IDeviceAuthorizationService
+	exchangeService ITokenExchangeService
+	clients         IClientAuthenticator
+}
+
+// NewOAuthHandler creates a new OAuth handler instance.
+// Without a device service the device authorization endpoints are not served and the device code grant
+// is unsupported.
+func NewOAuthHandler(authService IAuthService, oauthService IOAuthService, deviceService IDeviceAuthorizationService,
+	exchangeService ITokenExchangeService, clients IClientAuthenticator) *OAuthHandler {
+	return &OAuthHandler{
+		authService:     authService,
+		oauthService:    oauthService,
+		deviceService:   deviceService,
+		exchangeService: exchangeService,
+		clients:         clients,
+	}
+}
+
+// OAuth grant types accepted by the token endpoint.
+const (
+	grantTypeAuthorizationCode = "authorization_code"
+	grantTypeRefreshToken      = "refresh_token"
+	grantTypeClientCredentials = "client_credentials"
+	grantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+	grantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+)
+
+// Authorize starts the authorization code flow: it validates the authorization request in the query
+// and shows the sign-in form. Requests from unknown clients or to unregistered redirect URIs are rejected
+// with an error page; other invalid requests are sent back to the client's redirect URI.
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	req := authorizationRequest(r.URL.Query())
+
+	client, err := h.oauthService.ValidateRedirect(req)
+	if err != nil {
+		writeAuthorizeErrorPage(w, err)
+		return
+	}
+
+	if err := h.oauthService.ValidateAuthorizationRequest(req); err != nil {
+		redirectWithError(w, r, req, err)
+		return
+	}
+
+	writeHTML(w, http.StatusOK, authorizePage, authorizePageData{ClientName: client.Name, Request: req})
+}
+
+// AuthorizeSubmit handles the sign-in form: on valid credentials it redirects the user back to the client
+// with an authorization code, on invalid ones it shows the form again.
+// Users with two-factor authentication or a passkey are asked for a code or the passkey in a second step
+// before being redirected.
+func (h *OAuthHandler) AuthorizeSubmit(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(w, r); err != nil {
+		writeAuthorizeErrorPage(w, err)
+		return
+	}
+	req := authorizationRequest(r.PostForm)
+
+	client, err := h.oauthService.ValidateRedirect(req)
+	if err != nil {
+		writeAuthorizeErrorPage(w, err)
+		return
+	}
+
+	data := authorizePageData{ClientName: client.Name, Request: req, MFAToken: r.PostForm.Get("mfa_token")}
+
+	var code string
+	switch {
+	case data.MFAToken != "" && r.PostForm.Get("passkey_credential") != "":
+		code, err = h.authorizePasskeyMFA(req, data.MFAToken, r.PostForm.Get("passkey_credential"), clientIP(r))
+	case data.MFAToken != "":
+		code, err = h.oauthService.AuthorizeMFA(req, data.MFAToken, r.PostForm.Get("code"), clientIP(r))
+	default:
+		code, err = h.oauthService.Authorize(req, r.PostForm.Get("login"), r.PostForm.Get("password"), clientIP(r))
+	}
+
+	var mfaErr *autherrors.MFARequiredError
+	switch {
+	case errors.As(err, &mfaErr) && mfaErr.ChallengeToken != nil:
+		data.MFAToken = mfaErr.ChallengeToken.Value
+		h.writeAuthorizePage(w, http.StatusOK, data)
+		return
+	case errors.Is(err, autherrors.ErrPasswordMismatch), errors.Is(err, autherrors.ErrUserNotExist):
+		data.Error = msgInvalidCredentials
+		h.writeAuthorizePage(w, http.StatusUnauthorized, data)
+		return
+	case errors.Is(err, autherrors.ErrInvalidMFACode):
+		data.Error = autherrors.ErrInvalidMFACode.Error()
+		h.writeAuthorizePage(w, http.StatusUnauthorized, data)
+		return
+	case errors.Is(err, autherrors.ErrInvalidPasskey), errors.Is(err, autherrors.ErrPasskeyCounter),
+		errors.Is(err, autherrors.ErrEmptyCredential):
+		data.Error = autherrors.ErrInvalidPasskey.Error()
+		h.writeAuthorizePage(w, http.StatusUnauthorized, data)
+		return
+	case errors.Is(err, autherrors.ErrAccountLocked):
+		setRetryAfter(w, err)
+		data.Error = autherrors.ErrAccountLocked.Error()
+		h.writeAuthorizePage(w, http.StatusTooManyRequests, data)
+		return
+	case err != nil:
+		redirectWithError(w, r, req, err)
+		return
+	}
+
+	redirectWithParams(w, r, req.RedirectURI, url.Values{"code": {code}, "state": {req.State}})
+}
+
+// authorizePasskeyMFA checks the passkey credential the sign-in page posted, serialized with toJSON,
+// as the second factor of the user of the challenge token.
+func (h *OAuthHandler) authorizePasskeyMFA(req *models.AuthorizationRequest, challengeTokenValue string,
+	credentialJSON string, ipAddress string) (string, error) {
+
+	var credential AuthenticationCredential
+	if err := json.Unmarshal([]byte(credentialJSON), &credential); err != nil {
+		return "", autherrors.ErrEmptyCredential
+	}
+	if err := credential.validate(); err != nil {
+		return "", err
+	}
+
+	return h.oauthService.AuthorizePasskeyMFA(req, challengeTokenValue, credential.Assertion(), ipAddress)
+}
+
+// writeAuthorizePage shows the sign-in form. Its second step offers the user's passkeys along with the code,
+// with the options of a new challenge for navigator.credentials.get.
+func (h *OAuthHandler) writeAuthorizePage(w http.ResponseWriter, status int, data authorizePageData) {
+	if data.MFAToken != "" {
+		options, err := h.oauthService.BeginPasskeyMFA(data.MFAToken)
+		switch {
+		case err == nil:
+			data.PasskeyOptions = NewPasskeyRequestOptionsResponse(options)
+		case !errors.Is(err, autherrors.ErrNoPasskeys) && !errors.Is(err, autherrors.ErrNoPasskeyService):
+			log.Printf("failed to offer passkeys on the sign-in page: %v", err)
+		}
+	}
+
+	writeHTML(w, status, authorizePage, data)
+}
+
+// Token issues tokens for an authorization code, a refresh token, a confidential client's own credentials
+// (RFC 6749, sections 4.1.3, 6 and 4.4), an approved device code (RFC 8628, section 3.4)
+// or a user's access token presented by a service acting for the user (RFC 8693, section 2.1).
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(w, r); err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	var tokens *models.IssuedTokens
+	var err error
+
+	switch r.PostForm.Get("grant_type") {
+	case grantTypeAuthorizationCode:
+		tokens, err = h.exchangeAuthorizationCode(r)
+
+	case grantTypeRefreshToken:
+		tokens, err = h.refresh(r)
+
+	case grantTypeClientCredentials:
+		tokens, err = h.issueClientToken(r)
+
+	case grantTypeDeviceCode:
+		if h.deviceService == nil {
+			err = autherrors.ErrUnsupportedGrantType
+			break
+		}
+		tokens, err = h.pollDeviceToken(r)
+
+	case grantTypeTokenExchange:
+		tokens, err = h.exchangeToken(r)
+
+	case "":
+		err = autherrors.ErrMissingParam("grant_type")
+
+	default:
+		err = autherrors.ErrUnsupportedGrantType
+	}
+
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	writeJSON(w, http.StatusOK, NewOAuthTokenResponse(tokens))
+}
+
+// issueClientToken issues a client token for the authenticated client and the scopes it requests.
+func (h *OAuthHandler) issueClientToken(r *http.Request) (*models.IssuedTokens, error) {
+	clientID, clientSecret, err := clientCredentials(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.oauthService.IssueClientToken(clientID, clientSecret, strings.Fields(r.PostForm.Get("scope")))
+}
+
+// pollDeviceToken answers the device's poll for the tokens of its device code.
+func (h *OAuthHandler) pollDeviceToken(r *http.Request) (*models.IssuedTokens, error) {
+	clientID, clientSecret, err := clientCredentials(r)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceCode := r.PostForm.Get("device_code")
+	if deviceCode == "" {
+		return nil, autherrors.ErrMissingParam("device_code")
+	}
+
+	return h.deviceService.PollDeviceToken(clientID, clientSecret, deviceCode, sessionMetadata(r, ""))
+}
+
+// exchangeToken exchanges the subject token of the token request for a token meant for the requested audience.
+// The authenticated client is the actor, so actor tokens are not accepted.
+func (h *OAuthHandler) exchangeToken(r *http.Request) (*models.IssuedTokens, error) {
+	clientID, clientSecret, err := clientCredentials(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.PostForm.Get("actor_token") != "" {
+		return nil, autherrors.ErrParamNotSupported("actor_token")
+	}
+
+	exchange := &models.TokenExchange{
+		ClientID:           clientID,
+		ClientSecret:       clientSecret,
+		SubjectToken:       r.PostForm.Get("subject_token"),
+		SubjectTokenType:   r.PostForm.Get("subject_token_type"),
+		RequestedTokenType: r.PostForm.Get("requested_token_type"),
+		Audience:           r.PostForm.Get("audience"),
+		Scopes:             strings.Fields(r.PostForm.Get("scope")),
+	}
+
+	if err := requireParams(
+		formParam{"subject_token", exchange.SubjectToken},
+		formParam{"subject_token_type", exchange.SubjectTokenType},
+		formParam{"audience", exchange.Audience},
+	); err != nil {
+		return nil, err
+	}
+
+	return h.exchangeService.ExchangeToken(exchange)
+}
+
+// formParam is a parameter of a token request and its value.
+type formParam struct{ name, value string }
+
+// requireParams returns ErrMissingParam for the first empty parameter. They are checked in order,
+// so the same request always reports the same missing parameter.
+func requireParams(params ...formParam) error {
+	for _, param := range params {
+		if param.value == "" {
+			return autherrors.ErrMissingParam(param.name)
+		}
+	}
+	return nil
+}
+
+// exchangeAuthorizationCode redeems the authorization code of the token request.
+// Public clients identify themselves with the client_id parameter only.
+func (h *OAuthHandler) exchangeAuthorizationCode(r *http.Request) (*models.IssuedTokens, error) {
+	clientID, clientSecret, err := clientCredentials(r)
+	if err != nil {
+		return nil, err
+	}
+
+	exchange := &models.AuthorizationCodeExchange{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+	}
+
+	if err := requireParams(
+		formParam{"code", exchange.Code},
+		formParam{"redirect_uri", exchange.RedirectURI},
+		formParam{"code_verifier", exchange.CodeVerifier},
+	); err != nil {
+		return nil, err
+	}
+
+	return h.oauthService.ExchangeAuthorizationCode(exchange, sessionMetadata(r, ""))
+}
+
+// refresh rotates the refresh token of the token request, like the /auth/refresh endpoint.
+// Refresh tokens issued to a client must be refreshed by that client; confidential clients authenticate.
+func (h *OAuthHandler) refresh(r *http.Request) (*models.IssuedTokens, error) {
+	clientID, err := h.identifyClient(r)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTokenValue := r.PostForm.Get("refresh_token")
+	if refreshTokenValue == "" {
+		return nil, autherrors.ErrMissingParam("refresh_token")
+	}
+
+	accessToken, refreshToken, err := h.authService.RefreshForClient(refreshTokenValue, clientID, sessionMetadata(r, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.IssuedTokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// DeviceAuthorization starts the device authorization grant (RFC 8628, section 3.1): it responds with
+// the device code the device polls the token endpoint with, and the user code and verification URI
+// the device shows to the user.
+func (h *OAuthHandler) DeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(w, r); err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	clientID, clientSecret, err := clientCredentials(r)
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	codes, err := h.deviceService.StartDeviceAuthorization(clientID, clientSecret, strings.Fields(r.PostForm.Get("scope")))
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, NewDeviceAuthorizationResponse(codes))
+}
+
+// DeviceVerification describes the pending device authorization of the user code in the query,
+// so the signed-in user can check which app they are about to approve.
+func (h *OAuthHandler) DeviceVerification(w http.ResponseWriter, r *http.Request) {
+	userCode := r.URL.Query().Get("user_code")
+	if userCode == "" {
+		writeError(w, autherrors.ErrMissingParam("user_code"))
+		return
+	}
+
+	auth, client, err := h.deviceService.FindPendingAuthorization(userCode)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, NewDeviceVerificationResponse(auth, client))
+}
+
+// DeviceVerificationSubmit approves or denies the device showing the user code for the signed-in user.
+func (h *OAuthHandler) DeviceVerificationSubmit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	var req DeviceVerificationRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.deviceService.DecideDeviceAuthorization(req.UserCode, userID, *req.Approve); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Introspect responds with the state of the token given in the form-encoded request body (RFC 7662).
+// The caller must authenticate as a registered client. The token_type_hint parameter is accepted
+// but not needed, since tokens carry their type.
+func (h *OAuthHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(w, r); err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	if err := h.authenticateClient(r); err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	tokenValue := r.PostForm.Get("token")
+	if tokenValue == "" {
+		writeOAuthError(w, autherrors.ErrEmptyTokenParam)
+		return
+	}
+
+	introspection, err := h.authService.Introspect(tokenValue)
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, NewIntrospectionResponse(introspection))
+}
+
+// Revoke revokes the access or refresh token given in the form-encoded request body (RFC 7009).
+// Confidential clients must send valid credentials and public clients their client_id; only the tokens
+// issued to the calling client are revoked. Tokens of the auth endpoints are revoked by presenting them
+// without client credentials. The response is 200 for unknown, already revoked and other clients' tokens too. The token_type_hint parameter
+// is accepted but not needed, since tokens carry their type.
+func (h *OAuthHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(w, r); err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	clientID, err := h.identifyClient(r)
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	tokenValue := r.PostForm.Get("token")
+	if tokenValue == "" {
+		writeOAuthError(w, autherrors.ErrEmptyTokenParam)
+		return
+	}
+
+	if err := h.authService.Revoke(tokenValue, clientID); err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+// authenticateClient checks the credentials of a confidential client sent with HTTP Basic authentication
+// or, as a fallback, in the client_id and client_secret form parameters (RFC 6749, section 2.3.1).
+func (h *OAuthHandler) authenticateClient(r *http.Request) error {
+	clientID, clientSecret, err := clientCredentials(r)
+	if err != nil {
+		return err
+	}
+
+	if clientID == "" {
+		return autherrors.ErrInvalidClient
+	}
+
+	return h.clients.AuthenticateClient(clientID, clientSecret)
+}
+
+// identifyClient returns the ID of the client making the request, if it sent one. Confidential clients
+// must authenticate like in authenticateClient, public clients send their client_id only.
+// Requests without any client credentials are let through with an empty client ID.
+func (h *OAuthHandler) identifyClient(r *http.Request) (string, error) {
+	clientID, clientSecret, err := clientCredentials(r)
+	if err != nil {
+		return "", err
+	}
+
+	if clientID == "" && clientSecret == "" {
+		return "", nil
+	}
+
+	client, err := h.clients.IdentifyClient(clientID, clientSecret)
+	if err != nil {
+		return "", err
+	}
+
+	return client.ID, nil
+}
+
+// clientCredentials reads the client ID and secret from the HTTP Basic credentials
+// or the client_id and client_secret form parameters.
+func clientCredentials(r *http.Request) (clientID string, clientSecret string, err error) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret"), nil
+	}
+
+	// Basic credentials of OAuth clients are form-encoded before being joined
+	if clientID, err = url.QueryUnescape(clientID); err != nil {
+		return "", "", autherrors.ErrInvalidClient
+	}
+	if clientSecret, err = url.QueryUnescape(clientSecret); err != nil {
+		return "", "", autherrors.ErrInvalidClient
+	}
+
+	return clientID, clientSecret, nil
+}
+
+// authorizationRequest reads the parameters of an authorization request.
+func authorizationRequest(values url.Values) *models.AuthorizationRequest {
+	return &models.AuthorizationRequest{
+		ResponseType:        values.Get("response_type"),
+		ClientID:            values.Get("client_id"),
+		RedirectURI:         values.Get("redirect_uri"),
+		State:               values.Get("state"),
+		CodeChallenge:       values.Get("code_challenge"),
+		CodeChallengeMethod: values.Get("code_challenge_method"),
+		Scope:               values.Get("scope"),
+		Nonce:               values.Get("nonce"),
+	}
+}
+
+// writeAuthorizeErrorPage tells the user that the authorization request can't be processed.
+func writeAuthorizeErrorPage(w http.ResponseWriter, err error) {
+	status, _, description := oauthErrorStatus(err)
+	switch status {
+	case http.StatusInternalServerError:
+		log.Printf("internal error: %v", err)
+		description = msgInternalError
+	case http.StatusUnauthorized:
+		// An unknown client can't be authenticated interactively, so it is just a bad request
+		status = http.StatusBadRequest
+		description = "unknown client"
+	}
+
+	writeHTML(w, status, authorizeErrorPage, description)
+}
+
+// redirectWithError sends the error of an authorization request back to the client (RFC 6749, section 4.1.2.1).
+func redirectWithError(w http.ResponseWriter, r *http.Request, req *models.AuthorizationRequest, err error) {
+	_, code, description := oauthErrorStatus(err)
+	if code == oauthServerError {
+		log.Printf("internal error: %v", err)
+	}
+
+	params := url.Values{"error": {code}, "state": {req.State}}
+	if description != "" {
+		params.Set("error_description", description)
+	}
+	redirectWithParams(w, r, req.RedirectURI, params)
+}
+
+// redirectWithParams redirects to the registered redirect URI with params added to its query.
+// Empty params are left out.
+func redirectWithParams(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values) {
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		writeAuthorizeErrorPage(w, autherrors.ErrInvalidRedirectURI)
+		return
+	}
+
+	query := target.Query()
+	for name, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			query.Set(name, values[0])
+		}
+	}
+	target.RawQuery = query.Encode()
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
@@ -0,0 +1,1332 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+	"github.com/breakfront-planner/auth-service/internal/totp"
+	"github.com/breakfront-planner/auth-service/internal/validators"
+)
+
+const (
+	testClientID        = "api-gateway"
+	testClientSecret    = "gateway-secret"
+	testRedirectURI     = "https://planner.example.com/callback?source=login"
+	testCodeVerifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge   = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	testServiceSecret   = "export-secret"
+	testIssuer          = "https://auth.breakfront.test/"
+	testVerificationURI = "https://planner.example.com/device"
+	testAudience        = "notifications"
+)
+
+type OAuthHandlerTestSuite struct {
+	suite.Suite
+	ctrl             *gomock.Controller
+	mockUserRepo     *mocks.MockIUserRepository
+	mockTokenRepo    *mocks.MockITokenRepository
+	mockDenylistRepo *mocks.MockIDenylistRepository
+	mockCodeRepo     *mocks.MockIAuthorizationCodeRepository
+	mockDeviceRepo   *mocks.MockIDeviceAuthorizationRepository
+	mockMFARepo      *mocks.MockIMFARepository
+	jwtManager       *jwt.Manager
+	router           http.Handler
+	testUser         *models.User
+	testPassword     string
+	publicClient     *models.Client
+	serviceAccount   *models.Client
+	// totpCredential is the test user's authenticator; tests set it to require two-factor authentication
+	totpCredential *models.TOTPCredential
+}
+
+func (s *OAuthHandlerTestSuite) SetupSuite() {
+	err := godotenv.Load("../../.env.test")
+	require.NoError(s.T(), err, "Failed to load .env.test")
+
+	jwtSecret := os.Getenv("TEST_JWT_SECRET")
+	require.NotEmpty(s.T(), jwtSecret, "TEST_JWT_SECRET must be set in .env.test")
+
+	s.jwtManager = jwt.NewManager(jwtSecret, 10*time.Minute, time.Hour)
+	s.testUser = &models.User{
+		ID:          uuid.New(),
+		Login:       os.Getenv("TEST_LOGIN"),
+		Roles:       []string{"editor"},
+		Permissions: []string{"plans:read", "plans:write"},
+	}
+	s.testPassword = os.Getenv("TEST_PASS")
+	require.NotEmpty(s.T(), s.testPassword, "TEST_PASS must be set in .env.test")
+
+	s.testUser.PasswordHash, err = services.NewHashService().HashPassword(s.testPassword)
+	require.NoError(s.T(), err)
+
+	s.publicClient = &models.Client{
+		ID:           "planner-spa",
+		Name:         "Breakfront Planner",
+		RedirectURIs: []string{testRedirectURI},
+		Scopes:       []string{constants.ScopeOpenID, "plans:read"},
+	}
+	s.serviceAccount = &models.Client{
+		ID:         "nightly-export",
+		Name:       "Nightly export",
+		SecretHash: services.NewHashService().HashToken(testServiceSecret),
+		Scopes:     []string{"plans:read", "users:read"},
+	}
+}
+
+func (s *OAuthHandlerTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockUserRepo = mocks.NewMockIUserRepository(s.ctrl)
+	s.mockTokenRepo = mocks.NewMockITokenRepository(s.ctrl)
+	s.mockDenylistRepo = mocks.NewMockIDenylistRepository(s.ctrl)
+	s.mockCodeRepo = mocks.NewMockIAuthorizationCodeRepository(s.ctrl)
+	s.mockDeviceRepo = mocks.NewMockIDeviceAuthorizationRepository(s.ctrl)
+	s.mockMFARepo = mocks.NewMockIMFARepository(s.ctrl)
+
+	s.totpCredential = nil
+	s.mockMFARepo.EXPECT().
+		FindTOTP(gomock.Any()).
+		DoAndReturn(func(uuid.UUID) (*models.TOTPCredential, error) {
+			return s.totpCredential, nil
+		}).
+		AnyTimes()
+
+	mockClientRepo := mocks.NewMockIClientRepository(s.ctrl)
+	mockClientRepo.EXPECT().
+		FindClient(gomock.Any()).
+		DoAndReturn(func(clientID string) (*models.Client, error) {
+			for _, client := range []*models.Client{s.publicClient, s.serviceAccount} {
+				if client.ID == clientID {
+					return client, nil
+				}
+			}
+			return nil, nil
+		}).
+		AnyTimes()
+
+	mockRoleRepo := mocks.NewMockIRoleRepository(s.ctrl)
+	mockRoleRepo.EXPECT().
+		FindUserRoles(gomock.Any()).
+		Return([]*models.Role{{Name: "editor", Permissions: []string{"plans:read", "plans:write"}}}, nil).
+		AnyTimes()
+
+	hashService := services.NewHashService()
+	userService := services.NewUserService(s.mockUserRepo, hashService)
+	tokenService := services.NewTokenService(s.mockTokenRepo, mocks.NewMockISecurityEventRepository(s.ctrl), hashService, s.jwtManager)
+	denylist := services.NewDenylistService(s.mockDenylistRepo)
+	tokenValidator := validators.NewTokenValidator(s.jwtManager, userService, denylist)
+	roleService := services.NewRoleService(mockRoleRepo)
+	mfaService := services.NewMFAService(s.mockMFARepo, userService, hashService, "Breakfront")
+	authService := services.NewAuthService(tokenService, userService, tokenValidator, denylist, roleService,
+		services.WithMFA(mfaService))
+	clientService := services.NewClientService(mockClientRepo, hashService, map[string]string{testClientID: testClientSecret})
+	oauthService := services.NewOAuthService(clientService, s.mockCodeRepo, authService, userService, roleService,
+		tokenService, hashService, time.Minute, services.WithOpenIDConnect())
+	deviceService := services.NewDeviceAuthorizationService(clientService, s.mockDeviceRepo, userService, roleService,
+		tokenService, hashService, testVerificationURI, 10*time.Minute, 5*time.Second)
+	exchangeService := services.NewTokenExchangeService(clientService, tokenValidator, tokenService,
+		[]string{"planner-api", testAudience})
+
+	s.router = NewRouter(NewAuthHandler(authService), NewJWKSHandler(s.jwtManager.KeySet()),
+		NewOAuthHandler(authService, oauthService, deviceService, exchangeService, clientService),
+		NewOIDCHandler(oauthService, testIssuer, s.jwtManager.KeySet().Active().Method.Alg(), true), nil, nil, nil, tokenValidator)
+}
+
+func (s *OAuthHandlerTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *OAuthHandlerTestSuite) generateToken(tokenType constants.TokenType) string {
+	return s.generateTokenForClient(tokenType, "")
+}
+
+// generateTokenForClient returns a token of the test user issued to the OAuth client with the given scopes.
+func (s *OAuthHandlerTestSuite) generateTokenForClient(tokenType constants.TokenType, clientID string, scopes ...string) string {
+	token, err := s.jwtManager.GenerateTokenForClient(s.testUser, tokenType, clientID, scopes)
+	require.NoError(s.T(), err)
+	return token.Value
+}
+
+// introspect posts the form to the introspection endpoint, authenticating with HTTP Basic if clientID is set.
+func (s *OAuthHandlerTestSuite) introspect(form url.Values, clientID, clientSecret string) *httptest.ResponseRecorder {
+	return s.postForm("/oauth/introspect", form, clientID, clientSecret)
+}
+
+// revoke posts the form to the revocation endpoint, authenticating with HTTP Basic if clientID is set.
+func (s *OAuthHandlerTestSuite) revoke(form url.Values, clientID, clientSecret string) *httptest.ResponseRecorder {
+	return s.postForm("/oauth/revoke", form, clientID, clientSecret)
+}
+
+func (s *OAuthHandlerTestSuite) postForm(path string, form url.Values, clientID, clientSecret string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientID != "" {
+		req.SetBasicAuth(clientID, clientSecret)
+	}
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func (s *OAuthHandlerTestSuite) decodeIntrospection(rec *httptest.ResponseRecorder) IntrospectionResponse {
+	var resp IntrospectionResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	return resp
+}
+
+// authorizeParams returns the parameters of a valid authorization request from the public client.
+func (s *OAuthHandlerTestSuite) authorizeParams() url.Values {
+	return url.Values{
+		"response_type":         {constants.ResponseTypeCode},
+		"client_id":             {s.publicClient.ID},
+		"redirect_uri":          {testRedirectURI},
+		"state":                 {"af0ifjsldkj"},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {constants.CodeChallengeMethodS256},
+	}
+}
+
+func (s *OAuthHandlerTestSuite) getAuthorize(params url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+params.Encode(), nil)
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// redirectParams checks that the response redirects to the registered redirect URI and returns the query.
+func (s *OAuthHandlerTestSuite) redirectParams(rec *httptest.ResponseRecorder) url.Values {
+	require.Equal(s.T(), http.StatusFound, rec.Code)
+
+	location, err := url.Parse(rec.Header().Get("Location"))
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "https://planner.example.com/callback", location.Scheme+"://"+location.Host+location.Path)
+	assert.Equal(s.T(), "login", location.Query().Get("source"), "the redirect URI query must be preserved")
+
+	return location.Query()
+}
+
+func (s *OAuthHandlerTestSuite) decodeOAuthError(rec *httptest.ResponseRecorder) OAuthErrorResponse {
+	var resp OAuthErrorResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	return resp
+}
+
+func (s *OAuthHandlerTestSuite) TestIntrospectActiveAccessToken() {
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, nil)
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	rec := s.introspect(url.Values{
+		"token":           {s.generateToken(constants.TokenTypeAccess)},
+		"token_type_hint": {constants.TokenTypeHintAccess},
+	}, testClientID, testClientSecret)
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Equal(s.T(), "no-store", rec.Header().Get("Cache-Control"))
+
+	resp := s.decodeIntrospection(rec)
+	assert.True(s.T(), resp.Active)
+	assert.Equal(s.T(), s.testUser.ID.String(), resp.Subject)
+	assert.Equal(s.T(), constants.TokenTypeHintAccess, resp.TokenType)
+	assert.Equal(s.T(), "plans:read plans:write", resp.Scope)
+	assert.Equal(s.T(), []string{"editor"}, resp.Roles)
+	assert.NotEmpty(s.T(), resp.JTI)
+	assert.Greater(s.T(), resp.ExpiresAt, time.Now().Unix())
+}
+
+func (s *OAuthHandlerTestSuite) TestIntrospectRefreshToken() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		Times(2)
+
+	s.mockTokenRepo.EXPECT().
+		FindToken(gomock.Any()).
+		Return(nil)
+
+	refreshToken := s.generateToken(constants.TokenTypeRefresh)
+
+	rec := s.introspect(url.Values{"token": {refreshToken}}, testClientID, testClientSecret)
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	resp := s.decodeIntrospection(rec)
+	assert.True(s.T(), resp.Active)
+	assert.Equal(s.T(), constants.TokenTypeHintRefresh, resp.TokenType)
+
+	s.mockTokenRepo.EXPECT().
+		FindToken(gomock.Any()).
+		Return(autherrors.ErrInvalidToken(autherrors.ErrTokenRevoked))
+
+	rec = s.introspect(url.Values{"token": {refreshToken}}, testClientID, testClientSecret)
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Equal(s.T(), IntrospectionResponse{Active: false}, s.decodeIntrospection(rec))
+}
+
+func (s *OAuthHandlerTestSuite) TestIntrospectInactiveToken() {
+	rec := s.introspect(url.Values{"token": {"invalid.jwt.token"}}, testClientID, testClientSecret)
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Equal(s.T(), IntrospectionResponse{Active: false}, s.decodeIntrospection(rec))
+}
+
+func (s *OAuthHandlerTestSuite) TestIntrospectClientSecretPost() {
+	rec := s.introspect(url.Values{
+		"token":         {"invalid.jwt.token"},
+		"client_id":     {testClientID},
+		"client_secret": {testClientSecret},
+	}, "", "")
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+}
+
+func (s *OAuthHandlerTestSuite) TestIntrospectClientAuthenticationFailed() {
+	testCases := []struct {
+		name         string
+		clientID     string
+		clientSecret string
+	}{
+		{name: "no credentials"},
+		{name: "wrong secret", clientID: testClientID, clientSecret: "wrong"},
+		{name: "unknown client", clientID: "legacy-service", clientSecret: testClientSecret},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			rec := s.introspect(url.Values{"token": {s.generateToken(constants.TokenTypeAccess)}}, tc.clientID, tc.clientSecret)
+
+			assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+			assert.Contains(s.T(), rec.Header().Get("WWW-Authenticate"), "Basic")
+			assert.Equal(s.T(), "invalid_client", s.decodeOAuthError(rec).Error)
+		})
+	}
+}
+
+func (s *OAuthHandlerTestSuite) TestIntrospectMissingToken() {
+	rec := s.introspect(url.Values{}, testClientID, testClientSecret)
+
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Equal(s.T(), "invalid_request", s.decodeOAuthError(rec).Error)
+}
+
+func (s *OAuthHandlerTestSuite) TestIntrospectStorageError() {
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, errors.New("database error"))
+
+	rec := s.introspect(url.Values{"token": {s.generateToken(constants.TokenTypeAccess)}}, testClientID, testClientSecret)
+
+	assert.Equal(s.T(), http.StatusInternalServerError, rec.Code)
+	resp := s.decodeOAuthError(rec)
+	assert.Equal(s.T(), "server_error", resp.Error)
+	assert.Empty(s.T(), resp.ErrorDescription)
+}
+
+func (s *OAuthHandlerTestSuite) TestRevokeAccessToken() {
+	accessToken := s.generateTokenForClient(constants.TokenTypeAccess, testClientID)
+	parsedToken, err := s.jwtManager.ParseToken(accessToken)
+	require.NoError(s.T(), err)
+
+	s.mockDenylistRepo.EXPECT().
+		SaveEntry(gomock.Any()).
+		DoAndReturn(func(entry *models.DenylistEntry) error {
+			assert.Equal(s.T(), parsedToken.JTI, entry.JTI)
+			return nil
+		})
+
+	s.mockDenylistRepo.EXPECT().
+		DeleteExpiredEntries().
+		Return(nil)
+
+	rec := s.revoke(url.Values{
+		"token":           {accessToken},
+		"token_type_hint": {constants.TokenTypeHintAccess},
+	}, testClientID, testClientSecret)
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Empty(s.T(), rec.Body.String())
+}
+
+func (s *OAuthHandlerTestSuite) TestRevokeRefreshTokenAsPublicClient() {
+	s.mockTokenRepo.EXPECT().
+		FindToken(gomock.Any()).
+		Return(nil)
+
+	s.mockTokenRepo.EXPECT().
+		RevokeToken(gomock.Any()).
+		Return(nil)
+
+	rec := s.revoke(url.Values{
+		"token":           {s.generateTokenForClient(constants.TokenTypeRefresh, s.publicClient.ID)},
+		"token_type_hint": {constants.TokenTypeHintRefresh},
+		"client_id":       {s.publicClient.ID},
+	}, "", "")
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+}
+
+func (s *OAuthHandlerTestSuite) TestRevokeOtherClientsToken() {
+	rec := s.revoke(url.Values{
+		"token": {s.generateTokenForClient(constants.TokenTypeRefresh, s.publicClient.ID)},
+	}, testClientID, testClientSecret)
+	assert.Equal(s.T(), http.StatusOK, rec.Code, "tokens of other clients must be left alone without telling")
+
+	rec = s.revoke(url.Values{
+		"token":     {s.generateToken(constants.TokenTypeAccess)},
+		"client_id": {s.publicClient.ID},
+	}, "", "")
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+
+	rec = s.revoke(url.Values{
+		"token": {s.generateTokenForClient(constants.TokenTypeAccess, s.publicClient.ID)},
+	}, "", "")
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+}
+
+func (s *OAuthHandlerTestSuite) TestRevokeUnknownTokens() {
+	s.mockTokenRepo.EXPECT().
+		FindToken(gomock.Any()).
+		Return(autherrors.ErrInvalidToken(autherrors.ErrTokenRevoked))
+
+	rec := s.revoke(url.Values{"token": {s.generateToken(constants.TokenTypeRefresh)}}, "", "")
+	assert.Equal(s.T(), http.StatusOK, rec.Code, "already revoked tokens must be accepted")
+
+	rec = s.revoke(url.Values{"token": {"invalid.jwt.token"}}, "", "")
+	assert.Equal(s.T(), http.StatusOK, rec.Code, "unknown tokens must be accepted")
+}
+
+func (s *OAuthHandlerTestSuite) TestRevokeErrors() {
+	rec := s.revoke(url.Values{"token": {"invalid.jwt.token"}}, testClientID, "wrong")
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Equal(s.T(), "invalid_client", s.decodeOAuthError(rec).Error)
+
+	rec = s.revoke(url.Values{"token": {"invalid.jwt.token"}, "client_id": {testClientID}}, "", "")
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code, "confidential clients must authenticate")
+	assert.Equal(s.T(), "invalid_client", s.decodeOAuthError(rec).Error)
+
+	rec = s.revoke(url.Values{}, "", "")
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Equal(s.T(), "invalid_request", s.decodeOAuthError(rec).Error)
+
+	s.mockTokenRepo.EXPECT().
+		FindToken(gomock.Any()).
+		Return(autherrors.ErrCheckToken(errors.New("database error")))
+
+	rec = s.revoke(url.Values{"token": {s.generateToken(constants.TokenTypeRefresh)}}, "", "")
+	assert.Equal(s.T(), http.StatusInternalServerError, rec.Code)
+	assert.Equal(s.T(), "server_error", s.decodeOAuthError(rec).Error)
+}
+
+func (s *OAuthHandlerTestSuite) TestAuthorizeShowsLoginForm() {
+	rec := s.getAuthorize(s.authorizeParams())
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Equal(s.T(), "DENY", rec.Header().Get("X-Frame-Options"))
+	assert.Contains(s.T(), rec.Body.String(), s.publicClient.Name)
+	assert.Contains(s.T(), rec.Body.String(), `name="code_challenge" value="`+testCodeChallenge+`"`)
+}
+
+func (s *OAuthHandlerTestSuite) TestAuthorizeRejectsUntrustedRedirect() {
+	testCases := []struct {
+		name   string
+		modify func(params url.Values)
+	}{
+		{name: "unknown client", modify: func(params url.Values) { params.Set("client_id", "unknown-app") }},
+		{name: "unregistered redirect URI", modify: func(params url.Values) { params.Set("redirect_uri", "https://attacker.example.com/") }},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			params := s.authorizeParams()
+			tc.modify(params)
+
+			rec := s.getAuthorize(params)
+
+			assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+			assert.Empty(s.T(), rec.Header().Get("Location"), "errors must not be sent to untrusted redirect URIs")
+		})
+	}
+}
+
+func (s *OAuthHandlerTestSuite) TestAuthorizeRedirectsRequestErrors() {
+	testCases := []struct {
+		name   string
+		modify func(params url.Values)
+		error  string
+	}{
+		{name: "implicit grant", modify: func(params url.Values) { params.Set("response_type", "token") }, error: "unsupported_response_type"},
+		{name: "missing PKCE", modify: func(params url.Values) { params.Del("code_challenge") }, error: "invalid_request"},
+		{name: "plain PKCE", modify: func(params url.Values) { params.Set("code_challenge_method", "plain") }, error: "invalid_request"},
+		{name: "scope not allowed", modify: func(params url.Values) { params.Set("scope", "openid plans:write") }, error: "invalid_scope"},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			params := s.authorizeParams()
+			tc.modify(params)
+
+			query := s.redirectParams(s.getAuthorize(params))
+
+			assert.Equal(s.T(), tc.error, query.Get("error"))
+			assert.Equal(s.T(), "af0ifjsldkj", query.Get("state"))
+		})
+	}
+}
+
+func (s *OAuthHandlerTestSuite) TestAuthorizationCodeFlow() {
+	var savedCode *models.AuthorizationCode
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		AnyTimes()
+	s.mockCodeRepo.EXPECT().
+		SaveCode(gomock.Any()).
+		DoAndReturn(func(code *models.AuthorizationCode) error {
+			savedCode = code
+			return nil
+		})
+	s.mockCodeRepo.EXPECT().DeleteExpiredCodes().Return(nil)
+
+	form := s.authorizeParams()
+	form.Set("login", s.testUser.Login)
+	form.Set("password", s.testPassword)
+
+	query := s.redirectParams(s.postForm("/oauth/authorize", form, "", ""))
+
+	code := query.Get("code")
+	require.NotEmpty(s.T(), code)
+	assert.Equal(s.T(), "af0ifjsldkj", query.Get("state"))
+	require.NotNil(s.T(), savedCode)
+	assert.Equal(s.T(), s.testUser.ID, savedCode.UserID)
+
+	s.mockCodeRepo.EXPECT().
+		ConsumeCode(savedCode.CodeHash, s.publicClient.ID).
+		Return(savedCode, nil)
+	s.mockTokenRepo.EXPECT().
+		SaveTokenForCode(gomock.Any(), savedCode.CodeHash).
+		Return(nil)
+
+	rec := s.postForm("/oauth/token", url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"client_id":     {s.publicClient.ID},
+		"code_verifier": {testCodeVerifier},
+	}, "", "")
+
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(s.T(), "no-store", rec.Header().Get("Cache-Control"))
+
+	var resp OAuthTokenResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(s.T(), "Bearer", resp.TokenType)
+	assert.Positive(s.T(), resp.ExpiresIn)
+	assert.NotEmpty(s.T(), resp.RefreshToken)
+
+	parsed, err := s.jwtManager.ParseToken(resp.AccessToken)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.testUser.ID, parsed.UserID)
+}
+
+func (s *OAuthHandlerTestSuite) TestOpenIDAuthorizationCodeFlow() {
+	var savedCode *models.AuthorizationCode
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		AnyTimes()
+	s.mockCodeRepo.EXPECT().
+		SaveCode(gomock.Any()).
+		DoAndReturn(func(code *models.AuthorizationCode) error {
+			code.CreatedAt = time.Now().UTC().Truncate(time.Second)
+			savedCode = code
+			return nil
+		})
+	s.mockCodeRepo.EXPECT().DeleteExpiredCodes().Return(nil)
+
+	form := s.authorizeParams()
+	form.Set("scope", "openid")
+	form.Set("nonce", "n-0S6_WzA2Mj")
+	form.Set("login", s.testUser.Login)
+	form.Set("password", s.testPassword)
+
+	code := s.redirectParams(s.postForm("/oauth/authorize", form, "", "")).Get("code")
+	require.NotEmpty(s.T(), code)
+
+	s.mockCodeRepo.EXPECT().
+		ConsumeCode(savedCode.CodeHash, s.publicClient.ID).
+		Return(savedCode, nil)
+	s.mockTokenRepo.EXPECT().
+		SaveTokenForCode(gomock.Any(), savedCode.CodeHash).
+		Return(nil)
+
+	rec := s.postForm("/oauth/token", url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"client_id":     {s.publicClient.ID},
+		"code_verifier": {testCodeVerifier},
+	}, "", "")
+
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp OAuthTokenResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(s.T(), constants.ScopeOpenID, resp.Scope)
+	require.NotEmpty(s.T(), resp.IDToken)
+
+	parsed, err := s.jwtManager.ParseToken(resp.IDToken)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), string(constants.TokenTypeID), parsed.Type)
+	assert.Equal(s.T(), s.testUser.ID, parsed.UserID)
+	assert.Equal(s.T(), []string{s.publicClient.ID}, parsed.Audience)
+
+	claims := jwtlib.MapClaims{}
+	_, _, err = jwtlib.NewParser().ParseUnverified(resp.IDToken, claims)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "n-0S6_WzA2Mj", claims["nonce"])
+	assert.EqualValues(s.T(), savedCode.CreatedAt.Unix(), claims["auth_time"])
+
+	userInfo := httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+	userInfo.Header.Set("Authorization", "Bearer "+resp.IDToken)
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, userInfo)
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code, "ID tokens must not be accepted as access tokens")
+}
+
+func (s *OAuthHandlerTestSuite) TestDiscovery() {
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.NotEmpty(s.T(), rec.Header().Get("Cache-Control"))
+
+	var metadata ProviderMetadata
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&metadata))
+	assert.Equal(s.T(), "https://auth.breakfront.test", metadata.Issuer)
+	assert.Equal(s.T(), "https://auth.breakfront.test/oauth/token", metadata.TokenEndpoint)
+	assert.Equal(s.T(), "https://auth.breakfront.test/userinfo", metadata.UserInfoEndpoint)
+	assert.Equal(s.T(), "https://auth.breakfront.test/.well-known/jwks.json", metadata.JWKSURI)
+	assert.Equal(s.T(), "https://auth.breakfront.test/oauth/device_authorization", metadata.DeviceAuthorizationEndpoint)
+	assert.Equal(s.T(), []string{"HS256"}, metadata.IDTokenSigningAlgValuesSupported)
+	assert.Contains(s.T(), metadata.ScopesSupported, constants.ScopeOpenID)
+	assert.Contains(s.T(), metadata.CodeChallengeMethodsSupported, constants.CodeChallengeMethodS256)
+}
+
+func (s *OAuthHandlerTestSuite) TestWithoutDeviceGrant() {
+	s.router = NewRouter(NewAuthHandler(nil), NewJWKSHandler(s.jwtManager.KeySet()), NewOAuthHandler(nil, nil, nil, nil, nil),
+		nil, nil, nil, nil, nil)
+
+	rec := s.postForm("/oauth/device_authorization", url.Values{"client_id": {s.publicClient.ID}}, "", "")
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code)
+
+	rec = s.postForm("/oauth/token", url.Values{"grant_type": {grantTypeDeviceCode}, "device_code": {"code"}}, "", "")
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Equal(s.T(), oauthUnsupportedGrantType, s.decodeOAuthError(rec).Error)
+
+	metadata := NewProviderMetadata(testIssuer, "RS256", false)
+	assert.Empty(s.T(), metadata.DeviceAuthorizationEndpoint)
+	assert.NotContains(s.T(), metadata.GrantTypesSupported, grantTypeDeviceCode)
+}
+
+func (s *OAuthHandlerTestSuite) TestUserInfo() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		Times(2)
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+s.generateTokenForClient(constants.TokenTypeAccess, s.publicClient.ID,
+		constants.ScopeOpenID))
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(s.T(), "no-store", rec.Header().Get("Cache-Control"))
+
+	var resp UserInfoResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(s.T(), s.testUser.ID.String(), resp.Subject)
+	assert.Equal(s.T(), s.testUser.Login, resp.PreferredUsername)
+}
+
+func (s *OAuthHandlerTestSuite) TestUserInfoWithoutOpenIDScope() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		AnyTimes()
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, nil).
+		AnyTimes()
+
+	req := httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+s.generateTokenForClient(constants.TokenTypeAccess, s.publicClient.ID))
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code)
+}
+
+func (s *OAuthHandlerTestSuite) TestUserInfoWithoutToken() {
+	req := httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+}
+
+func (s *OAuthHandlerTestSuite) TestAuthorizeWrongPasswordShowsForm() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	form := s.authorizeParams()
+	form.Set("login", s.testUser.Login)
+	form.Set("password", "wrong-password")
+
+	rec := s.postForm("/oauth/authorize", form, "", "")
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Empty(s.T(), rec.Header().Get("Location"))
+	assert.Contains(s.T(), rec.Body.String(), msgInvalidCredentials)
+}
+
+func (s *OAuthHandlerTestSuite) TestAuthorizeWithMFA() {
+	secret, err := totp.GenerateSecret()
+	require.NoError(s.T(), err)
+	confirmedAt := time.Now().Add(-time.Hour)
+	s.totpCredential = &models.TOTPCredential{UserID: s.testUser.ID, Secret: secret, ConfirmedAt: &confirmedAt}
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		AnyTimes()
+
+	form := s.authorizeParams()
+	form.Set("login", s.testUser.Login)
+	form.Set("password", s.testPassword)
+
+	rec := s.postForm("/oauth/authorize", form, "", "")
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Empty(s.T(), rec.Header().Get("Location"), "no code may be issued before the second factor")
+	body := rec.Body.String()
+	assert.Contains(s.T(), body, `name="code"`)
+	assert.NotContains(s.T(), body, `name="password"`)
+	match := regexp.MustCompile(`name="mfa_token" value="([^"]+)"`).FindStringSubmatch(body)
+	require.Len(s.T(), match, 2)
+	mfaToken := match[1]
+
+	// A wrong code shows the code form again
+	s.mockDenylistRepo.EXPECT().IsDenylisted(gomock.Any()).Return(false, nil).Times(2)
+	s.mockMFARepo.EXPECT().UseRecoveryCode(s.testUser.ID, gomock.Any()).Return(false, nil)
+
+	form = s.authorizeParams()
+	form.Set("mfa_token", mfaToken)
+	form.Set("code", "ABCDE-FGHJK")
+	rec = s.postForm("/oauth/authorize", form, "", "")
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Contains(s.T(), rec.Body.String(), autherrors.ErrInvalidMFACode.Error())
+	assert.Contains(s.T(), rec.Body.String(), `name="mfa_token" value="`+mfaToken+`"`)
+
+	s.mockMFARepo.EXPECT().UseTOTPStep(s.testUser.ID, gomock.Any()).Return(true, nil)
+	s.mockDenylistRepo.EXPECT().SaveEntry(gomock.Any()).Return(nil)
+	s.mockDenylistRepo.EXPECT().DeleteExpiredEntries().Return(nil)
+	s.mockCodeRepo.EXPECT().SaveCode(gomock.Any()).Return(nil)
+	s.mockCodeRepo.EXPECT().DeleteExpiredCodes().Return(nil)
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(s.T(), err)
+	form.Set("code", code)
+
+	query := s.redirectParams(s.postForm("/oauth/authorize", form, "", ""))
+
+	assert.NotEmpty(s.T(), query.Get("code"))
+	assert.Equal(s.T(), "af0ifjsldkj", query.Get("state"))
+}
+
+func (s *OAuthHandlerTestSuite) TestTokenErrors() {
+	testCases := []struct {
+		name   string
+		form   url.Values
+		status int
+		error  string
+	}{
+		{
+			name:   "missing grant type",
+			form:   url.Values{},
+			status: http.StatusBadRequest,
+			error:  "invalid_request",
+		},
+		{
+			name:   "unsupported grant type",
+			form:   url.Values{"grant_type": {"password"}},
+			status: http.StatusBadRequest,
+			error:  "unsupported_grant_type",
+		},
+		{
+			name:   "missing code verifier",
+			form:   url.Values{"grant_type": {"authorization_code"}, "client_id": {"planner-spa"}, "code": {"abc"}, "redirect_uri": {testRedirectURI}},
+			status: http.StatusBadRequest,
+			error:  "invalid_request",
+		},
+		{
+			name: "unknown client",
+			form: url.Values{"grant_type": {"authorization_code"}, "client_id": {"unknown-app"}, "code": {"abc"},
+				"redirect_uri": {testRedirectURI}, "code_verifier": {testCodeVerifier}},
+			status: http.StatusUnauthorized,
+			error:  "invalid_client",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			rec := s.postForm("/oauth/token", tc.form, "", "")
+
+			assert.Equal(s.T(), tc.status, rec.Code)
+			assert.Equal(s.T(), tc.error, s.decodeOAuthError(rec).Error)
+		})
+	}
+}
+
+func (s *OAuthHandlerTestSuite) TestTokenReportsFirstMissingParam() {
+	for range 10 {
+		rec := s.postForm("/oauth/token", url.Values{"grant_type": {"authorization_code"}, "client_id": {"planner-spa"}}, "", "")
+
+		require.Equal(s.T(), http.StatusBadRequest, rec.Code)
+		assert.Equal(s.T(), autherrors.ErrMissingParam("code").Error(), s.decodeOAuthError(rec).ErrorDescription)
+	}
+}
+
+func (s *OAuthHandlerTestSuite) TestTokenReusedCode() {
+	s.mockCodeRepo.EXPECT().
+		ConsumeCode(gomock.Any(), s.publicClient.ID).
+		Return(nil, autherrors.ErrCodeNotFound)
+	s.mockCodeRepo.EXPECT().
+		FindUsedCode(gomock.Any()).
+		Return(nil, autherrors.ErrCodeNotFound)
+
+	rec := s.postForm("/oauth/token", url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"already-used"},
+		"redirect_uri":  {testRedirectURI},
+		"client_id":     {s.publicClient.ID},
+		"code_verifier": {testCodeVerifier},
+	}, "", "")
+
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Equal(s.T(), "invalid_grant", s.decodeOAuthError(rec).Error)
+}
+
+func (s *OAuthHandlerTestSuite) TestRefreshOtherClientsToken() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		AnyTimes()
+	refreshToken := s.generateTokenForClient(constants.TokenTypeRefresh, s.publicClient.ID)
+
+	rec := s.postForm("/oauth/token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}, testClientID, testClientSecret)
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Equal(s.T(), "invalid_grant", s.decodeOAuthError(rec).Error)
+
+	rec = s.postForm("/oauth/token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}, "", "")
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code, "tokens of a client can't be refreshed without it")
+	assert.Equal(s.T(), "invalid_grant", s.decodeOAuthError(rec).Error)
+}
+
+func (s *OAuthHandlerTestSuite) TestClientCredentialsGrant() {
+	rec := s.postForm("/oauth/token", url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {"plans:read"},
+	}, s.serviceAccount.ID, testServiceSecret)
+
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp OAuthTokenResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(s.T(), "plans:read", resp.Scope)
+	assert.Empty(s.T(), resp.RefreshToken, "client tokens come without a refresh token")
+
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, nil)
+
+	rec = s.introspect(url.Values{"token": {resp.AccessToken}}, testClientID, testClientSecret)
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	introspection := s.decodeIntrospection(rec)
+	assert.True(s.T(), introspection.Active)
+	assert.Equal(s.T(), s.serviceAccount.ID, introspection.Subject)
+	assert.Equal(s.T(), s.serviceAccount.ID, introspection.ClientID)
+	assert.Equal(s.T(), "plans:read", introspection.Scope)
+}
+
+func (s *OAuthHandlerTestSuite) TestClientCredentialsErrors() {
+	testCases := []struct {
+		name         string
+		form         url.Values
+		clientID     string
+		clientSecret string
+		status       int
+		error        string
+	}{
+		{
+			name:         "wrong secret",
+			form:         url.Values{"grant_type": {"client_credentials"}},
+			clientID:     s.serviceAccount.ID,
+			clientSecret: "wrong",
+			status:       http.StatusUnauthorized,
+			error:        "invalid_client",
+		},
+		{
+			name:         "scope not allowed",
+			form:         url.Values{"grant_type": {"client_credentials"}, "scope": {"users:manage"}},
+			clientID:     s.serviceAccount.ID,
+			clientSecret: testServiceSecret,
+			status:       http.StatusBadRequest,
+			error:        "invalid_scope",
+		},
+		{
+			name:   "public client",
+			form:   url.Values{"grant_type": {"client_credentials"}, "client_id": {s.publicClient.ID}},
+			status: http.StatusBadRequest,
+			error:  "unauthorized_client",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			rec := s.postForm("/oauth/token", tc.form, tc.clientID, tc.clientSecret)
+
+			assert.Equal(s.T(), tc.status, rec.Code)
+			assert.Equal(s.T(), tc.error, s.decodeOAuthError(rec).Error)
+		})
+	}
+}
+
+// authorizedRequest builds a request to the auth service carrying a valid access token of the test user.
+func (s *OAuthHandlerTestSuite) authorizedRequest(method string, target string, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+s.generateToken(constants.TokenTypeAccess))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+func (s *OAuthHandlerTestSuite) TestDeviceAuthorizationFlow() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		AnyTimes()
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, nil).
+		AnyTimes()
+
+	var saved *models.DeviceAuthorization
+	s.mockDeviceRepo.EXPECT().
+		SaveDeviceAuthorization(gomock.Any()).
+		DoAndReturn(func(auth *models.DeviceAuthorization) error {
+			saved = auth
+			return nil
+		})
+	s.mockDeviceRepo.EXPECT().DeleteExpiredDeviceAuthorizations().Return(nil)
+
+	rec := s.postForm("/oauth/device_authorization", url.Values{
+		"client_id": {s.publicClient.ID},
+		"scope":     {"plans:read"},
+	}, "", "")
+
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var codes DeviceAuthorizationResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&codes))
+	assert.NotEmpty(s.T(), codes.DeviceCode)
+	assert.Regexp(s.T(), `^[A-Z]{4}-[A-Z]{4}$`, codes.UserCode)
+	assert.Equal(s.T(), testVerificationURI, codes.VerificationURI)
+	assert.Equal(s.T(), testVerificationURI+"?user_code="+codes.UserCode, codes.VerificationURIComplete)
+	assert.EqualValues(s.T(), 5, codes.Interval)
+	assert.InDelta(s.T(), 600, codes.ExpiresIn, 1)
+	require.NotNil(s.T(), saved)
+
+	// The user checks the request on the verification page and approves it
+	s.mockDeviceRepo.EXPECT().FindByUserCode(saved.UserCodeHash).Return(saved, nil)
+
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, s.authorizedRequest(http.MethodGet, "/oauth/device?user_code="+strings.ToLower(codes.UserCode), ""))
+
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var verification DeviceVerificationResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&verification))
+	assert.Equal(s.T(), s.publicClient.Name, verification.ClientName)
+	assert.Equal(s.T(), "plans:read", verification.Scope)
+
+	s.mockDeviceRepo.EXPECT().
+		DecideDeviceAuthorization(saved.UserCodeHash, s.testUser.ID, models.DeviceAuthorizationApproved).
+		DoAndReturn(func(string, uuid.UUID, models.DeviceAuthorizationStatus) (*models.DeviceAuthorization, error) {
+			saved.Status = models.DeviceAuthorizationApproved
+			saved.UserID = &s.testUser.ID
+			return saved, nil
+		})
+
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, s.authorizedRequest(http.MethodPost, "/oauth/device",
+		`{"user_code": "`+codes.UserCode+`", "approve": true}`))
+	require.Equal(s.T(), http.StatusNoContent, rec.Code, rec.Body.String())
+
+	// The device's next poll receives the tokens
+	s.mockDeviceRepo.EXPECT().PollDeviceAuthorization(saved.DeviceCodeHash, gomock.Any()).Return(saved, nil)
+	s.mockDeviceRepo.EXPECT().ConsumeDeviceAuthorization(saved.DeviceCodeHash).Return(saved, nil)
+	s.mockTokenRepo.EXPECT().
+		SaveToken(gomock.Any()).
+		Return(nil)
+
+	rec = s.postForm("/oauth/token", url.Values{
+		"grant_type":  {grantTypeDeviceCode},
+		"device_code": {codes.DeviceCode},
+		"client_id":   {s.publicClient.ID},
+	}, "", "")
+
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var resp OAuthTokenResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	assert.NotEmpty(s.T(), resp.RefreshToken)
+	assert.Equal(s.T(), "plans:read", resp.Scope)
+
+	parsed, err := s.jwtManager.ParseToken(resp.AccessToken)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.testUser.ID, parsed.UserID)
+}
+
+func (s *OAuthHandlerTestSuite) TestDeviceAuthorizationScopeNotAllowed() {
+	rec := s.postForm("/oauth/device_authorization", url.Values{
+		"client_id": {s.publicClient.ID},
+		"scope":     {"plans:write"},
+	}, "", "")
+
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Equal(s.T(), "invalid_scope", s.decodeOAuthError(rec).Error)
+}
+
+func (s *OAuthHandlerTestSuite) TestDeviceTokenErrors() {
+	recentPoll := time.Now().UTC().Add(-time.Second)
+
+	testCases := []struct {
+		name   string
+		modify func(auth *models.DeviceAuthorization)
+		error  string
+	}{
+		{name: "pending", modify: func(*models.DeviceAuthorization) {}, error: "authorization_pending"},
+		{name: "polling too fast", modify: func(a *models.DeviceAuthorization) { a.LastPolledAt = &recentPoll }, error: "slow_down"},
+		{name: "denied", modify: func(a *models.DeviceAuthorization) { a.Status = models.DeviceAuthorizationDenied }, error: "access_denied"},
+		{
+			name:   "expired",
+			modify: func(a *models.DeviceAuthorization) { a.ExpiresAt = time.Now().UTC().Add(-time.Second) },
+			error:  "expired_token",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			auth := &models.DeviceAuthorization{
+				ClientID:     s.publicClient.ID,
+				Status:       models.DeviceAuthorizationPending,
+				PollInterval: 5 * time.Second,
+				ExpiresAt:    time.Now().UTC().Add(time.Minute),
+			}
+			tc.modify(auth)
+			s.mockDeviceRepo.EXPECT().PollDeviceAuthorization(gomock.Any(), gomock.Any()).Return(auth, nil)
+			s.mockDeviceRepo.EXPECT().UpdatePollInterval(gomock.Any(), 10*time.Second).Return(nil).MaxTimes(1)
+
+			rec := s.postForm("/oauth/token", url.Values{
+				"grant_type":  {grantTypeDeviceCode},
+				"device_code": {"device-code"},
+				"client_id":   {s.publicClient.ID},
+			}, "", "")
+
+			assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+			assert.Equal(s.T(), tc.error, s.decodeOAuthError(rec).Error)
+		})
+	}
+
+	rec := s.postForm("/oauth/token", url.Values{"grant_type": {grantTypeDeviceCode}, "client_id": {s.publicClient.ID}}, "", "")
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Equal(s.T(), "invalid_request", s.decodeOAuthError(rec).Error)
+}
+
+func (s *OAuthHandlerTestSuite) TestDeviceVerificationErrors() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		AnyTimes()
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, nil).
+		AnyTimes()
+	s.mockDeviceRepo.EXPECT().FindByUserCode(gomock.Any()).Return(nil, autherrors.ErrDeviceCodeNotFound)
+	s.mockDeviceRepo.EXPECT().
+		DecideDeviceAuthorization(gomock.Any(), s.testUser.ID, models.DeviceAuthorizationDenied).
+		Return(nil, autherrors.ErrDeviceCodeNotFound)
+
+	testCases := []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{
+			name:   "unknown user code",
+			req:    s.authorizedRequest(http.MethodGet, "/oauth/device?user_code=BBBB-BBBB", ""),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "missing user code",
+			req:    s.authorizedRequest(http.MethodGet, "/oauth/device", ""),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "missing decision",
+			req:    s.authorizedRequest(http.MethodPost, "/oauth/device", `{"user_code": "BBBB-BBBB"}`),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "already decided",
+			req:    s.authorizedRequest(http.MethodPost, "/oauth/device", `{"user_code": "BBBB-BBBB", "approve": false}`),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "not signed in",
+			req:    httptest.NewRequest(http.MethodGet, "/oauth/device?user_code=BBBB-BBBB", nil),
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			rec := httptest.NewRecorder()
+			s.router.ServeHTTP(rec, tc.req)
+
+			assert.Equal(s.T(), tc.status, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestOAuthHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(OAuthHandlerTestSuite))
+}
+
+// exchangeForm returns the parameters of a token exchange request for the subject token.
+func exchangeForm(subjectToken string) url.Values {
+	return url.Values{
+		"grant_type":         {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {constants.TokenTypeURIAccessToken},
+		"audience":           {testAudience},
+		"scope":              {"plans:read"},
+	}
+}
+
+func (s *OAuthHandlerTestSuite) TestTokenExchange() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		AnyTimes()
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, nil).
+		AnyTimes()
+
+	rec := s.postForm("/oauth/token", exchangeForm(s.generateToken(constants.TokenTypeAccess)),
+		s.serviceAccount.ID, testServiceSecret)
+
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(s.T(), "no-store", rec.Header().Get("Cache-Control"))
+
+	var resp OAuthTokenResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(s.T(), constants.TokenTypeURIAccessToken, resp.IssuedTokenType)
+	assert.Equal(s.T(), constants.BearerScheme, resp.TokenType)
+	assert.Equal(s.T(), "plans:read", resp.Scope)
+	assert.Empty(s.T(), resp.RefreshToken, "exchanged tokens come without a refresh token")
+
+	rec = s.introspect(url.Values{"token": {resp.AccessToken}}, testClientID, testClientSecret)
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	introspection := s.decodeIntrospection(rec)
+	assert.True(s.T(), introspection.Active)
+	assert.Equal(s.T(), s.testUser.ID.String(), introspection.Subject)
+	assert.Equal(s.T(), []string{testAudience}, introspection.Audience)
+	assert.Equal(s.T(), "plans:read", introspection.Scope)
+	assert.Empty(s.T(), introspection.Roles, "roles would grant more than the requested scope")
+	assert.Equal(s.T(), &ActorClaim{Subject: s.serviceAccount.ID}, introspection.Actor)
+
+	// The notification service passes the delegated token on, keeping the chain of actors
+	form := exchangeForm(resp.AccessToken)
+	form.Set("audience", "planner-api")
+	form.Del("scope")
+	rec = s.postForm("/oauth/token", form, testClientID, testClientSecret)
+
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(s.T(), "plans:read", resp.Scope)
+
+	parsed, err := s.jwtManager.ParseToken(resp.AccessToken)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"planner-api"}, parsed.Audience)
+	assert.Equal(s.T(), &models.Actor{Subject: testClientID, Actor: &models.Actor{Subject: s.serviceAccount.ID}}, parsed.Actor)
+}
+
+func (s *OAuthHandlerTestSuite) TestTokenExchangeErrors() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil).
+		AnyTimes()
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(gomock.Any()).
+		Return(false, nil).
+		AnyTimes()
+
+	accessToken := s.generateToken(constants.TokenTypeAccess)
+	withParam := func(name, value string) url.Values {
+		form := exchangeForm(accessToken)
+		form.Set(name, value)
+		return form
+	}
+	withoutParam := func(name string) url.Values {
+		form := exchangeForm(accessToken)
+		form.Del(name)
+		return form
+	}
+
+	testCases := []struct {
+		name         string
+		form         url.Values
+		clientID     string
+		clientSecret string
+		status       int
+		error        string
+	}{
+		{
+			name:         "missing audience",
+			form:         withoutParam("audience"),
+			clientID:     s.serviceAccount.ID,
+			clientSecret: testServiceSecret,
+			status:       http.StatusBadRequest,
+			error:        "invalid_request",
+		},
+		{
+			name:         "unsupported subject token type",
+			form:         withParam("subject_token_type", "urn:ietf:params:oauth:token-type:refresh_token"),
+			clientID:     s.serviceAccount.ID,
+			clientSecret: testServiceSecret,
+			status:       http.StatusBadRequest,
+			error:        "invalid_request",
+		},
+		{
+			name:         "actor token",
+			form:         withParam("actor_token", accessToken),
+			clientID:     s.serviceAccount.ID,
+			clientSecret: testServiceSecret,
+			status:       http.StatusBadRequest,
+			error:        "invalid_request",
+		},
+		{
+			name:         "invalid subject token",
+			form:         withParam("subject_token", "not-a-token"),
+			clientID:     s.serviceAccount.ID,
+			clientSecret: testServiceSecret,
+			status:       http.StatusBadRequest,
+			error:        "invalid_request",
+		},
+		{
+			name:         "refresh token as subject token",
+			form:         withParam("subject_token", s.generateToken(constants.TokenTypeRefresh)),
+			clientID:     s.serviceAccount.ID,
+			clientSecret: testServiceSecret,
+			status:       http.StatusBadRequest,
+			error:        "invalid_request",
+		},
+		{
+			name:         "audience not allowed",
+			form:         withParam("audience", "billing"),
+			clientID:     s.serviceAccount.ID,
+			clientSecret: testServiceSecret,
+			status:       http.StatusBadRequest,
+			error:        "invalid_target",
+		},
+		{
+			name:         "scope beyond the subject token",
+			form:         withParam("scope", "plans:read users:manage"),
+			clientID:     s.serviceAccount.ID,
+			clientSecret: testServiceSecret,
+			status:       http.StatusBadRequest,
+			error:        "invalid_scope",
+		},
+		{
+			name:   "public client",
+			form:   withParam("client_id", s.publicClient.ID),
+			status: http.StatusBadRequest,
+			error:  "unauthorized_client",
+		},
+		{
+			name:         "wrong secret",
+			form:         exchangeForm(accessToken),
+			clientID:     s.serviceAccount.ID,
+			clientSecret: "wrong",
+			status:       http.StatusUnauthorized,
+			error:        "invalid_client",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			rec := s.postForm("/oauth/token", tc.form, tc.clientID, tc.clientSecret)
+
+			assert.Equal(s.T(), tc.status, rec.Code, rec.Body.String())
+			assert.Equal(s.T(), tc.error, s.decodeOAuthError(rec).Error)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/pkg/authmw"
+)
+
+// IUserInfoService defines the lookup of the user behind an access token.
+type IUserInfoService interface {
+	UserInfo(userID uuid.UUID) (*models.User, error)
+}
+
+// OIDCHandler serves the OpenID Connect discovery document and the userinfo endpoint,
+// so that tools speaking OpenID Connect can use the auth service as their identity provider.
+type OIDCHandler struct {
+	userInfo IUserInfoService
+	metadata *ProviderMetadata
+}
+
+// NewOIDCHandler creates a new OpenID Connect handler instance.
+// issuer is the base URL the auth service is reachable at, which must match the "iss" claim of issued tokens,
+// signingAlgorithm is the algorithm ID tokens are signed with and deviceGrant whether the device authorization
+// grant is enabled.
+func NewOIDCHandler(userInfo IUserInfoService, issuer string, signingAlgorithm string, deviceGrant bool) *OIDCHandler {
+	return &OIDCHandler{
+		userInfo: userInfo,
+		metadata: NewProviderMetadata(strings.TrimSuffix(issuer, "/"), signingAlgorithm, deviceGrant),
+	}
+}
+
+// Discovery responds with the OpenID Provider Metadata (OpenID Connect Discovery 1.0).
+func (h *OIDCHandler) Discovery(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, h.metadata)
+}
+
+// UserInfo responds with the claims of the user the access token was issued to.
+func (h *OIDCHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	user, err := h.userInfo.UserInfo(userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, NewUserInfoResponse(user))
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/pkg/authmw"
+)
+
+// publicKeyCredentialType is the only credential type of WebAuthn.
+const publicKeyCredentialType = "public-key"
+
+// IPasskeyService defines the passkey management of the signed-in user.
+type IPasskeyService interface {
+	FinishRegistration(userID uuid.UUID, name string, attestation *models.PasskeyAttestation) (*models.Passkey, error)
+	ListPasskeys(userID uuid.UUID) ([]*models.Passkey, error)
+	DeletePasskey(userID uuid.UUID, credentialID []byte) error
+}
+
+// IPasskeyAuthService defines the logins with passkeys, instead of a password or as the second factor after it.
+// Registering a passkey is started here too, as it requires re-authenticating the user.
+type IPasskeyAuthService interface {
+	BeginPasskeyRegistration(userID uuid.UUID, password string, code string, ipAddress string) (*models.PasskeyCreationOptions, error)
+	BeginPasskeyLogin() (*models.PasskeyRequestOptions, error)
+	PasskeyLogin(assertion *models.PasskeyAssertion, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error)
+	BeginPasskeyMFA(challengeTokenValue string) (*models.PasskeyRequestOptions, error)
+	VerifyPasskeyMFA(challengeTokenValue string, assertion *models.PasskeyAssertion, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error)
+}
+
+// PasskeyHandler serves the WebAuthn endpoints with which users register passkeys and sign in with them.
+// Each ceremony takes two requests: the first responds with the options for navigator.credentials,
+// the second sends back the authenticator's response.
+type PasskeyHandler struct {
+	passkeyService IPasskeyService
+	authService    IPasskeyAuthService
+}
+
+// NewPasskeyHandler creates a new passkey handler instance.
+func NewPasskeyHandler(passkeyService IPasskeyService, authService IPasskeyAuthService) *PasskeyHandler {
+	return &PasskeyHandler{
+		passkeyService: passkeyService,
+		authService:    authService,
+	}
+}
+
+// List responds with the passkeys of the authenticated user.
+func (h *PasskeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	passkeys, err := h.passkeyService.ListPasskeys(userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewPasskeysResponse(passkeys))
+}
+
+// RegistrationOptions starts registering a passkey for the authenticated user after checking their current password
+// or authentication code, and responds with the options for navigator.credentials.create.
+func (h *PasskeyHandler) RegistrationOptions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	var req PasskeyRegistrationOptionsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	options, err := h.authService.BeginPasskeyRegistration(userID, req.Password, req.Code, clientIP(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewPasskeyCreationOptionsResponse(options))
+}
+
+// Register finishes registering the passkey created by the browser and responds with it.
+func (h *PasskeyHandler) Register(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	var req PasskeyRegisterRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	passkey, err := h.passkeyService.FinishRegistration(userID, req.Name, req.Attestation())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, NewPasskeyResponse(passkey))
+}
+
+// Delete removes the passkey given in the path, by its base64url credential ID, from the authenticated user.
+func (h *PasskeyHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authmw.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, autherrors.ErrMissingAuthHeader)
+		return
+	}
+
+	credentialID, err := base64.RawURLEncoding.DecodeString(r.PathValue("id"))
+	if err != nil || len(credentialID) == 0 {
+		writeError(w, autherrors.ErrInvalidPasskeyID)
+		return
+	}
+
+	if err := h.passkeyService.DeletePasskey(userID, credentialID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LoginOptions starts a login with a passkey and responds with the options for navigator.credentials.get.
+func (h *PasskeyHandler) LoginOptions(w http.ResponseWriter, r *http.Request) {
+	options, err := h.authService.BeginPasskeyLogin()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewPasskeyRequestOptionsResponse(options))
+}
+
+// Login finishes a login with a passkey and responds with a fresh token pair.
+// The passkey replaces both the password and the second factor.
+func (h *PasskeyHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req PasskeyLoginRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	accessToken, refreshToken, err := h.authService.PasskeyLogin(req.Credential.Assertion(), sessionMetadata(r, req.DeviceName))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewTokenPairResponse(accessToken, refreshToken))
+}
+
+// MFAOptions starts checking a passkey as the second factor of a login, for the challenge token
+// returned by the password login, and responds with the options for navigator.credentials.get.
+func (h *PasskeyHandler) MFAOptions(w http.ResponseWriter, r *http.Request) {
+	var req MFAPasskeyOptionsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	options, err := h.authService.BeginPasskeyMFA(req.ChallengeToken)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewPasskeyRequestOptionsResponse(options))
+}
+
+// VerifyMFA finishes the login of a user with two-factor authentication with a passkey instead of a code
+// and responds with a fresh token pair.
+func (h *PasskeyHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req MFAPasskeyVerifyRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	accessToken, refreshToken, err := h.authService.VerifyPasskeyMFA(req.ChallengeToken, req.Credential.Assertion(),
+		sessionMetadata(r, req.DeviceName))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewTokenPairResponse(accessToken, refreshToken))
+}
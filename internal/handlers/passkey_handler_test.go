@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+	"github.com/breakfront-planner/auth-service/internal/validators"
+	"github.com/breakfront-planner/auth-service/internal/webauthn"
+	"github.com/breakfront-planner/auth-service/internal/webauthn/webauthntest"
+)
+
+const (
+	testRPID     = "example.com"
+	testOrigin   = "https://example.com"
+	testPassword = "correct horse battery staple"
+)
+
+type PasskeyHandlerTestSuite struct {
+	suite.Suite
+	ctrl             *gomock.Controller
+	mockPasskeyRepo  *mocks.MockIPasskeyRepository
+	mockTokenRepo    *mocks.MockITokenRepository
+	mockDenylistRepo *mocks.MockIDenylistRepository
+	mockCodeRepo     *mocks.MockIAuthorizationCodeRepository
+	jwtManager       *jwt.Manager
+	router           http.Handler
+	testUser         *models.User
+	accessToken      string
+	// challenges and passkeys are the contents of the mocked passkey repository.
+	challenges map[string]*models.PasskeyChallenge
+	passkeys   map[string]*models.Passkey
+}
+
+func (s *PasskeyHandlerTestSuite) SetupSuite() {
+	err := godotenv.Load("../../.env.test")
+	require.NoError(s.T(), err, "Failed to load .env.test")
+
+	jwtSecret := os.Getenv("TEST_JWT_SECRET")
+	require.NotEmpty(s.T(), jwtSecret, "TEST_JWT_SECRET must be set in .env.test")
+
+	s.jwtManager = jwt.NewManager(jwtSecret, 10*time.Minute, time.Hour)
+	passwordHash, err := services.NewHashService().HashPassword(testPassword)
+	require.NoError(s.T(), err)
+	s.testUser = &models.User{ID: uuid.New(), Login: "alice@example.com", PasswordHash: passwordHash}
+}
+
+func (s *PasskeyHandlerTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockPasskeyRepo = mocks.NewMockIPasskeyRepository(s.ctrl)
+	s.mockTokenRepo = mocks.NewMockITokenRepository(s.ctrl)
+	s.mockDenylistRepo = mocks.NewMockIDenylistRepository(s.ctrl)
+	s.mockCodeRepo = mocks.NewMockIAuthorizationCodeRepository(s.ctrl)
+	s.challenges = make(map[string]*models.PasskeyChallenge)
+	s.passkeys = make(map[string]*models.Passkey)
+	s.mockPasskeyRepository()
+
+	mockUserRepo := mocks.NewMockIUserRepository(s.ctrl)
+	mockUserRepo.EXPECT().FindUser(gomock.Any()).Return(s.testUser, nil).AnyTimes()
+	s.mockDenylistRepo.EXPECT().IsDenylisted(gomock.Any()).Return(false, nil).AnyTimes()
+	mockRoleRepo := mocks.NewMockIRoleRepository(s.ctrl)
+	mockRoleRepo.EXPECT().FindUserRoles(gomock.Any()).Return(nil, nil).AnyTimes()
+
+	hashService := services.NewHashService()
+	userService := services.NewUserService(mockUserRepo, hashService)
+	tokenService := services.NewTokenService(s.mockTokenRepo, mocks.NewMockISecurityEventRepository(s.ctrl), hashService,
+		s.jwtManager)
+	denylist := services.NewDenylistService(s.mockDenylistRepo)
+	tokenValidator := validators.NewTokenValidator(s.jwtManager, userService, denylist)
+	mockEventRepo := mocks.NewMockISecurityEventRepository(s.ctrl)
+	mockEventRepo.EXPECT().SaveEvent(gomock.Any()).Return(nil).AnyTimes()
+	relyingParty := &webauthn.RelyingParty{ID: testRPID, Name: "Breakfront", Origins: []string{testOrigin}}
+	passkeyService := services.NewPasskeyService(s.mockPasskeyRepo, mockEventRepo, userService, hashService,
+		relyingParty, 5*time.Minute)
+	roleService := services.NewRoleService(mockRoleRepo)
+	authService := services.NewAuthService(tokenService, userService, tokenValidator, denylist, roleService,
+		services.WithPasskeys(passkeyService))
+	mockClientRepo := mocks.NewMockIClientRepository(s.ctrl)
+	mockClientRepo.EXPECT().FindClient("planner-spa").
+		Return(&models.Client{ID: "planner-spa", Name: "Breakfront Planner", RedirectURIs: []string{testRedirectURI}}, nil).
+		AnyTimes()
+	clientService := services.NewClientService(mockClientRepo, hashService, nil)
+	oauthService := services.NewOAuthService(clientService, s.mockCodeRepo, authService, userService, roleService,
+		tokenService, hashService, time.Minute)
+
+	s.router = NewRouter(NewAuthHandler(authService), NewJWKSHandler(s.jwtManager.KeySet()),
+		NewOAuthHandler(authService, oauthService, nil, nil, clientService), nil, nil,
+		NewPasskeyHandler(passkeyService, authService), nil, tokenValidator)
+
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+	s.accessToken = accessToken.Value
+}
+
+func (s *PasskeyHandlerTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+// mockPasskeyRepository backs the mocked passkey repository with the suite's maps.
+func (s *PasskeyHandlerTestSuite) mockPasskeyRepository() {
+	s.mockPasskeyRepo.EXPECT().SaveChallenge(gomock.Any()).DoAndReturn(func(challenge *models.PasskeyChallenge) error {
+		s.challenges[challenge.ChallengeHash] = challenge
+		return nil
+	}).AnyTimes()
+	s.mockPasskeyRepo.EXPECT().ConsumeChallenge(gomock.Any()).DoAndReturn(func(challengeHash string) (*models.PasskeyChallenge, error) {
+		challenge := s.challenges[challengeHash]
+		delete(s.challenges, challengeHash)
+		return challenge, nil
+	}).AnyTimes()
+	s.mockPasskeyRepo.EXPECT().DeleteExpiredChallenges().Return(nil).AnyTimes()
+	s.mockPasskeyRepo.EXPECT().SavePasskey(gomock.Any()).DoAndReturn(func(passkey *models.Passkey) error {
+		passkey.CreatedAt = time.Now()
+		s.passkeys[string(passkey.ID)] = passkey
+		return nil
+	}).AnyTimes()
+	s.mockPasskeyRepo.EXPECT().FindPasskey(gomock.Any()).DoAndReturn(func(credentialID []byte) (*models.Passkey, error) {
+		return s.passkeys[string(credentialID)], nil
+	}).AnyTimes()
+	s.mockPasskeyRepo.EXPECT().FindPasskeys(gomock.Any()).DoAndReturn(func(uuid.UUID) ([]*models.Passkey, error) {
+		var passkeys []*models.Passkey
+		for _, passkey := range s.passkeys {
+			passkeys = append(passkeys, passkey)
+		}
+		return passkeys, nil
+	}).AnyTimes()
+	s.mockPasskeyRepo.EXPECT().UseSignCount(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(credentialID []byte, signCount uint32, _ bool) (bool, error) {
+			passkey := s.passkeys[string(credentialID)]
+			if passkey.SignCount >= signCount {
+				return false, nil
+			}
+			passkey.SignCount = signCount
+			return true, nil
+		}).AnyTimes()
+}
+
+func (s *PasskeyHandlerTestSuite) doRequest(method, path string, body any) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		require.NoError(s.T(), json.NewEncoder(&payload).Encode(body))
+	}
+
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.accessToken)
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// register registers the passkey of a new software authenticator for the test user through the endpoints.
+func (s *PasskeyHandlerTestSuite) register() *webauthntest.Authenticator {
+	authenticator, err := webauthntest.New(testRPID, testOrigin)
+	require.NoError(s.T(), err)
+
+	rec := s.doRequest(http.MethodPost, "/auth/passkeys/register/options",
+		PasskeyRegistrationOptionsRequest{Password: testPassword})
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var options PasskeyCreationOptionsResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&options))
+
+	attestation, err := authenticator.Register(options.Challenge, options.User.ID)
+	require.NoError(s.T(), err)
+
+	rec = s.doRequest(http.MethodPost, "/auth/passkeys/register", registerRequest("Laptop", attestation))
+	require.Equal(s.T(), http.StatusCreated, rec.Code, rec.Body.String())
+
+	return authenticator
+}
+
+// assert answers the request options of the response with a passkey assertion of the authenticator.
+func (s *PasskeyHandlerTestSuite) assert(authenticator *webauthntest.Authenticator,
+	rec *httptest.ResponseRecorder) *AuthenticationCredential {
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var options PasskeyRequestOptionsResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&options))
+
+	assertion, err := authenticator.Assert(options.Challenge)
+	require.NoError(s.T(), err)
+	return authenticationCredential(assertion)
+}
+
+func (s *PasskeyHandlerTestSuite) TestRegistrationOptions() {
+	rec := s.doRequest(http.MethodPost, "/auth/passkeys/register/options",
+		PasskeyRegistrationOptionsRequest{Password: testPassword})
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var options map[string]any
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&options))
+	assert.Equal(s.T(), map[string]any{"id": testRPID, "name": "Breakfront"}, options["rp"])
+	assert.Equal(s.T(), map[string]any{
+		"id":          base64.RawURLEncoding.EncodeToString(s.testUser.ID[:]),
+		"name":        "alice@example.com",
+		"displayName": "alice@example.com",
+	}, options["user"])
+	assert.Len(s.T(), options["challenge"], base64.RawURLEncoding.EncodedLen(32))
+	assert.Equal(s.T(), float64(300000), options["timeout"])
+	assert.Equal(s.T(), "none", options["attestation"])
+	assert.Contains(s.T(), options["pubKeyCredParams"], map[string]any{"type": "public-key", "alg": float64(-7)})
+}
+
+func (s *PasskeyHandlerTestSuite) TestRegistrationOptionsWrongPassword() {
+	rec := s.doRequest(http.MethodPost, "/auth/passkeys/register/options",
+		PasskeyRegistrationOptionsRequest{Password: "wrong password"})
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Empty(s.T(), s.challenges)
+}
+
+func (s *PasskeyHandlerTestSuite) TestRegistrationOptionsCodeWithoutMFA() {
+	rec := s.doRequest(http.MethodPost, "/auth/passkeys/register/options",
+		PasskeyRegistrationOptionsRequest{Code: "123456"})
+
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Equal(s.T(), autherrors.ErrMFANotEnabled.Error(), s.decodeError(rec).Error)
+}
+
+func (s *PasskeyHandlerTestSuite) TestRegisterAndList() {
+	authenticator := s.register()
+
+	rec := s.doRequest(http.MethodGet, "/auth/passkeys", nil)
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var resp PasskeysResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(s.T(), resp.Passkeys, 1)
+	assert.Equal(s.T(), Base64URL(authenticator.CredentialID), resp.Passkeys[0].ID)
+	assert.Equal(s.T(), "Laptop", resp.Passkeys[0].Name)
+}
+
+func (s *PasskeyHandlerTestSuite) TestRegisterWithoutChallenge() {
+	authenticator, err := webauthntest.New(testRPID, testOrigin)
+	require.NoError(s.T(), err)
+	attestation, err := authenticator.Register([]byte("never issued"), s.testUser.ID[:])
+	require.NoError(s.T(), err)
+
+	rec := s.doRequest(http.MethodPost, "/auth/passkeys/register", registerRequest("Laptop", attestation))
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Equal(s.T(), autherrors.ErrInvalidPasskey.Error(), s.decodeError(rec).Error)
+}
+
+func (s *PasskeyHandlerTestSuite) TestDelete() {
+	authenticator := s.register()
+	s.mockPasskeyRepo.EXPECT().DeletePasskey(s.testUser.ID, authenticator.CredentialID).Return(nil)
+	s.mockPasskeyRepo.EXPECT().DeletePasskey(s.testUser.ID, []byte("unknown")).Return(autherrors.ErrPasskeyNotFound)
+
+	rec := s.doRequest(http.MethodDelete, "/auth/passkeys/"+base64.RawURLEncoding.EncodeToString(authenticator.CredentialID), nil)
+	assert.Equal(s.T(), http.StatusNoContent, rec.Code)
+
+	rec = s.doRequest(http.MethodDelete, "/auth/passkeys/"+base64.RawURLEncoding.EncodeToString([]byte("unknown")), nil)
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code)
+
+	rec = s.doRequest(http.MethodDelete, "/auth/passkeys/not+base64url", nil)
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Equal(s.T(), autherrors.ErrInvalidPasskeyID.Error(), s.decodeError(rec).Error)
+}
+
+func (s *PasskeyHandlerTestSuite) TestLogin() {
+	authenticator := s.register()
+	s.mockTokenRepo.EXPECT().SaveToken(gomock.Any()).Return(nil)
+
+	credential := s.assert(authenticator, s.doRequest(http.MethodPost, "/auth/passkeys/login/options", nil))
+	rec := s.doRequest(http.MethodPost, "/auth/passkeys/login", PasskeyLoginRequest{Credential: credential})
+
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var resp TokenPairResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	assert.NotEmpty(s.T(), resp.AccessToken)
+	assert.NotEmpty(s.T(), resp.RefreshToken)
+}
+
+func (s *PasskeyHandlerTestSuite) TestLoginReplayedAssertion() {
+	authenticator := s.register()
+	s.mockTokenRepo.EXPECT().SaveToken(gomock.Any()).Return(nil)
+
+	credential := s.assert(authenticator, s.doRequest(http.MethodPost, "/auth/passkeys/login/options", nil))
+	rec := s.doRequest(http.MethodPost, "/auth/passkeys/login", PasskeyLoginRequest{Credential: credential})
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+
+	rec = s.doRequest(http.MethodPost, "/auth/passkeys/login", PasskeyLoginRequest{Credential: credential})
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Equal(s.T(), autherrors.ErrInvalidPasskey.Error(), s.decodeError(rec).Error)
+}
+
+func (s *PasskeyHandlerTestSuite) TestVerifyMFA() {
+	authenticator := s.register()
+	challengeToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeMFAChallenge)
+	require.NoError(s.T(), err)
+	s.mockDenylistRepo.EXPECT().SaveEntry(gomock.Any()).Return(nil)
+	s.mockDenylistRepo.EXPECT().DeleteExpiredEntries().Return(nil)
+	s.mockTokenRepo.EXPECT().SaveToken(gomock.Any()).Return(nil)
+
+	rec := s.doRequest(http.MethodPost, "/auth/mfa/passkey/options", MFAPasskeyOptionsRequest{ChallengeToken: challengeToken.Value})
+	credential := s.assert(authenticator, rec)
+	rec = s.doRequest(http.MethodPost, "/auth/mfa/passkey/verify",
+		MFAPasskeyVerifyRequest{ChallengeToken: challengeToken.Value, Credential: credential})
+
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var resp TokenPairResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	assert.NotEmpty(s.T(), resp.AccessToken)
+}
+
+func (s *PasskeyHandlerTestSuite) TestMFAOptionsWithoutPasskeys() {
+	challengeToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeMFAChallenge)
+	require.NoError(s.T(), err)
+
+	rec := s.doRequest(http.MethodPost, "/auth/mfa/passkey/options", MFAPasskeyOptionsRequest{ChallengeToken: challengeToken.Value})
+
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Equal(s.T(), autherrors.ErrNoPasskeys.Error(), s.decodeError(rec).Error)
+}
+
+func (s *PasskeyHandlerTestSuite) TestAuthorizeWithPasskey() {
+	authenticator := s.register()
+	form := url.Values{
+		"response_type":         {constants.ResponseTypeCode},
+		"client_id":             {"planner-spa"},
+		"redirect_uri":          {testRedirectURI},
+		"state":                 {"af0ifjsldkj"},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {constants.CodeChallengeMethodS256},
+		"login":                 {s.testUser.Login},
+		"password":              {testPassword},
+	}
+
+	// The password alone doesn't issue a code to a user with a passkey
+	rec := s.postForm("/oauth/authorize", form)
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Empty(s.T(), rec.Header().Get("Location"))
+	body := rec.Body.String()
+	assert.Contains(s.T(), body, `id="use-passkey"`)
+	mfaToken := regexp.MustCompile(`name="mfa_token" value="([^"]+)"`).FindStringSubmatch(body)
+	require.Len(s.T(), mfaToken, 2)
+	challenge := regexp.MustCompile(`"challenge":"([^"]+)"`).FindStringSubmatch(body)
+	require.Len(s.T(), challenge, 2)
+
+	// A credential that doesn't parse shows the form again
+	form.Del("login")
+	form.Del("password")
+	form.Set("mfa_token", mfaToken[1])
+	form.Set("passkey_credential", "{}")
+	rec = s.postForm("/oauth/authorize", form)
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Contains(s.T(), rec.Body.String(), autherrors.ErrInvalidPasskey.Error())
+
+	challengeBytes, err := base64.RawURLEncoding.DecodeString(challenge[1])
+	require.NoError(s.T(), err)
+	assertion, err := authenticator.Assert(challengeBytes)
+	require.NoError(s.T(), err)
+	credential, err := json.Marshal(authenticationCredential(assertion))
+	require.NoError(s.T(), err)
+	form.Set("passkey_credential", string(credential))
+	s.mockDenylistRepo.EXPECT().SaveEntry(gomock.Any()).Return(nil)
+	s.mockDenylistRepo.EXPECT().DeleteExpiredEntries().Return(nil)
+	s.mockCodeRepo.EXPECT().SaveCode(gomock.Any()).Return(nil)
+	s.mockCodeRepo.EXPECT().DeleteExpiredCodes().Return(nil)
+
+	rec = s.postForm("/oauth/authorize", form)
+
+	require.Equal(s.T(), http.StatusFound, rec.Code, rec.Body.String())
+	location, err := url.Parse(rec.Header().Get("Location"))
+	require.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), location.Query().Get("code"))
+}
+
+func (s *PasskeyHandlerTestSuite) TestValidation() {
+	tests := []struct {
+		name string
+		path string
+		body any
+		err  error
+	}{
+		{name: "registration options without password or code", path: "/auth/passkeys/register/options",
+			body: PasskeyRegistrationOptionsRequest{}, err: autherrors.ErrEmptyReauthentication},
+		{name: "register without credential", path: "/auth/passkeys/register", body: PasskeyRegisterRequest{Name: "Laptop"},
+			err: autherrors.ErrEmptyCredential},
+		{name: "login without credential", path: "/auth/passkeys/login", body: PasskeyLoginRequest{},
+			err: autherrors.ErrEmptyCredential},
+		{name: "MFA options without challenge token", path: "/auth/mfa/passkey/options", body: MFAPasskeyOptionsRequest{},
+			err: autherrors.ErrEmptyChallengeToken},
+		{name: "MFA without challenge token", path: "/auth/mfa/passkey/verify", body: MFAPasskeyVerifyRequest{},
+			err: autherrors.ErrEmptyChallengeToken},
+		{name: "MFA without credential", path: "/auth/mfa/passkey/verify",
+			body: MFAPasskeyVerifyRequest{ChallengeToken: "challenge"}, err: autherrors.ErrEmptyCredential},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			rec := s.doRequest(http.MethodPost, tt.path, tt.body)
+
+			assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+			assert.Equal(s.T(), tt.err.Error(), s.decodeError(rec).Error)
+		})
+	}
+}
+
+func (s *PasskeyHandlerTestSuite) TestManagementRequiresAccessToken() {
+	s.accessToken = ""
+
+	for _, path := range []string{"/auth/passkeys/register/options", "/auth/passkeys/register"} {
+		rec := s.doRequest(http.MethodPost, path, nil)
+		assert.Equal(s.T(), http.StatusUnauthorized, rec.Code, path)
+	}
+}
+
+func (s *PasskeyHandlerTestSuite) postForm(path string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func (s *PasskeyHandlerTestSuite) decodeError(rec *httptest.ResponseRecorder) ErrorResponse {
+	var resp ErrorResponse
+	require.NoError(s.T(), json.NewDecoder(rec.Body).Decode(&resp))
+	return resp
+}
+
+// registerRequest wraps the authenticator's attestation as the browser sends it.
+func registerRequest(name string, attestation *webauthntest.Attestation) PasskeyRegisterRequest {
+	credential := &RegistrationCredential{
+		ID:    base64.RawURLEncoding.EncodeToString(attestation.CredentialID),
+		RawID: attestation.CredentialID,
+		Type:  publicKeyCredentialType,
+	}
+	credential.Response.ClientDataJSON = attestation.ClientDataJSON
+	credential.Response.AttestationObject = attestation.AttestationObject
+	return PasskeyRegisterRequest{Name: name, Credential: credential}
+}
+
+// authenticationCredential wraps the authenticator's assertion as the browser sends it.
+func authenticationCredential(assertion *webauthntest.Assertion) *AuthenticationCredential {
+	credential := &AuthenticationCredential{
+		ID:    base64.RawURLEncoding.EncodeToString(assertion.CredentialID),
+		RawID: assertion.CredentialID,
+		Type:  publicKeyCredentialType,
+	}
+	credential.Response.ClientDataJSON = assertion.ClientDataJSON
+	credential.Response.AuthenticatorData = assertion.AuthenticatorData
+	credential.Response.Signature = assertion.Signature
+	credential.Response.UserHandle = assertion.UserHandle
+	return credential
+}
+
+func TestPasskeyHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(PasskeyHandlerTestSuite))
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/ratelimit"
+)
+
+// IRateLimiter defines the rate limiting of requests by key.
+type IRateLimiter interface {
+	Allow(key string) (ratelimit.Result, error)
+}
+
+// RateLimitKeyFunc derives the key a request is rate limited by. Requests without a key are not limited.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitRule limits requests by the key derived from them.
+type RateLimitRule struct {
+	Limiter IRateLimiter
+	Key     RateLimitKeyFunc
+}
+
+// RateLimit returns middleware that rejects requests exceeding any of the rules with 429 and a Retry-After header
+// telling when the request would be allowed. Requests are let through if a limiter fails, e.g. because its store
+// is unavailable: rate limiting only protects the endpoints, which still authenticate every request.
+func RateLimit(rules ...RateLimitRule) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var limited *autherrors.RateLimitError
+
+			for _, rule := range rules {
+				key := rule.Key(r)
+				if key == "" {
+					continue
+				}
+
+				result, err := rule.Limiter.Allow(key)
+				if err != nil {
+					log.Printf("rate limiting failed: %v", err)
+					continue
+				}
+				if !result.Allowed && (limited == nil || result.RetryAfter > limited.RetryAfter) {
+					limited = &autherrors.RateLimitError{RetryAfter: result.RetryAfter}
+				}
+			}
+
+			if limited != nil {
+				writeError(w, limited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitByIP keys requests by the client's IP address.
+func RateLimitByIP(r *http.Request) string {
+	return clientIP(r)
+}
+
+// RateLimitByLogin keys requests by the login in their JSON or form-encoded body.
+func RateLimitByLogin(r *http.Request) string {
+	return bodyField(r, "login")
+}
+
+// RateLimitByClientID keys requests by the OAuth client ID in their HTTP Basic credentials or form-encoded body.
+func RateLimitByClientID(r *http.Request) string {
+	clientID, _, ok := r.BasicAuth()
+	if !ok {
+		return bodyField(r, "client_id")
+	}
+	if unescaped, err := url.QueryUnescape(clientID); err == nil {
+		return unescaped
+	}
+	return clientID
+}
+
+// bodyField returns a string field of a JSON or form-encoded request body, or "" if there is none.
+// The body is restored afterwards, so the handler can still read it.
+func bodyField(r *http.Request, name string) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize+1))
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return ""
+		}
+		return values.Get(name)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+	value, _ := fields[name].(string)
+	return value
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/clientip"
+	"github.com/breakfront-planner/auth-service/internal/ratelimit"
+)
+
+// stubLimiter records the keys it is asked about and answers with result or err.
+type stubLimiter struct {
+	keys   []string
+	result ratelimit.Result
+	err    error
+}
+
+func (l *stubLimiter) Allow(key string) (ratelimit.Result, error) {
+	l.keys = append(l.keys, key)
+	return l.result, l.err
+}
+
+type RateLimitTestSuite struct {
+	suite.Suite
+	body string
+}
+
+// serve sends the request through the middleware with the rules to a handler that records the body it reads.
+func (s *RateLimitTestSuite) serve(req *http.Request, rules ...RateLimitRule) *httptest.ResponseRecorder {
+	s.body = ""
+	handler := RateLimit(rules...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(s.T(), err)
+		s.body = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *RateLimitTestSuite) TestKeys() {
+	form := url.Values{"login": {"alice"}, "client_id": {"planner-web"}}.Encode()
+
+	testCases := []struct {
+		name    string
+		request func() *http.Request
+		key     RateLimitKeyFunc
+		want    string
+	}{
+		{
+			name: "IP address",
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+			},
+			key:  RateLimitByIP,
+			want: "192.0.2.1",
+		},
+		{
+			name: "login in JSON",
+			request: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"login":"alice","password":"x"}`))
+				req.Header.Set("Content-Type", "application/json")
+				return req
+			},
+			key:  RateLimitByLogin,
+			want: "alice",
+		},
+		{
+			name: "login in form",
+			request: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/oauth/authorize", strings.NewReader(form))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+				return req
+			},
+			key:  RateLimitByLogin,
+			want: "alice",
+		},
+		{
+			name: "client ID in form",
+			request: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req
+			},
+			key:  RateLimitByClientID,
+			want: "planner-web",
+		},
+		{
+			name: "client ID in Basic credentials",
+			request: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				req.SetBasicAuth(url.QueryEscape("billing service"), "secret")
+				return req
+			},
+			key:  RateLimitByClientID,
+			want: "billing service",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			limiter := &stubLimiter{result: ratelimit.Result{Allowed: true}}
+			req := tc.request()
+			var body string
+			if req.Body != nil {
+				raw, err := io.ReadAll(req.Body)
+				require.NoError(s.T(), err)
+				body = string(raw)
+				req.Body = io.NopCloser(strings.NewReader(body))
+			}
+
+			rec := s.serve(req, RateLimitRule{Limiter: limiter, Key: tc.key})
+
+			assert.Equal(s.T(), http.StatusNoContent, rec.Code)
+			assert.Equal(s.T(), []string{tc.want}, limiter.keys)
+			assert.Equal(s.T(), body, s.body, "the handler should read the whole body")
+		})
+	}
+}
+
+func (s *RateLimitTestSuite) TestIPFromTrustedProxies() {
+	resolver := clientip.NewResolver([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		{name: "trusted proxy", remoteAddr: "10.1.2.3:443", want: "198.51.100.23"},
+		{name: "untrusted peer", remoteAddr: "203.0.113.7:52113", want: "203.0.113.7"},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			limiter := &stubLimiter{result: ratelimit.Result{Allowed: true}}
+			handler := ResolveClientIP(resolver)(RateLimit(RateLimitRule{Limiter: limiter, Key: RateLimitByIP})(
+				http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })))
+			req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+			req.RemoteAddr = tc.remoteAddr
+			req.Header.Set(clientip.ForwardedForHeader, "198.51.100.23")
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(s.T(), http.StatusNoContent, rec.Code)
+			assert.Equal(s.T(), []string{tc.want}, limiter.keys)
+		})
+	}
+}
+
+func (s *RateLimitTestSuite) TestRequestsWithoutKeyAreNotLimited() {
+	limiter := &stubLimiter{}
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"password":"x"}`))
+
+	rec := s.serve(req, RateLimitRule{Limiter: limiter, Key: RateLimitByLogin})
+
+	assert.Equal(s.T(), http.StatusNoContent, rec.Code)
+	assert.Empty(s.T(), limiter.keys)
+}
+
+func (s *RateLimitTestSuite) TestRejectsWithLongestRetryAfter() {
+	ipLimiter := &stubLimiter{result: ratelimit.Result{RetryAfter: 1500 * time.Millisecond}}
+	loginLimiter := &stubLimiter{result: ratelimit.Result{RetryAfter: 30 * time.Second}}
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"login":"alice"}`))
+
+	rec := s.serve(req,
+		RateLimitRule{Limiter: ipLimiter, Key: RateLimitByIP},
+		RateLimitRule{Limiter: loginLimiter, Key: RateLimitByLogin})
+
+	assert.Equal(s.T(), http.StatusTooManyRequests, rec.Code)
+	assert.Equal(s.T(), "30", rec.Header().Get("Retry-After"))
+	assert.Empty(s.T(), s.body, "the handler should not be called")
+}
+
+func (s *RateLimitTestSuite) TestLimiterFailureLetsRequestsThrough() {
+	limiter := &stubLimiter{err: errors.New("connection refused")}
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+
+	rec := s.serve(req, RateLimitRule{Limiter: limiter, Key: RateLimitByIP})
+
+	assert.Equal(s.T(), http.StatusNoContent, rec.Code)
+}
+
+func TestRateLimitTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitTestSuite))
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+const maxRequestBodySize = 1 << 20
+
+// decodeJSON reads a JSON request body into dst, rejecting unknown fields and oversized bodies.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		return autherrors.ErrInvalidRequestBody(err)
+	}
+
+	return nil
+}
+
+// parseForm reads a form-encoded request body into r.PostForm, rejecting oversized bodies.
+func parseForm(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	if err := r.ParseForm(); err != nil {
+		return autherrors.ErrInvalidRequestBody(err)
+	}
+
+	return nil
+}
+
+// writeJSON encodes body as JSON and writes it with the given status code.
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/breakfront-planner/auth-service/internal/clientip"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/pkg/authmw"
+)
+
+// RouterOption is a function that modifies the router configuration.
+type RouterOption func(*routerConfig)
+
+// routerConfig holds the optional settings of the router.
+type routerConfig struct {
+	rateLimits       map[string][]RateLimitRule
+	audience         string
+	clientIPResolver *clientip.Resolver
+}
+
+// WithRateLimit rate limits the endpoint registered with the pattern, e.g. "POST /auth/login", by the rules.
+// It can be passed several times for the same endpoint to add rules.
+func WithRateLimit(pattern string, rules ...RateLimitRule) RouterOption {
+	return func(c *routerConfig) {
+		c.rateLimits[pattern] = append(c.rateLimits[pattern], rules...)
+	}
+}
+
+// WithAudience makes the endpoints requiring an access token accept only tokens meant for the audience,
+// the name of the auth service itself among the services in the tokens' aud claim.
+func WithAudience(audience string) RouterOption {
+	return func(c *routerConfig) {
+		c.audience = audience
+	}
+}
+
+// WithClientIPResolver determines the client IP address of requests with the resolver instead of taking
+// the address of the peer, so that clients behind trusted proxies are rate limited and locked out individually.
+func WithClientIPResolver(resolver *clientip.Resolver) RouterOption {
+	return func(c *routerConfig) {
+		c.clientIPResolver = resolver
+	}
+}
+
+// NewRouter registers all HTTP endpoints and returns the resulting handler.
+// Session endpoints require a valid access token, verified with tokenValidator
+// against the user store and the denylist so that revoked tokens and tokens of deleted or signed-out users are rejected.
+// Tokens another service obtained by token exchange to act for the user are rejected too: they can't manage the account.
+// OAuth endpoints authenticate the calling client instead, or the user through the sign-in form.
+// OpenID Connect endpoints are only served if oidcHandler is not nil,
+// the two-factor authentication settings only if mfaHandler is not nil and the passkey endpoints only if passkeyHandler is not nil.
+// The administrative lockout endpoints are only served if lockoutHandler is not nil and require the
+// constants.PermissionUnlockLogins permission.
+// Rate limits apply before any other checks, so rejected requests cost as little as possible.
+// With WithClientIPResolver, the client IP address used by rate limits, lockouts and sessions is resolved first.
+func NewRouter(authHandler *AuthHandler, jwksHandler *JWKSHandler, oauthHandler *OAuthHandler, oidcHandler *OIDCHandler,
+	mfaHandler *MFAHandler, passkeyHandler *PasskeyHandler, lockoutHandler *LockoutHandler, tokenValidator authmw.Validator,
+	opts ...RouterOption) http.Handler {
+	cfg := &routerConfig{rateLimits: make(map[string][]RateLimitRule)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	mux := &rateLimitedMux{ServeMux: http.NewServeMux(), rateLimits: cfg.rateLimits}
+	requireAuth := authmw.Middleware(tokenValidator, authmw.WithAudience(cfg.audience), authmw.WithoutDelegatedTokens(),
+		authmw.WithRevocationCheck(), authmw.WithUserExistenceCheck())
+
+	mux.HandleFunc("POST /auth/register", authHandler.Register)
+	mux.HandleFunc("POST /auth/login", authHandler.Login)
+	mux.HandleFunc("POST /auth/refresh", authHandler.Refresh)
+	mux.HandleFunc("POST /auth/logout", authHandler.Logout)
+	mux.HandleFunc("POST /auth/mfa/verify", authHandler.VerifyMFA)
+
+	mux.Handle("GET /auth/sessions", requireAuth(http.HandlerFunc(authHandler.ListSessions)))
+	mux.Handle("DELETE /auth/sessions/{id}", requireAuth(http.HandlerFunc(authHandler.RevokeSession)))
+	mux.Handle("POST /auth/logout-all", requireAuth(http.HandlerFunc(authHandler.LogoutAll)))
+
+	if mfaHandler != nil {
+		mux.Handle("GET /auth/mfa", requireAuth(http.HandlerFunc(mfaHandler.Status)))
+		mux.Handle("POST /auth/mfa/totp", requireAuth(http.HandlerFunc(mfaHandler.EnrollTOTP)))
+		mux.Handle("POST /auth/mfa/totp/confirm", requireAuth(http.HandlerFunc(mfaHandler.ConfirmTOTP)))
+		mux.Handle("POST /auth/mfa/totp/disable", requireAuth(http.HandlerFunc(mfaHandler.DisableTOTP)))
+		mux.Handle("POST /auth/mfa/recovery-codes", requireAuth(http.HandlerFunc(mfaHandler.RegenerateRecoveryCodes)))
+	}
+
+	if passkeyHandler != nil {
+		mux.Handle("GET /auth/passkeys", requireAuth(http.HandlerFunc(passkeyHandler.List)))
+		mux.Handle("POST /auth/passkeys/register/options", requireAuth(http.HandlerFunc(passkeyHandler.RegistrationOptions)))
+		mux.Handle("POST /auth/passkeys/register", requireAuth(http.HandlerFunc(passkeyHandler.Register)))
+		mux.Handle("DELETE /auth/passkeys/{id}", requireAuth(http.HandlerFunc(passkeyHandler.Delete)))
+		mux.HandleFunc("POST /auth/passkeys/login/options", passkeyHandler.LoginOptions)
+		mux.HandleFunc("POST /auth/passkeys/login", passkeyHandler.Login)
+		mux.HandleFunc("POST /auth/mfa/passkey/options", passkeyHandler.MFAOptions)
+		mux.HandleFunc("POST /auth/mfa/passkey/verify", passkeyHandler.VerifyMFA)
+	}
+
+	if lockoutHandler != nil {
+		requireUnlock := authmw.Middleware(tokenValidator, authmw.WithAudience(cfg.audience), authmw.WithoutDelegatedTokens(),
+			authmw.WithRevocationCheck(), authmw.WithUserExistenceCheck(),
+			authmw.WithRequiredPermission(constants.PermissionUnlockLogins))
+		mux.Handle("DELETE /admin/lockouts/logins/{login}", requireUnlock(http.HandlerFunc(lockoutHandler.UnlockLogin)))
+		mux.Handle("DELETE /admin/lockouts/ip-addresses/{ip}", requireUnlock(http.HandlerFunc(lockoutHandler.UnlockIPAddress)))
+	}
+
+	mux.HandleFunc("GET /oauth/authorize", oauthHandler.Authorize)
+	mux.HandleFunc("POST /oauth/authorize", oauthHandler.AuthorizeSubmit)
+	mux.HandleFunc("POST /oauth/token", oauthHandler.Token)
+	if oauthHandler.deviceService != nil {
+		mux.HandleFunc("POST /oauth/device_authorization", oauthHandler.DeviceAuthorization)
+		mux.Handle("GET /oauth/device", requireAuth(http.HandlerFunc(oauthHandler.DeviceVerification)))
+		mux.Handle("POST /oauth/device", requireAuth(http.HandlerFunc(oauthHandler.DeviceVerificationSubmit)))
+	}
+	mux.HandleFunc("POST /oauth/introspect", oauthHandler.Introspect)
+	mux.HandleFunc("POST /oauth/revoke", oauthHandler.Revoke)
+
+	mux.HandleFunc("GET /.well-known/jwks.json", jwksHandler.JWKS)
+
+	if oidcHandler != nil {
+		// Tokens issued to OAuth clients aren't meant for the auth service, so the openid scope is required instead
+		requireOpenID := authmw.Middleware(tokenValidator, authmw.WithoutDelegatedTokens(), authmw.WithRevocationCheck(),
+			authmw.WithUserExistenceCheck(), authmw.WithRequiredPermission(constants.ScopeOpenID))
+		mux.HandleFunc("GET /.well-known/openid-configuration", oidcHandler.Discovery)
+		mux.Handle("GET /userinfo", requireOpenID(http.HandlerFunc(oidcHandler.UserInfo)))
+		mux.Handle("POST /userinfo", requireOpenID(http.HandlerFunc(oidcHandler.UserInfo)))
+	}
+
+	if cfg.clientIPResolver != nil {
+		return ResolveClientIP(cfg.clientIPResolver)(mux)
+	}
+	return mux
+}
+
+// rateLimitedMux registers endpoints behind the rate limits configured for their patterns.
+type rateLimitedMux struct {
+	*http.ServeMux
+	rateLimits map[string][]RateLimitRule
+}
+
+func (m *rateLimitedMux) Handle(pattern string, handler http.Handler) {
+	if rules := m.rateLimits[pattern]; len(rules) > 0 {
+		handler = RateLimit(rules...)(handler)
+	}
+	m.ServeMux.Handle(pattern, handler)
+}
+
+func (m *rateLimitedMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.Handle(pattern, http.HandlerFunc(handler))
+}
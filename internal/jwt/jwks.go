@@ -0,0 +1,239 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set as served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// JWK returns the public part of the key in JWK format.
+// It reports false for symmetric keys, which must never be published.
+func (k *SigningKey) JWK() (*JWK, bool) {
+	jwk, ok := publicJWK(k.VerifyKey)
+	if !ok {
+		return nil, false
+	}
+
+	jwk.Use = "sig"
+	jwk.Alg = k.Method.Alg()
+	jwk.Kid = k.ID
+
+	return jwk, true
+}
+
+// publicJWK encodes the key material of a public key without metadata.
+func publicJWK(publicKey any) (*JWK, bool) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return &JWK{
+			Kty: "RSA",
+			N:   b64.EncodeToString(key.N.Bytes()),
+			E:   b64.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, true
+	case *ecdsa.PublicKey:
+		ecdhKey, err := key.ECDH()
+		if err != nil {
+			return nil, false
+		}
+		// Uncompressed point encoding: 0x04 || X || Y with fixed-size coordinates.
+		point := ecdhKey.Bytes()[1:]
+		size := len(point) / 2
+		return &JWK{
+			Kty: "EC",
+			Crv: key.Curve.Params().Name,
+			X:   b64.EncodeToString(point[:size]),
+			Y:   b64.EncodeToString(point[size:]),
+		}, true
+	case ed25519.PublicKey:
+		return &JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   b64.EncodeToString(key),
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint of a public key, used as its key ID.
+func thumbprint(publicKey any) string {
+	jwk, ok := publicJWK(publicKey)
+	if !ok {
+		return ""
+	}
+
+	// Required members only, in lexicographic order.
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	return b64.EncodeToString(sum[:])
+}
+
+// ParseJWKS decodes a JSON Web Key Set into verification-only keys.
+func ParseJWKS(data []byte) ([]*SigningKey, error) {
+	var jwks JWKS
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, autherrors.ErrInvalidJWK(err)
+	}
+
+	keys := make([]*SigningKey, 0, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		key, err := jwk.verificationKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// verificationKey decodes the JWK into a verification-only signing key.
+func (j *JWK) verificationKey() (*SigningKey, error) {
+	method, err := signingMethod(j.Alg)
+	if err != nil {
+		return nil, err
+	}
+
+	var publicKey any
+	switch j.Kty {
+	case "RSA":
+		n, errN := b64.DecodeString(j.N)
+		e, errE := b64.DecodeString(j.E)
+		if err = errors.Join(errN, errE); err != nil {
+			return nil, autherrors.ErrInvalidJWK(err)
+		}
+		publicKey = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	case "EC":
+		publicKey, err = ecPublicKey(j.Crv, j.X, j.Y)
+		if err != nil {
+			return nil, autherrors.ErrInvalidJWK(err)
+		}
+	case "OKP":
+		x, errX := b64.DecodeString(j.X)
+		if errX != nil || j.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, autherrors.ErrInvalidJWK(autherrors.ErrKeyAlgMismatch)
+		}
+		publicKey = ed25519.PublicKey(x)
+	default:
+		return nil, autherrors.ErrUnsupportedAlgorithm(j.Kty)
+	}
+
+	if !keyMatchesMethod(publicKey, method) {
+		return nil, autherrors.ErrInvalidJWK(autherrors.ErrKeyAlgMismatch)
+	}
+
+	return &SigningKey{
+		ID:        j.Kid,
+		Method:    method,
+		VerifyKey: publicKey,
+	}, nil
+}
+
+func ecPublicKey(crv, x, y string) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, autherrors.ErrKeyAlgMismatch
+	}
+
+	xBytes, errX := b64.DecodeString(x)
+	yBytes, errY := b64.DecodeString(y)
+	if err := errors.Join(errX, errY); err != nil {
+		return nil, err
+	}
+
+	key := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(xBytes),
+		Y:     new(big.Int).SetBytes(yBytes),
+	}
+	// ECDH conversion validates that the point lies on the curve.
+	if _, err := key.ECDH(); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// keyMatchesMethod reports whether the public key type can verify signatures of method.
+func keyMatchesMethod(publicKey any, method jwt.SigningMethod) bool {
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok := publicKey.(*rsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		key, ok := publicKey.(*ecdsa.PublicKey)
+		return ok && key.Curve.Params().BitSize == m.CurveBits
+	case *jwt.SigningMethodEd25519:
+		_, ok := publicKey.(ed25519.PublicKey)
+		return ok
+	default:
+		return false
+	}
+}
@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"strings"
 	"time"
 
 	"github.com/breakfront-planner/auth-service/internal/autherrors"
@@ -10,29 +11,124 @@ import (
 	"github.com/google/uuid"
 )
 
+// DefaultLeeway is the clock skew tolerated between Breakfront services unless configured otherwise.
+const DefaultLeeway = 30 * time.Second
+
+// DefaultChallengeDuration is how long users have to enter their authentication code after the password
+// unless configured otherwise.
+const DefaultChallengeDuration = 5 * time.Minute
+
 // Manager manages JWT token generation and validation.
 // It handles both access and refresh tokens with configurable expiration durations.
 type Manager struct {
-	secret          string
-	accessDuration  time.Duration
-	refreshDuration time.Duration
+	keys              *KeySet
+	accessDuration    time.Duration
+	refreshDuration   time.Duration
+	challengeDuration time.Duration
+	issuer            string
+	audience          []string
+	clientAudience    []string
+	leeway            time.Duration
+}
+
+// ManagerOption is a function that modifies the Manager configuration.
+type ManagerOption func(*Manager)
+
+// WithIssuer sets the "iss" claim of generated tokens.
+// Parsed tokens must carry the same issuer.
+func WithIssuer(issuer string) ManagerOption {
+	return func(m *Manager) {
+		m.issuer = issuer
+	}
+}
+
+// WithAudience sets the "aud" claim of generated tokens, i.e. the services the tokens are meant for.
+func WithAudience(audience ...string) ManagerOption {
+	return func(m *Manager) {
+		m.audience = audience
+	}
+}
+
+// WithClientAudience sets the "aud" claim of tokens issued to users signed in to an OAuth client.
+// It should leave out the auth service itself, so that clients can't manage the accounts of their users.
+func WithClientAudience(audience ...string) ManagerOption {
+	return func(m *Manager) {
+		m.clientAudience = audience
+	}
+}
+
+// WithLeeway allows for clock skew between services when checking the exp, nbf and iat claims.
+func WithLeeway(leeway time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.leeway = leeway
+	}
 }
 
-// NewManager creates a new JWT manager instance.
+// WithChallengeDuration sets how long the challenge tokens of two-factor logins can be exchanged for tokens.
+func WithChallengeDuration(duration time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.challengeDuration = duration
+	}
+}
+
+// NewManager creates a new JWT manager instance signing with HS256.
 // The secret is used for signing tokens, while accessDuration and refreshDuration
 // define the expiration time for access and refresh tokens respectively.
-func NewManager(secret string, accessDuration, refreshDuration time.Duration) *Manager {
-	return &Manager{
-		secret:          secret,
-		accessDuration:  accessDuration,
-		refreshDuration: refreshDuration,
+func NewManager(secret string, accessDuration, refreshDuration time.Duration, opts ...ManagerOption) *Manager {
+	return NewManagerWithKey(NewHMACKey(secret), accessDuration, refreshDuration, opts...)
+}
+
+// NewManagerWithKey creates a new JWT manager instance signing with a single key.
+// A verification-only key allows parsing tokens but not generating them.
+func NewManagerWithKey(key *SigningKey, accessDuration, refreshDuration time.Duration, opts ...ManagerOption) *Manager {
+	keys := &KeySet{
+		keys: map[string]*SigningKey{key.ID: key},
 	}
+	if key.SignKey != nil {
+		keys.active = key
+	}
+	return NewManagerWithKeySet(keys, accessDuration, refreshDuration, opts...)
+}
+
+// NewManagerWithKeySet creates a new JWT manager instance that signs with the active key of the set
+// and verifies tokens with the key matching their "kid" header.
+func NewManagerWithKeySet(keys *KeySet, accessDuration, refreshDuration time.Duration, opts ...ManagerOption) *Manager {
+	m := &Manager{
+		keys:              keys,
+		accessDuration:    accessDuration,
+		refreshDuration:   refreshDuration,
+		challengeDuration: DefaultChallengeDuration,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// KeySet returns the keys used by the manager.
+func (m *Manager) KeySet() *KeySet {
+	return m.keys
+}
+
+// Leeway returns the allowed clock skew for time based claims.
+func (m *Manager) Leeway() time.Duration {
+	return m.leeway
 }
 
 // GenerateToken creates a new JWT token for the specified user.
-// The tokenType parameter determines whether to generate an access or refresh token,
+// The tokenType parameter determines whether to generate an access, refresh or MFA challenge token,
 // which affects the token's expiration duration and claims.
 func (m *Manager) GenerateToken(user *models.User, tokenType constants.TokenType) (*models.Token, error) {
+	return m.GenerateTokenForClient(user, tokenType, "", nil)
+}
+
+// GenerateTokenForClient creates a token like GenerateToken for a user signed in to an OAuth client.
+// The client is named in the "azp" (authorized party) claim, so that only it can refresh or revoke the token.
+// Instead of the user's roles and permissions the token grants only the scopes in its "scope" claim,
+// which refresh tokens carry too, and its audience is the one set by WithClientAudience.
+// If clientID is empty, the token is issued by the auth endpoints like GenerateToken and scopes are ignored.
+func (m *Manager) GenerateTokenForClient(user *models.User, tokenType constants.TokenType,
+	clientID string, scopes []string) (*models.Token, error) {
 	var duration time.Duration
 
 	switch tokenType {
@@ -40,39 +136,197 @@ func (m *Manager) GenerateToken(user *models.User, tokenType constants.TokenType
 		duration = m.accessDuration
 	case constants.TokenTypeRefresh:
 		duration = m.refreshDuration
+	case constants.TokenTypeMFAChallenge:
+		duration = m.challengeDuration
 	default:
 		return nil, autherrors.ErrWrongTokenType
 	}
 
 	expiresAt := time.Now().UTC().Add(duration)
 
-	claims := jwt.MapClaims{
-		"user_id": user.ID.String(),
-		"exp":     expiresAt.Unix(),
-		"type":    tokenType,
-		"jti":     uuid.New().String(),
+	claims := m.newClaims(user.ID.String(), tokenType, expiresAt)
+	claims["user_id"] = user.ID.String()
+	if clientID != "" {
+		claims["azp"] = clientID
+		delete(claims, "aud")
+		if len(m.clientAudience) > 0 {
+			claims["aud"] = m.clientAudience
+		}
+		if len(scopes) > 0 {
+			claims["scope"] = strings.Join(scopes, " ")
+		}
+	}
+	// Authorization data is only needed by services accepting access tokens
+	if tokenType == constants.TokenTypeAccess && clientID == "" {
+		if len(user.Roles) > 0 {
+			claims["roles"] = user.Roles
+		}
+		if len(user.Permissions) > 0 {
+			claims["permissions"] = user.Permissions
+		}
+	}
+
+	value, err := m.sign(claims)
+	if err != nil {
+		return nil, err
+	}
+	token := models.Token{
+		Value:     value,
+		UserID:    user.ID,
+		ExpiresAt: expiresAt,
+		ClientID:  clientID,
+	}
+	if clientID != "" {
+		token.Scopes = scopes
+	}
+	return &token, nil
+}
+
+// GenerateClientToken creates an access token for a service acting on its own behalf.
+// The token's subject is the client ID and the granted scopes are listed in the space separated
+// "scope" claim (RFC 9068). Client tokens expire like access tokens and have no refresh token.
+func (m *Manager) GenerateClientToken(clientID string, scopes []string) (*models.Token, error) {
+	expiresAt := time.Now().UTC().Add(m.accessDuration)
+
+	claims := m.newClaims(clientID, constants.TokenTypeClient, expiresAt)
+	claims["client_id"] = clientID
+	if len(scopes) > 0 {
+		claims["scope"] = strings.Join(scopes, " ")
+	}
+
+	value, err := m.sign(claims)
+	if err != nil {
+		return nil, err
+	}
+	token := models.Token{
+		Value:     value,
+		ClientID:  clientID,
+		ExpiresAt: expiresAt,
 	}
-	unsignedToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	value, err := unsignedToken.SignedString([]byte(m.secret))
+	return &token, nil
+}
+
+// GenerateIDToken creates an OpenID Connect ID token telling the client which user signed in.
+// Its audience is the client instead of the configured services, nonce is the value of the
+// authorization request (omitted if empty) and authTime is when the user authenticated.
+func (m *Manager) GenerateIDToken(user *models.User, clientID string, nonce string, authTime time.Time) (*models.Token, error) {
+	expiresAt := time.Now().UTC().Add(m.accessDuration)
+
+	claims := m.newClaims(user.ID.String(), constants.TokenTypeID, expiresAt)
+	claims["aud"] = clientID
+	claims["auth_time"] = authTime.Unix()
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	value, err := m.sign(claims)
 	if err != nil {
 		return nil, err
 	}
 	token := models.Token{
 		Value:     value,
 		UserID:    user.ID,
+		ClientID:  clientID,
+		ExpiresAt: expiresAt,
+	}
+	return &token, nil
+}
+
+// GenerateExchangedToken creates the access token issued by token exchange (RFC 8693): it is issued to the
+// subject token's user for the given audience only, grants only the given permissions and names the acting
+// service in the "act" claim. The user's roles are left out, as they would grant more than the permissions.
+// The token expires like an access token, but not after the subject token.
+func (m *Manager) GenerateExchangedToken(subject *models.ParsedToken, audience string, permissions []string,
+	actor *models.Actor) (*models.Token, error) {
+	expiresAt := time.Now().UTC().Add(m.accessDuration)
+	if subject.ExpiresAt.Before(expiresAt) {
+		expiresAt = subject.ExpiresAt
+	}
+
+	claims := m.newClaims(subject.UserID.String(), constants.TokenTypeAccess, expiresAt)
+	claims["user_id"] = subject.UserID.String()
+	claims["aud"] = audience
+	if len(permissions) > 0 {
+		claims["permissions"] = permissions
+	}
+	claims["act"] = actorClaim(actor)
+
+	value, err := m.sign(claims)
+	if err != nil {
+		return nil, err
+	}
+	token := models.Token{
+		Value:     value,
+		UserID:    subject.UserID,
 		ExpiresAt: expiresAt,
 	}
 	return &token, nil
 }
 
-// ParseToken extract user info from token
+// actorClaim returns the "act" claim naming the actor and, nested, the previous actors.
+func actorClaim(actor *models.Actor) map[string]interface{} {
+	claim := map[string]interface{}{"sub": actor.Subject}
+	if actor.Actor != nil {
+		claim["act"] = actorClaim(actor.Actor)
+	}
+	return claim
+}
+
+// newClaims returns the registered claims shared by all tokens, issued now.
+func (m *Manager) newClaims(subject string, tokenType constants.TokenType, expiresAt time.Time) jwt.MapClaims {
+	issuedAt := time.Now().UTC()
+
+	claims := jwt.MapClaims{
+		"sub":  subject,
+		"iat":  issuedAt.Unix(),
+		"nbf":  issuedAt.Unix(),
+		"exp":  expiresAt.Unix(),
+		"type": tokenType,
+		"jti":  uuid.New().String(),
+	}
+	if m.issuer != "" {
+		claims["iss"] = m.issuer
+	}
+	if len(m.audience) > 0 {
+		claims["aud"] = m.audience
+	}
+	return claims
+}
+
+// sign signs the claims with the active key, naming the key in the "kid" header.
+func (m *Manager) sign(claims jwt.MapClaims) (string, error) {
+	signingKey := m.keys.Active()
+	if signingKey == nil || signingKey.SignKey == nil {
+		return "", autherrors.ErrNoSigningKey
+	}
+
+	unsignedToken := jwt.NewWithClaims(signingKey.Method, claims)
+	if signingKey.ID != "" {
+		unsignedToken.Header["kid"] = signingKey.ID
+	}
+	return unsignedToken.SignedString(signingKey.SignKey)
+}
+
+// ParseToken extract user info from token.
+// Besides the signature it checks exp, nbf and iat (allowing for the configured leeway)
+// and, if the manager has an issuer, the "iss" claim.
 func (m *Manager) ParseToken(tokenString string) (parsedToken *models.ParsedToken, err error) {
+	parserOpts := []jwt.ParserOption{jwt.WithLeeway(m.leeway), jwt.WithIssuedAt()}
+	if m.issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(m.issuer))
+	}
+
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.keys.Find(kid)
+		if !ok {
+			return nil, autherrors.ErrUnknownKeyID
+		}
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, autherrors.ErrTokenSignMethod
 		}
-		return []byte(m.secret), nil
-	})
+		return key.VerifyKey, nil
+	}, parserOpts...)
 
 	if err != nil {
 		return nil, err
@@ -83,18 +337,37 @@ func (m *Manager) ParseToken(tokenString string) (parsedToken *models.ParsedToke
 		return nil, autherrors.ErrInvalidJWT
 	}
 
-	userIDStr, ok := claims["user_id"].(string)
+	tokenType, ok := claims["type"].(string)
 	if !ok {
-		return nil, autherrors.ErrNoClaimInToken("user_id")
+		return nil, autherrors.ErrNoClaimInToken("type")
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	subject, err := claims.GetSubject()
 	if err != nil {
-		return nil, autherrors.ErrInvalidUserID
+		return nil, autherrors.ErrInvalidJWT
 	}
-	tokenType, ok := claims["type"].(string)
-	if !ok {
-		return nil, autherrors.ErrNoClaimInToken("type")
+
+	// Client tokens are issued to a client, all others to a user
+	var userID uuid.UUID
+	var clientID string
+	if tokenType == string(constants.TokenTypeClient) {
+		if subject == "" {
+			return nil, autherrors.ErrNoClaimInToken("sub")
+		}
+		clientID = subject
+	} else {
+		// Tokens issued before sub was added only carry user_id
+		if subject == "" {
+			subject, ok = claims["user_id"].(string)
+			if !ok {
+				return nil, autherrors.ErrNoClaimInToken("user_id")
+			}
+		}
+
+		userID, err = uuid.Parse(subject)
+		if err != nil {
+			return nil, autherrors.ErrInvalidUserID
+		}
 	}
 
 	expFloat, ok := claims["exp"].(float64)
@@ -103,11 +376,117 @@ func (m *Manager) ParseToken(tokenString string) (parsedToken *models.ParsedToke
 	}
 	exp := time.Unix(int64(expFloat), 0)
 
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return nil, autherrors.ErrNoClaimInToken("jti")
+	}
+
+	// Tokens issued before iat was added carry no issue time and are treated as issued at the zero time.
+	var issuedAt time.Time
+	if iatFloat, ok := claims["iat"].(float64); ok {
+		issuedAt = time.Unix(int64(iatFloat), 0)
+	}
+
+	issuer, err := claims.GetIssuer()
+	if err != nil {
+		return nil, autherrors.ErrInvalidJWT
+	}
+
+	audience, err := claims.GetAudience()
+	if err != nil {
+		return nil, autherrors.ErrInvalidJWT
+	}
+
+	roles, err := stringsClaim(claims, "roles")
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := stringsClaim(claims, "permissions")
+	if err != nil {
+		return nil, err
+	}
+	if scope, ok := claims["scope"]; ok {
+		scopeStr, ok := scope.(string)
+		if !ok {
+			return nil, autherrors.ErrInvalidClaim("scope")
+		}
+		permissions = strings.Fields(scopeStr)
+	}
+
+	var authorizedParty string
+	if azp, ok := claims["azp"]; ok {
+		authorizedParty, ok = azp.(string)
+		if !ok {
+			return nil, autherrors.ErrInvalidClaim("azp")
+		}
+	}
+
+	actor, err := parseActor(claims["act"])
+	if err != nil {
+		return nil, err
+	}
+
 	parsedToken = &models.ParsedToken{
-		UserID:    userID,
-		Type:      tokenType,
-		ExpiresAt: exp,
+		JTI:             jti,
+		UserID:          userID,
+		Type:            tokenType,
+		Issuer:          issuer,
+		Audience:        audience,
+		Roles:           roles,
+		Permissions:     permissions,
+		IssuedAt:        issuedAt,
+		ExpiresAt:       exp,
+		ClientID:        clientID,
+		AuthorizedParty: authorizedParty,
+		Actor:           actor,
 	}
 
 	return parsedToken, nil
 }
+
+// parseActor reads the optional "act" claim, including the nested claims of previous actors.
+func parseActor(raw interface{}) (*models.Actor, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	claim, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, autherrors.ErrInvalidClaim("act")
+	}
+	subject, ok := claim["sub"].(string)
+	if !ok || subject == "" {
+		return nil, autherrors.ErrInvalidClaim("act")
+	}
+
+	previous, err := parseActor(claim["act"])
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Actor{Subject: subject, Actor: previous}, nil
+}
+
+// stringsClaim reads an optional claim holding a list of strings.
+func stringsClaim(claims jwt.MapClaims, name string) ([]string, error) {
+	raw, ok := claims[name]
+	if !ok {
+		return nil, nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, autherrors.ErrInvalidClaim(name)
+	}
+
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		value, ok := item.(string)
+		if !ok {
+			return nil, autherrors.ErrInvalidClaim(name)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
@@ -0,0 +1,507 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+type ManagerTestSuite struct {
+	suite.Suite
+	testUser    *models.User
+	privatePEMs map[string][]byte
+	publicPEMs  map[string][]byte
+	keyDir      string
+}
+
+func encodePEM(blockType string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func (s *ManagerTestSuite) addKeyPair(algorithm string, privateKey any, publicKey any) {
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(s.T(), err)
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(s.T(), err)
+
+	s.privatePEMs[algorithm] = encodePEM("PRIVATE KEY", privateDER)
+	s.publicPEMs[algorithm] = encodePEM("PUBLIC KEY", publicDER)
+}
+
+func (s *ManagerTestSuite) SetupSuite() {
+	s.testUser = &models.User{ID: uuid.New()}
+	s.privatePEMs = make(map[string][]byte)
+	s.publicPEMs = make(map[string][]byte)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(s.T(), err)
+	s.addKeyPair("RS256", rsaKey, &rsaKey.PublicKey)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(s.T(), err)
+	s.addKeyPair("ES256", ecKey, &ecKey.PublicKey)
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(s.T(), err)
+	s.addKeyPair("EdDSA", edPrivate, edPublic)
+
+	s.keyDir = s.T().TempDir()
+	for algorithm, pemData := range s.privatePEMs {
+		err := os.WriteFile(filepath.Join(s.keyDir, algorithm+".pem"), pemData, 0o600)
+		require.NoError(s.T(), err)
+	}
+}
+
+func (s *ManagerTestSuite) TestSignAndVerifyRoundTrip() {
+	for _, algorithm := range []string{"HS256", "RS256", "ES256", "EdDSA"} {
+		s.Run(algorithm, func() {
+			key, err := LoadSigningKey(algorithm, strings.Repeat("s", MinHMACSecretLength), filepath.Join(s.keyDir, algorithm+".pem"))
+			require.NoError(s.T(), err)
+
+			manager := NewManagerWithKey(key, time.Minute, time.Hour)
+			token, err := manager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+			require.NoError(s.T(), err)
+
+			parsedToken, err := manager.ParseToken(token.Value)
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), s.testUser.ID, parsedToken.UserID)
+			assert.Equal(s.T(), string(constants.TokenTypeAccess), parsedToken.Type)
+			assert.WithinDuration(s.T(), time.Now(), parsedToken.IssuedAt, 2*time.Second)
+			assert.NotEmpty(s.T(), parsedToken.JTI)
+		})
+	}
+}
+
+func (s *ManagerTestSuite) TestVerifyWithPublicKeyOnly() {
+	for _, algorithm := range []string{"RS256", "ES256", "EdDSA"} {
+		s.Run(algorithm, func() {
+			signingKey, err := ParsePrivateKey(algorithm, s.privatePEMs[algorithm])
+			require.NoError(s.T(), err)
+			verifyKey, err := ParsePublicKey(algorithm, s.publicPEMs[algorithm])
+			require.NoError(s.T(), err)
+
+			token, err := NewManagerWithKey(signingKey, time.Minute, time.Hour).
+				GenerateToken(s.testUser, constants.TokenTypeRefresh)
+			require.NoError(s.T(), err)
+
+			verifier := NewManagerWithKey(verifyKey, 0, 0)
+			parsedToken, err := verifier.ParseToken(token.Value)
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), s.testUser.ID, parsedToken.UserID)
+
+			_, err = verifier.GenerateToken(s.testUser, constants.TokenTypeAccess)
+			assert.ErrorIs(s.T(), err, autherrors.ErrNoSigningKey)
+		})
+	}
+}
+
+func (s *ManagerTestSuite) TestRejectsOtherAlgorithm() {
+	rsaKey, err := ParsePrivateKey("RS256", s.privatePEMs["RS256"])
+	require.NoError(s.T(), err)
+	rsaManager := NewManagerWithKey(rsaKey, time.Minute, time.Hour)
+
+	// HS256 token signed with the RSA public key as the HMAC secret (algorithm confusion attack).
+	hmacManager := NewManager(string(s.publicPEMs["RS256"]), time.Minute, time.Hour)
+	token, err := hmacManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	_, err = rsaManager.ParseToken(token.Value)
+	assert.Error(s.T(), err)
+}
+
+func (s *ManagerTestSuite) TestRejectsForeignKey() {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(s.T(), err)
+	otherDER, err := x509.MarshalPKIXPublicKey(&otherKey.PublicKey)
+	require.NoError(s.T(), err)
+
+	signingKey, err := ParsePrivateKey("RS256", s.privatePEMs["RS256"])
+	require.NoError(s.T(), err)
+	verifyKey, err := ParsePublicKey("RS256", encodePEM("PUBLIC KEY", otherDER))
+	require.NoError(s.T(), err)
+
+	token, err := NewManagerWithKey(signingKey, time.Minute, time.Hour).
+		GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	_, err = NewManagerWithKey(verifyKey, 0, 0).ParseToken(token.Value)
+	assert.Error(s.T(), err)
+}
+
+func (s *ManagerTestSuite) TestStandardClaims() {
+	manager := NewManager("test-secret", time.Minute, time.Hour,
+		WithIssuer("https://auth.breakfront.test"), WithAudience("planner", "notifications"))
+
+	token, err := manager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(token.Value, claims)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.testUser.ID.String(), claims["sub"])
+	assert.Equal(s.T(), "https://auth.breakfront.test", claims["iss"])
+	assert.Contains(s.T(), claims, "iat")
+	assert.Contains(s.T(), claims, "nbf")
+
+	parsedToken, err := manager.ParseToken(token.Value)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.testUser.ID, parsedToken.UserID)
+	assert.Equal(s.T(), "https://auth.breakfront.test", parsedToken.Issuer)
+	assert.Equal(s.T(), []string{"planner", "notifications"}, parsedToken.Audience)
+}
+
+func (s *ManagerTestSuite) TestAuthorizationClaims() {
+	manager := NewManager("test-secret", time.Minute, time.Hour)
+	user := &models.User{
+		ID:          uuid.New(),
+		Roles:       []string{"admin"},
+		Permissions: []string{"users:manage"},
+	}
+
+	accessToken, err := manager.GenerateToken(user, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	parsedToken, err := manager.ParseToken(accessToken.Value)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"admin"}, parsedToken.Roles)
+	assert.Equal(s.T(), []string{"users:manage"}, parsedToken.Permissions)
+
+	refreshToken, err := manager.GenerateToken(user, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+
+	parsedToken, err = manager.ParseToken(refreshToken.Value)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), parsedToken.Roles, "refresh tokens must not carry authorization data")
+	assert.Empty(s.T(), parsedToken.Permissions)
+}
+
+func (s *ManagerTestSuite) TestMFAChallengeToken() {
+	manager := NewManager("test-secret", time.Minute, time.Hour, WithChallengeDuration(2*time.Minute))
+	user := &models.User{
+		ID:    uuid.New(),
+		Roles: []string{"admin"},
+	}
+
+	token, err := manager.GenerateToken(user, constants.TokenTypeMFAChallenge)
+	require.NoError(s.T(), err)
+	assert.WithinDuration(s.T(), time.Now().Add(2*time.Minute), token.ExpiresAt, 5*time.Second)
+
+	parsedToken, err := manager.ParseToken(token.Value)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), string(constants.TokenTypeMFAChallenge), parsedToken.Type)
+	assert.Equal(s.T(), user.ID, parsedToken.UserID)
+	assert.Empty(s.T(), parsedToken.Roles, "challenge tokens must not carry authorization data")
+}
+
+func (s *ManagerTestSuite) TestTokenForClient() {
+	manager := NewManager("test-secret", time.Minute, time.Hour)
+	user := &models.User{ID: uuid.New()}
+
+	token, err := manager.GenerateTokenForClient(user, constants.TokenTypeRefresh, "planner-spa", nil)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "planner-spa", token.ClientID)
+
+	parsedToken, err := manager.ParseToken(token.Value)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), user.ID, parsedToken.UserID)
+	assert.Equal(s.T(), "planner-spa", parsedToken.AuthorizedParty)
+	assert.Empty(s.T(), parsedToken.ClientID, "user tokens must not look like client tokens")
+
+	token, err = manager.GenerateToken(user, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+	parsedToken, err = manager.ParseToken(token.Value)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), parsedToken.AuthorizedParty)
+}
+
+func (s *ManagerTestSuite) TestTokenForClientIsLimitedToScopes() {
+	manager := NewManager("test-secret", time.Minute, time.Hour, WithAudience("planner-api", "auth-service"),
+		WithClientAudience("planner-api"))
+	user := &models.User{ID: uuid.New(), Roles: []string{"admin"}, Permissions: []string{"plans:read", "lockouts:unlock"}}
+
+	token, err := manager.GenerateTokenForClient(user, constants.TokenTypeAccess, "planner-spa", []string{"plans:read"})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"plans:read"}, token.Scopes)
+
+	parsedToken, err := manager.ParseToken(token.Value)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"planner-api"}, parsedToken.Audience)
+	assert.Empty(s.T(), parsedToken.Roles)
+	assert.Equal(s.T(), []string{"plans:read"}, parsedToken.Permissions)
+
+	token, err = manager.GenerateTokenForClient(user, constants.TokenTypeRefresh, "planner-spa", []string{"plans:read"})
+	require.NoError(s.T(), err)
+	parsedToken, err = manager.ParseToken(token.Value)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"plans:read"}, parsedToken.Permissions, "refresh tokens keep the granted scopes")
+}
+
+func (s *ManagerTestSuite) TestClientToken() {
+	manager := NewManager("test-secret", time.Minute, time.Hour, WithAudience("planner"))
+
+	token, err := manager.GenerateClientToken("nightly-export", []string{"plans:read", "users:read"})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "nightly-export", token.ClientID)
+	assert.WithinDuration(s.T(), time.Now().Add(time.Minute), token.ExpiresAt, 5*time.Second)
+
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(token.Value, claims)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "nightly-export", claims["sub"])
+	assert.Equal(s.T(), "nightly-export", claims["client_id"])
+	assert.Equal(s.T(), "plans:read users:read", claims["scope"])
+	assert.NotContains(s.T(), claims, "user_id")
+
+	parsedToken, err := manager.ParseToken(token.Value)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), string(constants.TokenTypeClient), parsedToken.Type)
+	assert.Equal(s.T(), "nightly-export", parsedToken.ClientID)
+	assert.Equal(s.T(), uuid.Nil, parsedToken.UserID)
+	assert.Equal(s.T(), []string{"plans:read", "users:read"}, parsedToken.Permissions)
+	assert.Equal(s.T(), []string{"planner"}, parsedToken.Audience)
+}
+
+func (s *ManagerTestSuite) TestIDToken() {
+	manager := NewManager("test-secret", time.Minute, time.Hour,
+		WithIssuer("https://auth.breakfront.test"), WithAudience("planner"))
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	token, err := manager.GenerateIDToken(s.testUser, "planner-spa", "n-0S6_WzA2Mj", authTime)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "planner-spa", token.ClientID)
+
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(token.Value, claims)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.testUser.ID.String(), claims["sub"])
+	assert.Equal(s.T(), "https://auth.breakfront.test", claims["iss"])
+	assert.Equal(s.T(), "planner-spa", claims["aud"], "ID tokens are issued to the client, not the configured audience")
+	assert.Equal(s.T(), "n-0S6_WzA2Mj", claims["nonce"])
+	assert.EqualValues(s.T(), authTime.Unix(), claims["auth_time"])
+	assert.NotContains(s.T(), claims, "roles")
+
+	parsedToken, err := manager.ParseToken(token.Value)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), string(constants.TokenTypeID), parsedToken.Type)
+
+	token, err = manager.GenerateIDToken(s.testUser, "planner-spa", "", authTime)
+	require.NoError(s.T(), err)
+	claims = jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(token.Value, claims)
+	require.NoError(s.T(), err)
+	assert.NotContains(s.T(), claims, "nonce")
+}
+
+func (s *ManagerTestSuite) TestExchangedToken() {
+	manager := NewManager("test-secret", time.Hour, time.Hour, WithAudience("planner"))
+	subject := &models.ParsedToken{
+		UserID:      s.testUser.ID,
+		Type:        string(constants.TokenTypeAccess),
+		Roles:       []string{"editor"},
+		Permissions: []string{"plans:read", "plans:write"},
+		ExpiresAt:   time.Now().Add(10 * time.Minute),
+		Actor:       &models.Actor{Subject: "planner-api"},
+	}
+	actor := &models.Actor{Subject: "scheduler", Actor: subject.Actor}
+
+	token, err := manager.GenerateExchangedToken(subject, "notifications", []string{"plans:read"}, actor)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.testUser.ID, token.UserID)
+	assert.Equal(s.T(), subject.ExpiresAt.Unix(), token.ExpiresAt.Unix(), "exchanged tokens don't outlive the subject token")
+
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(token.Value, claims)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "notifications", claims["aud"])
+	assert.Equal(s.T(), map[string]interface{}{"sub": "scheduler", "act": map[string]interface{}{"sub": "planner-api"}},
+		claims["act"])
+	assert.NotContains(s.T(), claims, "roles")
+
+	parsedToken, err := manager.ParseToken(token.Value)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), string(constants.TokenTypeAccess), parsedToken.Type)
+	assert.Equal(s.T(), s.testUser.ID, parsedToken.UserID)
+	assert.Equal(s.T(), []string{"notifications"}, parsedToken.Audience)
+	assert.Equal(s.T(), []string{"plans:read"}, parsedToken.Permissions)
+	assert.Equal(s.T(), actor, parsedToken.Actor)
+}
+
+func (s *ManagerTestSuite) TestRejectsMalformedActorClaim() {
+	for _, act := range []interface{}{"scheduler", map[string]interface{}{"client_id": "scheduler"},
+		map[string]interface{}{"sub": "scheduler", "act": "planner-api"}} {
+		claims := jwt.MapClaims{
+			"sub":  s.testUser.ID.String(),
+			"type": string(constants.TokenTypeAccess),
+			"exp":  time.Now().Add(time.Minute).Unix(),
+			"jti":  uuid.NewString(),
+			"act":  act,
+		}
+		value, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+		require.NoError(s.T(), err)
+
+		_, err = NewManager("test-secret", 0, 0).ParseToken(value)
+		assert.ErrorContains(s.T(), err, "act")
+	}
+}
+
+func (s *ManagerTestSuite) TestRejectsMalformedRolesClaim() {
+	claims := jwt.MapClaims{
+		"sub":   s.testUser.ID.String(),
+		"type":  string(constants.TokenTypeAccess),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"jti":   uuid.NewString(),
+		"roles": "admin",
+	}
+	value, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+	require.NoError(s.T(), err)
+
+	_, err = NewManager("test-secret", 0, 0).ParseToken(value)
+	assert.ErrorContains(s.T(), err, "roles")
+}
+
+func (s *ManagerTestSuite) TestRejectsForeignIssuer() {
+	token, err := NewManager("test-secret", time.Minute, time.Hour, WithIssuer("https://other.test")).
+		GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	_, err = NewManager("test-secret", 0, 0, WithIssuer("https://auth.breakfront.test")).ParseToken(token.Value)
+	assert.ErrorIs(s.T(), err, jwt.ErrTokenInvalidIssuer)
+}
+
+func (s *ManagerTestSuite) TestTimeClaimsLeeway() {
+	// A token minted by a server whose clock runs ahead
+	signTokenAt := func(issuedAt time.Time) string {
+		claims := jwt.MapClaims{
+			"sub":  s.testUser.ID.String(),
+			"iat":  issuedAt.Unix(),
+			"nbf":  issuedAt.Unix(),
+			"exp":  issuedAt.Add(time.Minute).Unix(),
+			"type": string(constants.TokenTypeAccess),
+			"jti":  uuid.NewString(),
+		}
+		value, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+		require.NoError(s.T(), err)
+		return value
+	}
+
+	strict := NewManager("test-secret", 0, 0)
+	lenient := NewManager("test-secret", 0, 0, WithLeeway(DefaultLeeway))
+
+	skewed := signTokenAt(time.Now().Add(10 * time.Second))
+	_, err := strict.ParseToken(skewed)
+	assert.ErrorIs(s.T(), err, jwt.ErrTokenNotValidYet)
+	_, err = lenient.ParseToken(skewed)
+	assert.NoError(s.T(), err)
+
+	future := signTokenAt(time.Now().Add(time.Hour))
+	_, err = lenient.ParseToken(future)
+	assert.ErrorIs(s.T(), err, jwt.ErrTokenNotValidYet)
+
+	justExpired := signTokenAt(time.Now().Add(-time.Minute - 10*time.Second))
+	_, err = strict.ParseToken(justExpired)
+	assert.ErrorIs(s.T(), err, jwt.ErrTokenExpired)
+	_, err = lenient.ParseToken(justExpired)
+	assert.NoError(s.T(), err)
+}
+
+func (s *ManagerTestSuite) TestKeyLoadingErrors() {
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(s.T(), err)
+	p384DER, err := x509.MarshalPKCS8PrivateKey(p384Key)
+	require.NoError(s.T(), err)
+
+	testCases := []struct {
+		name          string
+		load          func() error
+		errorContains string
+	}{
+		{
+			name: "none algorithm",
+			load: func() error {
+				_, err := LoadSigningKey("none", "", "")
+				return err
+			},
+			errorContains: "unsupported signing algorithm",
+		},
+		{
+			name: "empty secret",
+			load: func() error {
+				_, err := LoadSigningKey("HS256", "", "")
+				return err
+			},
+			errorContains: autherrors.ErrShortSecret.Error(),
+		},
+		{
+			name: "short secret",
+			load: func() error {
+				_, err := LoadSigningKey("HS256", "test-secret", "")
+				return err
+			},
+			errorContains: autherrors.ErrShortSecret.Error(),
+		},
+		{
+			name: "unknown algorithm",
+			load: func() error {
+				_, err := ParsePublicKey("XX999", s.publicPEMs["RS256"])
+				return err
+			},
+			errorContains: "unsupported signing algorithm",
+		},
+		{
+			name: "missing key file",
+			load: func() error {
+				_, err := LoadSigningKey("RS256", "", filepath.Join(s.keyDir, "missing.pem"))
+				return err
+			},
+			errorContains: "failed to load signing key",
+		},
+		{
+			name: "key of another type",
+			load: func() error {
+				_, err := ParsePrivateKey("ES256", s.privatePEMs["RS256"])
+				return err
+			},
+			errorContains: "failed to load signing key",
+		},
+		{
+			name: "curve doesn't match algorithm",
+			load: func() error {
+				_, err := ParsePrivateKey("ES256", encodePEM("PRIVATE KEY", p384DER))
+				return err
+			},
+			errorContains: autherrors.ErrKeyAlgMismatch.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			err := tc.load()
+
+			require.Error(s.T(), err)
+			assert.ErrorContains(s.T(), err, tc.errorContains)
+		})
+	}
+}
+
+func TestManagerTestSuite(t *testing.T) {
+	suite.Run(t, new(ManagerTestSuite))
+}
@@ -0,0 +1,119 @@
+package jwt
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// KeySet holds the keys known to a Manager, indexed by key ID.
+// The active key signs new tokens; the remaining keys only verify tokens
+// issued before a rotation until they are retired.
+type KeySet struct {
+	mu     sync.RWMutex
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// NewKeySet creates a key set with the given active key and additional verification keys.
+// The active key may be nil for verification-only key sets.
+func NewKeySet(active *SigningKey, verificationKeys ...*SigningKey) (*KeySet, error) {
+	ks := &KeySet{}
+	if err := ks.Replace(active, verificationKeys...); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Replace atomically swaps all keys of the set.
+func (ks *KeySet) Replace(active *SigningKey, verificationKeys ...*SigningKey) error {
+	keys := make(map[string]*SigningKey, len(verificationKeys)+1)
+
+	all := verificationKeys
+	if active != nil {
+		all = append([]*SigningKey{active}, verificationKeys...)
+	}
+	for _, key := range all {
+		if _, exists := keys[key.ID]; exists {
+			return autherrors.ErrDuplicateKeyID(key.ID)
+		}
+		keys[key.ID] = key
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.active = active
+	ks.keys = keys
+
+	return nil
+}
+
+// Active returns the key used to sign new tokens, or nil for verification-only sets.
+func (ks *KeySet) Active() *SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return ks.active
+}
+
+// Find returns the key with the given key ID.
+func (ks *KeySet) Find(kid string) (*SigningKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// Rotate makes newActive the signing key. The previous active key stays available for verification.
+func (ks *KeySet) Rotate(newActive *SigningKey) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if _, exists := ks.keys[newActive.ID]; exists {
+		return autherrors.ErrDuplicateKeyID(newActive.ID)
+	}
+
+	ks.keys[newActive.ID] = newActive
+	ks.active = newActive
+
+	return nil
+}
+
+// Retire removes a verification key, after which tokens signed with it are rejected.
+func (ks *KeySet) Retire(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if ks.active != nil && ks.active.ID == kid {
+		return autherrors.ErrRetireActiveKey
+	}
+	if _, exists := ks.keys[kid]; !exists {
+		return autherrors.ErrUnknownKeyID
+	}
+
+	delete(ks.keys, kid)
+
+	return nil
+}
+
+// JWKS returns the public keys of the set. Symmetric keys are never published.
+func (ks *KeySet) JWKS() *JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	jwks := &JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		if jwk, ok := key.JWK(); ok {
+			jwks.Keys = append(jwks.Keys, *jwk)
+		}
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+
+	return jwks
+}
@@ -0,0 +1,183 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+type KeySetTestSuite struct {
+	suite.Suite
+	testUser *models.User
+}
+
+func (s *KeySetTestSuite) SetupSuite() {
+	s.testUser = &models.User{ID: uuid.New()}
+}
+
+func (s *KeySetTestSuite) newECKey() *SigningKey {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(s.T(), err)
+	der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(s.T(), err)
+
+	key, err := ParsePrivateKey("ES256", encodePEM("PRIVATE KEY", der))
+	require.NoError(s.T(), err)
+	return key
+}
+
+func (s *KeySetTestSuite) TestTokenCarriesKeyID() {
+	key := s.newECKey()
+	keySet, err := NewKeySet(key)
+	require.NoError(s.T(), err)
+	manager := NewManagerWithKeySet(keySet, time.Minute, time.Hour)
+
+	token, err := manager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	var header map[string]any
+	encodedHeader, _, _ := strings.Cut(token.Value, ".")
+	segment, err := b64.DecodeString(encodedHeader)
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), json.Unmarshal(segment, &header))
+
+	assert.NotEmpty(s.T(), key.ID)
+	assert.Equal(s.T(), key.ID, header["kid"])
+	assert.Equal(s.T(), "ES256", header["alg"])
+}
+
+func (s *KeySetTestSuite) TestRotationKeepsOldTokensValidUntilRetired() {
+	oldKey := s.newECKey()
+	newKey := s.newECKey()
+
+	keySet, err := NewKeySet(oldKey)
+	require.NoError(s.T(), err)
+	manager := NewManagerWithKeySet(keySet, time.Minute, time.Hour)
+
+	oldToken, err := manager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), keySet.Rotate(newKey))
+	assert.Equal(s.T(), newKey.ID, keySet.Active().ID)
+
+	newToken, err := manager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	_, err = manager.ParseToken(oldToken.Value)
+	assert.NoError(s.T(), err, "tokens signed before rotation must stay valid")
+	_, err = manager.ParseToken(newToken.Value)
+	assert.NoError(s.T(), err)
+
+	require.NoError(s.T(), keySet.Retire(oldKey.ID))
+
+	_, err = manager.ParseToken(oldToken.Value)
+	assert.ErrorIs(s.T(), err, autherrors.ErrUnknownKeyID)
+	_, err = manager.ParseToken(newToken.Value)
+	assert.NoError(s.T(), err)
+}
+
+func (s *KeySetTestSuite) TestRejectsUnknownKeyID() {
+	foreignManager := NewManagerWithKey(s.newECKey(), time.Minute, time.Hour)
+	token, err := foreignManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	manager := NewManagerWithKey(s.newECKey(), time.Minute, time.Hour)
+	_, err = manager.ParseToken(token.Value)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrUnknownKeyID)
+}
+
+func (s *KeySetTestSuite) TestKeySetErrors() {
+	key := s.newECKey()
+
+	_, err := NewKeySet(key, key)
+	assert.ErrorContains(s.T(), err, "duplicate key id")
+
+	keySet, err := NewKeySet(key)
+	require.NoError(s.T(), err)
+
+	assert.ErrorIs(s.T(), keySet.Retire(key.ID), autherrors.ErrRetireActiveKey)
+	assert.ErrorIs(s.T(), keySet.Retire("missing"), autherrors.ErrUnknownKeyID)
+	assert.ErrorContains(s.T(), keySet.Rotate(key), "duplicate key id")
+}
+
+func (s *KeySetTestSuite) TestJWKSPublishesOnlyPublicKeys() {
+	activeKey := s.newECKey()
+	verificationKey := s.newECKey()
+	verificationKey.SignKey = nil
+
+	keySet, err := NewKeySet(activeKey, verificationKey, NewHMACKey("secret"))
+	require.NoError(s.T(), err)
+
+	data, err := json.Marshal(keySet.JWKS())
+	require.NoError(s.T(), err)
+	assert.NotContains(s.T(), string(data), `"d"`)
+	assert.NotContains(s.T(), string(data), "oct")
+
+	keys, err := ParseJWKS(data)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), keys, 2)
+
+	// Consumers rebuild a verification-only key set from the published JWKS.
+	consumerKeys, err := NewKeySet(nil, keys...)
+	require.NoError(s.T(), err)
+	consumer := NewManagerWithKeySet(consumerKeys, 0, 0)
+
+	token, err := NewManagerWithKeySet(keySet, time.Minute, time.Hour).
+		GenerateToken(s.testUser, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+
+	parsedToken, err := consumer.ParseToken(token.Value)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.testUser.ID, parsedToken.UserID)
+}
+
+func (s *KeySetTestSuite) TestThumbprintMatchesRFC7638() {
+	// Example key and thumbprint from RFC 7638, section 3.1.
+	jwks := `{"keys":[{"kty":"RSA","alg":"RS256","kid":"2011-04-29","e":"AQAB",` +
+		`"n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"}]}`
+
+	keys, err := ParseJWKS([]byte(jwks))
+	require.NoError(s.T(), err)
+	require.Len(s.T(), keys, 1)
+
+	assert.Equal(s.T(), "2011-04-29", keys[0].ID)
+	assert.Equal(s.T(), "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint(keys[0].VerifyKey))
+}
+
+func (s *KeySetTestSuite) TestParseJWKSErrors() {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{name: "not JSON", data: "keys"},
+		{name: "unsupported algorithm", data: `{"keys":[{"kty":"oct","alg":"none"}]}`},
+		{name: "key type doesn't match algorithm", data: `{"keys":[{"kty":"OKP","crv":"Ed25519","alg":"ES256","x":"AAAA"}]}`},
+		{name: "point not on curve", data: `{"keys":[{"kty":"EC","crv":"P-256","alg":"ES256","x":"AQ","y":"AQ"}]}`},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			_, err := ParseJWKS([]byte(tc.data))
+			assert.Error(s.T(), err)
+		})
+	}
+}
+
+func TestKeySetTestSuite(t *testing.T) {
+	suite.Run(t, new(KeySetTestSuite))
+}
@@ -0,0 +1,174 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// MinHMACSecretLength is the minimum length in bytes of the shared secret of HMAC algorithms, the output size of
+// SHA-256. Shorter secrets, and an empty one in particular, would let anyone guess the key and forge tokens.
+const MinHMACSecretLength = 32
+
+// SigningKey holds a signing method together with the keys used to sign and verify tokens.
+// For HMAC methods both keys are the shared secret. For asymmetric methods SignKey is the
+// private key and VerifyKey the matching public key; SignKey is nil for verification-only keys.
+// ID is sent as the "kid" header; asymmetric keys use their RFC 7638 thumbprint.
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	SignKey   any
+	VerifyKey any
+}
+
+// NewHMACKey creates an HS256 signing key from a shared secret.
+func NewHMACKey(secret string) *SigningKey {
+	return &SigningKey{
+		Method:    jwt.SigningMethodHS256,
+		SignKey:   []byte(secret),
+		VerifyKey: []byte(secret),
+	}
+}
+
+// LoadSigningKey builds the signing key for the configured algorithm.
+// HMAC algorithms use the shared secret, which must be at least MinHMACSecretLength bytes long,
+// asymmetric ones read the PEM private key from privateKeyPath.
+func LoadSigningKey(algorithm, secret, privateKeyPath string) (*SigningKey, error) {
+	method, err := signingMethod(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		if len(secret) < MinHMACSecretLength {
+			return nil, autherrors.ErrShortSecret
+		}
+		return &SigningKey{
+			Method:    method,
+			SignKey:   []byte(secret),
+			VerifyKey: []byte(secret),
+		}, nil
+	}
+
+	pemData, err := os.ReadFile(privateKeyPath) //nolint:gosec // G304: key path comes from service configuration
+	if err != nil {
+		return nil, autherrors.ErrLoadKey(err)
+	}
+
+	return ParsePrivateKey(algorithm, pemData)
+}
+
+// LoadPublicKey reads a PEM public key from path and builds a verification-only key for algorithm.
+func LoadPublicKey(algorithm, path string) (*SigningKey, error) {
+	pemData, err := os.ReadFile(path) //nolint:gosec // G304: key path comes from service configuration
+	if err != nil {
+		return nil, autherrors.ErrLoadKey(err)
+	}
+
+	return ParsePublicKey(algorithm, pemData)
+}
+
+// ParsePrivateKey builds a signing key from a PEM encoded RSA, ECDSA or Ed25519 private key.
+// The public key used for verification is derived from the private key.
+func ParsePrivateKey(algorithm string, pemData []byte) (*SigningKey, error) {
+	method, err := signingMethod(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	var privateKey crypto.Signer
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		privateKey, err = jwt.ParseRSAPrivateKeyFromPEM(pemData)
+	case *jwt.SigningMethodECDSA:
+		var ecKey *ecdsa.PrivateKey
+		ecKey, err = jwt.ParseECPrivateKeyFromPEM(pemData)
+		if err == nil && ecKey.Curve.Params().BitSize != m.CurveBits {
+			return nil, autherrors.ErrKeyAlgMismatch
+		}
+		privateKey = ecKey
+	case *jwt.SigningMethodEd25519:
+		var edKey crypto.PrivateKey
+		edKey, err = jwt.ParseEdPrivateKeyFromPEM(pemData)
+		if err == nil {
+			privateKey, _ = edKey.(ed25519.PrivateKey)
+		}
+	default:
+		return nil, autherrors.ErrUnsupportedAlgorithm(algorithm)
+	}
+
+	if err != nil {
+		return nil, autherrors.ErrLoadKey(err)
+	}
+	if privateKey == nil {
+		return nil, autherrors.ErrKeyAlgMismatch
+	}
+
+	return &SigningKey{
+		ID:        thumbprint(privateKey.Public()),
+		Method:    method,
+		SignKey:   privateKey,
+		VerifyKey: privateKey.Public(),
+	}, nil
+}
+
+// ParsePublicKey builds a verification-only key from a PEM encoded RSA, ECDSA or Ed25519 public key.
+func ParsePublicKey(algorithm string, pemData []byte) (*SigningKey, error) {
+	method, err := signingMethod(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	var publicKey crypto.PublicKey
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		var rsaKey *rsa.PublicKey
+		rsaKey, err = jwt.ParseRSAPublicKeyFromPEM(pemData)
+		publicKey = rsaKey
+	case *jwt.SigningMethodECDSA:
+		var ecKey *ecdsa.PublicKey
+		ecKey, err = jwt.ParseECPublicKeyFromPEM(pemData)
+		if err == nil && ecKey.Curve.Params().BitSize != m.CurveBits {
+			return nil, autherrors.ErrKeyAlgMismatch
+		}
+		publicKey = ecKey
+	case *jwt.SigningMethodEd25519:
+		publicKey, err = jwt.ParseEdPublicKeyFromPEM(pemData)
+	default:
+		return nil, autherrors.ErrUnsupportedAlgorithm(algorithm)
+	}
+
+	if err != nil {
+		return nil, autherrors.ErrLoadKey(err)
+	}
+
+	return &SigningKey{
+		ID:        thumbprint(publicKey),
+		Method:    method,
+		VerifyKey: publicKey,
+	}, nil
+}
+
+// signingMethod resolves a JWA algorithm name, rejecting "none" and unknown algorithms.
+// IsHMAC reports whether the algorithm signs with a shared secret.
+func IsHMAC(algorithm string) bool {
+	_, ok := jwt.GetSigningMethod(algorithm).(*jwt.SigningMethodHMAC)
+	return ok
+}
+
+func signingMethod(algorithm string) (jwt.SigningMethod, error) {
+	method := jwt.GetSigningMethod(algorithm)
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC, *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS,
+		*jwt.SigningMethodECDSA, *jwt.SigningMethodEd25519:
+		return method, nil
+	default:
+		return nil, autherrors.ErrUnsupportedAlgorithm(algorithm)
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthorizationRequest holds the parameters of a request to the authorization endpoint.
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// Scope is the space separated list of requested scopes; "openid" asks for an ID token.
+	Scope string
+	// Nonce is echoed in the ID token to bind it to the client's session (OpenID Connect).
+	Nonce string
+}
+
+// AuthorizationCode is a short-lived, single-use code the client exchanges for a token pair.
+// Only the hash of the code is stored.
+type AuthorizationCode struct {
+	Value               string
+	CodeHash            string
+	ClientID            string
+	UserID              uuid.UUID
+	RedirectURI         string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	ExpiresAt           time.Time
+	UsedAt              *time.Time
+	// CreatedAt is also when the user authenticated, reported as auth_time in the ID token.
+	CreatedAt time.Time
+	Scopes    []string
+	Nonce     string
+	// TokenFamilyID is the session started by exchanging the code, nil until then.
+	// It is revoked if the code is presented again.
+	TokenFamilyID *uuid.UUID
+}
+
+// AuthorizationCodeExchange holds the parameters of an authorization code grant at the token endpoint.
+type AuthorizationCodeExchange struct {
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+}
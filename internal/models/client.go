@@ -0,0 +1,36 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Client is an application registered to obtain tokens on behalf of users through the OAuth endpoints.
+// Confidential clients, such as backend services, authenticate with a secret; public clients,
+// such as single-page and mobile apps, can't keep a secret and have none.
+type Client struct {
+	ID   string
+	Name string
+	// SecretHash is the SHA-256 hash of the client secret, empty for public clients.
+	SecretHash   string
+	RedirectURIs []string
+	// Scopes are the permissions a confidential client may request for its own client tokens.
+	Scopes    []string
+	CreatedAt time.Time
+}
+
+// IsConfidential reports whether the client authenticates with a secret.
+func (c *Client) IsConfidential() bool {
+	return c.SecretHash != ""
+}
+
+// AllowsScope reports whether the client may be granted the scope.
+func (c *Client) AllowsScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// AllowsRedirectURI reports whether uri is one of the client's registered redirect URIs.
+// URIs are compared exactly, as required for OAuth 2.0 security best practice.
+func (c *Client) AllowsRedirectURI(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
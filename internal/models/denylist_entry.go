@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DenylistEntry marks a single access token, identified by its jti, as revoked until the token expires.
+type DenylistEntry struct {
+	JTI       string
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeviceAuthorizationStatus is the user's decision on a device authorization request.
+type DeviceAuthorizationStatus string
+
+const (
+	DeviceAuthorizationPending  DeviceAuthorizationStatus = "pending"
+	DeviceAuthorizationApproved DeviceAuthorizationStatus = "approved"
+	DeviceAuthorizationDenied   DeviceAuthorizationStatus = "denied"
+)
+
+// DeviceAuthorization is a pending sign-in of a device that can't show a browser, such as the planner CLI.
+// The device polls with the device code while the user enters the user code on another device.
+// Only the hashes of both codes are stored.
+type DeviceAuthorization struct {
+	DeviceCodeHash string
+	UserCodeHash   string
+	ClientID       string
+	Scopes         []string
+	Status         DeviceAuthorizationStatus
+	// UserID is set once the user approved or denied the request.
+	UserID *uuid.UUID
+	// PollInterval is the minimum time between two token requests of the device.
+	PollInterval time.Duration
+	LastPolledAt *time.Time
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// DeviceCodes are issued to the device by the device authorization endpoint (RFC 8628, section 3.2).
+type DeviceCodes struct {
+	DeviceCode string
+	UserCode   string
+	// VerificationURI is where the user enters the user code; VerificationURIComplete already includes it.
+	VerificationURI         string
+	VerificationURIComplete string
+	ExpiresAt               time.Time
+	Interval                time.Duration
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// LoginAttemptKind is what failed login attempts are counted for.
+type LoginAttemptKind string
+
+const (
+	LoginAttemptLogin     LoginAttemptKind = "login"
+	LoginAttemptIPAddress LoginAttemptKind = "ip"
+)
+
+// LoginAttemptKey identifies a login or a client IP address whose failed login attempts are counted.
+type LoginAttemptKey struct {
+	Kind  LoginAttemptKind
+	Value string
+}
+
+// LoginAttempts are the recent failed login attempts for a login or an IP address.
+type LoginAttempts struct {
+	Key          LoginAttemptKey
+	FailedCount  int
+	LastFailedAt time.Time
+	// LockedUntil is set once the failures reached the lockout threshold; logins are rejected until then.
+	LockedUntil *time.Time
+}
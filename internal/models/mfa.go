@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TOTPCredential is the authenticator app a user set up for two-factor authentication.
+// Secret is encrypted if an encryption key is configured; it can't be hashed, as codes are computed from it.
+type TOTPCredential struct {
+	UserID uuid.UUID
+	Secret string
+	// ConfirmedAt is set once the user entered a code of the new authenticator; until then the credential
+	// is a pending enrollment that is not required at login.
+	ConfirmedAt *time.Time
+	// LastUsedStep is the time step of the last accepted code; codes of this or earlier steps are rejected,
+	// so that an observed code can't be used again.
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+// IsConfirmed reports whether the credential is required at login.
+func (c *TOTPCredential) IsConfirmed() bool {
+	return c.ConfirmedAt != nil
+}
+
+// TOTPEnrollment is returned when a user starts setting up an authenticator app.
+// ProvisioningURI is the otpauth URI to show as a QR code; Secret is the same key for typing it in by hand.
+type TOTPEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// MFAStatus describes the two-factor authentication of a user.
+type MFAStatus struct {
+	TOTPEnabled bool
+	// RecoveryCodesRemaining is the number of recovery codes the user hasn't used yet.
+	RecoveryCodesRemaining int
+}
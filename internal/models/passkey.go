@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasskeyCeremony is the WebAuthn ceremony a passkey challenge was issued for.
+type PasskeyCeremony string
+
+const (
+	// PasskeyCeremonyRegistration adds a passkey to the account of a signed-in user.
+	PasskeyCeremonyRegistration PasskeyCeremony = "registration"
+	// PasskeyCeremonyLogin signs in with a passkey instead of a password; the passkey identifies the user.
+	PasskeyCeremonyLogin PasskeyCeremony = "login"
+	// PasskeyCeremonyMFA checks a passkey as the second factor of a password login.
+	PasskeyCeremonyMFA PasskeyCeremony = "mfa"
+)
+
+// Passkey is a WebAuthn credential with which a user signs in instead of with a password,
+// or as a second factor after it.
+type Passkey struct {
+	// ID is the credential ID chosen by the authenticator.
+	ID     []byte
+	UserID uuid.UUID
+	// Name is chosen by the user to tell their passkeys apart.
+	Name string
+	// PublicKey is the COSE_Key encoding of the public key that assertions are verified with.
+	PublicKey []byte
+	// SignCount is the last signature counter reported by the authenticator, or 0 if it doesn't count.
+	SignCount uint32
+	// AAGUID identifies the authenticator model, if it discloses it.
+	AAGUID []byte
+	// BackupEligible reports whether the passkey may be synced to other devices, BackedUp whether it is.
+	BackupEligible bool
+	BackedUp       bool
+	CreatedAt      time.Time
+	LastUsedAt     *time.Time
+}
+
+// PasskeyChallenge is the challenge of a running WebAuthn ceremony. It is single-use
+// and only its hash is stored.
+type PasskeyChallenge struct {
+	ChallengeHash string
+	Ceremony      PasskeyCeremony
+	// UserID is the user registering a passkey or checking one as a second factor;
+	// nil for passkey logins, where the user is not known before the passkey is checked.
+	UserID    *uuid.UUID
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// PasskeyCreationOptions are the options of a registration ceremony, passed by the browser
+// to navigator.credentials.create.
+type PasskeyCreationOptions struct {
+	Challenge []byte
+	RPID      string
+	RPName    string
+	// UserHandle is the user ID stored with the passkey by the authenticator and returned at login.
+	UserHandle []byte
+	UserName   string
+	// Algorithms are the COSE algorithms of the public keys accepted, in order of preference.
+	Algorithms []int64
+	// ExcludeCredentials are the user's passkeys, so the same authenticator isn't registered twice.
+	ExcludeCredentials [][]byte
+	Timeout            time.Duration
+}
+
+// PasskeyRequestOptions are the options of an authentication ceremony, passed by the browser
+// to navigator.credentials.get.
+type PasskeyRequestOptions struct {
+	Challenge []byte
+	RPID      string
+	// AllowCredentials are the passkeys of the user checking a second factor; empty for passkey logins,
+	// which let the user pick any passkey stored on the authenticator.
+	AllowCredentials [][]byte
+	// UserVerification is "required" for passkey logins, which replace the password,
+	// and "discouraged" for second factors, where presence suffices.
+	UserVerification string
+	Timeout          time.Duration
+}
+
+// PasskeyAttestation is the authenticator's response to a registration ceremony.
+type PasskeyAttestation struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// PasskeyAssertion is the authenticator's response to an authentication ceremony.
+type PasskeyAssertion struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	// UserHandle is the user the passkey was registered for; authenticators return it for passkey logins.
+	UserHandle []byte
+}
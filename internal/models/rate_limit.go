@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RateLimitState is what a rate limiting algorithm remembers about a key between requests.
+type RateLimitState struct {
+	Key string
+	// Value is the number of tokens left in a token bucket, or the number of requests in the current sliding window.
+	Value float64
+	// PreviousValue is the number of requests in the previous sliding window.
+	PreviousValue float64
+	// UpdatedAt is when a token bucket was last refilled or when the current sliding window started;
+	// it is zero for keys without requests.
+	UpdatedAt time.Time
+	// ExpiresAt is when the state no longer affects requests and can be dropped.
+	ExpiresAt time.Time
+}
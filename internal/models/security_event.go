@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SecurityEvent records a suspicious action detected on a user's account or a change to how the user signs in.
+type SecurityEvent struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	Type          string
+	TokenFamilyID *uuid.UUID
+	CreatedAt     time.Time
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionMetadata describes the client a session was opened from.
+type SessionMetadata struct {
+	UserAgent  string
+	IPAddress  string
+	DeviceName string
+}
+
+// Session represents a login on one device, spanning all refresh tokens of a token family.
+type Session struct {
+	ID uuid.UUID
+	SessionMetadata
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+}
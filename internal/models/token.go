@@ -11,13 +11,67 @@ type Token struct {
 	Value       string
 	HashedValue string
 	UserID      uuid.UUID
-	ExpiresAt   time.Time
-	RevokedAt   *time.Time
+	FamilyID    uuid.UUID
+	Session     SessionMetadata
+	CreatedAt   time.Time
+	// SessionStartedAt is when the first token of the family was issued.
+	SessionStartedAt time.Time
+	ExpiresAt        time.Time
+	RevokedAt        *time.Time
+	// ClientID is set instead of UserID for client tokens. For user tokens it is the OAuth client
+	// the user signed in to, empty for tokens issued by the auth endpoints.
+	ClientID string
+	// Scopes are the scopes granted to the OAuth client of a user token, which the token is limited to.
+	Scopes []string
 }
 
 // Token represents parsed JWT token claims.
 type ParsedToken struct {
-	UserID    uuid.UUID
-	Type      string
-	ExpiresAt time.Time
+	// JTI is the unique token ID, used to revoke a single access token.
+	JTI      string
+	UserID   uuid.UUID
+	Type     string
+	Issuer   string
+	Audience []string
+	// Roles and Permissions are only carried by access tokens.
+	// The permissions of a client token, or of a user token issued to an OAuth client, are the scopes granted
+	// to the client.
+	Roles       []string
+	Permissions []string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+	// ClientID is the subject of client tokens, which have no user.
+	ClientID string
+	// AuthorizedParty is the OAuth client a user token was issued to, from the "azp" claim,
+	// empty for tokens issued by the auth endpoints.
+	AuthorizedParty string
+	// Actor is the service acting on behalf of the user of a token issued by token exchange, nil otherwise.
+	Actor *Actor
+}
+
+// Actor is the party acting on behalf of a token's subject, carried in the "act" claim (RFC 8693, section 4.1).
+// When a delegated token is exchanged again, the previous actor is nested in Actor, the current one on top.
+type Actor struct {
+	// Subject is the client ID of the acting service.
+	Subject string
+	Actor   *Actor
+}
+
+// IssuedTokens are the tokens issued by a grant of the OAuth token endpoint.
+// Only the authorization code and refresh token grants issue a refresh token,
+// and an ID token is only issued if the "openid" scope was granted.
+type IssuedTokens struct {
+	AccessToken  *Token
+	RefreshToken *Token
+	IDToken      *Token
+	Scopes       []string
+	// IssuedTokenType is the token type URI of AccessToken, only reported by the token exchange grant.
+	IssuedTokenType string
+}
+
+// TokenIntrospection is the state of a token as reported to resource servers (RFC 7662).
+type TokenIntrospection struct {
+	Active bool
+	// Token holds the claims of an active token and is nil otherwise.
+	Token *ParsedToken
 }
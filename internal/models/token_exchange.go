@@ -0,0 +1,13 @@
+package models
+
+// TokenExchange holds the parameters of a token exchange request (RFC 8693, section 2.1).
+// The client is the actor; SubjectToken is the token of the user it acts for.
+type TokenExchange struct {
+	ClientID           string
+	ClientSecret       string
+	SubjectToken       string
+	SubjectTokenType   string
+	RequestedTokenType string
+	Audience           string
+	Scopes             []string
+}
@@ -13,6 +13,11 @@ type User struct {
 	PasswordHash string
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	// TokensValidAfter invalidates every token issued before it; nil if the user never signed out everywhere.
+	TokensValidAfter *time.Time
+	// Roles and Permissions are loaded separately from the user record before access tokens are issued.
+	Roles       []string
+	Permissions []string
 }
 
 // UserFilter provides criteria for searching users.
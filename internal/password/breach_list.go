@@ -0,0 +1,173 @@
+package password
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1" //nolint:gosec // G505: the breached password list is keyed by SHA-1
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+const (
+	// hashPrefixLength is the length of the hash prefixes naming the files of a breach list directory.
+	hashPrefixLength = 5
+	// maxLineLength bounds the lines of a breach list: a SHA-1 hash, a colon and the number of breaches.
+	maxLineLength = 128
+)
+
+// BreachList looks up passwords in an offline copy of the Have I Been Pwned list of breached passwords,
+// so that passwords never leave the service. Two layouts of the list are supported:
+//   - a single file of "<SHA-1>:<count>" lines sorted by hash, as downloaded in one piece,
+//     which is binary searched without being loaded into memory;
+//   - a directory of range files named "<first 5 hex digits of SHA-1>.txt" with "<remaining 35 hex digits>:<count>"
+//     lines, as returned by the range API and saved by the official downloader.
+type BreachList struct {
+	file *os.File
+	size int64
+	dir  string
+}
+
+// OpenBreachList opens the breach list file or directory at the path.
+func OpenBreachList(path string) (*BreachList, error) {
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, autherrors.ErrReadBreachList(err)
+	}
+	if info.IsDir() {
+		return &BreachList{dir: path}, nil
+	}
+
+	file, err := os.Open(path) //nolint:gosec // G304: list path comes from service configuration
+	if err != nil {
+		return nil, autherrors.ErrReadBreachList(err)
+	}
+
+	return &BreachList{file: file, size: info.Size()}, nil
+
+}
+
+// Contains reports whether the password appears in the breach list.
+func (l *BreachList) Contains(password string) (bool, error) {
+
+	sum := sha1.Sum([]byte(password)) //nolint:gosec // G401: the breached password list is keyed by SHA-1
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	if l.dir != "" {
+		return l.containsInRange(hash)
+	}
+	return l.containsInFile(hash)
+
+}
+
+// Close closes the breach list file.
+func (l *BreachList) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// containsInRange scans the range file of the hash prefix for the rest of the hash.
+// A missing range file has no hashes, so partial copies of the list can be used.
+func (l *BreachList) containsInRange(hash string) (bool, error) {
+
+	file, err := os.Open(filepath.Join(l.dir, hash[:hashPrefixLength]+".txt")) //nolint:gosec // G304: list path comes from service configuration
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, autherrors.ErrReadBreachList(err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	suffix := hash[hashPrefixLength:]
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if strings.EqualFold(lineHash(scanner.Text()), suffix) {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, autherrors.ErrReadBreachList(err)
+	}
+
+	return false, nil
+
+}
+
+// containsInFile binary searches the sorted file for the hash. It finds the smallest offset
+// at or after which the next line's hash is not less than the hash, then compares that line.
+func (l *BreachList) containsInFile(hash string) (bool, error) {
+
+	low, high := int64(0), l.size
+	for low < high {
+		mid := low + (high-low)/2
+		line, err := l.lineAt(mid)
+		if err != nil {
+			return false, err
+		}
+		if line == "" || strings.ToUpper(lineHash(line)) >= hash {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+
+	line, err := l.lineAt(low)
+	if err != nil {
+		return false, err
+	}
+
+	return line != "" && strings.EqualFold(lineHash(line), hash), nil
+
+}
+
+// lineAt returns the first line starting at or after the offset, or "" if there is none.
+func (l *BreachList) lineAt(offset int64) (string, error) {
+
+	// Starting one byte early finds a line starting right at the offset after the preceding newline
+	start := max(offset-1, 0)
+	buf := make([]byte, 2*maxLineLength)
+	n, err := l.file.ReadAt(buf, start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", autherrors.ErrReadBreachList(err)
+	}
+	buf = buf[:n]
+
+	if offset > 0 {
+		newline := bytes.IndexByte(buf, '\n')
+		if newline < 0 {
+			if start+int64(n) >= l.size {
+				return "", nil
+			}
+			return "", autherrors.ErrInvalidBreachList
+		}
+		buf = buf[newline+1:]
+	}
+
+	end := bytes.IndexByte(buf, '\n')
+	if end < 0 {
+		if len(buf) > maxLineLength {
+			return "", autherrors.ErrInvalidBreachList
+		}
+		end = len(buf)
+	}
+
+	return strings.TrimRight(string(buf[:end]), "\r"), nil
+
+}
+
+// lineHash returns the hash of a breach list line, i.e. everything before the count.
+func lineHash(line string) string {
+	hash, _, _ := strings.Cut(line, ":")
+	return hash
+}
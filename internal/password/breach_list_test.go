@@ -0,0 +1,151 @@
+package password
+
+import (
+	"crypto/sha1" //nolint:gosec // G505: the breached password list is keyed by SHA-1
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+type BreachListTestSuite struct {
+	suite.Suite
+	breached []string
+	hashes   []string
+}
+
+func (s *BreachListTestSuite) SetupSuite() {
+	s.breached = []string{"password", "123456", "letmein", "P@ssw0rd", "correct horse battery staple"}
+	for i := range 500 {
+		s.breached = append(s.breached, fmt.Sprintf("breached-%d", i))
+	}
+
+	for _, password := range s.breached {
+		sum := sha1.Sum([]byte(password)) //nolint:gosec // G401: the breached password list is keyed by SHA-1
+		s.hashes = append(s.hashes, strings.ToUpper(hex.EncodeToString(sum[:])))
+	}
+	slices.Sort(s.hashes)
+}
+
+// writeFile writes the hashes as a sorted breach list file with the given line ending.
+func (s *BreachListTestSuite) writeFile(lineEnding string) string {
+	var content strings.Builder
+	for i, hash := range s.hashes {
+		fmt.Fprintf(&content, "%s:%d%s", hash, i+1, lineEnding)
+	}
+
+	path := filepath.Join(s.T().TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	require.NoError(s.T(), os.WriteFile(path, []byte(content.String()), 0o600))
+	return path
+}
+
+// writeDirectory writes the hashes as a breach list directory of range files.
+func (s *BreachListTestSuite) writeDirectory() string {
+	ranges := make(map[string]*strings.Builder)
+	for i, hash := range s.hashes {
+		prefix := hash[:hashPrefixLength]
+		if ranges[prefix] == nil {
+			ranges[prefix] = &strings.Builder{}
+		}
+		fmt.Fprintf(ranges[prefix], "%s:%d\r\n", hash[hashPrefixLength:], i+1)
+	}
+
+	dir := s.T().TempDir()
+	for prefix, content := range ranges {
+		require.NoError(s.T(), os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte(content.String()), 0o600))
+	}
+	return dir
+}
+
+// assertContains checks that the list contains exactly the breached passwords.
+func (s *BreachListTestSuite) assertContains(list *BreachList) {
+	for _, password := range s.breached {
+		breached, err := list.Contains(password)
+		require.NoError(s.T(), err)
+		assert.True(s.T(), breached, password)
+	}
+
+	for _, password := range []string{"kG7#pq2Lm!x9", "breached-500", "Password"} {
+		breached, err := list.Contains(password)
+		require.NoError(s.T(), err)
+		assert.False(s.T(), breached, password)
+	}
+}
+
+func (s *BreachListTestSuite) TestContainsInFile() {
+	for _, lineEnding := range []string{"\n", "\r\n"} {
+		list, err := OpenBreachList(s.writeFile(lineEnding))
+		require.NoError(s.T(), err)
+
+		s.assertContains(list)
+		assert.NoError(s.T(), list.Close())
+	}
+}
+
+func (s *BreachListTestSuite) TestContainsInFileWithoutTrailingNewline() {
+	path := s.writeFile("\n")
+	content, err := os.ReadFile(path)
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), os.WriteFile(path, content[:len(content)-1], 0o600))
+
+	list, err := OpenBreachList(path)
+	require.NoError(s.T(), err)
+	defer func() {
+		_ = list.Close()
+	}()
+
+	s.assertContains(list)
+}
+
+func (s *BreachListTestSuite) TestContainsInDirectory() {
+	list, err := OpenBreachList(s.writeDirectory())
+	require.NoError(s.T(), err)
+
+	s.assertContains(list)
+	assert.NoError(s.T(), list.Close())
+}
+
+func (s *BreachListTestSuite) TestContainsInDirectoryMissingRange() {
+	list, err := OpenBreachList(s.T().TempDir())
+	require.NoError(s.T(), err)
+
+	breached, err := list.Contains("password")
+
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), breached)
+}
+
+func (s *BreachListTestSuite) TestContainsInMalformedFile() {
+	path := filepath.Join(s.T().TempDir(), "list.txt")
+	require.NoError(s.T(), os.WriteFile(path, []byte(strings.Repeat("A", 4*maxLineLength)+"\n"), 0o600))
+	list, err := OpenBreachList(path)
+	require.NoError(s.T(), err)
+	defer func() {
+		_ = list.Close()
+	}()
+
+	breached, err := list.Contains("password")
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidBreachList)
+	assert.False(s.T(), breached)
+}
+
+func (s *BreachListTestSuite) TestOpenMissingBreachList() {
+	list, err := OpenBreachList(filepath.Join(s.T().TempDir(), "missing.txt"))
+
+	assert.Error(s.T(), err)
+	assert.Nil(s.T(), list)
+}
+
+func TestBreachListTestSuite(t *testing.T) {
+	suite.Run(t, new(BreachListTestSuite))
+}
@@ -0,0 +1,189 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// Default password policy settings.
+const (
+	DefaultMinLength = 10
+	// DefaultMaxLength bounds the work of hashing and strength estimation for a single password.
+	DefaultMaxLength = 128
+	// DefaultMinCharacterClasses doesn't require any character classes, as long passwords without them can be strong.
+	DefaultMinCharacterClasses = 0
+	DefaultMinStrength         = 2
+)
+
+// Rules of the password policy reported in autherrors.PasswordViolation.
+const (
+	RuleMinLength        = "min_length"
+	RuleMaxLength        = "max_length"
+	RuleCharacterClasses = "character_classes"
+	RuleNotLogin         = "not_login"
+	RuleStrength         = "strength"
+	RuleBreached         = "breached"
+)
+
+// minLoginLength is the length from which passwords must not contain the login at all;
+// shorter logins must only not be the whole password.
+const minLoginLength = 4
+
+// IBreachList defines the lookup of passwords known from data breaches.
+type IBreachList interface {
+	Contains(password string) (bool, error)
+}
+
+// Policy checks new passwords against configurable rules.
+type Policy struct {
+	minLength           int
+	maxLength           int
+	minCharacterClasses int
+	minStrength         int
+	breachList          IBreachList
+}
+
+// PolicyOption is a function that modifies the Policy configuration.
+type PolicyOption func(*Policy)
+
+// WithMinLength sets the minimum number of characters of passwords.
+func WithMinLength(length int) PolicyOption {
+	return func(p *Policy) {
+		p.minLength = length
+	}
+}
+
+// WithMaxLength sets the maximum number of characters of passwords.
+func WithMaxLength(length int) PolicyOption {
+	return func(p *Policy) {
+		p.maxLength = length
+	}
+}
+
+// WithMinCharacterClasses requires passwords to mix lowercase letters, uppercase letters, digits and symbols:
+// at least the given number of these classes must occur.
+func WithMinCharacterClasses(classes int) PolicyOption {
+	return func(p *Policy) {
+		p.minCharacterClasses = classes
+	}
+}
+
+// WithMinStrength sets the minimum strength score (0-4) of passwords, see Strength.
+func WithMinStrength(score int) PolicyOption {
+	return func(p *Policy) {
+		p.minStrength = score
+	}
+}
+
+// WithBreachList rejects passwords contained in the list of breached passwords.
+func WithBreachList(list IBreachList) PolicyOption {
+	return func(p *Policy) {
+		p.breachList = list
+	}
+}
+
+// NewPolicy creates a new password policy with the default settings unless configured otherwise.
+// Breached passwords are only screened if a breach list is given.
+func NewPolicy(opts ...PolicyOption) *Policy {
+	p := &Policy{
+		minLength:           DefaultMinLength,
+		maxLength:           DefaultMaxLength,
+		minCharacterClasses: DefaultMinCharacterClasses,
+		minStrength:         DefaultMinStrength,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Check checks the password the user with the given login wants to use.
+// It returns an *autherrors.PasswordPolicyError listing every rule the password fails,
+// or another error if the breach list can't be read.
+func (p *Policy) Check(login string, password string) error {
+	var violations []autherrors.PasswordViolation
+	violate := func(rule string, format string, args ...any) {
+		violations = append(violations, autherrors.PasswordViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.minLength {
+		violate(RuleMinLength, "must be at least %d characters long", p.minLength)
+	}
+	if p.maxLength > 0 && length > p.maxLength {
+		violate(RuleMaxLength, "must be at most %d characters long", p.maxLength)
+		// Longer passwords are not estimated or looked up
+		return &autherrors.PasswordPolicyError{Violations: violations}
+	}
+
+	if classes := characterClasses(password); classes < p.minCharacterClasses {
+		violate(RuleCharacterClasses,
+			"must contain at least %d of lowercase letters, uppercase letters, digits and symbols", p.minCharacterClasses)
+	}
+
+	if containsLogin(password, login) {
+		violate(RuleNotLogin, "must not contain the login")
+	}
+
+	if password != "" && Strength(password, login) < p.minStrength {
+		violate(RuleStrength, "is too easy to guess")
+	}
+
+	if p.breachList != nil && password != "" {
+		breached, err := p.breachList.Contains(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violate(RuleBreached, "appeared in a data breach and must not be used")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &autherrors.PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// characterClasses counts which of lowercase letters, uppercase letters, digits and symbols occur in the password.
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	return classes
+}
+
+// containsLogin reports whether the password is the login or, for logins that aren't very short, contains it.
+// Case is ignored.
+func containsLogin(password string, login string) bool {
+	password = strings.ToLower(password)
+	login = strings.ToLower(login)
+	if login == "" {
+		return false
+	}
+	if utf8.RuneCountInString(login) < minLoginLength {
+		return password == login
+	}
+	return strings.Contains(password, login)
+}
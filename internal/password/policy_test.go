@@ -0,0 +1,154 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// stubBreachList contains the given passwords or fails with err.
+type stubBreachList struct {
+	passwords []string
+	err       error
+}
+
+func (l *stubBreachList) Contains(password string) (bool, error) {
+	if l.err != nil {
+		return false, l.err
+	}
+	for _, breached := range l.passwords {
+		if breached == password {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type PolicyTestSuite struct {
+	suite.Suite
+}
+
+// violatedRules returns the rules of the policy error the check returned.
+func (s *PolicyTestSuite) violatedRules(err error) []string {
+	var policyErr *autherrors.PasswordPolicyError
+	require.ErrorAs(s.T(), err, &policyErr)
+
+	rules := make([]string, 0, len(policyErr.Violations))
+	for _, violation := range policyErr.Violations {
+		assert.NotEmpty(s.T(), violation.Message)
+		rules = append(rules, violation.Rule)
+	}
+	return rules
+}
+
+func (s *PolicyTestSuite) TestCheckAcceptsStrongPassword() {
+	policy := NewPolicy(WithMinCharacterClasses(3), WithBreachList(&stubBreachList{passwords: []string{"password123"}}))
+
+	err := policy.Check("alice", "kG7#pq2Lm!x9")
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *PolicyTestSuite) TestCheckRejects() {
+	testCases := []struct {
+		name     string
+		policy   *Policy
+		login    string
+		password string
+		rules    []string
+	}{
+		{
+			name:     "too short",
+			policy:   NewPolicy(WithMinStrength(0)),
+			login:    "alice",
+			password: "kG7#pq2",
+			rules:    []string{RuleMinLength},
+		},
+		{
+			name:     "too long",
+			policy:   NewPolicy(WithMaxLength(16)),
+			login:    "alice",
+			password: strings.Repeat("kG7#pq2Lm!x9", 2),
+			rules:    []string{RuleMaxLength},
+		},
+		{
+			name:     "too few character classes",
+			policy:   NewPolicy(WithMinCharacterClasses(3)),
+			login:    "alice",
+			password: "correct horse battery staple",
+			rules:    []string{RuleCharacterClasses},
+		},
+		{
+			name:     "contains login",
+			policy:   NewPolicy(WithMinStrength(0)),
+			login:    "Alice",
+			password: "kG7#alice!x9",
+			rules:    []string{RuleNotLogin},
+		},
+		{
+			name:     "is short login",
+			policy:   NewPolicy(WithMinLength(3), WithMinStrength(0)),
+			login:    "bob",
+			password: "BOB",
+			rules:    []string{RuleNotLogin},
+		},
+		{
+			name:     "too easy to guess",
+			policy:   NewPolicy(),
+			login:    "alice",
+			password: "qwertyuiop123",
+			rules:    []string{RuleStrength},
+		},
+		{
+			name:     "breached",
+			policy:   NewPolicy(WithBreachList(&stubBreachList{passwords: []string{"kG7#pq2Lm!x9"}})),
+			login:    "alice",
+			password: "kG7#pq2Lm!x9",
+			rules:    []string{RuleBreached},
+		},
+		{
+			name: "every failed rule",
+			policy: NewPolicy(WithMinCharacterClasses(2),
+				WithBreachList(&stubBreachList{passwords: []string{"alice"}})),
+			login:    "alice",
+			password: "alice",
+			rules:    []string{RuleMinLength, RuleCharacterClasses, RuleNotLogin, RuleStrength, RuleBreached},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			err := tc.policy.Check(tc.login, tc.password)
+
+			assert.ErrorIs(s.T(), err, autherrors.ErrWeakPassword)
+			assert.Equal(s.T(), tc.rules, s.violatedRules(err))
+		})
+	}
+}
+
+func (s *PolicyTestSuite) TestCheckBreachListError() {
+	listErr := errors.New("disk failure")
+	policy := NewPolicy(WithBreachList(&stubBreachList{err: listErr}))
+
+	err := policy.Check("alice", "kG7#pq2Lm!x9")
+
+	assert.ErrorIs(s.T(), err, listErr)
+	assert.NotErrorIs(s.T(), err, autherrors.ErrWeakPassword)
+}
+
+func (s *PolicyTestSuite) TestPolicyErrorMessage() {
+	err := NewPolicy().Check("alice", "alice")
+
+	assert.EqualError(s.T(), err, "password does not meet the password policy: "+
+		"must be at least 10 characters long; must not contain the login; is too easy to guess")
+}
+
+func TestPolicyTestSuite(t *testing.T) {
+	suite.Run(t, new(PolicyTestSuite))
+}
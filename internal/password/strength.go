@@ -0,0 +1,314 @@
+package password
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// bruteforceCardinality is the number of guesses per character not covered by a pattern, as in zxcvbn.
+	bruteforceCardinality = 10
+	// minMatchGuesses keeps a pattern from being cheaper than a few characters guessed by brute force,
+	// so splitting a password into many tiny patterns doesn't make it look weak.
+	minMatchGuesses = 50
+	// minMatchLength is the minimum length of dictionary words, sequences, repeats and keyboard walks.
+	minMatchLength = 3
+	// keyboardStarts is the number of keys a keyboard walk can start at.
+	keyboardStarts = 47
+	// maxScoredLength bounds the work of estimating a single password; longer passwords are scored by their start,
+	// which is at most as guessable as the whole password.
+	maxScoredLength = 128
+	// maxRepeatDepth bounds how deeply repeated blocks are searched for repeats themselves, as in "abab abab".
+	maxRepeatDepth = 2
+)
+
+// scoreThresholds are the numbers of guesses separating the scores 0 to 4, as in zxcvbn:
+// from "too guessable" (below 10^3) to "very unguessable" (10^10 and above).
+var scoreThresholds = []float64{1e3 + 5, 1e6 + 5, 1e8 + 5, 1e10 + 5}
+
+// keyboardRows are the rows of a US keyboard; walks along a row are as guessable as sequences.
+var keyboardRows = []string{"1234567890-=", "qwertyuiop[]", "asdfghjkl;'", "zxcvbnm,./"}
+
+// leetSubstitutions maps characters commonly substituted for letters back to the letters.
+var leetSubstitutions = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '9': 'g', '1': 'i', '!': 'i', '|': 'l',
+	'0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+// match is a pattern covering the characters [start, end) of the password.
+type match struct {
+	start   int
+	end     int
+	guesses float64
+}
+
+// Strength estimates how hard the password is to guess and returns a score from 0 (too guessable)
+// to 4 (very unguessable), in the style of zxcvbn: the password is split into the most guessable sequence
+// of common passwords and words, the user's own inputs such as the login, keyboard walks, sequences,
+// repeats and brute-forced characters, and the guesses needed for each part are multiplied.
+func Strength(password string, userInputs ...string) int {
+	runes := []rune(password)
+	if len(runes) > maxScoredLength {
+		runes = runes[:maxScoredLength]
+	}
+	guesses := newEstimator(userDictionary(userInputs)).estimateGuesses(runes, 0)
+
+	for score, threshold := range scoreThresholds {
+		if guesses < threshold {
+			return score
+		}
+	}
+	return len(scoreThresholds)
+}
+
+// userDictionary ranks the user's inputs like the most common passwords.
+func userDictionary(userInputs []string) map[string]int {
+	dictionary := make(map[string]int, len(userInputs))
+	for _, input := range userInputs {
+		input = strings.ToLower(input)
+		if len([]rune(input)) >= minMatchLength {
+			dictionary[input] = 1
+		}
+	}
+	return dictionary
+}
+
+// estimator estimates the guesses for a password and the blocks repeated in it.
+type estimator struct {
+	userWords map[string]int
+	// blockGuesses memoizes the guesses of repeated blocks by their content: a block such as "a"
+	// in "aaaa...a" is found at every position and with every repeat count.
+	blockGuesses map[string]float64
+}
+
+// newEstimator creates an estimator matching the user's words as well as the common passwords.
+func newEstimator(userWords map[string]int) *estimator {
+	return &estimator{userWords: userWords, blockGuesses: make(map[string]float64)}
+}
+
+// estimateGuesses returns the guesses needed for the most guessable split of the password into patterns.
+// depth is the number of repeated blocks the password is nested in.
+func (e *estimator) estimateGuesses(password []rune, depth int) float64 {
+	n := len(password)
+	if n == 0 {
+		return 1
+	}
+
+	matchesByEnd := make([][]match, n+1)
+	for _, m := range e.findMatches(password, depth) {
+		matchesByEnd[m.end] = append(matchesByEnd[m.end], m)
+	}
+
+	// best[k] is the minimal number of guesses for the first k characters
+	best := make([]float64, n+1)
+	best[0] = 1
+	for end := 1; end <= n; end++ {
+		// Brute forcing a character extends the best split of the preceding ones
+		best[end] = best[end-1] * bruteforceCardinality
+		for _, m := range matchesByEnd[end] {
+			best[end] = math.Min(best[end], best[m.start]*m.guesses)
+		}
+	}
+
+	return best[n]
+}
+
+// findMatches returns all patterns found in the password.
+func (e *estimator) findMatches(password []rune, depth int) []match {
+	var matches []match
+	matches = append(matches, dictionaryMatches(password, e.userWords)...)
+	matches = append(matches, sequenceMatches(password)...)
+	if depth < maxRepeatDepth {
+		matches = append(matches, e.repeatMatches(password, depth)...)
+	}
+	matches = append(matches, keyboardMatches(password)...)
+	return matches
+}
+
+// dictionaryMatches finds common passwords and words and the user's inputs, also with leet substitutions.
+// The guesses are the word's rank, multiplied for capitalization and substitutions.
+func dictionaryMatches(password []rune, userWords map[string]int) []match {
+	lower := []rune(strings.ToLower(string(password)))
+	unleet := make([]rune, len(lower))
+	substituted := false
+	for i, r := range lower {
+		unleet[i] = r
+		if letter, ok := leetSubstitutions[r]; ok {
+			unleet[i] = letter
+			substituted = true
+		}
+	}
+
+	var matches []match
+	for start := 0; start < len(password); start++ {
+		for end := start + minMatchLength; end <= len(password); end++ {
+			rank, ok := wordRank(string(lower[start:end]), userWords)
+			leet := false
+			if !ok && substituted {
+				rank, ok = wordRank(string(unleet[start:end]), userWords)
+				leet = true
+			}
+			if !ok {
+				continue
+			}
+
+			guesses := float64(rank) * uppercaseVariations(password[start:end])
+			if leet {
+				guesses *= 2
+			}
+			matches = append(matches, match{start: start, end: end, guesses: math.Max(guesses, minMatchGuesses)})
+		}
+	}
+	return matches
+}
+
+// wordRank returns the rank of the word among the user's inputs and the common passwords.
+func wordRank(word string, userWords map[string]int) (int, bool) {
+	if rank, ok := userWords[word]; ok {
+		return rank, true
+	}
+	rank, ok := commonPasswordRanks[word]
+	return rank, ok
+}
+
+// uppercaseVariations is the number of ways the word could be capitalized as it is: capitalizing
+// the first or all letters is common and only doubles the guesses, other mixes are counted.
+func uppercaseVariations(word []rune) float64 {
+	upper, lower := 0, 0
+	for _, r := range word {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+
+	switch {
+	case upper == 0:
+		return 1
+	case lower == 0, upper == 1 && unicode.IsUpper(word[0]):
+		return 2
+	}
+
+	variations := 0.0
+	for i := 1; i <= min(upper, lower); i++ {
+		variations += binomial(upper+lower, i)
+	}
+	return variations
+}
+
+// sequenceMatches finds runs of consecutive characters such as "abcd" or "9876".
+func sequenceMatches(password []rune) []match {
+	var matches []match
+	for start := 0; start < len(password)-1; {
+		delta := password[start+1] - password[start]
+		end := start + 1
+		for end < len(password) && password[end]-password[end-1] == delta {
+			end++
+		}
+
+		if (delta == 1 || delta == -1) && end-start >= minMatchLength {
+			matches = append(matches, match{start: start, end: end, guesses: sequenceGuesses(password[start:end], delta)})
+		}
+		if end-start > 1 {
+			start = end - 1
+		} else {
+			start = end
+		}
+	}
+	return matches
+}
+
+// sequenceGuesses counts the possible starts of the sequence and its length and direction.
+func sequenceGuesses(sequence []rune, delta rune) float64 {
+	var starts float64
+	switch first := unicode.ToLower(sequence[0]); {
+	case strings.ContainsRune("az019", first):
+		starts = 4
+	case unicode.IsDigit(first):
+		starts = 10
+	default:
+		starts = 26
+	}
+	if delta < 0 {
+		starts *= 2
+	}
+	return math.Max(starts*float64(len(sequence)), minMatchGuesses)
+}
+
+// repeatMatches finds characters or blocks of characters repeated at least twice, such as "aaa" or "abcabc".
+// The guesses are the guesses for the block times the number of repeats.
+func (e *estimator) repeatMatches(password []rune, depth int) []match {
+	var matches []match
+	for start := range password {
+		for size := 1; start+2*size <= len(password); size++ {
+			block := string(password[start : start+size])
+			end := start + size
+			for end+size <= len(password) && string(password[end:end+size]) == block {
+				end += size
+			}
+
+			repeats := (end - start) / size
+			if repeats < 2 || end-start < minMatchLength {
+				continue
+			}
+			blockGuesses, ok := e.blockGuesses[block]
+			if !ok {
+				blockGuesses = e.estimateGuesses(password[start:start+size], depth+1)
+				e.blockGuesses[block] = blockGuesses
+			}
+			matches = append(matches, match{
+				start:   start,
+				end:     end,
+				guesses: math.Max(blockGuesses*float64(repeats), minMatchGuesses),
+			})
+		}
+	}
+	return matches
+}
+
+// keyboardMatches finds walks along a keyboard row such as "qwer" or "lkjh".
+func keyboardMatches(password []rune) []match {
+	lower := []rune(strings.ToLower(string(password)))
+
+	var matches []match
+	for _, row := range keyboardRows {
+		for _, keys := range []string{row, reverse(row)} {
+			for start := 0; start < len(lower); start++ {
+				key := strings.IndexRune(keys, lower[start])
+				if key < 0 {
+					continue
+				}
+				end := start + 1
+				for end < len(lower) && key+end-start < len(keys) && rune(keys[key+end-start]) == lower[end] {
+					end++
+				}
+				if end-start >= minMatchLength+1 {
+					guesses := float64(keyboardStarts*2*(end-start)) * uppercaseVariations(password[start:end])
+					matches = append(matches, match{start: start, end: end, guesses: guesses})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// reverse returns the string with its characters in reverse order.
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// binomial returns n choose k.
+func binomial(n, k int) float64 {
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
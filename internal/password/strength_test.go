@@ -0,0 +1,74 @@
+package password
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type StrengthTestSuite struct {
+	suite.Suite
+}
+
+func (s *StrengthTestSuite) TestStrength() {
+	testCases := []struct {
+		password string
+		score    int
+	}{
+		{password: "", score: 0},
+		{password: "password", score: 0},
+		{password: "P@ssw0rd", score: 0},
+		{password: "qwertyuiop", score: 0},
+		{password: "1234567890", score: 0},
+		{password: "abcdefghij", score: 0},
+		{password: "aaaaaaaaaaaa", score: 0},
+		{password: "abcabcabcabc", score: 0},
+		{password: "iloveyou123", score: 1},
+		{password: "alice2024", score: 1},
+		{password: "kG7#pq2Lm!x9", score: 4},
+		{password: "correct horse battery staple", score: 4},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.password, func() {
+			assert.Equal(s.T(), tc.score, Strength(tc.password, "alice"))
+		})
+	}
+}
+
+func (s *StrengthTestSuite) TestStrengthUserInputs() {
+	assert.Greater(s.T(), Strength("mallory1987"), Strength("mallory1987", "Mallory"),
+		"passwords made of the user's inputs should be weaker")
+}
+
+func (s *StrengthTestSuite) TestStrengthLongPassword() {
+	assert.Equal(s.T(), 4, Strength(strings.Repeat("kG7#pq2Lm!x9", 10)))
+}
+
+func (s *StrengthTestSuite) TestStrengthRepetitivePasswordIsFast() {
+	for _, password := range []string{strings.Repeat("a", 128), strings.Repeat("ab", 64), strings.Repeat("abcabd", 22)} {
+		started := time.Now()
+		Strength(password)
+
+		assert.Less(s.T(), time.Since(started), 100*time.Millisecond, "scoring %q", password)
+	}
+}
+
+func (s *StrengthTestSuite) TestStrengthScoresStartOfOverlongPassword() {
+	repetitive := strings.Repeat("a", maxScoredLength)
+	assert.Equal(s.T(), Strength(repetitive), Strength(repetitive+"kG7#pq2Lm!x9"))
+}
+
+func BenchmarkStrengthRepetitive(b *testing.B) {
+	password := strings.Repeat("a", 128)
+	for b.Loop() {
+		Strength(password)
+	}
+}
+
+func TestStrengthTestSuite(t *testing.T) {
+	suite.Run(t, new(StrengthTestSuite))
+}
@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// Result is the decision on a request.
+type Result struct {
+	Allowed bool
+	// Remaining is the number of further requests that would be allowed right now.
+	Remaining int
+	// RetryAfter is how long a rejected request would have to wait to be allowed.
+	RetryAfter time.Duration
+}
+
+// Algorithm decides whether requests are allowed, based on the state it keeps per key.
+type Algorithm interface {
+	// Take decides on a request made at now and updates the state of its key. Rejected requests are not counted.
+	Take(state *models.RateLimitState, now time.Time) Result
+	// Retention is how long the state of a key matters after a request; older state is as good as none.
+	Retention() time.Duration
+}
+
+// TokenBucket allows bursts of up to the rate's limit and then one request per period divided by the limit:
+// the bucket holds up to limit tokens, refills continuously at that pace and every request takes a token.
+type TokenBucket struct {
+	rate Rate
+}
+
+// NewTokenBucket creates a token bucket algorithm for the rate.
+func NewTokenBucket(rate Rate) *TokenBucket {
+	return &TokenBucket{rate: rate}
+}
+
+// Take refills the key's bucket for the time since its last request and takes a token if there is one.
+func (b *TokenBucket) Take(state *models.RateLimitState, now time.Time) Result {
+	capacity := float64(b.rate.Limit)
+	tokensPerSecond := capacity / b.rate.Period.Seconds()
+
+	tokens := capacity
+	if !state.UpdatedAt.IsZero() {
+		elapsed := max(now.Sub(state.UpdatedAt), 0)
+		tokens = math.Min(capacity, state.Value+elapsed.Seconds()*tokensPerSecond)
+	}
+	state.UpdatedAt = now
+
+	if tokens < 1 {
+		state.Value = tokens
+		return Result{RetryAfter: seconds((1 - tokens) / tokensPerSecond)}
+	}
+
+	state.Value = tokens - 1
+	return Result{Allowed: true, Remaining: int(state.Value)}
+}
+
+// Retention is the time an empty bucket takes to fill up.
+func (b *TokenBucket) Retention() time.Duration {
+	return b.rate.Period
+}
+
+// SlidingWindow allows up to the rate's limit of requests in any period. Like a sliding window counter, it counts
+// the requests of fixed windows of one period each and estimates the requests of the sliding window
+// from the current count and the previous count, weighted by how much of the previous window it still covers.
+// Unlike a fixed window, it doesn't allow twice the limit around the start of a window.
+type SlidingWindow struct {
+	rate Rate
+}
+
+// NewSlidingWindow creates a sliding window algorithm for the rate.
+func NewSlidingWindow(rate Rate) *SlidingWindow {
+	return &SlidingWindow{rate: rate}
+}
+
+// Take moves the key's windows forward to now and counts the request if the estimate stays within the limit.
+func (w *SlidingWindow) Take(state *models.RateLimitState, now time.Time) Result {
+	period := w.rate.Period
+	start := now.Truncate(period)
+
+	switch {
+	case state.UpdatedAt.Equal(start):
+	case state.UpdatedAt.Add(period).Equal(start):
+		state.PreviousValue, state.Value = state.Value, 0
+	default:
+		// A new key, or no requests in the previous window
+		state.PreviousValue, state.Value = 0, 0
+	}
+	state.UpdatedAt = start
+
+	limit := float64(w.rate.Limit)
+	elapsed := now.Sub(start)
+	count := state.PreviousValue*previousWeight(elapsed, period) + state.Value
+
+	if count+1 > limit {
+		return Result{RetryAfter: w.retryAfter(state, elapsed)}
+	}
+
+	state.Value++
+	return Result{Allowed: true, Remaining: int(limit - count - 1)}
+}
+
+// Retention is two periods: the current window and the previous one.
+func (w *SlidingWindow) Retention() time.Duration {
+	return 2 * w.rate.Period
+}
+
+// retryAfter returns how long it takes until the estimate leaves room for another request. If the current window
+// has room, the previous window's weight has to decrease enough; otherwise the current window has to become
+// the previous one and its weight decrease.
+func (w *SlidingWindow) retryAfter(state *models.RateLimitState, elapsed time.Duration) time.Duration {
+	period := w.rate.Period
+	room := float64(w.rate.Limit) - 1
+
+	if state.Value <= room {
+		weight := (room - state.Value) / state.PreviousValue
+		return elapsedAtWeight(weight, period) - elapsed
+	}
+
+	weight := room / state.Value
+	return period - elapsed + elapsedAtWeight(weight, period)
+}
+
+// previousWeight returns the share of the previous window covered by a sliding window ending elapsed into the current one.
+func previousWeight(elapsed time.Duration, period time.Duration) float64 {
+	return 1 - float64(elapsed)/float64(period)
+}
+
+// elapsedAtWeight returns how far into a window the previous window's weight drops to the given weight.
+func elapsedAtWeight(weight float64, period time.Duration) time.Duration {
+	return time.Duration(math.Round((1 - weight) * float64(period)))
+}
+
+// seconds converts seconds to a duration.
+func seconds(value float64) time.Duration {
+	return time.Duration(math.Round(value * float64(time.Second)))
+}
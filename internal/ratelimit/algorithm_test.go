@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+type AlgorithmTestSuite struct {
+	suite.Suite
+	start time.Time
+}
+
+func (s *AlgorithmTestSuite) SetupTest() {
+	// The start of a sliding window, so tests know where windows begin
+	s.start = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+}
+
+// take makes requests at the given offsets from the start and returns the decisions.
+func (s *AlgorithmTestSuite) take(algorithm Algorithm, state *models.RateLimitState, offsets ...time.Duration) []Result {
+	results := make([]Result, 0, len(offsets))
+	for _, offset := range offsets {
+		results = append(results, algorithm.Take(state, s.start.Add(offset)))
+	}
+	return results
+}
+
+func (s *AlgorithmTestSuite) TestTokenBucketAllowsBurst() {
+	bucket := NewTokenBucket(Rate{Limit: 3, Period: 3 * time.Second})
+	state := &models.RateLimitState{}
+
+	results := s.take(bucket, state, 0, 0, 0, 0)
+
+	for i, result := range results[:3] {
+		assert.True(s.T(), result.Allowed, "request %d", i)
+		assert.Equal(s.T(), 2-i, result.Remaining)
+	}
+	assert.False(s.T(), results[3].Allowed)
+	assert.Equal(s.T(), time.Second, results[3].RetryAfter)
+}
+
+func (s *AlgorithmTestSuite) TestTokenBucketRefills() {
+	bucket := NewTokenBucket(Rate{Limit: 2, Period: 2 * time.Second})
+	state := &models.RateLimitState{}
+
+	results := s.take(bucket, state, 0, 0, 500*time.Millisecond, time.Second, time.Second)
+
+	assert.False(s.T(), results[2].Allowed)
+	assert.Equal(s.T(), 500*time.Millisecond, results[2].RetryAfter)
+	assert.True(s.T(), results[3].Allowed, "a token refills after a second")
+	assert.False(s.T(), results[4].Allowed)
+}
+
+func (s *AlgorithmTestSuite) TestTokenBucketRefillsUpToLimit() {
+	bucket := NewTokenBucket(Rate{Limit: 2, Period: time.Second})
+	state := &models.RateLimitState{}
+
+	results := s.take(bucket, state, 0, time.Hour, time.Hour, time.Hour)
+
+	assert.True(s.T(), results[1].Allowed)
+	assert.True(s.T(), results[2].Allowed)
+	assert.False(s.T(), results[3].Allowed)
+}
+
+func (s *AlgorithmTestSuite) TestSlidingWindowLimitsWindow() {
+	window := NewSlidingWindow(Rate{Limit: 3, Period: time.Minute})
+	state := &models.RateLimitState{}
+
+	results := s.take(window, state, 0, 10*time.Second, 20*time.Second, 30*time.Second)
+
+	for i, result := range results[:3] {
+		assert.True(s.T(), result.Allowed, "request %d", i)
+		assert.Equal(s.T(), 2-i, result.Remaining)
+	}
+	assert.False(s.T(), results[3].Allowed)
+	// All requests count until the next window, where the previous one's weight drops to 2/3 after 20 seconds
+	assert.Equal(s.T(), 50*time.Second, results[3].RetryAfter)
+}
+
+func (s *AlgorithmTestSuite) TestSlidingWindowWeighsPreviousWindow() {
+	window := NewSlidingWindow(Rate{Limit: 4, Period: time.Minute})
+	state := &models.RateLimitState{}
+
+	// Four requests at the end of a window leave no room at the start of the next one
+	results := s.take(window, state, 50*time.Second, 50*time.Second, 50*time.Second, 50*time.Second,
+		time.Minute+5*time.Second, time.Minute+15*time.Second, time.Minute+16*time.Second)
+
+	assert.False(s.T(), results[4].Allowed, "unlike a fixed window, the limit isn't reset at the window start")
+	assert.Equal(s.T(), 10*time.Second, results[4].RetryAfter)
+	assert.True(s.T(), results[5].Allowed, "the previous window's weight dropped to 3/4")
+	assert.False(s.T(), results[6].Allowed)
+}
+
+func (s *AlgorithmTestSuite) TestSlidingWindowForgetsOldWindows() {
+	window := NewSlidingWindow(Rate{Limit: 1, Period: time.Minute})
+	state := &models.RateLimitState{}
+
+	results := s.take(window, state, 0, 2*time.Minute)
+
+	require.True(s.T(), results[0].Allowed)
+	assert.True(s.T(), results[1].Allowed)
+	assert.Zero(s.T(), state.PreviousValue)
+}
+
+func (s *AlgorithmTestSuite) TestRejectedRequestsAreNotCounted() {
+	for _, algorithm := range []Algorithm{
+		NewTokenBucket(Rate{Limit: 1, Period: time.Minute}),
+		NewSlidingWindow(Rate{Limit: 1, Period: time.Minute}),
+	} {
+		state := &models.RateLimitState{}
+
+		results := s.take(algorithm, state, 0, time.Second, time.Second, 2*time.Minute)
+
+		assert.False(s.T(), results[1].Allowed)
+		assert.Equal(s.T(), results[1].RetryAfter, results[2].RetryAfter)
+		assert.True(s.T(), results[3].Allowed)
+	}
+}
+
+func TestAlgorithmTestSuite(t *testing.T) {
+	suite.Run(t, new(AlgorithmTestSuite))
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// Names of the algorithms, as configured with RATE_LIMIT_ALGORITHM.
+const (
+	AlgorithmTokenBucket   = "token_bucket"
+	AlgorithmSlidingWindow = "sliding_window"
+)
+
+// Names of the stores, as configured with RATE_LIMIT_STORE.
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+// purgeInterval is the minimum time between two purges of expired state from the store.
+const purgeInterval = time.Minute
+
+// Limiter rate limits requests per key with an algorithm, keeping the state of the keys in a store.
+type Limiter struct {
+	name      string
+	algorithm Algorithm
+	store     Store
+
+	mu        sync.Mutex
+	lastPurge time.Time
+}
+
+// NewLimiter creates a limiter. The name prefixes the keys in the store, so that limiters can share a store.
+func NewLimiter(name string, algorithm Algorithm, store Store) *Limiter {
+	return &Limiter{
+		name:      name,
+		algorithm: algorithm,
+		store:     store,
+	}
+}
+
+// NewAlgorithm creates the algorithm with the given name for the rate; unknown names fall back to the token bucket.
+func NewAlgorithm(name string, rate Rate) Algorithm {
+	if name == AlgorithmSlidingWindow {
+		return NewSlidingWindow(rate)
+	}
+	return NewTokenBucket(rate)
+}
+
+// Allow decides on a request for the key and counts it if it is allowed.
+func (l *Limiter) Allow(key string) (Result, error) {
+
+	now := time.Now()
+	var result Result
+
+	err := l.store.Update(l.name+":"+key, func(state *models.RateLimitState) {
+		result = l.algorithm.Take(state, now)
+		state.ExpiresAt = now.Add(l.algorithm.Retention())
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	l.purgeExpired(now)
+
+	return result, nil
+
+}
+
+// purgeExpired drops expired state from the store at most once per purgeInterval.
+// Expired state is only purged opportunistically; failing to do so doesn't affect the request.
+func (l *Limiter) purgeExpired(now time.Time) {
+
+	l.mu.Lock()
+	if now.Sub(l.lastPurge) < purgeInterval {
+		l.mu.Unlock()
+		return
+	}
+	l.lastPurge = now
+	l.mu.Unlock()
+
+	if err := l.store.DeleteExpired(now); err != nil {
+		log.Printf("failed to purge rate limits: %v", err)
+	}
+
+}
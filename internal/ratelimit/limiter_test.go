@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// failingStore fails every update with err.
+type failingStore struct {
+	err error
+}
+
+func (s *failingStore) Update(string, func(state *models.RateLimitState)) error {
+	return s.err
+}
+
+func (s *failingStore) DeleteExpired(time.Time) error {
+	return s.err
+}
+
+type LimiterTestSuite struct {
+	suite.Suite
+}
+
+func (s *LimiterTestSuite) TestParseRate() {
+	testCases := []struct {
+		value string
+		rate  Rate
+	}{
+		{"10/1m", Rate{Limit: 10, Period: time.Minute}},
+		{" 5 / 30s ", Rate{Limit: 5, Period: 30 * time.Second}},
+		{"5/1", Rate{Limit: 5, Period: time.Second}},
+		{"0/1m", Rate{}},
+		{"0", Rate{}},
+		{"", Rate{}},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.value, func() {
+			rate, err := ParseRate(tc.value)
+
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), tc.rate, rate)
+		})
+	}
+}
+
+func (s *LimiterTestSuite) TestParseRateInvalid() {
+	for _, value := range []string{"10", "ten/1m", "-1/1m", "10/", "10/0s", "10/-1m", "10/minute"} {
+		s.Run(value, func() {
+			_, err := ParseRate(value)
+
+			assert.ErrorIs(s.T(), err, autherrors.ErrInvalidRate)
+		})
+	}
+}
+
+func (s *LimiterTestSuite) TestAllowLimitsPerKey() {
+	limiter := NewLimiter("login", NewTokenBucket(Rate{Limit: 2, Period: time.Hour}), NewMemoryStore())
+
+	for i := range 2 {
+		result, err := limiter.Allow("alice")
+		require.NoError(s.T(), err)
+		assert.True(s.T(), result.Allowed, "request %d", i)
+	}
+
+	result, err := limiter.Allow("alice")
+	require.NoError(s.T(), err)
+	assert.False(s.T(), result.Allowed)
+	assert.Greater(s.T(), result.RetryAfter, time.Duration(0))
+
+	result, err = limiter.Allow("bob")
+	require.NoError(s.T(), err)
+	assert.True(s.T(), result.Allowed, "keys are limited separately")
+}
+
+func (s *LimiterTestSuite) TestLimitersShareStore() {
+	store := NewMemoryStore()
+	ipLimiter := NewLimiter("ip", NewSlidingWindow(Rate{Limit: 1, Period: time.Hour}), store)
+	loginLimiter := NewLimiter("login", NewSlidingWindow(Rate{Limit: 1, Period: time.Hour}), store)
+
+	result, err := ipLimiter.Allow("alice")
+	require.NoError(s.T(), err)
+	require.True(s.T(), result.Allowed)
+
+	result, err = loginLimiter.Allow("alice")
+	require.NoError(s.T(), err)
+	assert.True(s.T(), result.Allowed, "limiter names keep keys apart")
+}
+
+func (s *LimiterTestSuite) TestAllowCountsConcurrentRequests() {
+	limiter := NewLimiter("ip", NewTokenBucket(Rate{Limit: 10, Period: time.Hour}), NewMemoryStore())
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := limiter.Allow("203.0.113.7")
+			assert.NoError(s.T(), err)
+			if result.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(s.T(), 10, allowed)
+}
+
+func (s *LimiterTestSuite) TestAllowStoreError() {
+	storeErr := errors.New("connection refused")
+	limiter := NewLimiter("ip", NewTokenBucket(Rate{Limit: 1, Period: time.Minute}), &failingStore{err: storeErr})
+
+	_, err := limiter.Allow("203.0.113.7")
+
+	assert.ErrorIs(s.T(), err, storeErr)
+}
+
+func (s *LimiterTestSuite) TestMemoryStoreDeletesExpired() {
+	store := NewMemoryStore()
+	now := time.Now()
+	require.NoError(s.T(), store.Update("stale", func(state *models.RateLimitState) {
+		state.ExpiresAt = now.Add(-time.Second)
+	}))
+	require.NoError(s.T(), store.Update("active", func(state *models.RateLimitState) {
+		state.ExpiresAt = now.Add(time.Minute)
+	}))
+
+	require.NoError(s.T(), store.DeleteExpired(now))
+
+	assert.NotContains(s.T(), store.states, "stale")
+	assert.Contains(s.T(), store.states, "active")
+}
+
+func TestLimiterTestSuite(t *testing.T) {
+	suite.Run(t, new(LimiterTestSuite))
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// Rate is the number of requests allowed per period.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseRate parses a rate written as "<requests>/<period>", e.g. "10/1m". A missing period unit means seconds,
+// so "5/1" and "5/1s" are the same. An empty value, "0" or a limit of 0 is the zero Rate, which disables a limit.
+func ParseRate(value string) (Rate, error) {
+
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return Rate{}, nil
+	}
+
+	limitValue, periodValue, found := strings.Cut(value, "/")
+	if !found {
+		return Rate{}, autherrors.ErrInvalidRate
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(limitValue))
+	if err != nil || limit < 0 {
+		return Rate{}, autherrors.ErrInvalidRate
+	}
+
+	periodValue = strings.TrimSpace(periodValue)
+	if _, err := strconv.Atoi(periodValue); err == nil {
+		periodValue += "s"
+	}
+	period, err := time.ParseDuration(periodValue)
+	if err != nil || period <= 0 {
+		return Rate{}, autherrors.ErrInvalidRate
+	}
+
+	if limit == 0 {
+		return Rate{}, nil
+	}
+
+	return Rate{Limit: limit, Period: period}, nil
+
+}
+
+// IsZero reports whether the rate allows any number of requests.
+func (r Rate) IsZero() bool {
+	return r.Limit <= 0 || r.Period <= 0
+}
+
+// String formats the rate as accepted by ParseRate.
+func (r Rate) String() string {
+	return strconv.Itoa(r.Limit) + "/" + r.Period.String()
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// Store keeps the state of rate limited keys. MemoryStore serves a single instance of the service,
+// repositories.RateLimitRepository shares the state of all replicas through PostgreSQL.
+type Store interface {
+	// Update passes the state of the key, or a zero state if the key has none, to update and saves the result.
+	// Updates of the same key must not overlap, so that concurrent requests are all counted.
+	Update(key string, update func(state *models.RateLimitState)) error
+	// DeleteExpired drops state that expired before the given time.
+	DeleteExpired(before time.Time) error
+}
+
+// MemoryStore keeps the state of rate limited keys in memory.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]*models.RateLimitState
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]*models.RateLimitState)}
+}
+
+// Update updates the state of the key while holding the store's lock.
+func (s *MemoryStore) Update(key string, update func(state *models.RateLimitState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[key]
+	if !ok {
+		state = &models.RateLimitState{Key: key}
+		s.states[key] = state
+	}
+	update(state)
+
+	return nil
+}
+
+// DeleteExpired drops state that expired before the given time.
+func (s *MemoryStore) DeleteExpired(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, state := range s.states {
+		if state.ExpiresAt.Before(before) {
+			delete(s.states, key)
+		}
+	}
+
+	return nil
+}
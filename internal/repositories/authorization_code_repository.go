@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// AuthorizationCodeRepository handles persistence of OAuth authorization codes.
+type AuthorizationCodeRepository struct {
+	db *sql.DB
+}
+
+// NewAuthorizationCodeRepository creates a new authorization code repository instance.
+func NewAuthorizationCodeRepository(db *sql.DB) *AuthorizationCodeRepository {
+	return &AuthorizationCodeRepository{db: db}
+}
+
+// SaveCode stores the hashed authorization code and fills in its creation time.
+func (r *AuthorizationCodeRepository) SaveCode(code *models.AuthorizationCode) error {
+
+	query := `INSERT INTO authorization_codes
+	(code_hash, client_id, user_id, redirect_uri, code_challenge, code_challenge_method, expires_at, scopes, nonce)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8, '{}'::TEXT[]), $9)
+	RETURNING created_at`
+
+	err := r.db.QueryRow(query,
+		code.CodeHash, code.ClientID, code.UserID, code.RedirectURI,
+		code.CodeChallenge, code.CodeChallengeMethod, code.ExpiresAt,
+		pq.Array(code.Scopes), code.Nonce,
+	).Scan(&code.CreatedAt)
+	if err != nil {
+		return autherrors.ErrSaveAuthorizationCode(err)
+	}
+
+	return nil
+
+}
+
+// ConsumeCode marks the code with the given hash issued to the client as used and returns it.
+// The update is atomic, so a code can be consumed only once even by concurrent requests;
+// an unknown or already used code, or one issued to another client, returns autherrors.ErrCodeNotFound.
+func (r *AuthorizationCodeRepository) ConsumeCode(codeHash string, clientID string) (*models.AuthorizationCode, error) {
+
+	var code models.AuthorizationCode
+	query := `UPDATE authorization_codes SET used_at = CURRENT_TIMESTAMP
+	WHERE code_hash = $1 AND client_id = $2 AND used_at IS NULL
+	RETURNING code_hash, client_id, user_id, redirect_uri, code_challenge, code_challenge_method,
+		expires_at, used_at, created_at, scopes, nonce`
+
+	err := r.db.QueryRow(query, codeHash, clientID).Scan(
+		&code.CodeHash, &code.ClientID, &code.UserID, &code.RedirectURI,
+		&code.CodeChallenge, &code.CodeChallengeMethod,
+		&code.ExpiresAt, &code.UsedAt, &code.CreatedAt, pq.Array(&code.Scopes), &code.Nonce)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, autherrors.ErrCodeNotFound
+	}
+	if err != nil {
+		return nil, autherrors.ErrConsumeAuthorizationCode(err)
+	}
+
+	return &code, nil
+
+}
+
+// FindUsedCode returns the already used code with the given hash, including the token family
+// started with it. Returns autherrors.ErrCodeNotFound if there is no used code with this hash.
+func (r *AuthorizationCodeRepository) FindUsedCode(codeHash string) (*models.AuthorizationCode, error) {
+
+	code := models.AuthorizationCode{CodeHash: codeHash}
+	query := `SELECT client_id, user_id, used_at, token_family_id
+	FROM authorization_codes
+	WHERE code_hash = $1 AND used_at IS NOT NULL`
+
+	err := r.db.QueryRow(query, codeHash).Scan(&code.ClientID, &code.UserID, &code.UsedAt, &code.TokenFamilyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, autherrors.ErrCodeNotFound
+	}
+	if err != nil {
+		return nil, autherrors.ErrFindAuthorizationCode(err)
+	}
+
+	return &code, nil
+
+}
+
+// DeleteExpiredCodes removes codes that can no longer be exchanged.
+func (r *AuthorizationCodeRepository) DeleteExpiredCodes() error {
+
+	_, err := r.db.Exec(`DELETE FROM authorization_codes WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return autherrors.ErrDeleteAuthorizationCodes(err)
+	}
+
+	return nil
+
+}
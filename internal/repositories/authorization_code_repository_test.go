@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+type AuthorizationCodeRepositoryTestSuite struct {
+	RepositoryTestSuite
+	TestUser   *models.User
+	TestClient *models.Client
+}
+
+func (s *AuthorizationCodeRepositoryTestSuite) SetupTest() {
+	user, err := s.UserRepo.CreateUser(s.TestLogin, s.TestPassword)
+	require.NoError(s.T(), err)
+	s.TestUser = user
+
+	s.TestClient = &models.Client{
+		ID:           "planner-spa",
+		Name:         "Breakfront Planner",
+		RedirectURIs: []string{"https://planner.example.com/callback"},
+	}
+	require.NoError(s.T(), s.ClientRepo.CreateClient(s.TestClient))
+}
+
+func (s *AuthorizationCodeRepositoryTestSuite) newCode(codeHash string, expiresAt time.Time) *models.AuthorizationCode {
+	code := &models.AuthorizationCode{
+		CodeHash:            codeHash,
+		ClientID:            s.TestClient.ID,
+		UserID:              s.TestUser.ID,
+		RedirectURI:         s.TestClient.RedirectURIs[0],
+		CodeChallenge:       "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		CodeChallengeMethod: "S256",
+		ExpiresAt:           expiresAt,
+		Scopes:              []string{"openid"},
+		Nonce:               "n-0S6_WzA2Mj",
+	}
+	require.NoError(s.T(), s.CodeRepo.SaveCode(code))
+	return code
+}
+
+func (s *AuthorizationCodeRepositoryTestSuite) TestConsumeCodeOnce() {
+	saved := s.newCode(s.TokenHashedValue, time.Now().UTC().Add(time.Minute))
+	assert.NotZero(s.T(), saved.CreatedAt)
+
+	_, err := s.CodeRepo.ConsumeCode(saved.CodeHash, "other-client")
+	assert.ErrorIs(s.T(), err, autherrors.ErrCodeNotFound, "codes are only consumed by their client")
+
+	code, err := s.CodeRepo.ConsumeCode(saved.CodeHash, s.TestClient.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), saved.UserID, code.UserID)
+	assert.Equal(s.T(), saved.ClientID, code.ClientID)
+	assert.Equal(s.T(), saved.CodeChallenge, code.CodeChallenge)
+	assert.Equal(s.T(), []string{"openid"}, code.Scopes)
+	assert.Equal(s.T(), "n-0S6_WzA2Mj", code.Nonce)
+	assert.NotNil(s.T(), code.UsedAt)
+
+	_, err = s.CodeRepo.ConsumeCode(saved.CodeHash, s.TestClient.ID)
+	assert.ErrorIs(s.T(), err, autherrors.ErrCodeNotFound)
+}
+
+func (s *AuthorizationCodeRepositoryTestSuite) TestFindUsedCodeWithTokenFamily() {
+	saved := s.newCode(s.TokenHashedValue, time.Now().UTC().Add(time.Minute))
+
+	_, err := s.CodeRepo.FindUsedCode(saved.CodeHash)
+	assert.ErrorIs(s.T(), err, autherrors.ErrCodeNotFound, "unused codes are not found")
+
+	_, err = s.CodeRepo.ConsumeCode(saved.CodeHash, s.TestClient.ID)
+	require.NoError(s.T(), err)
+	familyID := uuid.New()
+	token := &models.Token{HashedValue: "code-session-token-hash", UserID: s.TestUser.ID, FamilyID: familyID,
+		ExpiresAt: time.Now().UTC().Add(time.Hour)}
+	require.NoError(s.T(), s.TokenRepo.SaveTokenForCode(token, saved.CodeHash))
+
+	code, err := s.CodeRepo.FindUsedCode(saved.CodeHash)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.TestUser.ID, code.UserID)
+	require.NotNil(s.T(), code.TokenFamilyID)
+	assert.Equal(s.T(), familyID, *code.TokenFamilyID)
+}
+
+func (s *AuthorizationCodeRepositoryTestSuite) TestConcurrentConsume() {
+	saved := s.newCode(s.TokenHashedValue, time.Now().UTC().Add(time.Minute))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	consumed := 0
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.CodeRepo.ConsumeCode(saved.CodeHash, s.TestClient.ID); err == nil {
+				mu.Lock()
+				consumed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(s.T(), 1, consumed, "a code must be exchangeable only once")
+}
+
+func (s *AuthorizationCodeRepositoryTestSuite) TestDeleteExpiredCodes() {
+	s.newCode("expired-code-hash", time.Now().UTC().Add(-time.Minute))
+	s.newCode("live-code-hash", time.Now().UTC().Add(time.Minute))
+
+	err := s.CodeRepo.DeleteExpiredCodes()
+	require.NoError(s.T(), err)
+
+	var count int
+	err = s.DB.QueryRow(`SELECT count(*) FROM authorization_codes`).Scan(&count)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, count)
+}
+
+func TestAuthorizationCodeRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthorizationCodeRepositoryTestSuite))
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// ClientRepository handles persistence of registered OAuth clients.
+type ClientRepository struct {
+	db *sql.DB
+}
+
+// NewClientRepository creates a new client repository instance.
+func NewClientRepository(db *sql.DB) *ClientRepository {
+	return &ClientRepository{db: db}
+}
+
+// CreateClient registers the client and fills in its creation time.
+func (r *ClientRepository) CreateClient(client *models.Client) error {
+
+	query := `INSERT INTO oauth_clients (id, name, secret_hash, redirect_uris, scopes)
+	VALUES ($1, $2, $3, COALESCE($4, '{}'::TEXT[]), COALESCE($5, '{}'::TEXT[]))
+	RETURNING created_at`
+
+	err := r.db.QueryRow(query, client.ID, client.Name, client.SecretHash,
+		pq.Array(client.RedirectURIs), pq.Array(client.Scopes)).Scan(&client.CreatedAt)
+	if err != nil {
+		return autherrors.ErrSaveClient(err)
+	}
+
+	return nil
+
+}
+
+// FindClient returns the client with the given ID, or nil if no such client is registered.
+func (r *ClientRepository) FindClient(clientID string) (*models.Client, error) {
+
+	var client models.Client
+	query := `SELECT id, name, secret_hash, redirect_uris, scopes, created_at FROM oauth_clients WHERE id = $1`
+
+	err := r.db.QueryRow(query, clientID).Scan(&client.ID, &client.Name, &client.SecretHash,
+		pq.Array(&client.RedirectURIs), pq.Array(&client.Scopes), &client.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, autherrors.ErrFindClient(err)
+	}
+
+	return &client, nil
+
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+type ClientRepositoryTestSuite struct {
+	RepositoryTestSuite
+}
+
+func (s *ClientRepositoryTestSuite) TestCreateAndFindClient() {
+	client := models.Client{
+		ID:           "planner-spa",
+		Name:         "Breakfront Planner",
+		RedirectURIs: []string{"https://planner.example.com/callback", "http://localhost:3000/callback"},
+	}
+
+	err := s.ClientRepo.CreateClient(&client)
+	require.NoError(s.T(), err)
+	assert.NotZero(s.T(), client.CreatedAt)
+
+	found, err := s.ClientRepo.FindClient(client.ID)
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), found)
+	assert.Equal(s.T(), client.Name, found.Name)
+	assert.Equal(s.T(), client.RedirectURIs, found.RedirectURIs)
+	assert.False(s.T(), found.IsConfidential())
+
+	err = s.ClientRepo.CreateClient(&client)
+	assert.Error(s.T(), err, "client IDs must be unique")
+}
+
+func (s *ClientRepositoryTestSuite) TestServiceAccountScopes() {
+	client := models.Client{
+		ID:         "nightly-export",
+		Name:       "Nightly export",
+		SecretHash: s.TokenHashedValue,
+		Scopes:     []string{"plans:read", "users:read"},
+	}
+	require.NoError(s.T(), s.ClientRepo.CreateClient(&client))
+
+	found, err := s.ClientRepo.FindClient(client.ID)
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), found)
+	assert.True(s.T(), found.IsConfidential())
+	assert.Equal(s.T(), client.Scopes, found.Scopes)
+	assert.Empty(s.T(), found.RedirectURIs)
+}
+
+func (s *ClientRepositoryTestSuite) TestFindUnknownClient() {
+	client, err := s.ClientRepo.FindClient("unknown-app")
+
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), client)
+}
+
+func TestClientRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(ClientRepositoryTestSuite))
+}
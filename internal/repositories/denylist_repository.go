@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// DenylistRepository handles persistence of revoked access tokens.
+type DenylistRepository struct {
+	db *sql.DB
+}
+
+// NewDenylistRepository creates a new denylist repository instance.
+func NewDenylistRepository(db *sql.DB) *DenylistRepository {
+	return &DenylistRepository{db: db}
+}
+
+// SaveEntry adds an access token to the denylist and fills in its revocation time.
+// Revoking an already denylisted token is not an error.
+// Client tokens have no user and are stored without one.
+func (r *DenylistRepository) SaveEntry(entry *models.DenylistEntry) error {
+
+	var userID any
+	if entry.UserID != uuid.Nil {
+		userID = entry.UserID
+	}
+
+	query := `INSERT INTO access_token_denylist (jti, user_id, expires_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (jti) DO UPDATE SET jti = EXCLUDED.jti
+	RETURNING revoked_at`
+
+	err := r.db.QueryRow(query, entry.JTI, userID, entry.ExpiresAt).Scan(&entry.RevokedAt)
+	if err != nil {
+		return autherrors.ErrSaveDenylistEntry(err)
+	}
+
+	return nil
+
+}
+
+// IsDenylisted reports whether the access token with the given jti is revoked and not yet expired.
+func (r *DenylistRepository) IsDenylisted(jti string) (bool, error) {
+
+	var denylisted bool
+	err := r.db.QueryRow(`SELECT EXISTS (
+		SELECT 1 FROM access_token_denylist WHERE jti = $1 AND expires_at > CURRENT_TIMESTAMP
+	)`, jti).Scan(&denylisted)
+	if err != nil {
+		return false, autherrors.ErrCheckDenylist(err)
+	}
+
+	return denylisted, nil
+
+}
+
+// DeleteExpiredEntries removes entries whose tokens have expired and no longer need to be denied.
+func (r *DenylistRepository) DeleteExpiredEntries() error {
+
+	_, err := r.db.Exec(`DELETE FROM access_token_denylist WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return autherrors.ErrDeleteDenylistEntries(err)
+	}
+
+	return nil
+
+}
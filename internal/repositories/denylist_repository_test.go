@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+type DenylistRepositoryTestSuite struct {
+	RepositoryTestSuite
+	TestUser *models.User
+}
+
+func (s *DenylistRepositoryTestSuite) SetupSuite() {
+	s.RepositoryTestSuite.SetupSuite()
+
+	user, err := s.UserRepo.CreateUser(s.TestLogin, s.TestPassword)
+	require.NoError(s.T(), err)
+	s.TestUser = user
+}
+
+func (s *DenylistRepositoryTestSuite) TestSaveEntryAndCheck() {
+	entry := models.DenylistEntry{
+		JTI:       uuid.NewString(),
+		UserID:    s.TestUser.ID,
+		ExpiresAt: time.Now().UTC().Add(10 * time.Minute),
+	}
+
+	denylisted, err := s.DenylistRepo.IsDenylisted(entry.JTI)
+	require.NoError(s.T(), err)
+	assert.False(s.T(), denylisted)
+
+	err = s.DenylistRepo.SaveEntry(&entry)
+	require.NoError(s.T(), err)
+	assert.NotZero(s.T(), entry.RevokedAt)
+
+	denylisted, err = s.DenylistRepo.IsDenylisted(entry.JTI)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), denylisted)
+
+	err = s.DenylistRepo.SaveEntry(&entry)
+	assert.NoError(s.T(), err, "revoking a token twice should succeed")
+}
+
+func (s *DenylistRepositoryTestSuite) TestSaveClientTokenEntry() {
+	entry := models.DenylistEntry{
+		JTI:       uuid.NewString(),
+		ExpiresAt: time.Now().UTC().Add(10 * time.Minute),
+	}
+
+	err := s.DenylistRepo.SaveEntry(&entry)
+	require.NoError(s.T(), err, "client tokens have no user")
+
+	denylisted, err := s.DenylistRepo.IsDenylisted(entry.JTI)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), denylisted)
+}
+
+func (s *DenylistRepositoryTestSuite) TestExpiredEntries() {
+	expired := models.DenylistEntry{
+		JTI:       uuid.NewString(),
+		UserID:    s.TestUser.ID,
+		ExpiresAt: time.Now().UTC().Add(-time.Minute),
+	}
+	live := models.DenylistEntry{
+		JTI:       uuid.NewString(),
+		UserID:    s.TestUser.ID,
+		ExpiresAt: time.Now().UTC().Add(10 * time.Minute),
+	}
+	require.NoError(s.T(), s.DenylistRepo.SaveEntry(&expired))
+	require.NoError(s.T(), s.DenylistRepo.SaveEntry(&live))
+
+	denylisted, err := s.DenylistRepo.IsDenylisted(expired.JTI)
+	require.NoError(s.T(), err)
+	assert.False(s.T(), denylisted, "expired entries should no longer be reported")
+
+	err = s.DenylistRepo.DeleteExpiredEntries()
+	require.NoError(s.T(), err)
+
+	var count int
+	err = s.DB.QueryRow(`SELECT count(*) FROM access_token_denylist`).Scan(&count)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, count)
+}
+
+func (s *DenylistRepositoryTestSuite) TearDownTest() {
+	_, err := s.DB.Exec("DELETE FROM access_token_denylist")
+	require.NoError(s.T(), err, "Failed to cleanup access_token_denylist")
+}
+
+func (s *DenylistRepositoryTestSuite) TearDownSuite() {
+	_, err := s.DB.Exec("DELETE FROM users")
+	require.NoError(s.T(), err, "Failed to cleanup users")
+
+	s.RepositoryTestSuite.TearDownSuite()
+}
+
+func TestDenylistRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(DenylistRepositoryTestSuite))
+}
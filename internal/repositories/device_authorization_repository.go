@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// DeviceAuthorizationRepository handles persistence of OAuth device authorization requests.
+type DeviceAuthorizationRepository struct {
+	db *sql.DB
+}
+
+// NewDeviceAuthorizationRepository creates a new device authorization repository instance.
+func NewDeviceAuthorizationRepository(db *sql.DB) *DeviceAuthorizationRepository {
+	return &DeviceAuthorizationRepository{db: db}
+}
+
+// SaveDeviceAuthorization stores the pending request with the hashes of its codes and fills in its creation time.
+// Returns autherrors.ErrUserCodeTaken if another stored request has the same user code.
+func (r *DeviceAuthorizationRepository) SaveDeviceAuthorization(auth *models.DeviceAuthorization) error {
+
+	query := `INSERT INTO device_authorizations
+	(device_code_hash, user_code_hash, client_id, scopes, status, poll_interval_seconds, expires_at)
+	VALUES ($1, $2, $3, COALESCE($4, '{}'::TEXT[]), $5, $6, $7)
+	ON CONFLICT (user_code_hash) DO NOTHING
+	RETURNING created_at`
+
+	err := r.db.QueryRow(query,
+		auth.DeviceCodeHash, auth.UserCodeHash, auth.ClientID, pq.Array(auth.Scopes),
+		auth.Status, int(auth.PollInterval/time.Second), auth.ExpiresAt,
+	).Scan(&auth.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return autherrors.ErrUserCodeTaken
+	}
+	if err != nil {
+		return autherrors.ErrSaveDeviceAuthorization(err)
+	}
+
+	return nil
+
+}
+
+// FindByUserCode returns the request with the given user code hash,
+// or autherrors.ErrDeviceCodeNotFound if there is none.
+func (r *DeviceAuthorizationRepository) FindByUserCode(userCodeHash string) (*models.DeviceAuthorization, error) {
+
+	query := `SELECT ` + deviceAuthorizationColumns + `
+	FROM device_authorizations
+	WHERE user_code_hash = $1`
+
+	auth, err := scanDeviceAuthorization(r.db.QueryRow(query, userCodeHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, autherrors.ErrDeviceCodeNotFound
+	}
+	if err != nil {
+		return nil, autherrors.ErrFindDeviceAuthorization(err)
+	}
+
+	return auth, nil
+
+}
+
+// DecideDeviceAuthorization records the user's approval or denial of a pending, unexpired request.
+// A request can be decided only once; otherwise autherrors.ErrDeviceCodeNotFound is returned.
+func (r *DeviceAuthorizationRepository) DecideDeviceAuthorization(userCodeHash string, userID uuid.UUID,
+	status models.DeviceAuthorizationStatus) (*models.DeviceAuthorization, error) {
+
+	query := `UPDATE device_authorizations SET status = $3, user_id = $2
+	WHERE user_code_hash = $1 AND status = 'pending' AND expires_at > CURRENT_TIMESTAMP
+	RETURNING ` + deviceAuthorizationColumns
+
+	auth, err := scanDeviceAuthorization(r.db.QueryRow(query, userCodeHash, userID, status))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, autherrors.ErrDeviceCodeNotFound
+	}
+	if err != nil {
+		return nil, autherrors.ErrUpdateDeviceAuthorization(err)
+	}
+
+	return auth, nil
+
+}
+
+// PollDeviceAuthorization records a token request of the device at polledAt and returns the request
+// with LastPolledAt set to the time of the previous poll, so callers can enforce the poll interval.
+// An unknown device code returns autherrors.ErrDeviceCodeNotFound.
+func (r *DeviceAuthorizationRepository) PollDeviceAuthorization(deviceCodeHash string,
+	polledAt time.Time) (*models.DeviceAuthorization, error) {
+
+	// The row lock makes concurrent polls see each other, so neither can skip the interval
+	query := `UPDATE device_authorizations SET last_polled_at = $2
+	FROM (
+		SELECT device_code_hash AS polled_hash, last_polled_at AS previous_poll
+		FROM device_authorizations
+		WHERE device_code_hash = $1
+		FOR UPDATE
+	) previous
+	WHERE device_code_hash = previous.polled_hash
+	RETURNING device_code_hash, user_code_hash, client_id, scopes, status, user_id,
+		poll_interval_seconds, expires_at, created_at, previous.previous_poll`
+
+	auth, err := scanDeviceAuthorization(r.db.QueryRow(query, deviceCodeHash, polledAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, autherrors.ErrDeviceCodeNotFound
+	}
+	if err != nil {
+		return nil, autherrors.ErrUpdateDeviceAuthorization(err)
+	}
+
+	return auth, nil
+
+}
+
+// UpdatePollInterval changes the minimum time between two polls of the device.
+func (r *DeviceAuthorizationRepository) UpdatePollInterval(deviceCodeHash string, interval time.Duration) error {
+
+	_, err := r.db.Exec(`UPDATE device_authorizations SET poll_interval_seconds = $2 WHERE device_code_hash = $1`,
+		deviceCodeHash, int(interval/time.Second))
+	if err != nil {
+		return autherrors.ErrUpdateDeviceAuthorization(err)
+	}
+
+	return nil
+
+}
+
+// ConsumeDeviceAuthorization removes the approved request with the given device code hash and returns it.
+// The delete is atomic, so tokens are issued for an approval only once even to concurrent polls;
+// an unknown or not approved request returns autherrors.ErrDeviceCodeNotFound.
+func (r *DeviceAuthorizationRepository) ConsumeDeviceAuthorization(deviceCodeHash string) (*models.DeviceAuthorization, error) {
+
+	query := `DELETE FROM device_authorizations
+	WHERE device_code_hash = $1 AND status = 'approved'
+	RETURNING ` + deviceAuthorizationColumns
+
+	auth, err := scanDeviceAuthorization(r.db.QueryRow(query, deviceCodeHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, autherrors.ErrDeviceCodeNotFound
+	}
+	if err != nil {
+		return nil, autherrors.ErrUpdateDeviceAuthorization(err)
+	}
+
+	return auth, nil
+
+}
+
+// DeleteExpiredDeviceAuthorizations removes requests that can no longer be approved or polled.
+func (r *DeviceAuthorizationRepository) DeleteExpiredDeviceAuthorizations() error {
+
+	_, err := r.db.Exec(`DELETE FROM device_authorizations WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return autherrors.ErrDeleteDeviceAuthorizations(err)
+	}
+
+	return nil
+
+}
+
+const deviceAuthorizationColumns = `device_code_hash, user_code_hash, client_id, scopes, status, user_id,
+	poll_interval_seconds, expires_at, created_at, last_polled_at`
+
+// scanDeviceAuthorization scans a row of deviceAuthorizationColumns.
+func scanDeviceAuthorization(row *sql.Row) (*models.DeviceAuthorization, error) {
+
+	var auth models.DeviceAuthorization
+	var intervalSeconds int
+
+	err := row.Scan(
+		&auth.DeviceCodeHash, &auth.UserCodeHash, &auth.ClientID, pq.Array(&auth.Scopes), &auth.Status, &auth.UserID,
+		&intervalSeconds, &auth.ExpiresAt, &auth.CreatedAt, &auth.LastPolledAt)
+	if err != nil {
+		return nil, err
+	}
+	auth.PollInterval = time.Duration(intervalSeconds) * time.Second
+
+	return &auth, nil
+
+}
@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+type DeviceAuthorizationRepositoryTestSuite struct {
+	RepositoryTestSuite
+	TestUser   *models.User
+	TestClient *models.Client
+}
+
+func (s *DeviceAuthorizationRepositoryTestSuite) SetupTest() {
+	user, err := s.UserRepo.CreateUser(s.TestLogin, s.TestPassword)
+	require.NoError(s.T(), err)
+	s.TestUser = user
+
+	s.TestClient = &models.Client{ID: "planner-cli", Name: "Planner CLI"}
+	require.NoError(s.T(), s.ClientRepo.CreateClient(s.TestClient))
+}
+
+func (s *DeviceAuthorizationRepositoryTestSuite) newAuthorization(deviceCodeHash string, userCodeHash string,
+	expiresAt time.Time) *models.DeviceAuthorization {
+	auth := &models.DeviceAuthorization{
+		DeviceCodeHash: deviceCodeHash,
+		UserCodeHash:   userCodeHash,
+		ClientID:       s.TestClient.ID,
+		Scopes:         []string{"plans:read"},
+		Status:         models.DeviceAuthorizationPending,
+		PollInterval:   5 * time.Second,
+		ExpiresAt:      expiresAt,
+	}
+	require.NoError(s.T(), s.DeviceRepo.SaveDeviceAuthorization(auth))
+	return auth
+}
+
+func (s *DeviceAuthorizationRepositoryTestSuite) TestSaveAndFindByUserCode() {
+	saved := s.newAuthorization(s.TokenHashedValue, "user-code-hash", time.Now().UTC().Add(time.Minute))
+	assert.NotZero(s.T(), saved.CreatedAt)
+
+	auth, err := s.DeviceRepo.FindByUserCode("user-code-hash")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), saved.DeviceCodeHash, auth.DeviceCodeHash)
+	assert.Equal(s.T(), s.TestClient.ID, auth.ClientID)
+	assert.Equal(s.T(), []string{"plans:read"}, auth.Scopes)
+	assert.Equal(s.T(), models.DeviceAuthorizationPending, auth.Status)
+	assert.Equal(s.T(), 5*time.Second, auth.PollInterval)
+	assert.Nil(s.T(), auth.UserID)
+	assert.Nil(s.T(), auth.LastPolledAt)
+
+	_, err = s.DeviceRepo.FindByUserCode("unknown-hash")
+	assert.ErrorIs(s.T(), err, autherrors.ErrDeviceCodeNotFound)
+}
+
+func (s *DeviceAuthorizationRepositoryTestSuite) TestSaveTakenUserCode() {
+	s.newAuthorization(s.TokenHashedValue, "user-code-hash", time.Now().UTC().Add(time.Minute))
+
+	err := s.DeviceRepo.SaveDeviceAuthorization(&models.DeviceAuthorization{
+		DeviceCodeHash: "other-device-hash",
+		UserCodeHash:   "user-code-hash",
+		ClientID:       s.TestClient.ID,
+		Status:         models.DeviceAuthorizationPending,
+		PollInterval:   5 * time.Second,
+		ExpiresAt:      time.Now().UTC().Add(time.Minute),
+	})
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrUserCodeTaken)
+}
+
+func (s *DeviceAuthorizationRepositoryTestSuite) TestDecideOnce() {
+	s.newAuthorization(s.TokenHashedValue, "user-code-hash", time.Now().UTC().Add(time.Minute))
+	s.newAuthorization("expired-device-hash", "expired-user-hash", time.Now().UTC().Add(-time.Minute))
+
+	auth, err := s.DeviceRepo.DecideDeviceAuthorization("user-code-hash", s.TestUser.ID, models.DeviceAuthorizationApproved)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), models.DeviceAuthorizationApproved, auth.Status)
+	require.NotNil(s.T(), auth.UserID)
+	assert.Equal(s.T(), s.TestUser.ID, *auth.UserID)
+
+	_, err = s.DeviceRepo.DecideDeviceAuthorization("user-code-hash", s.TestUser.ID, models.DeviceAuthorizationDenied)
+	assert.ErrorIs(s.T(), err, autherrors.ErrDeviceCodeNotFound, "a decision can't be changed")
+
+	_, err = s.DeviceRepo.DecideDeviceAuthorization("expired-user-hash", s.TestUser.ID, models.DeviceAuthorizationApproved)
+	assert.ErrorIs(s.T(), err, autherrors.ErrDeviceCodeNotFound)
+}
+
+func (s *DeviceAuthorizationRepositoryTestSuite) TestPollReturnsPreviousPoll() {
+	s.newAuthorization(s.TokenHashedValue, "user-code-hash", time.Now().UTC().Add(time.Minute))
+
+	firstPoll := time.Now().UTC().Add(-time.Second).Truncate(time.Microsecond)
+
+	auth, err := s.DeviceRepo.PollDeviceAuthorization(s.TokenHashedValue, firstPoll)
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), auth.LastPolledAt, "the first poll has no previous poll")
+
+	auth, err = s.DeviceRepo.PollDeviceAuthorization(s.TokenHashedValue, time.Now().UTC())
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), auth.LastPolledAt)
+	assert.True(s.T(), firstPoll.Equal(*auth.LastPolledAt))
+
+	require.NoError(s.T(), s.DeviceRepo.UpdatePollInterval(s.TokenHashedValue, 10*time.Second))
+	auth, err = s.DeviceRepo.PollDeviceAuthorization(s.TokenHashedValue, time.Now().UTC())
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 10*time.Second, auth.PollInterval)
+
+	_, err = s.DeviceRepo.PollDeviceAuthorization("unknown-hash", time.Now().UTC())
+	assert.ErrorIs(s.T(), err, autherrors.ErrDeviceCodeNotFound)
+}
+
+func (s *DeviceAuthorizationRepositoryTestSuite) TestConsumeOnlyApproved() {
+	s.newAuthorization(s.TokenHashedValue, "user-code-hash", time.Now().UTC().Add(time.Minute))
+
+	_, err := s.DeviceRepo.ConsumeDeviceAuthorization(s.TokenHashedValue)
+	assert.ErrorIs(s.T(), err, autherrors.ErrDeviceCodeNotFound, "pending requests can't be consumed")
+
+	_, err = s.DeviceRepo.DecideDeviceAuthorization("user-code-hash", s.TestUser.ID, models.DeviceAuthorizationApproved)
+	require.NoError(s.T(), err)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	consumed := 0
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.DeviceRepo.ConsumeDeviceAuthorization(s.TokenHashedValue); err == nil {
+				mu.Lock()
+				consumed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(s.T(), 1, consumed, "an approval must be redeemed only once")
+}
+
+func (s *DeviceAuthorizationRepositoryTestSuite) TestDeleteExpiredDeviceAuthorizations() {
+	s.newAuthorization("expired-device-hash", "expired-user-hash", time.Now().UTC().Add(-time.Minute))
+	s.newAuthorization(s.TokenHashedValue, "user-code-hash", time.Now().UTC().Add(time.Minute))
+
+	err := s.DeviceRepo.DeleteExpiredDeviceAuthorizations()
+	require.NoError(s.T(), err)
+
+	var count int
+	err = s.DB.QueryRow(`SELECT count(*) FROM device_authorizations`).Scan(&count)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, count)
+}
+
+func TestDeviceAuthorizationRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(DeviceAuthorizationRepositoryTestSuite))
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// LoginAttemptRepository handles persistence of failed login attempts per login and per IP address.
+// Keeping them in the database makes lockouts apply across all replicas of the service.
+type LoginAttemptRepository struct {
+	db *sql.DB
+}
+
+// NewLoginAttemptRepository creates a new login attempt repository instance.
+func NewLoginAttemptRepository(db *sql.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+// FindAttempts returns the failed attempts for the key, or nil if there are none.
+func (r *LoginAttemptRepository) FindAttempts(key models.LoginAttemptKey) (*models.LoginAttempts, error) {
+
+	query := `SELECT kind, value, failed_count, last_failed_at, locked_until
+	FROM login_attempts
+	WHERE kind = $1 AND value = $2`
+
+	attempts, err := scanLoginAttempts(r.db.QueryRow(query, key.Kind, key.Value))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, autherrors.ErrFindLoginAttempts(err)
+	}
+
+	return attempts, nil
+
+}
+
+// RecordFailure counts a failed attempt at failedAt and returns the updated attempts.
+// If the previous failure was before windowStart, counting starts over and an expired lock is cleared.
+// The upsert is atomic, so concurrent failures on several replicas are all counted.
+func (r *LoginAttemptRepository) RecordFailure(key models.LoginAttemptKey, failedAt time.Time,
+	windowStart time.Time) (*models.LoginAttempts, error) {
+
+	query := `INSERT INTO login_attempts (kind, value, failed_count, last_failed_at)
+	VALUES ($1, $2, 1, $3)
+	ON CONFLICT (kind, value) DO UPDATE SET
+		failed_count = CASE WHEN login_attempts.last_failed_at < $4 THEN 1 ELSE login_attempts.failed_count + 1 END,
+		locked_until = CASE WHEN login_attempts.last_failed_at < $4 THEN NULL ELSE login_attempts.locked_until END,
+		last_failed_at = EXCLUDED.last_failed_at
+	RETURNING kind, value, failed_count, last_failed_at, locked_until`
+
+	attempts, err := scanLoginAttempts(r.db.QueryRow(query, key.Kind, key.Value, failedAt, windowStart))
+	if err != nil {
+		return nil, autherrors.ErrRecordLoginAttempt(err)
+	}
+
+	return attempts, nil
+
+}
+
+// Lock rejects logins for the key until the given time. A lock never ends earlier than an existing one.
+func (r *LoginAttemptRepository) Lock(key models.LoginAttemptKey, until time.Time) error {
+
+	_, err := r.db.Exec(`UPDATE login_attempts SET locked_until = GREATEST(locked_until, $3)
+	WHERE kind = $1 AND value = $2`, key.Kind, key.Value, until)
+	if err != nil {
+		return autherrors.ErrRecordLoginAttempt(err)
+	}
+
+	return nil
+
+}
+
+// DeleteAttempts removes the failed attempts and the lock of the key. Deleting unknown keys is not an error.
+func (r *LoginAttemptRepository) DeleteAttempts(key models.LoginAttemptKey) error {
+
+	_, err := r.db.Exec(`DELETE FROM login_attempts WHERE kind = $1 AND value = $2`, key.Kind, key.Value)
+	if err != nil {
+		return autherrors.ErrDeleteLoginAttempts(err)
+	}
+
+	return nil
+
+}
+
+// DeleteStaleAttempts removes the attempts of keys that last failed before the given time and aren't locked anymore.
+func (r *LoginAttemptRepository) DeleteStaleAttempts(before time.Time) error {
+
+	_, err := r.db.Exec(`DELETE FROM login_attempts
+	WHERE last_failed_at < $1 AND (locked_until IS NULL OR locked_until < $1)`, before)
+	if err != nil {
+		return autherrors.ErrDeleteLoginAttempts(err)
+	}
+
+	return nil
+
+}
+
+// scanLoginAttempts scans a row of login attempts.
+func scanLoginAttempts(row *sql.Row) (*models.LoginAttempts, error) {
+	var attempts models.LoginAttempts
+	var lockedUntil sql.NullTime
+
+	err := row.Scan(&attempts.Key.Kind, &attempts.Key.Value, &attempts.FailedCount, &attempts.LastFailedAt, &lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lockedUntil.Valid {
+		attempts.LockedUntil = &lockedUntil.Time
+	}
+
+	return &attempts, nil
+}
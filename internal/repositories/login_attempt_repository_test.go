@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+type LoginAttemptRepositoryTestSuite struct {
+	RepositoryTestSuite
+	key models.LoginAttemptKey
+}
+
+func (s *LoginAttemptRepositoryTestSuite) SetupTest() {
+	s.key = models.LoginAttemptKey{Kind: models.LoginAttemptLogin, Value: s.TestLogin}
+}
+
+func (s *LoginAttemptRepositoryTestSuite) TestRecordFailureCountsWithinWindow() {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	windowStart := now.Add(-time.Hour)
+
+	for i := 1; i <= 3; i++ {
+		attempts, err := s.AttemptRepo.RecordFailure(s.key, now, windowStart)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), i, attempts.FailedCount)
+		assert.Equal(s.T(), s.key, attempts.Key)
+		assert.Nil(s.T(), attempts.LockedUntil)
+	}
+
+	other := models.LoginAttemptKey{Kind: models.LoginAttemptIPAddress, Value: s.TestLogin}
+	attempts, err := s.AttemptRepo.RecordFailure(other, now, windowStart)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, attempts.FailedCount, "logins and IP addresses should be counted separately")
+}
+
+func (s *LoginAttemptRepositoryTestSuite) TestRecordFailureStartsOverAfterWindow() {
+	failedAt := time.Now().UTC().Add(-2 * time.Hour)
+	_, err := s.AttemptRepo.RecordFailure(s.key, failedAt, failedAt.Add(-time.Hour))
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), s.AttemptRepo.Lock(s.key, failedAt.Add(time.Minute)))
+
+	now := time.Now().UTC()
+	attempts, err := s.AttemptRepo.RecordFailure(s.key, now, now.Add(-time.Hour))
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, attempts.FailedCount)
+	assert.Nil(s.T(), attempts.LockedUntil, "the expired lock should be cleared")
+}
+
+func (s *LoginAttemptRepositoryTestSuite) TestLockAndFind() {
+	attempts, err := s.AttemptRepo.FindAttempts(s.key)
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), attempts)
+
+	now := time.Now().UTC()
+	_, err = s.AttemptRepo.RecordFailure(s.key, now, now.Add(-time.Hour))
+	require.NoError(s.T(), err)
+
+	until := now.Add(10 * time.Minute)
+	require.NoError(s.T(), s.AttemptRepo.Lock(s.key, until))
+	require.NoError(s.T(), s.AttemptRepo.Lock(s.key, now.Add(time.Minute)))
+
+	attempts, err = s.AttemptRepo.FindAttempts(s.key)
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), attempts)
+	assert.Equal(s.T(), 1, attempts.FailedCount)
+	require.NotNil(s.T(), attempts.LockedUntil)
+	assert.WithinDuration(s.T(), until, *attempts.LockedUntil, time.Millisecond, "a lock should not be shortened")
+}
+
+func (s *LoginAttemptRepositoryTestSuite) TestDeleteAttempts() {
+	now := time.Now().UTC()
+	_, err := s.AttemptRepo.RecordFailure(s.key, now, now.Add(-time.Hour))
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), s.AttemptRepo.DeleteAttempts(s.key))
+	require.NoError(s.T(), s.AttemptRepo.DeleteAttempts(s.key), "deleting unknown keys should succeed")
+
+	attempts, err := s.AttemptRepo.FindAttempts(s.key)
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), attempts)
+}
+
+func (s *LoginAttemptRepositoryTestSuite) TestDeleteStaleAttempts() {
+	now := time.Now().UTC()
+	stale := models.LoginAttemptKey{Kind: models.LoginAttemptIPAddress, Value: "203.0.113.7"}
+	_, err := s.AttemptRepo.RecordFailure(stale, now.Add(-2*time.Hour), now.Add(-3*time.Hour))
+	require.NoError(s.T(), err)
+	_, err = s.AttemptRepo.RecordFailure(s.key, now, now.Add(-time.Hour))
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), s.AttemptRepo.DeleteStaleAttempts(now.Add(-time.Hour)))
+
+	attempts, err := s.AttemptRepo.FindAttempts(stale)
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), attempts)
+
+	attempts, err = s.AttemptRepo.FindAttempts(s.key)
+	require.NoError(s.T(), err)
+	assert.NotNil(s.T(), attempts)
+}
+
+func TestLoginAttemptRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(LoginAttemptRepositoryTestSuite))
+}
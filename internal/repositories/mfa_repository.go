@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// MFARepository handles persistence of the users' TOTP credentials and the hashes of their recovery codes.
+type MFARepository struct {
+	db *sql.DB
+}
+
+// NewMFARepository creates a new MFA repository instance.
+func NewMFARepository(db *sql.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+// SaveTOTP stores a pending TOTP credential, replacing a previous pending one of the user.
+// Returns autherrors.ErrMFAAlreadyEnabled if the user already has a confirmed credential, which is never replaced.
+func (r *MFARepository) SaveTOTP(credential *models.TOTPCredential) error {
+
+	query := `INSERT INTO totp_credentials (user_id, secret)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id) DO UPDATE SET
+		secret = EXCLUDED.secret,
+		last_used_step = 0,
+		created_at = now()
+	WHERE totp_credentials.confirmed_at IS NULL
+	RETURNING created_at`
+
+	err := r.db.QueryRow(query, credential.UserID, credential.Secret).Scan(&credential.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return autherrors.ErrMFAAlreadyEnabled
+	}
+	if err != nil {
+		return autherrors.ErrSaveTOTP(err)
+	}
+
+	credential.ConfirmedAt = nil
+	credential.LastUsedStep = 0
+
+	return nil
+
+}
+
+// FindTOTP returns the TOTP credential of the user, or nil if the user has none.
+func (r *MFARepository) FindTOTP(userID uuid.UUID) (*models.TOTPCredential, error) {
+
+	query := `SELECT user_id, secret, confirmed_at, last_used_step, created_at
+	FROM totp_credentials
+	WHERE user_id = $1`
+
+	var credential models.TOTPCredential
+	var confirmedAt sql.NullTime
+
+	err := r.db.QueryRow(query, userID).Scan(&credential.UserID, &credential.Secret, &confirmedAt,
+		&credential.LastUsedStep, &credential.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, autherrors.ErrFindTOTP(err)
+	}
+
+	if confirmedAt.Valid {
+		credential.ConfirmedAt = &confirmedAt.Time
+	}
+
+	return &credential, nil
+
+}
+
+// ConfirmTOTP confirms the pending credential of the user with the code of the given time step
+// and replaces the user's recovery codes with the given hashes, in one transaction.
+// Returns autherrors.ErrMFANotEnrolled if the user has no pending credential.
+func (r *MFARepository) ConfirmTOTP(userID uuid.UUID, step int64, codeHashes []string) error {
+	return withTx(r.db, func(tx *sql.Tx) error {
+		result, err := tx.Exec(`UPDATE totp_credentials SET confirmed_at = now(), last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NULL`, userID, step)
+		if err != nil {
+			return autherrors.ErrUpdateTOTP(err)
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return autherrors.ErrUpdateTOTP(err)
+		}
+		if rows == 0 {
+			return autherrors.ErrMFANotEnrolled
+		}
+
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// UseTOTPStep records that a code of the given time step was accepted. It reports false if a code of this
+// or a later step was accepted before, i.e. the code is replayed; the check and the update are atomic.
+func (r *MFARepository) UseTOTPStep(userID uuid.UUID, step int64) (bool, error) {
+
+	result, err := r.db.Exec(`UPDATE totp_credentials SET last_used_step = $2
+	WHERE user_id = $1 AND last_used_step < $2`, userID, step)
+	if err != nil {
+		return false, autherrors.ErrUpdateTOTP(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, autherrors.ErrUpdateTOTP(err)
+	}
+
+	return rows > 0, nil
+
+}
+
+// DeleteTOTP removes the TOTP credential and the recovery codes of the user. Deleting for a user without
+// a credential is not an error.
+func (r *MFARepository) DeleteTOTP(userID uuid.UUID) error {
+	return withTx(r.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return autherrors.ErrDeleteTOTP(err)
+		}
+
+		if _, err := tx.Exec(`DELETE FROM totp_credentials WHERE user_id = $1`, userID); err != nil {
+			return autherrors.ErrDeleteTOTP(err)
+		}
+
+		return nil
+	})
+}
+
+// ReplaceRecoveryCodes replaces all recovery codes of the user, used or not, with the given hashes.
+func (r *MFARepository) ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	return withTx(r.db, func(tx *sql.Tx) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// UseRecoveryCode marks the user's recovery code with the hash as used. It reports false if the user
+// has no such code or it was used before; the check and the update are atomic.
+func (r *MFARepository) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+
+	result, err := r.db.Exec(`UPDATE recovery_codes SET used_at = now()
+	WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, codeHash)
+	if err != nil {
+		return false, autherrors.ErrUseRecoveryCode(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, autherrors.ErrUseRecoveryCode(err)
+	}
+
+	return rows > 0, nil
+
+}
+
+// CountRecoveryCodes returns the number of recovery codes of the user that haven't been used.
+func (r *MFARepository) CountRecoveryCodes(userID uuid.UUID) (int, error) {
+
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL`,
+		userID).Scan(&count)
+	if err != nil {
+		return 0, autherrors.ErrFindRecoveryCodes(err)
+	}
+
+	return count, nil
+
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and inserts the given hashes within the transaction.
+func replaceRecoveryCodes(tx *sql.Tx, userID uuid.UUID, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return autherrors.ErrSaveRecoveryCodes(err)
+	}
+
+	for _, codeHash := range codeHashes {
+		_, err := tx.Exec(`INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, codeHash)
+		if err != nil {
+			return autherrors.ErrSaveRecoveryCodes(err)
+		}
+	}
+
+	return nil
+}
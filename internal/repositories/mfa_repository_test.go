@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+type MFARepositoryTestSuite struct {
+	RepositoryTestSuite
+	TestUser *models.User
+}
+
+func (s *MFARepositoryTestSuite) SetupTest() {
+	user, err := s.UserRepo.CreateUser(s.TestLogin, s.TestPassword)
+	require.NoError(s.T(), err)
+	s.TestUser = user
+}
+
+func (s *MFARepositoryTestSuite) saveConfirmed(codeHashes ...string) {
+	require.NoError(s.T(), s.MFARepo.SaveTOTP(&models.TOTPCredential{UserID: s.TestUser.ID, Secret: "secret"}))
+	require.NoError(s.T(), s.MFARepo.ConfirmTOTP(s.TestUser.ID, 100, codeHashes))
+}
+
+func (s *MFARepositoryTestSuite) TestSaveAndFindTOTP() {
+	credential, err := s.MFARepo.FindTOTP(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), credential)
+
+	saved := &models.TOTPCredential{UserID: s.TestUser.ID, Secret: "first"}
+	require.NoError(s.T(), s.MFARepo.SaveTOTP(saved))
+	assert.NotZero(s.T(), saved.CreatedAt)
+
+	// A new enrollment replaces the pending one
+	require.NoError(s.T(), s.MFARepo.SaveTOTP(&models.TOTPCredential{UserID: s.TestUser.ID, Secret: "second"}))
+
+	credential, err = s.MFARepo.FindTOTP(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), credential)
+	assert.Equal(s.T(), "second", credential.Secret)
+	assert.False(s.T(), credential.IsConfirmed())
+}
+
+func (s *MFARepositoryTestSuite) TestConfirmTOTP() {
+	s.saveConfirmed(s.TokenHashedValue)
+
+	credential, err := s.MFARepo.FindTOTP(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), credential.IsConfirmed())
+	assert.Equal(s.T(), int64(100), credential.LastUsedStep)
+
+	count, err := s.MFARepo.CountRecoveryCodes(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, count)
+
+	err = s.MFARepo.ConfirmTOTP(s.TestUser.ID, 101, nil)
+	assert.ErrorIs(s.T(), err, autherrors.ErrMFANotEnrolled, "a confirmed credential can't be confirmed again")
+
+	err = s.MFARepo.SaveTOTP(&models.TOTPCredential{UserID: s.TestUser.ID, Secret: "other"})
+	assert.ErrorIs(s.T(), err, autherrors.ErrMFAAlreadyEnabled)
+}
+
+func (s *MFARepositoryTestSuite) TestUseTOTPStepRejectsReplays() {
+	s.saveConfirmed()
+
+	used, err := s.MFARepo.UseTOTPStep(s.TestUser.ID, 101)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), used)
+
+	for _, step := range []int64{101, 100} {
+		used, err = s.MFARepo.UseTOTPStep(s.TestUser.ID, step)
+		require.NoError(s.T(), err)
+		assert.False(s.T(), used, "step %d", step)
+	}
+}
+
+func (s *MFARepositoryTestSuite) TestUseRecoveryCodeOnce() {
+	s.saveConfirmed("hash-1", "hash-2")
+
+	used, err := s.MFARepo.UseRecoveryCode(s.TestUser.ID, "hash-1")
+	require.NoError(s.T(), err)
+	assert.True(s.T(), used)
+
+	used, err = s.MFARepo.UseRecoveryCode(s.TestUser.ID, "hash-1")
+	require.NoError(s.T(), err)
+	assert.False(s.T(), used, "recovery codes are single use")
+
+	used, err = s.MFARepo.UseRecoveryCode(s.TestUser.ID, "unknown")
+	require.NoError(s.T(), err)
+	assert.False(s.T(), used)
+
+	count, err := s.MFARepo.CountRecoveryCodes(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, count)
+}
+
+func (s *MFARepositoryTestSuite) TestReplaceRecoveryCodes() {
+	s.saveConfirmed("hash-1")
+	_, err := s.MFARepo.UseRecoveryCode(s.TestUser.ID, "hash-1")
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), s.MFARepo.ReplaceRecoveryCodes(s.TestUser.ID, []string{"hash-1", "hash-2"}))
+
+	count, err := s.MFARepo.CountRecoveryCodes(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, count)
+}
+
+func (s *MFARepositoryTestSuite) TestDeleteTOTP() {
+	s.saveConfirmed("hash-1")
+
+	require.NoError(s.T(), s.MFARepo.DeleteTOTP(s.TestUser.ID))
+
+	credential, err := s.MFARepo.FindTOTP(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), credential)
+
+	count, err := s.MFARepo.CountRecoveryCodes(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	assert.Zero(s.T(), count)
+
+	assert.NoError(s.T(), s.MFARepo.DeleteTOTP(s.TestUser.ID), "deleting again should succeed")
+}
+
+func TestMFARepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(MFARepositoryTestSuite))
+}
@@ -0,0 +1,209 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// PasskeyRepository handles persistence of the users' passkeys and the challenges of running WebAuthn ceremonies.
+type PasskeyRepository struct {
+	db *sql.DB
+}
+
+// NewPasskeyRepository creates a new passkey repository instance.
+func NewPasskeyRepository(db *sql.DB) *PasskeyRepository {
+	return &PasskeyRepository{db: db}
+}
+
+// SaveChallenge stores the hashed challenge of a new ceremony and fills in its creation time.
+func (r *PasskeyRepository) SaveChallenge(challenge *models.PasskeyChallenge) error {
+
+	query := `INSERT INTO passkey_challenges (challenge_hash, ceremony, user_id, expires_at)
+	VALUES ($1, $2, $3, $4)
+	RETURNING created_at`
+
+	err := r.db.QueryRow(query, challenge.ChallengeHash, challenge.Ceremony, challenge.UserID,
+		challenge.ExpiresAt).Scan(&challenge.CreatedAt)
+	if err != nil {
+		return autherrors.ErrSavePasskeyChallenge(err)
+	}
+
+	return nil
+
+}
+
+// ConsumeChallenge deletes the challenge with the given hash and returns it, or nil if there is none.
+// The deletion is atomic, so a challenge can be consumed only once even by concurrent requests.
+// Expired challenges are returned too; checking the expiry is left to the caller.
+func (r *PasskeyRepository) ConsumeChallenge(challengeHash string) (*models.PasskeyChallenge, error) {
+
+	query := `DELETE FROM passkey_challenges
+	WHERE challenge_hash = $1
+	RETURNING challenge_hash, ceremony, user_id, expires_at, created_at`
+
+	var challenge models.PasskeyChallenge
+	err := r.db.QueryRow(query, challengeHash).Scan(&challenge.ChallengeHash, &challenge.Ceremony,
+		&challenge.UserID, &challenge.ExpiresAt, &challenge.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, autherrors.ErrConsumePasskeyChallenge(err)
+	}
+
+	return &challenge, nil
+
+}
+
+// DeleteExpiredChallenges removes the challenges of ceremonies that were never completed.
+func (r *PasskeyRepository) DeleteExpiredChallenges() error {
+
+	_, err := r.db.Exec(`DELETE FROM passkey_challenges WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return autherrors.ErrDeletePasskeyChallenges(err)
+	}
+
+	return nil
+
+}
+
+// SavePasskey stores a newly registered passkey and fills in its creation time.
+// Returns autherrors.ErrPasskeyExists if a passkey with the same credential ID is already registered.
+func (r *PasskeyRepository) SavePasskey(passkey *models.Passkey) error {
+
+	query := `INSERT INTO passkeys (id, user_id, name, public_key, sign_count, aaguid, backup_eligible, backed_up)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (id) DO NOTHING
+	RETURNING created_at`
+
+	err := r.db.QueryRow(query, passkey.ID, passkey.UserID, passkey.Name, passkey.PublicKey,
+		int64(passkey.SignCount), passkey.AAGUID, passkey.BackupEligible, passkey.BackedUp).Scan(&passkey.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return autherrors.ErrPasskeyExists
+	}
+	if err != nil {
+		return autherrors.ErrSavePasskey(err)
+	}
+
+	return nil
+
+}
+
+// FindPasskey returns the passkey with the credential ID, or nil if there is none.
+func (r *PasskeyRepository) FindPasskey(credentialID []byte) (*models.Passkey, error) {
+
+	query := `SELECT id, user_id, name, public_key, sign_count, aaguid, backup_eligible, backed_up,
+		created_at, last_used_at
+	FROM passkeys
+	WHERE id = $1`
+
+	passkey, err := scanPasskey(r.db.QueryRow(query, credentialID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, autherrors.ErrFindPasskeys(err)
+	}
+
+	return passkey, nil
+
+}
+
+// FindPasskeys returns the passkeys of the user, oldest first.
+func (r *PasskeyRepository) FindPasskeys(userID uuid.UUID) ([]*models.Passkey, error) {
+
+	rows, err := r.db.Query(`SELECT id, user_id, name, public_key, sign_count, aaguid, backup_eligible, backed_up,
+		created_at, last_used_at
+	FROM passkeys
+	WHERE user_id = $1
+	ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, autherrors.ErrFindPasskeys(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var passkeys []*models.Passkey
+	for rows.Next() {
+		passkey, err := scanPasskey(rows)
+		if err != nil {
+			return nil, autherrors.ErrFindPasskeys(err)
+		}
+		passkeys = append(passkeys, passkey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, autherrors.ErrFindPasskeys(err)
+	}
+
+	return passkeys, nil
+
+}
+
+// UseSignCount records a login with the passkey and the signature counter the authenticator reported.
+// It reports false if the counter didn't increase, i.e. another copy of the passkey signed since;
+// authenticators without a counter always report 0, which is accepted as long as the stored counter is 0 too.
+// The check and the update are atomic.
+func (r *PasskeyRepository) UseSignCount(credentialID []byte, signCount uint32, backedUp bool) (bool, error) {
+
+	result, err := r.db.Exec(`UPDATE passkeys SET sign_count = $2, backed_up = $3, last_used_at = now()
+	WHERE id = $1 AND (sign_count < $2 OR (sign_count = 0 AND $2 = 0))`, credentialID, int64(signCount), backedUp)
+	if err != nil {
+		return false, autherrors.ErrUpdatePasskey(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, autherrors.ErrUpdatePasskey(err)
+	}
+
+	return rows > 0, nil
+
+}
+
+// DeletePasskey removes the passkey of the user with the credential ID.
+// Returns autherrors.ErrPasskeyNotFound if the user has no such passkey.
+func (r *PasskeyRepository) DeletePasskey(userID uuid.UUID, credentialID []byte) error {
+
+	result, err := r.db.Exec(`DELETE FROM passkeys WHERE id = $1 AND user_id = $2`, credentialID, userID)
+	if err != nil {
+		return autherrors.ErrDeletePasskey(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return autherrors.ErrDeletePasskey(err)
+	}
+	if rows == 0 {
+		return autherrors.ErrPasskeyNotFound
+	}
+
+	return nil
+
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanPasskey reads a passkey from the columns selected by FindPasskey and FindPasskeys.
+func scanPasskey(row rowScanner) (*models.Passkey, error) {
+	var passkey models.Passkey
+	var signCount int64
+
+	err := row.Scan(&passkey.ID, &passkey.UserID, &passkey.Name, &passkey.PublicKey, &signCount, &passkey.AAGUID,
+		&passkey.BackupEligible, &passkey.BackedUp, &passkey.CreatedAt, &passkey.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey.SignCount = uint32(signCount)
+	return &passkey, nil
+}
@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+type PasskeyRepositoryTestSuite struct {
+	RepositoryTestSuite
+	TestUser *models.User
+}
+
+func (s *PasskeyRepositoryTestSuite) SetupTest() {
+	user, err := s.UserRepo.CreateUser(s.TestLogin, s.TestPassword)
+	require.NoError(s.T(), err)
+	s.TestUser = user
+}
+
+func (s *PasskeyRepositoryTestSuite) savePasskey(id string) *models.Passkey {
+	passkey := &models.Passkey{
+		ID:             []byte(id),
+		UserID:         s.TestUser.ID,
+		Name:           "Laptop",
+		PublicKey:      []byte("public key"),
+		SignCount:      5,
+		AAGUID:         make([]byte, 16),
+		BackupEligible: true,
+	}
+	require.NoError(s.T(), s.PasskeyRepo.SavePasskey(passkey))
+	return passkey
+}
+
+func (s *PasskeyRepositoryTestSuite) TestSaveAndConsumeChallenge() {
+	challenge := &models.PasskeyChallenge{
+		ChallengeHash: s.TokenHashedValue,
+		Ceremony:      models.PasskeyCeremonyRegistration,
+		UserID:        &s.TestUser.ID,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+	require.NoError(s.T(), s.PasskeyRepo.SaveChallenge(challenge))
+	assert.NotZero(s.T(), challenge.CreatedAt)
+
+	consumed, err := s.PasskeyRepo.ConsumeChallenge(s.TokenHashedValue)
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), consumed)
+	assert.Equal(s.T(), models.PasskeyCeremonyRegistration, consumed.Ceremony)
+	assert.Equal(s.T(), &s.TestUser.ID, consumed.UserID)
+
+	consumed, err = s.PasskeyRepo.ConsumeChallenge(s.TokenHashedValue)
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), consumed, "a challenge is consumed only once")
+}
+
+func (s *PasskeyRepositoryTestSuite) TestDeleteExpiredChallenges() {
+	require.NoError(s.T(), s.PasskeyRepo.SaveChallenge(&models.PasskeyChallenge{
+		ChallengeHash: s.TokenHashedValue,
+		Ceremony:      models.PasskeyCeremonyLogin,
+		ExpiresAt:     time.Now().Add(-time.Minute),
+	}))
+
+	require.NoError(s.T(), s.PasskeyRepo.DeleteExpiredChallenges())
+
+	consumed, err := s.PasskeyRepo.ConsumeChallenge(s.TokenHashedValue)
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), consumed)
+}
+
+func (s *PasskeyRepositoryTestSuite) TestSaveAndFindPasskeys() {
+	saved := s.savePasskey("first")
+	assert.NotZero(s.T(), saved.CreatedAt)
+	s.savePasskey("second")
+
+	err := s.PasskeyRepo.SavePasskey(&models.Passkey{ID: []byte("first"), UserID: s.TestUser.ID, PublicKey: []byte("other")})
+	assert.ErrorIs(s.T(), err, autherrors.ErrPasskeyExists)
+
+	passkey, err := s.PasskeyRepo.FindPasskey([]byte("first"))
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), passkey)
+	assert.Equal(s.T(), s.TestUser.ID, passkey.UserID)
+	assert.Equal(s.T(), []byte("public key"), passkey.PublicKey)
+	assert.Equal(s.T(), uint32(5), passkey.SignCount)
+	assert.True(s.T(), passkey.BackupEligible)
+	assert.Nil(s.T(), passkey.LastUsedAt)
+
+	passkey, err = s.PasskeyRepo.FindPasskey([]byte("unknown"))
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), passkey)
+
+	passkeys, err := s.PasskeyRepo.FindPasskeys(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), passkeys, 2)
+	assert.Equal(s.T(), []byte("first"), passkeys[0].ID)
+}
+
+func (s *PasskeyRepositoryTestSuite) TestUseSignCount() {
+	s.savePasskey("counting")
+
+	used, err := s.PasskeyRepo.UseSignCount([]byte("counting"), 6, true)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), used)
+
+	for _, signCount := range []uint32{6, 3, 0} {
+		used, err = s.PasskeyRepo.UseSignCount([]byte("counting"), signCount, true)
+		require.NoError(s.T(), err)
+		assert.False(s.T(), used, "sign count %d", signCount)
+	}
+
+	passkey, err := s.PasskeyRepo.FindPasskey([]byte("counting"))
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), uint32(6), passkey.SignCount)
+	assert.True(s.T(), passkey.BackedUp)
+	assert.NotNil(s.T(), passkey.LastUsedAt)
+}
+
+func (s *PasskeyRepositoryTestSuite) TestUseSignCountWithoutCounter() {
+	require.NoError(s.T(), s.PasskeyRepo.SavePasskey(&models.Passkey{
+		ID: []byte("static"), UserID: s.TestUser.ID, Name: "Key", PublicKey: []byte("public key"),
+	}))
+
+	for range 2 {
+		used, err := s.PasskeyRepo.UseSignCount([]byte("static"), 0, false)
+		require.NoError(s.T(), err)
+		assert.True(s.T(), used)
+	}
+}
+
+func (s *PasskeyRepositoryTestSuite) TestDeletePasskey() {
+	s.savePasskey("first")
+
+	require.NoError(s.T(), s.PasskeyRepo.DeletePasskey(s.TestUser.ID, []byte("first")))
+
+	err := s.PasskeyRepo.DeletePasskey(s.TestUser.ID, []byte("first"))
+	assert.ErrorIs(s.T(), err, autherrors.ErrPasskeyNotFound)
+
+	passkeys, err := s.PasskeyRepo.FindPasskeys(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), passkeys)
+}
+
+func TestPasskeyRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(PasskeyRepositoryTestSuite))
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// RateLimitRepository keeps the state of rate limited keys in the database, so that all replicas
+// of the service share the limits. It implements ratelimit.Store.
+type RateLimitRepository struct {
+	db *sql.DB
+}
+
+// NewRateLimitRepository creates a new rate limit repository instance.
+func NewRateLimitRepository(db *sql.DB) *RateLimitRepository {
+	return &RateLimitRepository{db: db}
+}
+
+// Update passes the state of the key, or a zero state if the key has none, to update and saves the result.
+// The key's row is locked (SELECT ... FOR UPDATE) while update runs, so concurrent requests on several replicas
+// are applied one after another.
+func (r *RateLimitRepository) Update(key string, update func(state *models.RateLimitState)) error {
+	return withTx(r.db, func(tx *sql.Tx) error {
+		// Creating the row first lets the first requests for a key lock it as well
+		_, err := tx.Exec(`INSERT INTO rate_limits (key) VALUES ($1) ON CONFLICT (key) DO NOTHING`, key)
+		if err != nil {
+			return autherrors.ErrUpdateRateLimit(err)
+		}
+
+		state := models.RateLimitState{Key: key}
+		var updatedAt sql.NullTime
+
+		err = tx.QueryRow(`SELECT value, previous_value, updated_at, expires_at
+		FROM rate_limits
+		WHERE key = $1
+		FOR UPDATE`, key).Scan(&state.Value, &state.PreviousValue, &updatedAt, &state.ExpiresAt)
+		if err != nil {
+			return autherrors.ErrUpdateRateLimit(err)
+		}
+		if updatedAt.Valid {
+			state.UpdatedAt = updatedAt.Time
+		}
+
+		update(&state)
+
+		_, err = tx.Exec(`UPDATE rate_limits
+		SET value = $2, previous_value = $3, updated_at = $4, expires_at = $5
+		WHERE key = $1`, key, state.Value, state.PreviousValue, state.UpdatedAt, state.ExpiresAt)
+		if err != nil {
+			return autherrors.ErrUpdateRateLimit(err)
+		}
+
+		return nil
+	})
+}
+
+// DeleteExpired removes the state of keys that expired before the given time.
+func (r *RateLimitRepository) DeleteExpired(before time.Time) error {
+
+	_, err := r.db.Exec(`DELETE FROM rate_limits WHERE expires_at < $1`, before)
+	if err != nil {
+		return autherrors.ErrDeleteRateLimits(err)
+	}
+
+	return nil
+
+}
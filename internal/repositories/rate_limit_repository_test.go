@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+type RateLimitRepositoryTestSuite struct {
+	RepositoryTestSuite
+}
+
+// state returns the stored state of the key without changing it.
+func (s *RateLimitRepositoryTestSuite) state(key string) models.RateLimitState {
+	var state models.RateLimitState
+	err := s.RateLimitRepo.Update(key, func(stored *models.RateLimitState) {
+		state = *stored
+	})
+	require.NoError(s.T(), err)
+	return state
+}
+
+func (s *RateLimitRepositoryTestSuite) TestUpdateStartsWithZeroState() {
+	var state models.RateLimitState
+	err := s.RateLimitRepo.Update("ip:203.0.113.7", func(stored *models.RateLimitState) {
+		state = *stored
+	})
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "ip:203.0.113.7", state.Key)
+	assert.Zero(s.T(), state.Value)
+	assert.True(s.T(), state.UpdatedAt.IsZero())
+}
+
+func (s *RateLimitRepositoryTestSuite) TestUpdateSavesState() {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	err := s.RateLimitRepo.Update("login:"+s.TestLogin, func(state *models.RateLimitState) {
+		state.Value = 2.5
+		state.PreviousValue = 4
+		state.UpdatedAt = now
+		state.ExpiresAt = now.Add(time.Minute)
+	})
+	require.NoError(s.T(), err)
+
+	state := s.state("login:" + s.TestLogin)
+	assert.InDelta(s.T(), 2.5, state.Value, 1e-9)
+	assert.InDelta(s.T(), 4, state.PreviousValue, 1e-9)
+	assert.True(s.T(), now.Equal(state.UpdatedAt))
+	assert.True(s.T(), now.Add(time.Minute).Equal(state.ExpiresAt))
+}
+
+func (s *RateLimitRepositoryTestSuite) TestConcurrentUpdatesAreSerialized() {
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.RateLimitRepo.Update("ip:203.0.113.7", func(state *models.RateLimitState) {
+				state.Value++
+				state.ExpiresAt = time.Now().Add(time.Minute)
+			})
+			assert.NoError(s.T(), err)
+		}()
+	}
+	wg.Wait()
+
+	assert.InDelta(s.T(), 10, s.state("ip:203.0.113.7").Value, 1e-9, "no update should be lost")
+}
+
+func (s *RateLimitRepositoryTestSuite) TestDeleteExpired() {
+	now := time.Now().UTC()
+	require.NoError(s.T(), s.RateLimitRepo.Update("stale", func(state *models.RateLimitState) {
+		state.Value = 1
+		state.ExpiresAt = now.Add(-time.Second)
+	}))
+	require.NoError(s.T(), s.RateLimitRepo.Update("active", func(state *models.RateLimitState) {
+		state.Value = 1
+		state.ExpiresAt = now.Add(time.Minute)
+	}))
+
+	require.NoError(s.T(), s.RateLimitRepo.DeleteExpired(now))
+
+	assert.Zero(s.T(), s.state("stale").Value)
+	assert.InDelta(s.T(), 1, s.state("active").Value, 1e-9)
+}
+
+func TestRateLimitRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitRepositoryTestSuite))
+}
@@ -22,6 +22,16 @@ type RepositoryTestSuite struct {
 	DB               *sql.DB
 	UserRepo         *UserRepository
 	TokenRepo        *TokenRepository
+	EventRepo        *SecurityEventRepository
+	DenylistRepo     *DenylistRepository
+	RoleRepo         *RoleRepository
+	ClientRepo       *ClientRepository
+	CodeRepo         *AuthorizationCodeRepository
+	DeviceRepo       *DeviceAuthorizationRepository
+	AttemptRepo      *LoginAttemptRepository
+	RateLimitRepo    *RateLimitRepository
+	MFARepo          *MFARepository
+	PasskeyRepo      *PasskeyRepository
 	TestLogin        string
 	TestPassword     string
 	RefreshDuration  time.Duration
@@ -89,11 +99,32 @@ func (s *RepositoryTestSuite) SetupSuite() {
 	// Initialize all repositories
 	s.UserRepo = NewUserRepository(db)
 	s.TokenRepo = NewTokenRepository(db)
+	s.EventRepo = NewSecurityEventRepository(db)
+	s.DenylistRepo = NewDenylistRepository(db)
+	s.RoleRepo = NewRoleRepository(db)
+	s.ClientRepo = NewClientRepository(db)
+	s.CodeRepo = NewAuthorizationCodeRepository(db)
+	s.DeviceRepo = NewDeviceAuthorizationRepository(db)
+	s.AttemptRepo = NewLoginAttemptRepository(db)
+	s.RateLimitRepo = NewRateLimitRepository(db)
+	s.MFARepo = NewMFARepository(db)
+	s.PasskeyRepo = NewPasskeyRepository(db)
 
 }
 
 func (s *RepositoryTestSuite) TearDownTest() {
-	_, err := s.DB.Exec("DELETE FROM refresh_tokens")
+	for _, table := range []string{"authorization_codes", "device_authorizations", "login_attempts", "rate_limits", "recovery_codes", "totp_credentials", "passkey_challenges", "passkeys", "oauth_clients", "user_roles", "role_permissions", "roles", "permissions"} {
+		_, err := s.DB.Exec("DELETE FROM " + table)
+		require.NoError(s.T(), err, "Failed to cleanup "+table)
+	}
+
+	_, err := s.DB.Exec("DELETE FROM access_token_denylist")
+	require.NoError(s.T(), err, "Failed to cleanup access_token_denylist")
+
+	_, err = s.DB.Exec("DELETE FROM security_events")
+	require.NoError(s.T(), err, "Failed to cleanup security_events")
+
+	_, err = s.DB.Exec("DELETE FROM refresh_tokens")
 	require.NoError(s.T(), err, "Failed to cleanup refresh_tokens")
 
 	_, err = s.DB.Exec("DELETE FROM users")
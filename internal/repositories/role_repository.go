@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// roleQuery selects roles with one row per permission; roles without permissions yield a single row with a NULL permission.
+const roleQuery = `SELECT r.id, r.name, r.description, r.created_at, p.name
+	FROM roles r
+	LEFT JOIN role_permissions rp ON rp.role_id = r.id
+	LEFT JOIN permissions p ON p.id = rp.permission_id`
+
+// RoleRepository handles persistence of roles, their permissions and role assignments.
+type RoleRepository struct {
+	db *sql.DB
+}
+
+// NewRoleRepository creates a new role repository instance.
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// CreateRole inserts a role together with its permissions and fills in its ID and creation time.
+// Permissions are created on first use and shared between roles.
+// Returns autherrors.ErrRoleExists if a role with the same name already exists.
+func (r *RoleRepository) CreateRole(role *models.Role) error {
+
+	return withTx(r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRow(`INSERT INTO roles (name, description) VALUES ($1, $2)
+		ON CONFLICT (name) DO NOTHING
+		RETURNING id, created_at`, role.Name, role.Description).Scan(&role.ID, &role.CreatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return autherrors.ErrRoleExists
+		}
+		if err != nil {
+			return autherrors.ErrSaveRole(err)
+		}
+
+		for _, permission := range role.Permissions {
+			var permissionID uuid.UUID
+			err := tx.QueryRow(`INSERT INTO permissions (name) VALUES ($1)
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id`, permission).Scan(&permissionID)
+			if err != nil {
+				return autherrors.ErrSaveRole(err)
+			}
+
+			_, err = tx.Exec(`INSERT INTO role_permissions (role_id, permission_id) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`, role.ID, permissionID)
+			if err != nil {
+				return autherrors.ErrSaveRole(err)
+			}
+		}
+
+		return nil
+	})
+
+}
+
+// FindRole returns the role with the given name and its permissions.
+// Returns nil if no such role exists.
+func (r *RoleRepository) FindRole(name string) (*models.Role, error) {
+
+	rows, err := r.db.Query(roleQuery+` WHERE r.name = $1 ORDER BY p.name`, name)
+	if err != nil {
+		return nil, autherrors.ErrFindRoles(err)
+	}
+
+	roles, err := scanRoles(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(roles) == 0 {
+		return nil, nil
+	}
+	return roles[0], nil
+
+}
+
+// FindUserRoles returns the roles assigned to the user, ordered by name, with their permissions.
+func (r *RoleRepository) FindUserRoles(userID uuid.UUID) ([]*models.Role, error) {
+
+	rows, err := r.db.Query(roleQuery+`
+	JOIN user_roles ur ON ur.role_id = r.id
+	WHERE ur.user_id = $1
+	ORDER BY r.name, p.name`, userID)
+	if err != nil {
+		return nil, autherrors.ErrFindRoles(err)
+	}
+
+	return scanRoles(rows)
+
+}
+
+// AssignRole grants the role to the user. Assigning a role the user already has is not an error.
+func (r *RoleRepository) AssignRole(userID uuid.UUID, roleID uuid.UUID) error {
+
+	_, err := r.db.Exec(`INSERT INTO user_roles (user_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, userID, roleID)
+	if err != nil {
+		return autherrors.ErrAssignRole(err)
+	}
+
+	return nil
+
+}
+
+// UnassignRole takes the role away from the user.
+// Returns autherrors.ErrRoleNotAssigned if the user doesn't have the role.
+func (r *RoleRepository) UnassignRole(userID uuid.UUID, roleID uuid.UUID) error {
+
+	result, err := r.db.Exec(`DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2`, userID, roleID)
+	if err != nil {
+		return autherrors.ErrAssignRole(err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return autherrors.ErrAssignRole(err)
+	}
+	if deleted == 0 {
+		return autherrors.ErrRoleNotAssigned
+	}
+
+	return nil
+
+}
+
+// scanRoles groups the rows of roleQuery into roles. Rows of the same role must be adjacent.
+func scanRoles(rows *sql.Rows) ([]*models.Role, error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var roles []*models.Role
+	for rows.Next() {
+		var role models.Role
+		var permission sql.NullString
+		err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.CreatedAt, &permission)
+		if err != nil {
+			return nil, autherrors.ErrFindRoles(err)
+		}
+
+		if len(roles) == 0 || roles[len(roles)-1].ID != role.ID {
+			roles = append(roles, &role)
+		}
+		if permission.Valid {
+			last := roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, permission.String)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, autherrors.ErrFindRoles(err)
+	}
+
+	return roles, nil
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+type RoleRepositoryTestSuite struct {
+	RepositoryTestSuite
+	TestUser *models.User
+}
+
+func (s *RoleRepositoryTestSuite) SetupSuite() {
+	s.RepositoryTestSuite.SetupSuite()
+
+	user, err := s.UserRepo.CreateUser(s.TestLogin, s.TestPassword)
+	require.NoError(s.T(), err)
+	s.TestUser = user
+}
+
+func (s *RoleRepositoryTestSuite) TestCreateAndFindRole() {
+	role := models.Role{
+		Name:        "editor",
+		Description: "Edits plans",
+		Permissions: []string{"plans:read", "plans:write"},
+	}
+
+	err := s.RoleRepo.CreateRole(&role)
+	require.NoError(s.T(), err)
+	assert.NotZero(s.T(), role.ID)
+
+	found, err := s.RoleRepo.FindRole("editor")
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), found)
+	assert.Equal(s.T(), role.ID, found.ID)
+	assert.Equal(s.T(), "Edits plans", found.Description)
+	assert.ElementsMatch(s.T(), role.Permissions, found.Permissions)
+
+	missing, err := s.RoleRepo.FindRole("missing")
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), missing)
+}
+
+func (s *RoleRepositoryTestSuite) TestCreateExistingRole() {
+	require.NoError(s.T(), s.RoleRepo.CreateRole(&models.Role{Name: "planner", Permissions: []string{"plans:read"}}))
+
+	err := s.RoleRepo.CreateRole(&models.Role{Name: "planner", Permissions: []string{"plans:write"}})
+	assert.ErrorIs(s.T(), err, autherrors.ErrRoleExists)
+
+	found, err := s.RoleRepo.FindRole("planner")
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), found)
+	assert.Equal(s.T(), []string{"plans:read"}, found.Permissions, "the existing role must be left unchanged")
+}
+
+func (s *RoleRepositoryTestSuite) TestRolesShareNamedPermissions() {
+	reader := models.Role{Name: "reader", Permissions: []string{"plans:read"}}
+	editor := models.Role{Name: "editor", Permissions: []string{"plans:read", "plans:write"}}
+
+	require.NoError(s.T(), s.RoleRepo.CreateRole(&reader))
+	require.NoError(s.T(), s.RoleRepo.CreateRole(&editor))
+
+	var count int
+	err := s.DB.QueryRow(`SELECT count(*) FROM permissions`).Scan(&count)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, count)
+}
+
+func (s *RoleRepositoryTestSuite) TestAssignAndUnassignRole() {
+	role := models.Role{Name: "admin", Permissions: []string{"users:manage"}}
+	require.NoError(s.T(), s.RoleRepo.CreateRole(&role))
+
+	err := s.RoleRepo.AssignRole(s.TestUser.ID, role.ID)
+	require.NoError(s.T(), err)
+
+	err = s.RoleRepo.AssignRole(s.TestUser.ID, role.ID)
+	assert.NoError(s.T(), err, "assigning a role twice should succeed")
+
+	roles, err := s.RoleRepo.FindUserRoles(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), roles, 1)
+	assert.Equal(s.T(), "admin", roles[0].Name)
+	assert.Equal(s.T(), []string{"users:manage"}, roles[0].Permissions)
+
+	err = s.RoleRepo.UnassignRole(s.TestUser.ID, role.ID)
+	require.NoError(s.T(), err)
+
+	roles, err = s.RoleRepo.FindUserRoles(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), roles)
+
+	err = s.RoleRepo.UnassignRole(s.TestUser.ID, role.ID)
+	assert.ErrorIs(s.T(), err, autherrors.ErrRoleNotAssigned)
+}
+
+func (s *RoleRepositoryTestSuite) TearDownSuite() {
+	_, err := s.DB.Exec("DELETE FROM users")
+	require.NoError(s.T(), err, "Failed to cleanup users")
+
+	s.RepositoryTestSuite.TearDownSuite()
+}
+
+func TestRoleRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(RoleRepositoryTestSuite))
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// SecurityEventRepository handles security event persistence operations.
+type SecurityEventRepository struct {
+	db *sql.DB
+}
+
+// NewSecurityEventRepository creates a new security event repository instance.
+func NewSecurityEventRepository(db *sql.DB) *SecurityEventRepository {
+	return &SecurityEventRepository{db: db}
+}
+
+// SaveEvent persists a security event and fills in its generated ID and creation time.
+func (r *SecurityEventRepository) SaveEvent(event *models.SecurityEvent) error {
+
+	query := `INSERT INTO security_events (user_id, event_type, token_family_id)
+	VALUES ($1, $2, $3)
+	RETURNING id, created_at`
+
+	err := r.db.QueryRow(query, event.UserID, event.Type, event.TokenFamilyID).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return autherrors.ErrSaveSecurityEvent(err)
+	}
+
+	return nil
+
+}
+
+// FindUserEvents returns the security events recorded for the user, newest first.
+func (r *SecurityEventRepository) FindUserEvents(userID uuid.UUID) ([]*models.SecurityEvent, error) {
+
+	rows, err := r.db.Query(`SELECT id, user_id, event_type, token_family_id, created_at
+	FROM security_events
+	WHERE user_id = $1
+	ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, autherrors.ErrFindSecurityEvents(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var events []*models.SecurityEvent
+	for rows.Next() {
+		var event models.SecurityEvent
+		err := rows.Scan(&event.ID, &event.UserID, &event.Type, &event.TokenFamilyID, &event.CreatedAt)
+		if err != nil {
+			return nil, autherrors.ErrFindSecurityEvents(err)
+		}
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, autherrors.ErrFindSecurityEvents(err)
+	}
+
+	return events, nil
+
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"log"
 
+	"github.com/google/uuid"
+
 	"github.com/breakfront-planner/auth-service/internal/autherrors"
 	"github.com/breakfront-planner/auth-service/internal/models"
 )
@@ -18,44 +20,212 @@ func NewTokenRepository(db *sql.DB) *TokenRepository {
 	return &TokenRepository{db: db}
 }
 
-// SaveToken persists a refresh token to the database.
+// SaveToken persists a refresh token that starts a new session and fills in its creation times.
 func (r *TokenRepository) SaveToken(token *models.Token) error {
+	return insertToken(r.db, token)
+}
+
+// RevokeToken marks a refresh token as revoked by setting its revoked_at timestamp.
+func (r *TokenRepository) RevokeToken(token *models.Token) error {
 
-	_, err := r.db.Exec(`INSERT INTO refresh_tokens (token_hash, user_id, expires_at) VALUES ($1, $2, $3)`,
-		token.HashedValue, token.UserID, token.ExpiresAt)
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE token_hash = $1`, token.HashedValue)
 	if err != nil {
-		return autherrors.ErrSaveToken(err)
+		return autherrors.ErrDeleteToken(err)
 	}
 
 	return nil
 
 }
 
-// RevokeToken marks a refresh token as revoked by setting its revoked_at timestamp.
-func (r *TokenRepository) RevokeToken(token *models.Token) error {
+// RevokeTokenFamily revokes every refresh token that descends from the same login.
+func (r *TokenRepository) RevokeTokenFamily(familyID uuid.UUID) error {
 
-	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE token_hash = $1`, token.HashedValue)
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE family_id = $1 AND revoked_at IS NULL`, familyID)
+	if err != nil {
+		return autherrors.ErrDeleteToken(err)
+	}
+
+	return nil
+
+}
+
+// RevokeAllUserTokens revokes every live refresh token of the user, ending all of their sessions.
+func (r *TokenRepository) RevokeAllUserTokens(userID uuid.UUID) error {
+
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		return autherrors.ErrDeleteToken(err)
+	}
+
+	return nil
+
+}
+
+// RevokeSession revokes the user's session with the given ID, i.e. every live token of that family.
+// Returns autherrors.ErrSessionNotFound if the user has no active session with this ID.
+func (r *TokenRepository) RevokeSession(userID uuid.UUID, sessionID uuid.UUID) error {
+
+	result, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP
+	WHERE user_id = $1 AND family_id = $2 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP`, userID, sessionID)
 	if err != nil {
 		return autherrors.ErrDeleteToken(err)
 	}
 
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return autherrors.ErrDeleteToken(err)
+	}
+
+	if revoked == 0 {
+		return autherrors.ErrSessionNotFound
+	}
+
 	return nil
 
 }
 
-// CheckToken validates a refresh token by verifying it exists, is not revoked, and has not expired.
+// FindActiveTokens returns the user's refresh tokens that are neither revoked nor expired, newest first.
+// Each returned token is the current one of its session.
+func (r *TokenRepository) FindActiveTokens(userID uuid.UUID) ([]*models.Token, error) {
+
+	query := `SELECT ` + tokenColumns + `
+	FROM refresh_tokens
+	WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+	ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, autherrors.ErrFindSessions(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var tokens []*models.Token
+	for rows.Next() {
+		var token models.Token
+		if err := rows.Scan(tokenFields(&token)...); err != nil {
+			return nil, autherrors.ErrFindSessions(err)
+		}
+		tokens = append(tokens, &token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, autherrors.ErrFindSessions(err)
+	}
+
+	return tokens, nil
+
+}
+
+// FindToken validates a refresh token by verifying it exists and is not revoked, and fills in its stored fields.
+// A revoked token returns an error wrapping autherrors.ErrTokenRevoked, so callers can detect reuse.
 func (r *TokenRepository) FindToken(token *models.Token) error {
+	return findToken(r.db, token, false)
+}
+
+// SaveTokenForCode saves the refresh token starting a session by exchanging the authorization code with the given
+// hash and records the token's family on the code. Both happen in one transaction, so a session started with a code
+// is always found, and revoked, if the code is presented again.
+func (r *TokenRepository) SaveTokenForCode(token *models.Token, codeHash string) error {
+	return withTx(r.db, func(tx *sql.Tx) error {
+		if err := insertToken(tx, token); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`UPDATE authorization_codes SET token_family_id = $2 WHERE code_hash = $1`, codeHash, token.FamilyID)
+		if err != nil {
+			return autherrors.ErrSaveAuthorizationCode(err)
+		}
+
+		return nil
+	})
+}
+
+// RotateToken atomically revokes oldToken and saves newToken in its family.
+// The new token continues the old token's session: session metadata not set on newToken is carried over.
+// The old token's row is locked for the duration of the transaction, so of several concurrent
+// rotations of the same token exactly one succeeds; the others see it revoked.
+func (r *TokenRepository) RotateToken(oldToken, newToken *models.Token) error {
+	return withTx(r.db, func(tx *sql.Tx) error {
+		err := findToken(tx, oldToken, true)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE token_hash = $1`, oldToken.HashedValue)
+		if err != nil {
+			return autherrors.ErrDeleteToken(err)
+		}
+
+		newToken.FamilyID = oldToken.FamilyID
+		newToken.SessionStartedAt = oldToken.SessionStartedAt
+		if newToken.Session.UserAgent == "" {
+			newToken.Session.UserAgent = oldToken.Session.UserAgent
+		}
+		if newToken.Session.IPAddress == "" {
+			newToken.Session.IPAddress = oldToken.Session.IPAddress
+		}
+		if newToken.Session.DeviceName == "" {
+			newToken.Session.DeviceName = oldToken.Session.DeviceName
+		}
+
+		return insertToken(tx, newToken)
+	})
+}
+
+const tokenColumns = `token_hash, user_id, family_id, user_agent, ip_address, device_name,
+	created_at, session_started_at, expires_at, revoked_at`
+
+// tokenFields returns the scan destinations matching tokenColumns.
+func tokenFields(token *models.Token) []any {
+	return []any{
+		&token.HashedValue, &token.UserID, &token.FamilyID,
+		&token.Session.UserAgent, &token.Session.IPAddress, &token.Session.DeviceName,
+		&token.CreatedAt, &token.SessionStartedAt, &token.ExpiresAt, &token.RevokedAt,
+	}
+}
+
+// insertToken saves the token; a zero SessionStartedAt starts the session now.
+func insertToken(q querier, token *models.Token) error {
+
+	var sessionStartedAt sql.NullTime
+	if !token.SessionStartedAt.IsZero() {
+		sessionStartedAt = sql.NullTime{Time: token.SessionStartedAt, Valid: true}
+	}
+
+	query := `INSERT INTO refresh_tokens
+	(token_hash, user_id, family_id, user_agent, ip_address, device_name, session_started_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, now()), $8)
+	RETURNING created_at, session_started_at`
+
+	err := q.QueryRow(query,
+		token.HashedValue, token.UserID, token.FamilyID,
+		token.Session.UserAgent, token.Session.IPAddress, token.Session.DeviceName,
+		sessionStartedAt, token.ExpiresAt,
+	).Scan(&token.CreatedAt, &token.SessionStartedAt)
+	if err != nil {
+		return autherrors.ErrSaveToken(err)
+	}
+
+	return nil
+
+}
+
+func findToken(q querier, token *models.Token, forUpdate bool) error {
 
 	var dbToken models.Token
 
-	query := `SELECT user_id, expires_at, revoked_at 
+	query := `SELECT ` + tokenColumns + `
 	FROM refresh_tokens 
 	WHERE token_hash = $1`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
 
-	err := r.db.QueryRow(query, token.HashedValue).Scan(
-		&dbToken.UserID, &dbToken.ExpiresAt, &dbToken.RevokedAt)
+	err := q.QueryRow(query, token.HashedValue).Scan(tokenFields(&dbToken)...)
 
-	if err == sql.ErrNoRows || dbToken.UserID != token.UserID || dbToken.RevokedAt != nil {
+	if err == sql.ErrNoRows || (err == nil && dbToken.UserID != token.UserID) {
 		log.Printf("invalid token: %v ", err)
 		return autherrors.ErrInvalidToken(err)
 	}
@@ -64,6 +234,14 @@ func (r *TokenRepository) FindToken(token *models.Token) error {
 		return autherrors.ErrCheckToken(err)
 	}
 
+	dbToken.Value = token.Value
+	*token = dbToken
+
+	if dbToken.RevokedAt != nil {
+		log.Printf("revoked token presented, family %v", dbToken.FamilyID)
+		return autherrors.ErrInvalidToken(autherrors.ErrTokenRevoked)
+	}
+
 	return nil
 
 }
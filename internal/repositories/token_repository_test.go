@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,6 +12,8 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
 	"github.com/breakfront-planner/auth-service/internal/models"
 )
 
@@ -157,8 +161,289 @@ func (s *TokenRepositoryTestSuite) TestRevokeNonExistentToken() {
 	require.NoError(s.T(), err)
 }
 
+func (s *TokenRepositoryTestSuite) TestFindTokenFillsFamily() {
+	token := models.Token{
+		HashedValue: s.TokenHashedValue,
+		UserID:      s.TestUser.ID,
+		FamilyID:    uuid.New(),
+		ExpiresAt:   time.Now().UTC().Add(s.RefreshDuration),
+	}
+
+	err := s.TokenRepo.SaveToken(&token)
+	require.NoError(s.T(), err)
+
+	found := models.Token{
+		HashedValue: token.HashedValue,
+		UserID:      token.UserID,
+	}
+	err = s.TokenRepo.FindToken(&found)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), token.FamilyID, found.FamilyID)
+
+	err = s.TokenRepo.RevokeToken(&token)
+	require.NoError(s.T(), err)
+
+	revoked := models.Token{
+		HashedValue: token.HashedValue,
+		UserID:      token.UserID,
+	}
+	err = s.TokenRepo.FindToken(&revoked)
+	require.Error(s.T(), err)
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenRevoked)
+	assert.Equal(s.T(), token.FamilyID, revoked.FamilyID)
+}
+
+func (s *TokenRepositoryTestSuite) TestRevokeTokenFamily() {
+	familyID := uuid.New()
+	family := []models.Token{
+		{HashedValue: s.TokenHashedValue[:30] + "first", UserID: s.TestUser.ID, FamilyID: familyID},
+		{HashedValue: s.TokenHashedValue[:30] + "second", UserID: s.TestUser.ID, FamilyID: familyID},
+	}
+	other := models.Token{HashedValue: s.TokenHashedValue[:30] + "other", UserID: s.TestUser.ID, FamilyID: uuid.New()}
+
+	for _, token := range append(family, other) {
+		token.ExpiresAt = time.Now().UTC().Add(s.RefreshDuration)
+		err := s.TokenRepo.SaveToken(&token)
+		require.NoError(s.T(), err)
+	}
+
+	err := s.TokenRepo.RevokeTokenFamily(familyID)
+	require.NoError(s.T(), err)
+
+	for _, token := range family {
+		err := s.TokenRepo.FindToken(&token)
+		assert.ErrorIs(s.T(), err, autherrors.ErrTokenRevoked)
+	}
+
+	err = s.TokenRepo.FindToken(&other)
+	assert.NoError(s.T(), err, "tokens of other families must stay valid")
+}
+
+func (s *TokenRepositoryTestSuite) TestRotateTokenSuccess() {
+	oldToken := models.Token{
+		HashedValue: s.TokenHashedValue,
+		UserID:      s.TestUser.ID,
+		FamilyID:    uuid.New(),
+		ExpiresAt:   time.Now().UTC().Add(s.RefreshDuration),
+	}
+
+	err := s.TokenRepo.SaveToken(&oldToken)
+	require.NoError(s.T(), err)
+
+	newToken := models.Token{
+		HashedValue: s.TokenHashedValue[:30] + "rotated",
+		UserID:      s.TestUser.ID,
+		ExpiresAt:   time.Now().UTC().Add(s.RefreshDuration),
+	}
+
+	err = s.TokenRepo.RotateToken(&models.Token{HashedValue: oldToken.HashedValue, UserID: oldToken.UserID}, &newToken)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), oldToken.FamilyID, newToken.FamilyID)
+
+	err = s.TokenRepo.FindToken(&oldToken)
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenRevoked)
+
+	err = s.TokenRepo.FindToken(&newToken)
+	assert.NoError(s.T(), err)
+}
+
+func (s *TokenRepositoryTestSuite) TestRotateTokenRollsBackOnInsertError() {
+	oldToken := models.Token{
+		HashedValue: s.TokenHashedValue,
+		UserID:      s.TestUser.ID,
+		FamilyID:    uuid.New(),
+		ExpiresAt:   time.Now().UTC().Add(s.RefreshDuration),
+	}
+
+	err := s.TokenRepo.SaveToken(&oldToken)
+	require.NoError(s.T(), err)
+
+	// Reusing the old hash violates the unique constraint, so the insert fails after the revoke.
+	duplicate := models.Token{
+		HashedValue: oldToken.HashedValue,
+		UserID:      s.TestUser.ID,
+		ExpiresAt:   time.Now().UTC().Add(s.RefreshDuration),
+	}
+
+	err = s.TokenRepo.RotateToken(&models.Token{HashedValue: oldToken.HashedValue, UserID: oldToken.UserID}, &duplicate)
+	require.Error(s.T(), err)
+	assert.ErrorContains(s.T(), err, "failed to save token")
+
+	err = s.TokenRepo.FindToken(&oldToken)
+	assert.NoError(s.T(), err, "revocation must be rolled back together with the failed insert")
+}
+
+func (s *TokenRepositoryTestSuite) TestRotateTokenConcurrentOnlyOneWins() {
+	oldToken := models.Token{
+		HashedValue: s.TokenHashedValue,
+		UserID:      s.TestUser.ID,
+		FamilyID:    uuid.New(),
+		ExpiresAt:   time.Now().UTC().Add(s.RefreshDuration),
+	}
+
+	err := s.TokenRepo.SaveToken(&oldToken)
+	require.NoError(s.T(), err)
+
+	const attempts = 10
+	errs := make([]error, attempts)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+
+			newToken := models.Token{
+				HashedValue: fmt.Sprintf("%s%02d", s.TokenHashedValue[:30], i),
+				UserID:      s.TestUser.ID,
+				ExpiresAt:   time.Now().UTC().Add(s.RefreshDuration),
+			}
+			errs[i] = s.TokenRepo.RotateToken(&models.Token{HashedValue: oldToken.HashedValue, UserID: oldToken.UserID}, &newToken)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(s.T(), err, autherrors.ErrTokenRevoked)
+	}
+	assert.Equal(s.T(), 1, succeeded, "exactly one concurrent rotation must win")
+
+	var liveTokens int
+	err = s.DB.QueryRow(`SELECT COUNT(*) FROM refresh_tokens WHERE family_id = $1 AND revoked_at IS NULL`, oldToken.FamilyID).
+		Scan(&liveTokens)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, liveTokens)
+}
+
+func (s *TokenRepositoryTestSuite) TestRotateTokenKeepsSession() {
+	oldToken := models.Token{
+		HashedValue: s.TokenHashedValue,
+		UserID:      s.TestUser.ID,
+		FamilyID:    uuid.New(),
+		Session:     models.SessionMetadata{UserAgent: "planner-ios/2.0", IPAddress: "203.0.113.7", DeviceName: "iPhone"},
+		ExpiresAt:   time.Now().UTC().Add(s.RefreshDuration),
+	}
+
+	err := s.TokenRepo.SaveToken(&oldToken)
+	require.NoError(s.T(), err)
+	assert.False(s.T(), oldToken.SessionStartedAt.IsZero())
+
+	newToken := models.Token{
+		HashedValue: s.TokenHashedValue[:30] + "rotated",
+		UserID:      s.TestUser.ID,
+		Session:     models.SessionMetadata{UserAgent: "planner-ios/2.1", IPAddress: "198.51.100.4"},
+		ExpiresAt:   time.Now().UTC().Add(s.RefreshDuration),
+	}
+
+	err = s.TokenRepo.RotateToken(&models.Token{HashedValue: oldToken.HashedValue, UserID: oldToken.UserID}, &newToken)
+	require.NoError(s.T(), err)
+
+	tokens, err := s.TokenRepo.FindActiveTokens(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), tokens, 1)
+	assert.Equal(s.T(), oldToken.FamilyID, tokens[0].FamilyID)
+	assert.Equal(s.T(), "planner-ios/2.1", tokens[0].Session.UserAgent)
+	assert.Equal(s.T(), "198.51.100.4", tokens[0].Session.IPAddress)
+	assert.Equal(s.T(), "iPhone", tokens[0].Session.DeviceName, "device name is carried over")
+	assert.True(s.T(), oldToken.SessionStartedAt.Equal(tokens[0].SessionStartedAt))
+	assert.False(s.T(), tokens[0].CreatedAt.Before(tokens[0].SessionStartedAt))
+}
+
+func (s *TokenRepositoryTestSuite) TestFindActiveTokensAndRevokeSession() {
+	phone := models.Token{
+		HashedValue: s.TokenHashedValue[:30] + "phone",
+		UserID:      s.TestUser.ID,
+		FamilyID:    uuid.New(),
+		ExpiresAt:   time.Now().UTC().Add(s.RefreshDuration),
+	}
+	laptop := models.Token{
+		HashedValue: s.TokenHashedValue[:30] + "laptop",
+		UserID:      s.TestUser.ID,
+		FamilyID:    uuid.New(),
+		ExpiresAt:   time.Now().UTC().Add(s.RefreshDuration),
+	}
+	expired := models.Token{
+		HashedValue: s.TokenHashedValue[:30] + "expired",
+		UserID:      s.TestUser.ID,
+		FamilyID:    uuid.New(),
+		ExpiresAt:   time.Now().UTC().Add(-s.RefreshDuration),
+	}
+
+	for _, token := range []*models.Token{&phone, &laptop, &expired} {
+		require.NoError(s.T(), s.TokenRepo.SaveToken(token))
+	}
+
+	tokens, err := s.TokenRepo.FindActiveTokens(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	assert.Len(s.T(), tokens, 2)
+
+	err = s.TokenRepo.RevokeSession(uuid.New(), phone.FamilyID)
+	assert.ErrorIs(s.T(), err, autherrors.ErrSessionNotFound, "sessions of other users cannot be revoked")
+
+	err = s.TokenRepo.RevokeSession(s.TestUser.ID, expired.FamilyID)
+	assert.ErrorIs(s.T(), err, autherrors.ErrSessionNotFound)
+
+	err = s.TokenRepo.RevokeSession(s.TestUser.ID, phone.FamilyID)
+	require.NoError(s.T(), err)
+
+	tokens, err = s.TokenRepo.FindActiveTokens(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), tokens, 1)
+	assert.Equal(s.T(), laptop.FamilyID, tokens[0].FamilyID)
+}
+
+func (s *TokenRepositoryTestSuite) TestRevokeAllUserTokens() {
+	for _, suffix := range []string{"phone", "laptop"} {
+		token := models.Token{
+			HashedValue: s.TokenHashedValue[:30] + suffix,
+			UserID:      s.TestUser.ID,
+			FamilyID:    uuid.New(),
+			ExpiresAt:   time.Now().UTC().Add(s.RefreshDuration),
+		}
+		require.NoError(s.T(), s.TokenRepo.SaveToken(&token))
+	}
+
+	err := s.TokenRepo.RevokeAllUserTokens(s.TestUser.ID)
+	require.NoError(s.T(), err)
+
+	tokens, err := s.TokenRepo.FindActiveTokens(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), tokens)
+}
+
+func (s *TokenRepositoryTestSuite) TestSaveSecurityEvent() {
+	familyID := uuid.New()
+	event := models.SecurityEvent{
+		UserID:        s.TestUser.ID,
+		Type:          string(constants.SecurityEventRefreshTokenReuse),
+		TokenFamilyID: &familyID,
+	}
+
+	err := s.EventRepo.SaveEvent(&event)
+	require.NoError(s.T(), err)
+	assert.NotEqual(s.T(), uuid.Nil, event.ID)
+
+	events, err := s.EventRepo.FindUserEvents(s.TestUser.ID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), events, 1)
+	assert.Equal(s.T(), event.Type, events[0].Type)
+	require.NotNil(s.T(), events[0].TokenFamilyID)
+	assert.Equal(s.T(), familyID, *events[0].TokenFamilyID)
+}
+
 func (s *TokenRepositoryTestSuite) TearDownTest() {
-	_, err := s.DB.Exec("DELETE FROM refresh_tokens")
+	_, err := s.DB.Exec("DELETE FROM security_events")
+	require.NoError(s.T(), err, "Failed to cleanup security_events")
+
+	_, err = s.DB.Exec("DELETE FROM refresh_tokens")
 	require.NoError(s.T(), err, "Failed to cleanup refresh_tokens")
 }
 
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx, so the same queries can run inside or outside a transaction.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// withTx runs fn inside a transaction, committing if fn succeeds and rolling back otherwise.
+// Errors returned by fn are passed through unchanged so callers can still match them.
+func withTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return autherrors.ErrDBTransactionFailed(err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return autherrors.ErrDBTransactionFailed(err)
+	}
+
+	return nil
+}
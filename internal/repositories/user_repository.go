@@ -4,9 +4,11 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/breakfront-planner/auth-service/internal/autherrors"
 	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/google/uuid"
 )
 
 // UserRepository handles user data persistence operations.
@@ -25,11 +27,11 @@ func (r *UserRepository) CreateUser(login string, passHash string) (*models.User
 	query := `
         INSERT INTO users (login, password_hash)
         VALUES ($1, $2)
-        RETURNING id, login, password_hash, created_at, updated_at
+        RETURNING id, login, password_hash, created_at, updated_at, tokens_valid_after
     `
 
 	err := r.db.QueryRow(query, login, passHash).Scan(
-		&user.ID, &user.Login, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
+		&user.ID, &user.Login, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt, &user.TokensValidAfter)
 
 	if err != nil {
 		return nil, autherrors.ErrFailToCreateUser(err)
@@ -53,11 +55,11 @@ func (r *UserRepository) FindUser(filter *models.UserFilter) (*models.User, erro
 		conditions = append(conditions, fmt.Sprintf("%s = $%d", value.DBName, i+1))
 		args = append(args, value.Value)
 	}
-	query := `SELECT id, login, password_hash, created_at, updated_at FROM users WHERE ` + strings.Join(conditions, " AND ")
+	query := `SELECT id, login, password_hash, created_at, updated_at, tokens_valid_after FROM users WHERE ` + strings.Join(conditions, " AND ")
 
 	var user models.User
 	err = r.db.QueryRow(query, args...).Scan(
-		&user.ID, &user.Login, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
+		&user.ID, &user.Login, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt, &user.TokensValidAfter)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -67,3 +69,49 @@ func (r *UserRepository) FindUser(filter *models.UserFilter) (*models.User, erro
 	}
 	return &user, nil
 }
+
+// InvalidateTokens sets the user's token cutoff so that every token issued before validAfter is rejected.
+func (r *UserRepository) InvalidateTokens(userID uuid.UUID, validAfter time.Time) error {
+	query := `
+        UPDATE users SET tokens_valid_after = $2, updated_at = now()
+        WHERE id = $1
+    `
+
+	result, err := r.db.Exec(query, userID, validAfter)
+	if err != nil {
+		return autherrors.ErrUpdateUser(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return autherrors.ErrUpdateUser(err)
+	}
+	if rows == 0 {
+		return autherrors.ErrUpdateUser(autherrors.ErrUserNotExist)
+	}
+
+	return nil
+}
+
+// UpdatePasswordHash replaces the stored password hash of the user, e.g. after rehashing it with newer parameters.
+func (r *UserRepository) UpdatePasswordHash(userID uuid.UUID, passHash string) error {
+	query := `
+        UPDATE users SET password_hash = $2, updated_at = now()
+        WHERE id = $1
+    `
+
+	result, err := r.db.Exec(query, userID, passHash)
+	if err != nil {
+		return autherrors.ErrUpdateUser(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return autherrors.ErrUpdateUser(err)
+	}
+	if rows == 0 {
+		return autherrors.ErrUpdateUser(autherrors.ErrUserNotExist)
+	}
+
+	return nil
+}
@@ -2,12 +2,14 @@ package repositories
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
 	"github.com/breakfront-planner/auth-service/internal/models"
 )
 
@@ -118,6 +120,40 @@ func (s *UserRepositoryTestSuite) TestFindWithEmptyFilter() {
 	assert.Nil(s.T(), user, "User should be nil when filter is empty")
 }
 
+func (s *UserRepositoryTestSuite) TestInvalidateTokens() {
+	createdUser, err := s.UserRepo.CreateUser(s.TestLogin, s.TestPassword)
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), createdUser.TokensValidAfter)
+
+	validAfter := time.Now().UTC().Truncate(time.Second).Add(time.Second)
+	err = s.UserRepo.InvalidateTokens(createdUser.ID, validAfter)
+	require.NoError(s.T(), err)
+
+	user, err := s.UserRepo.FindUser(&models.UserFilter{ID: &createdUser.ID})
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), user.TokensValidAfter)
+	assert.True(s.T(), validAfter.Equal(*user.TokensValidAfter))
+
+	err = s.UserRepo.InvalidateTokens(uuid.New(), validAfter)
+	assert.ErrorIs(s.T(), err, autherrors.ErrUserNotExist)
+}
+
+func (s *UserRepositoryTestSuite) TestUpdatePasswordHash() {
+	createdUser, err := s.UserRepo.CreateUser(s.TestLogin, s.TestPassword)
+	require.NoError(s.T(), err)
+
+	newHash := "$argon2id$v=19$m=19456,t=2,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5"
+	err = s.UserRepo.UpdatePasswordHash(createdUser.ID, newHash)
+	require.NoError(s.T(), err)
+
+	user, err := s.UserRepo.FindUser(&models.UserFilter{ID: &createdUser.ID})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), newHash, user.PasswordHash)
+
+	err = s.UserRepo.UpdatePasswordHash(uuid.New(), newHash)
+	assert.ErrorIs(s.T(), err, autherrors.ErrUserNotExist)
+}
+
 func (s *UserRepositoryTestSuite) TearDownTest() {
 
 	_, err := s.DB.Exec("DELETE FROM users")
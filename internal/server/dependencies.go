@@ -0,0 +1,266 @@
+package server
+
+import (
+	"database/sql"
+
+	"github.com/breakfront-planner/auth-service/internal/configs"
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+	"github.com/breakfront-planner/auth-service/internal/password"
+	"github.com/breakfront-planner/auth-service/internal/ratelimit"
+	"github.com/breakfront-planner/auth-service/internal/repositories"
+	"github.com/breakfront-planner/auth-service/internal/services"
+	"github.com/breakfront-planner/auth-service/internal/totp"
+	"github.com/breakfront-planner/auth-service/internal/validators"
+	"github.com/breakfront-planner/auth-service/internal/webauthn"
+)
+
+// Dependencies holds the wired application components shared by the transport layers.
+type Dependencies struct {
+	UserRepo        *repositories.UserRepository
+	TokenRepo       *repositories.TokenRepository
+	EventRepo       *repositories.SecurityEventRepository
+	DenylistRepo    *repositories.DenylistRepository
+	RoleRepo        *repositories.RoleRepository
+	ClientRepo      *repositories.ClientRepository
+	CodeRepo        *repositories.AuthorizationCodeRepository
+	DeviceRepo      *repositories.DeviceAuthorizationRepository
+	AttemptRepo     *repositories.LoginAttemptRepository
+	RateLimitRepo   *repositories.RateLimitRepository
+	MFARepo         *repositories.MFARepository
+	PasskeyRepo     *repositories.PasskeyRepository
+	JWTManager      *jwt.Manager
+	HashService     *services.HashService
+	UserService     *services.UserService
+	TokenService    *services.TokenService
+	Denylist        *services.DenylistService
+	RoleService     *services.RoleService
+	ClientService   *services.ClientService
+	OAuthService    *services.OAuthService
+	DeviceService   *services.DeviceAuthorizationService
+	ExchangeService *services.TokenExchangeService
+	LockoutService  *services.LockoutService
+	MFAService      *services.MFAService
+	TokenValidator  *validators.TokenValidator
+	AuthService     *services.AuthService
+	// PasskeyService is nil if passkeys are disabled.
+	PasskeyService *services.PasskeyService
+	// IPLimiter, LoginLimiter and ClientLimiter rate limit the authentication endpoints;
+	// they are nil if their limit is disabled.
+	IPLimiter     *ratelimit.Limiter
+	LoginLimiter  *ratelimit.Limiter
+	ClientLimiter *ratelimit.Limiter
+}
+
+// NewDependencies builds the repository, service and validator graph on top of the given database.
+// It fails if the configured signing or verification keys or the breached password list cannot be loaded,
+// or if the MFA encryption key is invalid.
+func NewDependencies(db *sql.DB, cfg *configs.Config) (*Dependencies, error) {
+	keySet, err := loadKeySet(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordPolicy, err := newPasswordPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	userRepo := repositories.NewUserRepository(db)
+	tokenRepo := repositories.NewTokenRepository(db)
+	eventRepo := repositories.NewSecurityEventRepository(db)
+	denylistRepo := repositories.NewDenylistRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	clientRepo := repositories.NewClientRepository(db)
+	codeRepo := repositories.NewAuthorizationCodeRepository(db)
+	deviceRepo := repositories.NewDeviceAuthorizationRepository(db)
+	attemptRepo := repositories.NewLoginAttemptRepository(db)
+	rateLimitRepo := repositories.NewRateLimitRepository(db)
+	mfaRepo := repositories.NewMFARepository(db)
+	passkeyRepo := repositories.NewPasskeyRepository(db)
+
+	jwtManager := jwt.NewManagerWithKeySet(keySet, cfg.AccessDuration, cfg.RefreshDuration,
+		jwt.WithIssuer(cfg.JWTIssuer), jwt.WithAudience(cfg.JWTAudience...), jwt.WithClientAudience(clientAudience(cfg)...),
+		jwt.WithLeeway(cfg.JWTLeeway), jwt.WithChallengeDuration(cfg.MFAChallengeDuration))
+	hashService := services.NewHashService(services.WithArgon2Params(services.Argon2Params{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+		SaltLength:  services.DefaultArgon2Params.SaltLength,
+		KeyLength:   services.DefaultArgon2Params.KeyLength,
+	}))
+	userService := services.NewUserService(userRepo, hashService, services.WithPasswordPolicy(passwordPolicy))
+	tokenService := services.NewTokenService(tokenRepo, eventRepo, hashService, jwtManager)
+	denylist := services.NewDenylistService(denylistRepo)
+	tokenValidator := validators.NewTokenValidator(jwtManager, userService, denylist)
+	roleService := services.NewRoleService(roleRepo)
+	lockoutService := services.NewLockoutService(attemptRepo, services.LockoutPolicy{
+		LoginThreshold: cfg.LockoutLoginThreshold,
+		IPThreshold:    cfg.LockoutIPThreshold,
+		Duration:       cfg.LockoutDuration,
+		MaxDuration:    cfg.LockoutMaxDuration,
+		FailureWindow:  cfg.LockoutFailureWindow,
+	})
+	mfaService, err := newMFAService(cfg, mfaRepo, userService, hashService, lockoutService)
+	if err != nil {
+		return nil, err
+	}
+	authOpts := []services.AuthServiceOption{services.WithLockout(lockoutService), services.WithMFA(mfaService)}
+	passkeyService := newPasskeyService(cfg, passkeyRepo, eventRepo, userService, hashService)
+	if passkeyService != nil {
+		authOpts = append(authOpts, services.WithPasskeys(passkeyService))
+	}
+	authService := services.NewAuthService(tokenService, userService, tokenValidator, denylist, roleService, authOpts...)
+	clientService := services.NewClientService(clientRepo, hashService, cfg.IntrospectionClients)
+	var oauthOpts []services.OAuthServiceOption
+	if openIDConnectEnabled(cfg) {
+		oauthOpts = append(oauthOpts, services.WithOpenIDConnect())
+	}
+	oauthService := services.NewOAuthService(clientService, codeRepo, authService, userService, roleService,
+		tokenService, hashService, cfg.AuthorizationCodeDuration, oauthOpts...)
+	// Users enter device codes on a frontend page, so the grant can't be completed without one
+	var deviceService *services.DeviceAuthorizationService
+	if cfg.DeviceVerificationURI != "" {
+		deviceService = services.NewDeviceAuthorizationService(clientService, deviceRepo, userService, roleService,
+			tokenService, hashService, cfg.DeviceVerificationURI, cfg.DeviceCodeDuration, cfg.DevicePollInterval)
+	}
+	exchangeService := services.NewTokenExchangeService(clientService, tokenValidator, tokenService,
+		cfg.TokenExchangeAudiences)
+
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimitStore == ratelimit.StorePostgres {
+		rateLimitStore = rateLimitRepo
+	}
+
+	return &Dependencies{
+		UserRepo:        userRepo,
+		TokenRepo:       tokenRepo,
+		EventRepo:       eventRepo,
+		DenylistRepo:    denylistRepo,
+		RoleRepo:        roleRepo,
+		ClientRepo:      clientRepo,
+		CodeRepo:        codeRepo,
+		DeviceRepo:      deviceRepo,
+		AttemptRepo:     attemptRepo,
+		RateLimitRepo:   rateLimitRepo,
+		MFARepo:         mfaRepo,
+		PasskeyRepo:     passkeyRepo,
+		JWTManager:      jwtManager,
+		HashService:     hashService,
+		UserService:     userService,
+		TokenService:    tokenService,
+		Denylist:        denylist,
+		RoleService:     roleService,
+		ClientService:   clientService,
+		OAuthService:    oauthService,
+		DeviceService:   deviceService,
+		ExchangeService: exchangeService,
+		LockoutService:  lockoutService,
+		MFAService:      mfaService,
+		PasskeyService:  passkeyService,
+		TokenValidator:  tokenValidator,
+		AuthService:     authService,
+		IPLimiter:       newLimiter("ip", cfg.RateLimitAlgorithm, cfg.RateLimitIP, rateLimitStore),
+		LoginLimiter:    newLimiter("login", cfg.RateLimitAlgorithm, cfg.RateLimitLogin, rateLimitStore),
+		ClientLimiter:   newLimiter("client", cfg.RateLimitAlgorithm, cfg.RateLimitClient, rateLimitStore),
+	}, nil
+}
+
+// openIDConnectEnabled reports whether the service acts as an OpenID Connect provider. Clients compare the issuer
+// with the iss claim of ID tokens and verify them with the keys of the JWKS, which never has symmetric keys,
+// so it takes a configured issuer and an asymmetric signing algorithm.
+func openIDConnectEnabled(cfg *configs.Config) bool {
+	return cfg.JWTIssuer != "" && !jwt.IsHMAC(cfg.JWTAlgorithm)
+}
+
+// clientAudience returns the audience of tokens issued to users signed in to an OAuth client:
+// the configured services without the auth service, whose account endpoints are for the user only.
+func clientAudience(cfg *configs.Config) []string {
+	var audience []string
+	for _, service := range cfg.JWTAudience {
+		if service != cfg.AuthAudience {
+			audience = append(audience, service)
+		}
+	}
+	return audience
+}
+
+// loadKeySet loads the active signing key and the keys kept for verifying tokens issued before a rotation.
+func loadKeySet(cfg *configs.Config) (*jwt.KeySet, error) {
+	signingKey, err := jwt.LoadSigningKey(cfg.JWTAlgorithm, cfg.JWTSecret, cfg.JWTPrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	verificationKeys := make([]*jwt.SigningKey, 0, len(cfg.JWTVerificationKeys))
+	for _, keyConfig := range cfg.JWTVerificationKeys {
+		key, err := jwt.LoadPublicKey(keyConfig.Algorithm, keyConfig.PublicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	return jwt.NewKeySet(signingKey, verificationKeys...)
+}
+
+// newPasswordPolicy creates the password policy for new passwords, screening them against the breached password list
+// if one is configured.
+func newPasswordPolicy(cfg *configs.Config) (*password.Policy, error) {
+	opts := []password.PolicyOption{
+		password.WithMinLength(cfg.PasswordMinLength),
+		password.WithMaxLength(cfg.PasswordMaxLength),
+		password.WithMinCharacterClasses(cfg.PasswordMinCharacterClasses),
+		password.WithMinStrength(cfg.PasswordMinStrength),
+	}
+
+	if cfg.PasswordBreachList != "" {
+		breachList, err := password.OpenBreachList(cfg.PasswordBreachList)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, password.WithBreachList(breachList))
+	}
+
+	return password.NewPolicy(opts...), nil
+}
+
+// newMFAService creates the two-factor authentication service, counting wrong codes with the lockout and
+// encrypting TOTP secrets if a key is configured.
+func newMFAService(cfg *configs.Config, mfaRepo *repositories.MFARepository, userService *services.UserService,
+	hashService *services.HashService, lockoutService *services.LockoutService) (*services.MFAService, error) {
+	opts := []services.MFAServiceOption{services.WithMFALockout(lockoutService)}
+	if len(cfg.MFAEncryptionKey) > 0 {
+		cipher, err := totp.NewCipher(cfg.MFAEncryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, services.WithSecretCipher(cipher))
+	}
+
+	return services.NewMFAService(mfaRepo, userService, hashService, cfg.MFAIssuer, opts...), nil
+}
+
+// newLimiter creates a rate limiter with the configured algorithm, or returns nil if the rate disables it.
+func newLimiter(name string, algorithm string, rate ratelimit.Rate, store ratelimit.Store) *ratelimit.Limiter {
+	if rate.IsZero() {
+		return nil
+	}
+	return ratelimit.NewLimiter(name, ratelimit.NewAlgorithm(algorithm, rate), store)
+}
+
+// newPasskeyService creates the passkey service for the configured relying party, or returns nil if passkeys are disabled.
+func newPasskeyService(cfg *configs.Config, passkeyRepo *repositories.PasskeyRepository,
+	eventRepo *repositories.SecurityEventRepository, userService *services.UserService,
+	hashService *services.HashService) *services.PasskeyService {
+	if cfg.WebAuthnRPID == "" {
+		return nil
+	}
+
+	relyingParty := &webauthn.RelyingParty{
+		ID:      cfg.WebAuthnRPID,
+		Name:    cfg.WebAuthnRPName,
+		Origins: cfg.WebAuthnOrigins,
+	}
+	return services.NewPasskeyService(passkeyRepo, eventRepo, userService, hashService, relyingParty,
+		cfg.WebAuthnChallengeDuration)
+}
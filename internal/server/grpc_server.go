@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+
+	"github.com/breakfront-planner/auth-service/internal/clientip"
+	"github.com/breakfront-planner/auth-service/internal/configs"
+	"github.com/breakfront-planner/auth-service/internal/grpchandlers"
+	"github.com/breakfront-planner/auth-service/internal/ratelimit"
+	authv1 "github.com/breakfront-planner/auth-service/pkg/api/auth/v1"
+	"github.com/breakfront-planner/auth-service/pkg/authmw"
+)
+
+// GRPCServer serves the gRPC API on top of the wired dependencies.
+type GRPCServer struct {
+	server *grpc.Server
+	addr   string
+}
+
+// NewGRPCServer creates a gRPC server exposing the AuthService API.
+func NewGRPCServer(cfg *configs.Config, deps *Dependencies) *GRPCServer {
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			grpchandlers.UnaryClientIPInterceptor(clientip.NewResolver(cfg.TrustedProxies)),
+			grpchandlers.UnaryRateLimitInterceptor(grpcRateLimits(deps)),
+			authmw.UnaryServerInterceptor(deps.TokenValidator,
+				authmw.WithPublicMethods(grpchandlers.PublicMethods...),
+				authmw.WithAudience(cfg.AuthAudience), authmw.WithoutDelegatedTokens(),
+				authmw.WithRevocationCheck(), authmw.WithUserExistenceCheck())),
+	)
+	authv1.RegisterAuthServiceServer(grpcServer, grpchandlers.NewAuthServer(deps.AuthService, deps.TokenValidator,
+		grpchandlers.WithAudience(cfg.AuthAudience)))
+
+	return &GRPCServer{
+		server: grpcServer,
+		addr:   cfg.GRPCAddr,
+	}
+}
+
+// grpcRateLimits limits the RPCs that authenticate users like their HTTP endpoints, per client IP address
+// and Login per login as well.
+func grpcRateLimits(deps *Dependencies) map[string][]grpchandlers.RateLimitRule {
+	byIP := grpcRateLimitRule(deps.IPLimiter, grpchandlers.RateLimitByIP)
+	byLogin := grpcRateLimitRule(deps.LoginLimiter, grpchandlers.RateLimitByLogin)
+
+	return map[string][]grpchandlers.RateLimitRule{
+		authv1.AuthService_Register_FullMethodName:  byIP,
+		authv1.AuthService_Login_FullMethodName:     append(byIP, byLogin...),
+		authv1.AuthService_VerifyMFA_FullMethodName: byIP,
+		authv1.AuthService_Refresh_FullMethodName:   byIP,
+	}
+}
+
+// grpcRateLimitRule returns the rule of the limiter, or no rule if the limiter is disabled.
+func grpcRateLimitRule(limiter *ratelimit.Limiter, key grpchandlers.RateLimitKeyFunc) []grpchandlers.RateLimitRule {
+	if limiter == nil {
+		return nil
+	}
+	return []grpchandlers.RateLimitRule{{Limiter: limiter, Key: key}}
+}
+
+// Start begins listening for gRPC requests and blocks until the server is stopped.
+func (s *GRPCServer) Start() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.server.Serve(listener)
+}
+
+// Shutdown gracefully stops the server, forcing it to stop if ctx expires first.
+func (s *GRPCServer) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
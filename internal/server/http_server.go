@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/clientip"
+	"github.com/breakfront-planner/auth-service/internal/configs"
+	"github.com/breakfront-planner/auth-service/internal/handlers"
+	"github.com/breakfront-planner/auth-service/internal/ratelimit"
+)
+
+// HTTPServer serves the REST API on top of the wired dependencies.
+type HTTPServer struct {
+	server *http.Server
+}
+
+// NewHTTPServer creates an HTTP server exposing the authentication endpoints.
+func NewHTTPServer(cfg *configs.Config, deps *Dependencies) *HTTPServer {
+	authHandler := handlers.NewAuthHandler(deps.AuthService)
+	jwksHandler := handlers.NewJWKSHandler(deps.JWTManager.KeySet())
+	var deviceService handlers.IDeviceAuthorizationService
+	if deps.DeviceService != nil {
+		deviceService = deps.DeviceService
+	}
+	oauthHandler := handlers.NewOAuthHandler(deps.AuthService, deps.OAuthService, deviceService, deps.ExchangeService,
+		deps.ClientService)
+
+	var oidcHandler *handlers.OIDCHandler
+	if openIDConnectEnabled(cfg) {
+		oidcHandler = handlers.NewOIDCHandler(deps.OAuthService, cfg.JWTIssuer, deps.JWTManager.KeySet().Active().Method.Alg(),
+			deviceService != nil)
+	}
+
+	mfaHandler := handlers.NewMFAHandler(deps.MFAService)
+
+	var passkeyHandler *handlers.PasskeyHandler
+	if deps.PasskeyService != nil {
+		passkeyHandler = handlers.NewPasskeyHandler(deps.PasskeyService, deps.AuthService)
+	}
+
+	lockoutHandler := handlers.NewLockoutHandler(deps.LockoutService)
+
+	opts := append(httpRateLimits(deps), handlers.WithAudience(cfg.AuthAudience),
+		handlers.WithClientIPResolver(clientip.NewResolver(cfg.TrustedProxies)))
+	router := handlers.NewRouter(authHandler, jwksHandler, oauthHandler, oidcHandler, mfaHandler, passkeyHandler,
+		lockoutHandler, deps.TokenValidator, opts...)
+
+	return &HTTPServer{
+		server: &http.Server{
+			Addr:              cfg.HTTPAddr,
+			Handler:           router,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       10 * time.Second,
+			WriteTimeout:      10 * time.Second,
+			IdleTimeout:       60 * time.Second,
+		},
+	}
+}
+
+// httpRateLimits limits the endpoints that authenticate users or clients or check their codes per client IP address,
+// the endpoints taking a password per login as well and the OAuth endpoints per client.
+func httpRateLimits(deps *Dependencies) []handlers.RouterOption {
+	byIP := httpRateLimitRule(deps.IPLimiter, handlers.RateLimitByIP)
+	byLogin := httpRateLimitRule(deps.LoginLimiter, handlers.RateLimitByLogin)
+	byClient := httpRateLimitRule(deps.ClientLimiter, handlers.RateLimitByClientID)
+
+	return []handlers.RouterOption{
+		handlers.WithRateLimit("POST /auth/register", byIP...),
+		handlers.WithRateLimit("POST /auth/login", append(byIP, byLogin...)...),
+		handlers.WithRateLimit("POST /auth/refresh", byIP...),
+		handlers.WithRateLimit("POST /auth/mfa/verify", byIP...),
+		handlers.WithRateLimit("POST /auth/mfa/totp/confirm", byIP...),
+		handlers.WithRateLimit("POST /auth/mfa/totp/disable", byIP...),
+		handlers.WithRateLimit("POST /auth/mfa/recovery-codes", byIP...),
+		handlers.WithRateLimit("POST /auth/passkeys/register/options", byIP...),
+		handlers.WithRateLimit("POST /auth/passkeys/login/options", byIP...),
+		handlers.WithRateLimit("POST /auth/passkeys/login", byIP...),
+		handlers.WithRateLimit("POST /auth/mfa/passkey/options", byIP...),
+		handlers.WithRateLimit("POST /auth/mfa/passkey/verify", byIP...),
+		handlers.WithRateLimit("POST /oauth/authorize", append(byIP, byLogin...)...),
+		handlers.WithRateLimit("POST /oauth/token", append(byIP, byClient...)...),
+		handlers.WithRateLimit("POST /oauth/device_authorization", append(byIP, byClient...)...),
+	}
+}
+
+// httpRateLimitRule returns the rule of the limiter, or no rule if the limiter is disabled.
+func httpRateLimitRule(limiter *ratelimit.Limiter, key handlers.RateLimitKeyFunc) []handlers.RateLimitRule {
+	if limiter == nil {
+		return nil
+	}
+	return []handlers.RateLimitRule{{Limiter: limiter, Key: key}}
+}
+
+// Start begins listening for HTTP requests and blocks until the server is shut down.
+func (s *HTTPServer) Start() error {
+	err := s.server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown gracefully stops the server, waiting for in-flight requests to finish.
+func (s *HTTPServer) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
@@ -1,6 +1,15 @@
 package services
 
 import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+
+	"github.com/breakfront-planner/auth-service/internal/constants"
 	"github.com/breakfront-planner/auth-service/internal/models"
 	"github.com/breakfront-planner/auth-service/internal/validators"
 )
@@ -10,42 +19,126 @@ type IUserService interface {
 	CreateUser(login string, passHash string) (*models.User, error)
 	FindUser(*models.UserFilter) (*models.User, error)
 	CheckPassword(login string, password string) error
+	InvalidateTokens(userID uuid.UUID) error
 }
 
 // ITokenService defines the interface for token management operations.
 type ITokenService interface {
-	CreateNewTokenPair(user *models.User) (accessToken, refreshToken *models.Token, err error)
-	Refresh(refreshToken *models.Token, user *models.User) (newAccessToken, newRefreshToken *models.Token, err error)
+	CreateNewTokenPair(user *models.User, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error)
+	CreateTokenPairForClient(user *models.User, clientID string, scopes []string, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error)
+	CreateTokenPairForCode(user *models.User, code *models.AuthorizationCode, scopes []string, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error)
+	Refresh(refreshToken *models.Token, user *models.User, session models.SessionMetadata) (newAccessToken, newRefreshToken *models.Token, err error)
 	RevokeToken(token *models.Token) error
+	CheckRefreshToken(token *models.Token) error
+	CreateClientToken(client *models.Client, scopes []string) (*models.Token, error)
+	CreateIDToken(user *models.User, clientID string, nonce string, authTime time.Time) (*models.Token, error)
+	CreateExchangedToken(subject *models.ParsedToken, audience string, scopes []string, actor *models.Actor) (*models.Token, error)
+	CreateMFAChallenge(user *models.User) (*models.Token, error)
+	ListSessions(userID uuid.UUID) ([]*models.Session, error)
+	RevokeSession(userID uuid.UUID, sessionID uuid.UUID) error
+	RevokeAllTokens(userID uuid.UUID) error
 }
 
 // ITokenValidator defines the interface for token validation.
 type ITokenValidator interface {
 	ValidateRefreshToken(tokenValue string) (*models.ParsedToken, error)
 	ValidateAccessToken(tokenValue string) (*models.ParsedToken, error)
+	ValidateClientToken(tokenValue string) (*models.ParsedToken, error)
 	Validate(tokenValue string, opts ...validators.ValidationOption) (*models.ParsedToken, error)
 }
 
+// IAccessTokenDenylist defines the interface for revoking individual access tokens.
+type IAccessTokenDenylist interface {
+	Revoke(token *models.ParsedToken) error
+}
+
+// IRoleService defines the interface for loading the authorization data embedded in access tokens.
+type IRoleService interface {
+	LoadAuthorization(user *models.User) error
+}
+
+// ILockoutService defines the interface for tracking failed logins and locking out brute-force attempts.
+type ILockoutService interface {
+	CheckLocked(login string, ipAddress string) error
+	RecordFailure(login string, ipAddress string) error
+	RecordSuccess(login string) error
+}
+
+// IMFAService defines the second factor check of users with two-factor authentication enabled.
+type IMFAService interface {
+	IsEnabled(userID uuid.UUID) (bool, error)
+	Verify(userID uuid.UUID, code string) error
+}
+
+// IPasskeyService defines the passkey checks of passkey logins and of second factors checked with a passkey.
+type IPasskeyService interface {
+	HasPasskeys(userID uuid.UUID) (bool, error)
+	BeginRegistration(userID uuid.UUID) (*models.PasskeyCreationOptions, error)
+	BeginLogin() (*models.PasskeyRequestOptions, error)
+	FinishLogin(assertion *models.PasskeyAssertion) (*models.User, error)
+	BeginSecondFactor(userID uuid.UUID) (*models.PasskeyRequestOptions, error)
+	VerifySecondFactor(userID uuid.UUID, assertion *models.PasskeyAssertion) error
+}
+
 // AuthService provides authentication and authorization functionality.
 // It coordinates between user, token, and validation services to handle registration, login, and logout flows.
 type AuthService struct {
 	tokenService   ITokenService
 	userService    IUserService
 	tokenValidator ITokenValidator
+	denylist       IAccessTokenDenylist
+	roleService    IRoleService
+	lockout        ILockoutService
+	mfa            IMFAService
+	passkeys       IPasskeyService
+}
+
+// AuthServiceOption is a function that modifies the AuthService configuration.
+type AuthServiceOption func(*AuthService)
+
+// WithLockout locks logins and IP addresses after too many failed password checks.
+func WithLockout(lockout ILockoutService) AuthServiceOption {
+	return func(s *AuthService) {
+		s.lockout = lockout
+	}
+}
+
+// WithMFA requires users who enabled two-factor authentication to enter a code after their password.
+func WithMFA(mfa IMFAService) AuthServiceOption {
+	return func(s *AuthService) {
+		s.mfa = mfa
+	}
+}
+
+// WithPasskeys lets users sign in with a passkey instead of a password, and users who enabled two-factor
+// authentication check a passkey instead of entering a code.
+func WithPasskeys(passkeys IPasskeyService) AuthServiceOption {
+	return func(s *AuthService) {
+		s.passkeys = passkeys
+	}
 }
 
 // NewAuthService creates a new authentication service instance.
-func NewAuthService(tokenService ITokenService, userService IUserService, tokenValidator ITokenValidator) *AuthService {
-	return &AuthService{
+// Failed logins are only limited if a lockout service is given, second factors are only checked
+// if an MFA service is given and passkeys are only accepted if a passkey service is given.
+func NewAuthService(tokenService ITokenService, userService IUserService, tokenValidator ITokenValidator,
+	denylist IAccessTokenDenylist, roleService IRoleService, opts ...AuthServiceOption) *AuthService {
+	s := &AuthService{
 		tokenService:   tokenService,
 		userService:    userService,
 		tokenValidator: tokenValidator,
+		denylist:       denylist,
+		roleService:    roleService,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// Register creates a new user account and returns access and refresh tokens.
+// Register creates a new user account and returns access and refresh tokens for a new session.
 // Returns an error if the user already exists or if token generation fails.
-func (s *AuthService) Register(login string, password string) (accessToken, refreshToken *models.Token, err error) {
+func (s *AuthService) Register(login string, password string, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error) {
 
 	user, err := s.userService.CreateUser(login, password)
 
@@ -53,50 +146,379 @@ func (s *AuthService) Register(login string, password string) (accessToken, refr
 		return nil, nil, err
 	}
 
-	return s.tokenService.CreateNewTokenPair(user)
+	if err := s.roleService.LoadAuthorization(user); err != nil {
+		return nil, nil, err
+	}
+
+	return s.tokenService.CreateNewTokenPair(user, session)
+
+}
+
+// Login authenticates a user with their credentials and returns access and refresh tokens for a new session.
+// Returns an error if credentials are invalid, the login or the client's IP address is locked
+// or token generation fails. Users with two-factor authentication enabled or with a passkey get
+// an *autherrors.MFARequiredError instead, whose challenge token is exchanged for the tokens with VerifyMFA, or VerifyPasskeyMFA with a passkey.
+func (s *AuthService) Login(login string, password string, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error) {
+
+	user, err := s.Authenticate(login, password, session.IPAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.issueTokenPair(user, session)
+
+}
+
+// VerifyMFA completes the login of a user with two-factor authentication enabled: it checks the code
+// of the user's authenticator or a recovery code against the challenge token returned by Login
+// and returns access and refresh tokens for a new session.
+func (s *AuthService) VerifyMFA(challengeTokenValue string, code string, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error) {
+
+	user, err := s.AuthenticateMFA(challengeTokenValue, code, session.IPAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.issueTokenPair(user, session)
 
 }
 
-// Login authenticates a user with their credentials and returns access and refresh tokens.
-// Returns an error if credentials are invalid or token generation fails.
-func (s *AuthService) Login(login string, password string) (accessToken, refreshToken *models.Token, err error) {
+// BeginPasskeyLogin starts a login with a passkey instead of a password and returns the options
+// for the user's authenticator.
+func (s *AuthService) BeginPasskeyLogin() (*models.PasskeyRequestOptions, error) {
+	if s.passkeys == nil {
+		return nil, autherrors.ErrNoPasskeyService
+	}
+
+	return s.passkeys.BeginLogin()
+}
 
-	err = s.userService.CheckPassword(login, password)
+// PasskeyLogin completes a login with a passkey started with BeginPasskeyLogin and returns access and refresh tokens
+// for a new session. The passkey identifies the user, who was verified by the authenticator, so neither a password
+// nor a second factor is checked. Returns an error matching autherrors.ErrInvalidPasskey if the assertion is invalid.
+func (s *AuthService) PasskeyLogin(assertion *models.PasskeyAssertion, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error) {
+
+	if s.passkeys == nil {
+		return nil, nil, autherrors.ErrNoPasskeyService
+	}
+
+	user, err := s.passkeys.FinishLogin(assertion)
 	if err != nil {
 		return nil, nil, err
 	}
+
+	return s.issueTokenPair(user, session)
+
+}
+
+// BeginPasskeyRegistration starts registering a passkey for the signed-in user and returns the options for
+// the user's authenticator. A passkey signs in without a password or second factor, so the user must confirm
+// the current password or a code of their authenticator app first; an access token alone, which may have leaked,
+// doesn't suffice. Wrong passwords and codes count as failed logins.
+// Returns autherrors.ErrEmptyReauthentication if neither is given.
+func (s *AuthService) BeginPasskeyRegistration(userID uuid.UUID, password string, code string,
+	ipAddress string) (*models.PasskeyCreationOptions, error) {
+
+	if s.passkeys == nil {
+		return nil, autherrors.ErrNoPasskeyService
+	}
+
+	user, err := s.userService.FindUser(&models.UserFilter{ID: &userID})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, autherrors.ErrUserNotExist
+	}
+
+	if err := s.reauthenticate(user, password, code, ipAddress); err != nil {
+		return nil, err
+	}
+
+	return s.passkeys.BeginRegistration(userID)
+
+}
+
+// reauthenticate checks the current password of the signed-in user or, if no password is given,
+// a code of their authenticator app, counting wrong ones as failed logins.
+func (s *AuthService) reauthenticate(user *models.User, password string, code string, ipAddress string) error {
+	if s.lockout != nil {
+		if err := s.lockout.CheckLocked(user.Login, ipAddress); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch {
+	case password != "":
+		err = s.userService.CheckPassword(user.Login, password)
+	case code != "" && s.mfa != nil:
+		err = s.mfa.Verify(user.ID, code)
+	case code != "":
+		err = autherrors.ErrMFANotEnabled
+	default:
+		return autherrors.ErrEmptyReauthentication
+	}
+
+	if err != nil {
+		if s.lockout != nil && (isWrongCredentials(err) || isWrongSecondFactor(err)) {
+			if lockoutErr := s.lockout.RecordFailure(user.Login, ipAddress); lockoutErr != nil {
+				return lockoutErr
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// BeginPasskeyMFA starts checking a passkey as the second factor of a login instead of a code and returns
+// the options for the user's authenticator. The challenge token must have been returned by Login.
+// Returns autherrors.ErrNoPasskeys if the user has no passkeys.
+func (s *AuthService) BeginPasskeyMFA(challengeTokenValue string) (*models.PasskeyRequestOptions, error) {
+
+	if s.passkeys == nil {
+		return nil, autherrors.ErrNoPasskeyService
+	}
+
+	parsedToken, err := s.validateMFAChallenge(challengeTokenValue)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.passkeys.BeginSecondFactor(parsedToken.UserID)
+
+}
+
+// VerifyPasskeyMFA completes the login of a user with two-factor authentication enabled with a passkey started
+// with BeginPasskeyMFA and returns access and refresh tokens for a new session.
+func (s *AuthService) VerifyPasskeyMFA(challengeTokenValue string, assertion *models.PasskeyAssertion,
+	session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error) {
+
+	user, err := s.AuthenticatePasskeyMFA(challengeTokenValue, assertion, session.IPAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.issueTokenPair(user, session)
+
+}
+
+// issueTokenPair loads the authenticated user's roles and issues a token pair for a new session.
+func (s *AuthService) issueTokenPair(user *models.User, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error) {
+	if err := s.roleService.LoadAuthorization(user); err != nil {
+		return nil, nil, err
+	}
+
+	return s.tokenService.CreateNewTokenPair(user, session)
+}
+
+// Authenticate checks the user's credentials and returns the user without issuing any tokens.
+// It is shared by the password login and the OAuth authorization endpoint.
+// With a lockout service, locked logins and IP addresses are rejected with an *autherrors.LockoutError
+// before the password is checked, wrong credentials are counted and a successful login resets the counts.
+// Users with two-factor authentication enabled or with a passkey are not returned: the right password
+// only yields an *autherrors.MFARequiredError with a challenge token for AuthenticateMFA or AuthenticatePasskeyMFA,
+// and the failure counts are only reset once the second factor is checked too.
+func (s *AuthService) Authenticate(login string, password string, ipAddress string) (*models.User, error) {
+
+	if s.lockout != nil {
+		if err := s.lockout.CheckLocked(login, ipAddress); err != nil {
+			return nil, err
+		}
+	}
+
+	err := s.userService.CheckPassword(login, password)
+	if err != nil {
+		if s.lockout != nil && isWrongCredentials(err) {
+			if lockoutErr := s.lockout.RecordFailure(login, ipAddress); lockoutErr != nil {
+				return nil, lockoutErr
+			}
+		}
+		return nil, err
+	}
+
 	filter := models.UserFilter{
 		Login: &login,
 	}
 
 	user, err := s.userService.FindUser(&filter)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+
+	if user != nil {
+		required, err := s.requiresSecondFactor(user)
+		if err != nil {
+			return nil, err
+		}
+		if required {
+			challenge, err := s.tokenService.CreateMFAChallenge(user)
+			if err != nil {
+				return nil, err
+			}
+			return nil, &autherrors.MFARequiredError{ChallengeToken: challenge}
+		}
+	}
+
+	s.recordSuccess(login)
+
+	return user, nil
+
+}
+
+// requiresSecondFactor reports whether the user must confirm a password login with a code of their
+// authenticator app or with one of their passkeys.
+func (s *AuthService) requiresSecondFactor(user *models.User) (bool, error) {
+	if s.mfa != nil {
+		enabled, err := s.mfa.IsEnabled(user.ID)
+		if err != nil || enabled {
+			return enabled, err
+		}
+	}
+
+	if s.passkeys != nil {
+		return s.passkeys.HasPasskeys(user.ID)
+	}
+
+	return false, nil
+}
+
+// AuthenticateMFA checks the second factor of a login and returns the user without issuing any tokens.
+// The challenge token must have been issued by Authenticate; it can be retried until it expires
+// and is revoked once a code has been accepted. Wrong codes count as failed logins of the user's login.
+// Returns autherrors.ErrInvalidMFACode if the code is wrong.
+func (s *AuthService) AuthenticateMFA(challengeTokenValue string, code string, ipAddress string) (*models.User, error) {
+
+	if s.mfa == nil {
+		return nil, autherrors.ErrMFANotEnabled
+	}
+
+	return s.authenticateSecondFactor(challengeTokenValue, ipAddress, func(userID uuid.UUID) error {
+		return s.mfa.Verify(userID, code)
+	})
+
+}
+
+// AuthenticatePasskeyMFA checks a passkey as the second factor of a login, like AuthenticateMFA checks a code.
+// Rejected passkeys count as failed logins of the user's login.
+// Returns an error matching autherrors.ErrInvalidPasskey if the assertion is invalid.
+func (s *AuthService) AuthenticatePasskeyMFA(challengeTokenValue string, assertion *models.PasskeyAssertion,
+	ipAddress string) (*models.User, error) {
+
+	if s.passkeys == nil {
+		return nil, autherrors.ErrNoPasskeyService
+	}
+
+	return s.authenticateSecondFactor(challengeTokenValue, ipAddress, func(userID uuid.UUID) error {
+		return s.passkeys.VerifySecondFactor(userID, assertion)
+	})
+
+}
+
+// authenticateSecondFactor checks the second factor of the user of the challenge token with verify,
+// counting rejected second factors as failed logins, and revokes the challenge token once it is accepted.
+func (s *AuthService) authenticateSecondFactor(challengeTokenValue string, ipAddress string,
+	verify func(userID uuid.UUID) error) (*models.User, error) {
+	parsedToken, err := s.validateMFAChallenge(challengeTokenValue)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.tokenService.CreateNewTokenPair(user)
+	user, err := s.userService.FindUser(&models.UserFilter{ID: &parsedToken.UserID})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, autherrors.ErrUserNotExist
+	}
+
+	if s.lockout != nil {
+		if err := s.lockout.CheckLocked(user.Login, ipAddress); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := verify(user.ID); err != nil {
+		if s.lockout != nil && isWrongSecondFactor(err) {
+			if lockoutErr := s.lockout.RecordFailure(user.Login, ipAddress); lockoutErr != nil {
+				return nil, lockoutErr
+			}
+		}
+		return nil, err
+	}
+
+	// The challenge is single use, so a leaked one can't be combined with another code or passkey
+	if err := s.denylist.Revoke(parsedToken); err != nil {
+		return nil, err
+	}
+
+	s.recordSuccess(user.Login)
+
+	return user, nil
+}
 
+// validateMFAChallenge parses a challenge token issued by Authenticate that has not been used yet.
+func (s *AuthService) validateMFAChallenge(challengeTokenValue string) (*models.ParsedToken, error) {
+	return s.tokenValidator.Validate(challengeTokenValue,
+		validators.WithTokenType(constants.TokenTypeMFAChallenge),
+		validators.WithRevocationCheck(),
+		validators.WithUserExistenceCheck())
+}
+
+// recordSuccess resets the failed login attempts of the login after a successful login.
+// Failing to reset the counts doesn't affect the login; they expire on their own.
+func (s *AuthService) recordSuccess(login string) {
+	if s.lockout == nil {
+		return
+	}
+	if err := s.lockout.RecordSuccess(login); err != nil {
+		log.Printf("failed to reset login attempts: %v", err)
+	}
 }
 
 // Refresh generates a new token pair using a valid refresh token.
-// The old refresh token is revoked after successful generation of new tokens.
-func (s *AuthService) Refresh(oldRefreshTokenValue string) (newAccessToken, newRefreshToken *models.Token, err error) {
+// The old refresh token is revoked after successful generation of new tokens,
+// and the session's last use is recorded with the given metadata.
+// Refresh tokens issued to an OAuth client can only be refreshed by that client, see RefreshForClient.
+func (s *AuthService) Refresh(oldRefreshTokenValue string, session models.SessionMetadata) (newAccessToken, newRefreshToken *models.Token, err error) {
+	return s.RefreshForClient(oldRefreshTokenValue, "", session)
+}
+
+// RefreshForClient refreshes a token pair like Refresh for the OAuth client making the request,
+// empty if it didn't identify itself. The refresh token must have been issued to the same client,
+// otherwise an error wrapping autherrors.ErrTokenClient is returned.
+func (s *AuthService) RefreshForClient(oldRefreshTokenValue string, clientID string,
+	session models.SessionMetadata) (newAccessToken, newRefreshToken *models.Token, err error) {
 	// Validate refresh token using the validator
 	parsedToken, err := s.tokenValidator.ValidateRefreshToken(oldRefreshTokenValue)
 	if err != nil {
 		return nil, nil, err
 	}
+	if parsedToken.AuthorizedParty != clientID {
+		return nil, nil, autherrors.ErrInvalidToken(autherrors.ErrTokenClient)
+	}
 
 	oldRefreshToken := models.Token{
-		UserID: parsedToken.UserID,
-		Value:  oldRefreshTokenValue,
+		UserID:   parsedToken.UserID,
+		Value:    oldRefreshTokenValue,
+		ClientID: parsedToken.AuthorizedParty,
 	}
 
 	user := models.User{
 		ID: parsedToken.UserID,
 	}
 
-	return s.tokenService.Refresh(&oldRefreshToken, &user)
+	// Roles are reloaded on every refresh, so role changes reach access tokens within one access token lifetime
+	if err := s.roleService.LoadAuthorization(&user); err != nil {
+		return nil, nil, err
+	}
+	// Refresh tokens of OAuth clients carry the granted scopes; the user may have lost some of them since
+	if parsedToken.AuthorizedParty != "" {
+		oldRefreshToken.Scopes = grantedScopes(&user, parsedToken.Permissions)
+	}
+
+	return s.tokenService.Refresh(&oldRefreshToken, &user, session)
 }
 
 // Logout invalidates the user's refresh token, effectively ending their session.
@@ -114,3 +536,165 @@ func (s *AuthService) Logout(refreshTokenValue string) error {
 
 	return s.tokenService.RevokeToken(&tokenToRevoke)
 }
+
+// ListSessions returns the active sessions of the user, one per device the user is logged in on.
+func (s *AuthService) ListSessions(userID uuid.UUID) ([]*models.Session, error) {
+	return s.tokenService.ListSessions(userID)
+}
+
+// RevokeSession logs the user out of the session with the given ID.
+// Returns autherrors.ErrSessionNotFound if the user has no such active session.
+func (s *AuthService) RevokeSession(userID uuid.UUID, sessionID uuid.UUID) error {
+	return s.tokenService.RevokeSession(userID, sessionID)
+}
+
+// LogoutAll logs the user out of every device.
+// Besides revoking all refresh tokens it moves the user's token cutoff forward,
+// so access tokens issued before the call are rejected too.
+func (s *AuthService) LogoutAll(userID uuid.UUID) error {
+	err := s.userService.InvalidateTokens(userID)
+	if err != nil {
+		return err
+	}
+
+	return s.tokenService.RevokeAllTokens(userID)
+}
+
+// RevokeAccessToken revokes a single access token, e.g. one that has leaked, before it expires.
+// Revoking an already revoked token succeeds.
+func (s *AuthService) RevokeAccessToken(accessTokenValue string) error {
+	parsedToken, err := s.tokenValidator.Validate(accessTokenValue, validators.WithTokenType(constants.TokenTypeAccess))
+	if err != nil {
+		return err
+	}
+
+	return s.denylist.Revoke(parsedToken)
+}
+
+// Introspect reports whether a token is active (RFC 7662) and, if so, its claims.
+// Access and client tokens must not be revoked, refresh tokens must still be stored and not revoked,
+// and the user owning an access or refresh token must exist and not have signed out from all devices
+// since the token was issued.
+// Tokens that fail these checks are reported inactive; an error is only returned if they can't be checked.
+func (s *AuthService) Introspect(tokenValue string) (*models.TokenIntrospection, error) {
+	parsedToken, err := s.tokenValidator.Validate(tokenValue)
+	if err != nil {
+		return inactiveOrError(err)
+	}
+
+	switch constants.TokenType(parsedToken.Type) {
+	case constants.TokenTypeAccess:
+		parsedToken, err = s.tokenValidator.ValidateAccessToken(tokenValue)
+		if err != nil {
+			return inactiveOrError(err)
+		}
+
+	case constants.TokenTypeRefresh:
+		parsedToken, err = s.tokenValidator.ValidateRefreshToken(tokenValue)
+		if err != nil {
+			return inactiveOrError(err)
+		}
+
+		refreshToken := models.Token{
+			UserID: parsedToken.UserID,
+			Value:  tokenValue,
+		}
+		if err := s.tokenService.CheckRefreshToken(&refreshToken); err != nil {
+			return inactiveOrError(err)
+		}
+
+	case constants.TokenTypeClient:
+		parsedToken, err = s.tokenValidator.ValidateClientToken(tokenValue)
+		if err != nil {
+			return inactiveOrError(err)
+		}
+
+	default:
+		return &models.TokenIntrospection{Active: false}, nil
+	}
+
+	return &models.TokenIntrospection{Active: true, Token: parsedToken}, nil
+}
+
+// Revoke revokes an access or refresh token (RFC 7009): refresh tokens are revoked in storage,
+// access and client tokens are put on the denylist until they expire.
+// Revoking an unknown, invalid, expired or already revoked token succeeds, as there is nothing left to revoke.
+// clientID is the client making the request, empty if it didn't identify itself. Tokens issued to another client,
+// or issued by the auth endpoints when a client is given, are left alone but the call succeeds as well,
+// so that clients can't probe for valid tokens.
+func (s *AuthService) Revoke(tokenValue string, clientID string) error {
+	parsedToken, err := s.tokenValidator.Validate(tokenValue)
+	if err != nil {
+		return ignoreInactive(err)
+	}
+
+	issuedTo := parsedToken.AuthorizedParty
+	if parsedToken.Type == string(constants.TokenTypeClient) {
+		issuedTo = parsedToken.ClientID
+	}
+	if issuedTo != clientID {
+		return nil
+	}
+
+	switch constants.TokenType(parsedToken.Type) {
+	case constants.TokenTypeAccess, constants.TokenTypeClient:
+		return s.denylist.Revoke(parsedToken)
+
+	case constants.TokenTypeRefresh:
+		refreshToken := models.Token{
+			UserID: parsedToken.UserID,
+			Value:  tokenValue,
+		}
+		return ignoreInactive(s.tokenService.RevokeToken(&refreshToken))
+
+	default:
+		return nil
+	}
+}
+
+// ignoreInactive drops the errors that only mean the token is no longer active.
+func ignoreInactive(err error) error {
+	if err == nil {
+		return nil
+	}
+	_, err = inactiveOrError(err)
+	return err
+}
+
+// inactiveOrError turns a validation failure into an inactive introspection result
+// and passes storage and other unexpected errors through.
+func inactiveOrError(err error) (*models.TokenIntrospection, error) {
+	switch {
+	case errors.Is(err, autherrors.ErrTokenParseFailed),
+		errors.Is(err, autherrors.ErrTokenExpired),
+		errors.Is(err, autherrors.ErrTokenInvalid),
+		errors.Is(err, autherrors.ErrTokenRevoked),
+		errors.Is(err, autherrors.ErrUserNotExist):
+		return &models.TokenIntrospection{Active: false}, nil
+	default:
+		return nil, err
+	}
+}
+
+// isWrongSecondFactor reports whether the second factor check failed because of a wrong code or passkey,
+// as opposed to a storage error.
+func isWrongSecondFactor(err error) bool {
+	return errors.Is(err, autherrors.ErrInvalidMFACode) ||
+		errors.Is(err, autherrors.ErrInvalidPasskey) ||
+		errors.Is(err, autherrors.ErrPasskeyCounter)
+}
+
+// purgeExpired deletes expired state with deleteExpired, named by what in the log. Lookups already ignore
+// expired state, so it is only purged opportunistically while saving new state, and a failed purge is logged
+// instead of failing the operation that triggered it.
+func purgeExpired(what string, deleteExpired func() error) {
+	if err := deleteExpired(); err != nil {
+		log.Printf("failed to purge %v: %v", what, err)
+	}
+}
+
+// isWrongCredentials reports whether the password check failed because of an unknown login or a wrong password,
+// as opposed to a storage error.
+func isWrongCredentials(err error) bool {
+	return errors.Is(err, autherrors.ErrPasswordMismatch) || errors.Is(err, autherrors.ErrUserNotExist)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
 
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
 	"github.com/breakfront-planner/auth-service/internal/constants"
 	"github.com/breakfront-planner/auth-service/internal/models"
 	"github.com/breakfront-planner/auth-service/internal/services/mocks"
@@ -24,10 +25,13 @@ type AuthServiceTestSuite struct {
 	mockUserService    *mocks.MockIUserService
 	mockTokenService   *mocks.MockITokenService
 	mockTokenValidator *mocks.MockITokenValidator
+	mockDenylist       *mocks.MockIAccessTokenDenylist
+	mockRoleService    *mocks.MockIRoleService
 	authService        *AuthService
 	testLogin          string
 	testPassword       string
 	testTokenValue     string
+	testSession        models.SessionMetadata
 }
 
 func (s *AuthServiceTestSuite) SetupSuite() {
@@ -42,6 +46,12 @@ func (s *AuthServiceTestSuite) SetupSuite() {
 	require.NotEmpty(s.T(), s.testPassword, "TEST_PASS must be set in .env.test")
 	require.NotEmpty(s.T(), s.testTokenValue, "TOKEN_TEST_VALUE must be set in .env.test")
 
+	s.testSession = models.SessionMetadata{
+		UserAgent:  "planner-ios/2.1",
+		IPAddress:  "203.0.113.7",
+		DeviceName: "iPhone",
+	}
+
 }
 
 func (s *AuthServiceTestSuite) SetupTest() {
@@ -49,7 +59,9 @@ func (s *AuthServiceTestSuite) SetupTest() {
 	s.mockUserService = mocks.NewMockIUserService(s.ctrl)
 	s.mockTokenService = mocks.NewMockITokenService(s.ctrl)
 	s.mockTokenValidator = mocks.NewMockITokenValidator(s.ctrl)
-	s.authService = NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator)
+	s.mockDenylist = mocks.NewMockIAccessTokenDenylist(s.ctrl)
+	s.mockRoleService = mocks.NewMockIRoleService(s.ctrl)
+	s.authService = NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist, s.mockRoleService)
 }
 
 func (s *AuthServiceTestSuite) TearDownTest() {
@@ -61,11 +73,15 @@ func (s *AuthServiceTestSuite) TestRegisterSuccess() {
 		CreateUser(s.testLogin, s.testPassword).
 		Return(&models.User{}, nil)
 
+	s.mockRoleService.EXPECT().
+		LoadAuthorization(gomock.Any()).
+		Return(nil)
+
 	s.mockTokenService.EXPECT().
-		CreateNewTokenPair(gomock.Any()).
+		CreateNewTokenPair(gomock.Any(), s.testSession).
 		Return(&models.Token{}, &models.Token{}, nil)
 
-	accessToken, refreshToken, err := s.authService.Register(s.testLogin, s.testPassword)
+	accessToken, refreshToken, err := s.authService.Register(s.testLogin, s.testPassword, s.testSession)
 
 	assert.NoError(s.T(), err)
 	assert.NotNil(s.T(), accessToken)
@@ -79,7 +95,7 @@ func (s *AuthServiceTestSuite) TestRegisterCreateUserError() {
 		CreateUser(s.testLogin, s.testPassword).
 		Return(nil, createUserError)
 
-	accessToken, refreshToken, err := s.authService.Register(s.testLogin, s.testPassword)
+	accessToken, refreshToken, err := s.authService.Register(s.testLogin, s.testPassword, s.testSession)
 
 	assert.Error(s.T(), err)
 	assert.Nil(s.T(), accessToken)
@@ -94,11 +110,15 @@ func (s *AuthServiceTestSuite) TestRegisterCreateTokenPairError() {
 		CreateUser(s.testLogin, s.testPassword).
 		Return(&models.User{}, nil)
 
+	s.mockRoleService.EXPECT().
+		LoadAuthorization(gomock.Any()).
+		Return(nil)
+
 	s.mockTokenService.EXPECT().
-		CreateNewTokenPair(gomock.Any()).
+		CreateNewTokenPair(gomock.Any(), s.testSession).
 		Return(nil, nil, tokenError)
 
-	accessToken, refreshToken, err := s.authService.Register(s.testLogin, s.testPassword)
+	accessToken, refreshToken, err := s.authService.Register(s.testLogin, s.testPassword, s.testSession)
 
 	assert.Error(s.T(), err)
 	assert.Nil(s.T(), accessToken)
@@ -115,11 +135,15 @@ func (s *AuthServiceTestSuite) TestLoginSuccess() {
 		FindUser(gomock.Any()).
 		Return(&models.User{}, nil)
 
+	s.mockRoleService.EXPECT().
+		LoadAuthorization(gomock.Any()).
+		Return(nil)
+
 	s.mockTokenService.EXPECT().
-		CreateNewTokenPair(gomock.Any()).
+		CreateNewTokenPair(gomock.Any(), s.testSession).
 		Return(&models.Token{}, &models.Token{}, nil)
 
-	accessToken, refreshToken, err := s.authService.Login(s.testLogin, s.testPassword)
+	accessToken, refreshToken, err := s.authService.Login(s.testLogin, s.testPassword, s.testSession)
 
 	assert.NoError(s.T(), err)
 	assert.NotNil(s.T(), accessToken)
@@ -133,7 +157,7 @@ func (s *AuthServiceTestSuite) TestLoginWrongPassword() {
 		CheckPassword(s.testLogin, s.testPassword).
 		Return(passwordError)
 
-	accessToken, refreshToken, err := s.authService.Login(s.testLogin, s.testPassword)
+	accessToken, refreshToken, err := s.authService.Login(s.testLogin, s.testPassword, s.testSession)
 
 	assert.Error(s.T(), err)
 	assert.Nil(s.T(), accessToken)
@@ -141,6 +165,437 @@ func (s *AuthServiceTestSuite) TestLoginWrongPassword() {
 	assert.ErrorContains(s.T(), err, "wrong password")
 }
 
+func (s *AuthServiceTestSuite) TestLoginWithLockout() {
+	lockout := mocks.NewMockILockoutService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithLockout(lockout))
+
+	lockout.EXPECT().CheckLocked(s.testLogin, s.testSession.IPAddress).Return(nil)
+	s.mockUserService.EXPECT().CheckPassword(s.testLogin, s.testPassword).Return(nil)
+	lockout.EXPECT().RecordSuccess(s.testLogin).Return(nil)
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(&models.User{}, nil)
+	s.mockRoleService.EXPECT().LoadAuthorization(gomock.Any()).Return(nil)
+	s.mockTokenService.EXPECT().
+		CreateNewTokenPair(gomock.Any(), s.testSession).
+		Return(&models.Token{}, &models.Token{}, nil)
+
+	accessToken, refreshToken, err := authService.Login(s.testLogin, s.testPassword, s.testSession)
+
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), accessToken)
+	assert.NotNil(s.T(), refreshToken)
+}
+
+func (s *AuthServiceTestSuite) TestLoginWithLockoutRecordsFailures() {
+	testCases := []struct {
+		name     string
+		checkErr error
+		record   bool
+	}{
+		{
+			name:     "wrong password",
+			checkErr: autherrors.ErrWrongPassword(autherrors.ErrHashMismatch),
+			record:   true,
+		},
+		{
+			name:     "unknown login",
+			checkErr: autherrors.ErrWrongLogin(autherrors.ErrUserNotExist),
+			record:   true,
+		},
+		{
+			name:     "storage error",
+			checkErr: autherrors.ErrWrongLogin(errors.New("connection refused")),
+			record:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			lockout := mocks.NewMockILockoutService(s.ctrl)
+			authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+				s.mockRoleService, WithLockout(lockout))
+
+			lockout.EXPECT().CheckLocked(s.testLogin, s.testSession.IPAddress).Return(nil)
+			s.mockUserService.EXPECT().CheckPassword(s.testLogin, s.testPassword).Return(tc.checkErr)
+			if tc.record {
+				lockout.EXPECT().RecordFailure(s.testLogin, s.testSession.IPAddress).Return(nil)
+			}
+
+			accessToken, refreshToken, err := authService.Login(s.testLogin, s.testPassword, s.testSession)
+
+			assert.ErrorIs(s.T(), err, tc.checkErr)
+			assert.Nil(s.T(), accessToken)
+			assert.Nil(s.T(), refreshToken)
+		})
+	}
+}
+
+func (s *AuthServiceTestSuite) TestLoginLocked() {
+	lockout := mocks.NewMockILockoutService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithLockout(lockout))
+	lockoutErr := &autherrors.LockoutError{Until: time.Now().Add(time.Minute)}
+
+	lockout.EXPECT().CheckLocked(s.testLogin, s.testSession.IPAddress).Return(lockoutErr)
+
+	accessToken, refreshToken, err := authService.Login(s.testLogin, s.testPassword, s.testSession)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrAccountLocked)
+	assert.Nil(s.T(), accessToken)
+	assert.Nil(s.T(), refreshToken)
+}
+
+func (s *AuthServiceTestSuite) TestLoginWithMFAReturnsChallenge() {
+	mfa := mocks.NewMockIMFAService(s.ctrl)
+	lockout := mocks.NewMockILockoutService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithLockout(lockout), WithMFA(mfa))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+	challenge := &models.Token{Value: "challenge", UserID: user.ID}
+
+	lockout.EXPECT().CheckLocked(s.testLogin, s.testSession.IPAddress).Return(nil)
+	s.mockUserService.EXPECT().CheckPassword(s.testLogin, s.testPassword).Return(nil)
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(user, nil)
+	mfa.EXPECT().IsEnabled(user.ID).Return(true, nil)
+	s.mockTokenService.EXPECT().CreateMFAChallenge(user).Return(challenge, nil)
+
+	accessToken, refreshToken, err := authService.Login(s.testLogin, s.testPassword, s.testSession)
+
+	var mfaErr *autherrors.MFARequiredError
+	require.ErrorAs(s.T(), err, &mfaErr)
+	assert.ErrorIs(s.T(), err, autherrors.ErrMFARequired)
+	assert.Equal(s.T(), challenge, mfaErr.ChallengeToken)
+	assert.Nil(s.T(), accessToken)
+	assert.Nil(s.T(), refreshToken)
+}
+
+func (s *AuthServiceTestSuite) TestLoginWithMFANotEnabled() {
+	mfa := mocks.NewMockIMFAService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithMFA(mfa))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+
+	s.mockUserService.EXPECT().CheckPassword(s.testLogin, s.testPassword).Return(nil)
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(user, nil)
+	mfa.EXPECT().IsEnabled(user.ID).Return(false, nil)
+	s.mockRoleService.EXPECT().LoadAuthorization(user).Return(nil)
+	s.mockTokenService.EXPECT().
+		CreateNewTokenPair(user, s.testSession).
+		Return(&models.Token{}, &models.Token{}, nil)
+
+	accessToken, refreshToken, err := authService.Login(s.testLogin, s.testPassword, s.testSession)
+
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), accessToken)
+	assert.NotNil(s.T(), refreshToken)
+}
+
+func (s *AuthServiceTestSuite) TestLoginWithPasskeyReturnsChallenge() {
+	mfa := mocks.NewMockIMFAService(s.ctrl)
+	passkeys := mocks.NewMockIPasskeyService(s.ctrl)
+	lockout := mocks.NewMockILockoutService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithLockout(lockout), WithMFA(mfa), WithPasskeys(passkeys))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+	challenge := &models.Token{Value: "challenge", UserID: user.ID}
+
+	lockout.EXPECT().CheckLocked(s.testLogin, s.testSession.IPAddress).Return(nil)
+	s.mockUserService.EXPECT().CheckPassword(s.testLogin, s.testPassword).Return(nil)
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(user, nil)
+	mfa.EXPECT().IsEnabled(user.ID).Return(false, nil)
+	passkeys.EXPECT().HasPasskeys(user.ID).Return(true, nil)
+	s.mockTokenService.EXPECT().CreateMFAChallenge(user).Return(challenge, nil)
+
+	accessToken, refreshToken, err := authService.Login(s.testLogin, s.testPassword, s.testSession)
+
+	var mfaErr *autherrors.MFARequiredError
+	require.ErrorAs(s.T(), err, &mfaErr)
+	assert.Equal(s.T(), challenge, mfaErr.ChallengeToken)
+	assert.Nil(s.T(), accessToken)
+	assert.Nil(s.T(), refreshToken)
+}
+
+func (s *AuthServiceTestSuite) TestLoginWithoutPasskeys() {
+	passkeys := mocks.NewMockIPasskeyService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithPasskeys(passkeys))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+
+	s.mockUserService.EXPECT().CheckPassword(s.testLogin, s.testPassword).Return(nil)
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(user, nil)
+	passkeys.EXPECT().HasPasskeys(user.ID).Return(false, nil)
+	s.mockRoleService.EXPECT().LoadAuthorization(user).Return(nil)
+	s.mockTokenService.EXPECT().
+		CreateNewTokenPair(user, s.testSession).
+		Return(&models.Token{}, &models.Token{}, nil)
+
+	accessToken, refreshToken, err := authService.Login(s.testLogin, s.testPassword, s.testSession)
+
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), accessToken)
+	assert.NotNil(s.T(), refreshToken)
+}
+
+func (s *AuthServiceTestSuite) TestVerifyMFASuccess() {
+	mfa := mocks.NewMockIMFAService(s.ctrl)
+	lockout := mocks.NewMockILockoutService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithLockout(lockout), WithMFA(mfa))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+	parsedChallenge := &models.ParsedToken{JTI: uuid.NewString(), UserID: user.ID,
+		Type: string(constants.TokenTypeMFAChallenge)}
+
+	s.mockTokenValidator.EXPECT().Validate("challenge", gomock.Any()).Return(parsedChallenge, nil)
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(user, nil)
+	lockout.EXPECT().CheckLocked(s.testLogin, s.testSession.IPAddress).Return(nil)
+	mfa.EXPECT().Verify(user.ID, "123456").Return(nil)
+	s.mockDenylist.EXPECT().Revoke(parsedChallenge).Return(nil)
+	lockout.EXPECT().RecordSuccess(s.testLogin).Return(nil)
+	s.mockRoleService.EXPECT().LoadAuthorization(user).Return(nil)
+	s.mockTokenService.EXPECT().
+		CreateNewTokenPair(user, s.testSession).
+		Return(&models.Token{}, &models.Token{}, nil)
+
+	accessToken, refreshToken, err := authService.VerifyMFA("challenge", "123456", s.testSession)
+
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), accessToken)
+	assert.NotNil(s.T(), refreshToken)
+}
+
+func (s *AuthServiceTestSuite) TestVerifyMFAWrongCodeCountsFailure() {
+	mfa := mocks.NewMockIMFAService(s.ctrl)
+	lockout := mocks.NewMockILockoutService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithLockout(lockout), WithMFA(mfa))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+
+	s.mockTokenValidator.EXPECT().Validate("challenge", gomock.Any()).Return(&models.ParsedToken{UserID: user.ID}, nil)
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(user, nil)
+	lockout.EXPECT().CheckLocked(s.testLogin, s.testSession.IPAddress).Return(nil)
+	mfa.EXPECT().Verify(user.ID, "000000").Return(autherrors.ErrInvalidMFACode)
+	lockout.EXPECT().RecordFailure(s.testLogin, s.testSession.IPAddress).Return(nil)
+
+	accessToken, refreshToken, err := authService.VerifyMFA("challenge", "000000", s.testSession)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidMFACode)
+	assert.Nil(s.T(), accessToken)
+	assert.Nil(s.T(), refreshToken)
+}
+
+func (s *AuthServiceTestSuite) TestVerifyMFAInvalidChallenge() {
+	mfa := mocks.NewMockIMFAService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithMFA(mfa))
+
+	s.mockTokenValidator.EXPECT().Validate("access-token", gomock.Any()).Return(nil, autherrors.ErrTokenType)
+
+	_, _, err := authService.VerifyMFA("access-token", "123456", s.testSession)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenType)
+}
+
+func (s *AuthServiceTestSuite) TestVerifyMFAWithoutMFAService() {
+	_, _, err := s.authService.VerifyMFA("challenge", "123456", s.testSession)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrMFANotEnabled)
+}
+
+func (s *AuthServiceTestSuite) TestPasskeyLogin() {
+	passkeys := mocks.NewMockIPasskeyService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithPasskeys(passkeys))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+	assertion := &models.PasskeyAssertion{CredentialID: []byte("credential")}
+
+	passkeys.EXPECT().BeginLogin().Return(&models.PasskeyRequestOptions{Challenge: []byte("challenge")}, nil)
+	passkeys.EXPECT().FinishLogin(assertion).Return(user, nil)
+	s.mockRoleService.EXPECT().LoadAuthorization(user).Return(nil)
+	s.mockTokenService.EXPECT().
+		CreateNewTokenPair(user, s.testSession).
+		Return(&models.Token{}, &models.Token{}, nil)
+
+	options, err := authService.BeginPasskeyLogin()
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []byte("challenge"), options.Challenge)
+
+	accessToken, refreshToken, err := authService.PasskeyLogin(assertion, s.testSession)
+
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), accessToken)
+	assert.NotNil(s.T(), refreshToken)
+}
+
+func (s *AuthServiceTestSuite) TestPasskeyLoginInvalidPasskey() {
+	passkeys := mocks.NewMockIPasskeyService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithPasskeys(passkeys))
+
+	passkeys.EXPECT().FinishLogin(gomock.Any()).Return(nil, autherrors.ErrPasskeyVerification("invalid signature"))
+
+	accessToken, refreshToken, err := authService.PasskeyLogin(&models.PasskeyAssertion{}, s.testSession)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey)
+	assert.Nil(s.T(), accessToken)
+	assert.Nil(s.T(), refreshToken)
+}
+
+func (s *AuthServiceTestSuite) TestPasskeyLoginWithoutPasskeyService() {
+	_, err := s.authService.BeginPasskeyLogin()
+	assert.ErrorIs(s.T(), err, autherrors.ErrNoPasskeyService)
+
+	_, _, err = s.authService.PasskeyLogin(&models.PasskeyAssertion{}, s.testSession)
+	assert.ErrorIs(s.T(), err, autherrors.ErrNoPasskeyService)
+
+	_, _, err = s.authService.VerifyPasskeyMFA("challenge", &models.PasskeyAssertion{}, s.testSession)
+	assert.ErrorIs(s.T(), err, autherrors.ErrNoPasskeyService)
+}
+
+func (s *AuthServiceTestSuite) TestVerifyPasskeyMFASuccess() {
+	passkeys := mocks.NewMockIPasskeyService(s.ctrl)
+	lockout := mocks.NewMockILockoutService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithLockout(lockout), WithPasskeys(passkeys))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+	parsedChallenge := &models.ParsedToken{JTI: uuid.NewString(), UserID: user.ID,
+		Type: string(constants.TokenTypeMFAChallenge)}
+	assertion := &models.PasskeyAssertion{CredentialID: []byte("credential")}
+
+	s.mockTokenValidator.EXPECT().Validate("challenge", gomock.Any()).Return(parsedChallenge, nil).Times(2)
+	passkeys.EXPECT().BeginSecondFactor(user.ID).Return(&models.PasskeyRequestOptions{}, nil)
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(user, nil)
+	lockout.EXPECT().CheckLocked(s.testLogin, s.testSession.IPAddress).Return(nil)
+	passkeys.EXPECT().VerifySecondFactor(user.ID, assertion).Return(nil)
+	s.mockDenylist.EXPECT().Revoke(parsedChallenge).Return(nil)
+	lockout.EXPECT().RecordSuccess(s.testLogin).Return(nil)
+	s.mockRoleService.EXPECT().LoadAuthorization(user).Return(nil)
+	s.mockTokenService.EXPECT().
+		CreateNewTokenPair(user, s.testSession).
+		Return(&models.Token{}, &models.Token{}, nil)
+
+	_, err := authService.BeginPasskeyMFA("challenge")
+	require.NoError(s.T(), err)
+
+	accessToken, refreshToken, err := authService.VerifyPasskeyMFA("challenge", assertion, s.testSession)
+
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), accessToken)
+	assert.NotNil(s.T(), refreshToken)
+}
+
+func (s *AuthServiceTestSuite) TestVerifyPasskeyMFAInvalidPasskeyCountsFailure() {
+	passkeys := mocks.NewMockIPasskeyService(s.ctrl)
+	lockout := mocks.NewMockILockoutService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithLockout(lockout), WithPasskeys(passkeys))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+
+	s.mockTokenValidator.EXPECT().Validate("challenge", gomock.Any()).Return(&models.ParsedToken{UserID: user.ID}, nil)
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(user, nil)
+	lockout.EXPECT().CheckLocked(s.testLogin, s.testSession.IPAddress).Return(nil)
+	passkeys.EXPECT().VerifySecondFactor(user.ID, gomock.Any()).Return(autherrors.ErrPasskeyVerification("invalid signature"))
+	lockout.EXPECT().RecordFailure(s.testLogin, s.testSession.IPAddress).Return(nil)
+
+	_, _, err := authService.VerifyPasskeyMFA("challenge", &models.PasskeyAssertion{}, s.testSession)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey)
+}
+
+func (s *AuthServiceTestSuite) TestBeginPasskeyMFAInvalidChallenge() {
+	passkeys := mocks.NewMockIPasskeyService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithPasskeys(passkeys))
+
+	s.mockTokenValidator.EXPECT().Validate("access-token", gomock.Any()).Return(nil, autherrors.ErrTokenType)
+
+	_, err := authService.BeginPasskeyMFA("access-token")
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenType)
+}
+
+func (s *AuthServiceTestSuite) TestBeginPasskeyRegistrationWithPassword() {
+	passkeys := mocks.NewMockIPasskeyService(s.ctrl)
+	lockout := mocks.NewMockILockoutService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithLockout(lockout), WithPasskeys(passkeys))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(user, nil)
+	lockout.EXPECT().CheckLocked(s.testLogin, s.testSession.IPAddress).Return(nil)
+	s.mockUserService.EXPECT().CheckPassword(s.testLogin, s.testPassword).Return(nil)
+	passkeys.EXPECT().BeginRegistration(user.ID).Return(&models.PasskeyCreationOptions{Challenge: []byte("challenge")}, nil)
+
+	options, err := authService.BeginPasskeyRegistration(user.ID, s.testPassword, "", s.testSession.IPAddress)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []byte("challenge"), options.Challenge)
+}
+
+func (s *AuthServiceTestSuite) TestBeginPasskeyRegistrationWithCode() {
+	passkeys := mocks.NewMockIPasskeyService(s.ctrl)
+	mfa := mocks.NewMockIMFAService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithMFA(mfa), WithPasskeys(passkeys))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(user, nil)
+	mfa.EXPECT().Verify(user.ID, "123456").Return(nil)
+	passkeys.EXPECT().BeginRegistration(user.ID).Return(&models.PasskeyCreationOptions{}, nil)
+
+	_, err := authService.BeginPasskeyRegistration(user.ID, "", "123456", s.testSession.IPAddress)
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *AuthServiceTestSuite) TestBeginPasskeyRegistrationWrongPasswordCountsFailure() {
+	passkeys := mocks.NewMockIPasskeyService(s.ctrl)
+	lockout := mocks.NewMockILockoutService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithLockout(lockout), WithPasskeys(passkeys))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(user, nil)
+	lockout.EXPECT().CheckLocked(s.testLogin, s.testSession.IPAddress).Return(nil)
+	s.mockUserService.EXPECT().CheckPassword(s.testLogin, "wrong").Return(autherrors.ErrPasswordMismatch)
+	lockout.EXPECT().RecordFailure(s.testLogin, s.testSession.IPAddress).Return(nil)
+
+	options, err := authService.BeginPasskeyRegistration(user.ID, "wrong", "", s.testSession.IPAddress)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrPasswordMismatch)
+	assert.Nil(s.T(), options)
+}
+
+func (s *AuthServiceTestSuite) TestBeginPasskeyRegistrationRequiresReauthentication() {
+	passkeys := mocks.NewMockIPasskeyService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithPasskeys(passkeys))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(user, nil).Times(2)
+
+	_, err := authService.BeginPasskeyRegistration(user.ID, "", "", s.testSession.IPAddress)
+	assert.ErrorIs(s.T(), err, autherrors.ErrEmptyReauthentication)
+
+	_, err = authService.BeginPasskeyRegistration(user.ID, "", "123456", s.testSession.IPAddress)
+	assert.ErrorIs(s.T(), err, autherrors.ErrMFANotEnabled)
+}
+
+func (s *AuthServiceTestSuite) TestBeginPasskeyRegistrationLocked() {
+	passkeys := mocks.NewMockIPasskeyService(s.ctrl)
+	lockout := mocks.NewMockILockoutService(s.ctrl)
+	authService := NewAuthService(s.mockTokenService, s.mockUserService, s.mockTokenValidator, s.mockDenylist,
+		s.mockRoleService, WithLockout(lockout), WithPasskeys(passkeys))
+	user := &models.User{ID: uuid.New(), Login: s.testLogin}
+
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(user, nil)
+	lockout.EXPECT().CheckLocked(s.testLogin, s.testSession.IPAddress).
+		Return(&autherrors.LockoutError{Until: time.Now().Add(time.Minute)})
+
+	_, err := authService.BeginPasskeyRegistration(user.ID, s.testPassword, "", s.testSession.IPAddress)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrAccountLocked)
+}
+
 func (s *AuthServiceTestSuite) TestLoginCreateTokenPairError() {
 	tokenError := errors.New("failed to create token")
 
@@ -152,11 +607,15 @@ func (s *AuthServiceTestSuite) TestLoginCreateTokenPairError() {
 		FindUser(gomock.Any()).
 		Return(&models.User{}, nil)
 
+	s.mockRoleService.EXPECT().
+		LoadAuthorization(gomock.Any()).
+		Return(nil)
+
 	s.mockTokenService.EXPECT().
-		CreateNewTokenPair(gomock.Any()).
+		CreateNewTokenPair(gomock.Any(), s.testSession).
 		Return(nil, nil, tokenError)
 
-	accessToken, refreshToken, err := s.authService.Login(s.testLogin, s.testPassword)
+	accessToken, refreshToken, err := s.authService.Login(s.testLogin, s.testPassword, s.testSession)
 
 	assert.Error(s.T(), err)
 	assert.Nil(s.T(), accessToken)
@@ -164,6 +623,54 @@ func (s *AuthServiceTestSuite) TestLoginCreateTokenPairError() {
 	assert.ErrorContains(s.T(), err, "failed to create token")
 }
 
+func (s *AuthServiceTestSuite) TestLoginLoadsRoles() {
+	s.mockUserService.EXPECT().
+		CheckPassword(s.testLogin, s.testPassword).
+		Return(nil)
+
+	s.mockUserService.EXPECT().
+		FindUser(gomock.Any()).
+		Return(&models.User{}, nil)
+
+	s.mockRoleService.EXPECT().
+		LoadAuthorization(gomock.Any()).
+		DoAndReturn(func(user *models.User) error {
+			user.Roles = []string{"admin"}
+			return nil
+		})
+
+	s.mockTokenService.EXPECT().
+		CreateNewTokenPair(gomock.Any(), s.testSession).
+		DoAndReturn(func(user *models.User, _ models.SessionMetadata) (*models.Token, *models.Token, error) {
+			assert.Equal(s.T(), []string{"admin"}, user.Roles)
+			return &models.Token{}, &models.Token{}, nil
+		})
+
+	_, _, err := s.authService.Login(s.testLogin, s.testPassword, s.testSession)
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *AuthServiceTestSuite) TestLoginLoadRolesError() {
+	s.mockUserService.EXPECT().
+		CheckPassword(s.testLogin, s.testPassword).
+		Return(nil)
+
+	s.mockUserService.EXPECT().
+		FindUser(gomock.Any()).
+		Return(&models.User{}, nil)
+
+	s.mockRoleService.EXPECT().
+		LoadAuthorization(gomock.Any()).
+		Return(errors.New("failed to load user roles"))
+
+	accessToken, refreshToken, err := s.authService.Login(s.testLogin, s.testPassword, s.testSession)
+
+	assert.Nil(s.T(), accessToken)
+	assert.Nil(s.T(), refreshToken)
+	assert.ErrorContains(s.T(), err, "failed to load user roles")
+}
+
 func (s *AuthServiceTestSuite) TestRefreshSuccess() {
 	testUserID := uuid.New()
 	parsedToken := &models.ParsedToken{
@@ -176,17 +683,52 @@ func (s *AuthServiceTestSuite) TestRefreshSuccess() {
 		ValidateRefreshToken(s.testTokenValue).
 		Return(parsedToken, nil)
 
+	s.mockRoleService.EXPECT().
+		LoadAuthorization(gomock.Any()).
+		Return(nil)
+
 	s.mockTokenService.EXPECT().
-		Refresh(gomock.Any(), gomock.Any()).
+		Refresh(gomock.Any(), gomock.Any(), s.testSession).
 		Return(&models.Token{}, &models.Token{}, nil)
 
-	newAccessToken, newRefreshToken, err := s.authService.Refresh(s.testTokenValue)
+	newAccessToken, newRefreshToken, err := s.authService.Refresh(s.testTokenValue, s.testSession)
 
 	assert.NoError(s.T(), err)
 	assert.NotNil(s.T(), newAccessToken)
 	assert.NotNil(s.T(), newRefreshToken)
 }
 
+func (s *AuthServiceTestSuite) TestRefreshForClientKeepsOnlyScopesTheUserStillHas() {
+	parsedToken := &models.ParsedToken{
+		UserID:          uuid.New(),
+		Type:            string(constants.TokenTypeRefresh),
+		AuthorizedParty: "planner-cli",
+		Permissions:     []string{constants.ScopeOpenID, "plans:read", "plans:write"},
+	}
+
+	s.mockTokenValidator.EXPECT().
+		ValidateRefreshToken(s.testTokenValue).
+		Return(parsedToken, nil)
+	s.mockRoleService.EXPECT().
+		LoadAuthorization(gomock.Any()).
+		DoAndReturn(func(user *models.User) error {
+			user.Permissions = []string{"plans:read"}
+			return nil
+		})
+	s.mockTokenService.EXPECT().
+		Refresh(gomock.Any(), gomock.Any(), s.testSession).
+		DoAndReturn(func(refreshToken *models.Token, _ *models.User,
+			_ models.SessionMetadata) (*models.Token, *models.Token, error) {
+			assert.Equal(s.T(), "planner-cli", refreshToken.ClientID)
+			assert.Equal(s.T(), []string{constants.ScopeOpenID, "plans:read"}, refreshToken.Scopes)
+			return &models.Token{}, &models.Token{}, nil
+		})
+
+	_, _, err := s.authService.RefreshForClient(s.testTokenValue, "planner-cli", s.testSession)
+
+	assert.NoError(s.T(), err)
+}
+
 func (s *AuthServiceTestSuite) TestRefreshValidationError() {
 	validationError := errors.New("token expired")
 
@@ -194,7 +736,7 @@ func (s *AuthServiceTestSuite) TestRefreshValidationError() {
 		ValidateRefreshToken(s.testTokenValue).
 		Return(nil, validationError)
 
-	newAccessToken, newRefreshToken, err := s.authService.Refresh(s.testTokenValue)
+	newAccessToken, newRefreshToken, err := s.authService.Refresh(s.testTokenValue, s.testSession)
 
 	assert.Error(s.T(), err)
 	assert.Nil(s.T(), newAccessToken)
@@ -215,11 +757,15 @@ func (s *AuthServiceTestSuite) TestRefreshTokenServiceError() {
 		ValidateRefreshToken(s.testTokenValue).
 		Return(parsedToken, nil)
 
+	s.mockRoleService.EXPECT().
+		LoadAuthorization(gomock.Any()).
+		Return(nil)
+
 	s.mockTokenService.EXPECT().
-		Refresh(gomock.Any(), gomock.Any()).
+		Refresh(gomock.Any(), gomock.Any(), s.testSession).
 		Return(nil, nil, refreshError)
 
-	newAccessToken, newRefreshToken, err := s.authService.Refresh(s.testTokenValue)
+	newAccessToken, newRefreshToken, err := s.authService.Refresh(s.testTokenValue, s.testSession)
 
 	assert.Error(s.T(), err)
 	assert.Nil(s.T(), newAccessToken)
@@ -284,6 +830,308 @@ func (s *AuthServiceTestSuite) TestLogoutRevokeTokenError() {
 	assert.ErrorContains(s.T(), err, "failed to revoke token")
 }
 
+func (s *AuthServiceTestSuite) TestListSessions() {
+	userID := uuid.New()
+	sessions := []*models.Session{{ID: uuid.New(), SessionMetadata: s.testSession}}
+
+	s.mockTokenService.EXPECT().
+		ListSessions(userID).
+		Return(sessions, nil)
+
+	result, err := s.authService.ListSessions(userID)
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), sessions, result)
+}
+
+func (s *AuthServiceTestSuite) TestRevokeSession() {
+	userID := uuid.New()
+	sessionID := uuid.New()
+
+	s.mockTokenService.EXPECT().
+		RevokeSession(userID, sessionID).
+		Return(nil)
+
+	err := s.authService.RevokeSession(userID, sessionID)
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *AuthServiceTestSuite) TestLogoutAll() {
+	userID := uuid.New()
+
+	gomock.InOrder(
+		s.mockUserService.EXPECT().
+			InvalidateTokens(userID).
+			Return(nil),
+		s.mockTokenService.EXPECT().
+			RevokeAllTokens(userID).
+			Return(nil),
+	)
+
+	err := s.authService.LogoutAll(userID)
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *AuthServiceTestSuite) TestLogoutAllInvalidateError() {
+	userID := uuid.New()
+
+	s.mockUserService.EXPECT().
+		InvalidateTokens(userID).
+		Return(errors.New("failed to revoke token"))
+
+	err := s.authService.LogoutAll(userID)
+
+	assert.ErrorContains(s.T(), err, "failed to revoke token")
+}
+
+func (s *AuthServiceTestSuite) TestRevokeAccessToken() {
+	parsedToken := &models.ParsedToken{
+		JTI:       uuid.NewString(),
+		UserID:    uuid.New(),
+		Type:      string(constants.TokenTypeAccess),
+		ExpiresAt: time.Now().UTC().Add(10 * time.Minute),
+	}
+
+	s.mockTokenValidator.EXPECT().
+		Validate(s.testTokenValue, gomock.Any()).
+		Return(parsedToken, nil)
+
+	s.mockDenylist.EXPECT().
+		Revoke(parsedToken).
+		Return(nil)
+
+	err := s.authService.RevokeAccessToken(s.testTokenValue)
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *AuthServiceTestSuite) TestRevokeAccessTokenValidationError() {
+	s.mockTokenValidator.EXPECT().
+		Validate(s.testTokenValue, gomock.Any()).
+		Return(nil, errors.New("token expired"))
+
+	err := s.authService.RevokeAccessToken(s.testTokenValue)
+
+	assert.ErrorContains(s.T(), err, "token expired")
+}
+
+func (s *AuthServiceTestSuite) TestIntrospectAccessToken() {
+	parsedToken := &models.ParsedToken{
+		UserID: uuid.New(),
+		Type:   string(constants.TokenTypeAccess),
+	}
+
+	s.mockTokenValidator.EXPECT().
+		Validate(s.testTokenValue).
+		Return(parsedToken, nil)
+
+	s.mockTokenValidator.EXPECT().
+		ValidateAccessToken(s.testTokenValue).
+		Return(parsedToken, nil)
+
+	introspection, err := s.authService.Introspect(s.testTokenValue)
+
+	require.NoError(s.T(), err)
+	assert.True(s.T(), introspection.Active)
+	assert.Equal(s.T(), parsedToken, introspection.Token)
+}
+
+func (s *AuthServiceTestSuite) TestIntrospectClientToken() {
+	parsedToken := &models.ParsedToken{
+		JTI:         uuid.NewString(),
+		ClientID:    "nightly-export",
+		Type:        string(constants.TokenTypeClient),
+		Permissions: []string{"plans:read"},
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+
+	s.mockTokenValidator.EXPECT().
+		Validate(s.testTokenValue).
+		Return(parsedToken, nil)
+
+	s.mockTokenValidator.EXPECT().
+		ValidateClientToken(s.testTokenValue).
+		Return(parsedToken, nil)
+
+	introspection, err := s.authService.Introspect(s.testTokenValue)
+
+	require.NoError(s.T(), err)
+	assert.True(s.T(), introspection.Active)
+	assert.Equal(s.T(), parsedToken, introspection.Token)
+}
+
+func (s *AuthServiceTestSuite) TestIntrospectRefreshToken() {
+	parsedToken := &models.ParsedToken{
+		UserID: uuid.New(),
+		Type:   string(constants.TokenTypeRefresh),
+	}
+
+	s.mockTokenValidator.EXPECT().
+		Validate(s.testTokenValue).
+		Return(parsedToken, nil).
+		Times(2)
+
+	s.mockTokenValidator.EXPECT().
+		ValidateRefreshToken(s.testTokenValue).
+		Return(parsedToken, nil).
+		Times(2)
+
+	s.mockTokenService.EXPECT().
+		CheckRefreshToken(gomock.Any()).
+		DoAndReturn(func(token *models.Token) error {
+			assert.Equal(s.T(), parsedToken.UserID, token.UserID)
+			assert.Equal(s.T(), s.testTokenValue, token.Value)
+			return nil
+		})
+
+	introspection, err := s.authService.Introspect(s.testTokenValue)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), introspection.Active)
+
+	s.mockTokenService.EXPECT().
+		CheckRefreshToken(gomock.Any()).
+		Return(autherrors.ErrInvalidToken(autherrors.ErrTokenRevoked))
+
+	introspection, err = s.authService.Introspect(s.testTokenValue)
+	require.NoError(s.T(), err)
+	assert.False(s.T(), introspection.Active, "revoked refresh tokens must be inactive")
+	assert.Nil(s.T(), introspection.Token)
+}
+
+func (s *AuthServiceTestSuite) TestIntrospectInactiveTokens() {
+	testCases := []struct {
+		name string
+		err  error
+	}{
+		{name: "malformed", err: autherrors.ErrParseToken(errors.New("token is malformed"))},
+		{name: "expired", err: autherrors.ErrTokenExpired},
+		{name: "revoked", err: autherrors.ErrTokenRevoked},
+		{name: "user deleted", err: autherrors.ErrUserNotExist},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.mockTokenValidator.EXPECT().
+				Validate(s.testTokenValue).
+				Return(nil, tc.err)
+
+			introspection, err := s.authService.Introspect(s.testTokenValue)
+
+			require.NoError(s.T(), err)
+			assert.False(s.T(), introspection.Active)
+		})
+	}
+}
+
+func (s *AuthServiceTestSuite) TestIntrospectStorageError() {
+	parsedToken := &models.ParsedToken{
+		UserID: uuid.New(),
+		Type:   string(constants.TokenTypeAccess),
+	}
+
+	s.mockTokenValidator.EXPECT().
+		Validate(s.testTokenValue).
+		Return(parsedToken, nil)
+
+	s.mockTokenValidator.EXPECT().
+		ValidateAccessToken(s.testTokenValue).
+		Return(nil, errors.New("database error"))
+
+	introspection, err := s.authService.Introspect(s.testTokenValue)
+
+	assert.Nil(s.T(), introspection)
+	assert.ErrorContains(s.T(), err, "database error")
+}
+
+func (s *AuthServiceTestSuite) TestRevokeAccessTokenByValue() {
+	parsedToken := &models.ParsedToken{
+		JTI:    uuid.NewString(),
+		UserID: uuid.New(),
+		Type:   string(constants.TokenTypeAccess),
+	}
+
+	s.mockTokenValidator.EXPECT().
+		Validate(s.testTokenValue).
+		Return(parsedToken, nil)
+
+	s.mockDenylist.EXPECT().
+		Revoke(parsedToken).
+		Return(nil)
+
+	err := s.authService.Revoke(s.testTokenValue, "")
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *AuthServiceTestSuite) TestRevokeRefreshTokenByValue() {
+	parsedToken := &models.ParsedToken{
+		UserID: uuid.New(),
+		Type:   string(constants.TokenTypeRefresh),
+	}
+
+	s.mockTokenValidator.EXPECT().
+		Validate(s.testTokenValue).
+		Return(parsedToken, nil).
+		Times(3)
+
+	s.mockTokenService.EXPECT().
+		RevokeToken(gomock.Any()).
+		DoAndReturn(func(token *models.Token) error {
+			assert.Equal(s.T(), parsedToken.UserID, token.UserID)
+			assert.Equal(s.T(), s.testTokenValue, token.Value)
+			return nil
+		})
+
+	err := s.authService.Revoke(s.testTokenValue, "")
+	assert.NoError(s.T(), err)
+
+	s.mockTokenService.EXPECT().
+		RevokeToken(gomock.Any()).
+		Return(autherrors.ErrInvalidToken(autherrors.ErrTokenRevoked))
+
+	err = s.authService.Revoke(s.testTokenValue, "")
+	assert.NoError(s.T(), err, "revoking an already revoked token must succeed")
+
+	s.mockTokenService.EXPECT().
+		RevokeToken(gomock.Any()).
+		Return(autherrors.ErrRevokeToken(errors.New("database error")))
+
+	err = s.authService.Revoke(s.testTokenValue, "")
+	assert.ErrorContains(s.T(), err, "database error")
+}
+
+func (s *AuthServiceTestSuite) TestRevokeOtherClientsToken() {
+	parsedToken := &models.ParsedToken{
+		JTI:             uuid.NewString(),
+		UserID:          uuid.New(),
+		Type:            string(constants.TokenTypeAccess),
+		AuthorizedParty: "planner-spa",
+	}
+
+	s.mockTokenValidator.EXPECT().
+		Validate(s.testTokenValue).
+		Return(parsedToken, nil).
+		Times(2)
+
+	err := s.authService.Revoke(s.testTokenValue, "api-gateway")
+	assert.NoError(s.T(), err)
+
+	err = s.authService.Revoke(s.testTokenValue, "")
+	assert.NoError(s.T(), err, "tokens of a client must not be revoked without it")
+}
+
+func (s *AuthServiceTestSuite) TestRevokeInvalidToken() {
+	s.mockTokenValidator.EXPECT().
+		Validate(s.testTokenValue).
+		Return(nil, autherrors.ErrParseToken(errors.New("token is malformed")))
+
+	err := s.authService.Revoke(s.testTokenValue, "")
+
+	assert.NoError(s.T(), err)
+}
+
 func TestAuthServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(AuthServiceTestSuite))
 }
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// clientSecretSize is the number of random bytes in generated client secrets.
+const clientSecretSize = 32
+
+// IClientRepository defines the interface for registered OAuth client persistence.
+type IClientRepository interface {
+	CreateClient(client *models.Client) error
+	FindClient(clientID string) (*models.Client, error)
+}
+
+// ClientService registers and authenticates OAuth clients.
+// Besides the clients registered in the repository it knows the confidential clients given in the configuration,
+// such as API gateways calling the introspection endpoint. Client secrets are only kept as hashes.
+type ClientService struct {
+	clientRepo    IClientRepository
+	hashService   IHashService
+	staticClients map[string]*models.Client
+}
+
+// NewClientService creates a new client service instance.
+// clientSecrets maps the IDs of the configured clients to their secrets.
+func NewClientService(clientRepo IClientRepository, hashService IHashService, clientSecrets map[string]string) *ClientService {
+	staticClients := make(map[string]*models.Client, len(clientSecrets))
+	for clientID, secret := range clientSecrets {
+		staticClients[clientID] = &models.Client{
+			ID:         clientID,
+			Name:       clientID,
+			SecretHash: hashService.HashToken(secret),
+		}
+	}
+
+	return &ClientService{
+		clientRepo:    clientRepo,
+		hashService:   hashService,
+		staticClients: staticClients,
+	}
+}
+
+// RegisterClient registers a new client allowed to redirect users to the given URIs and to request the given scopes,
+// including "openid" for OpenID Connect sign-in.
+// Confidential clients get a generated secret, which is returned once and only stored as a hash.
+func (s *ClientService) RegisterClient(name string, redirectURIs []string, scopes []string,
+	confidential bool) (client *models.Client, secret string, err error) {
+
+	client = &models.Client{
+		ID:           uuid.NewString(),
+		Name:         name,
+		RedirectURIs: redirectURIs,
+		Scopes:       scopes,
+	}
+
+	return s.register(client, confidential)
+}
+
+// RegisterServiceAccount registers a confidential client for a service calling other services on its own behalf,
+// such as a batch job. It may obtain client tokens granting the given scopes with the client credentials grant.
+// The generated secret is returned once and only stored as a hash.
+func (s *ClientService) RegisterServiceAccount(name string, scopes []string) (client *models.Client, secret string, err error) {
+
+	client = &models.Client{
+		ID:     uuid.NewString(),
+		Name:   name,
+		Scopes: scopes,
+	}
+
+	return s.register(client, true)
+}
+
+// register stores the client, generating a secret for confidential clients.
+func (s *ClientService) register(client *models.Client, confidential bool) (*models.Client, string, error) {
+
+	var secret string
+	var err error
+	if confidential {
+		secret, err = generateRandomValue(clientSecretSize)
+		if err != nil {
+			return nil, "", autherrors.ErrRegisterClient(err)
+		}
+		client.SecretHash = s.hashService.HashToken(secret)
+	}
+
+	if err := s.clientRepo.CreateClient(client); err != nil {
+		return nil, "", autherrors.ErrRegisterClient(err)
+	}
+
+	return client, secret, nil
+}
+
+// FindClient returns the client with the given ID.
+// Returns autherrors.ErrInvalidClient if there is no such client.
+func (s *ClientService) FindClient(clientID string) (*models.Client, error) {
+
+	if client, ok := s.staticClients[clientID]; ok {
+		return client, nil
+	}
+
+	client, err := s.clientRepo.FindClient(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, autherrors.ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+// IdentifyClient returns the client making a request to the token endpoint.
+// Confidential clients must present their secret; public clients must not present any.
+// Returns autherrors.ErrInvalidClient if the client is unknown or the credentials don't match.
+func (s *ClientService) IdentifyClient(clientID string, clientSecret string) (*models.Client, error) {
+
+	if clientID == "" {
+		return nil, autherrors.ErrInvalidClient
+	}
+
+	client, err := s.FindClient(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.IsConfidential() {
+		if clientSecret != "" {
+			return nil, autherrors.ErrInvalidClient
+		}
+		return client, nil
+	}
+
+	inputHash := s.hashService.HashToken(clientSecret)
+	if clientSecret == "" || subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(inputHash)) != 1 {
+		return nil, autherrors.ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+// AuthenticateClient checks the credentials of a confidential client.
+// Returns autherrors.ErrInvalidClient if the client is unknown, public or the secret is wrong.
+func (s *ClientService) AuthenticateClient(clientID string, clientSecret string) error {
+
+	client, err := s.IdentifyClient(clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+	if !client.IsConfidential() {
+		return autherrors.ErrInvalidClient
+	}
+
+	return nil
+}
+
+// generateRandomValue returns size random bytes, base64url encoded, for use as a secret or one-time code.
+func generateRandomValue(size int) (string, error) {
+	value := make([]byte, size)
+	if _, err := rand.Read(value); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(value), nil
+}
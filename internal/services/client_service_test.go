@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+)
+
+type ClientServiceTestSuite struct {
+	suite.Suite
+	ctrl           *gomock.Controller
+	mockClientRepo *mocks.MockIClientRepository
+	hashService    *HashService
+	clientService  *ClientService
+	publicClient   *models.Client
+}
+
+func (s *ClientServiceTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockClientRepo = mocks.NewMockIClientRepository(s.ctrl)
+	s.hashService = NewHashService()
+	s.clientService = NewClientService(s.mockClientRepo, s.hashService, map[string]string{
+		"api-gateway": "gateway-secret",
+	})
+	s.publicClient = &models.Client{
+		ID:           "planner-spa",
+		Name:         "Planner",
+		RedirectURIs: []string{"https://planner.example.com/callback"},
+		Scopes:       []string{"openid"},
+	}
+}
+
+func (s *ClientServiceTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *ClientServiceTestSuite) TestAuthenticateClientSuccess() {
+	err := s.clientService.AuthenticateClient("api-gateway", "gateway-secret")
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *ClientServiceTestSuite) TestAuthenticateClientRejected() {
+	s.mockClientRepo.EXPECT().FindClient("legacy-service").Return(nil, nil)
+	s.mockClientRepo.EXPECT().FindClient(s.publicClient.ID).Return(s.publicClient, nil)
+
+	testCases := []struct {
+		name     string
+		clientID string
+		secret   string
+	}{
+		{name: "wrong secret", clientID: "api-gateway", secret: "other-secret"},
+		{name: "empty secret", clientID: "api-gateway", secret: ""},
+		{name: "unknown client", clientID: "legacy-service", secret: "gateway-secret"},
+		{name: "public client", clientID: s.publicClient.ID, secret: ""},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			err := s.clientService.AuthenticateClient(tc.clientID, tc.secret)
+
+			assert.ErrorIs(s.T(), err, autherrors.ErrInvalidClient)
+		})
+	}
+}
+
+func (s *ClientServiceTestSuite) TestRegisterConfidentialClient() {
+	var saved *models.Client
+	s.mockClientRepo.EXPECT().
+		CreateClient(gomock.Any()).
+		DoAndReturn(func(client *models.Client) error {
+			saved = client
+			return nil
+		})
+
+	client, secret, err := s.clientService.RegisterClient("Reports", []string{"https://reports.example.com/cb"}, nil, true)
+
+	require.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), client.ID)
+	assert.NotEmpty(s.T(), secret)
+	assert.Same(s.T(), saved, client)
+	assert.True(s.T(), client.IsConfidential())
+	assert.Equal(s.T(), s.hashService.HashToken(secret), client.SecretHash, "only the hash of the secret should be stored")
+
+	s.mockClientRepo.EXPECT().FindClient(client.ID).Return(client, nil)
+	_, err = s.clientService.IdentifyClient(client.ID, secret)
+	assert.NoError(s.T(), err)
+}
+
+func (s *ClientServiceTestSuite) TestRegisterPublicClient() {
+	s.mockClientRepo.EXPECT().CreateClient(gomock.Any()).Return(nil)
+
+	client, secret, err := s.clientService.RegisterClient("Planner", s.publicClient.RedirectURIs, s.publicClient.Scopes, false)
+
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), secret)
+	assert.False(s.T(), client.IsConfidential())
+}
+
+func (s *ClientServiceTestSuite) TestRegisterServiceAccount() {
+	s.mockClientRepo.EXPECT().CreateClient(gomock.Any()).Return(nil)
+
+	client, secret, err := s.clientService.RegisterServiceAccount("Nightly export", []string{"plans:read"})
+
+	require.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), secret)
+	assert.True(s.T(), client.IsConfidential())
+	assert.Empty(s.T(), client.RedirectURIs, "service accounts don't sign users in")
+	assert.Equal(s.T(), []string{"plans:read"}, client.Scopes)
+}
+
+func (s *ClientServiceTestSuite) TestRegisterClientStorageError() {
+	s.mockClientRepo.EXPECT().CreateClient(gomock.Any()).Return(errors.New("db down"))
+
+	client, _, err := s.clientService.RegisterClient("Planner", s.publicClient.RedirectURIs, s.publicClient.Scopes, false)
+
+	assert.Error(s.T(), err)
+	assert.Nil(s.T(), client)
+}
+
+func (s *ClientServiceTestSuite) TestIdentifyPublicClient() {
+	s.mockClientRepo.EXPECT().FindClient(s.publicClient.ID).Return(s.publicClient, nil).Times(2)
+
+	client, err := s.clientService.IdentifyClient(s.publicClient.ID, "")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.publicClient, client)
+
+	_, err = s.clientService.IdentifyClient(s.publicClient.ID, "unexpected-secret")
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidClient)
+}
+
+func (s *ClientServiceTestSuite) TestFindClientStorageError() {
+	storageErr := errors.New("db down")
+	s.mockClientRepo.EXPECT().FindClient(s.publicClient.ID).Return(nil, storageErr)
+
+	_, err := s.clientService.FindClient(s.publicClient.ID)
+
+	assert.ErrorIs(s.T(), err, storageErr)
+	assert.NotErrorIs(s.T(), err, autherrors.ErrInvalidClient)
+}
+
+func TestClientServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ClientServiceTestSuite))
+}
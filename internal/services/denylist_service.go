@@ -0,0 +1,94 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// IDenylistRepository defines the interface for persisting revoked access tokens.
+type IDenylistRepository interface {
+	SaveEntry(entry *models.DenylistEntry) error
+	IsDenylisted(jti string) (bool, error)
+	DeleteExpiredEntries() error
+}
+
+// DenylistService tracks revoked access tokens by their jti.
+// Revocations are stored in the repository so that every instance of the service sees them,
+// and cached in memory until the token expires so that repeated checks of a revoked token skip the database.
+// Tokens that are not in the cache are always looked up in the repository, as another instance may have revoked them.
+type DenylistService struct {
+	denylistRepo IDenylistRepository
+	mu           sync.RWMutex
+	cache        map[string]time.Time
+}
+
+// NewDenylistService creates a new denylist service instance.
+func NewDenylistService(denylistRepo IDenylistRepository) *DenylistService {
+	return &DenylistService{
+		denylistRepo: denylistRepo,
+		cache:        make(map[string]time.Time),
+	}
+}
+
+// Revoke adds the parsed access token to the denylist until it expires.
+// Entries of tokens that have expired meanwhile are purged from the cache and the repository.
+func (s *DenylistService) Revoke(token *models.ParsedToken) error {
+
+	entry := models.DenylistEntry{
+		JTI:       token.JTI,
+		UserID:    token.UserID,
+		ExpiresAt: token.ExpiresAt,
+	}
+
+	if err := s.denylistRepo.SaveEntry(&entry); err != nil {
+		return autherrors.ErrRevokeToken(err)
+	}
+
+	s.mu.Lock()
+	s.cache[token.JTI] = token.ExpiresAt
+	s.purgeExpiredLocked(time.Now())
+	s.mu.Unlock()
+
+	purgeExpired("access token denylist", s.denylistRepo.DeleteExpiredEntries)
+
+	return nil
+}
+
+// IsRevoked reports whether the parsed access token has been revoked.
+// Revocations found in the repository are cached until the token expires.
+func (s *DenylistService) IsRevoked(token *models.ParsedToken) (bool, error) {
+
+	s.mu.RLock()
+	_, cached := s.cache[token.JTI]
+	s.mu.RUnlock()
+
+	if cached {
+		return true, nil
+	}
+
+	revoked, err := s.denylistRepo.IsDenylisted(token.JTI)
+	if err != nil {
+		return false, err
+	}
+
+	if revoked {
+		s.mu.Lock()
+		s.cache[token.JTI] = token.ExpiresAt
+		s.purgeExpiredLocked(time.Now())
+		s.mu.Unlock()
+	}
+
+	return revoked, nil
+}
+
+// purgeExpiredLocked drops cached entries of expired tokens. The caller must hold s.mu.
+func (s *DenylistService) purgeExpiredLocked(now time.Time) {
+	for jti, expiresAt := range s.cache {
+		if !now.Before(expiresAt) {
+			delete(s.cache, jti)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+)
+
+type DenylistServiceTestSuite struct {
+	suite.Suite
+	ctrl             *gomock.Controller
+	mockDenylistRepo *mocks.MockIDenylistRepository
+	denylistService  *DenylistService
+	testToken        *models.ParsedToken
+}
+
+func (s *DenylistServiceTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockDenylistRepo = mocks.NewMockIDenylistRepository(s.ctrl)
+	s.denylistService = NewDenylistService(s.mockDenylistRepo)
+	s.testToken = &models.ParsedToken{
+		JTI:       uuid.NewString(),
+		UserID:    uuid.New(),
+		ExpiresAt: time.Now().UTC().Add(10 * time.Minute),
+	}
+}
+
+func (s *DenylistServiceTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *DenylistServiceTestSuite) TestRevokeSavesEntry() {
+	s.mockDenylistRepo.EXPECT().
+		SaveEntry(gomock.Any()).
+		DoAndReturn(func(entry *models.DenylistEntry) error {
+			assert.Equal(s.T(), s.testToken.JTI, entry.JTI)
+			assert.Equal(s.T(), s.testToken.UserID, entry.UserID)
+			assert.Equal(s.T(), s.testToken.ExpiresAt, entry.ExpiresAt)
+			return nil
+		})
+
+	s.mockDenylistRepo.EXPECT().
+		DeleteExpiredEntries().
+		Return(nil)
+
+	err := s.denylistService.Revoke(s.testToken)
+	require.NoError(s.T(), err)
+
+	// The revocation is served from the cache without a repository lookup
+	revoked, err := s.denylistService.IsRevoked(s.testToken)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), revoked)
+}
+
+func (s *DenylistServiceTestSuite) TestRevokeSaveError() {
+	s.mockDenylistRepo.EXPECT().
+		SaveEntry(gomock.Any()).
+		Return(errors.New("database error"))
+
+	err := s.denylistService.Revoke(s.testToken)
+
+	assert.ErrorContains(s.T(), err, "failed to revoke token")
+
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(s.testToken.JTI).
+		Return(false, nil)
+
+	revoked, err := s.denylistService.IsRevoked(s.testToken)
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), revoked, "failed revocations must not be cached")
+}
+
+func (s *DenylistServiceTestSuite) TestRevokePurgeError() {
+	s.mockDenylistRepo.EXPECT().
+		SaveEntry(gomock.Any()).
+		Return(nil)
+	s.mockDenylistRepo.EXPECT().
+		DeleteExpiredEntries().
+		Return(errors.New("database error"))
+
+	err := s.denylistService.Revoke(s.testToken)
+	require.NoError(s.T(), err, "the token is revoked even if purging expired entries fails")
+
+	revoked, err := s.denylistService.IsRevoked(s.testToken)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), revoked)
+}
+
+func (s *DenylistServiceTestSuite) TestIsRevokedCachesRepositoryHits() {
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(s.testToken.JTI).
+		Return(true, nil).
+		Times(1)
+
+	for range 3 {
+		revoked, err := s.denylistService.IsRevoked(s.testToken)
+		assert.NoError(s.T(), err)
+		assert.True(s.T(), revoked)
+	}
+}
+
+func (s *DenylistServiceTestSuite) TestIsRevokedDoesNotCacheMisses() {
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(s.testToken.JTI).
+		Return(false, nil).
+		Times(2)
+
+	for range 2 {
+		revoked, err := s.denylistService.IsRevoked(s.testToken)
+		assert.NoError(s.T(), err)
+		assert.False(s.T(), revoked)
+	}
+}
+
+func (s *DenylistServiceTestSuite) TestRevokePurgesExpiredCacheEntries() {
+	expiredToken := &models.ParsedToken{
+		JTI:       uuid.NewString(),
+		ExpiresAt: time.Now().UTC().Add(-time.Minute),
+	}
+	s.denylistService.cache[expiredToken.JTI] = expiredToken.ExpiresAt
+
+	s.mockDenylistRepo.EXPECT().SaveEntry(gomock.Any()).Return(nil)
+	s.mockDenylistRepo.EXPECT().DeleteExpiredEntries().Return(nil)
+
+	err := s.denylistService.Revoke(s.testToken)
+	require.NoError(s.T(), err)
+
+	assert.NotContains(s.T(), s.denylistService.cache, expiredToken.JTI)
+	assert.Contains(s.T(), s.denylistService.cache, s.testToken.JTI)
+}
+
+func (s *DenylistServiceTestSuite) TestIsRevokedRepositoryError() {
+	s.mockDenylistRepo.EXPECT().
+		IsDenylisted(s.testToken.JTI).
+		Return(false, errors.New("database error"))
+
+	revoked, err := s.denylistService.IsRevoked(s.testToken)
+
+	assert.False(s.T(), revoked)
+	assert.ErrorContains(s.T(), err, "database error")
+}
+
+func TestDenylistServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(DenylistServiceTestSuite))
+}
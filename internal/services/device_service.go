@@ -0,0 +1,315 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+const (
+	// deviceCodeSize is the number of random bytes in device codes.
+	deviceCodeSize = 32
+	// userCodeAlphabet leaves out vowels, so user codes don't spell words, and characters that are easily confused.
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	// userCodeLength is the number of characters in user codes, shown in two groups of four (RFC 8628, section 6.1).
+	userCodeLength = 8
+	// userCodeAttempts is how often a new user code is generated if the previous one is already in use.
+	userCodeAttempts = 3
+	// slowDownIncrement is added to the poll interval each time the device polls too fast (RFC 8628, section 3.5).
+	slowDownIncrement = 5 * time.Second
+)
+
+// IDeviceAuthorizationRepository defines the interface for device authorization persistence.
+type IDeviceAuthorizationRepository interface {
+	SaveDeviceAuthorization(auth *models.DeviceAuthorization) error
+	FindByUserCode(userCodeHash string) (*models.DeviceAuthorization, error)
+	DecideDeviceAuthorization(userCodeHash string, userID uuid.UUID, status models.DeviceAuthorizationStatus) (*models.DeviceAuthorization, error)
+	PollDeviceAuthorization(deviceCodeHash string, polledAt time.Time) (*models.DeviceAuthorization, error)
+	UpdatePollInterval(deviceCodeHash string, interval time.Duration) error
+	ConsumeDeviceAuthorization(deviceCodeHash string) (*models.DeviceAuthorization, error)
+	DeleteExpiredDeviceAuthorizations() error
+}
+
+// DeviceAuthorizationService implements the OAuth 2.0 device authorization grant (RFC 8628)
+// for clients that can't show a browser or where typing a password is impractical, such as the planner CLI
+// and the wall display. The device shows a user code, the signed-in user approves it on another device,
+// and meanwhile the device polls the token endpoint with its device code.
+type DeviceAuthorizationService struct {
+	clientService   IClientService
+	deviceRepo      IDeviceAuthorizationRepository
+	userService     IUserService
+	roleService     IRoleService
+	tokenService    ITokenService
+	hashService     IHashService
+	verificationURI string
+	codeDuration    time.Duration
+	pollInterval    time.Duration
+}
+
+// NewDeviceAuthorizationService creates a new device authorization service instance.
+// verificationURI is the page where users enter the user code, codeDuration is how long the user has
+// to approve a device and pollInterval is the minimum time between two token requests of the device.
+func NewDeviceAuthorizationService(clientService IClientService, deviceRepo IDeviceAuthorizationRepository,
+	userService IUserService, roleService IRoleService, tokenService ITokenService, hashService IHashService,
+	verificationURI string, codeDuration time.Duration, pollInterval time.Duration) *DeviceAuthorizationService {
+	return &DeviceAuthorizationService{
+		clientService:   clientService,
+		deviceRepo:      deviceRepo,
+		userService:     userService,
+		roleService:     roleService,
+		tokenService:    tokenService,
+		hashService:     hashService,
+		verificationURI: verificationURI,
+		codeDuration:    codeDuration,
+		pollInterval:    pollInterval,
+	}
+}
+
+// StartDeviceAuthorization issues a device code and a user code for the client (RFC 8628, section 3.1).
+// Confidential clients must authenticate; public clients such as the CLI only send their client ID.
+// Returns autherrors.ErrInvalidScope if the client may not request one of the scopes.
+func (s *DeviceAuthorizationService) StartDeviceAuthorization(clientID string, clientSecret string,
+	scopes []string) (*models.DeviceCodes, error) {
+
+	client, err := s.clientService.IdentifyClient(clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkScopes(client, scopes); err != nil {
+		return nil, err
+	}
+
+	deviceCode, err := generateRandomValue(deviceCodeSize)
+	if err != nil {
+		return nil, autherrors.ErrSaveDeviceAuthorization(err)
+	}
+
+	auth := models.DeviceAuthorization{
+		DeviceCodeHash: s.hashService.HashToken(deviceCode),
+		ClientID:       client.ID,
+		Scopes:         scopes,
+		Status:         models.DeviceAuthorizationPending,
+		PollInterval:   s.pollInterval,
+		ExpiresAt:      time.Now().UTC().Add(s.codeDuration),
+	}
+	userCode, err := s.saveWithUserCode(&auth)
+	if err != nil {
+		return nil, err
+	}
+
+	purgeExpired("device authorizations", s.deviceRepo.DeleteExpiredDeviceAuthorizations)
+
+	return &models.DeviceCodes{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         s.verificationURI,
+		VerificationURIComplete: s.verificationURIComplete(userCode),
+		ExpiresAt:               auth.ExpiresAt,
+		Interval:                auth.PollInterval,
+	}, nil
+}
+
+// saveWithUserCode stores the request with a new user code and returns the code.
+// User codes are short enough to collide with those of other requests, so a taken code is replaced a few times.
+func (s *DeviceAuthorizationService) saveWithUserCode(auth *models.DeviceAuthorization) (string, error) {
+
+	for attempt := 1; ; attempt++ {
+		userCode, err := generateUserCode()
+		if err != nil {
+			return "", autherrors.ErrSaveDeviceAuthorization(err)
+		}
+
+		auth.UserCodeHash = s.hashService.HashToken(normalizeUserCode(userCode))
+		err = s.deviceRepo.SaveDeviceAuthorization(auth)
+		if errors.Is(err, autherrors.ErrUserCodeTaken) && attempt < userCodeAttempts {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		return userCode, nil
+	}
+}
+
+// verificationURIComplete returns the verification URI with the user code filled in,
+// for devices that can show it as a QR code or link. It is empty if the verification URI can't be parsed.
+func (s *DeviceAuthorizationService) verificationURIComplete(userCode string) string {
+	target, err := url.Parse(s.verificationURI)
+	if err != nil {
+		return ""
+	}
+
+	query := target.Query()
+	query.Set("user_code", userCode)
+	target.RawQuery = query.Encode()
+
+	return target.String()
+}
+
+// FindPendingAuthorization returns the request the user code was issued for and the client that started it,
+// so the user can check which app they are about to sign in. The user code is matched case-insensitively
+// and with or without its dash; unknown, expired and already decided codes return autherrors.ErrInvalidUserCode.
+func (s *DeviceAuthorizationService) FindPendingAuthorization(userCode string) (*models.DeviceAuthorization,
+	*models.Client, error) {
+
+	auth, err := s.deviceRepo.FindByUserCode(s.hashService.HashToken(normalizeUserCode(userCode)))
+	if errors.Is(err, autherrors.ErrDeviceCodeNotFound) {
+		return nil, nil, autherrors.ErrInvalidUserCode
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if auth.Status != models.DeviceAuthorizationPending || !auth.ExpiresAt.After(time.Now().UTC()) {
+		return nil, nil, autherrors.ErrInvalidUserCode
+	}
+
+	client, err := s.clientService.FindClient(auth.ClientID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return auth, client, nil
+}
+
+// DecideDeviceAuthorization records whether the signed-in user approves the device showing the user code.
+// On approval the device's next poll receives tokens for the user; on denial it receives access_denied.
+func (s *DeviceAuthorizationService) DecideDeviceAuthorization(userCode string, userID uuid.UUID, approve bool) error {
+
+	status := models.DeviceAuthorizationDenied
+	if approve {
+		status = models.DeviceAuthorizationApproved
+	}
+
+	_, err := s.deviceRepo.DecideDeviceAuthorization(s.hashService.HashToken(normalizeUserCode(userCode)), userID, status)
+	if errors.Is(err, autherrors.ErrDeviceCodeNotFound) {
+		return autherrors.ErrInvalidUserCode
+	}
+
+	return err
+}
+
+// PollDeviceToken answers a token request of the device (RFC 8628, section 3.4). Until the user decides
+// it fails with autherrors.ErrAuthorizationPending, or autherrors.ErrSlowDown if the device polls faster
+// than its interval, which is then increased. Once approved, the device receives a token pair for the user,
+// exactly once.
+func (s *DeviceAuthorizationService) PollDeviceToken(clientID string, clientSecret string, deviceCode string,
+	session models.SessionMetadata) (*models.IssuedTokens, error) {
+
+	client, err := s.clientService.IdentifyClient(clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	deviceCodeHash := s.hashService.HashToken(deviceCode)
+
+	auth, err := s.deviceRepo.PollDeviceAuthorization(deviceCodeHash, now)
+	if errors.Is(err, autherrors.ErrDeviceCodeNotFound) {
+		return nil, autherrors.ErrInvalidDeviceGrant("unknown device code")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case auth.ClientID != client.ID:
+		return nil, autherrors.ErrInvalidDeviceGrant("device code was issued to another client")
+	case !auth.ExpiresAt.After(now):
+		return nil, autherrors.ErrDeviceCodeExpired
+	}
+
+	switch auth.Status {
+	case models.DeviceAuthorizationDenied:
+		return nil, autherrors.ErrAccessDenied
+
+	case models.DeviceAuthorizationApproved:
+		return s.issueDeviceTokens(deviceCodeHash, session)
+
+	default:
+		if auth.LastPolledAt != nil && now.Sub(*auth.LastPolledAt) < auth.PollInterval {
+			if err := s.deviceRepo.UpdatePollInterval(deviceCodeHash, auth.PollInterval+slowDownIncrement); err != nil {
+				return nil, err
+			}
+			return nil, autherrors.ErrSlowDown
+		}
+		return nil, autherrors.ErrAuthorizationPending
+	}
+}
+
+// issueDeviceTokens redeems the approved request for a token pair starting a new session of the user.
+func (s *DeviceAuthorizationService) issueDeviceTokens(deviceCodeHash string,
+	session models.SessionMetadata) (*models.IssuedTokens, error) {
+
+	auth, err := s.deviceRepo.ConsumeDeviceAuthorization(deviceCodeHash)
+	if errors.Is(err, autherrors.ErrDeviceCodeNotFound) {
+		return nil, autherrors.ErrInvalidDeviceGrant("device code already used")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.FindUser(&models.UserFilter{ID: auth.UserID})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, autherrors.ErrInvalidDeviceGrant("user no longer exists")
+	}
+
+	if err := s.roleService.LoadAuthorization(user); err != nil {
+		return nil, err
+	}
+
+	scopes := grantedScopes(user, auth.Scopes)
+	accessToken, refreshToken, err := s.tokenService.CreateTokenPairForClient(user, auth.ClientID, scopes, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.IssuedTokens{AccessToken: accessToken, RefreshToken: refreshToken, Scopes: scopes}, nil
+}
+
+// generateUserCode returns a random user code formatted as two groups of four characters, e.g. "WDJB-MJHT".
+func generateUserCode() (string, error) {
+	return generateGroupedCode(userCodeAlphabet, userCodeLength)
+}
+
+// generateGroupedCode returns a random code of the given length from the alphabet, split into two groups by a dash
+// so it is easier to read and type.
+func generateGroupedCode(alphabet string, length int) (string, error) {
+	var code strings.Builder
+	limit := big.NewInt(int64(len(alphabet)))
+
+	for i := range length {
+		if i == length/2 {
+			code.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(alphabet[n.Int64()])
+	}
+
+	return code.String(), nil
+}
+
+// normalizeUserCode makes user codes typed by the user comparable: case and separators don't matter.
+func normalizeUserCode(userCode string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(userCode))
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+)
+
+type DeviceAuthorizationServiceTestSuite struct {
+	suite.Suite
+	ctrl              *gomock.Controller
+	mockClientService *mocks.MockIClientService
+	mockDeviceRepo    *mocks.MockIDeviceAuthorizationRepository
+	mockUserService   *mocks.MockIUserService
+	mockRoleService   *mocks.MockIRoleService
+	mockTokenService  *mocks.MockITokenService
+	hashService       *HashService
+	deviceService     *DeviceAuthorizationService
+	client            *models.Client
+	user              *models.User
+	session           models.SessionMetadata
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockClientService = mocks.NewMockIClientService(s.ctrl)
+	s.mockDeviceRepo = mocks.NewMockIDeviceAuthorizationRepository(s.ctrl)
+	s.mockUserService = mocks.NewMockIUserService(s.ctrl)
+	s.mockRoleService = mocks.NewMockIRoleService(s.ctrl)
+	s.mockTokenService = mocks.NewMockITokenService(s.ctrl)
+	s.hashService = NewHashService()
+	s.deviceService = NewDeviceAuthorizationService(s.mockClientService, s.mockDeviceRepo, s.mockUserService,
+		s.mockRoleService, s.mockTokenService, s.hashService, "https://planner.example.com/device", 10*time.Minute, 5*time.Second)
+
+	s.client = &models.Client{ID: "planner-cli", Name: "Planner CLI", Scopes: []string{"plans:read"}}
+	s.user = &models.User{ID: uuid.New(), Login: "planner-user", Permissions: []string{"plans:read"}}
+	s.session = models.SessionMetadata{UserAgent: "planner-cli/1.4", IPAddress: "203.0.113.7"}
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+// pendingAuthorization returns a pending, unexpired request of the suite's client.
+func (s *DeviceAuthorizationServiceTestSuite) pendingAuthorization() *models.DeviceAuthorization {
+	return &models.DeviceAuthorization{
+		DeviceCodeHash: s.hashService.HashToken("device-code"),
+		UserCodeHash:   s.hashService.HashToken("WDJBMJHT"),
+		ClientID:       s.client.ID,
+		Status:         models.DeviceAuthorizationPending,
+		PollInterval:   5 * time.Second,
+		ExpiresAt:      time.Now().UTC().Add(time.Minute),
+	}
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) TestStartDeviceAuthorization() {
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+
+	var saved *models.DeviceAuthorization
+	s.mockDeviceRepo.EXPECT().
+		SaveDeviceAuthorization(gomock.Any()).
+		DoAndReturn(func(auth *models.DeviceAuthorization) error {
+			saved = auth
+			return nil
+		})
+	s.mockDeviceRepo.EXPECT().DeleteExpiredDeviceAuthorizations().Return(errors.New("purge failed"))
+
+	codes, err := s.deviceService.StartDeviceAuthorization(s.client.ID, "", []string{"plans:read"})
+
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), saved)
+	assert.Regexp(s.T(), regexp.MustCompile(`^[BCDFGHJKLMNPQRSTVWXZ]{4}-[BCDFGHJKLMNPQRSTVWXZ]{4}$`), codes.UserCode)
+	assert.NotEmpty(s.T(), codes.DeviceCode)
+	assert.Equal(s.T(), 5*time.Second, codes.Interval)
+	assert.Equal(s.T(), "https://planner.example.com/device", codes.VerificationURI)
+	assert.Equal(s.T(), "https://planner.example.com/device?user_code="+codes.UserCode, codes.VerificationURIComplete)
+	assert.WithinDuration(s.T(), time.Now().UTC().Add(10*time.Minute), codes.ExpiresAt, 5*time.Second)
+
+	assert.Equal(s.T(), s.hashService.HashToken(codes.DeviceCode), saved.DeviceCodeHash, "only hashes should be stored")
+	assert.Equal(s.T(), s.hashService.HashToken(normalizeUserCode(codes.UserCode)), saved.UserCodeHash)
+	assert.Equal(s.T(), s.client.ID, saved.ClientID)
+	assert.Equal(s.T(), []string{"plans:read"}, saved.Scopes)
+	assert.Equal(s.T(), models.DeviceAuthorizationPending, saved.Status)
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) TestStartDeviceAuthorizationRetriesTakenUserCode() {
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+
+	var userCodeHashes []string
+	gomock.InOrder(
+		s.mockDeviceRepo.EXPECT().
+			SaveDeviceAuthorization(gomock.Any()).
+			DoAndReturn(func(auth *models.DeviceAuthorization) error {
+				userCodeHashes = append(userCodeHashes, auth.UserCodeHash)
+				return autherrors.ErrUserCodeTaken
+			}),
+		s.mockDeviceRepo.EXPECT().
+			SaveDeviceAuthorization(gomock.Any()).
+			DoAndReturn(func(auth *models.DeviceAuthorization) error {
+				userCodeHashes = append(userCodeHashes, auth.UserCodeHash)
+				return nil
+			}),
+	)
+	s.mockDeviceRepo.EXPECT().DeleteExpiredDeviceAuthorizations().Return(nil)
+
+	codes, err := s.deviceService.StartDeviceAuthorization(s.client.ID, "", nil)
+
+	require.NoError(s.T(), err)
+	require.Len(s.T(), userCodeHashes, 2)
+	assert.Equal(s.T(), s.hashService.HashToken(normalizeUserCode(codes.UserCode)), userCodeHashes[1],
+		"the code of the saved request is returned")
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) TestStartDeviceAuthorizationUserCodesTaken() {
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+	s.mockDeviceRepo.EXPECT().
+		SaveDeviceAuthorization(gomock.Any()).
+		Return(autherrors.ErrUserCodeTaken).
+		Times(userCodeAttempts)
+
+	codes, err := s.deviceService.StartDeviceAuthorization(s.client.ID, "", nil)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrUserCodeTaken)
+	assert.Nil(s.T(), codes)
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) TestStartDeviceAuthorizationInvalidClient() {
+	s.mockClientService.EXPECT().IdentifyClient("unknown", "").Return(nil, autherrors.ErrInvalidClient)
+
+	_, err := s.deviceService.StartDeviceAuthorization("unknown", "", nil)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidClient)
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) TestStartDeviceAuthorizationScopeNotAllowed() {
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+
+	_, err := s.deviceService.StartDeviceAuthorization(s.client.ID, "", []string{"plans:read", "plans:write"})
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidScope)
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) TestFindPendingAuthorization() {
+	auth := s.pendingAuthorization()
+	s.mockDeviceRepo.EXPECT().FindByUserCode(s.hashService.HashToken("WDJBMJHT")).Return(auth, nil)
+	s.mockClientService.EXPECT().FindClient(s.client.ID).Return(s.client, nil)
+
+	found, client, err := s.deviceService.FindPendingAuthorization("wdjb mjht")
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), auth, found)
+	assert.Equal(s.T(), s.client, client)
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) TestFindPendingAuthorizationRejected() {
+	decided := s.pendingAuthorization()
+	decided.Status = models.DeviceAuthorizationApproved
+	expired := s.pendingAuthorization()
+	expired.ExpiresAt = time.Now().UTC().Add(-time.Second)
+
+	s.mockDeviceRepo.EXPECT().FindByUserCode(gomock.Any()).Return(nil, autherrors.ErrDeviceCodeNotFound)
+	s.mockDeviceRepo.EXPECT().FindByUserCode(gomock.Any()).Return(decided, nil)
+	s.mockDeviceRepo.EXPECT().FindByUserCode(gomock.Any()).Return(expired, nil)
+
+	for range 3 {
+		_, _, err := s.deviceService.FindPendingAuthorization("WDJB-MJHT")
+		assert.ErrorIs(s.T(), err, autherrors.ErrInvalidUserCode)
+	}
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) TestDecideDeviceAuthorization() {
+	userCodeHash := s.hashService.HashToken("WDJBMJHT")
+
+	s.mockDeviceRepo.EXPECT().
+		DecideDeviceAuthorization(userCodeHash, s.user.ID, models.DeviceAuthorizationApproved).
+		Return(s.pendingAuthorization(), nil)
+	assert.NoError(s.T(), s.deviceService.DecideDeviceAuthorization("WDJB-MJHT", s.user.ID, true))
+
+	s.mockDeviceRepo.EXPECT().
+		DecideDeviceAuthorization(userCodeHash, s.user.ID, models.DeviceAuthorizationDenied).
+		Return(s.pendingAuthorization(), nil)
+	assert.NoError(s.T(), s.deviceService.DecideDeviceAuthorization("wdjb-mjht", s.user.ID, false))
+
+	s.mockDeviceRepo.EXPECT().
+		DecideDeviceAuthorization(userCodeHash, s.user.ID, models.DeviceAuthorizationApproved).
+		Return(nil, autherrors.ErrDeviceCodeNotFound)
+	err := s.deviceService.DecideDeviceAuthorization("WDJB-MJHT", s.user.ID, true)
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidUserCode)
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) TestPollDeviceTokenApproved() {
+	approved := s.pendingAuthorization()
+	approved.Status = models.DeviceAuthorizationApproved
+	approved.UserID = &s.user.ID
+	approved.Scopes = []string{"plans:read"}
+
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+	s.mockDeviceRepo.EXPECT().PollDeviceAuthorization(approved.DeviceCodeHash, gomock.Any()).Return(approved, nil)
+	s.mockDeviceRepo.EXPECT().ConsumeDeviceAuthorization(approved.DeviceCodeHash).Return(approved, nil)
+	s.mockUserService.EXPECT().FindUser(&models.UserFilter{ID: &s.user.ID}).Return(s.user, nil)
+	s.mockRoleService.EXPECT().LoadAuthorization(s.user).Return(nil)
+	s.mockTokenService.EXPECT().
+		CreateTokenPairForClient(s.user, s.client.ID, []string{"plans:read"}, s.session).
+		Return(&models.Token{Value: "access"}, &models.Token{Value: "refresh"}, nil)
+
+	tokens, err := s.deviceService.PollDeviceToken(s.client.ID, "", "device-code", s.session)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "access", tokens.AccessToken.Value)
+	assert.Equal(s.T(), "refresh", tokens.RefreshToken.Value)
+	assert.Equal(s.T(), []string{"plans:read"}, tokens.Scopes)
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) TestPollDeviceTokenWithoutPermission() {
+	approved := s.pendingAuthorization()
+	approved.Status = models.DeviceAuthorizationApproved
+	approved.UserID = &s.user.ID
+	approved.Scopes = []string{"plans:read"}
+	s.user.Permissions = nil
+
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+	s.mockDeviceRepo.EXPECT().PollDeviceAuthorization(approved.DeviceCodeHash, gomock.Any()).Return(approved, nil)
+	s.mockDeviceRepo.EXPECT().ConsumeDeviceAuthorization(approved.DeviceCodeHash).Return(approved, nil)
+	s.mockUserService.EXPECT().FindUser(&models.UserFilter{ID: &s.user.ID}).Return(s.user, nil)
+	s.mockRoleService.EXPECT().LoadAuthorization(s.user).Return(nil)
+	s.mockTokenService.EXPECT().
+		CreateTokenPairForClient(s.user, s.client.ID, nil, s.session).
+		Return(&models.Token{Value: "access"}, &models.Token{Value: "refresh"}, nil)
+
+	tokens, err := s.deviceService.PollDeviceToken(s.client.ID, "", "device-code", s.session)
+
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), tokens.Scopes, "scopes the user lacks are not granted")
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) TestPollDeviceTokenSlowDown() {
+	polled := s.pendingAuthorization()
+	lastPoll := time.Now().UTC().Add(-2 * time.Second)
+	polled.LastPolledAt = &lastPoll
+
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+	s.mockDeviceRepo.EXPECT().PollDeviceAuthorization(polled.DeviceCodeHash, gomock.Any()).Return(polled, nil)
+	s.mockDeviceRepo.EXPECT().UpdatePollInterval(polled.DeviceCodeHash, 10*time.Second).Return(nil)
+
+	_, err := s.deviceService.PollDeviceToken(s.client.ID, "", "device-code", s.session)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrSlowDown)
+}
+
+func (s *DeviceAuthorizationServiceTestSuite) TestPollDeviceTokenRejected() {
+	testCases := []struct {
+		name     string
+		modify   func(auth *models.DeviceAuthorization)
+		notFound bool
+		consumed bool
+		err      error
+	}{
+		{
+			name:   "pending",
+			modify: func(*models.DeviceAuthorization) {},
+			err:    autherrors.ErrAuthorizationPending,
+		},
+		{
+			name: "pending after the interval",
+			modify: func(a *models.DeviceAuthorization) {
+				lastPoll := time.Now().UTC().Add(-6 * time.Second)
+				a.LastPolledAt = &lastPoll
+			},
+			err: autherrors.ErrAuthorizationPending,
+		},
+		{
+			name:   "denied",
+			modify: func(a *models.DeviceAuthorization) { a.Status = models.DeviceAuthorizationDenied },
+			err:    autherrors.ErrAccessDenied,
+		},
+		{
+			name:   "expired",
+			modify: func(a *models.DeviceAuthorization) { a.ExpiresAt = time.Now().UTC().Add(-time.Second) },
+			err:    autherrors.ErrDeviceCodeExpired,
+		},
+		{
+			name:   "other client",
+			modify: func(a *models.DeviceAuthorization) { a.ClientID = "other-client" },
+			err:    autherrors.ErrInvalidGrant,
+		},
+		{
+			name:     "unknown device code",
+			notFound: true,
+			err:      autherrors.ErrInvalidGrant,
+		},
+		{
+			name:     "already redeemed",
+			modify:   func(a *models.DeviceAuthorization) { a.Status = models.DeviceAuthorizationApproved },
+			consumed: true,
+			err:      autherrors.ErrInvalidGrant,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+			if tc.notFound {
+				s.mockDeviceRepo.EXPECT().PollDeviceAuthorization(gomock.Any(), gomock.Any()).
+					Return(nil, autherrors.ErrDeviceCodeNotFound)
+			} else {
+				auth := s.pendingAuthorization()
+				tc.modify(auth)
+				s.mockDeviceRepo.EXPECT().PollDeviceAuthorization(gomock.Any(), gomock.Any()).Return(auth, nil)
+			}
+			if tc.consumed {
+				s.mockDeviceRepo.EXPECT().ConsumeDeviceAuthorization(gomock.Any()).Return(nil, autherrors.ErrDeviceCodeNotFound)
+			}
+
+			tokens, err := s.deviceService.PollDeviceToken(s.client.ID, "", "device-code", s.session)
+
+			assert.ErrorIs(s.T(), err, tc.err)
+			assert.Nil(s.T(), tokens)
+		})
+	}
+}
+
+func TestDeviceAuthorizationServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(DeviceAuthorizationServiceTestSuite))
+}
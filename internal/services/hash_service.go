@@ -1,20 +1,73 @@
 package services
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
+	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
 )
 
+// argon2idPrefix starts password hashes in the PHC string format produced by HashPassword,
+// e.g. "$argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>".
+const argon2idPrefix = "$argon2id$"
+
+// Argon2Params are the cost parameters of Argon2id password hashes.
+type Argon2Params struct {
+	// Memory is the memory used per hash in KiB.
+	Memory uint32
+	// Iterations is the number of passes over the memory.
+	Iterations uint32
+	// Parallelism is the number of threads used per hash.
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follow the OWASP recommendation of 19 MiB, two iterations and one thread.
+var DefaultArgon2Params = Argon2Params{
+	Memory:      constants.DefaultArgon2Memory,
+	Iterations:  constants.DefaultArgon2Iterations,
+	Parallelism: constants.DefaultArgon2Parallelism,
+	SaltLength:  constants.DefaultArgon2SaltLength,
+	KeyLength:   constants.DefaultArgon2KeyLength,
+}
+
 // HashService provides cryptographic hashing functionality for tokens and passwords.
-type HashService struct{}
+// Passwords are hashed with Argon2id; bcrypt hashes of older accounts are still verified.
+type HashService struct {
+	argon2Params Argon2Params
+}
+
+// HashServiceOption is a function that modifies the HashService configuration.
+type HashServiceOption func(*HashService)
 
-// NewHashService creates a new hash service instance.
-func NewHashService() *HashService {
-	return &HashService{}
+// WithArgon2Params sets the cost parameters of new password hashes.
+// Hashes with other parameters are still verified, but reported by NeedsRehash.
+func WithArgon2Params(params Argon2Params) HashServiceOption {
+	return func(s *HashService) {
+		s.argon2Params = params
+	}
+}
+
+// NewHashService creates a new hash service instance hashing passwords with DefaultArgon2Params
+// unless configured otherwise.
+func NewHashService(opts ...HashServiceOption) *HashService {
+	s := &HashService{
+		argon2Params: DefaultArgon2Params,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // HashToken creates a SHA-256 hash of the provided token string.
@@ -23,25 +76,87 @@ func (s *HashService) HashToken(token string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// HashPassword generates a bcrypt hash of the provided password.
+// HashPassword generates an Argon2id hash of the provided password with a random salt.
 func (s *HashService) HashPassword(password string) (string, error) {
 
-	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+	params := s.argon2Params
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
 		return "", autherrors.ErrPassHash(err)
 	}
 
-	return string(passHash), nil
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
 
 }
 
-// ComparePasswords verifies that the input password matches the stored hash.
+// ComparePasswords verifies that the input password matches the stored Argon2id or bcrypt hash.
 func (s *HashService) ComparePasswords(passHash, input string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(passHash), []byte(input))
+
+	if !strings.HasPrefix(passHash, argon2idPrefix) {
+		err := bcrypt.CompareHashAndPassword([]byte(passHash), []byte(input))
+		if err != nil {
+			return autherrors.ErrWrongPassword(err)
+		}
+		return nil
+	}
+
+	params, salt, key, err := decodeArgon2Hash(passHash)
 	if err != nil {
 		return autherrors.ErrWrongPassword(err)
 	}
 
+	inputKey := argon2.IDKey([]byte(input), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, inputKey) != 1 {
+		return autherrors.ErrWrongPassword(autherrors.ErrHashMismatch)
+	}
+
 	return nil
 
 }
+
+// NeedsRehash reports whether the stored hash was made with another algorithm or other parameters
+// than new hashes, so it should be replaced once the password is known.
+func (s *HashService) NeedsRehash(passHash string) bool {
+
+	params, _, _, err := decodeArgon2Hash(passHash)
+
+	return err != nil || params != s.argon2Params
+
+}
+
+// decodeArgon2Hash parses an Argon2id hash in the PHC string format into its parameters, salt and key.
+func decodeArgon2Hash(passHash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	parts := strings.Split(passHash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, autherrors.ErrInvalidPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, autherrors.ErrInvalidPasswordHash
+	}
+
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, autherrors.ErrInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, autherrors.ErrInvalidPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, autherrors.ErrInvalidPasswordHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
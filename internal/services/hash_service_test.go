@@ -7,6 +7,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
 )
 
 type HashServiceTestSuite struct {
@@ -53,7 +56,8 @@ func (s *HashServiceTestSuite) TestHashPasswordSuccess() {
 
 	require.NoError(s.T(), err)
 	assert.NotEmpty(s.T(), hashedPassword)
-	assert.True(s.T(), strings.HasPrefix(hashedPassword, "$2a$"), "Bcrypt hash should start with $2a$")
+	assert.True(s.T(), strings.HasPrefix(hashedPassword, "$argon2id$v=19$m=19456,t=2,p=1$"),
+		"Argon2id hash should be in PHC format with the default parameters")
 }
 
 func (s *HashServiceTestSuite) TestHashPasswordUnique() {
@@ -65,7 +69,7 @@ func (s *HashServiceTestSuite) TestHashPasswordUnique() {
 	hash2, err2 := s.hashService.HashPassword(password)
 	require.NoError(s.T(), err2)
 
-	assert.NotEqual(s.T(), hash1, hash2, "Bcrypt should produce different hashes for same password due to random salt")
+	assert.NotEqual(s.T(), hash1, hash2, "Argon2id should produce different hashes for same password due to random salt")
 }
 
 func (s *HashServiceTestSuite) TestComparePasswordsSuccess() {
@@ -102,6 +106,52 @@ func (s *HashServiceTestSuite) TestComparePasswordsInvalidHash() {
 	assert.ErrorContains(s.T(), err, "wrong password")
 }
 
+func (s *HashServiceTestSuite) TestComparePasswordsBcrypt() {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("legacyPassword"), bcrypt.MinCost)
+	require.NoError(s.T(), err)
+
+	assert.NoError(s.T(), s.hashService.ComparePasswords(string(bcryptHash), "legacyPassword"))
+	assert.ErrorIs(s.T(), s.hashService.ComparePasswords(string(bcryptHash), "wrongPassword"), autherrors.ErrPasswordMismatch)
+}
+
+func (s *HashServiceTestSuite) TestComparePasswordsCustomParams() {
+	params := Argon2Params{Memory: 8 * 1024, Iterations: 3, Parallelism: 2, SaltLength: 16, KeyLength: 32}
+	hashedPassword, err := NewHashService(WithArgon2Params(params)).HashPassword("tunedPassword")
+	require.NoError(s.T(), err)
+	assert.True(s.T(), strings.HasPrefix(hashedPassword, "$argon2id$v=19$m=8192,t=3,p=2$"))
+
+	assert.NoError(s.T(), s.hashService.ComparePasswords(hashedPassword, "tunedPassword"),
+		"hashes with other parameters are still verified")
+}
+
+func (s *HashServiceTestSuite) TestComparePasswordsMalformedArgon2Hash() {
+	for _, passHash := range []string{
+		"$argon2id$v=19$m=19456,t=2,p=1$c2FsdA",
+		"$argon2id$v=16$m=19456,t=2,p=1$c2FsdHNhbHRzYWx0$a2V5",
+		"$argon2id$v=19$m=19456,t=0,p=1$c2FsdHNhbHRzYWx0$a2V5",
+		"$argon2id$v=19$m=19456,t=2,p=1$!!!$a2V5",
+	} {
+		err := s.hashService.ComparePasswords(passHash, "password123")
+		assert.ErrorIs(s.T(), err, autherrors.ErrInvalidPasswordHash, passHash)
+	}
+}
+
+func (s *HashServiceTestSuite) TestNeedsRehash() {
+	currentHash, err := s.hashService.HashPassword("password123")
+	require.NoError(s.T(), err)
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(s.T(), err)
+	weakerParams := DefaultArgon2Params
+	weakerParams.Iterations = 1
+	weakerHash, err := NewHashService(WithArgon2Params(weakerParams)).HashPassword("password123")
+	require.NoError(s.T(), err)
+
+	assert.False(s.T(), s.hashService.NeedsRehash(currentHash))
+	assert.True(s.T(), s.hashService.NeedsRehash(string(bcryptHash)))
+	assert.True(s.T(), s.hashService.NeedsRehash(weakerHash))
+	assert.True(s.T(), s.hashService.NeedsRehash("not_a_valid_hash"))
+}
+
 func TestHashServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(HashServiceTestSuite))
 }
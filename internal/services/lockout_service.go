@@ -0,0 +1,163 @@
+package services
+
+import (
+	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// ILoginAttemptRepository defines the interface for failed login attempt persistence operations.
+type ILoginAttemptRepository interface {
+	FindAttempts(key models.LoginAttemptKey) (*models.LoginAttempts, error)
+	RecordFailure(key models.LoginAttemptKey, failedAt time.Time, windowStart time.Time) (*models.LoginAttempts, error)
+	Lock(key models.LoginAttemptKey, until time.Time) error
+	DeleteAttempts(key models.LoginAttemptKey) error
+	DeleteStaleAttempts(before time.Time) error
+}
+
+// LockoutPolicy configures when logins are locked after failed attempts.
+type LockoutPolicy struct {
+	// LoginThreshold is the number of failed attempts for one login from which the login is locked;
+	// 0 disables locking logins.
+	LoginThreshold int
+	// IPThreshold is the number of failed attempts from one IP address, for any logins, from which the address
+	// is locked; 0 disables locking IP addresses.
+	IPThreshold int
+	// Duration is how long the first lock lasts; every further failure doubles it, up to MaxDuration,
+	// which must not be shorter.
+	Duration    time.Duration
+	MaxDuration time.Duration
+	// FailureWindow is how long failures are remembered: counting starts over after this long without one.
+	FailureWindow time.Duration
+}
+
+// DefaultLockoutPolicy locks a login after 5 and an IP address after 20 failures within a day,
+// for a minute at first and up to an hour.
+var DefaultLockoutPolicy = LockoutPolicy{
+	LoginThreshold: constants.DefaultLockoutLoginThreshold,
+	IPThreshold:    constants.DefaultLockoutIPThreshold,
+	Duration:       constants.DefaultLockoutDuration,
+	MaxDuration:    constants.DefaultLockoutMaxDuration,
+	FailureWindow:  constants.DefaultLockoutFailureWindow,
+}
+
+// LockoutService protects password logins against brute force by counting failed attempts per login
+// and per client IP address and locking them with exponential backoff once they reach the policy's thresholds.
+// Counting per login stops guessing the password of one account, counting per IP address stops
+// trying common passwords on many accounts.
+type LockoutService struct {
+	attemptRepo ILoginAttemptRepository
+	policy      LockoutPolicy
+}
+
+// NewLockoutService creates a new lockout service instance.
+func NewLockoutService(attemptRepo ILoginAttemptRepository, policy LockoutPolicy) *LockoutService {
+	return &LockoutService{
+		attemptRepo: attemptRepo,
+		policy:      policy,
+	}
+}
+
+// CheckLocked returns an *autherrors.LockoutError if the login or the IP address is locked.
+// Locks apply to unknown logins as well, so they don't reveal which logins exist.
+func (s *LockoutService) CheckLocked(login string, ipAddress string) error {
+
+	now := time.Now().UTC()
+	var lockedUntil time.Time
+
+	for _, key := range s.keys(login, ipAddress) {
+		attempts, err := s.attemptRepo.FindAttempts(key)
+		if err != nil {
+			return err
+		}
+		if attempts != nil && attempts.LockedUntil != nil && attempts.LockedUntil.After(now) &&
+			attempts.LockedUntil.After(lockedUntil) {
+			lockedUntil = *attempts.LockedUntil
+		}
+	}
+
+	if !lockedUntil.IsZero() {
+		return &autherrors.LockoutError{Until: lockedUntil}
+	}
+
+	return nil
+
+}
+
+// RecordFailure counts a failed login attempt for the login and the IP address
+// and locks those that reached their threshold.
+func (s *LockoutService) RecordFailure(login string, ipAddress string) error {
+
+	now := time.Now().UTC()
+	windowStart := now.Add(-s.policy.FailureWindow)
+
+	for _, key := range s.keys(login, ipAddress) {
+		attempts, err := s.attemptRepo.RecordFailure(key, now, windowStart)
+		if err != nil {
+			return err
+		}
+
+		if duration, locked := s.lockDuration(key.Kind, attempts.FailedCount); locked {
+			if err := s.attemptRepo.Lock(key, now.Add(duration)); err != nil {
+				return err
+			}
+		}
+	}
+
+	purgeExpired("login attempts", func() error { return s.attemptRepo.DeleteStaleAttempts(windowStart) })
+
+	return nil
+
+}
+
+// RecordSuccess resets the failed attempts of the login after a successful login.
+// The attempts of the IP address are kept: otherwise an attacker guessing passwords of many accounts
+// could reset the count of the address by signing in to an account of their own now and then.
+func (s *LockoutService) RecordSuccess(login string) error {
+	return s.attemptRepo.DeleteAttempts(models.LoginAttemptKey{Kind: models.LoginAttemptLogin, Value: login})
+}
+
+// UnlockLogin lifts the lock of the login and resets its failed attempts, e.g. once an administrator
+// verified the user. Unlocking a login that isn't locked succeeds.
+func (s *LockoutService) UnlockLogin(login string) error {
+	return s.attemptRepo.DeleteAttempts(models.LoginAttemptKey{Kind: models.LoginAttemptLogin, Value: login})
+}
+
+// UnlockIPAddress lifts the lock of the IP address and resets its failed attempts, e.g. for an office
+// whose users share one address. Unlocking an address that isn't locked succeeds.
+func (s *LockoutService) UnlockIPAddress(ipAddress string) error {
+	return s.attemptRepo.DeleteAttempts(models.LoginAttemptKey{Kind: models.LoginAttemptIPAddress, Value: ipAddress})
+}
+
+// keys returns the keys attempts are counted for. Attempts without a known IP address are counted for the login only.
+func (s *LockoutService) keys(login string, ipAddress string) []models.LoginAttemptKey {
+	keys := []models.LoginAttemptKey{{Kind: models.LoginAttemptLogin, Value: login}}
+	if ipAddress != "" {
+		keys = append(keys, models.LoginAttemptKey{Kind: models.LoginAttemptIPAddress, Value: ipAddress})
+	}
+	return keys
+}
+
+// lockDuration returns how long a key is locked after the given number of failures:
+// the policy's duration at the threshold, doubled for every further failure, up to the maximum.
+func (s *LockoutService) lockDuration(kind models.LoginAttemptKind, failures int) (time.Duration, bool) {
+	threshold := s.policy.LoginThreshold
+	if kind == models.LoginAttemptIPAddress {
+		threshold = s.policy.IPThreshold
+	}
+	if threshold <= 0 || failures < threshold {
+		return 0, false
+	}
+
+	duration := s.policy.Duration
+	for range failures - threshold {
+		if duration >= s.policy.MaxDuration {
+			break
+		}
+		duration *= 2
+	}
+
+	return min(duration, s.policy.MaxDuration), true
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+)
+
+type LockoutServiceTestSuite struct {
+	suite.Suite
+	ctrl           *gomock.Controller
+	mockRepo       *mocks.MockILoginAttemptRepository
+	lockoutService *LockoutService
+	loginKey       models.LoginAttemptKey
+	ipKey          models.LoginAttemptKey
+}
+
+func (s *LockoutServiceTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockRepo = mocks.NewMockILoginAttemptRepository(s.ctrl)
+	s.lockoutService = NewLockoutService(s.mockRepo, LockoutPolicy{
+		LoginThreshold: 3,
+		IPThreshold:    10,
+		Duration:       time.Minute,
+		MaxDuration:    10 * time.Minute,
+		FailureWindow:  time.Hour,
+	})
+	s.loginKey = models.LoginAttemptKey{Kind: models.LoginAttemptLogin, Value: "alice"}
+	s.ipKey = models.LoginAttemptKey{Kind: models.LoginAttemptIPAddress, Value: "203.0.113.7"}
+}
+
+func (s *LockoutServiceTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *LockoutServiceTestSuite) TestCheckLocked() {
+	now := time.Now().UTC()
+	expired := now.Add(-time.Minute)
+	soon := now.Add(time.Minute)
+	later := now.Add(5 * time.Minute)
+
+	testCases := []struct {
+		name        string
+		loginLocked *time.Time
+		ipLocked    *time.Time
+		until       *time.Time
+	}{
+		{name: "not locked"},
+		{name: "lock expired", loginLocked: &expired},
+		{name: "login locked", loginLocked: &soon, until: &soon},
+		{name: "IP address locked", ipLocked: &soon, until: &soon},
+		{name: "both locked", loginLocked: &soon, ipLocked: &later, until: &later},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.mockRepo.EXPECT().FindAttempts(s.loginKey).
+				Return(&models.LoginAttempts{Key: s.loginKey, FailedCount: 3, LockedUntil: tc.loginLocked}, nil)
+			var ipAttempts *models.LoginAttempts
+			if tc.ipLocked != nil {
+				ipAttempts = &models.LoginAttempts{Key: s.ipKey, FailedCount: 10, LockedUntil: tc.ipLocked}
+			}
+			s.mockRepo.EXPECT().FindAttempts(s.ipKey).Return(ipAttempts, nil)
+
+			err := s.lockoutService.CheckLocked("alice", "203.0.113.7")
+
+			if tc.until == nil {
+				assert.NoError(s.T(), err)
+				return
+			}
+			var lockoutErr *autherrors.LockoutError
+			require.ErrorAs(s.T(), err, &lockoutErr)
+			assert.ErrorIs(s.T(), err, autherrors.ErrAccountLocked)
+			assert.Equal(s.T(), *tc.until, lockoutErr.Until)
+		})
+	}
+}
+
+func (s *LockoutServiceTestSuite) TestCheckLockedWithoutIPAddress() {
+	s.mockRepo.EXPECT().FindAttempts(s.loginKey).Return(nil, nil)
+
+	assert.NoError(s.T(), s.lockoutService.CheckLocked("alice", ""))
+}
+
+func (s *LockoutServiceTestSuite) TestCheckLockedStorageError() {
+	storageErr := errors.New("connection refused")
+	s.mockRepo.EXPECT().FindAttempts(s.loginKey).Return(nil, storageErr)
+
+	assert.ErrorIs(s.T(), s.lockoutService.CheckLocked("alice", "203.0.113.7"), storageErr)
+}
+
+func (s *LockoutServiceTestSuite) TestRecordFailureLocksWithBackoff() {
+	testCases := []struct {
+		failures int
+		duration time.Duration
+	}{
+		{failures: 1},
+		{failures: 2},
+		{failures: 3, duration: time.Minute},
+		{failures: 4, duration: 2 * time.Minute},
+		{failures: 6, duration: 8 * time.Minute},
+		{failures: 7, duration: 10 * time.Minute},
+		{failures: 200, duration: 10 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		s.Run(fmt.Sprintf("%d failures", tc.failures), func() {
+			var failedAt time.Time
+			s.mockRepo.EXPECT().RecordFailure(s.loginKey, gomock.Any(), gomock.Any()).
+				DoAndReturn(func(key models.LoginAttemptKey, at time.Time, windowStart time.Time) (*models.LoginAttempts, error) {
+					failedAt = at
+					assert.Equal(s.T(), at.Add(-time.Hour), windowStart)
+					return &models.LoginAttempts{Key: key, FailedCount: tc.failures}, nil
+				})
+			s.mockRepo.EXPECT().RecordFailure(s.ipKey, gomock.Any(), gomock.Any()).
+				Return(&models.LoginAttempts{Key: s.ipKey, FailedCount: tc.failures}, nil)
+			if tc.duration > 0 {
+				s.mockRepo.EXPECT().Lock(s.loginKey, gomock.Any()).DoAndReturn(func(_ models.LoginAttemptKey, until time.Time) error {
+					assert.Equal(s.T(), tc.duration, until.Sub(failedAt))
+					return nil
+				})
+			}
+			if tc.failures >= 10 {
+				s.mockRepo.EXPECT().Lock(s.ipKey, gomock.Any()).Return(nil)
+			}
+			s.mockRepo.EXPECT().DeleteStaleAttempts(gomock.Any()).Return(nil)
+
+			assert.NoError(s.T(), s.lockoutService.RecordFailure("alice", "203.0.113.7"))
+		})
+	}
+}
+
+func (s *LockoutServiceTestSuite) TestRecordFailureIgnoresPurgeError() {
+	s.mockRepo.EXPECT().RecordFailure(s.loginKey, gomock.Any(), gomock.Any()).
+		Return(&models.LoginAttempts{Key: s.loginKey, FailedCount: 1}, nil)
+	s.mockRepo.EXPECT().DeleteStaleAttempts(gomock.Any()).Return(errors.New("connection refused"))
+
+	assert.NoError(s.T(), s.lockoutService.RecordFailure("alice", ""))
+}
+
+func (s *LockoutServiceTestSuite) TestRecordFailureDisabledThreshold() {
+	lockoutService := NewLockoutService(s.mockRepo, LockoutPolicy{
+		LoginThreshold: 3,
+		Duration:       time.Minute,
+		MaxDuration:    time.Hour,
+		FailureWindow:  time.Hour,
+	})
+
+	s.mockRepo.EXPECT().RecordFailure(s.loginKey, gomock.Any(), gomock.Any()).
+		Return(&models.LoginAttempts{Key: s.loginKey, FailedCount: 1}, nil)
+	s.mockRepo.EXPECT().RecordFailure(s.ipKey, gomock.Any(), gomock.Any()).
+		Return(&models.LoginAttempts{Key: s.ipKey, FailedCount: 1000}, nil)
+	s.mockRepo.EXPECT().DeleteStaleAttempts(gomock.Any()).Return(nil)
+
+	assert.NoError(s.T(), lockoutService.RecordFailure("alice", "203.0.113.7"), "IP addresses should never be locked")
+}
+
+func (s *LockoutServiceTestSuite) TestRecordSuccessResetsLoginAttempts() {
+	// Only the login is reset; the IP address keeps counting failures for other logins
+	s.mockRepo.EXPECT().DeleteAttempts(s.loginKey).Return(nil)
+
+	assert.NoError(s.T(), s.lockoutService.RecordSuccess("alice"))
+}
+
+func (s *LockoutServiceTestSuite) TestUnlock() {
+	s.mockRepo.EXPECT().DeleteAttempts(s.loginKey).Return(nil)
+	s.mockRepo.EXPECT().DeleteAttempts(s.ipKey).Return(nil)
+
+	assert.NoError(s.T(), s.lockoutService.UnlockLogin("alice"))
+	assert.NoError(s.T(), s.lockoutService.UnlockIPAddress("203.0.113.7"))
+}
+
+func TestLockoutServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(LockoutServiceTestSuite))
+}
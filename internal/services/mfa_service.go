@@ -0,0 +1,364 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/totp"
+)
+
+const (
+	// recoveryCodeCount is the number of recovery codes issued at once.
+	recoveryCodeCount = 10
+	// recoveryCodeAlphabet adds digits to the user code alphabet; TOTP codes are digits only, so recovery codes
+	// are told apart by their length.
+	recoveryCodeAlphabet = userCodeAlphabet + "23456789"
+	// recoveryCodeLength is the number of characters in recovery codes, shown in two groups of five.
+	recoveryCodeLength = 10
+)
+
+// IMFARepository defines the interface for TOTP credential and recovery code persistence.
+type IMFARepository interface {
+	SaveTOTP(credential *models.TOTPCredential) error
+	FindTOTP(userID uuid.UUID) (*models.TOTPCredential, error)
+	ConfirmTOTP(userID uuid.UUID, step int64, codeHashes []string) error
+	UseTOTPStep(userID uuid.UUID, step int64) (bool, error)
+	DeleteTOTP(userID uuid.UUID) error
+	ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error
+	UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error)
+	CountRecoveryCodes(userID uuid.UUID) (int, error)
+}
+
+// ISecretCipher defines the encryption of TOTP secrets at rest.
+type ISecretCipher interface {
+	Seal(secret string) (string, error)
+	Open(value string) (string, error)
+}
+
+// MFAService manages two-factor authentication with TOTP authenticator apps (RFC 6238) and recovery codes.
+// Users enroll an authenticator by scanning the provisioning URI and confirm it with a first code, which also
+// issues their recovery codes. Recovery codes stand in for a code once each if the authenticator is lost;
+// only their hashes are stored.
+type MFAService struct {
+	mfaRepo     IMFARepository
+	userService IUserService
+	hashService IHashService
+	cipher      ISecretCipher
+	lockout     ILockoutService
+	issuer      string
+}
+
+// MFAServiceOption is a function that modifies the MFAService configuration.
+type MFAServiceOption func(*MFAService)
+
+// WithSecretCipher encrypts the TOTP secrets of new enrollments. Secrets stored unencrypted before stay readable.
+func WithSecretCipher(cipher ISecretCipher) MFAServiceOption {
+	return func(s *MFAService) {
+		s.cipher = cipher
+	}
+}
+
+// WithMFALockout counts wrong codes entered to confirm an authenticator, turn two-factor authentication off
+// or replace recovery codes as failed logins, like wrong codes at login, so a stolen access token can't be used
+// to guess them.
+func WithMFALockout(lockout ILockoutService) MFAServiceOption {
+	return func(s *MFAService) {
+		s.lockout = lockout
+	}
+}
+
+// NewMFAService creates a new MFA service instance.
+// The issuer names the service in authenticator apps. Without a secret cipher TOTP secrets are stored unencrypted.
+func NewMFAService(mfaRepo IMFARepository, userService IUserService, hashService IHashService, issuer string,
+	opts ...MFAServiceOption) *MFAService {
+	s := &MFAService{
+		mfaRepo:     mfaRepo,
+		userService: userService,
+		hashService: hashService,
+		issuer:      issuer,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// EnrollTOTP starts setting up an authenticator app for the user, replacing an enrollment that wasn't confirmed.
+// Returns autherrors.ErrMFAAlreadyEnabled if the user already has a confirmed authenticator.
+func (s *MFAService) EnrollTOTP(userID uuid.UUID) (*models.TOTPEnrollment, error) {
+
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := s.mfaRepo.FindTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if credential != nil && credential.IsConfirmed() {
+		return nil, autherrors.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, autherrors.ErrSaveTOTP(err)
+	}
+
+	stored := secret
+	if s.cipher != nil {
+		stored, err = s.cipher.Seal(secret)
+		if err != nil {
+			return nil, autherrors.ErrSaveTOTP(err)
+		}
+	}
+
+	if err := s.mfaRepo.SaveTOTP(&models.TOTPCredential{UserID: userID, Secret: stored}); err != nil {
+		return nil, err
+	}
+
+	return &models.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.issuer, user.Login, secret),
+	}, nil
+
+}
+
+// ConfirmTOTP enables two-factor authentication once the user entered a code of the enrolled authenticator,
+// proving it was set up correctly, and returns the user's new recovery codes. They are shown only this once.
+// Returns autherrors.ErrMFANotEnrolled if there is no pending enrollment and autherrors.ErrInvalidMFACode
+// if the code is wrong. With a lockout, wrong codes count as failed logins of the user and the IP address.
+func (s *MFAService) ConfirmTOTP(userID uuid.UUID, code string, ipAddress string) ([]string, error) {
+
+	credential, err := s.mfaRepo.FindTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, autherrors.ErrMFANotEnrolled
+	}
+	if credential.IsConfirmed() {
+		return nil, autherrors.ErrMFAAlreadyEnabled
+	}
+
+	var step int64
+	err = s.checkCode(userID, ipAddress, func() (err error) {
+		step, err = s.validateTOTP(credential, code)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.ConfirmTOTP(userID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+
+}
+
+// DisableTOTP removes the user's authenticator and recovery codes after checking a code or recovery code,
+// so that a stolen access token alone can't turn two-factor authentication off.
+func (s *MFAService) DisableTOTP(userID uuid.UUID, code string, ipAddress string) error {
+
+	if err := s.checkCode(userID, ipAddress, func() error { return s.Verify(userID, code) }); err != nil {
+		return err
+	}
+
+	return s.mfaRepo.DeleteTOTP(userID)
+
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user after checking a code or recovery code
+// and returns the new ones. Codes issued before, used or not, stop working.
+func (s *MFAService) RegenerateRecoveryCodes(userID uuid.UUID, code string, ipAddress string) ([]string, error) {
+
+	if err := s.checkCode(userID, ipAddress, func() error { return s.Verify(userID, code) }); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+
+}
+
+// Status reports whether the user has two-factor authentication enabled and how many recovery codes are left.
+func (s *MFAService) Status(userID uuid.UUID) (*models.MFAStatus, error) {
+
+	enabled, err := s.IsEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return &models.MFAStatus{}, nil
+	}
+
+	remaining, err := s.mfaRepo.CountRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MFAStatus{TOTPEnabled: true, RecoveryCodesRemaining: remaining}, nil
+
+}
+
+// IsEnabled reports whether the user has a confirmed authenticator and must enter a code at login.
+func (s *MFAService) IsEnabled(userID uuid.UUID) (bool, error) {
+
+	credential, err := s.mfaRepo.FindTOTP(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return credential != nil && credential.IsConfirmed(), nil
+
+}
+
+// Verify checks a code of the user's authenticator or one of the user's recovery codes, which is used up.
+// Each TOTP code is accepted only once. Returns autherrors.ErrInvalidMFACode if the code is wrong or was used
+// before and autherrors.ErrMFANotEnabled if the user has no confirmed authenticator.
+func (s *MFAService) Verify(userID uuid.UUID, code string) error {
+
+	credential, err := s.mfaRepo.FindTOTP(userID)
+	if err != nil {
+		return err
+	}
+	if credential == nil || !credential.IsConfirmed() {
+		return autherrors.ErrMFANotEnabled
+	}
+
+	code = normalizeUserCode(code)
+
+	if totp.IsCode(code) {
+		step, err := s.validateTOTP(credential, code)
+		if err != nil {
+			return err
+		}
+
+		used, err := s.mfaRepo.UseTOTPStep(userID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return autherrors.ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.mfaRepo.UseRecoveryCode(userID, s.hashService.HashToken(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return autherrors.ErrInvalidMFACode
+	}
+
+	return nil
+
+}
+
+// checkCode runs the check of a code entered by the user. With a lockout, locked logins and IP addresses are
+// rejected with an *autherrors.LockoutError before the check and wrong codes are recorded as failed logins,
+// the same as second factors at login.
+func (s *MFAService) checkCode(userID uuid.UUID, ipAddress string, check func() error) error {
+	if s.lockout == nil {
+		return check()
+	}
+
+	user, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.lockout.CheckLocked(user.Login, ipAddress); err != nil {
+		return err
+	}
+
+	if err := check(); err != nil {
+		if isWrongSecondFactor(err) {
+			if lockoutErr := s.lockout.RecordFailure(user.Login, ipAddress); lockoutErr != nil {
+				return lockoutErr
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// validateTOTP checks the code against the credential's secret and returns its time step.
+// Codes of steps up to the last accepted one are rejected as replays.
+func (s *MFAService) validateTOTP(credential *models.TOTPCredential, code string) (int64, error) {
+	secret, err := s.openSecret(credential.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok, err := totp.Validate(secret, normalizeUserCode(code), time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if !ok || step <= credential.LastUsedStep {
+		return 0, autherrors.ErrInvalidMFACode
+	}
+
+	return step, nil
+}
+
+// openSecret decrypts a stored secret. Encrypted secrets can't be read once the cipher is removed.
+func (s *MFAService) openSecret(stored string) (string, error) {
+	if s.cipher != nil {
+		return s.cipher.Open(stored)
+	}
+	if totp.IsSealed(stored) {
+		return "", autherrors.ErrNoEncryptionKey
+	}
+	return stored, nil
+}
+
+// generateRecoveryCodes returns a new set of recovery codes and their hashes.
+// Hashes are taken of the normalized codes, so users may type them in any case and without the dash.
+func (s *MFAService) generateRecoveryCodes() (codes []string, hashes []string, err error) {
+	codes = make([]string, 0, recoveryCodeCount)
+	hashes = make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		code, err := generateGroupedCode(recoveryCodeAlphabet, recoveryCodeLength)
+		if err != nil {
+			return nil, nil, autherrors.ErrSaveRecoveryCodes(err)
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, s.hashService.HashToken(normalizeUserCode(code)))
+	}
+
+	return codes, hashes, nil
+}
+
+// findUser returns the user with the ID or autherrors.ErrUserNotExist.
+func (s *MFAService) findUser(userID uuid.UUID) (*models.User, error) {
+	user, err := s.userService.FindUser(&models.UserFilter{ID: &userID})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, autherrors.ErrUserNotExist
+	}
+	return user, nil
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+	"github.com/breakfront-planner/auth-service/internal/totp"
+)
+
+type MFAServiceTestSuite struct {
+	suite.Suite
+	ctrl            *gomock.Controller
+	mockRepo        *mocks.MockIMFARepository
+	mockUserService *mocks.MockIUserService
+	hashService     *HashService
+	mfaService      *MFAService
+	user            *models.User
+	secret          string
+}
+
+func (s *MFAServiceTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockRepo = mocks.NewMockIMFARepository(s.ctrl)
+	s.mockUserService = mocks.NewMockIUserService(s.ctrl)
+	s.hashService = NewHashService()
+	s.mfaService = NewMFAService(s.mockRepo, s.mockUserService, s.hashService, "Breakfront")
+	s.user = &models.User{ID: uuid.New(), Login: "alice"}
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(s.T(), err)
+	s.secret = secret
+}
+
+func (s *MFAServiceTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+// currentCode returns the code of the test secret now.
+func (s *MFAServiceTestSuite) currentCode() string {
+	code, err := totp.GenerateCode(s.secret, time.Now())
+	require.NoError(s.T(), err)
+	return code
+}
+
+// confirmed returns a confirmed credential with the test secret whose last code is from the given step.
+func (s *MFAServiceTestSuite) confirmed(lastUsedStep int64) *models.TOTPCredential {
+	confirmedAt := time.Now().Add(-time.Hour)
+	return &models.TOTPCredential{UserID: s.user.ID, Secret: s.secret, ConfirmedAt: &confirmedAt,
+		LastUsedStep: lastUsedStep}
+}
+
+func (s *MFAServiceTestSuite) TestEnrollTOTP() {
+	var saved *models.TOTPCredential
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(s.user, nil)
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(nil, nil)
+	s.mockRepo.EXPECT().SaveTOTP(gomock.Any()).DoAndReturn(func(credential *models.TOTPCredential) error {
+		saved = credential
+		return nil
+	})
+
+	enrollment, err := s.mfaService.EnrollTOTP(s.user.ID)
+
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), saved)
+	assert.Equal(s.T(), enrollment.Secret, saved.Secret, "without a cipher the secret is stored as is")
+	uri, err := url.Parse(enrollment.ProvisioningURI)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "/Breakfront:alice", uri.Path)
+	assert.Equal(s.T(), enrollment.Secret, uri.Query().Get("secret"))
+}
+
+func (s *MFAServiceTestSuite) TestEnrollTOTPEncryptsSecret() {
+	cipher, err := totp.NewCipher(make([]byte, 32))
+	require.NoError(s.T(), err)
+	mfaService := NewMFAService(s.mockRepo, s.mockUserService, s.hashService, "Breakfront", WithSecretCipher(cipher))
+
+	var saved *models.TOTPCredential
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(s.user, nil)
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(nil, nil)
+	s.mockRepo.EXPECT().SaveTOTP(gomock.Any()).DoAndReturn(func(credential *models.TOTPCredential) error {
+		saved = credential
+		return nil
+	})
+
+	enrollment, err := mfaService.EnrollTOTP(s.user.ID)
+
+	require.NoError(s.T(), err)
+	assert.True(s.T(), totp.IsSealed(saved.Secret))
+	opened, err := cipher.Open(saved.Secret)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), enrollment.Secret, opened)
+}
+
+func (s *MFAServiceTestSuite) TestEnrollTOTPAlreadyEnabled() {
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(s.user, nil)
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(s.confirmed(0), nil)
+
+	_, err := s.mfaService.EnrollTOTP(s.user.ID)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrMFAAlreadyEnabled)
+}
+
+func (s *MFAServiceTestSuite) TestConfirmTOTPIssuesRecoveryCodes() {
+	var hashes []string
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(&models.TOTPCredential{UserID: s.user.ID, Secret: s.secret}, nil)
+	s.mockRepo.EXPECT().ConfirmTOTP(s.user.ID, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ uuid.UUID, _ int64, codeHashes []string) error {
+			hashes = codeHashes
+			return nil
+		})
+
+	codes, err := s.mfaService.ConfirmTOTP(s.user.ID, s.currentCode(), "192.0.2.1")
+
+	require.NoError(s.T(), err)
+	require.Len(s.T(), codes, recoveryCodeCount)
+	require.Len(s.T(), hashes, recoveryCodeCount)
+	for i, code := range codes {
+		assert.Len(s.T(), code, recoveryCodeLength+1, "codes are two groups joined by a dash")
+		assert.Equal(s.T(), s.hashService.HashToken(strings.ReplaceAll(code, "-", "")), hashes[i],
+			"only the hashes of the codes are stored")
+	}
+}
+
+func (s *MFAServiceTestSuite) TestConfirmTOTPWrongCode() {
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(&models.TOTPCredential{UserID: s.user.ID, Secret: s.secret}, nil)
+
+	_, err := s.mfaService.ConfirmTOTP(s.user.ID, "abcdef", "192.0.2.1")
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidMFACode)
+}
+
+func (s *MFAServiceTestSuite) TestConfirmTOTPNotEnrolled() {
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(nil, nil)
+
+	_, err := s.mfaService.ConfirmTOTP(s.user.ID, s.currentCode(), "192.0.2.1")
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrMFANotEnrolled)
+}
+
+func (s *MFAServiceTestSuite) TestVerifyTOTPCode() {
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(s.confirmed(0), nil)
+	s.mockRepo.EXPECT().UseTOTPStep(s.user.ID, gomock.Any()).Return(true, nil)
+
+	assert.NoError(s.T(), s.mfaService.Verify(s.user.ID, s.currentCode()))
+}
+
+func (s *MFAServiceTestSuite) TestVerifyRejectsReplayedTOTPCode() {
+	code := s.currentCode()
+	step, ok, err := totp.Validate(s.secret, code, time.Now())
+	require.NoError(s.T(), err)
+	require.True(s.T(), ok)
+
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(s.confirmed(step), nil)
+
+	err = s.mfaService.Verify(s.user.ID, code)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidMFACode)
+}
+
+func (s *MFAServiceTestSuite) TestVerifyRejectsConcurrentlyUsedTOTPCode() {
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(s.confirmed(0), nil)
+	s.mockRepo.EXPECT().UseTOTPStep(s.user.ID, gomock.Any()).Return(false, nil)
+
+	err := s.mfaService.Verify(s.user.ID, s.currentCode())
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidMFACode)
+}
+
+func (s *MFAServiceTestSuite) TestVerifyRecoveryCode() {
+	// Recovery codes are accepted in any case and without the dash
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(s.confirmed(0), nil)
+	s.mockRepo.EXPECT().UseRecoveryCode(s.user.ID, s.hashService.HashToken("BCDFG23456")).Return(true, nil)
+
+	assert.NoError(s.T(), s.mfaService.Verify(s.user.ID, "bcdfg 23456"))
+}
+
+func (s *MFAServiceTestSuite) TestVerifyUnknownRecoveryCode() {
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(s.confirmed(0), nil)
+	s.mockRepo.EXPECT().UseRecoveryCode(s.user.ID, gomock.Any()).Return(false, nil)
+
+	err := s.mfaService.Verify(s.user.ID, "BCDFG-23456")
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidMFACode)
+}
+
+func (s *MFAServiceTestSuite) TestVerifyNotEnabled() {
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(&models.TOTPCredential{UserID: s.user.ID, Secret: s.secret}, nil)
+
+	err := s.mfaService.Verify(s.user.ID, s.currentCode())
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrMFANotEnabled, "pending enrollments are not enabled")
+}
+
+func (s *MFAServiceTestSuite) TestVerifyEncryptedSecretWithoutCipher() {
+	cipher, err := totp.NewCipher(make([]byte, 32))
+	require.NoError(s.T(), err)
+	credential := s.confirmed(0)
+	credential.Secret, err = cipher.Seal(s.secret)
+	require.NoError(s.T(), err)
+
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(credential, nil)
+
+	err = s.mfaService.Verify(s.user.ID, s.currentCode())
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrNoEncryptionKey)
+}
+
+func (s *MFAServiceTestSuite) TestDisableTOTP() {
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(s.confirmed(0), nil)
+	s.mockRepo.EXPECT().UseTOTPStep(s.user.ID, gomock.Any()).Return(true, nil)
+	s.mockRepo.EXPECT().DeleteTOTP(s.user.ID).Return(nil)
+
+	assert.NoError(s.T(), s.mfaService.DisableTOTP(s.user.ID, s.currentCode(), "192.0.2.1"))
+}
+
+func (s *MFAServiceTestSuite) TestDisableTOTPWrongCode() {
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(s.confirmed(0), nil)
+
+	err := s.mfaService.DisableTOTP(s.user.ID, "000000", "192.0.2.1")
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidMFACode)
+}
+
+func (s *MFAServiceTestSuite) TestWrongCodesCountAsFailedLogins() {
+	lockout := mocks.NewMockILockoutService(s.ctrl)
+	mfaService := NewMFAService(s.mockRepo, s.mockUserService, s.hashService, "Breakfront", WithMFALockout(lockout))
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(s.user, nil).Times(3)
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(s.confirmed(0), nil).Times(2)
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(&models.TOTPCredential{UserID: s.user.ID, Secret: s.secret}, nil)
+	s.mockRepo.EXPECT().UseRecoveryCode(s.user.ID, gomock.Any()).Return(false, nil)
+	lockout.EXPECT().CheckLocked(s.user.Login, "192.0.2.1").Return(nil).Times(3)
+	lockout.EXPECT().RecordFailure(s.user.Login, "192.0.2.1").Return(nil).Times(3)
+
+	err := mfaService.DisableTOTP(s.user.ID, "000000", "192.0.2.1")
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidMFACode)
+
+	_, err = mfaService.RegenerateRecoveryCodes(s.user.ID, "abcde-fghjk", "192.0.2.1")
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidMFACode)
+
+	_, err = mfaService.ConfirmTOTP(s.user.ID, "000000", "192.0.2.1")
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidMFACode)
+}
+
+func (s *MFAServiceTestSuite) TestLockedUserCantDisableTOTP() {
+	lockout := mocks.NewMockILockoutService(s.ctrl)
+	mfaService := NewMFAService(s.mockRepo, s.mockUserService, s.hashService, "Breakfront", WithMFALockout(lockout))
+	lockoutErr := &autherrors.LockoutError{Until: time.Now().Add(time.Minute)}
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(s.user, nil)
+	lockout.EXPECT().CheckLocked(s.user.Login, "192.0.2.1").Return(lockoutErr)
+
+	err := mfaService.DisableTOTP(s.user.ID, s.currentCode(), "192.0.2.1")
+
+	assert.ErrorIs(s.T(), err, lockoutErr)
+}
+
+func (s *MFAServiceTestSuite) TestRegenerateRecoveryCodes() {
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(s.confirmed(0), nil)
+	s.mockRepo.EXPECT().UseTOTPStep(s.user.ID, gomock.Any()).Return(true, nil)
+	s.mockRepo.EXPECT().ReplaceRecoveryCodes(s.user.ID, gomock.Len(recoveryCodeCount)).Return(nil)
+
+	codes, err := s.mfaService.RegenerateRecoveryCodes(s.user.ID, s.currentCode(), "192.0.2.1")
+
+	require.NoError(s.T(), err)
+	assert.Len(s.T(), codes, recoveryCodeCount)
+}
+
+func (s *MFAServiceTestSuite) TestStatus() {
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(s.confirmed(0), nil)
+	s.mockRepo.EXPECT().CountRecoveryCodes(s.user.ID).Return(7, nil)
+
+	status, err := s.mfaService.Status(s.user.ID)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), &models.MFAStatus{TOTPEnabled: true, RecoveryCodesRemaining: 7}, status)
+}
+
+func (s *MFAServiceTestSuite) TestStatusStorageError() {
+	storageErr := errors.New("connection refused")
+	s.mockRepo.EXPECT().FindTOTP(s.user.ID).Return(nil, storageErr)
+
+	_, err := s.mfaService.Status(s.user.ID)
+
+	assert.ErrorIs(s.T(), err, storageErr)
+}
+
+func TestMFAServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(MFAServiceTestSuite))
+}
@@ -3,7 +3,7 @@
 //
 // Generated by this command:
 //
-//	mockgen -source=internal/services/auth_service.go -destination=internal/services/mocks/mock_auth_service.go -package=mocks
+//	mockgen -source=internal/services/auth_service.go -destination=internal/services/mocks/mock_auth_service.go -package=mocks -exclude_interfaces=ITokenValidator
 //
 
 // Package mocks is a generated GoMock package.
@@ -11,8 +11,10 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	models "github.com/breakfront-planner/auth-service/internal/models"
+	uuid "github.com/google/uuid"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -84,6 +86,20 @@ func (mr *MockIUserServiceMockRecorder) FindUser(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUser", reflect.TypeOf((*MockIUserService)(nil).FindUser), arg0)
 }
 
+// InvalidateTokens mocks base method.
+func (m *MockIUserService) InvalidateTokens(userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidateTokens", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidateTokens indicates an expected call of InvalidateTokens.
+func (mr *MockIUserServiceMockRecorder) InvalidateTokens(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateTokens", reflect.TypeOf((*MockIUserService)(nil).InvalidateTokens), userID)
+}
+
 // MockITokenService is a mock of ITokenService interface.
 type MockITokenService struct {
 	ctrl     *gomock.Controller
@@ -108,10 +124,84 @@ func (m *MockITokenService) EXPECT() *MockITokenServiceMockRecorder {
 	return m.recorder
 }
 
+// CheckRefreshToken mocks base method.
+func (m *MockITokenService) CheckRefreshToken(token *models.Token) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckRefreshToken", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckRefreshToken indicates an expected call of CheckRefreshToken.
+func (mr *MockITokenServiceMockRecorder) CheckRefreshToken(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckRefreshToken", reflect.TypeOf((*MockITokenService)(nil).CheckRefreshToken), token)
+}
+
+// CreateClientToken mocks base method.
+func (m *MockITokenService) CreateClientToken(client *models.Client, scopes []string) (*models.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateClientToken", client, scopes)
+	ret0, _ := ret[0].(*models.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateClientToken indicates an expected call of CreateClientToken.
+func (mr *MockITokenServiceMockRecorder) CreateClientToken(client, scopes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateClientToken", reflect.TypeOf((*MockITokenService)(nil).CreateClientToken), client, scopes)
+}
+
+// CreateExchangedToken mocks base method.
+func (m *MockITokenService) CreateExchangedToken(subject *models.ParsedToken, audience string, scopes []string, actor *models.Actor) (*models.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExchangedToken", subject, audience, scopes, actor)
+	ret0, _ := ret[0].(*models.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateExchangedToken indicates an expected call of CreateExchangedToken.
+func (mr *MockITokenServiceMockRecorder) CreateExchangedToken(subject, audience, scopes, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExchangedToken", reflect.TypeOf((*MockITokenService)(nil).CreateExchangedToken), subject, audience, scopes, actor)
+}
+
+// CreateIDToken mocks base method.
+func (m *MockITokenService) CreateIDToken(user *models.User, clientID, nonce string, authTime time.Time) (*models.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIDToken", user, clientID, nonce, authTime)
+	ret0, _ := ret[0].(*models.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIDToken indicates an expected call of CreateIDToken.
+func (mr *MockITokenServiceMockRecorder) CreateIDToken(user, clientID, nonce, authTime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIDToken", reflect.TypeOf((*MockITokenService)(nil).CreateIDToken), user, clientID, nonce, authTime)
+}
+
+// CreateMFAChallenge mocks base method.
+func (m *MockITokenService) CreateMFAChallenge(user *models.User) (*models.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMFAChallenge", user)
+	ret0, _ := ret[0].(*models.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMFAChallenge indicates an expected call of CreateMFAChallenge.
+func (mr *MockITokenServiceMockRecorder) CreateMFAChallenge(user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMFAChallenge", reflect.TypeOf((*MockITokenService)(nil).CreateMFAChallenge), user)
+}
+
 // CreateNewTokenPair mocks base method.
-func (m *MockITokenService) CreateNewTokenPair(user *models.User) (*models.Token, *models.Token, error) {
+func (m *MockITokenService) CreateNewTokenPair(user *models.User, session models.SessionMetadata) (*models.Token, *models.Token, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateNewTokenPair", user)
+	ret := m.ctrl.Call(m, "CreateNewTokenPair", user, session)
 	ret0, _ := ret[0].(*models.Token)
 	ret1, _ := ret[1].(*models.Token)
 	ret2, _ := ret[2].(error)
@@ -119,30 +209,62 @@ func (m *MockITokenService) CreateNewTokenPair(user *models.User) (*models.Token
 }
 
 // CreateNewTokenPair indicates an expected call of CreateNewTokenPair.
-func (mr *MockITokenServiceMockRecorder) CreateNewTokenPair(user any) *gomock.Call {
+func (mr *MockITokenServiceMockRecorder) CreateNewTokenPair(user, session any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewTokenPair", reflect.TypeOf((*MockITokenService)(nil).CreateNewTokenPair), user, session)
+}
+
+// CreateTokenPairForClient mocks base method.
+func (m *MockITokenService) CreateTokenPairForClient(user *models.User, clientID string, scopes []string, session models.SessionMetadata) (*models.Token, *models.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTokenPairForClient", user, clientID, scopes, session)
+	ret0, _ := ret[0].(*models.Token)
+	ret1, _ := ret[1].(*models.Token)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateTokenPairForClient indicates an expected call of CreateTokenPairForClient.
+func (mr *MockITokenServiceMockRecorder) CreateTokenPairForClient(user, clientID, scopes, session any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewTokenPair", reflect.TypeOf((*MockITokenService)(nil).CreateNewTokenPair), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTokenPairForClient", reflect.TypeOf((*MockITokenService)(nil).CreateTokenPairForClient), user, clientID, scopes, session)
 }
 
-// ParseToken mocks base method.
-func (m *MockITokenService) ParseToken(tokenValue string) (*models.ParsedToken, error) {
+// CreateTokenPairForCode mocks base method.
+func (m *MockITokenService) CreateTokenPairForCode(user *models.User, code *models.AuthorizationCode, scopes []string, session models.SessionMetadata) (*models.Token, *models.Token, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ParseToken", tokenValue)
-	ret0, _ := ret[0].(*models.ParsedToken)
+	ret := m.ctrl.Call(m, "CreateTokenPairForCode", user, code, scopes, session)
+	ret0, _ := ret[0].(*models.Token)
+	ret1, _ := ret[1].(*models.Token)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateTokenPairForCode indicates an expected call of CreateTokenPairForCode.
+func (mr *MockITokenServiceMockRecorder) CreateTokenPairForCode(user, code, scopes, session any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTokenPairForCode", reflect.TypeOf((*MockITokenService)(nil).CreateTokenPairForCode), user, code, scopes, session)
+}
+
+// ListSessions mocks base method.
+func (m *MockITokenService) ListSessions(userID uuid.UUID) ([]*models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", userID)
+	ret0, _ := ret[0].([]*models.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ParseToken indicates an expected call of ParseToken.
-func (mr *MockITokenServiceMockRecorder) ParseToken(tokenValue any) *gomock.Call {
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockITokenServiceMockRecorder) ListSessions(userID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockITokenService)(nil).ParseToken), tokenValue)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockITokenService)(nil).ListSessions), userID)
 }
 
 // Refresh mocks base method.
-func (m *MockITokenService) Refresh(refreshToken *models.Token, user *models.User) (*models.Token, *models.Token, error) {
+func (m *MockITokenService) Refresh(refreshToken *models.Token, user *models.User, session models.SessionMetadata) (*models.Token, *models.Token, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Refresh", refreshToken, user)
+	ret := m.ctrl.Call(m, "Refresh", refreshToken, user, session)
 	ret0, _ := ret[0].(*models.Token)
 	ret1, _ := ret[1].(*models.Token)
 	ret2, _ := ret[2].(error)
@@ -150,9 +272,37 @@ func (m *MockITokenService) Refresh(refreshToken *models.Token, user *models.Use
 }
 
 // Refresh indicates an expected call of Refresh.
-func (mr *MockITokenServiceMockRecorder) Refresh(refreshToken, user any) *gomock.Call {
+func (mr *MockITokenServiceMockRecorder) Refresh(refreshToken, user, session any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockITokenService)(nil).Refresh), refreshToken, user, session)
+}
+
+// RevokeAllTokens mocks base method.
+func (m *MockITokenService) RevokeAllTokens(userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllTokens", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAllTokens indicates an expected call of RevokeAllTokens.
+func (mr *MockITokenServiceMockRecorder) RevokeAllTokens(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllTokens", reflect.TypeOf((*MockITokenService)(nil).RevokeAllTokens), userID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockITokenService) RevokeSession(userID, sessionID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockITokenServiceMockRecorder) RevokeSession(userID, sessionID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockITokenService)(nil).Refresh), refreshToken, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockITokenService)(nil).RevokeSession), userID, sessionID)
 }
 
 // RevokeToken mocks base method.
@@ -168,3 +318,311 @@ func (mr *MockITokenServiceMockRecorder) RevokeToken(token any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockITokenService)(nil).RevokeToken), token)
 }
+
+// MockIAccessTokenDenylist is a mock of IAccessTokenDenylist interface.
+type MockIAccessTokenDenylist struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAccessTokenDenylistMockRecorder
+	isgomock struct{}
+}
+
+// MockIAccessTokenDenylistMockRecorder is the mock recorder for MockIAccessTokenDenylist.
+type MockIAccessTokenDenylistMockRecorder struct {
+	mock *MockIAccessTokenDenylist
+}
+
+// NewMockIAccessTokenDenylist creates a new mock instance.
+func NewMockIAccessTokenDenylist(ctrl *gomock.Controller) *MockIAccessTokenDenylist {
+	mock := &MockIAccessTokenDenylist{ctrl: ctrl}
+	mock.recorder = &MockIAccessTokenDenylistMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIAccessTokenDenylist) EXPECT() *MockIAccessTokenDenylistMockRecorder {
+	return m.recorder
+}
+
+// Revoke mocks base method.
+func (m *MockIAccessTokenDenylist) Revoke(token *models.ParsedToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockIAccessTokenDenylistMockRecorder) Revoke(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockIAccessTokenDenylist)(nil).Revoke), token)
+}
+
+// MockIRoleService is a mock of IRoleService interface.
+type MockIRoleService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIRoleServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockIRoleServiceMockRecorder is the mock recorder for MockIRoleService.
+type MockIRoleServiceMockRecorder struct {
+	mock *MockIRoleService
+}
+
+// NewMockIRoleService creates a new mock instance.
+func NewMockIRoleService(ctrl *gomock.Controller) *MockIRoleService {
+	mock := &MockIRoleService{ctrl: ctrl}
+	mock.recorder = &MockIRoleServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIRoleService) EXPECT() *MockIRoleServiceMockRecorder {
+	return m.recorder
+}
+
+// LoadAuthorization mocks base method.
+func (m *MockIRoleService) LoadAuthorization(user *models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadAuthorization", user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LoadAuthorization indicates an expected call of LoadAuthorization.
+func (mr *MockIRoleServiceMockRecorder) LoadAuthorization(user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAuthorization", reflect.TypeOf((*MockIRoleService)(nil).LoadAuthorization), user)
+}
+
+// MockILockoutService is a mock of ILockoutService interface.
+type MockILockoutService struct {
+	ctrl     *gomock.Controller
+	recorder *MockILockoutServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockILockoutServiceMockRecorder is the mock recorder for MockILockoutService.
+type MockILockoutServiceMockRecorder struct {
+	mock *MockILockoutService
+}
+
+// NewMockILockoutService creates a new mock instance.
+func NewMockILockoutService(ctrl *gomock.Controller) *MockILockoutService {
+	mock := &MockILockoutService{ctrl: ctrl}
+	mock.recorder = &MockILockoutServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockILockoutService) EXPECT() *MockILockoutServiceMockRecorder {
+	return m.recorder
+}
+
+// CheckLocked mocks base method.
+func (m *MockILockoutService) CheckLocked(login, ipAddress string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckLocked", login, ipAddress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckLocked indicates an expected call of CheckLocked.
+func (mr *MockILockoutServiceMockRecorder) CheckLocked(login, ipAddress any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckLocked", reflect.TypeOf((*MockILockoutService)(nil).CheckLocked), login, ipAddress)
+}
+
+// RecordFailure mocks base method.
+func (m *MockILockoutService) RecordFailure(login, ipAddress string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailure", login, ipAddress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockILockoutServiceMockRecorder) RecordFailure(login, ipAddress any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockILockoutService)(nil).RecordFailure), login, ipAddress)
+}
+
+// RecordSuccess mocks base method.
+func (m *MockILockoutService) RecordSuccess(login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordSuccess", login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordSuccess indicates an expected call of RecordSuccess.
+func (mr *MockILockoutServiceMockRecorder) RecordSuccess(login any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordSuccess", reflect.TypeOf((*MockILockoutService)(nil).RecordSuccess), login)
+}
+
+// MockIMFAService is a mock of IMFAService interface.
+type MockIMFAService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIMFAServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockIMFAServiceMockRecorder is the mock recorder for MockIMFAService.
+type MockIMFAServiceMockRecorder struct {
+	mock *MockIMFAService
+}
+
+// NewMockIMFAService creates a new mock instance.
+func NewMockIMFAService(ctrl *gomock.Controller) *MockIMFAService {
+	mock := &MockIMFAService{ctrl: ctrl}
+	mock.recorder = &MockIMFAServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIMFAService) EXPECT() *MockIMFAServiceMockRecorder {
+	return m.recorder
+}
+
+// IsEnabled mocks base method.
+func (m *MockIMFAService) IsEnabled(userID uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsEnabled", userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsEnabled indicates an expected call of IsEnabled.
+func (mr *MockIMFAServiceMockRecorder) IsEnabled(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEnabled", reflect.TypeOf((*MockIMFAService)(nil).IsEnabled), userID)
+}
+
+// Verify mocks base method.
+func (m *MockIMFAService) Verify(userID uuid.UUID, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", userID, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockIMFAServiceMockRecorder) Verify(userID, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockIMFAService)(nil).Verify), userID, code)
+}
+
+// MockIPasskeyService is a mock of IPasskeyService interface.
+type MockIPasskeyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIPasskeyServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockIPasskeyServiceMockRecorder is the mock recorder for MockIPasskeyService.
+type MockIPasskeyServiceMockRecorder struct {
+	mock *MockIPasskeyService
+}
+
+// NewMockIPasskeyService creates a new mock instance.
+func NewMockIPasskeyService(ctrl *gomock.Controller) *MockIPasskeyService {
+	mock := &MockIPasskeyService{ctrl: ctrl}
+	mock.recorder = &MockIPasskeyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIPasskeyService) EXPECT() *MockIPasskeyServiceMockRecorder {
+	return m.recorder
+}
+
+// BeginLogin mocks base method.
+func (m *MockIPasskeyService) BeginLogin() (*models.PasskeyRequestOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginLogin")
+	ret0, _ := ret[0].(*models.PasskeyRequestOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginLogin indicates an expected call of BeginLogin.
+func (mr *MockIPasskeyServiceMockRecorder) BeginLogin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginLogin", reflect.TypeOf((*MockIPasskeyService)(nil).BeginLogin))
+}
+
+// BeginRegistration mocks base method.
+func (m *MockIPasskeyService) BeginRegistration(userID uuid.UUID) (*models.PasskeyCreationOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginRegistration", userID)
+	ret0, _ := ret[0].(*models.PasskeyCreationOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginRegistration indicates an expected call of BeginRegistration.
+func (mr *MockIPasskeyServiceMockRecorder) BeginRegistration(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginRegistration", reflect.TypeOf((*MockIPasskeyService)(nil).BeginRegistration), userID)
+}
+
+// BeginSecondFactor mocks base method.
+func (m *MockIPasskeyService) BeginSecondFactor(userID uuid.UUID) (*models.PasskeyRequestOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginSecondFactor", userID)
+	ret0, _ := ret[0].(*models.PasskeyRequestOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginSecondFactor indicates an expected call of BeginSecondFactor.
+func (mr *MockIPasskeyServiceMockRecorder) BeginSecondFactor(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginSecondFactor", reflect.TypeOf((*MockIPasskeyService)(nil).BeginSecondFactor), userID)
+}
+
+// FinishLogin mocks base method.
+func (m *MockIPasskeyService) FinishLogin(assertion *models.PasskeyAssertion) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishLogin", assertion)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishLogin indicates an expected call of FinishLogin.
+func (mr *MockIPasskeyServiceMockRecorder) FinishLogin(assertion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishLogin", reflect.TypeOf((*MockIPasskeyService)(nil).FinishLogin), assertion)
+}
+
+// HasPasskeys mocks base method.
+func (m *MockIPasskeyService) HasPasskeys(userID uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasPasskeys", userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasPasskeys indicates an expected call of HasPasskeys.
+func (mr *MockIPasskeyServiceMockRecorder) HasPasskeys(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasPasskeys", reflect.TypeOf((*MockIPasskeyService)(nil).HasPasskeys), userID)
+}
+
+// VerifySecondFactor mocks base method.
+func (m *MockIPasskeyService) VerifySecondFactor(userID uuid.UUID, assertion *models.PasskeyAssertion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifySecondFactor", userID, assertion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifySecondFactor indicates an expected call of VerifySecondFactor.
+func (mr *MockIPasskeyServiceMockRecorder) VerifySecondFactor(userID, assertion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySecondFactor", reflect.TypeOf((*MockIPasskeyService)(nil).VerifySecondFactor), userID, assertion)
+}
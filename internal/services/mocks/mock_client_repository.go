@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/client_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/services/client_service.go -destination=internal/services/mocks/mock_client_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/breakfront-planner/auth-service/internal/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIClientRepository is a mock of IClientRepository interface.
+type MockIClientRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIClientRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockIClientRepositoryMockRecorder is the mock recorder for MockIClientRepository.
+type MockIClientRepositoryMockRecorder struct {
+	mock *MockIClientRepository
+}
+
+// NewMockIClientRepository creates a new mock instance.
+func NewMockIClientRepository(ctrl *gomock.Controller) *MockIClientRepository {
+	mock := &MockIClientRepository{ctrl: ctrl}
+	mock.recorder = &MockIClientRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIClientRepository) EXPECT() *MockIClientRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateClient mocks base method.
+func (m *MockIClientRepository) CreateClient(client *models.Client) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateClient", client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateClient indicates an expected call of CreateClient.
+func (mr *MockIClientRepositoryMockRecorder) CreateClient(client any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateClient", reflect.TypeOf((*MockIClientRepository)(nil).CreateClient), client)
+}
+
+// FindClient mocks base method.
+func (m *MockIClientRepository) FindClient(clientID string) (*models.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindClient", clientID)
+	ret0, _ := ret[0].(*models.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindClient indicates an expected call of FindClient.
+func (mr *MockIClientRepositoryMockRecorder) FindClient(clientID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindClient", reflect.TypeOf((*MockIClientRepository)(nil).FindClient), clientID)
+}
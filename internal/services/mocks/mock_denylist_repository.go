@@ -0,0 +1,84 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/denylist_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/services/denylist_service.go -destination=internal/services/mocks/mock_denylist_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/breakfront-planner/auth-service/internal/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIDenylistRepository is a mock of IDenylistRepository interface.
+type MockIDenylistRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIDenylistRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockIDenylistRepositoryMockRecorder is the mock recorder for MockIDenylistRepository.
+type MockIDenylistRepositoryMockRecorder struct {
+	mock *MockIDenylistRepository
+}
+
+// NewMockIDenylistRepository creates a new mock instance.
+func NewMockIDenylistRepository(ctrl *gomock.Controller) *MockIDenylistRepository {
+	mock := &MockIDenylistRepository{ctrl: ctrl}
+	mock.recorder = &MockIDenylistRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIDenylistRepository) EXPECT() *MockIDenylistRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteExpiredEntries mocks base method.
+func (m *MockIDenylistRepository) DeleteExpiredEntries() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredEntries")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredEntries indicates an expected call of DeleteExpiredEntries.
+func (mr *MockIDenylistRepositoryMockRecorder) DeleteExpiredEntries() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredEntries", reflect.TypeOf((*MockIDenylistRepository)(nil).DeleteExpiredEntries))
+}
+
+// IsDenylisted mocks base method.
+func (m *MockIDenylistRepository) IsDenylisted(jti string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsDenylisted", jti)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsDenylisted indicates an expected call of IsDenylisted.
+func (mr *MockIDenylistRepositoryMockRecorder) IsDenylisted(jti any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDenylisted", reflect.TypeOf((*MockIDenylistRepository)(nil).IsDenylisted), jti)
+}
+
+// SaveEntry mocks base method.
+func (m *MockIDenylistRepository) SaveEntry(entry *models.DenylistEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEntry", entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEntry indicates an expected call of SaveEntry.
+func (mr *MockIDenylistRepositoryMockRecorder) SaveEntry(entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEntry", reflect.TypeOf((*MockIDenylistRepository)(nil).SaveEntry), entry)
+}
@@ -0,0 +1,145 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/device_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/services/device_service.go -destination=internal/services/mocks/mock_device_service.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/breakfront-planner/auth-service/internal/models"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIDeviceAuthorizationRepository is a mock of IDeviceAuthorizationRepository interface.
+type MockIDeviceAuthorizationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIDeviceAuthorizationRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockIDeviceAuthorizationRepositoryMockRecorder is the mock recorder for MockIDeviceAuthorizationRepository.
+type MockIDeviceAuthorizationRepositoryMockRecorder struct {
+	mock *MockIDeviceAuthorizationRepository
+}
+
+// NewMockIDeviceAuthorizationRepository creates a new mock instance.
+func NewMockIDeviceAuthorizationRepository(ctrl *gomock.Controller) *MockIDeviceAuthorizationRepository {
+	mock := &MockIDeviceAuthorizationRepository{ctrl: ctrl}
+	mock.recorder = &MockIDeviceAuthorizationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIDeviceAuthorizationRepository) EXPECT() *MockIDeviceAuthorizationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ConsumeDeviceAuthorization mocks base method.
+func (m *MockIDeviceAuthorizationRepository) ConsumeDeviceAuthorization(deviceCodeHash string) (*models.DeviceAuthorization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeDeviceAuthorization", deviceCodeHash)
+	ret0, _ := ret[0].(*models.DeviceAuthorization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeDeviceAuthorization indicates an expected call of ConsumeDeviceAuthorization.
+func (mr *MockIDeviceAuthorizationRepositoryMockRecorder) ConsumeDeviceAuthorization(deviceCodeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeDeviceAuthorization", reflect.TypeOf((*MockIDeviceAuthorizationRepository)(nil).ConsumeDeviceAuthorization), deviceCodeHash)
+}
+
+// DecideDeviceAuthorization mocks base method.
+func (m *MockIDeviceAuthorizationRepository) DecideDeviceAuthorization(userCodeHash string, userID uuid.UUID, status models.DeviceAuthorizationStatus) (*models.DeviceAuthorization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecideDeviceAuthorization", userCodeHash, userID, status)
+	ret0, _ := ret[0].(*models.DeviceAuthorization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DecideDeviceAuthorization indicates an expected call of DecideDeviceAuthorization.
+func (mr *MockIDeviceAuthorizationRepositoryMockRecorder) DecideDeviceAuthorization(userCodeHash, userID, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecideDeviceAuthorization", reflect.TypeOf((*MockIDeviceAuthorizationRepository)(nil).DecideDeviceAuthorization), userCodeHash, userID, status)
+}
+
+// DeleteExpiredDeviceAuthorizations mocks base method.
+func (m *MockIDeviceAuthorizationRepository) DeleteExpiredDeviceAuthorizations() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredDeviceAuthorizations")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredDeviceAuthorizations indicates an expected call of DeleteExpiredDeviceAuthorizations.
+func (mr *MockIDeviceAuthorizationRepositoryMockRecorder) DeleteExpiredDeviceAuthorizations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredDeviceAuthorizations", reflect.TypeOf((*MockIDeviceAuthorizationRepository)(nil).DeleteExpiredDeviceAuthorizations))
+}
+
+// FindByUserCode mocks base method.
+func (m *MockIDeviceAuthorizationRepository) FindByUserCode(userCodeHash string) (*models.DeviceAuthorization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUserCode", userCodeHash)
+	ret0, _ := ret[0].(*models.DeviceAuthorization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUserCode indicates an expected call of FindByUserCode.
+func (mr *MockIDeviceAuthorizationRepositoryMockRecorder) FindByUserCode(userCodeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserCode", reflect.TypeOf((*MockIDeviceAuthorizationRepository)(nil).FindByUserCode), userCodeHash)
+}
+
+// PollDeviceAuthorization mocks base method.
+func (m *MockIDeviceAuthorizationRepository) PollDeviceAuthorization(deviceCodeHash string, polledAt time.Time) (*models.DeviceAuthorization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PollDeviceAuthorization", deviceCodeHash, polledAt)
+	ret0, _ := ret[0].(*models.DeviceAuthorization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PollDeviceAuthorization indicates an expected call of PollDeviceAuthorization.
+func (mr *MockIDeviceAuthorizationRepositoryMockRecorder) PollDeviceAuthorization(deviceCodeHash, polledAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PollDeviceAuthorization", reflect.TypeOf((*MockIDeviceAuthorizationRepository)(nil).PollDeviceAuthorization), deviceCodeHash, polledAt)
+}
+
+// SaveDeviceAuthorization mocks base method.
+func (m *MockIDeviceAuthorizationRepository) SaveDeviceAuthorization(auth *models.DeviceAuthorization) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDeviceAuthorization", auth)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveDeviceAuthorization indicates an expected call of SaveDeviceAuthorization.
+func (mr *MockIDeviceAuthorizationRepositoryMockRecorder) SaveDeviceAuthorization(auth any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDeviceAuthorization", reflect.TypeOf((*MockIDeviceAuthorizationRepository)(nil).SaveDeviceAuthorization), auth)
+}
+
+// UpdatePollInterval mocks base method.
+func (m *MockIDeviceAuthorizationRepository) UpdatePollInterval(deviceCodeHash string, interval time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePollInterval", deviceCodeHash, interval)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePollInterval indicates an expected call of UpdatePollInterval.
+func (mr *MockIDeviceAuthorizationRepositoryMockRecorder) UpdatePollInterval(deviceCodeHash, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePollInterval", reflect.TypeOf((*MockIDeviceAuthorizationRepository)(nil).UpdatePollInterval), deviceCodeHash, interval)
+}
@@ -0,0 +1,114 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/lockout_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/services/lockout_service.go -destination=internal/services/mocks/mock_login_attempt_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/breakfront-planner/auth-service/internal/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockILoginAttemptRepository is a mock of ILoginAttemptRepository interface.
+type MockILoginAttemptRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockILoginAttemptRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockILoginAttemptRepositoryMockRecorder is the mock recorder for MockILoginAttemptRepository.
+type MockILoginAttemptRepositoryMockRecorder struct {
+	mock *MockILoginAttemptRepository
+}
+
+// NewMockILoginAttemptRepository creates a new mock instance.
+func NewMockILoginAttemptRepository(ctrl *gomock.Controller) *MockILoginAttemptRepository {
+	mock := &MockILoginAttemptRepository{ctrl: ctrl}
+	mock.recorder = &MockILoginAttemptRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockILoginAttemptRepository) EXPECT() *MockILoginAttemptRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteAttempts mocks base method.
+func (m *MockILoginAttemptRepository) DeleteAttempts(key models.LoginAttemptKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAttempts", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAttempts indicates an expected call of DeleteAttempts.
+func (mr *MockILoginAttemptRepositoryMockRecorder) DeleteAttempts(key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAttempts", reflect.TypeOf((*MockILoginAttemptRepository)(nil).DeleteAttempts), key)
+}
+
+// DeleteStaleAttempts mocks base method.
+func (m *MockILoginAttemptRepository) DeleteStaleAttempts(before time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStaleAttempts", before)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteStaleAttempts indicates an expected call of DeleteStaleAttempts.
+func (mr *MockILoginAttemptRepositoryMockRecorder) DeleteStaleAttempts(before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStaleAttempts", reflect.TypeOf((*MockILoginAttemptRepository)(nil).DeleteStaleAttempts), before)
+}
+
+// FindAttempts mocks base method.
+func (m *MockILoginAttemptRepository) FindAttempts(key models.LoginAttemptKey) (*models.LoginAttempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAttempts", key)
+	ret0, _ := ret[0].(*models.LoginAttempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAttempts indicates an expected call of FindAttempts.
+func (mr *MockILoginAttemptRepositoryMockRecorder) FindAttempts(key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAttempts", reflect.TypeOf((*MockILoginAttemptRepository)(nil).FindAttempts), key)
+}
+
+// Lock mocks base method.
+func (m *MockILoginAttemptRepository) Lock(key models.LoginAttemptKey, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", key, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockILoginAttemptRepositoryMockRecorder) Lock(key, until any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockILoginAttemptRepository)(nil).Lock), key, until)
+}
+
+// RecordFailure mocks base method.
+func (m *MockILoginAttemptRepository) RecordFailure(key models.LoginAttemptKey, failedAt, windowStart time.Time) (*models.LoginAttempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailure", key, failedAt, windowStart)
+	ret0, _ := ret[0].(*models.LoginAttempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockILoginAttemptRepositoryMockRecorder) RecordFailure(key, failedAt, windowStart any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockILoginAttemptRepository)(nil).RecordFailure), key, failedAt, windowStart)
+}
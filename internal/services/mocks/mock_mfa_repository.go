@@ -0,0 +1,212 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/mfa_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/services/mfa_service.go -destination=internal/services/mocks/mock_mfa_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/breakfront-planner/auth-service/internal/models"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIMFARepository is a mock of IMFARepository interface.
+type MockIMFARepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIMFARepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockIMFARepositoryMockRecorder is the mock recorder for MockIMFARepository.
+type MockIMFARepositoryMockRecorder struct {
+	mock *MockIMFARepository
+}
+
+// NewMockIMFARepository creates a new mock instance.
+func NewMockIMFARepository(ctrl *gomock.Controller) *MockIMFARepository {
+	mock := &MockIMFARepository{ctrl: ctrl}
+	mock.recorder = &MockIMFARepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIMFARepository) EXPECT() *MockIMFARepositoryMockRecorder {
+	return m.recorder
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockIMFARepository) ConfirmTOTP(userID uuid.UUID, step int64, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", userID, step, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockIMFARepositoryMockRecorder) ConfirmTOTP(userID, step, codeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockIMFARepository)(nil).ConfirmTOTP), userID, step, codeHashes)
+}
+
+// CountRecoveryCodes mocks base method.
+func (m *MockIMFARepository) CountRecoveryCodes(userID uuid.UUID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRecoveryCodes", userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRecoveryCodes indicates an expected call of CountRecoveryCodes.
+func (mr *MockIMFARepositoryMockRecorder) CountRecoveryCodes(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRecoveryCodes", reflect.TypeOf((*MockIMFARepository)(nil).CountRecoveryCodes), userID)
+}
+
+// DeleteTOTP mocks base method.
+func (m *MockIMFARepository) DeleteTOTP(userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTOTP", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTOTP indicates an expected call of DeleteTOTP.
+func (mr *MockIMFARepositoryMockRecorder) DeleteTOTP(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTOTP", reflect.TypeOf((*MockIMFARepository)(nil).DeleteTOTP), userID)
+}
+
+// FindTOTP mocks base method.
+func (m *MockIMFARepository) FindTOTP(userID uuid.UUID) (*models.TOTPCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTOTP", userID)
+	ret0, _ := ret[0].(*models.TOTPCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTOTP indicates an expected call of FindTOTP.
+func (mr *MockIMFARepositoryMockRecorder) FindTOTP(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTOTP", reflect.TypeOf((*MockIMFARepository)(nil).FindTOTP), userID)
+}
+
+// ReplaceRecoveryCodes mocks base method.
+func (m *MockIMFARepository) ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRecoveryCodes", userID, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceRecoveryCodes indicates an expected call of ReplaceRecoveryCodes.
+func (mr *MockIMFARepositoryMockRecorder) ReplaceRecoveryCodes(userID, codeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRecoveryCodes", reflect.TypeOf((*MockIMFARepository)(nil).ReplaceRecoveryCodes), userID, codeHashes)
+}
+
+// SaveTOTP mocks base method.
+func (m *MockIMFARepository) SaveTOTP(credential *models.TOTPCredential) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTOTP", credential)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveTOTP indicates an expected call of SaveTOTP.
+func (mr *MockIMFARepositoryMockRecorder) SaveTOTP(credential any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTOTP", reflect.TypeOf((*MockIMFARepository)(nil).SaveTOTP), credential)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockIMFARepository) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", userID, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockIMFARepositoryMockRecorder) UseRecoveryCode(userID, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockIMFARepository)(nil).UseRecoveryCode), userID, codeHash)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockIMFARepository) UseTOTPStep(userID uuid.UUID, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", userID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockIMFARepositoryMockRecorder) UseTOTPStep(userID, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockIMFARepository)(nil).UseTOTPStep), userID, step)
+}
+
+// MockISecretCipher is a mock of ISecretCipher interface.
+type MockISecretCipher struct {
+	ctrl     *gomock.Controller
+	recorder *MockISecretCipherMockRecorder
+	isgomock struct{}
+}
+
+// MockISecretCipherMockRecorder is the mock recorder for MockISecretCipher.
+type MockISecretCipherMockRecorder struct {
+	mock *MockISecretCipher
+}
+
+// NewMockISecretCipher creates a new mock instance.
+func NewMockISecretCipher(ctrl *gomock.Controller) *MockISecretCipher {
+	mock := &MockISecretCipher{ctrl: ctrl}
+	mock.recorder = &MockISecretCipherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockISecretCipher) EXPECT() *MockISecretCipherMockRecorder {
+	return m.recorder
+}
+
+// Open mocks base method.
+func (m *MockISecretCipher) Open(value string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Open", value)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Open indicates an expected call of Open.
+func (mr *MockISecretCipherMockRecorder) Open(value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockISecretCipher)(nil).Open), value)
+}
+
+// Seal mocks base method.
+func (m *MockISecretCipher) Seal(secret string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Seal", secret)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Seal indicates an expected call of Seal.
+func (mr *MockISecretCipherMockRecorder) Seal(secret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Seal", reflect.TypeOf((*MockISecretCipher)(nil).Seal), secret)
+}
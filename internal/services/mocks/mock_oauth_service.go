@@ -0,0 +1,237 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/oauth_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/services/oauth_service.go -destination=internal/services/mocks/mock_oauth_service.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/breakfront-planner/auth-service/internal/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIAuthorizationCodeRepository is a mock of IAuthorizationCodeRepository interface.
+type MockIAuthorizationCodeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAuthorizationCodeRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockIAuthorizationCodeRepositoryMockRecorder is the mock recorder for MockIAuthorizationCodeRepository.
+type MockIAuthorizationCodeRepositoryMockRecorder struct {
+	mock *MockIAuthorizationCodeRepository
+}
+
+// NewMockIAuthorizationCodeRepository creates a new mock instance.
+func NewMockIAuthorizationCodeRepository(ctrl *gomock.Controller) *MockIAuthorizationCodeRepository {
+	mock := &MockIAuthorizationCodeRepository{ctrl: ctrl}
+	mock.recorder = &MockIAuthorizationCodeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIAuthorizationCodeRepository) EXPECT() *MockIAuthorizationCodeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ConsumeCode mocks base method.
+func (m *MockIAuthorizationCodeRepository) ConsumeCode(codeHash, clientID string) (*models.AuthorizationCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeCode", codeHash, clientID)
+	ret0, _ := ret[0].(*models.AuthorizationCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeCode indicates an expected call of ConsumeCode.
+func (mr *MockIAuthorizationCodeRepositoryMockRecorder) ConsumeCode(codeHash, clientID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeCode", reflect.TypeOf((*MockIAuthorizationCodeRepository)(nil).ConsumeCode), codeHash, clientID)
+}
+
+// DeleteExpiredCodes mocks base method.
+func (m *MockIAuthorizationCodeRepository) DeleteExpiredCodes() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredCodes")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredCodes indicates an expected call of DeleteExpiredCodes.
+func (mr *MockIAuthorizationCodeRepositoryMockRecorder) DeleteExpiredCodes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredCodes", reflect.TypeOf((*MockIAuthorizationCodeRepository)(nil).DeleteExpiredCodes))
+}
+
+// FindUsedCode mocks base method.
+func (m *MockIAuthorizationCodeRepository) FindUsedCode(codeHash string) (*models.AuthorizationCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUsedCode", codeHash)
+	ret0, _ := ret[0].(*models.AuthorizationCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUsedCode indicates an expected call of FindUsedCode.
+func (mr *MockIAuthorizationCodeRepositoryMockRecorder) FindUsedCode(codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUsedCode", reflect.TypeOf((*MockIAuthorizationCodeRepository)(nil).FindUsedCode), codeHash)
+}
+
+// SaveCode mocks base method.
+func (m *MockIAuthorizationCodeRepository) SaveCode(code *models.AuthorizationCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCode", code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCode indicates an expected call of SaveCode.
+func (mr *MockIAuthorizationCodeRepositoryMockRecorder) SaveCode(code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCode", reflect.TypeOf((*MockIAuthorizationCodeRepository)(nil).SaveCode), code)
+}
+
+// MockIClientService is a mock of IClientService interface.
+type MockIClientService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIClientServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockIClientServiceMockRecorder is the mock recorder for MockIClientService.
+type MockIClientServiceMockRecorder struct {
+	mock *MockIClientService
+}
+
+// NewMockIClientService creates a new mock instance.
+func NewMockIClientService(ctrl *gomock.Controller) *MockIClientService {
+	mock := &MockIClientService{ctrl: ctrl}
+	mock.recorder = &MockIClientServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIClientService) EXPECT() *MockIClientServiceMockRecorder {
+	return m.recorder
+}
+
+// FindClient mocks base method.
+func (m *MockIClientService) FindClient(clientID string) (*models.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindClient", clientID)
+	ret0, _ := ret[0].(*models.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindClient indicates an expected call of FindClient.
+func (mr *MockIClientServiceMockRecorder) FindClient(clientID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindClient", reflect.TypeOf((*MockIClientService)(nil).FindClient), clientID)
+}
+
+// IdentifyClient mocks base method.
+func (m *MockIClientService) IdentifyClient(clientID, clientSecret string) (*models.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdentifyClient", clientID, clientSecret)
+	ret0, _ := ret[0].(*models.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IdentifyClient indicates an expected call of IdentifyClient.
+func (mr *MockIClientServiceMockRecorder) IdentifyClient(clientID, clientSecret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentifyClient", reflect.TypeOf((*MockIClientService)(nil).IdentifyClient), clientID, clientSecret)
+}
+
+// MockIAuthenticator is a mock of IAuthenticator interface.
+type MockIAuthenticator struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAuthenticatorMockRecorder
+	isgomock struct{}
+}
+
+// MockIAuthenticatorMockRecorder is the mock recorder for MockIAuthenticator.
+type MockIAuthenticatorMockRecorder struct {
+	mock *MockIAuthenticator
+}
+
+// NewMockIAuthenticator creates a new mock instance.
+func NewMockIAuthenticator(ctrl *gomock.Controller) *MockIAuthenticator {
+	mock := &MockIAuthenticator{ctrl: ctrl}
+	mock.recorder = &MockIAuthenticatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIAuthenticator) EXPECT() *MockIAuthenticatorMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockIAuthenticator) Authenticate(login, password, ipAddress string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", login, password, ipAddress)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockIAuthenticatorMockRecorder) Authenticate(login, password, ipAddress any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockIAuthenticator)(nil).Authenticate), login, password, ipAddress)
+}
+
+// AuthenticateMFA mocks base method.
+func (m *MockIAuthenticator) AuthenticateMFA(challengeTokenValue, code, ipAddress string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateMFA", challengeTokenValue, code, ipAddress)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateMFA indicates an expected call of AuthenticateMFA.
+func (mr *MockIAuthenticatorMockRecorder) AuthenticateMFA(challengeTokenValue, code, ipAddress any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateMFA", reflect.TypeOf((*MockIAuthenticator)(nil).AuthenticateMFA), challengeTokenValue, code, ipAddress)
+}
+
+// AuthenticatePasskeyMFA mocks base method.
+func (m *MockIAuthenticator) AuthenticatePasskeyMFA(challengeTokenValue string, assertion *models.PasskeyAssertion, ipAddress string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticatePasskeyMFA", challengeTokenValue, assertion, ipAddress)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticatePasskeyMFA indicates an expected call of AuthenticatePasskeyMFA.
+func (mr *MockIAuthenticatorMockRecorder) AuthenticatePasskeyMFA(challengeTokenValue, assertion, ipAddress any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticatePasskeyMFA", reflect.TypeOf((*MockIAuthenticator)(nil).AuthenticatePasskeyMFA), challengeTokenValue, assertion, ipAddress)
+}
+
+// BeginPasskeyMFA mocks base method.
+func (m *MockIAuthenticator) BeginPasskeyMFA(challengeTokenValue string) (*models.PasskeyRequestOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginPasskeyMFA", challengeTokenValue)
+	ret0, _ := ret[0].(*models.PasskeyRequestOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginPasskeyMFA indicates an expected call of BeginPasskeyMFA.
+func (mr *MockIAuthenticatorMockRecorder) BeginPasskeyMFA(challengeTokenValue any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginPasskeyMFA", reflect.TypeOf((*MockIAuthenticator)(nil).BeginPasskeyMFA), challengeTokenValue)
+}
@@ -0,0 +1,158 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/passkey_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/services/passkey_service.go -destination=internal/services/mocks/mock_passkey_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/breakfront-planner/auth-service/internal/models"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIPasskeyRepository is a mock of IPasskeyRepository interface.
+type MockIPasskeyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIPasskeyRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockIPasskeyRepositoryMockRecorder is the mock recorder for MockIPasskeyRepository.
+type MockIPasskeyRepositoryMockRecorder struct {
+	mock *MockIPasskeyRepository
+}
+
+// NewMockIPasskeyRepository creates a new mock instance.
+func NewMockIPasskeyRepository(ctrl *gomock.Controller) *MockIPasskeyRepository {
+	mock := &MockIPasskeyRepository{ctrl: ctrl}
+	mock.recorder = &MockIPasskeyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIPasskeyRepository) EXPECT() *MockIPasskeyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ConsumeChallenge mocks base method.
+func (m *MockIPasskeyRepository) ConsumeChallenge(challengeHash string) (*models.PasskeyChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeChallenge", challengeHash)
+	ret0, _ := ret[0].(*models.PasskeyChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeChallenge indicates an expected call of ConsumeChallenge.
+func (mr *MockIPasskeyRepositoryMockRecorder) ConsumeChallenge(challengeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeChallenge", reflect.TypeOf((*MockIPasskeyRepository)(nil).ConsumeChallenge), challengeHash)
+}
+
+// DeleteExpiredChallenges mocks base method.
+func (m *MockIPasskeyRepository) DeleteExpiredChallenges() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredChallenges")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredChallenges indicates an expected call of DeleteExpiredChallenges.
+func (mr *MockIPasskeyRepositoryMockRecorder) DeleteExpiredChallenges() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredChallenges", reflect.TypeOf((*MockIPasskeyRepository)(nil).DeleteExpiredChallenges))
+}
+
+// DeletePasskey mocks base method.
+func (m *MockIPasskeyRepository) DeletePasskey(userID uuid.UUID, credentialID []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePasskey", userID, credentialID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePasskey indicates an expected call of DeletePasskey.
+func (mr *MockIPasskeyRepositoryMockRecorder) DeletePasskey(userID, credentialID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePasskey", reflect.TypeOf((*MockIPasskeyRepository)(nil).DeletePasskey), userID, credentialID)
+}
+
+// FindPasskey mocks base method.
+func (m *MockIPasskeyRepository) FindPasskey(credentialID []byte) (*models.Passkey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPasskey", credentialID)
+	ret0, _ := ret[0].(*models.Passkey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindPasskey indicates an expected call of FindPasskey.
+func (mr *MockIPasskeyRepositoryMockRecorder) FindPasskey(credentialID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPasskey", reflect.TypeOf((*MockIPasskeyRepository)(nil).FindPasskey), credentialID)
+}
+
+// FindPasskeys mocks base method.
+func (m *MockIPasskeyRepository) FindPasskeys(userID uuid.UUID) ([]*models.Passkey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPasskeys", userID)
+	ret0, _ := ret[0].([]*models.Passkey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindPasskeys indicates an expected call of FindPasskeys.
+func (mr *MockIPasskeyRepositoryMockRecorder) FindPasskeys(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPasskeys", reflect.TypeOf((*MockIPasskeyRepository)(nil).FindPasskeys), userID)
+}
+
+// SaveChallenge mocks base method.
+func (m *MockIPasskeyRepository) SaveChallenge(challenge *models.PasskeyChallenge) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveChallenge", challenge)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveChallenge indicates an expected call of SaveChallenge.
+func (mr *MockIPasskeyRepositoryMockRecorder) SaveChallenge(challenge any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveChallenge", reflect.TypeOf((*MockIPasskeyRepository)(nil).SaveChallenge), challenge)
+}
+
+// SavePasskey mocks base method.
+func (m *MockIPasskeyRepository) SavePasskey(passkey *models.Passkey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePasskey", passkey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePasskey indicates an expected call of SavePasskey.
+func (mr *MockIPasskeyRepositoryMockRecorder) SavePasskey(passkey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePasskey", reflect.TypeOf((*MockIPasskeyRepository)(nil).SavePasskey), passkey)
+}
+
+// UseSignCount mocks base method.
+func (m *MockIPasskeyRepository) UseSignCount(credentialID []byte, signCount uint32, backedUp bool) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseSignCount", credentialID, signCount, backedUp)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseSignCount indicates an expected call of UseSignCount.
+func (mr *MockIPasskeyRepositoryMockRecorder) UseSignCount(credentialID, signCount, backedUp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseSignCount", reflect.TypeOf((*MockIPasskeyRepository)(nil).UseSignCount), credentialID, signCount, backedUp)
+}
@@ -0,0 +1,114 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/role_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/services/role_service.go -destination=internal/services/mocks/mock_role_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/breakfront-planner/auth-service/internal/models"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIRoleRepository is a mock of IRoleRepository interface.
+type MockIRoleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIRoleRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockIRoleRepositoryMockRecorder is the mock recorder for MockIRoleRepository.
+type MockIRoleRepositoryMockRecorder struct {
+	mock *MockIRoleRepository
+}
+
+// NewMockIRoleRepository creates a new mock instance.
+func NewMockIRoleRepository(ctrl *gomock.Controller) *MockIRoleRepository {
+	mock := &MockIRoleRepository{ctrl: ctrl}
+	mock.recorder = &MockIRoleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIRoleRepository) EXPECT() *MockIRoleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AssignRole mocks base method.
+func (m *MockIRoleRepository) AssignRole(userID, roleID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignRole", userID, roleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignRole indicates an expected call of AssignRole.
+func (mr *MockIRoleRepositoryMockRecorder) AssignRole(userID, roleID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRole", reflect.TypeOf((*MockIRoleRepository)(nil).AssignRole), userID, roleID)
+}
+
+// CreateRole mocks base method.
+func (m *MockIRoleRepository) CreateRole(role *models.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRole", role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRole indicates an expected call of CreateRole.
+func (mr *MockIRoleRepositoryMockRecorder) CreateRole(role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRole", reflect.TypeOf((*MockIRoleRepository)(nil).CreateRole), role)
+}
+
+// FindRole mocks base method.
+func (m *MockIRoleRepository) FindRole(name string) (*models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRole", name)
+	ret0, _ := ret[0].(*models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRole indicates an expected call of FindRole.
+func (mr *MockIRoleRepositoryMockRecorder) FindRole(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRole", reflect.TypeOf((*MockIRoleRepository)(nil).FindRole), name)
+}
+
+// FindUserRoles mocks base method.
+func (m *MockIRoleRepository) FindUserRoles(userID uuid.UUID) ([]*models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserRoles", userID)
+	ret0, _ := ret[0].([]*models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserRoles indicates an expected call of FindUserRoles.
+func (mr *MockIRoleRepositoryMockRecorder) FindUserRoles(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserRoles", reflect.TypeOf((*MockIRoleRepository)(nil).FindUserRoles), userID)
+}
+
+// UnassignRole mocks base method.
+func (m *MockIRoleRepository) UnassignRole(userID, roleID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnassignRole", userID, roleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnassignRole indicates an expected call of UnassignRole.
+func (mr *MockIRoleRepositoryMockRecorder) UnassignRole(userID, roleID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnassignRole", reflect.TypeOf((*MockIRoleRepository)(nil).UnassignRole), userID, roleID)
+}
@@ -13,6 +13,7 @@ import (
 	reflect "reflect"
 
 	models "github.com/breakfront-planner/auth-service/internal/models"
+	uuid "github.com/google/uuid"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -40,6 +41,21 @@ func (m *MockITokenRepository) EXPECT() *MockITokenRepositoryMockRecorder {
 	return m.recorder
 }
 
+// FindActiveTokens mocks base method.
+func (m *MockITokenRepository) FindActiveTokens(userID uuid.UUID) ([]*models.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindActiveTokens", userID)
+	ret0, _ := ret[0].([]*models.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindActiveTokens indicates an expected call of FindActiveTokens.
+func (mr *MockITokenRepositoryMockRecorder) FindActiveTokens(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindActiveTokens", reflect.TypeOf((*MockITokenRepository)(nil).FindActiveTokens), userID)
+}
+
 // FindToken mocks base method.
 func (m *MockITokenRepository) FindToken(token *models.Token) error {
 	m.ctrl.T.Helper()
@@ -54,6 +70,34 @@ func (mr *MockITokenRepositoryMockRecorder) FindToken(token any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindToken", reflect.TypeOf((*MockITokenRepository)(nil).FindToken), token)
 }
 
+// RevokeAllUserTokens mocks base method.
+func (m *MockITokenRepository) RevokeAllUserTokens(userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllUserTokens", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAllUserTokens indicates an expected call of RevokeAllUserTokens.
+func (mr *MockITokenRepositoryMockRecorder) RevokeAllUserTokens(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllUserTokens", reflect.TypeOf((*MockITokenRepository)(nil).RevokeAllUserTokens), userID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockITokenRepository) RevokeSession(userID, sessionID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockITokenRepositoryMockRecorder) RevokeSession(userID, sessionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockITokenRepository)(nil).RevokeSession), userID, sessionID)
+}
+
 // RevokeToken mocks base method.
 func (m *MockITokenRepository) RevokeToken(token *models.Token) error {
 	m.ctrl.T.Helper()
@@ -68,6 +112,34 @@ func (mr *MockITokenRepositoryMockRecorder) RevokeToken(token any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockITokenRepository)(nil).RevokeToken), token)
 }
 
+// RevokeTokenFamily mocks base method.
+func (m *MockITokenRepository) RevokeTokenFamily(familyID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeTokenFamily", familyID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeTokenFamily indicates an expected call of RevokeTokenFamily.
+func (mr *MockITokenRepositoryMockRecorder) RevokeTokenFamily(familyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeTokenFamily", reflect.TypeOf((*MockITokenRepository)(nil).RevokeTokenFamily), familyID)
+}
+
+// RotateToken mocks base method.
+func (m *MockITokenRepository) RotateToken(oldToken, newToken *models.Token) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateToken", oldToken, newToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateToken indicates an expected call of RotateToken.
+func (mr *MockITokenRepositoryMockRecorder) RotateToken(oldToken, newToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateToken", reflect.TypeOf((*MockITokenRepository)(nil).RotateToken), oldToken, newToken)
+}
+
 // SaveToken mocks base method.
 func (m *MockITokenRepository) SaveToken(token *models.Token) error {
 	m.ctrl.T.Helper()
@@ -82,6 +154,58 @@ func (mr *MockITokenRepositoryMockRecorder) SaveToken(token any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveToken", reflect.TypeOf((*MockITokenRepository)(nil).SaveToken), token)
 }
 
+// SaveTokenForCode mocks base method.
+func (m *MockITokenRepository) SaveTokenForCode(token *models.Token, codeHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTokenForCode", token, codeHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveTokenForCode indicates an expected call of SaveTokenForCode.
+func (mr *MockITokenRepositoryMockRecorder) SaveTokenForCode(token, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTokenForCode", reflect.TypeOf((*MockITokenRepository)(nil).SaveTokenForCode), token, codeHash)
+}
+
+// MockISecurityEventRepository is a mock of ISecurityEventRepository interface.
+type MockISecurityEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockISecurityEventRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockISecurityEventRepositoryMockRecorder is the mock recorder for MockISecurityEventRepository.
+type MockISecurityEventRepositoryMockRecorder struct {
+	mock *MockISecurityEventRepository
+}
+
+// NewMockISecurityEventRepository creates a new mock instance.
+func NewMockISecurityEventRepository(ctrl *gomock.Controller) *MockISecurityEventRepository {
+	mock := &MockISecurityEventRepository{ctrl: ctrl}
+	mock.recorder = &MockISecurityEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockISecurityEventRepository) EXPECT() *MockISecurityEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// SaveEvent mocks base method.
+func (m *MockISecurityEventRepository) SaveEvent(event *models.SecurityEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEvent", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEvent indicates an expected call of SaveEvent.
+func (mr *MockISecurityEventRepositoryMockRecorder) SaveEvent(event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEvent", reflect.TypeOf((*MockISecurityEventRepository)(nil).SaveEvent), event)
+}
+
 // MockIHashService is a mock of IHashService interface.
 type MockIHashService struct {
 	ctrl     *gomock.Controller
@@ -148,3 +272,17 @@ func (mr *MockIHashServiceMockRecorder) HashToken(token any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HashToken", reflect.TypeOf((*MockIHashService)(nil).HashToken), token)
 }
+
+// NeedsRehash mocks base method.
+func (m *MockIHashService) NeedsRehash(passHash string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NeedsRehash", passHash)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// NeedsRehash indicates an expected call of NeedsRehash.
+func (mr *MockIHashServiceMockRecorder) NeedsRehash(passHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NeedsRehash", reflect.TypeOf((*MockIHashService)(nil).NeedsRehash), passHash)
+}
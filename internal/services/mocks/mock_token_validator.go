@@ -76,6 +76,21 @@ func (mr *MockITokenValidatorMockRecorder) ValidateAccessToken(tokenValue any) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateAccessToken", reflect.TypeOf((*MockITokenValidator)(nil).ValidateAccessToken), tokenValue)
 }
 
+// ValidateClientToken mocks base method.
+func (m *MockITokenValidator) ValidateClientToken(tokenValue string) (*models.ParsedToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateClientToken", tokenValue)
+	ret0, _ := ret[0].(*models.ParsedToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateClientToken indicates an expected call of ValidateClientToken.
+func (mr *MockITokenValidatorMockRecorder) ValidateClientToken(tokenValue any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateClientToken", reflect.TypeOf((*MockITokenValidator)(nil).ValidateClientToken), tokenValue)
+}
+
 // ValidateRefreshToken mocks base method.
 func (m *MockITokenValidator) ValidateRefreshToken(tokenValue string) (*models.ParsedToken, error) {
 	m.ctrl.T.Helper()
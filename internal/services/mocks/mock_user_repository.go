@@ -11,8 +11,10 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	models "github.com/breakfront-planner/auth-service/internal/models"
+	uuid "github.com/google/uuid"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -69,3 +71,69 @@ func (mr *MockIUserRepositoryMockRecorder) FindUser(filter any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUser", reflect.TypeOf((*MockIUserRepository)(nil).FindUser), filter)
 }
+
+// InvalidateTokens mocks base method.
+func (m *MockIUserRepository) InvalidateTokens(userID uuid.UUID, validAfter time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidateTokens", userID, validAfter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidateTokens indicates an expected call of InvalidateTokens.
+func (mr *MockIUserRepositoryMockRecorder) InvalidateTokens(userID, validAfter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateTokens", reflect.TypeOf((*MockIUserRepository)(nil).InvalidateTokens), userID, validAfter)
+}
+
+// UpdatePasswordHash mocks base method.
+func (m *MockIUserRepository) UpdatePasswordHash(userID uuid.UUID, passHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePasswordHash", userID, passHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePasswordHash indicates an expected call of UpdatePasswordHash.
+func (mr *MockIUserRepositoryMockRecorder) UpdatePasswordHash(userID, passHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePasswordHash", reflect.TypeOf((*MockIUserRepository)(nil).UpdatePasswordHash), userID, passHash)
+}
+
+// MockIPasswordPolicy is a mock of IPasswordPolicy interface.
+type MockIPasswordPolicy struct {
+	ctrl     *gomock.Controller
+	recorder *MockIPasswordPolicyMockRecorder
+	isgomock struct{}
+}
+
+// MockIPasswordPolicyMockRecorder is the mock recorder for MockIPasswordPolicy.
+type MockIPasswordPolicyMockRecorder struct {
+	mock *MockIPasswordPolicy
+}
+
+// NewMockIPasswordPolicy creates a new mock instance.
+func NewMockIPasswordPolicy(ctrl *gomock.Controller) *MockIPasswordPolicy {
+	mock := &MockIPasswordPolicy{ctrl: ctrl}
+	mock.recorder = &MockIPasswordPolicyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIPasswordPolicy) EXPECT() *MockIPasswordPolicyMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockIPasswordPolicy) Check(login, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", login, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockIPasswordPolicyMockRecorder) Check(login, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockIPasswordPolicy)(nil).Check), login, password)
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+const (
+	// authorizationCodeSize is the number of random bytes in authorization codes.
+	authorizationCodeSize = 32
+	// minCodeVerifierLength and maxCodeVerifierLength bound PKCE code verifiers (RFC 7636, section 4.1).
+	minCodeVerifierLength = 43
+	maxCodeVerifierLength = 128
+)
+
+// IAuthorizationCodeRepository defines the interface for authorization code persistence.
+type IAuthorizationCodeRepository interface {
+	SaveCode(code *models.AuthorizationCode) error
+	ConsumeCode(codeHash string, clientID string) (*models.AuthorizationCode, error)
+	FindUsedCode(codeHash string) (*models.AuthorizationCode, error)
+	DeleteExpiredCodes() error
+}
+
+// IClientService defines the lookup and authentication of OAuth clients.
+type IClientService interface {
+	FindClient(clientID string) (*models.Client, error)
+	IdentifyClient(clientID string, clientSecret string) (*models.Client, error)
+}
+
+// IAuthenticator defines the check of user credentials and of the second factor of users who enabled it.
+type IAuthenticator interface {
+	Authenticate(login string, password string, ipAddress string) (*models.User, error)
+	AuthenticateMFA(challengeTokenValue string, code string, ipAddress string) (*models.User, error)
+	BeginPasskeyMFA(challengeTokenValue string) (*models.PasskeyRequestOptions, error)
+	AuthenticatePasskeyMFA(challengeTokenValue string, assertion *models.PasskeyAssertion, ipAddress string) (*models.User, error)
+}
+
+// OAuthService implements the OAuth 2.0 authorization code grant with PKCE (RFC 6749, RFC 7636)
+// for public clients such as the planner SPA and mobile apps, which never see the user's password,
+// and the client credentials grant for services calling other services without a user.
+type OAuthService struct {
+	clientService IClientService
+	codeRepo      IAuthorizationCodeRepository
+	authenticator IAuthenticator
+	userService   IUserService
+	roleService   IRoleService
+	tokenService  ITokenService
+	hashService   IHashService
+	codeDuration  time.Duration
+	openIDConnect bool
+}
+
+// OAuthServiceOption is a function that modifies the OAuthService configuration.
+type OAuthServiceOption func(*OAuthService)
+
+// WithOpenIDConnect issues ID tokens to clients requesting the "openid" scope. Relying parties verify ID tokens
+// with the published keys, so it must only be enabled with an asymmetric signing key and a configured issuer.
+func WithOpenIDConnect() OAuthServiceOption {
+	return func(s *OAuthService) {
+		s.openIDConnect = true
+	}
+}
+
+// NewOAuthService creates a new OAuth service instance.
+// codeDuration is how long an authorization code can be exchanged for tokens.
+// Without WithOpenIDConnect the "openid" scope is not granted.
+func NewOAuthService(clientService IClientService, codeRepo IAuthorizationCodeRepository, authenticator IAuthenticator,
+	userService IUserService, roleService IRoleService, tokenService ITokenService, hashService IHashService,
+	codeDuration time.Duration, opts ...OAuthServiceOption) *OAuthService {
+	s := &OAuthService{
+		clientService: clientService,
+		codeRepo:      codeRepo,
+		authenticator: authenticator,
+		userService:   userService,
+		roleService:   roleService,
+		tokenService:  tokenService,
+		hashService:   hashService,
+		codeDuration:  codeDuration,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ValidateRedirect checks that the client exists and registered the request's redirect URI.
+// Until this succeeds, errors must be shown to the user instead of being sent to the redirect URI.
+func (s *OAuthService) ValidateRedirect(req *models.AuthorizationRequest) (*models.Client, error) {
+
+	client, err := s.clientService.FindClient(req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.AllowsRedirectURI(req.RedirectURI) {
+		return nil, autherrors.ErrInvalidRedirectURI
+	}
+
+	return client, nil
+}
+
+// ValidateAuthorizationRequest checks a request to the authorization endpoint.
+// Besides the redirect URI it requires the code response type, an S256 PKCE challenge
+// and only scopes the client may be granted, "openid" only with OpenID Connect enabled.
+func (s *OAuthService) ValidateAuthorizationRequest(req *models.AuthorizationRequest) error {
+
+	client, err := s.ValidateRedirect(req)
+	if err != nil {
+		return err
+	}
+
+	if req.ResponseType != constants.ResponseTypeCode {
+		return autherrors.ErrUnsupportedResponseType
+	}
+
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != constants.CodeChallengeMethodS256 {
+		return autherrors.ErrInvalidCodeChallenge
+	}
+
+	scopes := strings.Fields(req.Scope)
+	if !s.openIDConnect && slices.Contains(scopes, constants.ScopeOpenID) {
+		return autherrors.ErrScopeNotAllowed(constants.ScopeOpenID)
+	}
+
+	return checkScopes(client, scopes)
+}
+
+// Authorize authenticates the user signing in from the IP address and issues an authorization code
+// for the validated request. The code is returned to the client through the redirect URI and only its hash is stored.
+// Users with two-factor authentication enabled or with a passkey get an *autherrors.MFARequiredError instead
+// and continue with AuthorizeMFA or AuthorizePasskeyMFA.
+func (s *OAuthService) Authorize(req *models.AuthorizationRequest, login string, password string,
+	ipAddress string) (string, error) {
+
+	if err := s.ValidateAuthorizationRequest(req); err != nil {
+		return "", err
+	}
+
+	user, err := s.authenticator.Authenticate(login, password, ipAddress)
+	if err != nil {
+		return "", err
+	}
+
+	return s.issueAuthorizationCode(req, user)
+}
+
+// AuthorizeMFA checks the second factor of a user signing in with the challenge token returned by Authorize
+// and issues an authorization code for the validated request.
+func (s *OAuthService) AuthorizeMFA(req *models.AuthorizationRequest, challengeTokenValue string, code string,
+	ipAddress string) (string, error) {
+
+	if err := s.ValidateAuthorizationRequest(req); err != nil {
+		return "", err
+	}
+
+	user, err := s.authenticator.AuthenticateMFA(challengeTokenValue, code, ipAddress)
+	if err != nil {
+		return "", err
+	}
+
+	return s.issueAuthorizationCode(req, user)
+}
+
+// BeginPasskeyMFA starts checking a passkey as the second factor of a user signing in with the challenge token
+// returned by Authorize and returns the options for the user's authenticator.
+// Returns autherrors.ErrNoPasskeys if the user has no passkeys.
+func (s *OAuthService) BeginPasskeyMFA(challengeTokenValue string) (*models.PasskeyRequestOptions, error) {
+	return s.authenticator.BeginPasskeyMFA(challengeTokenValue)
+}
+
+// AuthorizePasskeyMFA checks a passkey as the second factor of a user signing in, like AuthorizeMFA checks a code,
+// and issues an authorization code for the validated request.
+func (s *OAuthService) AuthorizePasskeyMFA(req *models.AuthorizationRequest, challengeTokenValue string,
+	assertion *models.PasskeyAssertion, ipAddress string) (string, error) {
+
+	if err := s.ValidateAuthorizationRequest(req); err != nil {
+		return "", err
+	}
+
+	user, err := s.authenticator.AuthenticatePasskeyMFA(challengeTokenValue, assertion, ipAddress)
+	if err != nil {
+		return "", err
+	}
+
+	return s.issueAuthorizationCode(req, user)
+}
+
+// issueAuthorizationCode stores the hash of a new authorization code of the authenticated user and returns the code.
+func (s *OAuthService) issueAuthorizationCode(req *models.AuthorizationRequest, user *models.User) (string, error) {
+	value, err := generateRandomValue(authorizationCodeSize)
+	if err != nil {
+		return "", autherrors.ErrSaveAuthorizationCode(err)
+	}
+
+	code := models.AuthorizationCode{
+		CodeHash:            s.hashService.HashToken(value),
+		ClientID:            req.ClientID,
+		UserID:              user.ID,
+		RedirectURI:         req.RedirectURI,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		ExpiresAt:           time.Now().UTC().Add(s.codeDuration),
+		Scopes:              strings.Fields(req.Scope),
+		Nonce:               req.Nonce,
+	}
+	if err := s.codeRepo.SaveCode(&code); err != nil {
+		return "", err
+	}
+
+	purgeExpired("authorization codes", s.codeRepo.DeleteExpiredCodes)
+
+	return value, nil
+}
+
+// IssueClientToken implements the client credentials grant (RFC 6749, section 4.4): it authenticates
+// a confidential client and issues a client token granting the requested scopes, or all of the client's
+// scopes if none are requested.
+func (s *OAuthService) IssueClientToken(clientID string, clientSecret string, scopes []string) (*models.IssuedTokens, error) {
+
+	client, err := s.clientService.IdentifyClient(clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	// Public clients can't prove their identity, so they can't act on their own behalf
+	if !client.IsConfidential() {
+		return nil, autherrors.ErrUnauthorizedClient
+	}
+
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if err := checkScopes(client, scopes); err != nil {
+		return nil, err
+	}
+
+	token, err := s.tokenService.CreateClientToken(client, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.IssuedTokens{AccessToken: token, Scopes: scopes}, nil
+}
+
+// ExchangeAuthorizationCode redeems an authorization code for a token pair starting a new session,
+// and an ID token if the "openid" scope was requested and OpenID Connect is enabled.
+// The code must have been issued to the same client for the same redirect URI, must not be expired
+// or already used, and the code verifier must match the PKCE challenge.
+// A code presented again means it leaked, so the session started with it is revoked (RFC 6749, section 4.1.2).
+func (s *OAuthService) ExchangeAuthorizationCode(exchange *models.AuthorizationCodeExchange,
+	session models.SessionMetadata) (*models.IssuedTokens, error) {
+
+	client, err := s.clientService.IdentifyClient(exchange.ClientID, exchange.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	codeHash := s.hashService.HashToken(exchange.Code)
+	code, err := s.codeRepo.ConsumeCode(codeHash, client.ID)
+	if errors.Is(err, autherrors.ErrCodeNotFound) {
+		if err := s.revokeReusedCode(codeHash, client.ID); err != nil {
+			return nil, err
+		}
+		return nil, autherrors.ErrInvalidAuthorizationCode("unknown or already used code")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case code.RedirectURI != exchange.RedirectURI:
+		return nil, autherrors.ErrInvalidAuthorizationCode("redirect_uri mismatch")
+	case !code.ExpiresAt.After(time.Now().UTC()):
+		return nil, autherrors.ErrInvalidAuthorizationCode("code expired")
+	case !verifyCodeChallenge(code.CodeChallenge, exchange.CodeVerifier):
+		return nil, autherrors.ErrInvalidAuthorizationCode("code_verifier mismatch")
+	}
+
+	user, err := s.userService.FindUser(&models.UserFilter{ID: &code.UserID})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, autherrors.ErrInvalidAuthorizationCode("user no longer exists")
+	}
+
+	if err := s.roleService.LoadAuthorization(user); err != nil {
+		return nil, err
+	}
+
+	scopes := grantedScopes(user, code.Scopes)
+	accessToken, refreshToken, err := s.tokenService.CreateTokenPairForCode(user, code, scopes, session)
+	if err != nil {
+		return nil, err
+	}
+	tokens := &models.IssuedTokens{AccessToken: accessToken, RefreshToken: refreshToken, Scopes: scopes}
+
+	if s.openIDConnect && slices.Contains(scopes, constants.ScopeOpenID) {
+		tokens.IDToken, err = s.tokenService.CreateIDToken(user, client.ID, code.Nonce, code.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tokens, nil
+}
+
+// checkScopes returns autherrors.ErrInvalidScope naming the first of the scopes the client may not be granted.
+func checkScopes(client *models.Client, scopes []string) error {
+	for _, scope := range scopes {
+		if !client.AllowsScope(scope) {
+			return autherrors.ErrScopeNotAllowed(scope)
+		}
+	}
+	return nil
+}
+
+// grantedScopes returns the scopes granted to a client signed in to by the user: the requested scopes the user has
+// as permissions, and "openid", which only identifies the user. Tokens issued to clients are limited to them.
+func grantedScopes(user *models.User, scopes []string) []string {
+	var granted []string
+	for _, scope := range scopes {
+		if scope == constants.ScopeOpenID || slices.Contains(user.Permissions, scope) {
+			granted = append(granted, scope)
+		}
+	}
+	return granted
+}
+
+// revokeReusedCode revokes the session started with the code if it has already been used by the client.
+// Its access tokens stay valid until they expire; its refresh tokens can't be used anymore.
+func (s *OAuthService) revokeReusedCode(codeHash string, clientID string) error {
+
+	code, err := s.codeRepo.FindUsedCode(codeHash)
+	if errors.Is(err, autherrors.ErrCodeNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Only the client the code was issued to can end the session by presenting it again
+	if code.ClientID != clientID || code.TokenFamilyID == nil {
+		return nil
+	}
+
+	log.Printf("authorization code of client %v presented again, revoking session %v", code.ClientID, *code.TokenFamilyID)
+	err = s.tokenService.RevokeSession(code.UserID, *code.TokenFamilyID)
+	if errors.Is(err, autherrors.ErrSessionNotFound) {
+		return nil
+	}
+	return err
+}
+
+// UserInfo returns the user an access token was issued to, for the OpenID Connect userinfo endpoint.
+// Returns autherrors.ErrUserNotExist if the user has been deleted.
+func (s *OAuthService) UserInfo(userID uuid.UUID) (*models.User, error) {
+
+	user, err := s.userService.FindUser(&models.UserFilter{ID: &userID})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, autherrors.ErrUserNotExist
+	}
+
+	return user, nil
+}
+
+// verifyCodeChallenge checks an S256 PKCE code verifier against the challenge (RFC 7636, section 4.6).
+func verifyCodeChallenge(challenge string, verifier string) bool {
+	if len(verifier) < minCodeVerifierLength || len(verifier) > maxCodeVerifierLength {
+		return false
+	}
+
+	hash := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(hash[:])
+
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
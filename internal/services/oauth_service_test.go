@@ -0,0 +1,460 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+)
+
+const (
+	testRedirectURI  = "https://planner.example.com/callback"
+	testCodeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+type OAuthServiceTestSuite struct {
+	suite.Suite
+	ctrl              *gomock.Controller
+	mockClientService *mocks.MockIClientService
+	mockCodeRepo      *mocks.MockIAuthorizationCodeRepository
+	mockAuthenticator *mocks.MockIAuthenticator
+	mockUserService   *mocks.MockIUserService
+	mockRoleService   *mocks.MockIRoleService
+	mockTokenService  *mocks.MockITokenService
+	hashService       *HashService
+	oauthService      *OAuthService
+	client            *models.Client
+	user              *models.User
+	request           *models.AuthorizationRequest
+	session           models.SessionMetadata
+}
+
+func (s *OAuthServiceTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockClientService = mocks.NewMockIClientService(s.ctrl)
+	s.mockCodeRepo = mocks.NewMockIAuthorizationCodeRepository(s.ctrl)
+	s.mockAuthenticator = mocks.NewMockIAuthenticator(s.ctrl)
+	s.mockUserService = mocks.NewMockIUserService(s.ctrl)
+	s.mockRoleService = mocks.NewMockIRoleService(s.ctrl)
+	s.mockTokenService = mocks.NewMockITokenService(s.ctrl)
+	s.hashService = NewHashService()
+	s.oauthService = NewOAuthService(s.mockClientService, s.mockCodeRepo, s.mockAuthenticator,
+		s.mockUserService, s.mockRoleService, s.mockTokenService, s.hashService, time.Minute, WithOpenIDConnect())
+
+	s.client = &models.Client{ID: "planner-spa", Name: "Planner", RedirectURIs: []string{testRedirectURI},
+		Scopes: []string{"openid", "profile"}}
+	s.user = &models.User{ID: uuid.New(), Login: "planner-user"}
+	s.request = &models.AuthorizationRequest{
+		ResponseType:        constants.ResponseTypeCode,
+		ClientID:            s.client.ID,
+		RedirectURI:         testRedirectURI,
+		State:               "xyz",
+		CodeChallenge:       codeChallenge(testCodeVerifier),
+		CodeChallengeMethod: constants.CodeChallengeMethodS256,
+	}
+	s.session = models.SessionMetadata{UserAgent: "planner-web", IPAddress: "203.0.113.7"}
+}
+
+func (s *OAuthServiceTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+// codeChallenge computes the S256 PKCE challenge for the verifier.
+func codeChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// issuedCode returns a valid, unused authorization code for the suite's request.
+func (s *OAuthServiceTestSuite) issuedCode() *models.AuthorizationCode {
+	return &models.AuthorizationCode{
+		ClientID:            s.client.ID,
+		UserID:              s.user.ID,
+		RedirectURI:         testRedirectURI,
+		CodeChallenge:       s.request.CodeChallenge,
+		CodeChallengeMethod: constants.CodeChallengeMethodS256,
+		ExpiresAt:           time.Now().UTC().Add(time.Minute),
+	}
+}
+
+func (s *OAuthServiceTestSuite) exchange() *models.AuthorizationCodeExchange {
+	return &models.AuthorizationCodeExchange{
+		ClientID:     s.client.ID,
+		Code:         "issued-code",
+		RedirectURI:  testRedirectURI,
+		CodeVerifier: testCodeVerifier,
+	}
+}
+
+func (s *OAuthServiceTestSuite) TestValidateRedirectUnregisteredURI() {
+	s.mockClientService.EXPECT().FindClient(s.client.ID).Return(s.client, nil)
+	s.request.RedirectURI = "https://attacker.example.com/callback"
+
+	_, err := s.oauthService.ValidateRedirect(s.request)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidRedirectURI)
+}
+
+func (s *OAuthServiceTestSuite) TestValidateAuthorizationRequest() {
+	testCases := []struct {
+		name   string
+		modify func(req *models.AuthorizationRequest)
+		err    error
+	}{
+		{name: "valid request", modify: func(*models.AuthorizationRequest) {}},
+		{name: "token response type", modify: func(req *models.AuthorizationRequest) { req.ResponseType = "token" }, err: autherrors.ErrUnsupportedResponseType},
+		{name: "missing challenge", modify: func(req *models.AuthorizationRequest) { req.CodeChallenge = "" }, err: autherrors.ErrInvalidCodeChallenge},
+		{name: "plain challenge", modify: func(req *models.AuthorizationRequest) { req.CodeChallengeMethod = "plain" }, err: autherrors.ErrInvalidCodeChallenge},
+		{name: "allowed scopes", modify: func(req *models.AuthorizationRequest) { req.Scope = "openid profile" }},
+		{name: "scope not allowed", modify: func(req *models.AuthorizationRequest) { req.Scope = "openid plans:write" }, err: autherrors.ErrInvalidScope},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.mockClientService.EXPECT().FindClient(s.client.ID).Return(s.client, nil)
+			req := *s.request
+			tc.modify(&req)
+
+			err := s.oauthService.ValidateAuthorizationRequest(&req)
+
+			if tc.err == nil {
+				assert.NoError(s.T(), err)
+			} else {
+				assert.ErrorIs(s.T(), err, tc.err)
+			}
+		})
+	}
+}
+
+func (s *OAuthServiceTestSuite) TestWithoutOpenIDConnect() {
+	oauthService := NewOAuthService(s.mockClientService, s.mockCodeRepo, s.mockAuthenticator,
+		s.mockUserService, s.mockRoleService, s.mockTokenService, s.hashService, time.Minute)
+	s.mockClientService.EXPECT().FindClient(s.client.ID).Return(s.client, nil)
+	req := *s.request
+	req.Scope = "openid profile"
+
+	err := oauthService.ValidateAuthorizationRequest(&req)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidScope)
+	assert.ErrorContains(s.T(), err, constants.ScopeOpenID)
+}
+
+func (s *OAuthServiceTestSuite) TestAuthorizeSuccess() {
+	s.mockClientService.EXPECT().FindClient(s.client.ID).Return(s.client, nil)
+	s.mockAuthenticator.EXPECT().Authenticate("planner-user", "secret", "203.0.113.7").Return(s.user, nil)
+
+	s.request.Scope = "openid profile"
+	s.request.Nonce = "n-0S6_WzA2Mj"
+
+	var saved *models.AuthorizationCode
+	s.mockCodeRepo.EXPECT().
+		SaveCode(gomock.Any()).
+		DoAndReturn(func(code *models.AuthorizationCode) error {
+			saved = code
+			return nil
+		})
+	s.mockCodeRepo.EXPECT().DeleteExpiredCodes().Return(errors.New("purge failed"))
+
+	code, err := s.oauthService.Authorize(s.request, "planner-user", "secret", "203.0.113.7")
+
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), saved)
+	assert.NotEmpty(s.T(), code)
+	assert.Equal(s.T(), s.hashService.HashToken(code), saved.CodeHash, "only the hash of the code should be stored")
+	assert.Equal(s.T(), s.client.ID, saved.ClientID)
+	assert.Equal(s.T(), s.user.ID, saved.UserID)
+	assert.Equal(s.T(), testRedirectURI, saved.RedirectURI)
+	assert.Equal(s.T(), s.request.CodeChallenge, saved.CodeChallenge)
+	assert.Equal(s.T(), []string{"openid", "profile"}, saved.Scopes)
+	assert.Equal(s.T(), "n-0S6_WzA2Mj", saved.Nonce)
+	assert.WithinDuration(s.T(), time.Now().UTC().Add(time.Minute), saved.ExpiresAt, 5*time.Second)
+}
+
+func (s *OAuthServiceTestSuite) TestAuthorizeWrongPassword() {
+	s.mockClientService.EXPECT().FindClient(s.client.ID).Return(s.client, nil)
+	s.mockAuthenticator.EXPECT().Authenticate("planner-user", "wrong", "203.0.113.7").Return(nil, autherrors.ErrPasswordMismatch)
+
+	code, err := s.oauthService.Authorize(s.request, "planner-user", "wrong", "203.0.113.7")
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrPasswordMismatch)
+	assert.Empty(s.T(), code)
+}
+
+func (s *OAuthServiceTestSuite) TestAuthorizeMFASuccess() {
+	s.mockClientService.EXPECT().FindClient(s.client.ID).Return(s.client, nil)
+	s.mockAuthenticator.EXPECT().AuthenticateMFA("challenge", "123456", "203.0.113.7").Return(s.user, nil)
+	s.mockCodeRepo.EXPECT().SaveCode(gomock.Any()).Return(nil)
+	s.mockCodeRepo.EXPECT().DeleteExpiredCodes().Return(nil)
+
+	code, err := s.oauthService.AuthorizeMFA(s.request, "challenge", "123456", "203.0.113.7")
+
+	require.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), code)
+}
+
+func (s *OAuthServiceTestSuite) TestAuthorizeMFAWrongCode() {
+	s.mockClientService.EXPECT().FindClient(s.client.ID).Return(s.client, nil)
+	s.mockAuthenticator.EXPECT().
+		AuthenticateMFA("challenge", "000000", "203.0.113.7").
+		Return(nil, autherrors.ErrInvalidMFACode)
+
+	code, err := s.oauthService.AuthorizeMFA(s.request, "challenge", "000000", "203.0.113.7")
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidMFACode)
+	assert.Empty(s.T(), code)
+}
+
+func (s *OAuthServiceTestSuite) TestAuthorizePasskeyMFASuccess() {
+	assertion := &models.PasskeyAssertion{CredentialID: []byte("credential")}
+	s.mockAuthenticator.EXPECT().BeginPasskeyMFA("challenge").Return(&models.PasskeyRequestOptions{}, nil)
+	s.mockClientService.EXPECT().FindClient(s.client.ID).Return(s.client, nil)
+	s.mockAuthenticator.EXPECT().AuthenticatePasskeyMFA("challenge", assertion, "203.0.113.7").Return(s.user, nil)
+	s.mockCodeRepo.EXPECT().SaveCode(gomock.Any()).Return(nil)
+	s.mockCodeRepo.EXPECT().DeleteExpiredCodes().Return(nil)
+
+	_, err := s.oauthService.BeginPasskeyMFA("challenge")
+	require.NoError(s.T(), err)
+
+	code, err := s.oauthService.AuthorizePasskeyMFA(s.request, "challenge", assertion, "203.0.113.7")
+
+	require.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), code)
+}
+
+func (s *OAuthServiceTestSuite) TestAuthorizePasskeyMFAInvalidPasskey() {
+	s.mockClientService.EXPECT().FindClient(s.client.ID).Return(s.client, nil)
+	s.mockAuthenticator.EXPECT().
+		AuthenticatePasskeyMFA("challenge", gomock.Any(), "203.0.113.7").
+		Return(nil, autherrors.ErrPasskeyVerification("invalid signature"))
+
+	code, err := s.oauthService.AuthorizePasskeyMFA(s.request, "challenge", &models.PasskeyAssertion{}, "203.0.113.7")
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey)
+	assert.Empty(s.T(), code)
+}
+
+func (s *OAuthServiceTestSuite) TestExchangeAuthorizationCodeSuccess() {
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+	code := s.issuedCode()
+	s.mockCodeRepo.EXPECT().ConsumeCode(s.hashService.HashToken("issued-code"), s.client.ID).Return(code, nil)
+	s.mockUserService.EXPECT().FindUser(&models.UserFilter{ID: &s.user.ID}).Return(s.user, nil)
+	s.mockRoleService.EXPECT().LoadAuthorization(s.user).Return(nil)
+	s.mockTokenService.EXPECT().
+		CreateTokenPairForCode(s.user, code, gomock.Any(), s.session).
+		Return(&models.Token{Value: "access"}, &models.Token{Value: "refresh"}, nil)
+
+	tokens, err := s.oauthService.ExchangeAuthorizationCode(s.exchange(), s.session)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "access", tokens.AccessToken.Value)
+	assert.Equal(s.T(), "refresh", tokens.RefreshToken.Value)
+	assert.Nil(s.T(), tokens.IDToken, "ID tokens are only issued for the openid scope")
+}
+
+func (s *OAuthServiceTestSuite) TestExchangeAuthorizationCodeOpenID() {
+	code := s.issuedCode()
+	code.Scopes = []string{constants.ScopeOpenID, "plans:write"}
+	code.Nonce = "n-0S6_WzA2Mj"
+	code.CreatedAt = time.Now().UTC().Add(-30 * time.Second)
+
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+	s.mockCodeRepo.EXPECT().ConsumeCode(gomock.Any(), s.client.ID).Return(code, nil)
+	s.mockUserService.EXPECT().FindUser(&models.UserFilter{ID: &s.user.ID}).Return(s.user, nil)
+	s.mockRoleService.EXPECT().LoadAuthorization(s.user).Return(nil)
+	s.mockTokenService.EXPECT().
+		CreateTokenPairForCode(s.user, code, []string{constants.ScopeOpenID}, s.session).
+		Return(&models.Token{Value: "access"}, &models.Token{Value: "refresh"}, nil)
+	s.mockTokenService.EXPECT().
+		CreateIDToken(s.user, s.client.ID, code.Nonce, code.CreatedAt).
+		Return(&models.Token{Value: "id"}, nil)
+
+	tokens, err := s.oauthService.ExchangeAuthorizationCode(s.exchange(), s.session)
+
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), tokens.IDToken)
+	assert.Equal(s.T(), "id", tokens.IDToken.Value)
+	assert.Equal(s.T(), []string{constants.ScopeOpenID}, tokens.Scopes, "scopes the user lacks are not granted")
+}
+
+func (s *OAuthServiceTestSuite) TestExchangeAuthorizationCodeRejected() {
+	testCases := []struct {
+		name     string
+		modify   func(code *models.AuthorizationCode, exchange *models.AuthorizationCodeExchange)
+		consumed bool
+	}{
+		{
+			name: "wrong verifier",
+			modify: func(_ *models.AuthorizationCode, e *models.AuthorizationCodeExchange) {
+				e.CodeVerifier = strings.Repeat("a", 43)
+			},
+			consumed: true,
+		},
+		{
+			name: "short verifier",
+			modify: func(c *models.AuthorizationCode, e *models.AuthorizationCodeExchange) {
+				e.CodeVerifier = "short"
+				c.CodeChallenge = codeChallenge("short")
+			},
+			consumed: true,
+		},
+		{
+			name: "redirect mismatch",
+			modify: func(_ *models.AuthorizationCode, e *models.AuthorizationCodeExchange) {
+				e.RedirectURI = testRedirectURI + "/other"
+			},
+			consumed: true,
+		},
+		{
+			name: "expired code",
+			modify: func(c *models.AuthorizationCode, _ *models.AuthorizationCodeExchange) {
+				c.ExpiresAt = time.Now().UTC().Add(-time.Second)
+			},
+			consumed: true,
+		},
+		{
+			name:   "used code",
+			modify: func(*models.AuthorizationCode, *models.AuthorizationCodeExchange) {},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			code, exchange := s.issuedCode(), s.exchange()
+			tc.modify(code, exchange)
+
+			s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+			if tc.consumed {
+				s.mockCodeRepo.EXPECT().ConsumeCode(gomock.Any(), s.client.ID).Return(code, nil)
+			} else {
+				s.mockCodeRepo.EXPECT().ConsumeCode(gomock.Any(), s.client.ID).Return(nil, autherrors.ErrCodeNotFound)
+				s.mockCodeRepo.EXPECT().FindUsedCode(gomock.Any()).Return(nil, autherrors.ErrCodeNotFound)
+			}
+
+			tokens, err := s.oauthService.ExchangeAuthorizationCode(exchange, s.session)
+
+			assert.ErrorIs(s.T(), err, autherrors.ErrInvalidGrant)
+			assert.Nil(s.T(), tokens)
+		})
+	}
+}
+
+func (s *OAuthServiceTestSuite) TestExchangeReusedAuthorizationCodeRevokesSession() {
+	code := s.issuedCode()
+	familyID := uuid.New()
+	code.TokenFamilyID = &familyID
+
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+	s.mockCodeRepo.EXPECT().
+		ConsumeCode(s.hashService.HashToken("issued-code"), s.client.ID).
+		Return(nil, autherrors.ErrCodeNotFound)
+	s.mockCodeRepo.EXPECT().FindUsedCode(s.hashService.HashToken("issued-code")).Return(code, nil)
+	s.mockTokenService.EXPECT().RevokeSession(s.user.ID, familyID).Return(nil)
+
+	tokens, err := s.oauthService.ExchangeAuthorizationCode(s.exchange(), s.session)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidGrant)
+	assert.Nil(s.T(), tokens)
+}
+
+func (s *OAuthServiceTestSuite) TestExchangeOtherClientsAuthorizationCode() {
+	code := s.issuedCode()
+	code.ClientID = "other-client"
+	familyID := uuid.New()
+	code.TokenFamilyID = &familyID
+
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+	s.mockCodeRepo.EXPECT().ConsumeCode(gomock.Any(), s.client.ID).Return(nil, autherrors.ErrCodeNotFound)
+	s.mockCodeRepo.EXPECT().FindUsedCode(gomock.Any()).Return(code, nil)
+
+	tokens, err := s.oauthService.ExchangeAuthorizationCode(s.exchange(), s.session)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidGrant)
+	assert.Nil(s.T(), tokens, "another client's code must neither be exchanged nor end its session")
+}
+
+func (s *OAuthServiceTestSuite) TestExchangeAuthorizationCodeInvalidClient() {
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(nil, autherrors.ErrInvalidClient)
+
+	_, err := s.oauthService.ExchangeAuthorizationCode(s.exchange(), s.session)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidClient)
+}
+
+func (s *OAuthServiceTestSuite) TestIssueClientToken() {
+	serviceAccount := &models.Client{ID: "nightly-export", SecretHash: "hash", Scopes: []string{"plans:read", "users:read"}}
+
+	testCases := []struct {
+		name      string
+		requested []string
+		granted   []string
+	}{
+		{name: "all scopes by default", requested: nil, granted: serviceAccount.Scopes},
+		{name: "requested subset", requested: []string{"plans:read"}, granted: []string{"plans:read"}},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.mockClientService.EXPECT().IdentifyClient(serviceAccount.ID, "secret").Return(serviceAccount, nil)
+			s.mockTokenService.EXPECT().
+				CreateClientToken(serviceAccount, tc.granted).
+				Return(&models.Token{Value: "client-token", ClientID: serviceAccount.ID}, nil)
+
+			tokens, err := s.oauthService.IssueClientToken(serviceAccount.ID, "secret", tc.requested)
+
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), "client-token", tokens.AccessToken.Value)
+			assert.Nil(s.T(), tokens.RefreshToken)
+			assert.Equal(s.T(), tc.granted, tokens.Scopes)
+		})
+	}
+}
+
+func (s *OAuthServiceTestSuite) TestIssueClientTokenRejected() {
+	serviceAccount := &models.Client{ID: "nightly-export", SecretHash: "hash", Scopes: []string{"plans:read"}}
+
+	s.mockClientService.EXPECT().IdentifyClient(serviceAccount.ID, "secret").Return(serviceAccount, nil)
+	_, err := s.oauthService.IssueClientToken(serviceAccount.ID, "secret", []string{"plans:read", "users:manage"})
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidScope)
+	assert.ErrorContains(s.T(), err, "users:manage")
+
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "").Return(s.client, nil)
+	_, err = s.oauthService.IssueClientToken(s.client.ID, "", nil)
+	assert.ErrorIs(s.T(), err, autherrors.ErrUnauthorizedClient, "public clients can't act on their own behalf")
+
+	s.mockClientService.EXPECT().IdentifyClient(serviceAccount.ID, "wrong").Return(nil, autherrors.ErrInvalidClient)
+	_, err = s.oauthService.IssueClientToken(serviceAccount.ID, "wrong", nil)
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidClient)
+}
+
+func (s *OAuthServiceTestSuite) TestUserInfo() {
+	s.mockUserService.EXPECT().FindUser(&models.UserFilter{ID: &s.user.ID}).Return(s.user, nil)
+
+	user, err := s.oauthService.UserInfo(s.user.ID)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.user, user)
+
+	missing := uuid.New()
+	s.mockUserService.EXPECT().FindUser(&models.UserFilter{ID: &missing}).Return(nil, nil)
+
+	_, err = s.oauthService.UserInfo(missing)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrUserNotExist)
+}
+
+func TestOAuthServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(OAuthServiceTestSuite))
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/webauthn"
+)
+
+const (
+	// defaultPasskeyName names passkeys registered without a name.
+	defaultPasskeyName = "Passkey"
+	// maxPasskeyNameLength is the length of the name column; longer names are cut.
+	maxPasskeyNameLength = 255
+	// User verification requirements of authentication ceremonies (WebAuthn Level 3, section 5.8.6).
+	userVerificationRequired    = "required"
+	userVerificationDiscouraged = "discouraged"
+)
+
+// IPasskeyRepository defines the interface for passkey and WebAuthn challenge persistence.
+type IPasskeyRepository interface {
+	SaveChallenge(challenge *models.PasskeyChallenge) error
+	ConsumeChallenge(challengeHash string) (*models.PasskeyChallenge, error)
+	DeleteExpiredChallenges() error
+	SavePasskey(passkey *models.Passkey) error
+	FindPasskey(credentialID []byte) (*models.Passkey, error)
+	FindPasskeys(userID uuid.UUID) ([]*models.Passkey, error)
+	UseSignCount(credentialID []byte, signCount uint32, backedUp bool) (bool, error)
+	DeletePasskey(userID uuid.UUID, credentialID []byte) error
+}
+
+// PasskeyService runs the WebAuthn ceremonies with which signed-in users register passkeys and later sign in
+// with them, either instead of a password or as the second factor after it.
+// Each ceremony starts with options holding a new challenge, which the browser passes to the authenticator;
+// the challenge is stored, hashed, until the authenticator's response comes back and is accepted only once.
+type PasskeyService struct {
+	passkeyRepo       IPasskeyRepository
+	eventRepo         ISecurityEventRepository
+	userService       IUserService
+	hashService       IHashService
+	relyingParty      *webauthn.RelyingParty
+	challengeDuration time.Duration
+}
+
+// NewPasskeyService creates a new passkey service instance.
+// Passkeys are bound to the relying party, and challengeDuration is how long the user has to complete a ceremony.
+func NewPasskeyService(passkeyRepo IPasskeyRepository, eventRepo ISecurityEventRepository, userService IUserService,
+	hashService IHashService, relyingParty *webauthn.RelyingParty, challengeDuration time.Duration) *PasskeyService {
+	return &PasskeyService{
+		passkeyRepo:       passkeyRepo,
+		eventRepo:         eventRepo,
+		userService:       userService,
+		hashService:       hashService,
+		relyingParty:      relyingParty,
+		challengeDuration: challengeDuration,
+	}
+}
+
+// BeginRegistration starts registering a passkey for the user and returns the options for the authenticator.
+// The passkey must be discoverable, so it can be used for logins without entering the login first.
+// A passkey signs in without a password, so callers must have the user re-authenticate first,
+// see AuthService.BeginPasskeyRegistration.
+func (s *PasskeyService) BeginRegistration(userID uuid.UUID) (*models.PasskeyCreationOptions, error) {
+
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeys, err := s.passkeyRepo.FindPasskeys(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.issueChallenge(models.PasskeyCeremonyRegistration, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PasskeyCreationOptions{
+		Challenge:          challenge,
+		RPID:               s.relyingParty.ID,
+		RPName:             s.relyingParty.Name,
+		UserHandle:         user.ID[:],
+		UserName:           user.Login,
+		Algorithms:         webauthn.Algorithms,
+		ExcludeCredentials: credentialIDs(passkeys),
+		Timeout:            s.challengeDuration,
+	}, nil
+
+}
+
+// FinishRegistration verifies the authenticator's response to BeginRegistration and stores the new passkey
+// under the name, or a default name if it is empty. A security event is recorded for the user.
+// Returns an error matching autherrors.ErrInvalidPasskey if the response is invalid
+// and autherrors.ErrPasskeyExists if the passkey is already registered.
+func (s *PasskeyService) FinishRegistration(userID uuid.UUID, name string,
+	attestation *models.PasskeyAttestation) (*models.Passkey, error) {
+
+	challenge, err := s.consumeChallenge(attestation.ClientDataJSON, models.PasskeyCeremonyRegistration, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := s.relyingParty.VerifyRegistration(challenge, attestation.ClientDataJSON, attestation.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+	if len(attestation.CredentialID) > 0 && !bytes.Equal(attestation.CredentialID, credential.ID) {
+		return nil, autherrors.ErrPasskeyVerification("credential ID mismatch")
+	}
+
+	passkey := &models.Passkey{
+		ID:             credential.ID,
+		UserID:         userID,
+		Name:           passkeyName(name),
+		PublicKey:      credential.PublicKey,
+		SignCount:      credential.SignCount,
+		AAGUID:         credential.AAGUID,
+		BackupEligible: credential.BackupEligible,
+		BackedUp:       credential.BackedUp,
+	}
+	if err := s.passkeyRepo.SavePasskey(passkey); err != nil {
+		return nil, err
+	}
+
+	event := models.SecurityEvent{
+		UserID: userID,
+		Type:   string(constants.SecurityEventPasskeyAdded),
+	}
+	if err := s.eventRepo.SaveEvent(&event); err != nil {
+		log.Printf("failed to record added passkey: %v", err)
+	}
+
+	return passkey, nil
+
+}
+
+// ListPasskeys returns the passkeys of the user, oldest first.
+func (s *PasskeyService) ListPasskeys(userID uuid.UUID) ([]*models.Passkey, error) {
+	return s.passkeyRepo.FindPasskeys(userID)
+}
+
+// HasPasskeys reports whether the user registered a passkey, which then serves as their second factor
+// after a password login.
+func (s *PasskeyService) HasPasskeys(userID uuid.UUID) (bool, error) {
+
+	passkeys, err := s.passkeyRepo.FindPasskeys(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return len(passkeys) > 0, nil
+
+}
+
+// DeletePasskey removes a passkey of the user. Returns autherrors.ErrPasskeyNotFound if the user has no such passkey.
+func (s *PasskeyService) DeletePasskey(userID uuid.UUID, credentialID []byte) error {
+	return s.passkeyRepo.DeletePasskey(userID, credentialID)
+}
+
+// BeginLogin starts a login with a passkey instead of a password and returns the options for the authenticator.
+// The user picks one of the passkeys the authenticator holds for this service, which identifies them,
+// and must be verified by the authenticator, e.g. with a fingerprint or PIN, as there is no password.
+func (s *PasskeyService) BeginLogin() (*models.PasskeyRequestOptions, error) {
+
+	challenge, err := s.issueChallenge(models.PasskeyCeremonyLogin, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PasskeyRequestOptions{
+		Challenge:        challenge,
+		RPID:             s.relyingParty.ID,
+		UserVerification: userVerificationRequired,
+		Timeout:          s.challengeDuration,
+	}, nil
+
+}
+
+// FinishLogin verifies the authenticator's response to BeginLogin and returns the user owning the passkey.
+// Returns an error matching autherrors.ErrInvalidPasskey if the response is invalid
+// and autherrors.ErrPasskeyCounter if the passkey appears to be cloned.
+func (s *PasskeyService) FinishLogin(assertion *models.PasskeyAssertion) (*models.User, error) {
+
+	challenge, err := s.consumeChallenge(assertion.ClientDataJSON, models.PasskeyCeremonyLogin, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey, err := s.verifyAssertion(challenge, assertion, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.findUser(passkey.UserID)
+
+}
+
+// BeginSecondFactor starts checking a passkey of the user as the second factor of a password login
+// and returns the options for the authenticator. The password was checked already, so the user's presence
+// suffices. Returns autherrors.ErrNoPasskeys if the user has none.
+func (s *PasskeyService) BeginSecondFactor(userID uuid.UUID) (*models.PasskeyRequestOptions, error) {
+
+	passkeys, err := s.passkeyRepo.FindPasskeys(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(passkeys) == 0 {
+		return nil, autherrors.ErrNoPasskeys
+	}
+
+	challenge, err := s.issueChallenge(models.PasskeyCeremonyMFA, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PasskeyRequestOptions{
+		Challenge:        challenge,
+		RPID:             s.relyingParty.ID,
+		AllowCredentials: credentialIDs(passkeys),
+		UserVerification: userVerificationDiscouraged,
+		Timeout:          s.challengeDuration,
+	}, nil
+
+}
+
+// VerifySecondFactor verifies the authenticator's response to BeginSecondFactor.
+// Returns an error matching autherrors.ErrInvalidPasskey if the response is invalid or the passkey
+// is not one of the user's, and autherrors.ErrPasskeyCounter if the passkey appears to be cloned.
+func (s *PasskeyService) VerifySecondFactor(userID uuid.UUID, assertion *models.PasskeyAssertion) error {
+
+	challenge, err := s.consumeChallenge(assertion.ClientDataJSON, models.PasskeyCeremonyMFA, &userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.verifyAssertion(challenge, assertion, &userID, false)
+	return err
+
+}
+
+// issueChallenge stores a new challenge for the ceremony of the user and returns it.
+func (s *PasskeyService) issueChallenge(ceremony models.PasskeyCeremony, userID *uuid.UUID) ([]byte, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, autherrors.ErrSavePasskeyChallenge(err)
+	}
+
+	err = s.passkeyRepo.SaveChallenge(&models.PasskeyChallenge{
+		ChallengeHash: s.hashChallenge(challenge),
+		Ceremony:      ceremony,
+		UserID:        userID,
+		ExpiresAt:     time.Now().UTC().Add(s.challengeDuration),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	purgeExpired("passkey challenges", s.passkeyRepo.DeleteExpiredChallenges)
+
+	return challenge, nil
+}
+
+// consumeChallenge looks up the challenge the response was created for and uses it up.
+// It must have been issued for the ceremony of the same user, or of no user for passkey logins, and not be expired.
+func (s *PasskeyService) consumeChallenge(clientDataJSON []byte, ceremony models.PasskeyCeremony,
+	userID *uuid.UUID) ([]byte, error) {
+	challenge, err := webauthn.ChallengeOf(clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.passkeyRepo.ConsumeChallenge(s.hashChallenge(challenge))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case stored == nil:
+		return nil, autherrors.ErrPasskeyVerification("unknown or already used challenge")
+	case stored.Ceremony != ceremony:
+		return nil, autherrors.ErrPasskeyVerification("challenge was issued for another ceremony")
+	case (stored.UserID == nil) != (userID == nil), stored.UserID != nil && *stored.UserID != *userID:
+		return nil, autherrors.ErrPasskeyVerification("challenge was issued to another user")
+	case !stored.ExpiresAt.After(time.Now().UTC()):
+		return nil, autherrors.ErrPasskeyVerification("challenge expired")
+	}
+
+	return challenge, nil
+}
+
+// verifyAssertion checks the assertion against the stored passkey, which must belong to the user if one is given,
+// and records the passkey's new signature counter. A counter that didn't increase means another copy of the passkey
+// signed in the meantime: the login is rejected and a security event is recorded for the user.
+func (s *PasskeyService) verifyAssertion(challenge []byte, assertion *models.PasskeyAssertion, userID *uuid.UUID,
+	requireUserVerification bool) (*models.Passkey, error) {
+	passkey, err := s.passkeyRepo.FindPasskey(assertion.CredentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case passkey == nil:
+		return nil, autherrors.ErrPasskeyVerification("unknown passkey")
+	case userID != nil && passkey.UserID != *userID:
+		return nil, autherrors.ErrPasskeyVerification("passkey of another user")
+	case len(assertion.UserHandle) > 0 && !bytes.Equal(assertion.UserHandle, passkey.UserID[:]):
+		return nil, autherrors.ErrPasskeyVerification("user handle mismatch")
+	}
+
+	result, err := s.relyingParty.VerifyAssertion(challenge, passkey.PublicKey, assertion.ClientDataJSON,
+		assertion.AuthenticatorData, assertion.Signature, requireUserVerification)
+	if err != nil {
+		return nil, err
+	}
+
+	used, err := s.passkeyRepo.UseSignCount(passkey.ID, result.SignCount, result.BackedUp)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		event := models.SecurityEvent{
+			UserID: passkey.UserID,
+			Type:   string(constants.SecurityEventPasskeyCloned),
+		}
+		if err := s.eventRepo.SaveEvent(&event); err != nil {
+			log.Printf("failed to record cloned passkey: %v", err)
+		}
+		return nil, autherrors.ErrPasskeyCounter
+	}
+
+	return passkey, nil
+}
+
+// hashChallenge returns the hash under which a challenge is stored.
+func (s *PasskeyService) hashChallenge(challenge []byte) string {
+	return s.hashService.HashToken(base64.RawURLEncoding.EncodeToString(challenge))
+}
+
+// findUser returns the user with the ID or autherrors.ErrUserNotExist.
+func (s *PasskeyService) findUser(userID uuid.UUID) (*models.User, error) {
+	user, err := s.userService.FindUser(&models.UserFilter{ID: &userID})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, autherrors.ErrUserNotExist
+	}
+	return user, nil
+}
+
+// credentialIDs returns the credential IDs of the passkeys.
+func credentialIDs(passkeys []*models.Passkey) [][]byte {
+	ids := make([][]byte, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		ids = append(ids, passkey.ID)
+	}
+	return ids
+}
+
+// passkeyName trims the name chosen by the user, cuts it to the stored length and falls back to a default name.
+func passkeyName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return defaultPasskeyName
+	}
+
+	runes := []rune(name)
+	if len(runes) > maxPasskeyNameLength {
+		return strings.TrimSpace(string(runes[:maxPasskeyNameLength]))
+	}
+	return name
+}
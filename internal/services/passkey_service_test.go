@@ -0,0 +1,379 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+	"github.com/breakfront-planner/auth-service/internal/webauthn"
+	"github.com/breakfront-planner/auth-service/internal/webauthn/webauthntest"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+type PasskeyServiceTestSuite struct {
+	suite.Suite
+	ctrl            *gomock.Controller
+	mockRepo        *mocks.MockIPasskeyRepository
+	mockEventRepo   *mocks.MockISecurityEventRepository
+	mockUserService *mocks.MockIUserService
+	passkeyService  *PasskeyService
+	user            *models.User
+	// challenges and passkeys are the contents of the mocked repository.
+	challenges map[string]*models.PasskeyChallenge
+	passkeys   map[string]*models.Passkey
+}
+
+func (s *PasskeyServiceTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockRepo = mocks.NewMockIPasskeyRepository(s.ctrl)
+	s.mockEventRepo = mocks.NewMockISecurityEventRepository(s.ctrl)
+	s.mockUserService = mocks.NewMockIUserService(s.ctrl)
+	relyingParty := &webauthn.RelyingParty{ID: testRPID, Name: "Breakfront", Origins: []string{testOrigin}}
+	s.passkeyService = NewPasskeyService(s.mockRepo, s.mockEventRepo, s.mockUserService, NewHashService(),
+		relyingParty, 5*time.Minute)
+	s.user = &models.User{ID: uuid.New(), Login: "alice"}
+	s.challenges = make(map[string]*models.PasskeyChallenge)
+	s.passkeys = make(map[string]*models.Passkey)
+
+	s.mockUserService.EXPECT().FindUser(gomock.Any()).Return(s.user, nil).AnyTimes()
+	s.mockRepo.EXPECT().SaveChallenge(gomock.Any()).DoAndReturn(func(challenge *models.PasskeyChallenge) error {
+		s.challenges[challenge.ChallengeHash] = challenge
+		return nil
+	}).AnyTimes()
+	s.mockRepo.EXPECT().ConsumeChallenge(gomock.Any()).DoAndReturn(func(challengeHash string) (*models.PasskeyChallenge, error) {
+		challenge := s.challenges[challengeHash]
+		delete(s.challenges, challengeHash)
+		return challenge, nil
+	}).AnyTimes()
+	s.mockRepo.EXPECT().DeleteExpiredChallenges().Return(nil).AnyTimes()
+	s.mockRepo.EXPECT().SavePasskey(gomock.Any()).DoAndReturn(func(passkey *models.Passkey) error {
+		if s.passkeys[string(passkey.ID)] != nil {
+			return autherrors.ErrPasskeyExists
+		}
+		s.passkeys[string(passkey.ID)] = passkey
+		return nil
+	}).AnyTimes()
+	s.mockRepo.EXPECT().FindPasskey(gomock.Any()).DoAndReturn(func(credentialID []byte) (*models.Passkey, error) {
+		return s.passkeys[string(credentialID)], nil
+	}).AnyTimes()
+	s.mockRepo.EXPECT().FindPasskeys(gomock.Any()).DoAndReturn(func(userID uuid.UUID) ([]*models.Passkey, error) {
+		var passkeys []*models.Passkey
+		for _, passkey := range s.passkeys {
+			if passkey.UserID == userID {
+				passkeys = append(passkeys, passkey)
+			}
+		}
+		return passkeys, nil
+	}).AnyTimes()
+	s.mockRepo.EXPECT().UseSignCount(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(credentialID []byte, signCount uint32, backedUp bool) (bool, error) {
+			passkey := s.passkeys[string(credentialID)]
+			if passkey.SignCount >= signCount && (passkey.SignCount != 0 || signCount != 0) {
+				return false, nil
+			}
+			passkey.SignCount = signCount
+			passkey.BackedUp = backedUp
+			return true, nil
+		}).AnyTimes()
+}
+
+func (s *PasskeyServiceTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+// register registers the passkey of a new software authenticator for the test user.
+func (s *PasskeyServiceTestSuite) register() *webauthntest.Authenticator {
+	authenticator, err := webauthntest.New(testRPID, testOrigin)
+	require.NoError(s.T(), err)
+
+	options, err := s.passkeyService.BeginRegistration(s.user.ID)
+	require.NoError(s.T(), err)
+	attestation, err := authenticator.Register(options.Challenge, options.UserHandle)
+	require.NoError(s.T(), err)
+
+	s.mockEventRepo.EXPECT().SaveEvent(gomock.Any()).Return(nil)
+	_, err = s.passkeyService.FinishRegistration(s.user.ID, "Laptop", toPasskeyAttestation(attestation))
+	require.NoError(s.T(), err)
+
+	return authenticator
+}
+
+// login signs in with the authenticator's passkey.
+func (s *PasskeyServiceTestSuite) login(authenticator *webauthntest.Authenticator) (*models.User, error) {
+	options, err := s.passkeyService.BeginLogin()
+	require.NoError(s.T(), err)
+	assertion, err := authenticator.Assert(options.Challenge)
+	require.NoError(s.T(), err)
+
+	return s.passkeyService.FinishLogin(toPasskeyAssertion(assertion))
+}
+
+func (s *PasskeyServiceTestSuite) TestRegistration() {
+	existing := s.register()
+	authenticator, err := webauthntest.New(testRPID, testOrigin)
+	require.NoError(s.T(), err)
+
+	options, err := s.passkeyService.BeginRegistration(s.user.ID)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), testRPID, options.RPID)
+	assert.Equal(s.T(), "Breakfront", options.RPName)
+	assert.Equal(s.T(), s.user.ID[:], options.UserHandle)
+	assert.Equal(s.T(), "alice", options.UserName)
+	assert.Equal(s.T(), webauthn.Algorithms, options.Algorithms)
+	assert.Equal(s.T(), [][]byte{existing.CredentialID}, options.ExcludeCredentials)
+	assert.Equal(s.T(), 5*time.Minute, options.Timeout)
+
+	attestation, err := authenticator.Register(options.Challenge, options.UserHandle)
+	require.NoError(s.T(), err)
+	s.mockEventRepo.EXPECT().SaveEvent(gomock.Any()).DoAndReturn(func(event *models.SecurityEvent) error {
+		assert.Equal(s.T(), s.user.ID, event.UserID)
+		assert.Equal(s.T(), string(constants.SecurityEventPasskeyAdded), event.Type)
+		return nil
+	})
+
+	passkey, err := s.passkeyService.FinishRegistration(s.user.ID, "  Phone ", toPasskeyAttestation(attestation))
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), authenticator.CredentialID, passkey.ID)
+	assert.Equal(s.T(), s.user.ID, passkey.UserID)
+	assert.Equal(s.T(), "Phone", passkey.Name)
+	assert.Equal(s.T(), authenticator.PublicKey(), passkey.PublicKey)
+	assert.Same(s.T(), passkey, s.passkeys[string(authenticator.CredentialID)])
+	assert.Empty(s.T(), s.challenges, "the challenge is used up")
+}
+
+func (s *PasskeyServiceTestSuite) TestFinishRegistrationRejectsChallenges() {
+	authenticator, err := webauthntest.New(testRPID, testOrigin)
+	require.NoError(s.T(), err)
+	options, err := s.passkeyService.BeginRegistration(s.user.ID)
+	require.NoError(s.T(), err)
+	attestation, err := authenticator.Register(options.Challenge, options.UserHandle)
+	require.NoError(s.T(), err)
+
+	_, err = s.passkeyService.FinishRegistration(uuid.New(), "", toPasskeyAttestation(attestation))
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey, "the challenge was issued to another user")
+
+	_, err = s.passkeyService.FinishRegistration(s.user.ID, "", toPasskeyAttestation(attestation))
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey, "the challenge was used up by the first attempt")
+
+	// A login challenge can't register a passkey
+	loginOptions, err := s.passkeyService.BeginLogin()
+	require.NoError(s.T(), err)
+	attestation, err = authenticator.Register(loginOptions.Challenge, options.UserHandle)
+	require.NoError(s.T(), err)
+	_, err = s.passkeyService.FinishRegistration(s.user.ID, "", toPasskeyAttestation(attestation))
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey)
+
+	options, err = s.passkeyService.BeginRegistration(s.user.ID)
+	require.NoError(s.T(), err)
+	for _, challenge := range s.challenges {
+		challenge.ExpiresAt = time.Now().Add(-time.Second)
+	}
+	attestation, err = authenticator.Register(options.Challenge, options.UserHandle)
+	require.NoError(s.T(), err)
+	_, err = s.passkeyService.FinishRegistration(s.user.ID, "", toPasskeyAttestation(attestation))
+	require.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey)
+	assert.Contains(s.T(), err.Error(), "challenge expired")
+
+	assert.Empty(s.T(), s.passkeys)
+}
+
+func (s *PasskeyServiceTestSuite) TestFinishRegistrationRejectsRegisteredPasskey() {
+	authenticator := s.register()
+
+	options, err := s.passkeyService.BeginRegistration(s.user.ID)
+	require.NoError(s.T(), err)
+	attestation, err := authenticator.Register(options.Challenge, options.UserHandle)
+	require.NoError(s.T(), err)
+
+	_, err = s.passkeyService.FinishRegistration(s.user.ID, "", toPasskeyAttestation(attestation))
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrPasskeyExists)
+}
+
+func (s *PasskeyServiceTestSuite) TestLogin() {
+	authenticator := s.register()
+
+	options, err := s.passkeyService.BeginLogin()
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), options.AllowCredentials, "the passkey identifies the user")
+	assert.Equal(s.T(), "required", options.UserVerification)
+
+	assertion, err := authenticator.Assert(options.Challenge)
+	require.NoError(s.T(), err)
+
+	user, err := s.passkeyService.FinishLogin(toPasskeyAssertion(assertion))
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.user, user)
+	assert.Equal(s.T(), uint32(1), s.passkeys[string(authenticator.CredentialID)].SignCount)
+}
+
+func (s *PasskeyServiceTestSuite) TestLoginRequiresUserVerification() {
+	authenticator := s.register()
+	authenticator.UserVerified = false
+
+	_, err := s.login(authenticator)
+
+	require.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey)
+	assert.Contains(s.T(), err.Error(), "user not verified")
+}
+
+func (s *PasskeyServiceTestSuite) TestLoginRejectsUnknownPasskey() {
+	authenticator, err := webauthntest.New(testRPID, testOrigin)
+	require.NoError(s.T(), err)
+
+	_, err = s.login(authenticator)
+
+	require.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey)
+	assert.Contains(s.T(), err.Error(), "unknown passkey")
+}
+
+func (s *PasskeyServiceTestSuite) TestLoginRejectsOtherUserHandle() {
+	authenticator := s.register()
+	authenticator.UserHandle = []byte("someone else")
+
+	_, err := s.login(authenticator)
+
+	require.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey)
+	assert.Contains(s.T(), err.Error(), "user handle mismatch")
+}
+
+func (s *PasskeyServiceTestSuite) TestLoginWithoutSignCounter() {
+	authenticator := s.register()
+	authenticator.CountsSignatures = false
+
+	for range 2 {
+		_, err := s.login(authenticator)
+		require.NoError(s.T(), err)
+	}
+}
+
+func (s *PasskeyServiceTestSuite) TestLoginDetectsClonedPasskey() {
+	authenticator := s.register()
+	_, err := s.login(authenticator)
+	require.NoError(s.T(), err)
+
+	// A copy of the passkey signs with the counter the original had before
+	authenticator.SignCount = 0
+	s.mockEventRepo.EXPECT().SaveEvent(gomock.Any()).DoAndReturn(func(event *models.SecurityEvent) error {
+		assert.Equal(s.T(), s.user.ID, event.UserID)
+		assert.Equal(s.T(), string(constants.SecurityEventPasskeyCloned), event.Type)
+		return nil
+	})
+
+	_, err = s.login(authenticator)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrPasskeyCounter)
+}
+
+func (s *PasskeyServiceTestSuite) TestSecondFactor() {
+	authenticator := s.register()
+	// Checking presence suffices after the password
+	authenticator.UserVerified = false
+
+	options, err := s.passkeyService.BeginSecondFactor(s.user.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), [][]byte{authenticator.CredentialID}, options.AllowCredentials)
+	assert.Equal(s.T(), "discouraged", options.UserVerification)
+
+	assertion, err := authenticator.Assert(options.Challenge)
+	require.NoError(s.T(), err)
+
+	err = s.passkeyService.VerifySecondFactor(s.user.ID, toPasskeyAssertion(assertion))
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *PasskeyServiceTestSuite) TestSecondFactorWithoutPasskeys() {
+	_, err := s.passkeyService.BeginSecondFactor(s.user.ID)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrNoPasskeys)
+}
+
+func (s *PasskeyServiceTestSuite) TestHasPasskeys() {
+	has, err := s.passkeyService.HasPasskeys(s.user.ID)
+	require.NoError(s.T(), err)
+	assert.False(s.T(), has)
+
+	s.register()
+
+	has, err = s.passkeyService.HasPasskeys(s.user.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), has)
+}
+
+func (s *PasskeyServiceTestSuite) TestSecondFactorRejectsPasskeyOfAnotherUser() {
+	s.register()
+	other := s.register()
+	s.passkeys[string(other.CredentialID)].UserID = uuid.New()
+
+	options, err := s.passkeyService.BeginSecondFactor(s.user.ID)
+	require.NoError(s.T(), err)
+	assertion, err := other.Assert(options.Challenge)
+	require.NoError(s.T(), err)
+	assertion.UserHandle = nil
+
+	err = s.passkeyService.VerifySecondFactor(s.user.ID, toPasskeyAssertion(assertion))
+
+	require.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey)
+	assert.Contains(s.T(), err.Error(), "passkey of another user")
+}
+
+func (s *PasskeyServiceTestSuite) TestSecondFactorRejectsLoginChallenge() {
+	authenticator := s.register()
+
+	options, err := s.passkeyService.BeginLogin()
+	require.NoError(s.T(), err)
+	assertion, err := authenticator.Assert(options.Challenge)
+	require.NoError(s.T(), err)
+
+	err = s.passkeyService.VerifySecondFactor(s.user.ID, toPasskeyAssertion(assertion))
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey)
+}
+
+func (s *PasskeyServiceTestSuite) TestPasskeyName() {
+	assert.Equal(s.T(), "Passkey", passkeyName("  "))
+	assert.Equal(s.T(), "YubiKey", passkeyName(" YubiKey\n"))
+	assert.Len(s.T(), []rune(passkeyName(strings.Repeat("ключ", 100))), maxPasskeyNameLength)
+}
+
+func TestPasskeyServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(PasskeyServiceTestSuite))
+}
+
+// toPasskeyAttestation converts the software authenticator's response to the service's model.
+func toPasskeyAttestation(attestation *webauthntest.Attestation) *models.PasskeyAttestation {
+	return &models.PasskeyAttestation{
+		CredentialID:      attestation.CredentialID,
+		ClientDataJSON:    attestation.ClientDataJSON,
+		AttestationObject: attestation.AttestationObject,
+	}
+}
+
+// toPasskeyAssertion converts the software authenticator's response to the service's model.
+func toPasskeyAssertion(assertion *webauthntest.Assertion) *models.PasskeyAssertion {
+	return &models.PasskeyAssertion{
+		CredentialID:      assertion.CredentialID,
+		ClientDataJSON:    assertion.ClientDataJSON,
+		AuthenticatorData: assertion.AuthenticatorData,
+		Signature:         assertion.Signature,
+		UserHandle:        assertion.UserHandle,
+	}
+}
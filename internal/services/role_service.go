@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// IRoleRepository defines the interface for role persistence and assignment operations.
+type IRoleRepository interface {
+	CreateRole(role *models.Role) error
+	FindRole(name string) (*models.Role, error)
+	FindUserRoles(userID uuid.UUID) ([]*models.Role, error)
+	AssignRole(userID uuid.UUID, roleID uuid.UUID) error
+	UnassignRole(userID uuid.UUID, roleID uuid.UUID) error
+}
+
+// RoleService manages roles and their assignment to users.
+type RoleService struct {
+	roleRepo IRoleRepository
+}
+
+// NewRoleService creates a new role service instance.
+func NewRoleService(roleRepo IRoleRepository) *RoleService {
+	return &RoleService{
+		roleRepo: roleRepo,
+	}
+}
+
+// CreateRole creates a role granting the given permissions.
+// Returns autherrors.ErrRoleExists if a role with this name already exists, also if it was created concurrently.
+func (s *RoleService) CreateRole(name string, description string, permissions []string) (*models.Role, error) {
+
+	existing, err := s.roleRepo.FindRole(name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, autherrors.ErrRoleExists
+	}
+
+	role := models.Role{
+		Name:        name,
+		Description: description,
+		Permissions: permissions,
+	}
+	if err := s.roleRepo.CreateRole(&role); err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// AssignRole grants the named role to the user.
+// Returns autherrors.ErrRoleNotFound if the role doesn't exist.
+func (s *RoleService) AssignRole(userID uuid.UUID, roleName string) error {
+
+	role, err := s.findRole(roleName)
+	if err != nil {
+		return err
+	}
+
+	return s.roleRepo.AssignRole(userID, role.ID)
+}
+
+// UnassignRole takes the named role away from the user.
+// Returns autherrors.ErrRoleNotFound if the role doesn't exist and autherrors.ErrRoleNotAssigned if the user doesn't have it.
+func (s *RoleService) UnassignRole(userID uuid.UUID, roleName string) error {
+
+	role, err := s.findRole(roleName)
+	if err != nil {
+		return err
+	}
+
+	err = s.roleRepo.UnassignRole(userID, role.ID)
+	if errors.Is(err, autherrors.ErrRoleNotAssigned) {
+		return err
+	}
+	if err != nil {
+		return autherrors.ErrUpdateRoles(err)
+	}
+
+	return nil
+}
+
+// FindUserRoles returns the roles assigned to the user.
+func (s *RoleService) FindUserRoles(userID uuid.UUID) ([]*models.Role, error) {
+	return s.roleRepo.FindUserRoles(userID)
+}
+
+// LoadAuthorization fills in the user's role names and the union of their permissions,
+// which are embedded in the access tokens issued to the user.
+func (s *RoleService) LoadAuthorization(user *models.User) error {
+
+	roles, err := s.roleRepo.FindUserRoles(user.ID)
+	if err != nil {
+		return autherrors.ErrLoadRoles(err)
+	}
+
+	user.Roles = nil
+	user.Permissions = nil
+	for _, role := range roles {
+		user.Roles = append(user.Roles, role.Name)
+		for _, permission := range role.Permissions {
+			if !slices.Contains(user.Permissions, permission) {
+				user.Permissions = append(user.Permissions, permission)
+			}
+		}
+	}
+	slices.Sort(user.Permissions)
+
+	return nil
+}
+
+func (s *RoleService) findRole(name string) (*models.Role, error) {
+	role, err := s.roleRepo.FindRole(name)
+	if err != nil {
+		return nil, autherrors.ErrUpdateRoles(err)
+	}
+	if role == nil {
+		return nil, autherrors.ErrRoleNotFound
+	}
+	return role, nil
+}
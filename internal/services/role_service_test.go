@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+)
+
+type RoleServiceTestSuite struct {
+	suite.Suite
+	ctrl         *gomock.Controller
+	mockRoleRepo *mocks.MockIRoleRepository
+	roleService  *RoleService
+	testUserID   uuid.UUID
+	testRole     *models.Role
+}
+
+func (s *RoleServiceTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockRoleRepo = mocks.NewMockIRoleRepository(s.ctrl)
+	s.roleService = NewRoleService(s.mockRoleRepo)
+	s.testUserID = uuid.New()
+	s.testRole = &models.Role{
+		ID:          uuid.New(),
+		Name:        "admin",
+		Permissions: []string{"users:manage"},
+	}
+}
+
+func (s *RoleServiceTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *RoleServiceTestSuite) TestCreateRoleSuccess() {
+	s.mockRoleRepo.EXPECT().
+		FindRole("editor").
+		Return(nil, nil)
+
+	s.mockRoleRepo.EXPECT().
+		CreateRole(gomock.Any()).
+		Return(nil)
+
+	role, err := s.roleService.CreateRole("editor", "Edits plans", []string{"plans:write"})
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "editor", role.Name)
+	assert.Equal(s.T(), []string{"plans:write"}, role.Permissions)
+}
+
+func (s *RoleServiceTestSuite) TestCreateRoleExists() {
+	s.mockRoleRepo.EXPECT().
+		FindRole("admin").
+		Return(s.testRole, nil)
+
+	role, err := s.roleService.CreateRole("admin", "", nil)
+
+	assert.Nil(s.T(), role)
+	assert.ErrorIs(s.T(), err, autherrors.ErrRoleExists)
+}
+
+func (s *RoleServiceTestSuite) TestCreateRoleCreatedConcurrently() {
+	s.mockRoleRepo.EXPECT().
+		FindRole("editor").
+		Return(nil, nil)
+
+	s.mockRoleRepo.EXPECT().
+		CreateRole(gomock.Any()).
+		Return(autherrors.ErrRoleExists)
+
+	role, err := s.roleService.CreateRole("editor", "", nil)
+
+	assert.Nil(s.T(), role)
+	assert.ErrorIs(s.T(), err, autherrors.ErrRoleExists)
+}
+
+func (s *RoleServiceTestSuite) TestAssignRoleSuccess() {
+	s.mockRoleRepo.EXPECT().
+		FindRole("admin").
+		Return(s.testRole, nil)
+
+	s.mockRoleRepo.EXPECT().
+		AssignRole(s.testUserID, s.testRole.ID).
+		Return(nil)
+
+	err := s.roleService.AssignRole(s.testUserID, "admin")
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *RoleServiceTestSuite) TestAssignRoleNotFound() {
+	s.mockRoleRepo.EXPECT().
+		FindRole("missing").
+		Return(nil, nil)
+
+	err := s.roleService.AssignRole(s.testUserID, "missing")
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrRoleNotFound)
+}
+
+func (s *RoleServiceTestSuite) TestUnassignRoleNotAssigned() {
+	s.mockRoleRepo.EXPECT().
+		FindRole("admin").
+		Return(s.testRole, nil)
+
+	s.mockRoleRepo.EXPECT().
+		UnassignRole(s.testUserID, s.testRole.ID).
+		Return(autherrors.ErrRoleNotAssigned)
+
+	err := s.roleService.UnassignRole(s.testUserID, "admin")
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrRoleNotAssigned)
+}
+
+func (s *RoleServiceTestSuite) TestUnassignRoleRepositoryError() {
+	s.mockRoleRepo.EXPECT().
+		FindRole("admin").
+		Return(s.testRole, nil)
+
+	s.mockRoleRepo.EXPECT().
+		UnassignRole(s.testUserID, s.testRole.ID).
+		Return(errors.New("database error"))
+
+	err := s.roleService.UnassignRole(s.testUserID, "admin")
+
+	assert.ErrorContains(s.T(), err, "failed to update user roles")
+	assert.ErrorContains(s.T(), err, "database error")
+}
+
+func (s *RoleServiceTestSuite) TestLoadAuthorizationMergesPermissions() {
+	user := &models.User{ID: s.testUserID}
+
+	s.mockRoleRepo.EXPECT().
+		FindUserRoles(s.testUserID).
+		Return([]*models.Role{
+			{Name: "editor", Permissions: []string{"plans:write", "plans:read"}},
+			{Name: "reader", Permissions: []string{"plans:read"}},
+		}, nil)
+
+	err := s.roleService.LoadAuthorization(user)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"editor", "reader"}, user.Roles)
+	assert.Equal(s.T(), []string{"plans:read", "plans:write"}, user.Permissions)
+}
+
+func (s *RoleServiceTestSuite) TestLoadAuthorizationError() {
+	user := &models.User{ID: s.testUserID}
+
+	s.mockRoleRepo.EXPECT().
+		FindUserRoles(s.testUserID).
+		Return(nil, errors.New("database error"))
+
+	err := s.roleService.LoadAuthorization(user)
+
+	assert.ErrorContains(s.T(), err, "failed to load user roles")
+}
+
+func TestRoleServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(RoleServiceTestSuite))
+}
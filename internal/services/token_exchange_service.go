@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+// TokenExchangeService implements the OAuth 2.0 token exchange grant (RFC 8693) for services calling other
+// services on behalf of a user, such as the scheduling service sending notifications for a user.
+// Instead of forwarding the user's access token, the calling service exchanges it for a token meant only for
+// the service it calls, granting only the permissions the call needs and naming the calling service as actor.
+type TokenExchangeService struct {
+	clientService  IClientService
+	tokenValidator ITokenValidator
+	tokenService   ITokenService
+	audiences      []string
+}
+
+// NewTokenExchangeService creates a new token exchange service instance.
+// audiences lists the only services tokens can be exchanged for; if it is empty, every exchange is refused.
+func NewTokenExchangeService(clientService IClientService, tokenValidator ITokenValidator, tokenService ITokenService,
+	audiences []string) *TokenExchangeService {
+	return &TokenExchangeService{
+		clientService:  clientService,
+		tokenValidator: tokenValidator,
+		tokenService:   tokenService,
+		audiences:      audiences,
+	}
+}
+
+// ExchangeToken issues an access token for the requested audience to the user of the subject token,
+// with the authenticated client recorded as the actor (RFC 8693, section 2). Only confidential clients
+// can act for users. The subject token must be a valid access token; the requested scopes must be among
+// its permissions and default to all of them. If the subject token was itself issued by token exchange,
+// its actors are kept, nested under the client.
+func (s *TokenExchangeService) ExchangeToken(exchange *models.TokenExchange) (*models.IssuedTokens, error) {
+
+	client, err := s.clientService.IdentifyClient(exchange.ClientID, exchange.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	// Public clients can't prove their identity, so they can't be named as the actor
+	if !client.IsConfidential() {
+		return nil, autherrors.ErrUnauthorizedClient
+	}
+
+	if exchange.SubjectTokenType != constants.TokenTypeURIAccessToken {
+		return nil, autherrors.ErrTokenTypeNotSupported(exchange.SubjectTokenType)
+	}
+	if exchange.RequestedTokenType != "" && exchange.RequestedTokenType != constants.TokenTypeURIAccessToken {
+		return nil, autherrors.ErrTokenTypeNotSupported(exchange.RequestedTokenType)
+	}
+	if !slices.Contains(s.audiences, exchange.Audience) {
+		return nil, autherrors.ErrAudienceNotAllowed(exchange.Audience)
+	}
+
+	subject, err := s.tokenValidator.ValidateAccessToken(exchange.SubjectToken)
+	if err != nil {
+		return nil, invalidSubjectToken(err)
+	}
+
+	scopes := exchange.Scopes
+	if len(scopes) == 0 {
+		scopes = subject.Permissions
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(subject.Permissions, scope) {
+			return nil, autherrors.ErrScopeNotAllowed(scope)
+		}
+	}
+
+	actor := &models.Actor{Subject: client.ID, Actor: subject.Actor}
+	token, err := s.tokenService.CreateExchangedToken(subject, exchange.Audience, scopes, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.IssuedTokens{AccessToken: token, Scopes: scopes, IssuedTokenType: constants.TokenTypeURIAccessToken}, nil
+}
+
+// invalidSubjectToken reports validation failures of the subject token as such
+// and passes storage and other unexpected errors through.
+func invalidSubjectToken(err error) error {
+	switch {
+	case errors.Is(err, autherrors.ErrTokenParseFailed),
+		errors.Is(err, autherrors.ErrTokenExpired),
+		errors.Is(err, autherrors.ErrTokenType),
+		errors.Is(err, autherrors.ErrTokenInvalid),
+		errors.Is(err, autherrors.ErrTokenRevoked),
+		errors.Is(err, autherrors.ErrUserNotExist):
+		return autherrors.ErrSubjectToken(err)
+	default:
+		return err
+	}
+}
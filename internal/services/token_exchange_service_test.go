@@ -0,0 +1,200 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/services/mocks"
+)
+
+type TokenExchangeServiceTestSuite struct {
+	suite.Suite
+	ctrl               *gomock.Controller
+	mockClientService  *mocks.MockIClientService
+	mockTokenValidator *mocks.MockITokenValidator
+	mockTokenService   *mocks.MockITokenService
+	exchangeService    *TokenExchangeService
+	client             *models.Client
+	subject            *models.ParsedToken
+}
+
+func (s *TokenExchangeServiceTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockClientService = mocks.NewMockIClientService(s.ctrl)
+	s.mockTokenValidator = mocks.NewMockITokenValidator(s.ctrl)
+	s.mockTokenService = mocks.NewMockITokenService(s.ctrl)
+	s.exchangeService = NewTokenExchangeService(s.mockClientService, s.mockTokenValidator, s.mockTokenService,
+		[]string{"planner-api", "notifications"})
+
+	s.client = &models.Client{ID: "scheduler", Name: "Scheduler", SecretHash: "secret-hash"}
+	s.subject = &models.ParsedToken{
+		UserID:      uuid.New(),
+		Type:        string(constants.TokenTypeAccess),
+		Permissions: []string{"plans:read", "plans:write"},
+		ExpiresAt:   time.Now().Add(10 * time.Minute),
+	}
+}
+
+func (s *TokenExchangeServiceTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+// exchange returns a valid token exchange request of the suite's client.
+func (s *TokenExchangeServiceTestSuite) exchange() *models.TokenExchange {
+	return &models.TokenExchange{
+		ClientID:         s.client.ID,
+		ClientSecret:     "secret",
+		SubjectToken:     "subject-token",
+		SubjectTokenType: constants.TokenTypeURIAccessToken,
+		Audience:         "notifications",
+		Scopes:           []string{"plans:read"},
+	}
+}
+
+func (s *TokenExchangeServiceTestSuite) TestExchangeToken() {
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "secret").Return(s.client, nil)
+	s.mockTokenValidator.EXPECT().ValidateAccessToken("subject-token").Return(s.subject, nil)
+	token := &models.Token{Value: "exchanged-token", UserID: s.subject.UserID}
+	s.mockTokenService.EXPECT().
+		CreateExchangedToken(s.subject, "notifications", []string{"plans:read"}, &models.Actor{Subject: s.client.ID}).
+		Return(token, nil)
+
+	tokens, err := s.exchangeService.ExchangeToken(s.exchange())
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), token, tokens.AccessToken)
+	assert.Nil(s.T(), tokens.RefreshToken)
+	assert.Equal(s.T(), []string{"plans:read"}, tokens.Scopes)
+	assert.Equal(s.T(), constants.TokenTypeURIAccessToken, tokens.IssuedTokenType)
+}
+
+func (s *TokenExchangeServiceTestSuite) TestExchangeTokenKeepsPreviousActors() {
+	s.subject.Actor = &models.Actor{Subject: "planner-api"}
+	exchange := s.exchange()
+	exchange.Scopes = nil
+
+	s.mockClientService.EXPECT().IdentifyClient(s.client.ID, "secret").Return(s.client, nil)
+	s.mockTokenValidator.EXPECT().ValidateAccessToken("subject-token").Return(s.subject, nil)
+	s.mockTokenService.EXPECT().
+		CreateExchangedToken(s.subject, "notifications", s.subject.Permissions,
+			&models.Actor{Subject: s.client.ID, Actor: &models.Actor{Subject: "planner-api"}}).
+		Return(&models.Token{Value: "exchanged-token"}, nil)
+
+	tokens, err := s.exchangeService.ExchangeToken(exchange)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.subject.Permissions, tokens.Scopes, "all permissions of the subject token are granted by default")
+}
+
+func (s *TokenExchangeServiceTestSuite) TestExchangeTokenErrors() {
+	storageErr := errors.New("connection refused")
+
+	testCases := []struct {
+		name         string
+		modify       func(exchange *models.TokenExchange)
+		client       *models.Client
+		validatorErr error
+		validate     bool
+		err          error
+	}{
+		{
+			name:   "public client",
+			client: &models.Client{ID: "scheduler"},
+			err:    autherrors.ErrUnauthorizedClient,
+		},
+		{
+			name: "unsupported subject token type",
+			modify: func(exchange *models.TokenExchange) {
+				exchange.SubjectTokenType = "urn:ietf:params:oauth:token-type:id_token"
+			},
+			err: autherrors.ErrUnsupportedTokenType,
+		},
+		{
+			name: "unsupported requested token type",
+			modify: func(exchange *models.TokenExchange) {
+				exchange.RequestedTokenType = "urn:ietf:params:oauth:token-type:refresh_token"
+			},
+			err: autherrors.ErrUnsupportedTokenType,
+		},
+		{
+			name:   "audience not allowed",
+			modify: func(exchange *models.TokenExchange) { exchange.Audience = "billing" },
+			err:    autherrors.ErrInvalidTarget,
+		},
+		{
+			name:         "expired subject token",
+			validate:     true,
+			validatorErr: autherrors.ErrTokenExpired,
+			err:          autherrors.ErrInvalidSubjectToken,
+		},
+		{
+			name:         "revoked subject token",
+			validate:     true,
+			validatorErr: autherrors.ErrTokenRevoked,
+			err:          autherrors.ErrInvalidSubjectToken,
+		},
+		{
+			name:         "storage error",
+			validate:     true,
+			validatorErr: storageErr,
+			err:          storageErr,
+		},
+		{
+			name:     "scope beyond the subject token",
+			modify:   func(exchange *models.TokenExchange) { exchange.Scopes = []string{"plans:read", "users:manage"} },
+			validate: true,
+			err:      autherrors.ErrInvalidScope,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			exchange := s.exchange()
+			if tc.modify != nil {
+				tc.modify(exchange)
+			}
+			client := s.client
+			if tc.client != nil {
+				client = tc.client
+			}
+
+			s.mockClientService.EXPECT().IdentifyClient(gomock.Any(), gomock.Any()).Return(client, nil)
+			if tc.validate {
+				subject := s.subject
+				if tc.validatorErr != nil {
+					subject = nil
+				}
+				s.mockTokenValidator.EXPECT().ValidateAccessToken(exchange.SubjectToken).Return(subject, tc.validatorErr)
+			}
+
+			tokens, err := s.exchangeService.ExchangeToken(exchange)
+
+			assert.ErrorIs(s.T(), err, tc.err)
+			assert.Nil(s.T(), tokens)
+		})
+	}
+}
+
+func (s *TokenExchangeServiceTestSuite) TestExchangeTokenWithoutAudiences() {
+	exchangeService := NewTokenExchangeService(s.mockClientService, s.mockTokenValidator, s.mockTokenService, nil)
+	s.mockClientService.EXPECT().IdentifyClient(gomock.Any(), gomock.Any()).Return(s.client, nil)
+
+	tokens, err := exchangeService.ExchangeToken(s.exchange())
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidTarget, "no audience is allowed by default")
+	assert.Nil(s.T(), tokens)
+}
+
+func TestTokenExchangeServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(TokenExchangeServiceTestSuite))
+}
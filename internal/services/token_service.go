@@ -1,6 +1,11 @@
 package services
 
 import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
 	"github.com/breakfront-planner/auth-service/internal/autherrors"
 	"github.com/breakfront-planner/auth-service/internal/constants"
 	"github.com/breakfront-planner/auth-service/internal/jwt"
@@ -12,6 +17,17 @@ type ITokenRepository interface {
 	SaveToken(token *models.Token) error
 	RevokeToken(token *models.Token) error
 	FindToken(token *models.Token) error
+	RotateToken(oldToken, newToken *models.Token) error
+	SaveTokenForCode(token *models.Token, codeHash string) error
+	RevokeTokenFamily(familyID uuid.UUID) error
+	RevokeSession(userID uuid.UUID, sessionID uuid.UUID) error
+	RevokeAllUserTokens(userID uuid.UUID) error
+	FindActiveTokens(userID uuid.UUID) ([]*models.Token, error)
+}
+
+// ISecurityEventRepository defines the interface for recording security events.
+type ISecurityEventRepository interface {
+	SaveEvent(event *models.SecurityEvent) error
 }
 
 // IHashService defines the interface for hashing operations.
@@ -19,41 +35,66 @@ type IHashService interface {
 	HashToken(token string) string
 	HashPassword(password string) (string, error)
 	ComparePasswords(passHash, input string) error
+	NeedsRehash(passHash string) bool
 }
 
 // TokenService manages JWT token lifecycle including creation, validation, and revocation.
 type TokenService struct {
 	tokenRepo   ITokenRepository
+	eventRepo   ISecurityEventRepository
 	hashService IHashService
 	jwtManager  *jwt.Manager
 }
 
 // NewTokenService creates a new token service instance.
-func NewTokenService(tokenRepo ITokenRepository, hashService IHashService, jwtManager *jwt.Manager) *TokenService {
+func NewTokenService(tokenRepo ITokenRepository, eventRepo ISecurityEventRepository, hashService IHashService, jwtManager *jwt.Manager) *TokenService {
 	return &TokenService{
 		tokenRepo:   tokenRepo,
+		eventRepo:   eventRepo,
 		hashService: hashService,
 		jwtManager:  jwtManager,
 	}
 }
 
 // CreateNewTokenPair generates a new access and refresh token pair for the user.
-// The refresh token is hashed and persisted in the repository.
-func (s *TokenService) CreateNewTokenPair(user *models.User) (accessToken, refreshToken *models.Token, err error) {
+// The refresh token starts a new session (token family) described by the given metadata
+// and is hashed and persisted in the repository.
+func (s *TokenService) CreateNewTokenPair(user *models.User, session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error) {
+	return s.CreateTokenPairForClient(user, "", nil, session)
+}
+
+// CreateTokenPairForClient generates a new token pair like CreateNewTokenPair for a user signed in to an OAuth client.
+// Both tokens name the client and grant only the scopes granted to it, not the user's roles and permissions,
+// and the refresh token stays bound to the client when it is rotated.
+func (s *TokenService) CreateTokenPairForClient(user *models.User, clientID string, scopes []string,
+	session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error) {
 
-	accessToken, err = s.jwtManager.GenerateToken(user, constants.TokenTypeAccess)
+	accessToken, refreshToken, err = s.startSession(user, clientID, scopes, session)
 	if err != nil {
-		return nil, nil, autherrors.ErrCreateToken(err)
+		return nil, nil, err
 	}
 
-	refreshToken, err = s.jwtManager.GenerateToken(user, constants.TokenTypeRefresh)
+	err = s.tokenRepo.SaveToken(refreshToken)
 	if err != nil {
-		return nil, nil, autherrors.ErrCreateToken(err)
+		return nil, nil, autherrors.ErrSaveToken(err)
 	}
 
-	refreshToken.HashedValue = s.hashService.HashToken(refreshToken.Value)
+	return accessToken, refreshToken, nil
 
-	err = s.tokenRepo.SaveToken(refreshToken)
+}
+
+// CreateTokenPairForCode generates a new token pair like CreateTokenPairForClient for the client the authorization
+// code was issued to. The session is recorded on the code together with the refresh token, so that it can be revoked
+// if the code is presented again.
+func (s *TokenService) CreateTokenPairForCode(user *models.User, code *models.AuthorizationCode, scopes []string,
+	session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error) {
+
+	accessToken, refreshToken, err = s.startSession(user, code.ClientID, scopes, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = s.tokenRepo.SaveTokenForCode(refreshToken, code.CodeHash)
 	if err != nil {
 		return nil, nil, autherrors.ErrSaveToken(err)
 	}
@@ -62,31 +103,149 @@ func (s *TokenService) CreateNewTokenPair(user *models.User) (accessToken, refre
 
 }
 
-// Refresh validates the provided refresh token and generates a new token pair.
-// The old refresh token is revoked after successful validation.
-func (s *TokenService) Refresh(refreshToken *models.Token, user *models.User) (newAccessToken, newRefreshToken *models.Token, err error) {
+// startSession generates a token pair whose refresh token starts a new session (token family), without persisting it.
+func (s *TokenService) startSession(user *models.User, clientID string, scopes []string,
+	session models.SessionMetadata) (accessToken, refreshToken *models.Token, err error) {
 
-	refreshToken.HashedValue = s.hashService.HashToken(refreshToken.Value)
+	accessToken, refreshToken, err = s.generateTokenPair(user, clientID, scopes)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	err = s.tokenRepo.FindToken(refreshToken)
+	refreshToken.FamilyID = uuid.New()
+	refreshToken.Session = session
+
+	return accessToken, refreshToken, nil
+
+}
+
+// CreateClientToken generates a client token granting the scopes to a service acting on its own behalf.
+// Client tokens are not persisted: there is no refresh token and the client requests a new token when it expires.
+func (s *TokenService) CreateClientToken(client *models.Client, scopes []string) (*models.Token, error) {
+
+	token, err := s.jwtManager.GenerateClientToken(client.ID, scopes)
 	if err != nil {
-		return nil, nil, autherrors.ErrRefreshToken(err)
+		return nil, autherrors.ErrCreateToken(err)
+	}
+
+	return token, nil
+
+}
+
+// CreateIDToken generates an OpenID Connect ID token for the user signing in to the client.
+func (s *TokenService) CreateIDToken(user *models.User, clientID string, nonce string, authTime time.Time) (*models.Token, error) {
+
+	token, err := s.jwtManager.GenerateIDToken(user, clientID, nonce, authTime)
+	if err != nil {
+		return nil, autherrors.ErrCreateToken(err)
+	}
+
+	return token, nil
+
+}
+
+// CreateMFAChallenge generates the challenge token of a password login that still needs the user's second factor.
+// It is not persisted; it expires quickly and is put on the denylist once it has been exchanged for tokens.
+func (s *TokenService) CreateMFAChallenge(user *models.User) (*models.Token, error) {
+
+	token, err := s.jwtManager.GenerateToken(user, constants.TokenTypeMFAChallenge)
+	if err != nil {
+		return nil, autherrors.ErrCreateToken(err)
+	}
+
+	return token, nil
+
+}
+
+// CreateExchangedToken creates the down-scoped access token issued for the subject token by token exchange.
+// Like access tokens of a token pair it is not persisted.
+func (s *TokenService) CreateExchangedToken(subject *models.ParsedToken, audience string, scopes []string,
+	actor *models.Actor) (*models.Token, error) {
+
+	token, err := s.jwtManager.GenerateExchangedToken(subject, audience, scopes, actor)
+	if err != nil {
+		return nil, autherrors.ErrCreateToken(err)
+	}
+
+	return token, nil
+
+}
+
+// generateTokenPair signs a new token pair issued to the client, if any, with the scopes granted to it,
+// and hashes the refresh token without persisting it.
+func (s *TokenService) generateTokenPair(user *models.User, clientID string,
+	scopes []string) (accessToken, refreshToken *models.Token, err error) {
+
+	accessToken, err = s.jwtManager.GenerateTokenForClient(user, constants.TokenTypeAccess, clientID, scopes)
+	if err != nil {
+		return nil, nil, autherrors.ErrCreateToken(err)
 	}
 
-	newAccessToken, newRefreshToken, err = s.CreateNewTokenPair(user)
+	refreshToken, err = s.jwtManager.GenerateTokenForClient(user, constants.TokenTypeRefresh, clientID, scopes)
 	if err != nil {
 		return nil, nil, autherrors.ErrCreateToken(err)
 	}
 
-	err = s.tokenRepo.RevokeToken(refreshToken)
+	refreshToken.HashedValue = s.hashService.HashToken(refreshToken.Value)
+
+	return accessToken, refreshToken, nil
+
+}
+
+// Refresh validates the provided refresh token and generates a new token pair in the same token family.
+// The new tokens are issued to the same client as the old one (refreshToken.ClientID) with its scopes
+// (refreshToken.Scopes).
+// The session metadata is updated with the given values; empty fields keep their previous value.
+// Revoking the old refresh token and saving the new one happen atomically in the repository,
+// so only one of several concurrent refreshes with the same token succeeds.
+// Presenting an already revoked refresh token is treated as reuse: the whole family is revoked
+// and a security event is recorded.
+func (s *TokenService) Refresh(refreshToken *models.Token, user *models.User, session models.SessionMetadata) (newAccessToken, newRefreshToken *models.Token, err error) {
+
+	refreshToken.HashedValue = s.hashService.HashToken(refreshToken.Value)
+
+	newAccessToken, newRefreshToken, err = s.generateTokenPair(user, refreshToken.ClientID, refreshToken.Scopes)
 	if err != nil {
-		return newAccessToken, newRefreshToken, autherrors.ErrRevokeToken(err)
+		return nil, nil, err
+	}
+	newRefreshToken.Session = session
+
+	err = s.tokenRepo.RotateToken(refreshToken, newRefreshToken)
+	if errors.Is(err, autherrors.ErrTokenRevoked) {
+		return nil, nil, autherrors.ErrRefreshToken(s.handleTokenReuse(refreshToken, err))
+	}
+	if err != nil {
+		return nil, nil, autherrors.ErrRefreshToken(err)
 	}
 
 	return newAccessToken, newRefreshToken, nil
 
 }
 
+// handleTokenReuse revokes the family of a replayed refresh token and records the incident.
+// It returns the error to report to the caller, which always wraps autherrors.ErrTokenReused.
+func (s *TokenService) handleTokenReuse(refreshToken *models.Token, findErr error) error {
+
+	err := s.tokenRepo.RevokeTokenFamily(refreshToken.FamilyID)
+	if err != nil {
+		return autherrors.ErrTokenReuse(autherrors.ErrRevokeToken(err))
+	}
+
+	familyID := refreshToken.FamilyID
+	event := models.SecurityEvent{
+		UserID:        refreshToken.UserID,
+		Type:          string(constants.SecurityEventRefreshTokenReuse),
+		TokenFamilyID: &familyID,
+	}
+
+	err = s.eventRepo.SaveEvent(&event)
+	if err != nil {
+		return autherrors.ErrTokenReuse(err)
+	}
+
+	return autherrors.ErrTokenReuse(findErr)
+}
+
 // RevokeToken invalidates the specified token by marking it as revoked in the repository.
 func (s *TokenService) RevokeToken(token *models.Token) error {
 
@@ -103,3 +262,59 @@ func (s *TokenService) RevokeToken(token *models.Token) error {
 
 	return nil
 }
+
+// CheckRefreshToken verifies that the refresh token is stored and not revoked, without changing its state.
+// An unknown or revoked token returns an error wrapping autherrors.ErrTokenInvalid.
+func (s *TokenService) CheckRefreshToken(token *models.Token) error {
+
+	token.HashedValue = s.hashService.HashToken(token.Value)
+
+	return s.tokenRepo.FindToken(token)
+}
+
+// ListSessions returns the user's active sessions, most recently used first.
+func (s *TokenService) ListSessions(userID uuid.UUID) ([]*models.Session, error) {
+
+	tokens, err := s.tokenRepo.FindActiveTokens(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*models.Session, 0, len(tokens))
+	for _, token := range tokens {
+		sessions = append(sessions, &models.Session{
+			ID:              token.FamilyID,
+			SessionMetadata: token.Session,
+			CreatedAt:       token.SessionStartedAt,
+			LastUsedAt:      token.CreatedAt,
+			ExpiresAt:       token.ExpiresAt,
+		})
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession signs the user out of one session by revoking its refresh tokens.
+func (s *TokenService) RevokeSession(userID uuid.UUID, sessionID uuid.UUID) error {
+
+	err := s.tokenRepo.RevokeSession(userID, sessionID)
+	if errors.Is(err, autherrors.ErrSessionNotFound) {
+		return err
+	}
+	if err != nil {
+		return autherrors.ErrRevokeToken(err)
+	}
+
+	return nil
+}
+
+// RevokeAllTokens revokes every refresh token of the user, ending all of their sessions.
+func (s *TokenService) RevokeAllTokens(userID uuid.UUID) error {
+
+	err := s.tokenRepo.RevokeAllUserTokens(userID)
+	if err != nil {
+		return autherrors.ErrRevokeToken(err)
+	}
+
+	return nil
+}
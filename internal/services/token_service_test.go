@@ -13,6 +13,8 @@ import (
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
 
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
 	"github.com/breakfront-planner/auth-service/internal/jwt"
 	"github.com/breakfront-planner/auth-service/internal/models"
 	"github.com/breakfront-planner/auth-service/internal/services/mocks"
@@ -22,6 +24,7 @@ type TokenServiceTestSuite struct {
 	suite.Suite
 	ctrl            *gomock.Controller
 	mockTokenRepo   *mocks.MockITokenRepository
+	mockEventRepo   *mocks.MockISecurityEventRepository
 	mockHashService *mocks.MockIHashService
 	jwtManager      *jwt.Manager
 	tokenService    *TokenService
@@ -79,8 +82,9 @@ func (s *TokenServiceTestSuite) SetupSuite() {
 func (s *TokenServiceTestSuite) SetupTest() {
 	s.ctrl = gomock.NewController(s.T())
 	s.mockTokenRepo = mocks.NewMockITokenRepository(s.ctrl)
+	s.mockEventRepo = mocks.NewMockISecurityEventRepository(s.ctrl)
 	s.mockHashService = mocks.NewMockIHashService(s.ctrl)
-	s.tokenService = NewTokenService(s.mockTokenRepo, s.mockHashService, s.jwtManager)
+	s.tokenService = NewTokenService(s.mockTokenRepo, s.mockEventRepo, s.mockHashService, s.jwtManager)
 }
 
 func (s *TokenServiceTestSuite) TearDownTest() {
@@ -96,7 +100,7 @@ func (s *TokenServiceTestSuite) TestCreateNewTokenPairSuccess() {
 		SaveToken(gomock.Any()).
 		Return(nil)
 
-	accessToken, refreshToken, err := s.tokenService.CreateNewTokenPair(s.testUser)
+	accessToken, refreshToken, err := s.tokenService.CreateNewTokenPair(s.testUser, models.SessionMetadata{})
 
 	assert.NoError(s.T(), err)
 	assert.NotNil(s.T(), accessToken)
@@ -108,6 +112,43 @@ func (s *TokenServiceTestSuite) TestCreateNewTokenPairSuccess() {
 	assert.Equal(s.T(), s.testHashedValue, refreshToken.HashedValue)
 }
 
+func (s *TokenServiceTestSuite) TestCreateMFAChallenge() {
+	challenge, err := s.tokenService.CreateMFAChallenge(s.testUser)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.testUser.ID, challenge.UserID)
+
+	parsedToken, err := s.jwtManager.ParseToken(challenge.Value)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), string(constants.TokenTypeMFAChallenge), parsedToken.Type)
+}
+
+func (s *TokenServiceTestSuite) TestCreateNewTokenPairStartsNewFamily() {
+	var families []uuid.UUID
+
+	s.mockHashService.EXPECT().
+		HashToken(gomock.Any()).
+		Return(s.testHashedValue).
+		Times(2)
+
+	s.mockTokenRepo.EXPECT().
+		SaveToken(gomock.Any()).
+		DoAndReturn(func(token *models.Token) error {
+			families = append(families, token.FamilyID)
+			return nil
+		}).
+		Times(2)
+
+	_, _, err := s.tokenService.CreateNewTokenPair(s.testUser, models.SessionMetadata{})
+	require.NoError(s.T(), err)
+	_, _, err = s.tokenService.CreateNewTokenPair(s.testUser, models.SessionMetadata{})
+	require.NoError(s.T(), err)
+
+	require.Len(s.T(), families, 2)
+	assert.NotEqual(s.T(), uuid.Nil, families[0])
+	assert.NotEqual(s.T(), families[0], families[1])
+}
+
 func (s *TokenServiceTestSuite) TestCreateNewTokenPairSaveTokenError() {
 	saveError := errors.New("database error")
 
@@ -119,7 +160,7 @@ func (s *TokenServiceTestSuite) TestCreateNewTokenPairSaveTokenError() {
 		SaveToken(gomock.Any()).
 		Return(saveError)
 
-	accessToken, refreshToken, err := s.tokenService.CreateNewTokenPair(s.testUser)
+	accessToken, refreshToken, err := s.tokenService.CreateNewTokenPair(s.testUser, models.SessionMetadata{})
 
 	assert.Error(s.T(), err)
 	assert.Nil(s.T(), accessToken)
@@ -127,42 +168,51 @@ func (s *TokenServiceTestSuite) TestCreateNewTokenPairSaveTokenError() {
 	assert.ErrorContains(s.T(), err, "failed to save token")
 }
 
-func (s *TokenServiceTestSuite) TestRefreshSuccess() {
-	oldRefreshToken := &models.Token{
-		Value:     s.testTokenValue,
-		UserID:    s.testUser.ID,
-		ExpiresAt: time.Now().UTC().Add(s.refreshDuration),
-	}
+func (s *TokenServiceTestSuite) TestCreateTokenPairForCodeRecordsFamilyOnCode() {
+	code := &models.AuthorizationCode{CodeHash: "code-hash", ClientID: "planner-spa", UserID: s.testUser.ID}
 
 	s.mockHashService.EXPECT().
 		HashToken(gomock.Any()).
-		Return(s.testHashedValue).
-		Times(1)
-
+		Return(s.testHashedValue)
 	s.mockTokenRepo.EXPECT().
-		FindToken(gomock.Any()).
-		DoAndReturn(func(token *models.Token) error {
-			assert.Equal(s.T(), s.testHashedValue, token.HashedValue)
+		SaveTokenForCode(gomock.Any(), code.CodeHash).
+		DoAndReturn(func(token *models.Token, _ string) error {
+			assert.NotEqual(s.T(), uuid.Nil, token.FamilyID)
 			return nil
 		})
 
+	accessToken, refreshToken, err := s.tokenService.CreateTokenPairForCode(s.testUser, code, []string{"openid"},
+		models.SessionMetadata{})
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), code.ClientID, accessToken.ClientID)
+	assert.Equal(s.T(), code.ClientID, refreshToken.ClientID)
+}
+
+func (s *TokenServiceTestSuite) TestRefreshSuccess() {
+	oldRefreshToken := &models.Token{
+		Value:     s.testTokenValue,
+		UserID:    s.testUser.ID,
+		ExpiresAt: time.Now().UTC().Add(s.refreshDuration),
+	}
+
 	s.mockHashService.EXPECT().
 		HashToken(gomock.Any()).
 		Return(s.testHashedValue).
-		Times(1)
+		Times(2)
 
+	familyID := uuid.New()
 	s.mockTokenRepo.EXPECT().
-		SaveToken(gomock.Any()).
-		Return(nil)
-
-	s.mockTokenRepo.EXPECT().
-		RevokeToken(gomock.Any()).
-		DoAndReturn(func(token *models.Token) error {
-			assert.Equal(s.T(), s.testHashedValue, token.HashedValue)
+		RotateToken(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(oldToken, newToken *models.Token) error {
+			assert.Equal(s.T(), s.testHashedValue, oldToken.HashedValue)
+			assert.NotEqual(s.T(), oldToken.Value, newToken.Value)
+			oldToken.FamilyID = familyID
+			newToken.FamilyID = familyID
 			return nil
 		})
 
-	newAccessToken, newRefreshToken, err := s.tokenService.Refresh(oldRefreshToken, s.testUser)
+	newAccessToken, newRefreshToken, err := s.tokenService.Refresh(oldRefreshToken, s.testUser, models.SessionMetadata{})
 
 	assert.NoError(s.T(), err)
 	assert.NotNil(s.T(), newAccessToken)
@@ -170,6 +220,7 @@ func (s *TokenServiceTestSuite) TestRefreshSuccess() {
 	assert.NotEmpty(s.T(), newAccessToken.Value)
 	assert.NotEmpty(s.T(), newRefreshToken.Value)
 	assert.NotEqual(s.T(), oldRefreshToken.Value, newRefreshToken.Value)
+	assert.Equal(s.T(), familyID, newRefreshToken.FamilyID, "rotated token must stay in the same family")
 }
 
 func (s *TokenServiceTestSuite) TestRefreshInvalidToken() {
@@ -183,13 +234,14 @@ func (s *TokenServiceTestSuite) TestRefreshInvalidToken() {
 
 	s.mockHashService.EXPECT().
 		HashToken(gomock.Any()).
-		Return(s.testHashedValue)
+		Return(s.testHashedValue).
+		Times(2)
 
 	s.mockTokenRepo.EXPECT().
-		FindToken(gomock.Any()).
+		RotateToken(gomock.Any(), gomock.Any()).
 		Return(checkError)
 
-	newAccessToken, newRefreshToken, err := s.tokenService.Refresh(oldRefreshToken, s.testUser)
+	newAccessToken, newRefreshToken, err := s.tokenService.Refresh(oldRefreshToken, s.testUser, models.SessionMetadata{})
 
 	assert.Error(s.T(), err)
 	assert.Nil(s.T(), newAccessToken)
@@ -197,78 +249,121 @@ func (s *TokenServiceTestSuite) TestRefreshInvalidToken() {
 	assert.ErrorContains(s.T(), err, "failed to refresh token")
 }
 
-func (s *TokenServiceTestSuite) TestRefreshRevokeError() {
+func (s *TokenServiceTestSuite) TestRefreshRotateError() {
 	oldRefreshToken := &models.Token{
 		Value:     s.testTokenValue,
 		UserID:    s.testUser.ID,
 		ExpiresAt: time.Now().UTC().Add(s.refreshDuration),
 	}
 
-	revokeError := errors.New("revoke failed")
+	rotateError := errors.New("transaction failed")
 
 	s.mockHashService.EXPECT().
 		HashToken(gomock.Any()).
 		Return(s.testHashedValue).
-		Times(1)
+		Times(2)
 
 	s.mockTokenRepo.EXPECT().
-		FindToken(gomock.Any()).
-		Return(nil)
+		RotateToken(gomock.Any(), gomock.Any()).
+		Return(rotateError)
 
-	s.mockHashService.EXPECT().
-		HashToken(gomock.Any()).
-		Return(s.testHashedValue).
-		Times(1)
+	newAccessToken, newRefreshToken, err := s.tokenService.Refresh(oldRefreshToken, s.testUser, models.SessionMetadata{})
 
-	s.mockTokenRepo.EXPECT().
-		SaveToken(gomock.Any()).
-		Return(nil)
+	assert.Error(s.T(), err)
+	assert.Nil(s.T(), newAccessToken, "no tokens may be handed out when the rotation was rolled back")
+	assert.Nil(s.T(), newRefreshToken)
+	assert.ErrorIs(s.T(), err, rotateError)
+}
 
-	s.mockTokenRepo.EXPECT().
-		RevokeToken(gomock.Any()).
-		Return(revokeError)
+func (s *TokenServiceTestSuite) TestRefreshCreateTokenPairError() {
+	oldRefreshToken := &models.Token{
+		Value:     s.testTokenValue,
+		UserID:    s.testUser.ID,
+		ExpiresAt: time.Now().UTC().Add(s.refreshDuration),
+	}
 
-	newAccessToken, newRefreshToken, err := s.tokenService.Refresh(oldRefreshToken, s.testUser)
+	emptyKeySet, err := jwt.NewKeySet(nil)
+	require.NoError(s.T(), err)
+	s.tokenService = NewTokenService(s.mockTokenRepo, s.mockEventRepo, s.mockHashService,
+		jwt.NewManagerWithKeySet(emptyKeySet, s.accessDuration, s.refreshDuration))
+
+	s.mockHashService.EXPECT().
+		HashToken(gomock.Any()).
+		Return(s.testHashedValue)
+
+	newAccessToken, newRefreshToken, err := s.tokenService.Refresh(oldRefreshToken, s.testUser, models.SessionMetadata{})
 
 	assert.Error(s.T(), err)
-	assert.NotNil(s.T(), newAccessToken)
-	assert.NotNil(s.T(), newRefreshToken)
-	assert.ErrorContains(s.T(), err, "failed to revoke token")
+	assert.Nil(s.T(), newAccessToken)
+	assert.Nil(s.T(), newRefreshToken)
+	assert.ErrorContains(s.T(), err, "failed to create token")
 }
 
-func (s *TokenServiceTestSuite) TestRefreshCreateTokenPairError() {
+func (s *TokenServiceTestSuite) TestRefreshReusedTokenRevokesFamily() {
 	oldRefreshToken := &models.Token{
 		Value:     s.testTokenValue,
 		UserID:    s.testUser.ID,
 		ExpiresAt: time.Now().UTC().Add(s.refreshDuration),
 	}
-
-	saveError := errors.New("save failed")
+	familyID := uuid.New()
 
 	s.mockHashService.EXPECT().
 		HashToken(gomock.Any()).
 		Return(s.testHashedValue).
-		Times(1)
+		Times(2)
 
 	s.mockTokenRepo.EXPECT().
-		FindToken(gomock.Any()).
+		RotateToken(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(oldToken, newToken *models.Token) error {
+			oldToken.FamilyID = familyID
+			return autherrors.ErrInvalidToken(autherrors.ErrTokenRevoked)
+		})
+
+	s.mockTokenRepo.EXPECT().
+		RevokeTokenFamily(familyID).
 		Return(nil)
 
+	s.mockEventRepo.EXPECT().
+		SaveEvent(gomock.Any()).
+		DoAndReturn(func(event *models.SecurityEvent) error {
+			assert.Equal(s.T(), s.testUser.ID, event.UserID)
+			assert.Equal(s.T(), string(constants.SecurityEventRefreshTokenReuse), event.Type)
+			require.NotNil(s.T(), event.TokenFamilyID)
+			assert.Equal(s.T(), familyID, *event.TokenFamilyID)
+			return nil
+		})
+
+	newAccessToken, newRefreshToken, err := s.tokenService.Refresh(oldRefreshToken, s.testUser, models.SessionMetadata{})
+
+	assert.Nil(s.T(), newAccessToken)
+	assert.Nil(s.T(), newRefreshToken)
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenReused)
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenInvalid)
+}
+
+func (s *TokenServiceTestSuite) TestRefreshReusedTokenRevokeFamilyError() {
+	oldRefreshToken := &models.Token{
+		Value:  s.testTokenValue,
+		UserID: s.testUser.ID,
+	}
+
 	s.mockHashService.EXPECT().
 		HashToken(gomock.Any()).
 		Return(s.testHashedValue).
-		Times(1)
+		Times(2)
 
 	s.mockTokenRepo.EXPECT().
-		SaveToken(gomock.Any()).
-		Return(saveError)
+		RotateToken(gomock.Any(), gomock.Any()).
+		Return(autherrors.ErrInvalidToken(autherrors.ErrTokenRevoked))
 
-	newAccessToken, newRefreshToken, err := s.tokenService.Refresh(oldRefreshToken, s.testUser)
+	s.mockTokenRepo.EXPECT().
+		RevokeTokenFamily(gomock.Any()).
+		Return(errors.New("database error"))
 
-	assert.Error(s.T(), err)
-	assert.Nil(s.T(), newAccessToken)
-	assert.Nil(s.T(), newRefreshToken)
-	assert.ErrorContains(s.T(), err, "failed to create token")
+	_, _, err := s.tokenService.Refresh(oldRefreshToken, s.testUser, models.SessionMetadata{})
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenReused)
+	assert.ErrorContains(s.T(), err, "failed to revoke token")
 }
 
 func (s *TokenServiceTestSuite) TestRevokeTokenSuccess() {
@@ -351,6 +446,134 @@ func (s *TokenServiceTestSuite) TestRevokeTokenRevokeError() {
 	assert.ErrorContains(s.T(), err, "failed to revoke token")
 }
 
+func (s *TokenServiceTestSuite) TestCheckRefreshToken() {
+	token := &models.Token{
+		Value:  s.testTokenValue,
+		UserID: s.testUser.ID,
+	}
+
+	s.mockHashService.EXPECT().
+		HashToken(s.testTokenValue).
+		Return(s.testHashedValue)
+
+	s.mockTokenRepo.EXPECT().
+		FindToken(gomock.Any()).
+		DoAndReturn(func(token *models.Token) error {
+			assert.Equal(s.T(), s.testHashedValue, token.HashedValue)
+			return autherrors.ErrInvalidToken(autherrors.ErrTokenRevoked)
+		})
+
+	err := s.tokenService.CheckRefreshToken(token)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenRevoked)
+}
+
+func (s *TokenServiceTestSuite) TestCreateNewTokenPairStoresSession() {
+	session := models.SessionMetadata{
+		UserAgent:  "Mozilla/5.0",
+		IPAddress:  "198.51.100.23",
+		DeviceName: "Work laptop",
+	}
+
+	s.mockHashService.EXPECT().
+		HashToken(gomock.Any()).
+		Return(s.testHashedValue)
+
+	s.mockTokenRepo.EXPECT().
+		SaveToken(gomock.Any()).
+		DoAndReturn(func(token *models.Token) error {
+			assert.Equal(s.T(), session, token.Session)
+			return nil
+		})
+
+	_, refreshToken, err := s.tokenService.CreateNewTokenPair(s.testUser, session)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), session, refreshToken.Session)
+}
+
+func (s *TokenServiceTestSuite) TestListSessions() {
+	startedAt := time.Now().UTC().Add(-48 * time.Hour)
+	lastUsedAt := time.Now().UTC().Add(-time.Hour)
+	token := &models.Token{
+		UserID:           s.testUser.ID,
+		FamilyID:         uuid.New(),
+		Session:          models.SessionMetadata{UserAgent: "planner-android/3.0", IPAddress: "192.0.2.1", DeviceName: "Pixel"},
+		CreatedAt:        lastUsedAt,
+		SessionStartedAt: startedAt,
+		ExpiresAt:        lastUsedAt.Add(s.refreshDuration),
+	}
+
+	s.mockTokenRepo.EXPECT().
+		FindActiveTokens(s.testUser.ID).
+		Return([]*models.Token{token}, nil)
+
+	sessions, err := s.tokenService.ListSessions(s.testUser.ID)
+
+	require.NoError(s.T(), err)
+	require.Len(s.T(), sessions, 1)
+	assert.Equal(s.T(), token.FamilyID, sessions[0].ID)
+	assert.Equal(s.T(), token.Session, sessions[0].SessionMetadata)
+	assert.Equal(s.T(), startedAt, sessions[0].CreatedAt)
+	assert.Equal(s.T(), lastUsedAt, sessions[0].LastUsedAt)
+	assert.Equal(s.T(), token.ExpiresAt, sessions[0].ExpiresAt)
+}
+
+func (s *TokenServiceTestSuite) TestListSessionsError() {
+	s.mockTokenRepo.EXPECT().
+		FindActiveTokens(s.testUser.ID).
+		Return(nil, errors.New("database error"))
+
+	sessions, err := s.tokenService.ListSessions(s.testUser.ID)
+
+	assert.Error(s.T(), err)
+	assert.Nil(s.T(), sessions)
+}
+
+func (s *TokenServiceTestSuite) TestRevokeSession() {
+	sessionID := uuid.New()
+
+	s.mockTokenRepo.EXPECT().
+		RevokeSession(s.testUser.ID, sessionID).
+		Return(nil)
+
+	err := s.tokenService.RevokeSession(s.testUser.ID, sessionID)
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *TokenServiceTestSuite) TestRevokeSessionErrors() {
+	s.mockTokenRepo.EXPECT().
+		RevokeSession(s.testUser.ID, gomock.Any()).
+		Return(autherrors.ErrSessionNotFound)
+
+	err := s.tokenService.RevokeSession(s.testUser.ID, uuid.New())
+	assert.ErrorIs(s.T(), err, autherrors.ErrSessionNotFound)
+
+	s.mockTokenRepo.EXPECT().
+		RevokeSession(s.testUser.ID, gomock.Any()).
+		Return(errors.New("database error"))
+
+	err = s.tokenService.RevokeSession(s.testUser.ID, uuid.New())
+	assert.ErrorContains(s.T(), err, "failed to revoke token")
+}
+
+func (s *TokenServiceTestSuite) TestRevokeAllTokens() {
+	s.mockTokenRepo.EXPECT().
+		RevokeAllUserTokens(s.testUser.ID).
+		Return(nil)
+
+	err := s.tokenService.RevokeAllTokens(s.testUser.ID)
+	assert.NoError(s.T(), err)
+
+	s.mockTokenRepo.EXPECT().
+		RevokeAllUserTokens(s.testUser.ID).
+		Return(errors.New("database error"))
+
+	err = s.tokenService.RevokeAllTokens(s.testUser.ID)
+	assert.ErrorContains(s.T(), err, "failed to revoke token")
+}
+
 func TestTokenServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(TokenServiceTestSuite))
 }
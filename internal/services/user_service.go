@@ -1,6 +1,11 @@
 package services
 
 import (
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
 	"github.com/breakfront-planner/auth-service/internal/autherrors"
 	"github.com/breakfront-planner/auth-service/internal/models"
 )
@@ -9,25 +14,55 @@ import (
 type IUserRepository interface {
 	CreateUser(login string, passHash string) (*models.User, error)
 	FindUser(filter *models.UserFilter) (*models.User, error)
+	InvalidateTokens(userID uuid.UUID, validAfter time.Time) error
+	UpdatePasswordHash(userID uuid.UUID, passHash string) error
+}
+
+// IPasswordPolicy defines the rules new passwords must meet.
+type IPasswordPolicy interface {
+	Check(login string, password string) error
 }
 
 // UserService handles user management operations including creation and retrieval.
 type UserService struct {
-	userRepo    IUserRepository
-	hashService IHashService
+	userRepo       IUserRepository
+	hashService    IHashService
+	passwordPolicy IPasswordPolicy
+}
+
+// UserServiceOption is a function that modifies the UserService configuration.
+type UserServiceOption func(*UserService)
+
+// WithPasswordPolicy makes new users' passwords meet the policy.
+func WithPasswordPolicy(policy IPasswordPolicy) UserServiceOption {
+	return func(s *UserService) {
+		s.passwordPolicy = policy
+	}
 }
 
 // NewUserService creates a new user service instance.
-func NewUserService(userRepo IUserRepository, hashService IHashService) *UserService {
-	return &UserService{
+// Passwords are only checked if a password policy is given.
+func NewUserService(userRepo IUserRepository, hashService IHashService, opts ...UserServiceOption) *UserService {
+	s := &UserService{
 		userRepo:    userRepo,
 		hashService: hashService,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // CreateUser creates a new user with the provided login and password.
-// Returns an error if the login is already taken or if password hashing fails.
+// Returns an error if the password doesn't meet the password policy, if the login is already taken
+// or if password hashing fails.
 func (s *UserService) CreateUser(login string, password string) (*models.User, error) {
+	if s.passwordPolicy != nil {
+		if err := s.passwordPolicy.Check(login, password); err != nil {
+			return nil, err
+		}
+	}
+
 	newUserFilter := models.UserFilter{
 		Login: &login,
 	}
@@ -74,6 +109,7 @@ func (s *UserService) FindUser(filter *models.UserFilter) (*models.User, error)
 }
 
 // CheckPassword verifies that the provided password matches the user's stored password hash.
+// If the hash was made with an outdated algorithm or parameters, it is replaced with a current one.
 func (s *UserService) CheckPassword(login string, password string) error {
 
 	filter := models.UserFilter{
@@ -85,11 +121,49 @@ func (s *UserService) CheckPassword(login string, password string) error {
 		return autherrors.ErrWrongLogin(err)
 	}
 
+	if user == nil {
+		return autherrors.ErrWrongLogin(autherrors.ErrUserNotExist)
+	}
+
 	err = s.hashService.ComparePasswords(user.PasswordHash, password)
 	if err != nil {
 		return err
 	}
 
+	if s.hashService.NeedsRehash(user.PasswordHash) {
+		s.rehashPassword(user, password)
+	}
+
+	return nil
+
+}
+
+// rehashPassword stores a current hash of the user's verified password.
+// Failing to do so doesn't affect the login; the old hash stays valid and is replaced on a later one.
+func (s *UserService) rehashPassword(user *models.User, password string) {
+
+	passHash, err := s.hashService.HashPassword(password)
+	if err == nil {
+		err = s.userRepo.UpdatePasswordHash(user.ID, passHash)
+	}
+	if err != nil {
+		log.Printf("failed to rehash password of user %v: %v", user.ID, err)
+	}
+
+}
+
+// InvalidateTokens makes every token issued to the user so far invalid.
+// Token issue times have a precision of one second, so the cutoff is rounded up to the next second:
+// tokens issued later within the current second are rejected as well.
+func (s *UserService) InvalidateTokens(userID uuid.UUID) error {
+
+	validAfter := time.Now().UTC().Truncate(time.Second).Add(time.Second)
+
+	err := s.userRepo.InvalidateTokens(userID, validAfter)
+	if err != nil {
+		return autherrors.ErrRevokeToken(err)
+	}
+
 	return nil
 
 }
@@ -3,7 +3,9 @@ package services
 import (
 	"errors"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
@@ -11,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
 
 	"github.com/breakfront-planner/auth-service/internal/autherrors"
 	"github.com/breakfront-planner/auth-service/internal/models"
@@ -130,6 +133,37 @@ func (s *UserServiceTestSuite) TestCreateUserFindUserError() {
 	assert.ErrorContains(s.T(), err, "registration failed")
 }
 
+func (s *UserServiceTestSuite) TestCreateUserChecksPasswordPolicy() {
+	policy := mocks.NewMockIPasswordPolicy(s.ctrl)
+	userService := NewUserService(s.mockUserRepo, s.hashService, WithPasswordPolicy(policy))
+
+	policy.EXPECT().Check(s.testLogin, s.testPassword).Return(nil)
+	s.mockUserRepo.EXPECT().FindUser(gomock.Any()).Return(nil, nil)
+	s.mockUserRepo.EXPECT().
+		CreateUser(s.testLogin, gomock.Any()).
+		Return(&models.User{ID: uuid.New(), Login: s.testLogin}, nil)
+
+	user, err := userService.CreateUser(s.testLogin, s.testPassword)
+
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), user)
+}
+
+func (s *UserServiceTestSuite) TestCreateUserWeakPassword() {
+	policy := mocks.NewMockIPasswordPolicy(s.ctrl)
+	userService := NewUserService(s.mockUserRepo, s.hashService, WithPasswordPolicy(policy))
+	policyErr := &autherrors.PasswordPolicyError{Violations: []autherrors.PasswordViolation{
+		{Rule: "min_length", Message: "must be at least 16 characters long"},
+	}}
+
+	policy.EXPECT().Check(s.testLogin, s.testPassword).Return(policyErr)
+
+	user, err := userService.CreateUser(s.testLogin, s.testPassword)
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrWeakPassword)
+	assert.Nil(s.T(), user, "no user should be looked up or created")
+}
+
 func (s *UserServiceTestSuite) TestFindUserSuccess() {
 	filter := &models.UserFilter{
 		Login: &s.testLogin,
@@ -202,6 +236,59 @@ func (s *UserServiceTestSuite) TestCheckPasswordSuccess() {
 	assert.NoError(s.T(), err)
 }
 
+func (s *UserServiceTestSuite) TestCheckPasswordRehashesBcrypt() {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte(s.testPassword), bcrypt.MinCost)
+	require.NoError(s.T(), err)
+
+	user := &models.User{
+		ID:           uuid.New(),
+		Login:        s.testLogin,
+		PasswordHash: string(bcryptHash),
+	}
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(user, nil)
+
+	var newHash string
+	s.mockUserRepo.EXPECT().
+		UpdatePasswordHash(user.ID, gomock.Any()).
+		DoAndReturn(func(_ uuid.UUID, passHash string) error {
+			newHash = passHash
+			return nil
+		})
+
+	err = s.userService.CheckPassword(s.testLogin, s.testPassword)
+
+	require.NoError(s.T(), err)
+	assert.True(s.T(), strings.HasPrefix(newHash, "$argon2id$"))
+	assert.NoError(s.T(), s.hashService.ComparePasswords(newHash, s.testPassword))
+}
+
+func (s *UserServiceTestSuite) TestCheckPasswordRehashesOutdatedParams() {
+	oldParams := DefaultArgon2Params
+	oldParams.Memory = 8 * 1024
+	oldHash, err := NewHashService(WithArgon2Params(oldParams)).HashPassword(s.testPassword)
+	require.NoError(s.T(), err)
+
+	user := &models.User{
+		ID:           uuid.New(),
+		Login:        s.testLogin,
+		PasswordHash: oldHash,
+	}
+
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(user, nil)
+	s.mockUserRepo.EXPECT().
+		UpdatePasswordHash(user.ID, gomock.Any()).
+		Return(errors.New("connection refused"))
+
+	err = s.userService.CheckPassword(s.testLogin, s.testPassword)
+
+	assert.NoError(s.T(), err, "a failed rehash must not fail the login")
+}
+
 func (s *UserServiceTestSuite) TestCheckPasswordWrongLogin() {
 	findError := errors.New("user not found")
 
@@ -215,6 +302,17 @@ func (s *UserServiceTestSuite) TestCheckPasswordWrongLogin() {
 	assert.ErrorContains(s.T(), err, "failed to find user")
 }
 
+func (s *UserServiceTestSuite) TestCheckPasswordUnknownLogin() {
+	s.mockUserRepo.EXPECT().
+		FindUser(gomock.Any()).
+		Return(nil, nil)
+
+	err := s.userService.CheckPassword(s.testLogin, s.testPassword)
+
+	assert.Error(s.T(), err)
+	assert.ErrorIs(s.T(), err, autherrors.ErrUserNotExist)
+}
+
 func (s *UserServiceTestSuite) TestCheckPasswordWrongPassword() {
 	hashedPassword, err := s.hashService.HashPassword(s.testPassword)
 	require.NoError(s.T(), err)
@@ -235,6 +333,34 @@ func (s *UserServiceTestSuite) TestCheckPasswordWrongPassword() {
 	assert.ErrorContains(s.T(), err, "wrong password")
 }
 
+func (s *UserServiceTestSuite) TestInvalidateTokensSuccess() {
+	userID := uuid.New()
+	var validAfter time.Time
+
+	s.mockUserRepo.EXPECT().
+		InvalidateTokens(userID, gomock.Any()).
+		DoAndReturn(func(_ uuid.UUID, cutoff time.Time) error {
+			validAfter = cutoff
+			return nil
+		})
+
+	err := s.userService.InvalidateTokens(userID)
+
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), validAfter.After(time.Now().UTC()), "cutoff should be rounded up to the next second")
+	assert.Equal(s.T(), validAfter, validAfter.Truncate(time.Second))
+}
+
+func (s *UserServiceTestSuite) TestInvalidateTokensError() {
+	s.mockUserRepo.EXPECT().
+		InvalidateTokens(gomock.Any(), gomock.Any()).
+		Return(errors.New("database error"))
+
+	err := s.userService.InvalidateTokens(uuid.New())
+
+	assert.ErrorContains(s.T(), err, "database error")
+}
+
 func TestUserServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(UserServiceTestSuite))
 }
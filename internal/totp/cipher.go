@@ -0,0 +1,79 @@
+package totp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// sealedPrefix marks secrets encrypted by a Cipher, so secrets stored before encryption was enabled
+// can still be read.
+const sealedPrefix = "aesgcm:"
+
+// Cipher encrypts TOTP secrets at rest with AES-256-GCM. Unlike passwords and recovery codes, secrets
+// can't be hashed, as codes are computed from them, so anyone reading the database could otherwise
+// generate the codes of every user.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a cipher with the 32 byte key.
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != 32 {
+		return nil, autherrors.ErrInvalidEncryptionKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// Seal encrypts the secret with a random nonce.
+func (c *Cipher) Seal(secret string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(secret), nil)
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a secret encrypted by Seal. Secrets that were stored unencrypted are returned as they are.
+func (c *Cipher) Open(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, sealedPrefix))
+	if err != nil {
+		return "", autherrors.ErrDecryptSecret(err)
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return "", autherrors.ErrDecryptSecret(autherrors.ErrInvalidTOTPSecret)
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	secret, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", autherrors.ErrDecryptSecret(err)
+	}
+
+	return string(secret), nil
+}
+
+// IsSealed reports whether the stored value is an encrypted secret.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
@@ -0,0 +1,134 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // G505: HMAC-SHA1 is the algorithm of RFC 6238 supported by all authenticator apps
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// Parameters of the generated codes. Authenticator apps assume these when a provisioning URI omits them,
+// and some ignore other values, so they are not configurable.
+const (
+	Digits = 6
+	Period = 30 * time.Second
+	// Skew is the number of time steps before and after the current one whose codes are accepted,
+	// allowing for clock drift of the device and the time the user takes to type the code.
+	Skew = 1
+	// secretSize is the number of random bytes of a secret, the length of the HMAC-SHA1 output (RFC 4226, section 4).
+	secretSize = 20
+)
+
+// encoding is the base32 encoding of secrets in provisioning URIs, without padding as authenticator apps expect.
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random secret, base32 encoded.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// ProvisioningURI returns the otpauth URI that authenticator apps scan as a QR code to add the secret.
+// The account is labelled with the issuer, so users with several accounts can tell them apart.
+func ProvisioningURI(issuer string, account string, secret string) string {
+	label := url.PathEscape(account)
+	if issuer != "" {
+		label = url.PathEscape(issuer) + ":" + label
+	}
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	if issuer != "" {
+		params.Set("issuer", issuer)
+	}
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(Digits))
+	params.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// GenerateCode returns the code of the secret at the given time.
+func GenerateCode(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return code(key, timeStep(t)), nil
+}
+
+// Validate checks the code against the codes of the secret around the given time and returns the time step
+// of the matching code. Callers must reject codes whose step is not after the last accepted one, so that
+// an observed code can't be replayed.
+func Validate(secret string, value string, t time.Time) (step int64, ok bool, err error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false, err
+	}
+
+	current := timeStep(t)
+	for step := current - Skew; step <= current+Skew; step++ {
+		if subtle.ConstantTimeCompare([]byte(code(key, step)), []byte(value)) == 1 {
+			return step, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// IsCode reports whether the value looks like a TOTP code rather than a recovery code.
+func IsCode(value string) bool {
+	if len(value) != Digits {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// timeStep returns the number of periods since the Unix epoch (RFC 6238, section 4.2).
+func timeStep(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// code computes the HOTP value of the time step (RFC 4226, section 5.3), truncated to the configured digits.
+func code(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step)) //nolint:gosec // G115: time steps are positive
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for range Digits {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%modulus)
+}
+
+// decodeSecret decodes a base32 secret, ignoring case, spaces and padding as users may type it in by hand.
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := encoding.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil || len(key) == 0 {
+		return nil, autherrors.ErrInvalidTOTPSecret
+	}
+	return key, nil
+}
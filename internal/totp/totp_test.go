@@ -0,0 +1,159 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// rfcSecret is the SHA-1 seed of the RFC 6238 test vectors, "12345678901234567890", base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+type TOTPTestSuite struct {
+	suite.Suite
+}
+
+func (s *TOTPTestSuite) TestGenerateCodeMatchesRFCVectors() {
+	// The RFC lists 8 digit codes; the 6 digit codes are their last digits
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.code, func() {
+			code, err := GenerateCode(rfcSecret, time.Unix(tc.unix, 0))
+
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), tc.code, code)
+		})
+	}
+}
+
+func (s *TOTPTestSuite) TestValidateAcceptsAdjacentSteps() {
+	now := time.Unix(1111111111, 0)
+
+	for _, offset := range []time.Duration{-Period, 0, Period} {
+		code, err := GenerateCode(rfcSecret, now.Add(offset))
+		require.NoError(s.T(), err)
+
+		step, ok, err := Validate(rfcSecret, code, now)
+
+		require.NoError(s.T(), err)
+		assert.True(s.T(), ok, "offset %v", offset)
+		assert.Equal(s.T(), timeStep(now.Add(offset)), step)
+	}
+}
+
+func (s *TOTPTestSuite) TestValidateRejectsOtherCodes() {
+	now := time.Unix(1111111111, 0)
+	staleCode, err := GenerateCode(rfcSecret, now.Add(-2*Period))
+	require.NoError(s.T(), err)
+
+	for _, code := range []string{staleCode, "000000", "", "50471"} {
+		_, ok, err := Validate(rfcSecret, code, now)
+
+		require.NoError(s.T(), err)
+		assert.False(s.T(), ok, "code %q", code)
+	}
+}
+
+func (s *TOTPTestSuite) TestValidateInvalidSecret() {
+	_, _, err := Validate("not base32!", "123456", time.Now())
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidTOTPSecret)
+}
+
+func (s *TOTPTestSuite) TestGenerateSecret() {
+	secret, err := GenerateSecret()
+	require.NoError(s.T(), err)
+	other, err := GenerateSecret()
+	require.NoError(s.T(), err)
+
+	assert.Len(s.T(), secret, 32, "20 bytes are 32 base32 characters")
+	assert.NotEqual(s.T(), secret, other)
+	_, err = GenerateCode(secret, time.Now())
+	assert.NoError(s.T(), err)
+}
+
+func (s *TOTPTestSuite) TestProvisioningURI() {
+	uri := ProvisioningURI("Breakfront", "alice@example.com", rfcSecret)
+
+	parsed, err := url.Parse(uri)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "otpauth", parsed.Scheme)
+	assert.Equal(s.T(), "totp", parsed.Host)
+	assert.Equal(s.T(), "/Breakfront:alice@example.com", parsed.Path)
+	assert.Equal(s.T(), rfcSecret, parsed.Query().Get("secret"))
+	assert.Equal(s.T(), "Breakfront", parsed.Query().Get("issuer"))
+	assert.Equal(s.T(), "6", parsed.Query().Get("digits"))
+	assert.Equal(s.T(), "30", parsed.Query().Get("period"))
+}
+
+func (s *TOTPTestSuite) TestIsCode() {
+	assert.True(s.T(), IsCode("012345"))
+	assert.False(s.T(), IsCode("12345"))
+	assert.False(s.T(), IsCode("abcde-fghij"))
+}
+
+func (s *TOTPTestSuite) TestCipherRoundTrip() {
+	cipher, err := NewCipher(make([]byte, 32))
+	require.NoError(s.T(), err)
+
+	sealed, err := cipher.Seal(rfcSecret)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), IsSealed(sealed))
+	assert.NotContains(s.T(), sealed, rfcSecret)
+
+	secret, err := cipher.Open(sealed)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), rfcSecret, secret)
+}
+
+func (s *TOTPTestSuite) TestCipherOpensUnencryptedSecrets() {
+	cipher, err := NewCipher(make([]byte, 32))
+	require.NoError(s.T(), err)
+
+	secret, err := cipher.Open(rfcSecret)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), rfcSecret, secret)
+}
+
+func (s *TOTPTestSuite) TestCipherRejectsOtherKey() {
+	cipher, err := NewCipher(make([]byte, 32))
+	require.NoError(s.T(), err)
+	sealed, err := cipher.Seal(rfcSecret)
+	require.NoError(s.T(), err)
+
+	otherKey := make([]byte, 32)
+	otherKey[0] = 1
+	other, err := NewCipher(otherKey)
+	require.NoError(s.T(), err)
+
+	_, err = other.Open(sealed)
+	assert.Error(s.T(), err)
+}
+
+func (s *TOTPTestSuite) TestNewCipherInvalidKey() {
+	_, err := NewCipher(make([]byte, 16))
+
+	assert.ErrorIs(s.T(), err, autherrors.ErrInvalidEncryptionKey)
+}
+
+func TestTOTPTestSuite(t *testing.T) {
+	suite.Run(t, new(TOTPTestSuite))
+}
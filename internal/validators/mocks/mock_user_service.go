@@ -54,3 +54,42 @@ func (mr *MockIUserServiceMockRecorder) FindUser(filter any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUser", reflect.TypeOf((*MockIUserService)(nil).FindUser), filter)
 }
+
+// MockIDenylist is a mock of IDenylist interface.
+type MockIDenylist struct {
+	ctrl     *gomock.Controller
+	recorder *MockIDenylistMockRecorder
+	isgomock struct{}
+}
+
+// MockIDenylistMockRecorder is the mock recorder for MockIDenylist.
+type MockIDenylistMockRecorder struct {
+	mock *MockIDenylist
+}
+
+// NewMockIDenylist creates a new mock instance.
+func NewMockIDenylist(ctrl *gomock.Controller) *MockIDenylist {
+	mock := &MockIDenylist{ctrl: ctrl}
+	mock.recorder = &MockIDenylistMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIDenylist) EXPECT() *MockIDenylistMockRecorder {
+	return m.recorder
+}
+
+// IsRevoked mocks base method.
+func (m *MockIDenylist) IsRevoked(token *models.ParsedToken) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRevoked", token)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRevoked indicates an expected call of IsRevoked.
+func (mr *MockIDenylistMockRecorder) IsRevoked(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRevoked", reflect.TypeOf((*MockIDenylist)(nil).IsRevoked), token)
+}
@@ -1,6 +1,7 @@
 package validators
 
 import (
+	"slices"
 	"time"
 
 	"github.com/breakfront-planner/auth-service/internal/autherrors"
@@ -14,10 +15,25 @@ type IUserService interface {
 	FindUser(filter *models.UserFilter) (*models.User, error)
 }
 
+// IDenylist defines the lookup of revoked access tokens.
+type IDenylist interface {
+	IsRevoked(token *models.ParsedToken) (bool, error)
+}
+
 // ValidationConfig holds the settings for token validation checks.
 type ValidationConfig struct {
 	RequiredType    *constants.TokenType
 	CheckUserExists bool
+	CheckRevoked    bool
+	Audience        string
+	Issuer          string
+	// RequiredRoles and RequiredPermissions must all be present in the token.
+	RequiredRoles       []string
+	RequiredPermissions []string
+	// AcceptClientTokens lets client tokens pass where access tokens are required.
+	AcceptClientTokens bool
+	// RejectDelegatedTokens rejects tokens issued by token exchange to a service acting for the user.
+	RejectDelegatedTokens bool
 }
 
 // ValidationOption is a function that modifies ValidationConfig.
@@ -30,24 +46,82 @@ func WithTokenType(tokenType constants.TokenType) ValidationOption {
 	}
 }
 
+// WithClientTokens accepts client tokens of services calling on their own behalf
+// in addition to the access tokens required with WithTokenType(constants.TokenTypeAccess).
+// Client tokens have no user, so the user existence check is skipped for them.
+func WithClientTokens() ValidationOption {
+	return func(config *ValidationConfig) {
+		config.AcceptClientTokens = true
+	}
+}
+
 // WithUserExistenceCheck enables user existence validation.
+// It also rejects tokens issued before the user's TokensValidAfter cutoff.
 func WithUserExistenceCheck() ValidationOption {
 	return func(config *ValidationConfig) {
 		config.CheckUserExists = true
 	}
 }
 
+// WithRevocationCheck rejects tokens whose jti is on the denylist.
+func WithRevocationCheck() ValidationOption {
+	return func(config *ValidationConfig) {
+		config.CheckRevoked = true
+	}
+}
+
+// WithAudience rejects tokens that are not meant for the given audience.
+func WithAudience(audience string) ValidationOption {
+	return func(config *ValidationConfig) {
+		config.Audience = audience
+	}
+}
+
+// WithoutDelegatedTokens rejects tokens with an actor, which a service obtained by token exchange
+// to act for the user, so that only tokens held by the user themselves are accepted.
+func WithoutDelegatedTokens() ValidationOption {
+	return func(config *ValidationConfig) {
+		config.RejectDelegatedTokens = true
+	}
+}
+
+// WithIssuer rejects tokens issued by anyone but the given issuer.
+func WithIssuer(issuer string) ValidationOption {
+	return func(config *ValidationConfig) {
+		config.Issuer = issuer
+	}
+}
+
+// WithRequiredRole rejects tokens that don't grant the given role.
+// It can be passed several times to require several roles.
+func WithRequiredRole(role string) ValidationOption {
+	return func(config *ValidationConfig) {
+		config.RequiredRoles = append(config.RequiredRoles, role)
+	}
+}
+
+// WithRequiredPermission rejects tokens that don't grant the given permission.
+// It can be passed several times to require several permissions.
+func WithRequiredPermission(permission string) ValidationOption {
+	return func(config *ValidationConfig) {
+		config.RequiredPermissions = append(config.RequiredPermissions, permission)
+	}
+}
+
 // TokenValidator validates JWT tokens with flexible configuration.
 type TokenValidator struct {
 	jwtManager  *jwt.Manager
 	userService IUserService
+	denylist    IDenylist
 }
 
 // NewTokenValidator creates a new token validator instance.
-func NewTokenValidator(jwtManager *jwt.Manager, userService IUserService) *TokenValidator {
+// The denylist is only used by the revocation check and may be nil if it is never enabled.
+func NewTokenValidator(jwtManager *jwt.Manager, userService IUserService, denylist IDenylist) *TokenValidator {
 	return &TokenValidator{
 		jwtManager:  jwtManager,
 		userService: userService,
+		denylist:    denylist,
 	}
 }
 
@@ -65,26 +139,75 @@ func (v *TokenValidator) Validate(tokenValue string, opts ...ValidationOption) (
 		return nil, autherrors.ErrParseToken(err)
 	}
 
-	// Always check expiration
-	if parsedToken.ExpiresAt.Before(time.Now().UTC()) {
+	// Always check expiration, allowing for clock skew
+	if parsedToken.ExpiresAt.Before(time.Now().UTC().Add(-v.jwtManager.Leeway())) {
 		return nil, autherrors.ErrTokenExpired
 	}
 
+	// Validate issuer and audience if specified
+	if config.Issuer != "" && parsedToken.Issuer != config.Issuer {
+		return nil, autherrors.ErrTokenIssuer
+	}
+	if config.Audience != "" && !slices.Contains(parsedToken.Audience, config.Audience) {
+		return nil, autherrors.ErrTokenAudience
+	}
+	if config.RejectDelegatedTokens && parsedToken.Actor != nil {
+		return nil, autherrors.ErrTokenDelegated
+	}
+
+	isClientToken := parsedToken.Type == string(constants.TokenTypeClient)
+
 	// Validate token type if specified
-	if config.RequiredType != nil {
-		if parsedToken.Type != string(*config.RequiredType) {
+	if config.RequiredType != nil && parsedToken.Type != string(*config.RequiredType) {
+		clientForAccess := config.AcceptClientTokens && isClientToken && *config.RequiredType == constants.TokenTypeAccess
+		if !clientForAccess {
 			return nil, autherrors.ErrTokenType
 		}
 	}
 
+	// Reject revoked tokens if specified
+	if config.CheckRevoked {
+		if v.denylist == nil {
+			return nil, autherrors.ErrNoDenylist
+		}
+		revoked, err := v.denylist.IsRevoked(parsedToken)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, autherrors.ErrTokenRevoked
+		}
+	}
+
 	// Validate user existence if specified
-	if config.CheckUserExists {
+	if config.CheckUserExists && !(config.AcceptClientTokens && isClientToken) {
 		filter := &models.UserFilter{
 			ID: &parsedToken.UserID,
 		}
-		if _, err := v.userService.FindUser(filter); err != nil {
+		user, err := v.userService.FindUser(filter)
+		if err != nil {
 			return nil, err
 		}
+		if user == nil {
+			return nil, autherrors.ErrUserNotExist
+		}
+		// Reject tokens issued before the user signed out from all devices
+		if user.TokensValidAfter != nil && parsedToken.IssuedAt.Before(*user.TokensValidAfter) {
+			return nil, autherrors.ErrTokenRevoked
+		}
+	}
+
+	// Validate authorization claims if specified, once the token is known to be valid,
+	// so that a revoked token is reported as such rather than as lacking a role
+	for _, role := range config.RequiredRoles {
+		if !slices.Contains(parsedToken.Roles, role) {
+			return nil, autherrors.ErrRoleRequired(role)
+		}
+	}
+	for _, permission := range config.RequiredPermissions {
+		if !slices.Contains(parsedToken.Permissions, permission) {
+			return nil, autherrors.ErrPermissionRequired(permission)
+		}
 	}
 
 	return parsedToken, nil
@@ -100,12 +223,22 @@ func (v *TokenValidator) ValidateRefreshToken(tokenValue string) (*models.Parsed
 	)
 }
 
+// ValidateClientToken validates a client token issued with the client credentials grant.
+func (v *TokenValidator) ValidateClientToken(tokenValue string) (*models.ParsedToken, error) {
+	return v.Validate(
+		tokenValue,
+		WithTokenType(constants.TokenTypeClient),
+		WithRevocationCheck(),
+	)
+}
+
 // ValidateAccessToken validates an access token with all checks enabled.
 func (v *TokenValidator) ValidateAccessToken(tokenValue string) (*models.ParsedToken, error) {
 	accessType := constants.TokenTypeAccess
 	return v.Validate(
 		tokenValue,
 		WithTokenType(accessType),
+		WithRevocationCheck(),
 		WithUserExistenceCheck(),
 	)
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
 
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
 	"github.com/breakfront-planner/auth-service/internal/constants"
 	"github.com/breakfront-planner/auth-service/internal/jwt"
 	"github.com/breakfront-planner/auth-service/internal/models"
@@ -23,6 +24,7 @@ type TokenValidatorTestSuite struct {
 	suite.Suite
 	ctrl            *gomock.Controller
 	mockUserService *mocks.MockIUserService
+	mockDenylist    *mocks.MockIDenylist
 	jwtManager      *jwt.Manager
 	validator       *TokenValidator
 	testUser        *models.User
@@ -56,7 +58,8 @@ func (s *TokenValidatorTestSuite) SetupSuite() {
 func (s *TokenValidatorTestSuite) SetupTest() {
 	s.ctrl = gomock.NewController(s.T())
 	s.mockUserService = mocks.NewMockIUserService(s.ctrl)
-	s.validator = NewTokenValidator(s.jwtManager, s.mockUserService)
+	s.mockDenylist = mocks.NewMockIDenylist(s.ctrl)
+	s.validator = NewTokenValidator(s.jwtManager, s.mockUserService, s.mockDenylist)
 
 	// Generate a valid refresh token
 	token, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeRefresh)
@@ -137,11 +140,74 @@ func (s *TokenValidatorTestSuite) TestValidateRefreshTokenUserNotFound() {
 	assert.ErrorContains(s.T(), err, "user not found")
 }
 
+// Test ValidateRefreshToken - User Deleted
+func (s *TokenValidatorTestSuite) TestValidateRefreshTokenUserDeleted() {
+	s.mockUserService.EXPECT().
+		FindUser(gomock.Any()).
+		Return(nil, nil)
+
+	parsedToken, err := s.validator.ValidateRefreshToken(s.validToken)
+
+	assert.Error(s.T(), err)
+	assert.Nil(s.T(), parsedToken)
+	assert.ErrorIs(s.T(), err, autherrors.ErrUserNotExist)
+}
+
+// Test ValidateAccessToken - Issued Before The User Signed Out Everywhere
+func (s *TokenValidatorTestSuite) TestValidateAccessTokenIssuedBeforeCutoff() {
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	validAfter := time.Now().UTC().Add(time.Second)
+	signedOutUser := *s.testUser
+	signedOutUser.TokensValidAfter = &validAfter
+
+	s.mockDenylist.EXPECT().
+		IsRevoked(gomock.Any()).
+		Return(false, nil)
+
+	s.mockUserService.EXPECT().
+		FindUser(gomock.Any()).
+		Return(&signedOutUser, nil)
+
+	parsedToken, err := s.validator.ValidateAccessToken(accessToken.Value)
+
+	assert.Nil(s.T(), parsedToken)
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenRevoked)
+}
+
+// Test ValidateAccessToken - Issued After The Cutoff
+func (s *TokenValidatorTestSuite) TestValidateAccessTokenIssuedAfterCutoff() {
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	validAfter := time.Now().UTC().Add(-time.Minute)
+	signedOutUser := *s.testUser
+	signedOutUser.TokensValidAfter = &validAfter
+
+	s.mockDenylist.EXPECT().
+		IsRevoked(gomock.Any()).
+		Return(false, nil)
+
+	s.mockUserService.EXPECT().
+		FindUser(gomock.Any()).
+		Return(&signedOutUser, nil)
+
+	parsedToken, err := s.validator.ValidateAccessToken(accessToken.Value)
+
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), parsedToken)
+}
+
 // Test ValidateAccessToken - Success
 func (s *TokenValidatorTestSuite) TestValidateAccessTokenSuccess() {
 	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
 	require.NoError(s.T(), err)
 
+	s.mockDenylist.EXPECT().
+		IsRevoked(gomock.Any()).
+		Return(false, nil)
+
 	s.mockUserService.EXPECT().
 		FindUser(gomock.Any()).
 		Return(s.testUser, nil)
@@ -154,6 +220,164 @@ func (s *TokenValidatorTestSuite) TestValidateAccessTokenSuccess() {
 	assert.Equal(s.T(), string(constants.TokenTypeAccess), parsedToken.Type)
 }
 
+// Test ValidateAccessToken - Revoked
+func (s *TokenValidatorTestSuite) TestValidateAccessTokenRevoked() {
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	s.mockDenylist.EXPECT().
+		IsRevoked(gomock.Any()).
+		DoAndReturn(func(token *models.ParsedToken) (bool, error) {
+			assert.NotEmpty(s.T(), token.JTI)
+			return true, nil
+		})
+
+	parsedToken, err := s.validator.ValidateAccessToken(accessToken.Value)
+
+	assert.Nil(s.T(), parsedToken)
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenRevoked)
+}
+
+// Test Validate - Revocation Check Errors
+func (s *TokenValidatorTestSuite) TestValidateRevocationCheckErrors() {
+	s.mockDenylist.EXPECT().
+		IsRevoked(gomock.Any()).
+		Return(false, errors.New("database error"))
+
+	parsedToken, err := s.validator.Validate(s.validToken, WithRevocationCheck())
+	assert.Nil(s.T(), parsedToken)
+	assert.ErrorContains(s.T(), err, "database error")
+
+	noDenylistValidator := NewTokenValidator(s.jwtManager, s.mockUserService, nil)
+	parsedToken, err = noDenylistValidator.Validate(s.validToken, WithRevocationCheck())
+	assert.Nil(s.T(), parsedToken)
+	assert.ErrorIs(s.T(), err, autherrors.ErrNoDenylist)
+}
+
+// Test Validate - Issuer And Audience
+func (s *TokenValidatorTestSuite) TestValidateIssuerAndAudience() {
+	issuer := jwt.NewManager(os.Getenv("TEST_JWT_SECRET"), s.accessDuration, s.refreshDuration,
+		jwt.WithIssuer("https://auth.breakfront.test"), jwt.WithAudience("planner", "notifications"))
+	token, err := issuer.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	parsedToken, err := s.validator.Validate(token.Value,
+		WithIssuer("https://auth.breakfront.test"), WithAudience("notifications"))
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), parsedToken)
+
+	parsedToken, err = s.validator.Validate(token.Value, WithAudience("billing"))
+	assert.Nil(s.T(), parsedToken)
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenAudience)
+
+	parsedToken, err = s.validator.Validate(token.Value, WithIssuer("https://other.test"))
+	assert.Nil(s.T(), parsedToken)
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenIssuer)
+}
+
+// Test Validate - Delegated Tokens
+func (s *TokenValidatorTestSuite) TestValidateWithoutDelegatedTokens() {
+	token, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+	subject, err := s.jwtManager.ParseToken(token.Value)
+	require.NoError(s.T(), err)
+	exchanged, err := s.jwtManager.GenerateExchangedToken(subject, "notifications", subject.Permissions,
+		&models.Actor{Subject: "scheduler"})
+	require.NoError(s.T(), err)
+
+	parsedToken, err := s.validator.Validate(token.Value, WithoutDelegatedTokens())
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), parsedToken)
+
+	parsedToken, err = s.validator.Validate(exchanged.Value, WithoutDelegatedTokens())
+	assert.Nil(s.T(), parsedToken)
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenDelegated)
+}
+
+// Test Validate - Required Role And Permission
+func (s *TokenValidatorTestSuite) TestValidateRequiredRoleAndPermission() {
+	admin := &models.User{
+		ID:          s.testUser.ID,
+		Roles:       []string{"admin"},
+		Permissions: []string{"users:manage"},
+	}
+	token, err := s.jwtManager.GenerateToken(admin, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	parsedToken, err := s.validator.Validate(token.Value,
+		WithRequiredRole("admin"), WithRequiredPermission("users:manage"))
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), parsedToken)
+
+	parsedToken, err = s.validator.Validate(token.Value, WithRequiredRole("auditor"))
+	assert.Nil(s.T(), parsedToken)
+	assert.ErrorIs(s.T(), err, autherrors.ErrMissingRole)
+
+	parsedToken, err = s.validator.Validate(token.Value, WithRequiredPermission("plans:write"))
+	assert.Nil(s.T(), parsedToken)
+	assert.ErrorIs(s.T(), err, autherrors.ErrMissingPermission)
+}
+
+// Test Validate - Revoked Token Without Required Permission
+func (s *TokenValidatorTestSuite) TestValidateRevokedTokenBeforePermission() {
+	s.mockDenylist.EXPECT().
+		IsRevoked(gomock.Any()).
+		Return(true, nil)
+
+	parsedToken, err := s.validator.Validate(s.validToken, WithRevocationCheck(), WithRequiredPermission("plans:write"))
+
+	assert.Nil(s.T(), parsedToken)
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenRevoked, "revoked tokens must be reported as revoked, not as forbidden")
+}
+
+func (s *TokenValidatorTestSuite) TestValidateClientToken() {
+	token, err := s.jwtManager.GenerateClientToken("nightly-export", []string{"plans:read"})
+	require.NoError(s.T(), err)
+
+	s.mockDenylist.EXPECT().
+		IsRevoked(gomock.Any()).
+		Return(false, nil).
+		Times(2)
+
+	parsedToken, err := s.validator.ValidateClientToken(token.Value)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "nightly-export", parsedToken.ClientID)
+
+	// User endpoints must not accept machine tokens
+	parsedToken, err = s.validator.ValidateAccessToken(token.Value)
+	assert.Nil(s.T(), parsedToken)
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenType)
+
+	// Services accepting both skip the user lookup for client tokens
+	parsedToken, err = s.validator.Validate(token.Value,
+		WithTokenType(constants.TokenTypeAccess), WithClientTokens(), WithUserExistenceCheck(),
+		WithRevocationCheck(), WithRequiredPermission("plans:read"))
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "nightly-export", parsedToken.ClientID)
+
+	parsedToken, err = s.validator.Validate(s.validToken, WithTokenType(constants.TokenTypeAccess), WithClientTokens())
+	assert.Nil(s.T(), parsedToken, "client tokens must not open the door for refresh tokens")
+	assert.ErrorIs(s.T(), err, autherrors.ErrTokenType)
+}
+
+// Test Validate - Expiration Leeway
+func (s *TokenValidatorTestSuite) TestValidateExpirationLeeway() {
+	expiredManager := jwt.NewManager(os.Getenv("TEST_JWT_SECRET"), -5*time.Second, -5*time.Second)
+	token, err := expiredManager.GenerateToken(s.testUser, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+
+	lenientManager := jwt.NewManager(os.Getenv("TEST_JWT_SECRET"), 0, 0, jwt.WithLeeway(jwt.DefaultLeeway))
+	lenientValidator := NewTokenValidator(lenientManager, s.mockUserService, s.mockDenylist)
+
+	parsedToken, err := lenientValidator.Validate(token.Value)
+	assert.NoError(s.T(), err)
+	assert.NotNil(s.T(), parsedToken)
+
+	parsedToken, err = s.validator.Validate(token.Value)
+	assert.Nil(s.T(), parsedToken)
+	assert.Error(s.T(), err)
+}
+
 // Test Validate - Only Expiration Check (no type, no user)
 func (s *TokenValidatorTestSuite) TestValidateOnlyExpirationCheck() {
 	// No options = only parse + expiration check
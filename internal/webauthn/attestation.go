@@ -0,0 +1,90 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"slices"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// Attestation statement formats (WebAuthn Level 3, section 8) that registrations may use.
+// Registration options ask for no attestation, so browsers usually replace any other format with "none";
+// "packed" is the format most authenticators produce themselves.
+const (
+	formatNone   = "none"
+	formatPacked = "packed"
+)
+
+// oidFIDOAAGUID is the certificate extension holding the AAGUID of the authenticator model
+// (WebAuthn Level 3, section 8.2.1).
+var oidFIDOAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// verifyAttestation checks the attestation statement of a registration.
+// The signature of a packed statement is verified, but not whom its certificate chains to: passkeys of any
+// authenticator are accepted, so there are no trust anchors to check it against.
+func verifyAttestation(format string, statement map[any]any, authData *authenticatorData, rawAuthData []byte,
+	clientDataHash []byte, credentialKey *publicKey) error {
+	switch format {
+	case formatNone:
+		if len(statement) != 0 {
+			return autherrors.ErrPasskeyVerification("malformed attestation statement")
+		}
+		return nil
+
+	case formatPacked:
+		return verifyPackedAttestation(statement, authData, slices.Concat(rawAuthData, clientDataHash), credentialKey)
+
+	default:
+		return autherrors.ErrPasskeyVerification("unsupported attestation format")
+	}
+}
+
+// verifyPackedAttestation checks a packed attestation statement (WebAuthn Level 3, section 8.2).
+// Without a certificate it is a self attestation, signed with the credential key itself.
+func verifyPackedAttestation(statement map[any]any, authData *authenticatorData, signed []byte, credentialKey *publicKey) error {
+	algorithm, hasAlgorithm := statement["alg"].(int64)
+	signature, hasSignature := statement["sig"].([]byte)
+	if !hasAlgorithm || !hasSignature {
+		return autherrors.ErrPasskeyVerification("malformed attestation statement")
+	}
+
+	chain, hasChain := statement["x5c"]
+	if !hasChain {
+		if algorithm != credentialKey.algorithm || !credentialKey.verify(signed, signature) {
+			return autherrors.ErrPasskeyVerification("invalid attestation signature")
+		}
+		return nil
+	}
+
+	certificates, _ := chain.([]any)
+	if len(certificates) == 0 {
+		return autherrors.ErrPasskeyVerification("malformed attestation statement")
+	}
+	der, _ := certificates[0].([]byte)
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return autherrors.ErrPasskeyVerification("malformed attestation certificate")
+	}
+
+	if certificate.Version != 3 || certificate.IsCA {
+		return autherrors.ErrPasskeyVerification("invalid attestation certificate")
+	}
+	for _, extension := range certificate.Extensions {
+		if !extension.Id.Equal(oidFIDOAAGUID) {
+			continue
+		}
+		var aaguid []byte
+		rest, err := asn1.Unmarshal(extension.Value, &aaguid)
+		if err != nil || len(rest) > 0 || extension.Critical || !bytes.Equal(aaguid, authData.aaguid) {
+			return autherrors.ErrPasskeyVerification("attestation certificate AAGUID mismatch")
+		}
+	}
+
+	if !verifySignature(algorithm, certificate.PublicKey, signed, signature) {
+		return autherrors.ErrPasskeyVerification("invalid attestation signature")
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package webauthn
+
+import (
+	"encoding/binary"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// Flags of the authenticator data (WebAuthn Level 3, section 6.1).
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagBackupEligible         = 0x08
+	flagBackedUp               = 0x10
+	flagAttestedCredentialData = 0x40
+	flagExtensionData          = 0x80
+)
+
+const (
+	// authenticatorDataMinLength is the length of the RP ID hash, the flags and the signature counter.
+	authenticatorDataMinLength = 37
+	aaguidLength               = 16
+	// maxCredentialIDLength is the longest credential ID authenticators may create.
+	maxCredentialIDLength = 1023
+)
+
+// authenticatorData is the data an authenticator signs in both ceremonies.
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+	// aaguid, credentialID and credentialPublicKey are only present in registrations,
+	// when the attested credential data flag is set.
+	aaguid              []byte
+	credentialID        []byte
+	credentialPublicKey []byte
+}
+
+// parseAuthenticatorData splits the authenticator data into its fields. The credential public key and the
+// extensions are CBOR encoded without a length, so they are decoded to find where they end.
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < authenticatorDataMinLength {
+		return nil, autherrors.ErrPasskeyVerification("authenticator data too short")
+	}
+
+	authData := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[authenticatorDataMinLength:]
+
+	if authData.has(flagAttestedCredentialData) {
+		if len(rest) < aaguidLength+2 {
+			return nil, autherrors.ErrPasskeyVerification("malformed attested credential data")
+		}
+		authData.aaguid = rest[:aaguidLength]
+		idLength := int(binary.BigEndian.Uint16(rest[aaguidLength:]))
+		rest = rest[aaguidLength+2:]
+
+		if idLength == 0 || idLength > maxCredentialIDLength || idLength > len(rest) {
+			return nil, autherrors.ErrPasskeyVerification("malformed credential ID")
+		}
+		authData.credentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		_, afterKey, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, autherrors.ErrPasskeyVerification("malformed public key")
+		}
+		authData.credentialPublicKey = rest[:len(rest)-len(afterKey)]
+		rest = afterKey
+	}
+
+	// Extension outputs are not used, but they must be well-formed to find the end of the data
+	if authData.has(flagExtensionData) {
+		extensions, afterExtensions, err := decodeCBOR(rest)
+		if _, ok := extensions.(map[any]any); err != nil || !ok {
+			return nil, autherrors.ErrPasskeyVerification("malformed extensions")
+		}
+		rest = afterExtensions
+	}
+
+	if len(rest) > 0 {
+		return nil, autherrors.ErrPasskeyVerification("trailing bytes in authenticator data")
+	}
+
+	return authData, nil
+}
+
+// has reports whether the flag is set.
+func (d *authenticatorData) has(flag byte) bool {
+	return d.flags&flag != 0
+}
@@ -0,0 +1,182 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"math"
+	"unicode/utf8"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// CBOR major types (RFC 8949, section 3.1).
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborBytes    = 2
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+	cborSimple   = 7
+)
+
+// CBOR simple values (RFC 8949, section 3.3).
+const (
+	cborFalse = 20
+	cborTrue  = 21
+	cborNull  = 22
+)
+
+// maxCBORDepth limits the nesting of arrays and maps, so crafted input can't exhaust the stack.
+const maxCBORDepth = 16
+
+// decodeCBOR decodes the first CBOR data item of data and returns it together with the bytes following it.
+// Integers are decoded to int64, byte strings to []byte, text strings to string, arrays to []any
+// and maps to map[any]any with int64 or string keys. Only what authenticators use is supported:
+// they encode attestation objects and public keys in the CTAP2 canonical form, without tags, floats
+// or indefinite lengths, which are rejected with autherrors.ErrInvalidCBOR like any malformed input.
+func decodeCBOR(data []byte) (any, []byte, error) {
+	d := cborDecoder{data: data}
+
+	value, err := d.decode(0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return value, d.data, nil
+}
+
+// cborDecoder reads data items from the front of data.
+type cborDecoder struct {
+	data []byte
+}
+
+func (d *cborDecoder) decode(depth int) (any, error) {
+	if depth > maxCBORDepth {
+		return nil, autherrors.ErrInvalidCBOR
+	}
+
+	major, info, arg, err := d.readHead()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUnsigned:
+		if arg > math.MaxInt64 {
+			return nil, autherrors.ErrInvalidCBOR
+		}
+		return int64(arg), nil
+
+	case cborNegative:
+		if arg > math.MaxInt64 {
+			return nil, autherrors.ErrInvalidCBOR
+		}
+		return -1 - int64(arg), nil
+
+	case cborBytes:
+		return d.readBytes(arg)
+
+	case cborText:
+		text, err := d.readBytes(arg)
+		if err != nil || !utf8.Valid(text) {
+			return nil, autherrors.ErrInvalidCBOR
+		}
+		return string(text), nil
+
+	case cborArray:
+		// Every item takes at least one byte, which bounds the allocation by the input size
+		if arg > uint64(len(d.data)) {
+			return nil, autherrors.ErrInvalidCBOR
+		}
+		items := make([]any, 0, arg)
+		for range arg {
+			item, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+
+	case cborMap:
+		if arg > uint64(len(d.data))/2 {
+			return nil, autherrors.ErrInvalidCBOR
+		}
+		entries := make(map[any]any, arg)
+		for range arg {
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, autherrors.ErrInvalidCBOR
+			}
+			if _, ok := entries[key]; ok {
+				return nil, autherrors.ErrInvalidCBOR
+			}
+
+			value, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			entries[key] = value
+		}
+		return entries, nil
+
+	case cborSimple:
+		switch info {
+		case cborFalse:
+			return false, nil
+		case cborTrue:
+			return true, nil
+		case cborNull:
+			return nil, nil
+		}
+	}
+
+	return nil, autherrors.ErrInvalidCBOR
+}
+
+// readHead reads the initial byte of a data item and its argument: the value of integers and simple values,
+// the length of strings, arrays and maps.
+func (d *cborDecoder) readHead() (major byte, info byte, arg uint64, err error) {
+	if len(d.data) == 0 {
+		return 0, 0, 0, autherrors.ErrInvalidCBOR
+	}
+
+	major, info = d.data[0]>>5, d.data[0]&0x1f
+	d.data = d.data[1:]
+
+	if info < 24 {
+		return major, info, uint64(info), nil
+	}
+	// 24 to 27 are followed by a 1, 2, 4 or 8 byte argument; the rest are reserved or indefinite lengths
+	if info > 27 {
+		return 0, 0, 0, autherrors.ErrInvalidCBOR
+	}
+
+	size := 1 << (info - 24)
+	if len(d.data) < size {
+		return 0, 0, 0, autherrors.ErrInvalidCBOR
+	}
+
+	var buf [8]byte
+	copy(buf[8-size:], d.data[:size])
+	d.data = d.data[size:]
+
+	return major, info, binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// readBytes reads the content of a string of the given length.
+func (d *cborDecoder) readBytes(length uint64) ([]byte, error) {
+	if length > uint64(len(d.data)) {
+		return nil, autherrors.ErrInvalidCBOR
+	}
+
+	content := d.data[:length]
+	d.data = d.data[length:]
+
+	return content, nil
+}
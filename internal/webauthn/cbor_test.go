@@ -0,0 +1,80 @@
+package webauthn
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/webauthn/webauthntest"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected any
+	}{
+		// Examples of RFC 8949, appendix A
+		{"small integer", []byte{0x17}, int64(23)},
+		{"one byte integer", []byte{0x18, 0x64}, int64(100)},
+		{"eight byte integer", []byte{0x1b, 0, 0, 0, 0xe8, 0xd4, 0xa5, 0x10, 0}, int64(1000000000000)},
+		{"negative integer", []byte{0x39, 0x03, 0xe7}, int64(-1000)},
+		{"byte string", []byte{0x44, 1, 2, 3, 4}, []byte{1, 2, 3, 4}},
+		{"text string", []byte{0x64, 0x49, 0x45, 0x54, 0x46}, "IETF"},
+		{"nested array", []byte{0x83, 0x01, 0x82, 0x02, 0x03, 0x82, 0x04, 0x05},
+			[]any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{"map", []byte{0xa2, 0x61, 0x61, 0x01, 0x20, 0xf5}, map[any]any{"a": int64(1), int64(-1): true}},
+		{"false", []byte{0xf4}, false},
+		{"null", []byte{0xf6}, nil},
+	}
+
+	for _, tt := range tests {
+		value, rest, err := decodeCBOR(append(tt.data, 0xff))
+
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.expected, value, tt.name)
+		assert.Equal(t, []byte{0xff}, rest, tt.name)
+	}
+}
+
+func TestDecodeCBORRoundTrip(t *testing.T) {
+	value := map[any]any{
+		"fmt":     "none",
+		"attStmt": map[any]any{},
+		"list":    []any{int64(-257), bytes.Repeat([]byte{7}, 300), int64(70000), int64(1) << 40},
+	}
+
+	decoded, rest, err := decodeCBOR(webauthntest.Encode(value))
+
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+	assert.Equal(t, value, decoded)
+}
+
+func TestDecodeCBORRejectsMalformedData(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":                   {},
+		"truncated argument":      {0x19, 0x01},
+		"truncated string":        {0x45, 1, 2},
+		"truncated array":         {0x82, 0x01},
+		"integer overflow":        {0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"indefinite length":       {0x5f, 0x41, 0x01, 0xff},
+		"tag":                     {0xc0, 0x01},
+		"float":                   {0xf9, 0x3c, 0x00},
+		"invalid UTF-8":           {0x62, 0xc3, 0x28},
+		"array key":               {0xa1, 0x80, 0x01},
+		"duplicate key":           {0xa2, 0x01, 0x01, 0x01, 0x02},
+		"oversized array length":  {0x9a, 0xff, 0xff, 0xff, 0xff},
+		"oversized map length":    {0xba, 0xff, 0xff, 0xff, 0xff},
+		"too deeply nested array": append(bytes.Repeat([]byte{0x81}, maxCBORDepth+1), 0x01),
+	}
+
+	for name, data := range tests {
+		_, _, err := decodeCBOR(data)
+
+		assert.ErrorIs(t, err, autherrors.ErrInvalidCBOR, name)
+	}
+}
@@ -0,0 +1,84 @@
+package webauthn
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// Types of the client data of both ceremonies.
+const (
+	clientDataTypeCreate = "webauthn.create"
+	clientDataTypeGet    = "webauthn.get"
+)
+
+// clientData is the JSON the browser passes to the authenticator (WebAuthn Level 3, section 5.8.1).
+// The authenticator signs its hash, binding the response to the challenge and the origin of the page.
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// ChallengeOf returns the challenge a response was created for, so the ceremony it belongs to can be looked up
+// before the response is verified.
+func ChallengeOf(clientDataJSON []byte) ([]byte, error) {
+	data, err := parseClientData(clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeChallenge(data.Challenge)
+}
+
+// parseClientData decodes the client data JSON.
+func parseClientData(clientDataJSON []byte) (*clientData, error) {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil {
+		return nil, autherrors.ErrPasskeyVerification("malformed client data")
+	}
+	return &data, nil
+}
+
+// decodeChallenge decodes a challenge of the client data, which browsers encode as base64url without padding.
+func decodeChallenge(challenge string) ([]byte, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(challenge)
+	if err != nil || len(decoded) == 0 {
+		return nil, autherrors.ErrPasskeyVerification("malformed challenge")
+	}
+	return decoded, nil
+}
+
+// verifyClientData checks that the client data belongs to a ceremony of the type with the challenge,
+// run by a page of one of the relying party's origins. Ceremonies in cross-origin iframes are rejected.
+func (rp *RelyingParty) verifyClientData(clientDataJSON []byte, ceremonyType string, challenge []byte) error {
+	data, err := parseClientData(clientDataJSON)
+	if err != nil {
+		return err
+	}
+
+	if data.Type != ceremonyType {
+		return autherrors.ErrPasskeyVerification("wrong client data type")
+	}
+
+	received, err := decodeChallenge(data.Challenge)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(received, challenge) != 1 {
+		return autherrors.ErrPasskeyVerification("challenge mismatch")
+	}
+
+	if !slices.Contains(rp.Origins, data.Origin) {
+		return autherrors.ErrPasskeyVerification("origin not allowed")
+	}
+	if data.CrossOrigin {
+		return autherrors.ErrPasskeyVerification("cross-origin ceremonies are not allowed")
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// COSE algorithms (RFC 9053) of the credential public keys accepted from authenticators.
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// Algorithms lists the accepted algorithms in order of preference, as offered in registration ceremonies.
+var Algorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key parameters (RFC 9052, section 7.1 and RFC 9053, section 7). The key type specific parameters
+// share labels: -1 is the curve of EC2 and OKP keys and the modulus of RSA keys, -2 the x coordinate
+// or the public exponent.
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+	coseCurve     = -1
+	coseX         = -2
+	coseY         = -3
+	coseModulus   = -1
+	coseExponent  = -2
+)
+
+// COSE key types and curves.
+const (
+	coseKeyTypeOKP  = 1
+	coseKeyTypeEC2  = 2
+	coseKeyTypeRSA  = 3
+	coseCurveP256   = 1
+	coseCurve25519  = 6
+	minRSAKeyBits   = 2048
+	p256CoordLength = 32
+)
+
+// publicKey is a credential public key decoded from its COSE_Key encoding.
+type publicKey struct {
+	algorithm int64
+	key       crypto.PublicKey
+}
+
+// parsePublicKey decodes a COSE_Key. Only the keys of the accepted algorithms are supported,
+// and the algorithm must match the key type and curve.
+func parsePublicKey(coseKey []byte) (*publicKey, error) {
+	value, rest, err := decodeCBOR(coseKey)
+	if err != nil || len(rest) > 0 {
+		return nil, autherrors.ErrPasskeyVerification("malformed public key")
+	}
+	params, ok := value.(map[any]any)
+	if !ok {
+		return nil, autherrors.ErrPasskeyVerification("malformed public key")
+	}
+
+	keyType, _ := params[int64(coseKeyType)].(int64)
+	algorithm, _ := params[int64(coseAlgorithm)].(int64)
+
+	switch {
+	case keyType == coseKeyTypeEC2 && algorithm == AlgES256:
+		curve, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+		y, _ := params[int64(coseY)].([]byte)
+		if curve != coseCurveP256 || len(x) != p256CoordLength || len(y) != p256CoordLength {
+			return nil, autherrors.ErrPasskeyVerification("malformed P-256 public key")
+		}
+		// crypto/ecdh rejects points that are not on the curve
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, autherrors.ErrPasskeyVerification("malformed P-256 public key")
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		return &publicKey{algorithm: algorithm, key: key}, nil
+
+	case keyType == coseKeyTypeOKP && algorithm == AlgEdDSA:
+		curve, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+		if curve != coseCurve25519 || len(x) != ed25519.PublicKeySize {
+			return nil, autherrors.ErrPasskeyVerification("malformed Ed25519 public key")
+		}
+		return &publicKey{algorithm: algorithm, key: ed25519.PublicKey(x)}, nil
+
+	case keyType == coseKeyTypeRSA && algorithm == AlgRS256:
+		modulus, _ := params[int64(coseModulus)].([]byte)
+		exponent, _ := params[int64(coseExponent)].([]byte)
+		n := new(big.Int).SetBytes(modulus)
+		e := new(big.Int).SetBytes(exponent)
+		if n.BitLen() < minRSAKeyBits || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, autherrors.ErrPasskeyVerification("malformed RSA public key")
+		}
+		return &publicKey{algorithm: algorithm, key: &rsa.PublicKey{N: n, E: int(e.Int64())}}, nil
+
+	default:
+		return nil, autherrors.ErrPasskeyVerification("unsupported public key algorithm")
+	}
+}
+
+// verify reports whether signature is a signature of data by the key.
+func (k *publicKey) verify(data []byte, signature []byte) bool {
+	return verifySignature(k.algorithm, k.key, data, signature)
+}
+
+// verifySignature reports whether signature is a signature of data with the COSE algorithm by the key.
+// ES256 signatures are ASN.1 DER encoded, as authenticators produce them.
+func verifySignature(algorithm int64, key crypto.PublicKey, data []byte, signature []byte) bool {
+	digest := sha256.Sum256(data)
+
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		return algorithm == AlgES256 && key.Curve == elliptic.P256() && ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		return algorithm == AlgEdDSA && ed25519.Verify(key, data, signature)
+	case *rsa.PublicKey:
+		return algorithm == AlgRS256 && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return false
+	}
+}
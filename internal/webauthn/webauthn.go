@@ -0,0 +1,163 @@
+// Package webauthn verifies the responses of authenticators in WebAuthn registration and authentication
+// ceremonies (WebAuthn Level 3), with which users sign in with passkeys instead of passwords.
+// Challenges are generated here, but storing them for the duration of a ceremony is left to the caller.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"slices"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+)
+
+// challengeSize is the number of random bytes of a challenge; the specification asks for at least 16.
+const challengeSize = 32
+
+// RelyingParty is this service as seen by authenticators. Passkeys are bound to its ID, a domain that must
+// be the domain of the origins or a parent of it, e.g. "example.com" for "https://login.example.com".
+type RelyingParty struct {
+	ID   string
+	Name string
+	// Origins are the origins of the pages allowed to run ceremonies, e.g. "https://login.example.com".
+	Origins []string
+}
+
+// Credential is a passkey registered with VerifyRegistration.
+type Credential struct {
+	ID []byte
+	// PublicKey is the COSE_Key encoding of the public key, to be stored and passed to VerifyAssertion.
+	PublicKey []byte
+	SignCount uint32
+	// AAGUID identifies the authenticator model; it is all zeros if the authenticator doesn't disclose it.
+	AAGUID []byte
+	// BackupEligible reports whether the passkey may be synced to other devices, BackedUp whether it is.
+	BackupEligible bool
+	BackedUp       bool
+}
+
+// Assertion is the result of a successful VerifyAssertion.
+type Assertion struct {
+	// SignCount is the authenticator's signature counter, to be compared with the stored one;
+	// authenticators without a counter always report 0.
+	SignCount    uint32
+	UserVerified bool
+	BackedUp     bool
+}
+
+// NewChallenge returns a new random challenge for a ceremony. It must be accepted only once.
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, challengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// VerifyRegistration checks the response of an authenticator to a registration ceremony with the challenge
+// (WebAuthn Level 3, section 7.1) and returns the new credential.
+// Returns an error matching autherrors.ErrInvalidPasskey if the response is invalid.
+func (rp *RelyingParty) VerifyRegistration(challenge []byte, clientDataJSON []byte, attestationObject []byte) (*Credential, error) {
+	if err := rp.verifyClientData(clientDataJSON, clientDataTypeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	value, rest, err := decodeCBOR(attestationObject)
+	if err != nil || len(rest) > 0 {
+		return nil, autherrors.ErrPasskeyVerification("malformed attestation object")
+	}
+	object, _ := value.(map[any]any)
+	format, hasFormat := object["fmt"].(string)
+	statement, hasStatement := object["attStmt"].(map[any]any)
+	rawAuthData, hasAuthData := object["authData"].([]byte)
+	if !hasFormat || !hasStatement || !hasAuthData {
+		return nil, autherrors.ErrPasskeyVerification("malformed attestation object")
+	}
+
+	authData, err := rp.verifyAuthenticatorData(rawAuthData, false)
+	if err != nil {
+		return nil, err
+	}
+	if !authData.has(flagAttestedCredentialData) {
+		return nil, autherrors.ErrPasskeyVerification("missing attested credential data")
+	}
+
+	credentialKey, err := parsePublicKey(authData.credentialPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if err := verifyAttestation(format, statement, authData, rawAuthData, clientDataHash[:], credentialKey); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:             slices.Clone(authData.credentialID),
+		PublicKey:      slices.Clone(authData.credentialPublicKey),
+		SignCount:      authData.signCount,
+		AAGUID:         slices.Clone(authData.aaguid),
+		BackupEligible: authData.has(flagBackupEligible),
+		BackedUp:       authData.has(flagBackedUp),
+	}, nil
+}
+
+// VerifyAssertion checks the response of an authenticator to an authentication ceremony with the challenge
+// (WebAuthn Level 3, section 7.2): that the passkey with the stored COSE public key signed the authenticator data
+// and the client data. Checking the signature counter and that the passkey belongs to the user signing in is left
+// to the caller. The user must have been verified by the authenticator, e.g. with a fingerprint or PIN,
+// if requireUserVerification is set; otherwise their presence suffices.
+// Returns an error matching autherrors.ErrInvalidPasskey if the response is invalid.
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, storedPublicKey []byte, clientDataJSON []byte,
+	rawAuthData []byte, signature []byte, requireUserVerification bool) (*Assertion, error) {
+	if err := rp.verifyClientData(clientDataJSON, clientDataTypeGet, challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := rp.verifyAuthenticatorData(rawAuthData, requireUserVerification)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialKey, err := parsePublicKey(storedPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if !credentialKey.verify(slices.Concat(rawAuthData, clientDataHash[:]), signature) {
+		return nil, autherrors.ErrPasskeyVerification("invalid signature")
+	}
+
+	return &Assertion{
+		SignCount:    authData.signCount,
+		UserVerified: authData.has(flagUserVerified),
+		BackedUp:     authData.has(flagBackedUp),
+	}, nil
+}
+
+// verifyAuthenticatorData parses the authenticator data and checks the parts shared by both ceremonies:
+// that it was created for the relying party, with the user present and, if required, verified.
+func (rp *RelyingParty) verifyAuthenticatorData(rawAuthData []byte, requireUserVerification bool) (*authenticatorData, error) {
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(authData.rpIDHash, rpIDHash[:]) {
+		return nil, autherrors.ErrPasskeyVerification("relying party ID mismatch")
+	}
+
+	switch {
+	case !authData.has(flagUserPresent):
+		return nil, autherrors.ErrPasskeyVerification("user not present")
+	case requireUserVerification && !authData.has(flagUserVerified):
+		return nil, autherrors.ErrPasskeyVerification("user not verified")
+	case authData.has(flagBackedUp) && !authData.has(flagBackupEligible):
+		return nil, autherrors.ErrPasskeyVerification("inconsistent backup flags")
+	}
+
+	return authData, nil
+}
@@ -0,0 +1,266 @@
+package webauthn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/webauthn/webauthntest"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://login.example.com"
+)
+
+type WebAuthnTestSuite struct {
+	suite.Suite
+	rp            *RelyingParty
+	authenticator *webauthntest.Authenticator
+	challenge     []byte
+}
+
+func (s *WebAuthnTestSuite) SetupTest() {
+	s.rp = &RelyingParty{ID: testRPID, Name: "Breakfront", Origins: []string{testOrigin}}
+
+	authenticator, err := webauthntest.New(testRPID, testOrigin)
+	require.NoError(s.T(), err)
+	s.authenticator = authenticator
+
+	challenge, err := NewChallenge()
+	require.NoError(s.T(), err)
+	s.challenge = challenge
+}
+
+// register registers the test authenticator's passkey and returns the stored credential.
+func (s *WebAuthnTestSuite) register() *Credential {
+	attestation, err := s.authenticator.Register(s.challenge, []byte("user-handle"))
+	require.NoError(s.T(), err)
+
+	credential, err := s.rp.VerifyRegistration(s.challenge, attestation.ClientDataJSON, attestation.AttestationObject)
+	require.NoError(s.T(), err)
+	return credential
+}
+
+// assertVerificationError checks that err is a passkey verification failure with the reason.
+func (s *WebAuthnTestSuite) assertVerificationError(err error, reason string) {
+	s.T().Helper()
+	require.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey)
+	assert.Contains(s.T(), err.Error(), reason)
+}
+
+func (s *WebAuthnTestSuite) TestNewChallenge() {
+	other, err := NewChallenge()
+	require.NoError(s.T(), err)
+
+	assert.Len(s.T(), s.challenge, challengeSize)
+	assert.NotEqual(s.T(), s.challenge, other)
+}
+
+func (s *WebAuthnTestSuite) TestVerifyRegistration() {
+	for _, algorithm := range []int64{webauthntest.AlgES256, webauthntest.AlgEdDSA, webauthntest.AlgRS256} {
+		for _, format := range []string{webauthntest.FormatNone, webauthntest.FormatPacked} {
+			authenticator, err := webauthntest.New(testRPID, testOrigin,
+				webauthntest.WithAlgorithm(algorithm), webauthntest.WithFormat(format))
+			require.NoError(s.T(), err)
+			authenticator.Synced = true
+
+			attestation, err := authenticator.Register(s.challenge, []byte("user-handle"))
+			require.NoError(s.T(), err)
+
+			credential, err := s.rp.VerifyRegistration(s.challenge, attestation.ClientDataJSON, attestation.AttestationObject)
+
+			require.NoError(s.T(), err, "algorithm %d, format %v", algorithm, format)
+			assert.Equal(s.T(), authenticator.CredentialID, credential.ID)
+			assert.Equal(s.T(), authenticator.PublicKey(), credential.PublicKey)
+			assert.Len(s.T(), credential.AAGUID, aaguidLength)
+			assert.Zero(s.T(), credential.SignCount)
+			assert.True(s.T(), credential.BackupEligible)
+			assert.True(s.T(), credential.BackedUp)
+		}
+	}
+}
+
+func (s *WebAuthnTestSuite) TestVerifyRegistrationRejectsClientData() {
+	attestation, err := s.authenticator.Register(s.challenge, nil)
+	require.NoError(s.T(), err)
+
+	other, err := NewChallenge()
+	require.NoError(s.T(), err)
+	_, err = s.rp.VerifyRegistration(other, attestation.ClientDataJSON, attestation.AttestationObject)
+	s.assertVerificationError(err, "challenge mismatch")
+
+	s.authenticator.Origin = "https://evil.example.org"
+	attestation, err = s.authenticator.Register(s.challenge, nil)
+	require.NoError(s.T(), err)
+	_, err = s.rp.VerifyRegistration(s.challenge, attestation.ClientDataJSON, attestation.AttestationObject)
+	s.assertVerificationError(err, "origin not allowed")
+
+	// An assertion's client data can't complete a registration
+	s.authenticator.Origin = testOrigin
+	assertion, err := s.authenticator.Assert(s.challenge)
+	require.NoError(s.T(), err)
+	_, err = s.rp.VerifyRegistration(s.challenge, assertion.ClientDataJSON, attestation.AttestationObject)
+	s.assertVerificationError(err, "wrong client data type")
+
+	_, err = s.rp.VerifyRegistration(s.challenge, []byte("{"), attestation.AttestationObject)
+	s.assertVerificationError(err, "malformed client data")
+}
+
+func (s *WebAuthnTestSuite) TestVerifyRegistrationRejectsOtherRelyingParty() {
+	s.authenticator.RPID = "evil.example.org"
+	attestation, err := s.authenticator.Register(s.challenge, nil)
+	require.NoError(s.T(), err)
+
+	_, err = s.rp.VerifyRegistration(s.challenge, attestation.ClientDataJSON, attestation.AttestationObject)
+
+	s.assertVerificationError(err, "relying party ID mismatch")
+}
+
+func (s *WebAuthnTestSuite) TestVerifyRegistrationRejectsAttestation() {
+	attestation, err := s.authenticator.Register(s.challenge, nil)
+	require.NoError(s.T(), err)
+
+	tests := []struct {
+		name   string
+		modify func(object map[any]any)
+		reason string
+	}{
+		{"unsupported format", func(object map[any]any) { object["fmt"] = "fido-u2f" }, "unsupported attestation format"},
+		{"none with statement", func(object map[any]any) { object["attStmt"] = map[any]any{"alg": -7} }, "malformed attestation statement"},
+		{"packed without signature", func(object map[any]any) {
+			object["fmt"] = "packed"
+			object["attStmt"] = map[any]any{"alg": -7}
+		}, "malformed attestation statement"},
+		{"packed with wrong signature", func(object map[any]any) {
+			object["fmt"] = "packed"
+			object["attStmt"] = map[any]any{"alg": -7, "sig": []byte("signature")}
+		}, "invalid attestation signature"},
+		{"packed with malformed certificate", func(object map[any]any) {
+			object["fmt"] = "packed"
+			object["attStmt"] = map[any]any{"alg": -7, "sig": []byte("signature"), "x5c": []any{[]byte("certificate")}}
+		}, "malformed attestation certificate"},
+		{"missing authenticator data", func(object map[any]any) { delete(object, "authData") }, "malformed attestation object"},
+		{"user not present", func(object map[any]any) {
+			authData := append([]byte{}, object["authData"].([]byte)...)
+			authData[32] &^= flagUserPresent
+			object["authData"] = authData
+		}, "user not present"},
+		{"missing credential", func(object map[any]any) {
+			authData := append([]byte{}, object["authData"].([]byte)[:authenticatorDataMinLength]...)
+			authData[32] &^= flagAttestedCredentialData
+			object["authData"] = authData
+		}, "missing attested credential data"},
+	}
+
+	for _, tt := range tests {
+		value, _, err := decodeCBOR(attestation.AttestationObject)
+		require.NoError(s.T(), err)
+		object := value.(map[any]any)
+		tt.modify(object)
+
+		_, err = s.rp.VerifyRegistration(s.challenge, attestation.ClientDataJSON, webauthntest.Encode(object))
+
+		s.assertVerificationError(err, tt.reason)
+	}
+}
+
+func (s *WebAuthnTestSuite) TestVerifyAssertion() {
+	credential := s.register()
+
+	for range 2 {
+		assertion, err := s.authenticator.Assert(s.challenge)
+		require.NoError(s.T(), err)
+
+		result, err := s.rp.VerifyAssertion(s.challenge, credential.PublicKey, assertion.ClientDataJSON,
+			assertion.AuthenticatorData, assertion.Signature, true)
+
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), s.authenticator.SignCount, result.SignCount)
+		assert.True(s.T(), result.UserVerified)
+	}
+}
+
+func (s *WebAuthnTestSuite) TestVerifyAssertionUserVerification() {
+	credential := s.register()
+	s.authenticator.UserVerified = false
+
+	assertion, err := s.authenticator.Assert(s.challenge)
+	require.NoError(s.T(), err)
+
+	_, err = s.rp.VerifyAssertion(s.challenge, credential.PublicKey, assertion.ClientDataJSON,
+		assertion.AuthenticatorData, assertion.Signature, true)
+	s.assertVerificationError(err, "user not verified")
+
+	result, err := s.rp.VerifyAssertion(s.challenge, credential.PublicKey, assertion.ClientDataJSON,
+		assertion.AuthenticatorData, assertion.Signature, false)
+	require.NoError(s.T(), err)
+	assert.False(s.T(), result.UserVerified)
+}
+
+func (s *WebAuthnTestSuite) TestVerifyAssertionRejectsForgeries() {
+	credential := s.register()
+	assertion, err := s.authenticator.Assert(s.challenge)
+	require.NoError(s.T(), err)
+
+	// Signed by another passkey
+	other, err := webauthntest.New(testRPID, testOrigin)
+	require.NoError(s.T(), err)
+	_, err = s.rp.VerifyAssertion(s.challenge, other.PublicKey(), assertion.ClientDataJSON,
+		assertion.AuthenticatorData, assertion.Signature, true)
+	s.assertVerificationError(err, "invalid signature")
+
+	// Authenticator data changed after signing
+	authData := append([]byte{}, assertion.AuthenticatorData...)
+	authData[36]++
+	_, err = s.rp.VerifyAssertion(s.challenge, credential.PublicKey, assertion.ClientDataJSON,
+		authData, assertion.Signature, true)
+	s.assertVerificationError(err, "invalid signature")
+
+	_, err = s.rp.VerifyAssertion(s.challenge, credential.PublicKey, assertion.ClientDataJSON,
+		append(assertion.AuthenticatorData, 0), assertion.Signature, true)
+	s.assertVerificationError(err, "trailing bytes")
+
+	// A registration's client data can't complete an authentication
+	attestation, err := s.authenticator.Register(s.challenge, nil)
+	require.NoError(s.T(), err)
+	_, err = s.rp.VerifyAssertion(s.challenge, credential.PublicKey, attestation.ClientDataJSON,
+		assertion.AuthenticatorData, assertion.Signature, true)
+	s.assertVerificationError(err, "wrong client data type")
+}
+
+func (s *WebAuthnTestSuite) TestChallengeOf() {
+	assertion, err := s.authenticator.Assert(s.challenge)
+	require.NoError(s.T(), err)
+
+	challenge, err := ChallengeOf(assertion.ClientDataJSON)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.challenge, challenge)
+
+	_, err = ChallengeOf([]byte(`{"type":"webauthn.get","challenge":"***"}`))
+	s.assertVerificationError(err, "malformed challenge")
+}
+
+func (s *WebAuthnTestSuite) TestParsePublicKeyRejectsMalformedKeys() {
+	tests := map[string]map[any]any{
+		"unsupported algorithm": {1: 2, 3: -35, -1: 2, -2: make([]byte, 48), -3: make([]byte, 48)},
+		"mismatched key type":   {1: 1, 3: -7, -1: 1, -2: make([]byte, 32), -3: make([]byte, 32)},
+		"point not on curve":    {1: 2, 3: -7, -1: 1, -2: make([]byte, 32), -3: make([]byte, 32)},
+		"short Ed25519 key":     {1: 1, 3: -8, -1: 6, -2: make([]byte, 31)},
+		"small RSA key":         {1: 3, 3: -257, -1: make([]byte, 128), -2: []byte{1, 0, 1}},
+	}
+
+	for name, key := range tests {
+		_, err := parsePublicKey(webauthntest.Encode(key))
+
+		assert.ErrorIs(s.T(), err, autherrors.ErrInvalidPasskey, name)
+	}
+}
+
+func TestWebAuthnTestSuite(t *testing.T) {
+	suite.Run(t, new(WebAuthnTestSuite))
+}
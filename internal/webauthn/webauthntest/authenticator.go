@@ -0,0 +1,237 @@
+// Package webauthntest provides a software authenticator for testing WebAuthn ceremonies
+// without a browser or a security key.
+package webauthntest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithms the authenticator can create passkeys with.
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// Attestation formats of registrations. Packed attestations are self attestations, signed with the new passkey.
+const (
+	FormatNone   = "none"
+	FormatPacked = "packed"
+)
+
+// Flags of the authenticator data.
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagBackupEligible         = 0x08
+	flagBackedUp               = 0x10
+	flagAttestedCredentialData = 0x40
+)
+
+// Authenticator holds a single passkey. Its exported fields may be changed between ceremonies,
+// e.g. to answer for another origin or without user verification.
+type Authenticator struct {
+	RPID   string
+	Origin string
+	// CredentialID identifies the passkey; it is random.
+	CredentialID []byte
+	// UserHandle is the user ID the passkey was registered for, returned with assertions.
+	UserHandle []byte
+	// SignCount is the signature counter, incremented before every assertion if CountsSignatures is set.
+	SignCount        uint32
+	CountsSignatures bool
+	UserVerified     bool
+	// Synced makes the passkey backup eligible and backed up, like passkeys synced by a platform.
+	Synced bool
+	Format string
+
+	algorithm int64
+	key       crypto.Signer
+}
+
+// Option is a function that modifies the Authenticator configuration.
+type Option func(*Authenticator)
+
+// WithAlgorithm creates the passkey with the COSE algorithm instead of ES256.
+func WithAlgorithm(algorithm int64) Option {
+	return func(a *Authenticator) {
+		a.algorithm = algorithm
+	}
+}
+
+// WithFormat registers the passkey with the attestation format instead of "none".
+func WithFormat(format string) Option {
+	return func(a *Authenticator) {
+		a.Format = format
+	}
+}
+
+// Attestation is the response to a registration ceremony, as the browser passes it on.
+type Attestation struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// Assertion is the response to an authentication ceremony, as the browser passes it on.
+type Assertion struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+}
+
+// New creates an authenticator with a new passkey for the relying party, answering for the origin.
+// It verifies the user and counts signatures unless changed.
+func New(rpID string, origin string, opts ...Option) (*Authenticator, error) {
+	a := &Authenticator{
+		RPID:             rpID,
+		Origin:           origin,
+		CountsSignatures: true,
+		UserVerified:     true,
+		Format:           FormatNone,
+		algorithm:        AlgES256,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	a.CredentialID = make([]byte, 16)
+	if _, err := rand.Read(a.CredentialID); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch a.algorithm {
+	case AlgES256:
+		a.key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, a.key, err = ed25519.GenerateKey(rand.Reader)
+	case AlgRS256:
+		a.key, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		err = fmt.Errorf("unsupported algorithm %d", a.algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// PublicKey returns the COSE_Key encoding of the passkey's public key.
+func (a *Authenticator) PublicKey() []byte {
+	switch key := a.key.Public().(type) {
+	case *ecdsa.PublicKey:
+		point, _ := key.ECDH()
+		coordinates := point.Bytes()[1:]
+		return Encode(cborMap{{1, 2}, {3, a.algorithm}, {-1, 1}, {-2, coordinates[:32]}, {-3, coordinates[32:]}})
+	case ed25519.PublicKey:
+		return Encode(cborMap{{1, 1}, {3, a.algorithm}, {-1, 6}, {-2, []byte(key)}})
+	case *rsa.PublicKey:
+		return Encode(cborMap{{1, 3}, {3, a.algorithm}, {-1, key.N.Bytes()}, {-2, big.NewInt(int64(key.E)).Bytes()}})
+	default:
+		return nil
+	}
+}
+
+// Register answers a registration ceremony with the challenge for the user with the handle.
+func (a *Authenticator) Register(challenge []byte, userHandle []byte) (*Attestation, error) {
+	a.UserHandle = userHandle
+	clientDataJSON := a.clientData("webauthn.create", challenge)
+
+	attestedCredentialData := make([]byte, 16, 18+len(a.CredentialID))
+	attestedCredentialData = binary.BigEndian.AppendUint16(attestedCredentialData, uint16(len(a.CredentialID)))
+	attestedCredentialData = append(attestedCredentialData, a.CredentialID...)
+	attestedCredentialData = append(attestedCredentialData, a.PublicKey()...)
+	authData := a.authenticatorData(flagAttestedCredentialData, attestedCredentialData)
+
+	statement := cborMap{}
+	if a.Format == FormatPacked {
+		signature, err := a.sign(authData, clientDataJSON)
+		if err != nil {
+			return nil, err
+		}
+		statement = cborMap{{"alg", a.algorithm}, {"sig", signature}}
+	}
+
+	return &Attestation{
+		CredentialID:      a.CredentialID,
+		ClientDataJSON:    clientDataJSON,
+		AttestationObject: Encode(cborMap{{"fmt", a.Format}, {"attStmt", statement}, {"authData", authData}}),
+	}, nil
+}
+
+// Assert answers an authentication ceremony with the challenge.
+func (a *Authenticator) Assert(challenge []byte) (*Assertion, error) {
+	if a.CountsSignatures {
+		a.SignCount++
+	}
+	clientDataJSON := a.clientData("webauthn.get", challenge)
+	authData := a.authenticatorData(0, nil)
+
+	signature, err := a.sign(authData, clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Assertion{
+		CredentialID:      a.CredentialID,
+		ClientDataJSON:    clientDataJSON,
+		AuthenticatorData: authData,
+		Signature:         signature,
+		UserHandle:        a.UserHandle,
+	}, nil
+}
+
+// clientData returns the client data JSON a browser would create for the ceremony.
+func (a *Authenticator) clientData(ceremonyType string, challenge []byte) []byte {
+	clientDataJSON, _ := json.Marshal(map[string]any{
+		"type":        ceremonyType,
+		"challenge":   base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+	return clientDataJSON
+}
+
+// authenticatorData returns the authenticator data with the flags of the authenticator and extra flags,
+// followed by the data.
+func (a *Authenticator) authenticatorData(flags byte, data []byte) []byte {
+	flags |= flagUserPresent
+	if a.UserVerified {
+		flags |= flagUserVerified
+	}
+	if a.Synced {
+		flags |= flagBackupEligible | flagBackedUp
+	}
+
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	authData := append(rpIDHash[:], flags)
+	authData = binary.BigEndian.AppendUint32(authData, a.SignCount)
+	return append(authData, data...)
+}
+
+// sign signs the authenticator data and the hash of the client data with the passkey.
+func (a *Authenticator) sign(authData []byte, clientDataJSON []byte) ([]byte, error) {
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, authData...), clientDataHash[:]...)
+
+	if a.algorithm == AlgEdDSA {
+		return a.key.Sign(rand.Reader, signed, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(signed)
+	return a.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
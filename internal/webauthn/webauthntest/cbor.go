@@ -0,0 +1,71 @@
+package webauthntest
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// cborMap is a CBOR map whose entries are encoded in the given order, so the canonical order
+// authenticators use can be kept.
+type cborMap []cborEntry
+
+type cborEntry struct {
+	key   any
+	value any
+}
+
+// Encode returns the CBOR encoding of a value built from int, int64, []byte, string, []any and maps
+// as the ones decoded by the webauthn package, for tests crafting their own attestation objects.
+// Maps are encoded in no particular order. It panics on other types.
+func Encode(value any) []byte {
+	switch value := value.(type) {
+	case map[any]any:
+		entries := make(cborMap, 0, len(value))
+		for key, entry := range value {
+			entries = append(entries, cborEntry{key, entry})
+		}
+		return Encode(entries)
+	case int:
+		return Encode(int64(value))
+	case int64:
+		if value < 0 {
+			return encodeHead(1, uint64(-1-value))
+		}
+		return encodeHead(0, uint64(value))
+	case []byte:
+		return append(encodeHead(2, uint64(len(value))), value...)
+	case string:
+		return append(encodeHead(3, uint64(len(value))), value...)
+	case []any:
+		encoded := encodeHead(4, uint64(len(value)))
+		for _, item := range value {
+			encoded = append(encoded, Encode(item)...)
+		}
+		return encoded
+	case cborMap:
+		encoded := encodeHead(5, uint64(len(value)))
+		for _, entry := range value {
+			encoded = append(encoded, Encode(entry.key)...)
+			encoded = append(encoded, Encode(entry.value)...)
+		}
+		return encoded
+	default:
+		panic(fmt.Sprintf("webauthntest: can't encode %T", value))
+	}
+}
+
+// encodeHead returns the initial byte of a data item of the major type with its argument, in the shortest form.
+func encodeHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, arg)
+	}
+}
@@ -0,0 +1,87 @@
+// Package authmw provides access token verification for Breakfront services.
+// It offers net/http middleware and gRPC interceptors that extract the Bearer token,
+// validate it and store the parsed token in the request context.
+package authmw
+
+import (
+	"errors"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/validators"
+)
+
+// ParsedToken holds the verified token claims: user ID, token type and expiration.
+type ParsedToken = models.ParsedToken
+
+// User and UserFilter are the types used by IUserService implementations.
+type (
+	User       = models.User
+	UserFilter = models.UserFilter
+)
+
+// ValidationOption modifies the checks performed by a Validator.
+type ValidationOption = validators.ValidationOption
+
+// IUserService defines the user lookup used by the optional user existence check.
+type IUserService = validators.IUserService
+
+// Errors reported by the middleware and interceptors.
+var (
+	ErrMissingAuthHeader   = autherrors.ErrMissingAuthHeader
+	ErrMalformedAuthHeader = autherrors.ErrMalformedAuthHeader
+	ErrTokenParseFailed    = autherrors.ErrTokenParseFailed
+	ErrTokenExpired        = autherrors.ErrTokenExpired
+	ErrTokenType           = autherrors.ErrTokenType
+	ErrUserNotExist        = autherrors.ErrUserNotExist
+	ErrTokenRevoked        = autherrors.ErrTokenRevoked
+	ErrTokenIssuer         = autherrors.ErrTokenIssuer
+	ErrTokenAudience       = autherrors.ErrTokenAudience
+	ErrTokenDelegated      = autherrors.ErrTokenDelegated
+	ErrMissingRole         = autherrors.ErrMissingRole
+	ErrMissingPermission   = autherrors.ErrMissingPermission
+)
+
+// Validator validates raw token values with the given options.
+type Validator interface {
+	Validate(tokenValue string, opts ...ValidationOption) (*ParsedToken, error)
+}
+
+// NewValidator creates a Validator for tokens signed with HS256 and the given secret.
+// The userService is only used when the user existence check is enabled and may be nil otherwise.
+func NewValidator(secret string, userService IUserService) Validator {
+	return validators.NewTokenValidator(jwt.NewManager(secret, 0, 0, jwt.WithLeeway(jwt.DefaultLeeway)), userService, nil)
+}
+
+// NewPublicKeyValidator creates a Validator for tokens signed with an asymmetric algorithm
+// (RS256, ES256, EdDSA, ...), verifying them with the PEM encoded public key of the auth service.
+func NewPublicKeyValidator(algorithm string, publicKeyPEM []byte, userService IUserService) (Validator, error) {
+	key, err := jwt.ParsePublicKey(algorithm, publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return validators.NewTokenValidator(jwt.NewManagerWithKey(key, 0, 0, jwt.WithLeeway(jwt.DefaultLeeway)), userService, nil), nil
+}
+
+// IsUnauthenticated reports whether err means the caller presented a missing or invalid token,
+// as opposed to an internal failure such as an unavailable user store.
+func IsUnauthenticated(err error) bool {
+	return errors.Is(err, autherrors.ErrMissingAuthHeader) ||
+		errors.Is(err, autherrors.ErrMalformedAuthHeader) ||
+		errors.Is(err, autherrors.ErrTokenParseFailed) ||
+		errors.Is(err, autherrors.ErrTokenExpired) ||
+		errors.Is(err, autherrors.ErrTokenType) ||
+		errors.Is(err, autherrors.ErrTokenIssuer) ||
+		errors.Is(err, autherrors.ErrTokenAudience) ||
+		errors.Is(err, autherrors.ErrTokenDelegated) ||
+		errors.Is(err, autherrors.ErrUserNotExist) ||
+		errors.Is(err, autherrors.ErrTokenRevoked)
+}
+
+// IsForbidden reports whether err means the caller presented a valid token
+// that lacks a required role or permission.
+func IsForbidden(err error) bool {
+	return errors.Is(err, autherrors.ErrMissingRole) ||
+		errors.Is(err, autherrors.ErrMissingPermission)
+}
@@ -0,0 +1,39 @@
+package authmw
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the parsed token.
+func NewContext(ctx context.Context, token *ParsedToken) context.Context {
+	return context.WithValue(ctx, contextKey{}, token)
+}
+
+// TokenFromContext returns the parsed token stored by the middleware, if any.
+func TokenFromContext(ctx context.Context) (*ParsedToken, bool) {
+	token, ok := ctx.Value(contextKey{}).(*ParsedToken)
+	return token, ok && token != nil
+}
+
+// UserIDFromContext returns the ID of the authenticated user, if any.
+// Requests authenticated with a client token have no user.
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	token, ok := TokenFromContext(ctx)
+	if !ok || token.ClientID != "" {
+		return uuid.Nil, false
+	}
+	return token.UserID, true
+}
+
+// ClientIDFromContext returns the ID of the client calling on its own behalf, if any.
+func ClientIDFromContext(ctx context.Context) (string, bool) {
+	token, ok := TokenFromContext(ctx)
+	if !ok || token.ClientID == "" {
+		return "", false
+	}
+	return token.ClientID, true
+}
@@ -0,0 +1,90 @@
+package authmw
+
+import (
+	"context"
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCErrorHandler converts a failed token verification into the error returned to the client.
+type GRPCErrorHandler func(ctx context.Context, err error) error
+
+// UnaryServerInterceptor returns a gRPC unary interceptor that requires a valid access token
+// in the "authorization" metadata and stores the parsed token in the handler context.
+func UnaryServerInterceptor(validator Validator, opts ...Option) grpc.UnaryServerInterceptor {
+	cfg := newConfig(opts)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if cfg.publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		parsedToken, err := cfg.verify(validator, authorizationFromMetadata(ctx))
+		if err != nil {
+			return nil, cfg.grpcErrorHandler(ctx, err)
+		}
+
+		return handler(NewContext(ctx, parsedToken), req)
+	}
+}
+
+// StreamServerInterceptor returns a gRPC stream interceptor that requires a valid access token
+// in the "authorization" metadata and stores the parsed token in the stream context.
+func StreamServerInterceptor(validator Validator, opts ...Option) grpc.StreamServerInterceptor {
+	cfg := newConfig(opts)
+
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if cfg.publicMethods[info.FullMethod] {
+			return handler(srv, stream)
+		}
+
+		ctx := stream.Context()
+		parsedToken, err := cfg.verify(validator, authorizationFromMetadata(ctx))
+		if err != nil {
+			return cfg.grpcErrorHandler(ctx, err)
+		}
+
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: NewContext(ctx, parsedToken)})
+	}
+}
+
+// DefaultGRPCErrorHandler returns Unauthenticated for invalid tokens, PermissionDenied for tokens lacking
+// a required role or permission and Internal for internal failures.
+func DefaultGRPCErrorHandler(_ context.Context, err error) error {
+	if IsUnauthenticated(err) {
+		return status.Error(codes.Unauthenticated, "invalid or expired token")
+	}
+	if IsForbidden(err) {
+		return status.Error(codes.PermissionDenied, "insufficient permissions")
+	}
+
+	log.Printf("token verification failed: %v", err)
+	return status.Error(codes.Internal, "internal server error")
+}
+
+func authorizationFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// authenticatedStream overrides the stream context with one carrying the parsed token.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
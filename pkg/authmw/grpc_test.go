@@ -0,0 +1,168 @@
+package authmw
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+	"github.com/breakfront-planner/auth-service/internal/models"
+)
+
+const testMethod = "/breakfront.test.v1.TestService/Call"
+
+type InterceptorTestSuite struct {
+	suite.Suite
+	validator   Validator
+	testUser    *models.User
+	accessToken string
+}
+
+func (s *InterceptorTestSuite) SetupSuite() {
+	err := godotenv.Load("../../.env.test")
+	require.NoError(s.T(), err, "Failed to load .env.test")
+
+	jwtSecret := os.Getenv("TEST_JWT_SECRET")
+	require.NotEmpty(s.T(), jwtSecret, "TEST_JWT_SECRET must be set")
+
+	jwtManager := jwt.NewManager(jwtSecret, 10*time.Minute, time.Hour)
+	s.validator = NewValidator(jwtSecret, nil)
+	s.testUser = &models.User{ID: uuid.New()}
+
+	accessToken, err := jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+	s.accessToken = accessToken.Value
+}
+
+func (s *InterceptorTestSuite) incomingContext(authHeader string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", authHeader))
+}
+
+func (s *InterceptorTestSuite) TestUnaryValidToken() {
+	interceptor := UnaryServerInterceptor(s.validator)
+
+	resp, err := interceptor(s.incomingContext("Bearer "+s.accessToken), "req", &grpc.UnaryServerInfo{FullMethod: testMethod},
+		func(ctx context.Context, _ any) (any, error) {
+			userID, _ := UserIDFromContext(ctx)
+			return userID, nil
+		})
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), s.testUser.ID, resp)
+}
+
+func (s *InterceptorTestSuite) TestUnaryInvalidToken() {
+	interceptor := UnaryServerInterceptor(s.validator)
+	handlerCalled := false
+
+	_, err := interceptor(s.incomingContext("Bearer invalid.jwt.token"), "req", &grpc.UnaryServerInfo{FullMethod: testMethod},
+		func(context.Context, any) (any, error) {
+			handlerCalled = true
+			return nil, nil
+		})
+
+	assert.Equal(s.T(), codes.Unauthenticated, status.Code(err))
+	assert.False(s.T(), handlerCalled)
+}
+
+func (s *InterceptorTestSuite) TestUnaryMissingMetadata() {
+	interceptor := UnaryServerInterceptor(s.validator)
+
+	_, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: testMethod},
+		func(context.Context, any) (any, error) {
+			return nil, nil
+		})
+
+	assert.Equal(s.T(), codes.Unauthenticated, status.Code(err))
+}
+
+func (s *InterceptorTestSuite) TestUnaryMissingRole() {
+	interceptor := UnaryServerInterceptor(s.validator, WithRequiredRole("admin"))
+	handlerCalled := false
+
+	_, err := interceptor(s.incomingContext("Bearer "+s.accessToken), "req", &grpc.UnaryServerInfo{FullMethod: testMethod},
+		func(context.Context, any) (any, error) {
+			handlerCalled = true
+			return nil, nil
+		})
+
+	assert.Equal(s.T(), codes.PermissionDenied, status.Code(err))
+	assert.False(s.T(), handlerCalled)
+}
+
+func (s *InterceptorTestSuite) TestUnaryPublicMethod() {
+	interceptor := UnaryServerInterceptor(s.validator, WithPublicMethods(testMethod))
+
+	resp, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: testMethod},
+		func(context.Context, any) (any, error) {
+			return "ok", nil
+		})
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "ok", resp)
+}
+
+func (s *InterceptorTestSuite) TestUnaryCustomErrorHandler() {
+	interceptor := UnaryServerInterceptor(s.validator, WithGRPCErrorHandler(func(context.Context, error) error {
+		return status.Error(codes.PermissionDenied, "denied")
+	}))
+
+	_, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: testMethod},
+		func(context.Context, any) (any, error) {
+			return nil, nil
+		})
+
+	assert.Equal(s.T(), codes.PermissionDenied, status.Code(err))
+}
+
+func (s *InterceptorTestSuite) TestStreamValidToken() {
+	interceptor := StreamServerInterceptor(s.validator)
+	stream := &testServerStream{ctx: s.incomingContext("Bearer " + s.accessToken)}
+
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: testMethod},
+		func(_ any, stream grpc.ServerStream) error {
+			parsedToken, ok := TokenFromContext(stream.Context())
+			require.True(s.T(), ok)
+			assert.Equal(s.T(), s.testUser.ID, parsedToken.UserID)
+			return nil
+		})
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *InterceptorTestSuite) TestStreamInvalidToken() {
+	interceptor := StreamServerInterceptor(s.validator)
+	stream := &testServerStream{ctx: s.incomingContext("Token abc")}
+
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: testMethod},
+		func(any, grpc.ServerStream) error {
+			return nil
+		})
+
+	assert.Equal(s.T(), codes.Unauthenticated, status.Code(err))
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestInterceptorTestSuite(t *testing.T) {
+	suite.Run(t, new(InterceptorTestSuite))
+}
@@ -0,0 +1,76 @@
+package authmw
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/constants"
+)
+
+// HTTPErrorHandler writes the response for a request that failed token verification.
+type HTTPErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// ExtractBearerToken returns the token from an "Authorization: Bearer <token>" header value.
+func ExtractBearerToken(authHeader string) (string, error) {
+	if authHeader == "" {
+		return "", autherrors.ErrMissingAuthHeader
+	}
+
+	scheme, token, found := strings.Cut(authHeader, " ")
+	if !found || !strings.EqualFold(scheme, constants.BearerScheme) {
+		return "", autherrors.ErrMalformedAuthHeader
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", autherrors.ErrMalformedAuthHeader
+	}
+
+	return token, nil
+}
+
+// Middleware returns net/http middleware that requires a valid access token
+// and stores the parsed token in the request context.
+func Middleware(validator Validator, opts ...Option) func(http.Handler) http.Handler {
+	cfg := newConfig(opts)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parsedToken, err := cfg.verify(validator, r.Header.Get("Authorization"))
+			if err != nil {
+				cfg.httpErrorHandler(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), parsedToken)))
+		})
+	}
+}
+
+// DefaultHTTPErrorHandler responds with 401 and a Bearer challenge for invalid tokens,
+// with 403 for tokens lacking a required role or permission and with 500 for internal failures.
+func DefaultHTTPErrorHandler(w http.ResponseWriter, _ *http.Request, err error) {
+	status := http.StatusUnauthorized
+	message := "invalid or expired token"
+
+	switch {
+	case IsUnauthenticated(err):
+		w.Header().Set("WWW-Authenticate", constants.BearerScheme)
+	case IsForbidden(err):
+		status = http.StatusForbidden
+		message = "insufficient permissions"
+	default:
+		log.Printf("token verification failed: %v", err)
+		status = http.StatusInternalServerError
+		message = "internal server error"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,376 @@
+package authmw
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+	"github.com/breakfront-planner/auth-service/internal/models"
+	"github.com/breakfront-planner/auth-service/internal/validators/mocks"
+)
+
+type MiddlewareTestSuite struct {
+	suite.Suite
+	ctrl            *gomock.Controller
+	mockUserService *mocks.MockIUserService
+	jwtManager      *jwt.Manager
+	validator       Validator
+	testUser        *models.User
+	accessToken     string
+	refreshToken    string
+	expiredToken    string
+}
+
+func (s *MiddlewareTestSuite) SetupSuite() {
+	err := godotenv.Load("../../.env.test")
+	require.NoError(s.T(), err, "Failed to load .env.test")
+
+	jwtSecret := os.Getenv("TEST_JWT_SECRET")
+	require.NotEmpty(s.T(), jwtSecret, "TEST_JWT_SECRET must be set")
+
+	s.jwtManager = jwt.NewManager(jwtSecret, 10*time.Minute, time.Hour)
+	s.testUser = &models.User{
+		ID:    uuid.New(),
+		Login: "testuser",
+	}
+
+	accessToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+	s.accessToken = accessToken.Value
+
+	refreshToken, err := s.jwtManager.GenerateToken(s.testUser, constants.TokenTypeRefresh)
+	require.NoError(s.T(), err)
+	s.refreshToken = refreshToken.Value
+
+	expiredManager := jwt.NewManager(jwtSecret, -time.Hour, -time.Hour)
+	expiredToken, err := expiredManager.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+	s.expiredToken = expiredToken.Value
+}
+
+func (s *MiddlewareTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockUserService = mocks.NewMockIUserService(s.ctrl)
+	s.validator = NewValidator(os.Getenv("TEST_JWT_SECRET"), s.mockUserService)
+}
+
+func (s *MiddlewareTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *MiddlewareTestSuite) serve(authHeader string, opts ...Option) (*httptest.ResponseRecorder, *ParsedToken) {
+	var seen *ParsedToken
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = TokenFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	rec := httptest.NewRecorder()
+
+	Middleware(s.validator, opts...)(next).ServeHTTP(rec, req)
+
+	return rec, seen
+}
+
+func (s *MiddlewareTestSuite) TestValidTokenPassesThrough() {
+	rec, parsedToken := s.serve("Bearer " + s.accessToken)
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	require.NotNil(s.T(), parsedToken)
+	assert.Equal(s.T(), s.testUser.ID, parsedToken.UserID)
+	assert.Equal(s.T(), string(constants.TokenTypeAccess), parsedToken.Type)
+}
+
+func (s *MiddlewareTestSuite) TestInvalidTokensRejected() {
+	testCases := []struct {
+		name       string
+		authHeader string
+	}{
+		{name: "missing header", authHeader: ""},
+		{name: "wrong scheme", authHeader: "Basic " + s.accessToken},
+		{name: "empty token", authHeader: "Bearer "},
+		{name: "malformed token", authHeader: "Bearer invalid.jwt.token"},
+		{name: "refresh token", authHeader: "Bearer " + s.refreshToken},
+		{name: "expired token", authHeader: "Bearer " + s.expiredToken},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			rec, parsedToken := s.serve(tc.authHeader)
+
+			assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+			assert.Equal(s.T(), constants.BearerScheme, rec.Header().Get("WWW-Authenticate"))
+			assert.Nil(s.T(), parsedToken)
+		})
+	}
+}
+
+func (s *MiddlewareTestSuite) TestUserExistenceCheck() {
+	s.mockUserService.EXPECT().
+		FindUser(gomock.Any()).
+		Return(s.testUser, nil)
+
+	rec, parsedToken := s.serve("Bearer "+s.accessToken, WithUserExistenceCheck())
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.NotNil(s.T(), parsedToken)
+}
+
+func (s *MiddlewareTestSuite) TestUserExistenceCheckUserDeleted() {
+	s.mockUserService.EXPECT().
+		FindUser(gomock.Any()).
+		Return(nil, nil)
+
+	rec, _ := s.serve("Bearer "+s.accessToken, WithUserExistenceCheck())
+
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+}
+
+func (s *MiddlewareTestSuite) TestUserExistenceCheckStorageError() {
+	s.mockUserService.EXPECT().
+		FindUser(gomock.Any()).
+		Return(nil, errors.New("database error"))
+
+	rec, _ := s.serve("Bearer "+s.accessToken, WithUserExistenceCheck())
+
+	assert.Equal(s.T(), http.StatusInternalServerError, rec.Code)
+	assert.NotContains(s.T(), rec.Body.String(), "database error")
+}
+
+func (s *MiddlewareTestSuite) TestAudienceAndIssuer() {
+	issuer := jwt.NewManager(os.Getenv("TEST_JWT_SECRET"), time.Minute, time.Hour,
+		jwt.WithIssuer("https://auth.breakfront.test"), jwt.WithAudience("planner"))
+	accessToken, err := issuer.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	rec, parsedToken := s.serve("Bearer "+accessToken.Value,
+		WithAudience("planner"), WithIssuer("https://auth.breakfront.test"))
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	require.NotNil(s.T(), parsedToken)
+	assert.Equal(s.T(), []string{"planner"}, parsedToken.Audience)
+
+	rec, _ = s.serve("Bearer "+accessToken.Value, WithAudience("notifications"))
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code, "tokens minted for another service must be rejected")
+
+	rec, _ = s.serve("Bearer "+accessToken.Value, WithIssuer("https://other.test"))
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+
+	rec, _ = s.serve("Bearer "+s.accessToken, WithAudience("planner"))
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code, "tokens without an audience must be rejected")
+}
+
+func (s *MiddlewareTestSuite) TestRequiredRoleAndPermission() {
+	admin := &models.User{
+		ID:          s.testUser.ID,
+		Roles:       []string{"admin"},
+		Permissions: []string{"users:manage"},
+	}
+	accessToken, err := s.jwtManager.GenerateToken(admin, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	rec, parsedToken := s.serve("Bearer "+accessToken.Value, WithRequiredRole("admin"), WithRequiredPermission("users:manage"))
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	require.NotNil(s.T(), parsedToken)
+	assert.Equal(s.T(), []string{"admin"}, parsedToken.Roles)
+
+	rec, _ = s.serve("Bearer "+s.accessToken, WithRequiredRole("admin"))
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code)
+	assert.Empty(s.T(), rec.Header().Get("WWW-Authenticate"))
+
+	rec, _ = s.serve("Bearer "+accessToken.Value, WithRequiredPermission("plans:write"))
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code)
+}
+
+func (s *MiddlewareTestSuite) TestClientTokens() {
+	clientToken, err := s.jwtManager.GenerateClientToken("nightly-export", []string{"plans:read"})
+	require.NoError(s.T(), err)
+
+	rec, _ := s.serve("Bearer " + clientToken.Value)
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code, "client tokens must be enabled explicitly")
+
+	var clientID string
+	var hasUser bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, _ = ClientIDFromContext(r.Context())
+		_, hasUser = UserIDFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+clientToken.Value)
+	rec = httptest.NewRecorder()
+
+	Middleware(s.validator, WithClientTokens(), WithUserExistenceCheck(), WithRequiredPermission("plans:read"))(next).
+		ServeHTTP(rec, req)
+
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Equal(s.T(), "nightly-export", clientID)
+	assert.False(s.T(), hasUser)
+
+	rec, _ = s.serve("Bearer "+clientToken.Value, WithClientTokens(), WithRequiredPermission("plans:write"))
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code)
+}
+
+func (s *MiddlewareTestSuite) TestCustomErrorHandler() {
+	var handledErr error
+	handler := func(w http.ResponseWriter, _ *http.Request, err error) {
+		handledErr = err
+		w.WriteHeader(http.StatusForbidden)
+	}
+
+	rec, _ := s.serve("", WithHTTPErrorHandler(handler))
+
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code)
+	assert.ErrorIs(s.T(), handledErr, ErrMissingAuthHeader)
+}
+
+func (s *MiddlewareTestSuite) TestExtractBearerToken() {
+	testCases := []struct {
+		name        string
+		header      string
+		expected    string
+		expectedErr error
+	}{
+		{name: "valid", header: "Bearer abc", expected: "abc"},
+		{name: "case insensitive scheme", header: "bearer abc", expected: "abc"},
+		{name: "empty", header: "", expectedErr: ErrMissingAuthHeader},
+		{name: "no token", header: "Bearer", expectedErr: ErrMalformedAuthHeader},
+		{name: "other scheme", header: "Token abc", expectedErr: ErrMalformedAuthHeader},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			token, err := ExtractBearerToken(tc.header)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(s.T(), err, tc.expectedErr)
+				return
+			}
+			assert.NoError(s.T(), err)
+			assert.Equal(s.T(), tc.expected, token)
+		})
+	}
+}
+
+func (s *MiddlewareTestSuite) TestUserIDFromContext() {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := UserIDFromContext(r.Context())
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), s.testUser.ID, userID)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+s.accessToken)
+
+	Middleware(s.validator)(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	_, ok := UserIDFromContext(req.Context())
+	assert.False(s.T(), ok)
+}
+
+func (s *MiddlewareTestSuite) TestPublicKeyValidator() {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(s.T(), err)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(s.T(), err)
+	publicDER, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	require.NoError(s.T(), err)
+
+	signingKey, err := jwt.ParsePrivateKey("ES256", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}))
+	require.NoError(s.T(), err)
+	accessToken, err := jwt.NewManagerWithKey(signingKey, time.Minute, time.Hour).
+		GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	s.validator, err = NewPublicKeyValidator("ES256", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), nil)
+	require.NoError(s.T(), err)
+
+	rec, parsedToken := s.serve("Bearer " + accessToken.Value)
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	require.NotNil(s.T(), parsedToken)
+	assert.Equal(s.T(), s.testUser.ID, parsedToken.UserID)
+
+	rec, _ = s.serve("Bearer " + s.accessToken)
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code, "HS256 tokens must be rejected by an ES256 validator")
+}
+
+func (s *MiddlewareTestSuite) newECKey() *jwt.SigningKey {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(s.T(), err)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(s.T(), err)
+
+	signingKey, err := jwt.ParsePrivateKey("ES256", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}))
+	require.NoError(s.T(), err)
+	return signingKey
+}
+
+func (s *MiddlewareTestSuite) TestJWKSValidatorFollowsRotation() {
+	oldKey := s.newECKey()
+	keys, err := jwt.NewKeySet(oldKey)
+	require.NoError(s.T(), err)
+	issuer := jwt.NewManagerWithKeySet(keys, time.Minute, time.Hour)
+
+	fetches := 0
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_ = json.NewEncoder(w).Encode(keys.JWKS())
+	}))
+	defer jwksServer.Close()
+
+	jwksValidator, err := NewJWKSValidator(jwksServer.URL, jwksServer.Client(), nil)
+	require.NoError(s.T(), err)
+	jwksValidator.refreshInterval = 0
+	s.validator = jwksValidator
+
+	oldToken, err := issuer.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+	rec, _ := s.serve("Bearer " + oldToken.Value)
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Equal(s.T(), 1, fetches)
+
+	require.NoError(s.T(), keys.Rotate(s.newECKey()))
+	newToken, err := issuer.GenerateToken(s.testUser, constants.TokenTypeAccess)
+	require.NoError(s.T(), err)
+
+	rec, parsedToken := s.serve("Bearer " + newToken.Value)
+	assert.Equal(s.T(), http.StatusOK, rec.Code, "unknown kid must trigger a JWKS refetch")
+	require.NotNil(s.T(), parsedToken)
+	assert.Equal(s.T(), s.testUser.ID, parsedToken.UserID)
+	assert.Equal(s.T(), 2, fetches)
+
+	rec, _ = s.serve("Bearer " + oldToken.Value)
+	assert.Equal(s.T(), http.StatusOK, rec.Code, "rotated-out key stays published until retired")
+
+	require.NoError(s.T(), keys.Retire(oldKey.ID))
+	jwksValidator.refreshInterval = time.Hour
+	require.NoError(s.T(), jwksValidator.Refresh())
+	rec, _ = s.serve("Bearer " + oldToken.Value)
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+	assert.Equal(s.T(), 3, fetches, "refetches are rate limited")
+}
+
+func TestMiddlewareTestSuite(t *testing.T) {
+	suite.Run(t, new(MiddlewareTestSuite))
+}
@@ -0,0 +1,114 @@
+package authmw
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/breakfront-planner/auth-service/internal/autherrors"
+	"github.com/breakfront-planner/auth-service/internal/jwt"
+	"github.com/breakfront-planner/auth-service/internal/validators"
+)
+
+const (
+	minJWKSRefreshInterval = time.Minute
+	maxJWKSSize            = 1 << 20
+)
+
+// JWKSValidator validates tokens against the key set published by the auth service
+// at /.well-known/jwks.json. The key set is refetched when a token carries an unknown
+// key ID, so consumers keep working across signing key rotations.
+type JWKSValidator struct {
+	jwksURL         string
+	client          *http.Client
+	refreshInterval time.Duration
+	keys            *jwt.KeySet
+	validator       *validators.TokenValidator
+
+	mu          sync.Mutex
+	lastRefresh time.Time
+}
+
+// NewJWKSValidator creates a JWKSValidator and fetches the initial key set.
+// A nil client falls back to http.DefaultClient. The userService is only used when
+// the user existence check is enabled and may be nil otherwise.
+func NewJWKSValidator(jwksURL string, client *http.Client, userService IUserService) (*JWKSValidator, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	keys, err := jwt.NewKeySet(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &JWKSValidator{
+		jwksURL:         jwksURL,
+		client:          client,
+		refreshInterval: minJWKSRefreshInterval,
+		keys:            keys,
+		validator:       validators.NewTokenValidator(jwt.NewManagerWithKeySet(keys, 0, 0, jwt.WithLeeway(jwt.DefaultLeeway)), userService, nil),
+	}
+
+	if err := v.Refresh(); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Validate validates the token, refreshing the key set once if the token's key ID is unknown.
+func (v *JWKSValidator) Validate(tokenValue string, opts ...ValidationOption) (*ParsedToken, error) {
+	parsedToken, err := v.validator.Validate(tokenValue, opts...)
+	if !errors.Is(err, autherrors.ErrUnknownKeyID) || !v.refreshAllowed() {
+		return parsedToken, err
+	}
+
+	if refreshErr := v.Refresh(); refreshErr != nil {
+		return nil, refreshErr
+	}
+
+	return v.validator.Validate(tokenValue, opts...)
+}
+
+// Refresh fetches the key set and replaces the known keys.
+func (v *JWKSValidator) Refresh() error {
+	v.mu.Lock()
+	v.lastRefresh = time.Now()
+	v.mu.Unlock()
+
+	resp, err := v.client.Get(v.jwksURL)
+	if err != nil {
+		return autherrors.ErrFetchJWKS(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return autherrors.ErrFetchJWKS(fmt.Errorf("unexpected status %d", resp.StatusCode))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return autherrors.ErrFetchJWKS(err)
+	}
+
+	keys, err := jwt.ParseJWKS(data)
+	if err != nil {
+		return autherrors.ErrFetchJWKS(err)
+	}
+
+	return v.keys.Replace(nil, keys...)
+}
+
+// refreshAllowed limits refetches so tokens with made-up key IDs can't flood the auth service.
+func (v *JWKSValidator) refreshAllowed() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return time.Since(v.lastRefresh) >= v.refreshInterval
+}
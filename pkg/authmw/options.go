@@ -0,0 +1,160 @@
+package authmw
+
+import (
+	"github.com/breakfront-planner/auth-service/internal/constants"
+	"github.com/breakfront-planner/auth-service/internal/validators"
+)
+
+// config holds the settings shared by the HTTP middleware and gRPC interceptors.
+type config struct {
+	checkUserExists  bool
+	clientTokens     bool
+	checkRevoked     bool
+	audience         string
+	noDelegation     bool
+	issuer           string
+	roles            []string
+	permissions      []string
+	httpErrorHandler HTTPErrorHandler
+	grpcErrorHandler GRPCErrorHandler
+	publicMethods    map[string]bool
+}
+
+// Option is a function that modifies the middleware configuration.
+type Option func(*config)
+
+// WithUserExistenceCheck rejects tokens whose user no longer exists
+// and tokens issued before the user signed out from all devices.
+// The Validator must have been created with a non-nil IUserService.
+func WithUserExistenceCheck() Option {
+	return func(c *config) {
+		c.checkUserExists = true
+	}
+}
+
+// WithClientTokens also accepts client tokens of services calling on their own behalf
+// (client credentials grant). Their ClientID is set instead of a user ID, and the
+// scopes granted to the client are checked by WithRequiredPermission.
+func WithClientTokens() Option {
+	return func(c *config) {
+		c.clientTokens = true
+	}
+}
+
+// WithRevocationCheck rejects access tokens that have been revoked individually.
+// The Validator must have been created with a denylist, which only the auth service itself has.
+func WithRevocationCheck() Option {
+	return func(c *config) {
+		c.checkRevoked = true
+	}
+}
+
+// WithAudience rejects access tokens that are not meant for the given audience,
+// normally the name of the service using the middleware.
+func WithAudience(audience string) Option {
+	return func(c *config) {
+		c.audience = audience
+	}
+}
+
+// WithoutDelegatedTokens rejects access tokens that another service obtained by token exchange
+// to act for the user, i.e. tokens with an actor.
+func WithoutDelegatedTokens() Option {
+	return func(c *config) {
+		c.noDelegation = true
+	}
+}
+
+// WithIssuer rejects access tokens that were not issued by the given auth service.
+func WithIssuer(issuer string) Option {
+	return func(c *config) {
+		c.issuer = issuer
+	}
+}
+
+// WithRequiredRole rejects access tokens that don't grant the given role.
+// It can be passed several times to require several roles.
+func WithRequiredRole(role string) Option {
+	return func(c *config) {
+		c.roles = append(c.roles, role)
+	}
+}
+
+// WithRequiredPermission rejects access tokens that don't grant the given permission.
+// It can be passed several times to require several permissions.
+func WithRequiredPermission(permission string) Option {
+	return func(c *config) {
+		c.permissions = append(c.permissions, permission)
+	}
+}
+
+// WithHTTPErrorHandler replaces the default HTTP failure response.
+func WithHTTPErrorHandler(handler HTTPErrorHandler) Option {
+	return func(c *config) {
+		c.httpErrorHandler = handler
+	}
+}
+
+// WithGRPCErrorHandler replaces the default gRPC failure status.
+func WithGRPCErrorHandler(handler GRPCErrorHandler) Option {
+	return func(c *config) {
+		c.grpcErrorHandler = handler
+	}
+}
+
+// WithPublicMethods lists full gRPC method names that are served without a token.
+func WithPublicMethods(fullMethods ...string) Option {
+	return func(c *config) {
+		for _, method := range fullMethods {
+			c.publicMethods[method] = true
+		}
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		httpErrorHandler: DefaultHTTPErrorHandler,
+		grpcErrorHandler: DefaultGRPCErrorHandler,
+		publicMethods:    make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// verify validates an Authorization header value as an access token.
+func (c *config) verify(validator Validator, authHeader string) (*ParsedToken, error) {
+	tokenValue, err := ExtractBearerToken(authHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	validationOpts := []ValidationOption{validators.WithTokenType(constants.TokenTypeAccess)}
+	if c.audience != "" {
+		validationOpts = append(validationOpts, validators.WithAudience(c.audience))
+	}
+	if c.noDelegation {
+		validationOpts = append(validationOpts, validators.WithoutDelegatedTokens())
+	}
+	if c.issuer != "" {
+		validationOpts = append(validationOpts, validators.WithIssuer(c.issuer))
+	}
+	for _, role := range c.roles {
+		validationOpts = append(validationOpts, validators.WithRequiredRole(role))
+	}
+	for _, permission := range c.permissions {
+		validationOpts = append(validationOpts, validators.WithRequiredPermission(permission))
+	}
+	if c.checkRevoked {
+		validationOpts = append(validationOpts, validators.WithRevocationCheck())
+	}
+	if c.checkUserExists {
+		validationOpts = append(validationOpts, validators.WithUserExistenceCheck())
+	}
+	if c.clientTokens {
+		validationOpts = append(validationOpts, validators.WithClientTokens())
+	}
+
+	return validator.Validate(tokenValue, validationOpts...)
+}